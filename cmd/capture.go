@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/lf-edge/eden/pkg/controller/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newCaptureCmd() *cobra.Command {
+	var captureCmd = &cobra.Command{
+		Use:   "capture",
+		Short: "Record objects of device from Adam into archive",
+		Long: `Record config, info, metrics, logs, app logs, flow logs and requests of device
+from Adam into archive which can be replayed later without EVE and Adam running with
+'eden log/info/metric --from-archive <file>'.`,
+	}
+
+	captureCmd.AddCommand(newCaptureRecordCmd())
+	return captureCmd
+}
+
+func newCaptureRecordCmd() *cobra.Command {
+	var since, until string
+
+	var recordCmd = &cobra.Command{
+		Use:   "record <file>",
+		Short: "Record objects of the current device into tar.gz archive",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			timeRange, err := types.ParseTimeRange(since, until)
+			if err != nil {
+				log.Fatalf("Capture record failed: %s", err)
+			}
+			if err := openEVEC.CaptureRecord(args[0], timeRange); err != nil {
+				log.Fatalf("Capture record failed: %s", err)
+			}
+		},
+	}
+
+	addTimeRangeFlags(recordCmd, &since, &until)
+	return recordCmd
+}
+
+// addFromArchiveFlag adds flag to read objects from capture archive instead of Adam
+func addFromArchiveFlag(cmd *cobra.Command, fromArchive *string) {
+	cmd.Flags().StringVar(fromArchive, "from-archive", "", "Read objects from archive recorded with 'eden capture record' instead of Adam")
+}
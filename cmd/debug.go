@@ -4,10 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
-	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -25,6 +26,8 @@ func newDebugCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 				newDebugStopEveCmd(cfg),
 				newDebugSaveEveCmd(cfg),
 				newDebugHardwareEveCmd(cfg),
+				newDebugCollectEveCmd(cfg),
+				newDebugFlamegraphCmd(),
 			},
 		},
 	}
@@ -102,36 +105,35 @@ func newDebugStopEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 }
 
 func newDebugSaveEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
-	var eveSSHKey, eveHost, perfLocation string
+	var eveSSHKey, eveHost, perfLocation, format, baseline string
 	var eveSSHPort int
 
 	var debugSaveEveCmd = &cobra.Command{
 		Use:   "save <file>",
-		Short: "save file with perf script output from EVE, create svg and save to provided file",
-		Args:  cobra.ExactArgs(1),
+		Short: "save file with perf script output from EVE, create flame graph and save to provided file",
+		Long: `Save perf script output from EVE next to the provided file (with ".perf" suffix)
+and render flame graph from it. Output format (svg, html or folded stacks) is detected
+from the file extension unless --format is set. Use --diff with perf script output
+(or folded stacks) of another capture to render differential flame graph.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			absPath, err := filepath.Abs(args[0])
 			if err != nil {
 				log.Fatal(err)
 			}
-			tmpFile := fmt.Sprintf("%s.tmp", absPath)
+			perfFile := fmt.Sprintf("%s.perf", absPath)
 			if _, err := os.Stat(eveSSHKey); !os.IsNotExist(err) {
 				commandToRun := fmt.Sprintf("perf script -i %s > %s", perfLocation, defaults.DefaultPerfScriptEVELocation)
 				if err = openEVEC.SdnForwardSSHToEve(commandToRun); err != nil {
 					log.Fatal(err)
 				}
-				err = openEVEC.SdnForwardSCPFromEve(defaults.DefaultPerfScriptEVELocation, tmpFile)
+				err = openEVEC.SdnForwardSCPFromEve(defaults.DefaultPerfScriptEVELocation, perfFile)
 				if err != nil {
 					log.Fatal(err)
 				}
-				image := fmt.Sprintf("%s:%s", defaults.DefaultProcContainerRef, defaults.DefaultProcTag)
-				commandToRun = fmt.Sprintf("-i /in/%s -o /out/%s svg", filepath.Base(tmpFile), filepath.Base(absPath))
-				volumeMap := map[string]string{"/in": filepath.Dir(tmpFile), "/out": filepath.Dir(absPath)}
-				var result string
-				if result, err = utils.RunDockerCommand(image, commandToRun, volumeMap); err != nil {
+				if err = openevec.DebugFlamegraph(perfFile, absPath, baseline, format, ""); err != nil {
 					log.Fatal(err)
 				}
-				fmt.Println(result)
 				log.Infof("Please see output inside %s", absPath)
 			} else {
 				log.Fatalf("SSH key problem: %s", err)
@@ -148,12 +150,36 @@ func newDebugSaveEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	debugSaveEveCmd.Flags().StringVarP(&eveHost, "eve-host", "", defaults.DefaultEVEHost, "IP of eve")
 	debugSaveEveCmd.Flags().IntVarP(&eveSSHPort, "eve-ssh-port", "", defaults.DefaultSSHPort, "Port for ssh access")
 	debugSaveEveCmd.Flags().StringVar(&perfLocation, "perf-location", defaults.DefaultPerfEVELocation, "Perf output location on EVE")
+	debugSaveEveCmd.Flags().StringVar(&format, "format", "", "Output format (svg, html or folded), detected from the file extension if not set")
+	debugSaveEveCmd.Flags().StringVar(&baseline, "diff", "", "Perf script output or folded stacks of baseline capture to render differential flame graph")
 
 	addSdnPortOpts(debugSaveEveCmd, cfg)
 
 	return debugSaveEveCmd
 }
 
+func newDebugFlamegraphCmd() *cobra.Command {
+	var format, baseline, title string
+
+	var debugFlamegraphCmd = &cobra.Command{
+		Use:   "flamegraph <perf-script> <file>",
+		Short: "create flame graph from local perf script output (or folded stacks) and save to provided file",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.DebugFlamegraph(args[0], args[1], baseline, format, title); err != nil {
+				log.Fatal(err)
+			}
+			log.Infof("Please see output inside %s", args[1])
+		},
+	}
+
+	debugFlamegraphCmd.Flags().StringVar(&format, "format", "", "Output format (svg, html or folded), detected from the file extension if not set")
+	debugFlamegraphCmd.Flags().StringVar(&baseline, "diff", "", "Perf script output or folded stacks of baseline capture to render differential flame graph")
+	debugFlamegraphCmd.Flags().StringVar(&title, "title", "", "Title of the flame graph")
+
+	return debugFlamegraphCmd
+}
+
 func newDebugHardwareEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var eveSSHKey, eveHost, hwLocation string
 	var eveSSHPort int
@@ -199,3 +225,44 @@ func newDebugHardwareEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 
 	return debugHardwareEveCmd
 }
+
+func newDebugCollectEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var collectOptions string
+	var keepOnEve bool
+
+	var debugCollectEveCmd = &cobra.Command{
+		Use:   "collect <dir>",
+		Short: "run collect-info on EVE, download the bundle into provided directory, unpack and index it",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			absPath, err := filepath.Abs(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			index, err := openEVEC.DebugCollect(absPath, collectOptions, keepOnEve)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("Bundle: %s\n", index.Tarball)
+			fmt.Printf("Unpacked into: %s\n", index.Dir)
+			fmt.Printf("PubSub dumps: %d\n", len(index.PubSub))
+			fmt.Printf("Log files: %d\n", len(index.Logs))
+			fmt.Printf("Other files: %d\n", len(index.Other))
+			var commands []string
+			for command := range index.Commands {
+				commands = append(commands, command)
+			}
+			sort.Strings(commands)
+			for _, command := range commands {
+				fmt.Printf("Output of %s: %s\n", command, strings.Join(index.Commands[command], ", "))
+			}
+		},
+	}
+
+	debugCollectEveCmd.Flags().StringVar(&collectOptions, "collect-options", "", "Options for collect-info script")
+	debugCollectEveCmd.Flags().BoolVar(&keepOnEve, "keep-on-eve", false, "Do not remove the bundle from EVE after download")
+
+	addSdnPortOpts(debugCollectEveCmd, cfg)
+
+	return debugCollectEveCmd
+}
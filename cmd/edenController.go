@@ -1,9 +1,17 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
 )
 
 func newControllerCmd(configName, verbosity *string) *cobra.Command {
@@ -32,6 +40,7 @@ func newControllerCmd(configName, verbosity *string) *cobra.Command {
 				newEdgeNodeEVEImageUpdate(controllerMode),
 				newEdgeNodeEVEImageRemove(controllerMode),
 				newEdgeNodeEVEImageUpdateRetry(controllerMode),
+				newEdgeNodeUpgradePlan(controllerMode),
 				newEdgeNodeUpdate(controllerMode),
 				newEdgeNodeGetConfig(controllerMode),
 				newEdgeNodeSetConfig(),
@@ -47,8 +56,10 @@ func newControllerCmd(configName, verbosity *string) *cobra.Command {
 
 	controllerCmd.AddCommand(newControllerGetOptions())
 	controllerCmd.AddCommand(newControllerSetOptions())
+	controllerCmd.AddCommand(newControllerCryptoCmd())
 
 	controllerCmd.PersistentFlags().StringVarP(&controllerMode, "mode", "m", "", "mode to use [file|proto|adam|zedcloud]://<URL> (default is adam)")
+	addEveInstanceFlag(controllerCmd)
 
 	return controllerCmd
 }
@@ -121,6 +132,55 @@ func newEdgeNodeEVEImageUpdate(controllerMode string) *cobra.Command {
 	return edgeNodeEVEImageUpdate
 }
 
+func newEdgeNodeUpgradePlan(controllerMode string) *cobra.Command {
+	var opts openevec.UpgradePlanOptions
+	var reportFile string
+
+	var edgeNodeUpgradePlan = &cobra.Command{
+		Use:   "upgrade-plan <image file or url (oci:// or file:// or http(s)://)>",
+		Short: "run A->B->A upgrade cycle of EVE image and check partition states",
+		Long: `Update EVE from the running image A to the provided image B and back, following
+partition states and versions reported by EVE in every step. With --rollback, testing
+of B is interrupted to make EVE fall back to A. Otherwise, B has to pass testing and
+EVE is updated back to A using --back-image. Report is written in JSON.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Image = args[0]
+			report, err := openEVEC.EdgeNodeUpgradePlan(controllerMode, opts)
+			data, marshalErr := json.MarshalIndent(report, "", "  ")
+			if marshalErr != nil {
+				log.Fatal(marshalErr)
+			}
+			if reportFile == "" {
+				fmt.Println(string(data))
+			} else {
+				if writeErr := os.WriteFile(reportFile, data, 0644); writeErr != nil {
+					log.Fatal(writeErr)
+				}
+				log.Infof("Report saved into %s", reportFile)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	edgeNodeUpgradePlan.Flags().StringVarP(&opts.Version, "os-version", "", "", "version of ROOTFS")
+	edgeNodeUpgradePlan.Flags().StringVar(&opts.Registry, "registry", "remote", "Select registry to use for containers (remote/local)")
+	edgeNodeUpgradePlan.Flags().BoolVar(&opts.Rollback, "rollback", false, "fail testing of the new image to force roll back")
+	edgeNodeUpgradePlan.Flags().StringVar(&opts.FailMethod, "fail-method", openevec.UpgradeFailPowerCycle,
+		fmt.Sprintf("how to fail testing with --rollback (%s|%s)", openevec.UpgradeFailPowerCycle, openevec.UpgradeFailReboot))
+	edgeNodeUpgradePlan.Flags().StringVar(&opts.BackImage, "back-image", "", "image of the running EVE version to return to (required without --rollback)")
+	edgeNodeUpgradePlan.Flags().StringVar(&opts.BackVersion, "back-os-version", "", "version of ROOTFS of the image to return to")
+	edgeNodeUpgradePlan.Flags().DurationVar(&opts.TestingTime, "testing-time", 0, "set timer.test.baseimage.update to the duration during the plan (0 to keep the current value)")
+	edgeNodeUpgradePlan.Flags().DurationVar(&opts.StepTimeout, "timeout", 30*time.Minute, "timeout for EVE to reach the expected state in each step")
+	edgeNodeUpgradePlan.Flags().StringVar(&reportFile, "report", "", "file to save report into (stdout by default)")
+	edgeNodeUpgradePlan.Flags().StringVar(&opts.VMName, "vmname", defaults.DefaultVBoxVMName, "vbox vmname of EVE to power-cycle")
+	edgeNodeUpgradePlan.Flags().StringVar(&opts.TapInterface, "with-tap", "", "tap interface used in QEMU as the third when EVE is power-cycled")
+
+	return edgeNodeUpgradePlan
+}
+
 func newEdgeNodeEVEImageRemove(controllerMode string) *cobra.Command {
 	var baseOSVersion string
 
@@ -279,3 +339,107 @@ func newEdgeNodeSetConfig() *cobra.Command {
 
 	return edgeNodeSetConfig
 }
+
+func newControllerCryptoCmd() *cobra.Command {
+	var cryptoCmd = &cobra.Command{
+		Use:   "crypto",
+		Short: "inspect and re-key encrypted device config",
+		Long: `Inspect cipher contexts and encrypted data (datastore credentials, cloud-init user data,
+Wi-Fi and cellular secrets) of the device config and re-key it.`,
+	}
+
+	cryptoCmd.AddCommand(newControllerCryptoListCmd())
+	cryptoCmd.AddCommand(newControllerCryptoDecryptCmd())
+	cryptoCmd.AddCommand(newControllerCryptoRotateCmd())
+	cryptoCmd.AddCommand(newControllerCryptoReencryptCmd())
+
+	return cryptoCmd
+}
+
+func newControllerCryptoListCmd() *cobra.Command {
+	var outputFormat types.OutputFormat
+
+	var cryptoListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "list cipher contexts of the device",
+		Long:  `List cipher contexts of the device together with config objects using them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.CryptoList(outputFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cryptoListCmd.Flags().Var(enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive), "format", "Format to print contexts, supports: lines, json")
+
+	return cryptoListCmd
+}
+
+func newControllerCryptoDecryptCmd() *cobra.Command {
+	var outputFormat types.OutputFormat
+	var keyFile string
+
+	var cryptoDecryptCmd = &cobra.Command{
+		Use:   "decrypt",
+		Short: "show encrypted config data in plaintext",
+		Long:  `Decrypt and show encrypted data of the device config for debugging.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.CryptoShowDecrypted(keyFile, outputFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cryptoDecryptCmd.Flags().StringVar(&keyFile, "key", "", "controller private key to decrypt with (current signing key if not set)")
+	cryptoDecryptCmd.Flags().Var(enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive), "format", "Format to print data, supports: lines, json")
+
+	return cryptoDecryptCmd
+}
+
+func newControllerCryptoRotateCmd() *cobra.Command {
+	var certFile, keyFile string
+	var newKey bool
+
+	var cryptoRotateCmd = &cobra.Command{
+		Use:   "rotate",
+		Short: "rotate controller signing certificate and key",
+		Long: `Change controller signing certificate (and optionally key) and re-encrypt config data for it.
+If certificate is not provided, a new one is generated and signed by the root CA.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if certFile == "" && keyFile != "" {
+				log.Fatal("--key-file requires --cert-file")
+			}
+			if certFile != "" && newKey {
+				log.Fatal("--new-key cannot be used with --cert-file")
+			}
+			if err := openEVEC.CryptoRotate(certFile, keyFile, newKey); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cryptoRotateCmd.Flags().StringVar(&certFile, "cert-file", "", "path to the new signing certificate")
+	cryptoRotateCmd.Flags().StringVar(&keyFile, "key-file", "", "path to the private key of the new signing certificate (current key if not set)")
+	cryptoRotateCmd.Flags().BoolVar(&newKey, "new-key", false, "generate a new key together with the certificate")
+
+	return cryptoRotateCmd
+}
+
+func newControllerCryptoReencryptCmd() *cobra.Command {
+	var prune bool
+
+	var cryptoReencryptCmd = &cobra.Command{
+		Use:   "reencrypt",
+		Short: "re-encrypt config data with the current cipher context",
+		Long:  `Re-encrypt all encrypted data of the device config using the cipher context of the current certificates.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.CryptoReencrypt(prune); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cryptoReencryptCmd.Flags().BoolVar(&prune, "prune", false, "remove cipher contexts not used anymore")
+
+	return cryptoReencryptCmd
+}
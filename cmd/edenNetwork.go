@@ -31,12 +31,14 @@ func newNetworkCmd() *cobra.Command {
 
 func newNetworkLsCmd() *cobra.Command {
 	var outputFormat types.OutputFormat
+	var watch bool
+	var history string
 	//networkLsCmd is a command to list deployed network instances
 	var networkLsCmd = &cobra.Command{
 		Use:   "ls",
 		Short: "List networks",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.NetworkLs(outputFormat); err != nil {
+			if err := openEVEC.NetworkLs(outputFormat, watch, history); err != nil {
 				log.Fatal(err)
 			}
 		},
@@ -45,6 +47,10 @@ func newNetworkLsCmd() *cobra.Command {
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+	networkLsCmd.Flags().BoolVarP(&watch, "watch", "w", false,
+		"redraw the list whenever EVE reports a change")
+	networkLsCmd.Flags().StringVar(&history, "history", "",
+		"print state transitions of the network with the given name (or UUID) instead of the list")
 	return networkLsCmd
 }
 
@@ -42,6 +42,7 @@ func newPodCmd(configName, verbosity *string) *cobra.Command {
 	}
 
 	groups.AddTo(podCmd)
+	addEveInstanceFlag(podCmd)
 
 	return podCmd
 }
@@ -128,11 +129,13 @@ You can set access VLAN ID (VID) for a particular network in the format '<networ
 
 func newPodPsCmd() *cobra.Command {
 	var outputFormat types.OutputFormat
+	var watch bool
+	var history string
 	var podPsCmd = &cobra.Command{
 		Use:   "ps",
 		Short: "List pods",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.PodPs(outputFormat); err != nil {
+			if err := openEVEC.PodPs(outputFormat, watch, history); err != nil {
 				log.Fatalf("EVE pod deploy failed: %s", err)
 			}
 		},
@@ -141,6 +144,10 @@ func newPodPsCmd() *cobra.Command {
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+	podPsCmd.Flags().BoolVarP(&watch, "watch", "w", false,
+		"redraw the list whenever EVE reports a change")
+	podPsCmd.Flags().StringVar(&history, "history", "",
+		"print state transitions of the app with the given name (or UUID) instead of the list")
 
 	return podPsCmd
 }
@@ -239,6 +246,7 @@ func newPodLogsCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		outputTail   uint
 		outputFields []string
 		outputFormat types.OutputFormat
+		since, until string
 	)
 
 	var podLogsCmd = &cobra.Command{
@@ -247,7 +255,11 @@ func newPodLogsCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			appName := args[0]
-			if err := openEVEC.PodLogs(appName, outputTail, outputFields, outputFormat); err != nil {
+			timeRange, err := types.ParseTimeRange(since, until)
+			if err != nil {
+				log.Fatalf("EVE pod logs failed: %s", err)
+			}
+			if err := openEVEC.PodLogs(appName, outputTail, outputFields, outputFormat, timeRange); err != nil {
 				log.Fatalf("EVE pod start failed: %s", err)
 			}
 		},
@@ -255,6 +267,7 @@ func newPodLogsCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 
 	podLogsCmd.Flags().UintVar(&outputTail, "tail", 0, "Show only last N lines")
 	podLogsCmd.Flags().StringSliceVar(&outputFields, "fields", []string{"log", "info", "metric", "netstat", "app"}, "Show defined elements")
+	addTimeRangeFlags(podLogsCmd, &since, &until)
 	podLogsCmd.Flags().Var(
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
@@ -82,8 +82,10 @@ func newSetupCmd(configName, verbosity *string) *cobra.Command {
 	addSdnConfigDirOpt(setupCmd, cfg)
 	addSdnImageOpt(setupCmd, cfg)
 	addSdnDisableOpt(setupCmd, cfg)
+	addSdnRunnerOpt(setupCmd, cfg)
 	addSdnSourceDirOpt(setupCmd, cfg)
 	addSdnLinuxkitOpt(setupCmd, cfg)
+	addEveInstanceFlag(setupCmd)
 
 	return setupCmd
 }
@@ -36,12 +36,14 @@ func newVolumeCmd(configName, verbosity *string) *cobra.Command {
 
 func newVolumeLsCmd() *cobra.Command {
 	var outputFormat types.OutputFormat
+	var watch bool
+	var history string
 	//volumeLsCmd is a command to list deployed volumes
 	var volumeLsCmd = &cobra.Command{
 		Use:   "ls",
 		Short: "List volumes",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.VolumeLs(outputFormat); err != nil {
+			if err := openEVEC.VolumeLs(outputFormat, watch, history); err != nil {
 				log.Fatal(err)
 			}
 		},
@@ -50,6 +52,10 @@ func newVolumeLsCmd() *cobra.Command {
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+	volumeLsCmd.Flags().BoolVarP(&watch, "watch", "w", false,
+		"redraw the list whenever EVE reports a change")
+	volumeLsCmd.Flags().StringVar(&history, "history", "",
+		"print state transitions of the volume with the given name (or UUID) instead of the list")
 	return volumeLsCmd
 }
 
@@ -39,6 +39,8 @@ func newEveCmd(configName, verbosity *string) *cobra.Command {
 	}
 
 	groups.AddTo(eveCmd)
+	eveCmd.AddCommand(newSimulateEveCmd())
+	addEveInstanceFlag(eveCmd)
 
 	return eveCmd
 }
@@ -91,6 +93,22 @@ func newStartEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	return startEveCmd
 }
 
+func newSimulateEveCmd() *cobra.Command {
+	var simulateEveCmd = &cobra.Command{
+		Use:    "simulate",
+		Short:  "run simulator of eve in foreground",
+		Long:   `Run simulator of eve in foreground. It is started by 'eden eve start' for devmodel sim.`,
+		Hidden: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.RunEveSim(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return simulateEveCmd
+}
+
 func newStopEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var vmName string
 
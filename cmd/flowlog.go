@@ -14,15 +14,24 @@ func newNetStatCmd(configName, verbosity *string) *cobra.Command {
 	var follow bool
 	var printFields []string
 	var logTail uint
+	var since, until string
 
 	var netStatCmd = &cobra.Command{
-		Use:   "netstat [field:regexp ...]",
+		Use:   "netstat [query ...]",
 		Short: "Get logs of network packets from a running EVE device",
 		Long: `Scans the ADAM flow messages for correspondence with regular expressions to show network flow statistics
-(TCP and UDP flows with IP addresses, port numbers, counters, whether dropped or accepted)`,
+(TCP and UDP flows with IP addresses, port numbers, counters, whether dropped or accepted)
+
+Query is a list of 'field:regexp' pairs or an expression with 'and', 'or', 'not',
+parentheses and the operators =, !=, ~ (regexp), !~, <, <=, >, >= and 'in (...)', e.g.
+  eden netstat 'flows[].flow.destPort in (53, 443)'`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenNetStat(outputFormat, follow, logTail, printFields, args); err != nil {
+			timeRange, err := types.ParseTimeRange(since, until)
+			if err != nil {
+				log.Fatalf("Setup eden failed: %s", err)
+			}
+			if err := openEVEC.EdenNetStat(outputFormat, follow, logTail, printFields, args, timeRange); err != nil {
 				log.Fatalf("Setup eden failed: %s", err)
 			}
 		},
@@ -31,6 +40,7 @@ func newNetStatCmd(configName, verbosity *string) *cobra.Command {
 	netStatCmd.Flags().UintVar(&logTail, "tail", 0, "Show only last N lines")
 	netStatCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
 	netStatCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected directory")
+	addTimeRangeFlags(netStatCmd, &since, &until)
 	netStatCmd.Flags().Var(enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive), "format", "Format to print logs, supports: lines, json")
 
 	return netStatCmd
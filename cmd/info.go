@@ -12,13 +12,23 @@ func newInfoCmd() *cobra.Command {
 	var infoTail uint
 	var follow bool
 	var printFields []string
+	var since, until string
+	var fromArchive string
 
 	var infoCmd = &cobra.Command{
-		Use:   "info [field:regexp ...]",
+		Use:   "info [query ...]",
 		Short: "Get information reports from a running EVE device",
-		Long:  ` Scans the ADAM Info for correspondence with regular expressions requests to json fields.`,
+		Long: ` Scans the ADAM Info for correspondence with query to json fields.
+
+Query is a list of 'field:regexp' pairs or an expression with 'and', 'or', 'not',
+parentheses and the operators =, !=, ~ (regexp), !~, <, <=, >, >= and 'in (...)', e.g.
+  eden info 'ztype in (ZiApp, ZiVolume)'`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenInfo(outputFormat, infoTail, follow, printFields, args); err != nil {
+			timeRange, err := types.ParseTimeRange(since, until)
+			if err != nil {
+				log.Fatal("Eden info failed ", err)
+			}
+			if err := openEVEC.EdenInfo(outputFormat, infoTail, follow, printFields, args, timeRange, fromArchive); err != nil {
 				log.Fatal("Eden info failed ", err)
 			}
 		},
@@ -27,6 +37,8 @@ func newInfoCmd() *cobra.Command {
 	infoCmd.Flags().UintVar(&infoTail, "tail", 0, "Show only last N lines")
 	infoCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected directory")
 	infoCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
+	addTimeRangeFlags(infoCmd, &since, &until)
+	addFromArchiveFlag(infoCmd, &fromArchive)
 
 	infoCmd.Flags().Var(
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
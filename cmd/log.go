@@ -17,13 +17,23 @@ func newLogCmd() *cobra.Command {
 	var follow bool
 	var printFields []string
 	var logTail uint
+	var since, until string
+	var fromArchive string
 
 	var logCmd = &cobra.Command{
-		Use:   "log [field:regexp ...]",
+		Use:   "log [query ...]",
 		Short: "Get logs from a running EVE device",
-		Long:  ` Scans the ADAM logs for correspondence with regular expressions requests to json fields.`,
+		Long: ` Scans the ADAM logs for correspondence with query to json fields.
+
+Query is a list of 'field:regexp' pairs or an expression with 'and', 'or', 'not',
+parentheses and the operators =, !=, ~ (regexp), !~, <, <=, >, >= and 'in (...)', e.g.
+  eden log 'severity >= warning and not source = newlogd and timestamp > now-10m'`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenLog(outputFormat, follow, logTail, printFields, args); err != nil {
+			timeRange, err := types.ParseTimeRange(since, until)
+			if err != nil {
+				log.Fatalf("Log eden failed: %s", err)
+			}
+			if err := openEVEC.EdenLog(outputFormat, follow, logTail, printFields, args, timeRange, fromArchive); err != nil {
 				log.Fatalf("Log eden failed: %s", err)
 			}
 		},
@@ -32,6 +42,8 @@ func newLogCmd() *cobra.Command {
 	logCmd.Flags().UintVar(&logTail, "tail", 0, "Show only last N lines")
 	logCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
 	logCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected directory")
+	addTimeRangeFlags(logCmd, &since, &until)
+	addFromArchiveFlag(logCmd, &fromArchive)
 
 	logCmd.Flags().Var(
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
@@ -39,3 +51,9 @@ func newLogCmd() *cobra.Command {
 		"Format to print logs, supports: lines, json")
 	return logCmd
 }
+
+// addTimeRangeFlags adds flags to limit processed objects by the time of receiving
+func addTimeRangeFlags(cmd *cobra.Command, since, until *string) {
+	cmd.Flags().StringVar(since, "since", "", "Show only objects received after the time (RFC3339 timestamp or duration ago, e.g. 10m)")
+	cmd.Flags().StringVar(until, "until", "", "Show only objects received before the time (RFC3339 timestamp or duration ago, e.g. 10m)")
+}
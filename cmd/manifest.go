@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newApplyCmd() *cobra.Command {
+	var manifestFile string
+
+	var applyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Reconcile edge-node config with manifest",
+		Long: `Reconcile edge-node config with YAML manifest describing apps, network instances,
+volumes, config items, base OS and disk layout. Only objects which differ are changed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.Apply(manifestFile); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	applyCmd.Flags().StringVarP(&manifestFile, "file", "f", "", "manifest file to apply")
+	_ = applyCmd.MarkFlagRequired("file")
+
+	return applyCmd
+}
+
+func newExportManifestCmd() *cobra.Command {
+	var manifestFile string
+	var redact bool
+
+	var exportManifestCmd = &cobra.Command{
+		Use:   "export-manifest",
+		Short: "Export manifest from edge-node config",
+		Long:  `Export manifest describing current config of edge-node to use with apply command.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ExportManifest(manifestFile, redact); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	exportManifestCmd.Flags().StringVarP(&manifestFile, "file", "f", "", "file to save manifest, stdout if empty")
+	exportManifestCmd.Flags().BoolVar(&redact, "redact", false, "do not export metadata and VNC passwords of apps")
+
+	return exportManifestCmd
+}
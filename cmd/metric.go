@@ -14,15 +14,25 @@ func newMetricCmd(configName, verbosity *string) *cobra.Command {
 	var follow bool
 	var printFields []string
 	var metricTail uint
+	var since, until string
+	var fromArchive string
 
 	var metricCmd = &cobra.Command{
-		Use:   "metric [field:regexp ...]",
+		Use:   "metric [query ...]",
 		Short: "Get metrics from a running EVE device",
 		Long: `
-Scans the ADAM metrics for correspondence with regular expressions requests to json fields.`,
+Scans the ADAM metrics for correspondence with query to json fields.
+
+Query is a list of 'field:regexp' pairs or an expression with 'and', 'or', 'not',
+parentheses and the operators =, !=, ~ (regexp), !~, <, <=, >, >= and 'in (...)', e.g.
+  eden metric 'dm.memory.usedPercentage > 80'`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenMetric(outputFormat, follow, metricTail, printFields, args); err != nil {
+			timeRange, err := types.ParseTimeRange(since, until)
+			if err != nil {
+				log.Fatalf("Metric eden failed: %s", err)
+			}
+			if err := openEVEC.EdenMetric(outputFormat, follow, metricTail, printFields, args, timeRange, fromArchive); err != nil {
 				log.Fatalf("Metric eden failed: %s", err)
 			}
 		},
@@ -31,10 +41,33 @@ Scans the ADAM metrics for correspondence with regular expressions requests to j
 	metricCmd.Flags().UintVar(&metricTail, "tail", 0, "Show only last N lines")
 	metricCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
 	metricCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected metrics")
+	addTimeRangeFlags(metricCmd, &since, &until)
+	addFromArchiveFlag(metricCmd, &fromArchive)
 
 	metricCmd.Flags().Var(
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+	metricCmd.AddCommand(newMetricExportCmd())
 	return metricCmd
 }
+
+func newMetricExportCmd() *cobra.Command {
+	var listen string
+
+	var metricExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export metrics of EVE device in Prometheus format",
+		Long: `Follows metrics received from EVE device and exposes device, app, volume
+and network instance metrics in Prometheus/OpenMetrics format on /metrics endpoint.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdenMetricExport(listen); err != nil {
+				log.Fatalf("Metric export failed: %s", err)
+			}
+		},
+	}
+
+	metricExportCmd.Flags().StringVar(&listen, "listen", ":9100", "Address to listen on for Prometheus requests")
+	return metricExportCmd
+}
@@ -45,6 +45,7 @@ func NewEdenCommand() *cobra.Command {
 				newLogCmd(),
 				newNetStatCmd(&configName, &verbosity),
 				newMetricCmd(&configName, &verbosity),
+				newCaptureCmd(),
 				newAdamCmd(&configName, &verbosity),
 				newRegistryCmd(&configName, &verbosity),
 				newRedisCmd(&configName, &verbosity),
@@ -55,6 +56,8 @@ func NewEdenCommand() *cobra.Command {
 				newNetworkCmd(),
 				newVolumeCmd(&configName, &verbosity),
 				newDisksCmd(),
+				newApplyCmd(),
+				newExportManifestCmd(),
 				newPacketCmd(&configName, &verbosity),
 				newRolCmd(&configName, &verbosity),
 			},
@@ -65,6 +68,7 @@ func NewEdenCommand() *cobra.Command {
 
 	rootCmd.PersistentFlags().StringVar(&configName, "config", defaults.DefaultContext, "Name of config")
 	rootCmd.PersistentFlags().StringVarP(&verbosity, "verbosity", "v", log.InfoLevel.String(), "Log level (debug, info, warn, error, fatal, panic")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Print changes of device config instead of sending it to controller")
 
 	return rootCmd
 }
@@ -77,11 +81,24 @@ func preRunViperLoadFunction(cfg *openevec.EdenSetupArgs, configName, verbosity
 		}
 		openevec.Merge(reflect.ValueOf(viperCfg).Elem(), reflect.ValueOf(*cfg), cmd.Flags())
 		*cfg = *viperCfg
+		if dryRun, err := cmd.Flags().GetBool("dry-run"); err == nil {
+			cfg.DryRun = dryRun
+		}
+		if instance, err := cmd.Flags().GetString("eve-instance"); err == nil && instance != "" {
+			if err := cfg.SelectEveInstance(instance); err != nil {
+				return err
+			}
+		}
 		openEVEC = openevec.CreateOpenEVEC(cfg)
 		return nil
 	}
 }
 
+// addEveInstanceFlag adds --eve-instance flag to select one of additional EVE instances
+func addEveInstanceFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("eve-instance", "", "Name of EVE instance from eve.instances of config (default instance if empty)")
+}
+
 // Execute primary function for cobra
 func Execute() {
 	rootCmd := NewEdenCommand()
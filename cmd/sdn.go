@@ -5,8 +5,10 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/edensdn"
 	"github.com/lf-edge/eden/pkg/openevec"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -36,6 +38,9 @@ func newSdnCmd(configName, verbosity *string) *cobra.Command {
 				newSdnMgmtIPCmd(cfg),
 				newSdnEndpointCmd(cfg),
 				newSdnFwdCmd(cfg),
+				newSdnCaptureCmd(cfg),
+				newSdnCaptivePortalCmd(cfg),
+				newSdnConntrackCmd(cfg),
 			},
 		},
 	}
@@ -57,6 +62,7 @@ func newSdnNetModelCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 			Commands: []*cobra.Command{
 				newSdnNetModelApplyCmd(cfg),
 				newSdnModelGetCmd(cfg),
+				newSdnNetModelValidateCmd(cfg),
 			},
 		},
 	}
@@ -103,6 +109,24 @@ Use string \"default\" instead of a file path to apply the default network model
 	return sdnNetModelApplyCmd
 }
 
+func newSdnNetModelValidateCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnNetModelValidateCmd = &cobra.Command{
+		Use:   "validate <filepath.json>",
+		Short: "validate network model without submitting it into Eden-SDN",
+		Long: `Load network model from a JSON file and check it for errors and likely mistakes
+(unreachable endpoints, overlapping subnets, unused bridges, etc.).
+Eden-SDN does not need to be running.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnNetModelValidate(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return sdnNetModelValidateCmd
+}
+
 func newSdnNetConfigGraphCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var sdnNetConfigGraphCmd = &cobra.Command{
 		Use:   "net-config-graph",
@@ -209,6 +233,7 @@ See sdn/api/endpoints.go to learn about all kinds of supported endpoints.`,
 	}
 
 	sdnEndpointCmd.AddCommand(newSdnEpExecCmd(cfg))
+	sdnEndpointCmd.AddCommand(newSdnEpLogsCmd(cfg))
 	addSdnPortOpts(sdnEndpointCmd, cfg)
 
 	return sdnEndpointCmd
@@ -289,6 +314,262 @@ This is currently limited to TCP port forwarding (i.e. not working with UDP)!`,
 	return sdnFwdCmd
 }
 
+func newSdnCaptureCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnCaptureCmd = &cobra.Command{
+		Use:   "capture",
+		Short: "Capture packets inside Eden-SDN",
+		Long: `Capture packets on a port, bond, bridge, network or endpoint deployed inside Eden-SDN.
+The capture target is referenced by its logical label from the network model.
+Network is captured on its interface facing the bridge (i.e. as seen by the network router),
+endpoint on its only interface.
+Captured packets are stored inside Eden-SDN until "eden sdn capture get" is called to download them
+in the pcap format. Downloading is possible also while the capture is still running.`,
+	}
+
+	sdnCaptureCmd.AddCommand(newSdnCaptureStartCmd(cfg))
+	sdnCaptureCmd.AddCommand(newSdnCaptureStopCmd(cfg))
+	sdnCaptureCmd.AddCommand(newSdnCaptureGetCmd(cfg))
+	sdnCaptureCmd.AddCommand(newSdnCaptureListCmd(cfg))
+
+	return sdnCaptureCmd
+}
+
+func newSdnCaptureStartCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var filter string
+	var maxSize uint64
+
+	var sdnCaptureStartCmd = &cobra.Command{
+		Use:   "start <logical-label>",
+		Short: "Start packet capture",
+		Long: `Start packet capture.
+Use --filter to capture only packets matching a BPF filter expression (same syntax as used by tcpdump).
+Capture stops automatically once the size limit (--max-size) is reached.`,
+		Example: `  eden sdn capture start eth0 --filter "udp port 53"`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnCaptureStart(args[0], filter, maxSize); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCaptureStartCmd, cfg)
+	sdnCaptureStartCmd.Flags().StringVar(&filter, "filter", "",
+		"BPF filter expression selecting packets to capture")
+	sdnCaptureStartCmd.Flags().Uint64Var(&maxSize, "max-size", 0,
+		"maximum size of the capture in bytes (0 = use default limit of 32MiB)")
+
+	return sdnCaptureStartCmd
+}
+
+func newSdnCaptureStopCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnCaptureStopCmd = &cobra.Command{
+		Use:   "stop <logical-label>",
+		Short: "Stop packet capture",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnCaptureStop(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCaptureStopCmd, cfg)
+
+	return sdnCaptureStopCmd
+}
+
+func newSdnCaptureGetCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var outFile string
+
+	var sdnCaptureGetCmd = &cobra.Command{
+		Use:   "get <logical-label>",
+		Short: "Download captured packets into a pcap file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			label := args[0]
+			if outFile == "" {
+				outFile = label + ".pcap"
+			}
+			if err := openEVEC.SdnCaptureGet(label, outFile); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCaptureGetCmd, cfg)
+	sdnCaptureGetCmd.Flags().StringVarP(&outFile, "output", "o", "",
+		"output pcap file (default <logical-label>.pcap)")
+
+	return sdnCaptureGetCmd
+}
+
+func newSdnCaptureListCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnCaptureListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List packet captures and their status",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnCaptureList(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCaptureListCmd, cfg)
+
+	return sdnCaptureListCmd
+}
+
+func newSdnCaptivePortalCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnCaptivePortalCmd = &cobra.Command{
+		Use:   "captive-portal",
+		Short: "Manage clients of captive portals deployed inside Eden-SDN",
+		Long: `Manage clients of captive portals deployed inside Eden-SDN.
+Until a client (EVE) logs in, its HTTP(S) traffic is redirected to the captive portal of the network,
+DNS queries are hijacked and all other traffic is rejected.
+Clients can also log in by themselves, using the portal login page.`,
+	}
+
+	sdnCaptivePortalCmd.AddCommand(newSdnCaptivePortalLoginCmd(cfg, true))
+	sdnCaptivePortalCmd.AddCommand(newSdnCaptivePortalLoginCmd(cfg, false))
+	sdnCaptivePortalCmd.AddCommand(newSdnCaptivePortalStatusCmd(cfg))
+
+	return sdnCaptivePortalCmd
+}
+
+func newSdnCaptivePortalLoginCmd(cfg *openevec.EdenSetupArgs, login bool) *cobra.Command {
+	var clientIP string
+
+	use, short := "logout", "Log client(s) out of the captive portal of a network"
+	if login {
+		use, short = "login", "Log client(s) into the captive portal of a network"
+	}
+	var sdnCaptivePortalLoginCmd = &cobra.Command{
+		Use:   use + " <network-logical-label>",
+		Short: short,
+		Long: short + `.
+Without --ip, all clients of the network are affected.`,
+		Example: fmt.Sprintf("  eden sdn captive-portal %s network0 --ip 172.22.12.10", use),
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if login {
+				err = openEVEC.SdnCaptivePortalLogin(args[0], clientIP)
+			} else {
+				err = openEVEC.SdnCaptivePortalLogout(args[0], clientIP)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCaptivePortalLoginCmd, cfg)
+	sdnCaptivePortalLoginCmd.Flags().StringVar(&clientIP, "ip", "",
+		"IP address of the client (all clients if not specified)")
+
+	return sdnCaptivePortalLoginCmd
+}
+
+func newSdnCaptivePortalStatusCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnCaptivePortalStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "List networks with captive portal and their logged-in clients",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnCaptivePortalStatus(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCaptivePortalStatusCmd, cfg)
+
+	return sdnCaptivePortalStatusCmd
+}
+
+func newSdnEpLogsCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var filter edensdn.SyslogFilter
+	var since, timeout time.Duration
+	var follow, jsonOutput bool
+	var count uint
+
+	var sdnEpLogsCmd = &cobra.Command{
+		Use:   "logs <endpoint-name>",
+		Short: "Print messages received by a syslog server endpoint",
+		Long: `Print messages received by a syslog server endpoint.
+Use --regex to print only messages matching the regular expression
+(matched against the message as received, including the syslog header).
+Use --follow to keep printing new messages as they arrive.
+Use --count to stop after the given number of messages (the command fails
+if fewer messages were received). Together with --follow and --timeout this can be used
+to wait for a particular message, e.g. from escript tests.`,
+		Example: "  eden sdn endpoint logs syslog-server --regex 'app1.*started' --follow --count 1 --timeout 5m",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if since > 0 {
+				filter.Since = time.Now().Add(-since)
+			}
+			err := openEVEC.SdnEndpointLogs(args[0], filter, follow, count, timeout,
+				jsonOutput)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnEpLogsCmd, cfg)
+	sdnEpLogsCmd.Flags().StringVar(&filter.Regex, "regex", "",
+		"print only messages matching the regular expression")
+	sdnEpLogsCmd.Flags().DurationVar(&since, "since", 0,
+		"print only messages received within the given duration (e.g. 10m)")
+	sdnEpLogsCmd.Flags().BoolVarP(&follow, "follow", "f", false,
+		"keep printing new messages as they arrive")
+	sdnEpLogsCmd.Flags().UintVar(&count, "count", 0,
+		"stop after printing the given number of messages")
+	sdnEpLogsCmd.Flags().DurationVar(&timeout, "timeout", 0,
+		"stop following after the given duration (0 for no timeout)")
+	sdnEpLogsCmd.Flags().BoolVar(&jsonOutput, "json", false,
+		"print messages in JSON (one message per line)")
+
+	return sdnEpLogsCmd
+}
+
+func newSdnConntrackCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var filter edensdn.ConntrackFilter
+
+	var sdnConntrackCmd = &cobra.Command{
+		Use:   "conntrack",
+		Short: "List connections tracked by the Eden-SDN firewall",
+		Long: `List connections tracked by the Eden-SDN firewall.
+Use --label to list connections tracked inside the network namespace of a network
+or an endpoint instead. Packet and byte counters are shown for both directions
+(original/reply).`,
+		Example: "  eden sdn conntrack --proto tcp --src 172.22.12.10 --port 443",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnConntrack(filter); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnConntrackCmd, cfg)
+	sdnConntrackCmd.Flags().StringVar(&filter.Label, "label", "",
+		"logical label of a network or endpoint")
+	sdnConntrackCmd.Flags().StringVar(&filter.Proto, "proto", "",
+		"filter by L4 protocol (tcp, udp, icmp, ...)")
+	sdnConntrackCmd.Flags().StringVar(&filter.Src, "src", "",
+		"filter by source IP address or subnet")
+	sdnConntrackCmd.Flags().StringVar(&filter.Dst, "dst", "",
+		"filter by destination IP address or subnet")
+	sdnConntrackCmd.Flags().Uint16Var(&filter.Port, "port", 0,
+		"filter by source or destination port")
+
+	return sdnConntrackCmd
+}
+
 func addSdnPidOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	currentPath, err := os.Getwd()
 	if err != nil {
@@ -328,6 +609,10 @@ func addSdnDisableOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	parentCmd.Flags().BoolVarP(&cfg.Sdn.Disable, "sdn-disable", "", false, "disable Eden-SDN (do not run SDN VM)")
 }
 
+func addSdnRunnerOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
+	parentCmd.Flags().StringVarP(&cfg.Sdn.Runner, "sdn-runner", "", defaults.DefaultSdnRunner, "how to run Eden-SDN: vm or container")
+}
+
 func addSdnSourceDirOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	parentCmd.Flags().StringVarP(&cfg.Sdn.SourceDir, "sdn-source-dir", "", "", "directory with SDN source code")
 }
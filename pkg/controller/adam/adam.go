@@ -47,6 +47,8 @@ type Ctx struct {
 	AdamCaching       bool   // enable caching of adam`s logs/info
 	AdamCachingRedis  bool   // caching to redis instead of files
 	AdamCachingPrefix string // custom prefix for file or stream naming for cache
+	timeRange         types.TimeRange
+	archive           *loaders.Archive // serve objects from captured archive instead of Adam
 }
 
 // parseRedisURL try to use string from config to obtain redis url
@@ -74,6 +76,10 @@ func parseRedisURL(s string) (addr, password string, databaseID int, err error)
 
 // getLoader return loader object from Adam`s config
 func (adam *Ctx) getLoader() (loader loaders.Loader) {
+	if adam.archive != nil {
+		log.Debug("will use archive loader")
+		return loaders.NewArchiveLoader(adam.archive)
+	}
 	if adam.AdamRemote {
 		log.Debug("will use remote adam loader")
 		if adam.AdamRemoteRedis {
@@ -134,6 +140,7 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 		}
 		loader.SetRemoteCache(cache)
 	}
+	loader.SetTimeRange(adam.timeRange)
 	return
 }
 
@@ -191,14 +198,64 @@ func (adam *Ctx) DeviceList(filter types.DeviceStateFilter) (out []string, err e
 
 // ConfigSet set config for devID
 func (adam *Ctx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
+	if adam.archive != nil {
+		return fmt.Errorf("cannot set config of device %s: archive is read-only", devUUID)
+	}
 	return adam.putObj(path.Join("/admin/device", devUUID.String(), "config"), devConfig, mimeProto)
 }
 
 // ConfigGet get config for devID
 func (adam *Ctx) ConfigGet(devUUID uuid.UUID) (out string, err error) {
+	if adam.archive != nil {
+		if !uuid.Equal(adam.archive.DevUUID, devUUID) {
+			return "", fmt.Errorf("no config for device %s in archive", devUUID)
+		}
+		return string(adam.archive.Config), nil
+	}
 	return adam.getObj(path.Join("/admin/device", devUUID.String(), "config"), mimeProto)
 }
 
+// SetTimeRange limits logs, info, metrics and requests processed by checkers to the timeRange
+func (adam *Ctx) SetTimeRange(timeRange types.TimeRange) {
+	adam.timeRange = timeRange
+}
+
+// SetArchive switches controller to serve logs, info, metrics, flow logs, requests
+// and config from the captured archive instead of Adam
+func (adam *Ctx) SetArchive(archive *loaders.Archive) {
+	adam.archive = archive
+}
+
+// CaptureRecord captures config and all objects of the device and its apps available in Adam
+func (adam *Ctx) CaptureRecord(devUUID uuid.UUID, appUUIDs []uuid.UUID) (*loaders.Archive, error) {
+	archive := loaders.NewArchive(devUUID)
+	devConfig, err := adam.ConfigGet(devUUID)
+	if err != nil {
+		return nil, fmt.Errorf("ConfigGet: %w", err)
+	}
+	archive.Config = []byte(devConfig)
+	loader := adam.getLoader()
+	objTypes := []types.LoaderObjectType{types.LogsType, types.InfoType, types.MetricsType, types.RequestType}
+	// only redis provides flow logs and app logs
+	withRedis := adam.AdamRemote && adam.AdamRemoteRedis
+	if withRedis {
+		objTypes = append(objTypes, types.FlowLogType)
+	}
+	for _, objType := range objTypes {
+		if err := archive.Record(loader, objType, uuid.Nil); err != nil {
+			return nil, fmt.Errorf("record of objects with type %d: %w", objType, err)
+		}
+	}
+	if withRedis {
+		for _, appUUID := range appUUIDs {
+			if err := archive.Record(loader, types.AppsType, appUUID); err != nil {
+				return nil, fmt.Errorf("record of logs of app %s: %w", appUUID, err)
+			}
+		}
+	}
+	return archive, nil
+}
+
 // GetECDHCert get cert for ECDH exchange for devID
 func (adam *Ctx) GetECDHCert(devUUID uuid.UUID) ([]byte, error) {
 	attestData, err := adam.getObj(path.Join("/admin/device", devUUID.String(), "certs"), mimeJSON)
@@ -274,7 +331,7 @@ func (adam *Ctx) InfoChecker(devUUID uuid.UUID, q map[string]string, handler ein
 func (adam *Ctx) InfoLastCallback(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error) {
 	var loader = adam.getLoader()
 	loader.SetUUID(devUUID)
-	return einfo.InfoLast(loader, q, einfo.ZInfoFind, handler)
+	return einfo.InfoLast(loader, q, handler)
 }
 
 // MetricChecker check metrics by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
@@ -82,6 +82,7 @@ type Cloud interface {
 	ListApplicationInstanceConfig() []*config.AppInstanceConfig
 	StateUpdate(dev *device.Ctx) (err error)
 	ResetDev(node *device.Ctx) error
+	ResetDevConfig(node *device.Ctx)
 	OnBoardDev(node *device.Ctx) error
 	GetVars() *utils.ConfigVars
 	SetVars(*utils.ConfigVars)
@@ -0,0 +1,196 @@
+// Package configdiff implements semantic comparison of EdgeDevConfig
+// to show what will be changed on device before sending config.
+package configdiff
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lf-edge/eve-api/go/config"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Action is type of change
+type Action string
+
+// Actions with objects of config
+const (
+	Added    Action = "added"
+	Removed  Action = "removed"
+	Modified Action = "modified"
+)
+
+// Kinds of objects in config
+const (
+	KindApp             = "app"
+	KindNetworkInstance = "network-instance"
+	KindVolume          = "volume"
+	KindContentTree     = "content-tree"
+	KindConfigItem      = "config-item"
+	KindDevice          = "device"
+)
+
+// Change describes difference of one object of config
+type Change struct {
+	Action Action
+	Kind   string
+	Name   string
+	ID     string
+	// Details contains names of modified fields or old and new values of config item
+	Details []string
+}
+
+// fields of EdgeDevConfig compared as lists of objects or ignored
+var skipDeviceFields = map[protoreflect.Name]bool{
+	"id":               true,
+	"config_timestamp": true,
+	"apps":             true,
+	"networkInstances": true,
+	"volumes":          true,
+	"contentInfo":      true,
+	"configItems":      true,
+}
+
+// Diff returns changes to apply to get newConfig from oldConfig
+func Diff(oldConfig, newConfig *config.EdgeDevConfig) []Change {
+	var changes []Change
+	changes = append(changes, diffList(KindApp, oldConfig.GetApps(), newConfig.GetApps(),
+		func(el *config.AppInstanceConfig) (string, string) {
+			return el.GetUuidandversion().GetUuid(), el.GetDisplayname()
+		})...)
+	changes = append(changes, diffList(KindNetworkInstance, oldConfig.GetNetworkInstances(), newConfig.GetNetworkInstances(),
+		func(el *config.NetworkInstanceConfig) (string, string) {
+			return el.GetUuidandversion().GetUuid(), el.GetDisplayname()
+		})...)
+	changes = append(changes, diffList(KindVolume, oldConfig.GetVolumes(), newConfig.GetVolumes(),
+		func(el *config.Volume) (string, string) {
+			return el.GetUuid(), el.GetDisplayName()
+		})...)
+	changes = append(changes, diffList(KindContentTree, oldConfig.GetContentInfo(), newConfig.GetContentInfo(),
+		func(el *config.ContentTree) (string, string) {
+			return el.GetUuid(), el.GetDisplayName()
+		})...)
+	changes = append(changes, diffConfigItems(oldConfig.GetConfigItems(), newConfig.GetConfigItems())...)
+	if fields := changedFields(oldConfig, newConfig, skipDeviceFields); len(fields) > 0 {
+		changes = append(changes, Change{
+			Action:  Modified,
+			Kind:    KindDevice,
+			Name:    "edge-node",
+			ID:      newConfig.GetId().GetUuid(),
+			Details: fields,
+		})
+	}
+	return changes
+}
+
+// diffList compares lists of objects identified by key
+func diffList[T proto.Message](kind string, oldList, newList []T, key func(T) (id, name string)) []Change {
+	var changes []Change
+	oldObjects := map[string]T{}
+	for _, el := range oldList {
+		id, _ := key(el)
+		oldObjects[id] = el
+	}
+	newIDs := map[string]bool{}
+	for _, el := range newList {
+		id, name := key(el)
+		newIDs[id] = true
+		oldEl, ok := oldObjects[id]
+		if !ok {
+			changes = append(changes, Change{Action: Added, Kind: kind, Name: name, ID: id})
+			continue
+		}
+		if fields := changedFields(oldEl, el, nil); len(fields) > 0 {
+			changes = append(changes, Change{Action: Modified, Kind: kind, Name: name, ID: id, Details: fields})
+		}
+	}
+	for _, el := range oldList {
+		id, name := key(el)
+		if !newIDs[id] {
+			changes = append(changes, Change{Action: Removed, Kind: kind, Name: name, ID: id})
+		}
+	}
+	return changes
+}
+
+// diffConfigItems compares config items by key
+func diffConfigItems(oldItems, newItems []*config.ConfigItem) []Change {
+	var changes []Change
+	oldValues := map[string]string{}
+	for _, item := range oldItems {
+		oldValues[item.GetKey()] = item.GetValue()
+	}
+	newKeys := map[string]bool{}
+	for _, item := range newItems {
+		newKeys[item.GetKey()] = true
+		oldValue, ok := oldValues[item.GetKey()]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Action: Added, Kind: KindConfigItem, Name: item.GetKey(),
+				Details: []string{item.GetValue()}})
+		case oldValue != item.GetValue():
+			changes = append(changes, Change{Action: Modified, Kind: KindConfigItem, Name: item.GetKey(),
+				Details: []string{fmt.Sprintf("%s -> %s", oldValue, item.GetValue())}})
+		}
+	}
+	for _, item := range oldItems {
+		if !newKeys[item.GetKey()] {
+			changes = append(changes, Change{Action: Removed, Kind: KindConfigItem, Name: item.GetKey(),
+				Details: []string{item.GetValue()}})
+		}
+	}
+	return changes
+}
+
+// changedFields returns names of top-level fields which differ in messages
+func changedFields(a, b proto.Message, skip map[protoreflect.Name]bool) []string {
+	ma, mb := a.ProtoReflect(), b.ProtoReflect()
+	var result []string
+	fields := ma.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if skip[fd.Name()] {
+			continue
+		}
+		// compare messages with only one field set to handle lists and maps the same way
+		onlyA, onlyB := ma.Type().New(), mb.Type().New()
+		if ma.Has(fd) {
+			onlyA.Set(fd, ma.Get(fd))
+		}
+		if mb.Has(fd) {
+			onlyB.Set(fd, mb.Get(fd))
+		}
+		if !proto.Equal(onlyA.Interface(), onlyB.Interface()) {
+			result = append(result, fd.JSONName())
+		}
+	}
+	return result
+}
+
+var actionSigns = map[Action]string{
+	Added:    "+",
+	Removed:  "-",
+	Modified: "~",
+}
+
+// Print writes changes in human-readable form
+func Print(w io.Writer, changes []Change) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes in config")
+		return err
+	}
+	for _, change := range changes {
+		line := fmt.Sprintf("%s %s %s", actionSigns[change.Action], change.Kind, change.Name)
+		if change.ID != "" {
+			line += fmt.Sprintf(" (%s)", change.ID)
+		}
+		if len(change.Details) > 0 {
+			line += fmt.Sprintf(": %v", change.Details)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package configdiff_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/configdiff"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func app(id, name string, activate bool) *config.AppInstanceConfig {
+	return &config.AppInstanceConfig{
+		Uuidandversion: &config.UUIDandVersion{Uuid: id, Version: "1"},
+		Displayname:    name,
+		Activate:       activate,
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	oldConfig := &config.EdgeDevConfig{
+		Id:          &config.UUIDandVersion{Uuid: "dev", Version: "1"},
+		Apps:        []*config.AppInstanceConfig{app("1", "kept", true), app("2", "stopped", true), app("3", "removed", true)},
+		Volumes:     []*config.Volume{{Uuid: "v1", DisplayName: "vol"}},
+		ConfigItems: []*config.ConfigItem{{Key: "timer.config.interval", Value: "5"}, {Key: "debug.enable.ssh", Value: "key"}},
+	}
+	newConfig := &config.EdgeDevConfig{
+		Id:          &config.UUIDandVersion{Uuid: "dev", Version: "2"},
+		Apps:        []*config.AppInstanceConfig{app("1", "kept", true), app("2", "stopped", false), app("4", "added", true)},
+		Volumes:     []*config.Volume{{Uuid: "v1", DisplayName: "vol"}},
+		ConfigItems: []*config.ConfigItem{{Key: "timer.config.interval", Value: "10"}},
+		Reboot:      &config.DeviceOpsCmd{Counter: 1},
+	}
+
+	changes := configdiff.Diff(oldConfig, newConfig)
+	assert.Equal(t, []configdiff.Change{
+		{Action: configdiff.Modified, Kind: configdiff.KindApp, Name: "stopped", ID: "2", Details: []string{"activate"}},
+		{Action: configdiff.Added, Kind: configdiff.KindApp, Name: "added", ID: "4"},
+		{Action: configdiff.Removed, Kind: configdiff.KindApp, Name: "removed", ID: "3"},
+		{Action: configdiff.Modified, Kind: configdiff.KindConfigItem, Name: "timer.config.interval", Details: []string{"5 -> 10"}},
+		{Action: configdiff.Removed, Kind: configdiff.KindConfigItem, Name: "debug.enable.ssh", Details: []string{"key"}},
+		{Action: configdiff.Modified, Kind: configdiff.KindDevice, Name: "edge-node", ID: "dev", Details: []string{"reboot"}},
+	}, changes)
+
+	var buf bytes.Buffer
+	assert.NoError(t, configdiff.Print(&buf, changes[1:2]))
+	assert.Equal(t, "+ app added (4)\n", buf.String())
+
+	assert.Empty(t, configdiff.Diff(oldConfig, oldConfig))
+}
@@ -5,13 +5,14 @@ package eflowlog
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/query"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/flowlog"
@@ -64,32 +65,14 @@ func FlowLogItemPrint(le *flowlog.FlowMessage, query []string) *types.PrintResul
 }
 
 // FlowLogItemFind find FlowMessage records by reqexps in 'query' corresponded to FlowMessage structure.
-func FlowLogItemFind(le *flowlog.FlowMessage, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, caser.String(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
+// Expression under the query.Key is parsed and ANDed with other pairs.
+// Callers filtering many objects should parse the query once with query.FromMap.
+func FlowLogItemFind(le *flowlog.FlowMessage, q map[string]string) (bool, error) {
+	expr, err := query.FromMap(q)
+	if err != nil {
+		return false, err
 	}
-	return matched
+	return expr.Match(le), nil
 }
 
 // HandleFactory implements HandlerFunc which prints log in the provided format
@@ -121,14 +104,19 @@ func FlowLogPrn(le *flowlog.FlowMessage, format types.OutputFormat) {
 // or false to continue
 type HandlerFunc func(*flowlog.FlowMessage) bool
 
-func flowLogProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFunction {
-	devID, ok := query["devId"]
+func flowLogProcess(q map[string]string, handler HandlerFunc) (loaders.ProcessFunction, error) {
+	q = maps.Clone(q)
+	devID, ok := q["devId"]
 	if ok {
-		delete(query, "devId")
+		delete(q, "devId")
 	}
-	_, ok = query["eveVersion"]
+	_, ok = q["eveVersion"]
 	if ok {
-		delete(query, "eveVersion")
+		delete(q, "eveVersion")
+	}
+	expr, err := query.FromMap(q)
+	if err != nil {
+		return nil, err
 	}
 	return func(bytes []byte) (bool, error) {
 		lb, err := ParseFullLogEntry(bytes)
@@ -138,25 +126,33 @@ func flowLogProcess(query map[string]string, handler HandlerFunc) loaders.Proces
 		if devID != "" && devID != lb.DevId {
 			return true, nil
 		}
-		if FlowLogItemFind(lb, query) {
+		if expr.Match(lb) {
 			if handler(lb) {
 				return false, nil
 			}
 		}
 		return true, nil
-	}
+	}, nil
 }
 
 // FlowLogWatch monitors the change of FlowLog files in the 'filepath' directory
 // according to the 'query' reqexps and processing using the 'handler' function.
 func FlowLogWatch(loader loaders.Loader, query map[string]string, handler HandlerFunc, timeoutSeconds time.Duration) error {
-	return loader.ProcessStream(flowLogProcess(query, handler), types.FlowLogType, timeoutSeconds)
+	process, err := flowLogProcess(query, handler)
+	if err != nil {
+		return err
+	}
+	return loader.ProcessStream(process, types.FlowLogType, timeoutSeconds)
 }
 
 // FlowLogLast function process FlowLog files in the 'filepath' directory
 // according to the 'query' reqexps and return last founded item
 func FlowLogLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
-	return loader.ProcessExisting(flowLogProcess(query, handler), types.FlowLogType)
+	process, err := flowLogProcess(query, handler)
+	if err != nil {
+		return err
+	}
+	return loader.ProcessExisting(process, types.FlowLogType)
 }
 
 // FlowLogChecker check logs by pattern from existence files with FlowLogLast and use FlowLogWatchWithTimeout with timeout for observe new files
@@ -192,7 +188,7 @@ func FlowLogChecker(loader loaders.Loader, devUUID uuid.UUID, q map[string]strin
 			return false
 		}
 		if err = FlowLogLast(loader.Clone(), q, handlerLocal); err != nil {
-			done <- err
+			return err
 		}
 		el, err := logQueue.Dequeue()
 		for err == nil {
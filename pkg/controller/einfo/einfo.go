@@ -5,11 +5,11 @@ package einfo
 import (
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/query"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/info"
@@ -25,10 +25,6 @@ import (
 // or false to continue
 type HandlerFunc func(im *info.ZInfoMsg) bool
 
-// QHandlerFunc must process info.ZInfoMsg with query parameters
-// and return true to exit or false to continue
-type QHandlerFunc func(im *info.ZInfoMsg, query map[string]string) bool
-
 // ParseZInfoMsg unmarshal ZInfoMsg
 func ParseZInfoMsg(data []byte) (ZInfoMsg *info.ZInfoMsg, err error) {
 	var zi info.ZInfoMsg
@@ -64,37 +60,6 @@ func HandleFactory(format types.OutputFormat, once bool) HandlerFunc {
 	}
 }
 
-func processElem(value reflect.Value, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, caser.String(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			if b, ok := inp.Interface().([]byte); ok {
-				f = fmt.Sprintf("%s", b)
-			}
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(value).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			break
-		}
-	}
-	return matched
-}
-
 // ZInfoPrintFiltered finds ZInfoMsg records by path in 'query'
 func ZInfoPrintFiltered(im *info.ZInfoMsg, query []string) *types.PrintResult {
 	result := make(types.PrintResult)
@@ -118,12 +83,14 @@ func ZInfoPrintFiltered(im *info.ZInfoMsg, query []string) *types.PrintResult {
 }
 
 // ZInfoFind finds ZInfoMsg records with 'devid' and ZInfoDevSWF structure fields
-// by reqexps in 'query'
-func ZInfoFind(im *info.ZInfoMsg, query map[string]string) bool {
-	if processElem(reflect.ValueOf(im), query) {
-		return true
+// by reqexps in 'query'. Expression under the query.Key is parsed and ANDed with other pairs.
+// Callers filtering many objects should parse the query once with query.FromMap.
+func ZInfoFind(im *info.ZInfoMsg, q map[string]string) (bool, error) {
+	expr, err := query.FromMap(q)
+	if err != nil {
+		return false, err
 	}
-	return false
+	return expr.Match(im), nil
 }
 
 // InfoCheckerMode is InfoExist, InfoNew and InfoAny
@@ -141,29 +108,41 @@ const (
 	InfoAny   InfoCheckerMode = -1 // use both mechanisms
 )
 
-func infoProcess(query map[string]string, qhandler QHandlerFunc, handler HandlerFunc) loaders.ProcessFunction {
+func infoProcess(q map[string]string, handler HandlerFunc) (loaders.ProcessFunction, error) {
+	expr, err := query.FromMap(q)
+	if err != nil {
+		return nil, err
+	}
 	return func(bytes []byte) (bool, error) {
 		im, err := ParseZInfoMsg(bytes)
 		if err != nil {
 			return true, nil
 		}
-		if qhandler(im, query) {
+		if expr.Match(im) {
 			if handler(im) {
 				return false, nil
 			}
 		}
 		return true, nil
-	}
+	}, nil
 }
 
-// InfoLast search Info files in the 'filepath' directory according to the 'query' parameters accepted by the 'qhandler' function and subsequent process using the 'handler' function.
-func InfoLast(loader loaders.Loader, query map[string]string, qhandler QHandlerFunc, handler HandlerFunc) error {
-	return loader.ProcessExisting(infoProcess(query, qhandler, handler), types.InfoType)
+// InfoLast search Info files in the 'filepath' directory according to the 'query' parameters and subsequent process using the 'handler' function.
+func InfoLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
+	process, err := infoProcess(query, handler)
+	if err != nil {
+		return err
+	}
+	return loader.ProcessExisting(process, types.InfoType)
 }
 
-// InfoWatch monitors the change of Info files in the 'filepath' directory according to the 'query' parameters accepted by the 'qhandler' function and subsequent processing using the 'handler' function with 'timeoutSeconds'.
-func InfoWatch(loader loaders.Loader, query map[string]string, qhandler QHandlerFunc, handler HandlerFunc, timeoutSeconds time.Duration) error {
-	return loader.ProcessStream(infoProcess(query, qhandler, handler), types.InfoType, timeoutSeconds)
+// InfoWatch monitors the change of Info files in the 'filepath' directory according to the 'query' parameters and subsequent processing using the 'handler' function with 'timeoutSeconds'.
+func InfoWatch(loader loaders.Loader, query map[string]string, handler HandlerFunc, timeoutSeconds time.Duration) error {
+	process, err := infoProcess(query, handler)
+	if err != nil {
+		return err
+	}
+	return loader.ProcessStream(process, types.InfoType, timeoutSeconds)
 }
 
 // InfoChecker checks the information in the regular expression pattern 'query' and processes the info.ZInfoMsg found by the function 'handler' from existing files (mode=InfoExist), new files (mode=InfoNew) or any of them (mode=InfoAny) with timeout (0 for infinite).
@@ -174,7 +153,7 @@ func InfoChecker(loader loaders.Loader, devUUID uuid.UUID, query map[string]stri
 	// observe new files
 	if mode == InfoNew || mode == InfoAny {
 		go func() {
-			done <- InfoWatch(loader.Clone(), query, ZInfoFind, handler, timeout)
+			done <- InfoWatch(loader.Clone(), query, handler, timeout)
 		}()
 	}
 	// check info by pattern in existing files
@@ -186,7 +165,7 @@ func InfoChecker(loader loaders.Loader, devUUID uuid.UUID, query map[string]stri
 				}
 				return
 			}
-			done <- InfoLast(loader.Clone(), query, ZInfoFind, handler)
+			done <- InfoLast(loader.Clone(), query, handler)
 		}()
 	}
 	// use for process only defined count of last messages
@@ -201,8 +180,8 @@ func InfoChecker(loader loaders.Loader, devUUID uuid.UUID, query map[string]stri
 			}
 			return false
 		}
-		if err = InfoLast(loader.Clone(), query, ZInfoFind, handlerLocal); err != nil {
-			done <- err
+		if err = InfoLast(loader.Clone(), query, handlerLocal); err != nil {
+			return err
 		}
 		el, err := infoQueue.Dequeue()
 		for err == nil {
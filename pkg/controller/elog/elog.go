@@ -4,12 +4,13 @@ package elog
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/query"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/logs"
@@ -69,32 +70,14 @@ func LogItemPrint(le *FullLogEntry, _ types.OutputFormat, query []string) *types
 }
 
 // LogItemFind find LogItem records by reqexps in 'query' corresponded to LogItem structure.
-func LogItemFind(le *FullLogEntry, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, caser.String(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
+// Expression under the query.Key is parsed and ANDed with other pairs.
+// Callers filtering many objects should parse the query once with query.FromMap.
+func LogItemFind(le *FullLogEntry, q map[string]string) (bool, error) {
+	expr, err := query.FromMap(q)
+	if err != nil {
+		return false, err
 	}
-	return matched
+	return expr.Match(le), nil
 }
 
 // HandleFactory implements HandlerFunc which prints log in the provided format
@@ -132,14 +115,19 @@ func LogPrn(le *FullLogEntry, format types.OutputFormat) {
 // or false to continue
 type HandlerFunc func(*FullLogEntry) bool
 
-func logProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFunction {
-	_, ok := query["devId"]
+func logProcess(q map[string]string, handler HandlerFunc) (loaders.ProcessFunction, error) {
+	q = maps.Clone(q)
+	_, ok := q["devId"]
 	if ok {
-		delete(query, "devId")
+		delete(q, "devId")
 	}
-	eveVersion, ok := query["eveVersion"]
+	eveVersion, ok := q["eveVersion"]
 	if ok {
-		delete(query, "eveVersion")
+		delete(q, "eveVersion")
+	}
+	expr, err := query.FromMap(q)
+	if err != nil {
+		return nil, err
 	}
 	return func(bytes []byte) (bool, error) {
 		lb, err := ParseFullLogEntry(bytes)
@@ -149,25 +137,33 @@ func logProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFun
 		if eveVersion != "" && eveVersion != lb.EveVersion {
 			return true, nil
 		}
-		if LogItemFind(lb, query) {
+		if expr.Match(lb) {
 			if handler(lb) {
 				return false, nil
 			}
 		}
 		return true, nil
-	}
+	}, nil
 }
 
 // LogWatch monitors the change of Log files in the 'filepath' directory
 // according to the 'query' reqexps and processing using the 'handler' function.
 func LogWatch(loader loaders.Loader, query map[string]string, handler HandlerFunc, timeoutSeconds time.Duration) error {
-	return loader.ProcessStream(logProcess(query, handler), types.LogsType, timeoutSeconds)
+	process, err := logProcess(query, handler)
+	if err != nil {
+		return err
+	}
+	return loader.ProcessStream(process, types.LogsType, timeoutSeconds)
 }
 
 // LogLast function process Log files in the 'filepath' directory
 // according to the 'query' reqexps and return last founded item
 func LogLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
-	return loader.ProcessExisting(logProcess(query, handler), types.LogsType)
+	process, err := logProcess(query, handler)
+	if err != nil {
+		return err
+	}
+	return loader.ProcessExisting(process, types.LogsType)
 }
 
 // LogChecker check logs by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
@@ -203,7 +199,7 @@ func LogChecker(loader loaders.Loader, devUUID uuid.UUID, q map[string]string, h
 			return false
 		}
 		if err = LogLast(loader.Clone(), q, handlerLocal); err != nil {
-			done <- err
+			return err
 		}
 		el, err := logQueue.Dequeue()
 		for err == nil {
@@ -4,12 +4,13 @@ package emetric
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/query"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/metrics"
@@ -67,37 +68,30 @@ func MetricItemPrint(mm *metrics.ZMetricMsg, query []string) *types.PrintResult
 }
 
 // MetricItemFind find ZMetricMsg records by reqexps in 'query' corresponded to ZMetricMsg structure.
-func MetricItemFind(mm *metrics.ZMetricMsg, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		splitRequest := strings.Split(k, ".")
-		if len(splitRequest) > 0 && strings.ToLower(splitRequest[0]) == "dm" { //dm is located in MetricContent
-			query[strings.Join(append([]string{"MetricContent"}, splitRequest...), ".")] = v
-			delete(query, k)
-		}
-		// Uppercase of filed's name first letter
-		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, caser.String(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(mm)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
+// Expression under the query.Key is parsed and ANDed with other pairs.
+// Callers filtering many objects should parse the query once with MetricQuery.
+func MetricItemFind(mm *metrics.ZMetricMsg, q map[string]string) (bool, error) {
+	expr, err := MetricQuery(q)
+	if err != nil {
+		return false, err
 	}
-	return matched
+	return expr.Match(mm), nil
+}
+
+// MetricQuery builds expression from query map and points
+// fields of dm (located in MetricContent) to the right place
+func MetricQuery(q map[string]string) (query.Expr, error) {
+	expr, err := query.FromMap(q)
+	if err != nil {
+		return nil, err
+	}
+	query.Walk(expr, func(t *query.Term) {
+		splitRequest := strings.Split(t.Field, ".")
+		if strings.ToLower(splitRequest[0]) == "dm" {
+			t.Field = strings.Join(append([]string{"MetricContent"}, splitRequest...), ".")
+		}
+	})
+	return expr, nil
 }
 
 // MetricPrn print Metric data
@@ -123,10 +117,15 @@ func MetricPrn(le *metrics.ZMetricMsg, format types.OutputFormat) {
 // or false to continue
 type HandlerFunc func(msg *metrics.ZMetricMsg) bool
 
-func metricProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFunction {
-	devID, ok := query["devId"]
+func metricProcess(q map[string]string, handler HandlerFunc) (loaders.ProcessFunction, error) {
+	q = maps.Clone(q)
+	devID, ok := q["devId"]
 	if ok {
-		delete(query, "devId")
+		delete(q, "devId")
+	}
+	expr, err := MetricQuery(q)
+	if err != nil {
+		return nil, err
 	}
 	return func(bytes []byte) (bool, error) {
 		lb, err := ParseMetricsBundle(bytes)
@@ -136,25 +135,33 @@ func metricProcess(query map[string]string, handler HandlerFunc) loaders.Process
 		if devID != "" && devID != lb.DevID {
 			return true, nil
 		}
-		if MetricItemFind(lb, query) {
+		if expr.Match(lb) {
 			if handler(lb) {
 				return false, nil
 			}
 		}
 		return true, nil
-	}
+	}, nil
 }
 
 // MetricWatch monitors the change of Metric files in the 'filepath' directory
 // according to the 'query' reqexps and processing using the 'handler' function.
 func MetricWatch(loader loaders.Loader, query map[string]string, handler HandlerFunc, timeoutSeconds time.Duration) error {
-	return loader.ProcessStream(metricProcess(query, handler), types.MetricsType, timeoutSeconds)
+	process, err := metricProcess(query, handler)
+	if err != nil {
+		return err
+	}
+	return loader.ProcessStream(process, types.MetricsType, timeoutSeconds)
 }
 
 // MetricLast function process Metric files in the 'filepath' directory
 // according to the 'query' reqexps and return last founded item
 func MetricLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
-	return loader.ProcessExisting(metricProcess(query, handler), types.MetricsType)
+	process, err := metricProcess(query, handler)
+	if err != nil {
+		return err
+	}
+	return loader.ProcessExisting(process, types.MetricsType)
 }
 
 // MetricChecker check metrics by pattern from existence files with HandlerFunc with timeout for observe new files
@@ -190,7 +197,7 @@ func MetricChecker(loader loaders.Loader, devUUID uuid.UUID, q map[string]string
 			return false
 		}
 		if err = MetricLast(loader.Clone(), q, handlerLocal); err != nil {
-			done <- err
+			return err
 		}
 		el, err := metricQueue.Dequeue()
 		for err == nil {
@@ -0,0 +1,271 @@
+// Package exporter exposes metrics received from EVE devices
+// in Prometheus/OpenMetrics format.
+package exporter
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "eden"
+	mb        = 1024 * 1024
+)
+
+var (
+	deviceLabels = []string{"device"}
+	appLabels    = []string{"device", "app", "app_uuid"}
+	volumeLabels = []string{"device", "volume", "volume_uuid"}
+	niLabels     = []string{"device", "network_instance", "network_uuid"}
+	diskLabels   = []string{"device", "disk", "mount_path"}
+
+	lastMetricDesc = newDesc("device", "last_metric_timestamp_seconds",
+		"Time of the last metric message received from the device", deviceLabels)
+	deviceCPUDesc = newDesc("device", "cpu_seconds_total",
+		"Total CPU time consumed on the device", deviceLabels)
+	deviceMemUsedDesc = newDesc("device", "memory_used_bytes",
+		"Memory used on the device", deviceLabels)
+	deviceMemAvailDesc = newDesc("device", "memory_available_bytes",
+		"Memory available on the device", deviceLabels)
+	deviceDiskReadDesc = newDesc("device", "disk_read_bytes_total",
+		"Bytes read from the disk of the device", diskLabels)
+	deviceDiskWriteDesc = newDesc("device", "disk_write_bytes_total",
+		"Bytes written to the disk of the device", diskLabels)
+	deviceDiskUsedDesc = newDesc("device", "disk_used_bytes",
+		"Used space of the disk of the device", diskLabels)
+	deviceDiskFreeDesc = newDesc("device", "disk_free_bytes",
+		"Free space of the disk of the device", diskLabels)
+	deviceNet = newNetDescs("device", deviceLabels)
+
+	appCPUDesc = newDesc("app", "cpu_seconds_total",
+		"Total CPU time consumed by the app", appLabels)
+	appMemUsedDesc = newDesc("app", "memory_used_bytes",
+		"Memory used by the app", appLabels)
+	appMemAllocatedDesc = newDesc("app", "memory_allocated_bytes",
+		"Memory allocated for the app", appLabels)
+	appNet = newNetDescs("app", appLabels)
+
+	volumeReadDesc = newDesc("volume", "read_bytes_total",
+		"Bytes read from the volume", volumeLabels)
+	volumeWriteDesc = newDesc("volume", "write_bytes_total",
+		"Bytes written to the volume", volumeLabels)
+	volumeUsedDesc = newDesc("volume", "used_bytes",
+		"Used space of the volume", volumeLabels)
+	volumeFreeDesc = newDesc("volume", "free_bytes",
+		"Free space of the volume", volumeLabels)
+	volumeTotalDesc = newDesc("volume", "total_bytes",
+		"Total space of the volume", volumeLabels)
+
+	niActivatedDesc = newDesc("network_instance", "activated",
+		"Is network instance activated", niLabels)
+	niNet = newNetDescs("network_instance", niLabels)
+)
+
+func newDesc(subsystem, name, help string, labels []string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, labels, nil)
+}
+
+// netDescs describes counters of network interface
+type netDescs struct {
+	rxBytes, txBytes   *prometheus.Desc
+	rxPkts, txPkts     *prometheus.Desc
+	rxDrops, txDrops   *prometheus.Desc
+	rxErrors, txErrors *prometheus.Desc
+}
+
+func newNetDescs(subsystem string, labels []string) *netDescs {
+	labels = append(append([]string{}, labels...), "interface")
+	return &netDescs{
+		rxBytes:  newDesc(subsystem, "network_receive_bytes_total", "Bytes received on the interface", labels),
+		txBytes:  newDesc(subsystem, "network_transmit_bytes_total", "Bytes transmitted on the interface", labels),
+		rxPkts:   newDesc(subsystem, "network_receive_packets_total", "Packets received on the interface", labels),
+		txPkts:   newDesc(subsystem, "network_transmit_packets_total", "Packets transmitted on the interface", labels),
+		rxDrops:  newDesc(subsystem, "network_receive_drop_total", "Received packets dropped on the interface", labels),
+		txDrops:  newDesc(subsystem, "network_transmit_drop_total", "Transmitted packets dropped on the interface", labels),
+		rxErrors: newDesc(subsystem, "network_receive_errs_total", "Receive errors on the interface", labels),
+		txErrors: newDesc(subsystem, "network_transmit_errs_total", "Transmit errors on the interface", labels),
+	}
+}
+
+func (d *netDescs) describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{d.rxBytes, d.txBytes, d.rxPkts, d.txPkts,
+		d.rxDrops, d.txDrops, d.rxErrors, d.txErrors} {
+		ch <- desc
+	}
+}
+
+func (d *netDescs) collect(e *emitter, network []*metrics.NetworkMetric, labels ...string) {
+	for _, nm := range network {
+		ifName := nm.GetIName()
+		if nm.GetLocalName() != "" {
+			ifName = nm.GetLocalName()
+		}
+		l := append(append([]string{}, labels...), ifName)
+		e.counter(d.rxBytes, float64(nm.GetRxBytes()), l...)
+		e.counter(d.txBytes, float64(nm.GetTxBytes()), l...)
+		e.counter(d.rxPkts, float64(nm.GetRxPkts()), l...)
+		e.counter(d.txPkts, float64(nm.GetTxPkts()), l...)
+		e.counter(d.rxDrops, float64(nm.GetRxDrops()), l...)
+		e.counter(d.txDrops, float64(nm.GetTxDrops()), l...)
+		e.counter(d.rxErrors, float64(nm.GetRxErrors()), l...)
+		e.counter(d.txErrors, float64(nm.GetTxErrors()), l...)
+	}
+}
+
+// emitter sends metrics to the channel and drops the ones already sent
+// with the same name and label values, which client_golang rejects
+// failing the whole scrape (e.g. two interfaces or disks reported
+// with the same name)
+type emitter struct {
+	ch   chan<- prometheus.Metric
+	seen map[string]struct{}
+}
+
+func newEmitter(ch chan<- prometheus.Metric) *emitter {
+	return &emitter{ch: ch, seen: map[string]struct{}{}}
+}
+
+func (e *emitter) emit(desc *prometheus.Desc, valueType prometheus.ValueType, value float64, labels ...string) {
+	key := strings.Join(append([]string{desc.String()}, labels...), "\xff")
+	if _, ok := e.seen[key]; ok {
+		return
+	}
+	e.seen[key] = struct{}{}
+	e.ch <- prometheus.MustNewConstMetric(desc, valueType, value, labels...)
+}
+
+func (e *emitter) counter(desc *prometheus.Desc, value float64, labels ...string) {
+	e.emit(desc, prometheus.CounterValue, value, labels...)
+}
+
+func (e *emitter) gauge(desc *prometheus.Desc, value float64, labels ...string) {
+	e.emit(desc, prometheus.GaugeValue, value, labels...)
+}
+
+func cpuSeconds(cpu *metrics.AppCpuMetric) float64 {
+	if cpu.GetTotalNs() != 0 {
+		return float64(cpu.GetTotalNs()) / 1e9
+	}
+	return float64(cpu.GetTotal())
+}
+
+// Collector keeps the last metric message of every device
+// and exposes it as Prometheus metrics
+type Collector struct {
+	mu     sync.RWMutex
+	latest map[string]*metrics.ZMetricMsg
+}
+
+// NewCollector creates empty Collector
+func NewCollector() *Collector {
+	return &Collector{latest: map[string]*metrics.ZMetricMsg{}}
+}
+
+// Update stores the message as the last one for its device
+func (c *Collector) Update(msg *metrics.ZMetricMsg) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.latest[msg.GetDevID()] = msg
+}
+
+// HandlerFunc returns emetric.HandlerFunc which updates the collector
+// with every received message and never stops processing
+func (c *Collector) HandlerFunc() emetric.HandlerFunc {
+	return func(msg *metrics.ZMetricMsg) bool {
+		c.Update(msg)
+		return false
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{lastMetricDesc, deviceCPUDesc, deviceMemUsedDesc, deviceMemAvailDesc,
+		deviceDiskReadDesc, deviceDiskWriteDesc, deviceDiskUsedDesc, deviceDiskFreeDesc,
+		appCPUDesc, appMemUsedDesc, appMemAllocatedDesc,
+		volumeReadDesc, volumeWriteDesc, volumeUsedDesc, volumeFreeDesc, volumeTotalDesc,
+		niActivatedDesc} {
+		ch <- desc
+	}
+	deviceNet.describe(ch)
+	appNet.describe(ch)
+	niNet.describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e := newEmitter(ch)
+	for devID, msg := range c.latest {
+		if ts := msg.GetAtTimeStamp(); ts != nil {
+			e.gauge(lastMetricDesc, float64(ts.AsTime().UnixNano())/1e9, devID)
+		}
+		collectDevice(e, devID, msg.GetDm())
+		for _, am := range msg.GetAm() {
+			collectApp(e, devID, am)
+		}
+		for _, vm := range msg.GetVm() {
+			collectVolume(e, devID, vm)
+		}
+		for _, nm := range msg.GetNm() {
+			collectNetworkInstance(e, devID, nm)
+		}
+	}
+}
+
+func collectDevice(e *emitter, devID string, dm *metrics.DeviceMetric) {
+	if dm == nil {
+		return
+	}
+	if dm.GetCpuMetric() != nil {
+		e.counter(deviceCPUDesc, cpuSeconds(dm.GetCpuMetric()), devID)
+	}
+	if dm.GetMemory() != nil {
+		e.gauge(deviceMemUsedDesc, float64(dm.GetMemory().GetUsedMem())*mb, devID)
+		e.gauge(deviceMemAvailDesc, float64(dm.GetMemory().GetAvailMem())*mb, devID)
+	}
+	for _, disk := range dm.GetDisk() {
+		e.counter(deviceDiskReadDesc, float64(disk.GetReadBytes())*mb, devID, disk.GetDisk(), disk.GetMountPath())
+		e.counter(deviceDiskWriteDesc, float64(disk.GetWriteBytes())*mb, devID, disk.GetDisk(), disk.GetMountPath())
+		e.gauge(deviceDiskUsedDesc, float64(disk.GetUsed())*mb, devID, disk.GetDisk(), disk.GetMountPath())
+		e.gauge(deviceDiskFreeDesc, float64(disk.GetFree())*mb, devID, disk.GetDisk(), disk.GetMountPath())
+	}
+	deviceNet.collect(e, dm.GetNetwork(), devID)
+}
+
+func collectApp(e *emitter, devID string, am *metrics.AppMetric) {
+	labels := []string{devID, am.GetAppName(), am.GetAppID()}
+	if am.GetCpu() != nil {
+		e.counter(appCPUDesc, cpuSeconds(am.GetCpu()), labels...)
+	}
+	if am.GetMemory() != nil {
+		e.gauge(appMemUsedDesc, float64(am.GetMemory().GetUsedMem())*mb, labels...)
+	}
+	if am.GetAppMemory() != nil {
+		e.gauge(appMemAllocatedDesc, float64(am.GetAppMemory().GetAllocatedMB())*mb, labels...)
+	}
+	appNet.collect(e, am.GetNetwork(), labels...)
+}
+
+func collectVolume(e *emitter, devID string, vm *metrics.ZMetricVolume) {
+	labels := []string{devID, vm.GetDisplayName(), vm.GetUuid()}
+	e.counter(volumeReadDesc, float64(vm.GetReadBytes()), labels...)
+	e.counter(volumeWriteDesc, float64(vm.GetWriteBytes()), labels...)
+	e.gauge(volumeUsedDesc, float64(vm.GetUsedBytes()), labels...)
+	e.gauge(volumeFreeDesc, float64(vm.GetFreeBytes()), labels...)
+	e.gauge(volumeTotalDesc, float64(vm.GetTotalBytes()), labels...)
+}
+
+func collectNetworkInstance(e *emitter, devID string, nm *metrics.ZMetricNetworkInstance) {
+	labels := []string{devID, nm.GetDisplayname(), nm.GetNetworkID()}
+	activated := 0.0
+	if nm.GetActivated() {
+		activated = 1
+	}
+	e.gauge(niActivatedDesc, activated, labels...)
+	niNet.collect(e, nm.GetNetwork(), labels...)
+}
@@ -0,0 +1,81 @@
+package exporter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/exporter"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	collector := exporter.NewCollector()
+	handler := collector.HandlerFunc()
+	assert.False(t, handler(&metrics.ZMetricMsg{
+		DevID: "dev",
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory:    &metrics.MemoryMetric{UsedMem: 2, AvailMem: 1},
+			CpuMetric: &metrics.AppCpuMetric{TotalNs: 3e9},
+		}},
+		Am: []*metrics.AppMetric{{
+			AppID:   "app-uuid",
+			AppName: "nginx",
+			Network: []*metrics.NetworkMetric{{IName: "nbu1x1", RxBytes: 100, TxBytes: 200}},
+		}},
+		Vm: []*metrics.ZMetricVolume{{Uuid: "vol-uuid", DisplayName: "vol", UsedBytes: 42}},
+	}))
+
+	expected := `
+# HELP eden_device_cpu_seconds_total Total CPU time consumed on the device
+# TYPE eden_device_cpu_seconds_total counter
+eden_device_cpu_seconds_total{device="dev"} 3
+# HELP eden_device_memory_used_bytes Memory used on the device
+# TYPE eden_device_memory_used_bytes gauge
+eden_device_memory_used_bytes{device="dev"} 2.097152e+06
+# HELP eden_app_network_receive_bytes_total Bytes received on the interface
+# TYPE eden_app_network_receive_bytes_total counter
+eden_app_network_receive_bytes_total{app="nginx",app_uuid="app-uuid",device="dev",interface="nbu1x1"} 100
+# HELP eden_volume_used_bytes Used space of the volume
+# TYPE eden_volume_used_bytes gauge
+eden_volume_used_bytes{device="dev",volume="vol",volume_uuid="vol-uuid"} 42
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"eden_device_cpu_seconds_total", "eden_device_memory_used_bytes",
+		"eden_app_network_receive_bytes_total", "eden_volume_used_bytes")
+	assert.NoError(t, err)
+}
+
+func TestCollectorDuplicateLabels(t *testing.T) {
+	t.Parallel()
+
+	collector := exporter.NewCollector()
+	collector.Update(&metrics.ZMetricMsg{
+		DevID: "dev",
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Disk: []*metrics.DiskMetric{
+				{Disk: "sda", MountPath: "/persist", Used: 1},
+				{Disk: "sda", MountPath: "/persist", Used: 2},
+			},
+			Network: []*metrics.NetworkMetric{
+				{IName: "eth0", RxBytes: 10},
+				{IName: "eth1", LocalName: "eth0", RxBytes: 20},
+			},
+		}},
+	})
+
+	expected := `
+# HELP eden_device_disk_used_bytes Used space of the disk of the device
+# TYPE eden_device_disk_used_bytes gauge
+eden_device_disk_used_bytes{device="dev",disk="sda",mount_path="/persist"} 1.048576e+06
+# HELP eden_device_network_receive_bytes_total Bytes received on the interface
+# TYPE eden_device_network_receive_bytes_total counter
+eden_device_network_receive_bytes_total{device="dev",interface="eth0"} 10
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"eden_device_disk_used_bytes", "eden_device_network_receive_bytes_total")
+	assert.NoError(t, err)
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/adam"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -36,6 +37,23 @@ func CloudPrepare() (Cloud, error) {
 	return ctx, nil
 }
 
+// ArchivePrepare is for init controller which serves objects from captured archive
+// without connection to Adam and obtain device from the archive
+func ArchivePrepare(archive *loaders.Archive) (Cloud, *device.Ctx, error) {
+	ctx := &CloudCtx{vars: &utils.ConfigVars{}, Controller: &adam.Ctx{}}
+	ctx.SetArchive(archive)
+	var deviceConfig config.EdgeDevConfig
+	if err := proto.Unmarshal(archive.Config, &deviceConfig); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal config from archive: %w", err)
+	}
+	dev, err := ctx.ConfigParse(&deviceConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("configParse: %w", err)
+	}
+	dev.SetState(device.Onboarded)
+	return ctx, dev, nil
+}
+
 // GetVars returns variables of controller
 func (cloud *CloudCtx) GetVars() *utils.ConfigVars {
 	return cloud.vars
@@ -48,6 +66,12 @@ func (cloud *CloudCtx) SetVars(vars *utils.ConfigVars) {
 
 // ResetDev to initial config in controller
 func (cloud *CloudCtx) ResetDev(node *device.Ctx) error {
+	cloud.ResetDevConfig(node)
+	return cloud.OnBoardDev(node)
+}
+
+// ResetDevConfig resets config of node to initial one without sending it to controller
+func (cloud *CloudCtx) ResetDevConfig(node *device.Ctx) {
 	vars := cloud.GetVars()
 	node.SetApplicationInstanceConfig(nil)
 	node.SetBaseOSConfig(nil)
@@ -62,7 +86,6 @@ func (cloud *CloudCtx) ResetDev(node *device.Ctx) error {
 	node.SetDevModel(vars.DevModel)
 	node.SetGlobalProfile("")
 	node.SetLocalProfileServer("")
-	return cloud.OnBoardDev(node)
 }
 
 // OnBoardDev in controller
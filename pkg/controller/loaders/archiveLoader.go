@@ -0,0 +1,365 @@
+package loaders
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/cachers"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/flowlog"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	archiveManifestFile = "capture.json"
+	archiveConfigFile   = "config"
+)
+
+// archiveDirs defines directories inside of archive to store objects of every type
+var archiveDirs = map[types.LoaderObjectType]string{
+	types.LogsType:    "logs",
+	types.InfoType:    "info",
+	types.MetricsType: "metrics",
+	types.FlowLogType: "flowlog",
+	types.RequestType: "requests",
+	types.AppsType:    "apps",
+}
+
+// archiveManifest describes the device captured into archive
+type archiveManifest struct {
+	DevUUID uuid.UUID `json:"devUUID"`
+	Created time.Time `json:"created"`
+}
+
+// Archive keeps objects of one device captured from controller
+type Archive struct {
+	DevUUID uuid.UUID
+	Created time.Time
+	Config  []byte
+	objects map[string][][]byte
+}
+
+// NewArchive creates empty archive for device
+func NewArchive(devUUID uuid.UUID) *Archive {
+	return &Archive{DevUUID: devUUID, Created: time.Now(), objects: map[string][][]byte{}}
+}
+
+func archiveDir(typeToProcess types.LoaderObjectType, appUUID uuid.UUID) string {
+	dir := archiveDirs[typeToProcess]
+	if typeToProcess == types.AppsType {
+		return path.Join(dir, appUUID.String())
+	}
+	return dir
+}
+
+// Add appends object of typeToProcess to the archive
+func (archive *Archive) Add(typeToProcess types.LoaderObjectType, appUUID uuid.UUID, data []byte) {
+	dir := archiveDir(typeToProcess, appUUID)
+	archive.objects[dir] = append(archive.objects[dir], append([]byte{}, data...))
+}
+
+// Count returns number of objects of typeToProcess inside the archive
+func (archive *Archive) Count(typeToProcess types.LoaderObjectType, appUUID uuid.UUID) int {
+	return len(archive.objects[archiveDir(typeToProcess, appUUID)])
+}
+
+// AppUUIDs returns UUIDs of apps with logs inside the archive
+func (archive *Archive) AppUUIDs() []uuid.UUID {
+	var appUUIDs []uuid.UUID
+	for dir := range archive.objects {
+		parent, name := path.Split(dir)
+		if path.Clean(parent) != archiveDirs[types.AppsType] {
+			continue
+		}
+		if appUUID, err := uuid.FromString(name); err == nil {
+			appUUIDs = append(appUUIDs, appUUID)
+		}
+	}
+	sort.Slice(appUUIDs, func(i, j int) bool {
+		return appUUIDs[i].String() < appUUIDs[j].String()
+	})
+	return appUUIDs
+}
+
+// Record adds to the archive all existing objects of typeToProcess obtained from loader
+func (archive *Archive) Record(loader Loader, typeToProcess types.LoaderObjectType, appUUID uuid.UUID) error {
+	loader = loader.Clone()
+	loader.SetUUID(archive.DevUUID)
+	loader.SetAppUUID(appUUID)
+	process := func(data []byte) (bool, error) {
+		archive.Add(typeToProcess, appUUID, data)
+		return true, nil
+	}
+	return loader.ProcessExisting(process, typeToProcess)
+}
+
+// Save writes archive into tar.gz file
+func (archive *Archive) Save(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	err = archive.write(tw)
+	// close writers in order to flush all data, keeping the first error
+	for _, c := range []io.Closer{tw, gz, f} {
+		if closeErr := c.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (archive *Archive) write(tw *tar.Writer) error {
+	writeFile := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: archive.Created,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	manifest, err := json.Marshal(archiveManifest{DevUUID: archive.DevUUID, Created: archive.Created})
+	if err != nil {
+		return err
+	}
+	if err = writeFile(archiveManifestFile, manifest); err != nil {
+		return err
+	}
+	if err = writeFile(archiveConfigFile, archive.Config); err != nil {
+		return err
+	}
+	for dir, objects := range archive.objects {
+		for i, data := range objects {
+			if err = writeFile(path.Join(dir, fmt.Sprintf("%08d", i)), data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// OpenArchive reads archive from tar.gz file created with Save
+func OpenArchive(file string) (*Archive, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read archive %s: %w", file, err)
+	}
+	defer gz.Close()
+	type indexedObject struct {
+		index int
+		data  []byte
+	}
+	indexed := map[string][]indexedObject{}
+	archive := &Archive{objects: map[string][][]byte{}}
+	manifestFound := false
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read archive %s: %w", file, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s from archive: %w", hdr.Name, err)
+		}
+		switch hdr.Name {
+		case archiveManifestFile:
+			var manifest archiveManifest
+			if err = json.Unmarshal(data, &manifest); err != nil {
+				return nil, fmt.Errorf("cannot parse %s: %w", archiveManifestFile, err)
+			}
+			archive.DevUUID = manifest.DevUUID
+			archive.Created = manifest.Created
+			manifestFound = true
+		case archiveConfigFile:
+			archive.Config = data
+		default:
+			dir, name := path.Split(hdr.Name)
+			index, err := strconv.Atoi(name)
+			if err != nil {
+				log.Warnf("skip unexpected file %s in archive", hdr.Name)
+				continue
+			}
+			dir = strings.TrimSuffix(dir, "/")
+			indexed[dir] = append(indexed[dir], indexedObject{index: index, data: data})
+		}
+	}
+	if !manifestFound {
+		return nil, fmt.Errorf("%s is not a capture archive: no %s inside", file, archiveManifestFile)
+	}
+	for dir, objects := range indexed {
+		sort.Slice(objects, func(i, j int) bool {
+			return objects[i].index < objects[j].index
+		})
+		for _, obj := range objects {
+			archive.objects[dir] = append(archive.objects[dir], obj.data)
+		}
+	}
+	return archive, nil
+}
+
+// ArchiveLoader implements read-only loader from captured Archive
+type ArchiveLoader struct {
+	archive   *Archive
+	appUUID   uuid.UUID
+	devUUID   uuid.UUID
+	timeRange types.TimeRange
+}
+
+// NewArchiveLoader return loader from archive
+func NewArchiveLoader(archive *Archive) *ArchiveLoader {
+	log.Debugf("NewArchiveLoader init")
+	return &ArchiveLoader{archive: archive}
+}
+
+// SetRemoteCache does nothing as archive is already a copy of objects
+func (loader *ArchiveLoader) SetRemoteCache(_ cachers.CacheProcessor) {
+}
+
+// SetTimeRange limits processing to objects with timestamps inside of the timeRange.
+// Objects without timestamp are not filtered.
+func (loader *ArchiveLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
+// objectTimestamp returns timestamp of the recorded object or zero time if there is none.
+// Objects are decoded in the format they are stored by the controller: info, metrics
+// and flow logs as binary protobuf, logs as protojson and requests as JSON.
+// Flow logs have no timestamp of the message, the start of the first flow
+// (or the time of the first DNS request) is used instead.
+func objectTimestamp(typeToProcess types.LoaderObjectType, data []byte) time.Time {
+	var ts *timestamppb.Timestamp
+	switch typeToProcess {
+	case types.InfoType:
+		var obj info.ZInfoMsg
+		if err := proto.Unmarshal(data, &obj); err != nil {
+			return time.Time{}
+		}
+		ts = obj.GetAtTimeStamp()
+	case types.MetricsType:
+		var obj metrics.ZMetricMsg
+		if err := proto.Unmarshal(data, &obj); err != nil {
+			return time.Time{}
+		}
+		ts = obj.GetAtTimeStamp()
+	case types.FlowLogType:
+		var obj flowlog.FlowMessage
+		if err := proto.Unmarshal(data, &obj); err != nil {
+			return time.Time{}
+		}
+		if flows := obj.GetFlows(); len(flows) > 0 {
+			ts = flows[0].GetStartTime()
+		} else if dnsReqs := obj.GetDnsReqs(); len(dnsReqs) > 0 {
+			ts = dnsReqs[0].GetRequestTime()
+		}
+	case types.LogsType, types.AppsType:
+		var obj logs.LogEntry
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, &obj); err != nil {
+			return time.Time{}
+		}
+		ts = obj.GetTimestamp()
+	case types.RequestType:
+		var obj types.APIRequest
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return time.Time{}
+		}
+		return obj.Timestamp
+	}
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// Clone create copy
+func (loader *ArchiveLoader) Clone() Loader {
+	return &ArchiveLoader{
+		archive:   loader.archive,
+		devUUID:   loader.devUUID,
+		appUUID:   loader.appUUID,
+		timeRange: loader.timeRange,
+	}
+}
+
+// SetUUID set device UUID
+func (loader *ArchiveLoader) SetUUID(devUUID uuid.UUID) {
+	loader.devUUID = devUUID
+}
+
+// SetAppUUID set app UUID
+func (loader *ArchiveLoader) SetAppUUID(appUUID uuid.UUID) {
+	loader.appUUID = appUUID
+}
+
+func (loader *ArchiveLoader) process(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	if !uuid.Equal(loader.devUUID, loader.archive.DevUUID) {
+		log.Debugf("archive does not contain objects of device %s", loader.devUUID)
+		return nil
+	}
+	for _, data := range loader.archive.objects[archiveDir(typeToProcess, loader.appUUID)] {
+		if !loader.timeRange.IsZero() {
+			if ts := objectTimestamp(typeToProcess, data); !ts.IsZero() && !loader.timeRange.Contains(ts) {
+				continue
+			}
+		}
+		doContinue, err := process(data)
+		if err != nil {
+			return err
+		}
+		if !doContinue {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ProcessExisting for observe objects in the order they were recorded
+func (loader *ArchiveLoader) ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	return loader.process(process, typeToProcess)
+}
+
+// ProcessStream does not replay recorded objects, ProcessExisting processes them.
+// No new objects may appear in the archive, so the stream is exhausted at once:
+// with zero timeout it returns without error, otherwise it waits for the timeout
+// as other loaders do when nothing new is received.
+func (loader *ArchiveLoader) ProcessStream(_ ProcessFunction, _ types.LoaderObjectType, timeoutSeconds time.Duration) error {
+	if timeoutSeconds == 0 {
+		return nil
+	}
+	time.Sleep(timeoutSeconds)
+	return fmt.Errorf("timeout")
+}
@@ -0,0 +1,181 @@
+package loaders_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/flowlog"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestArchiveSaveAndReplay(t *testing.T) {
+	t.Parallel()
+
+	devUUID := uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f")
+	appUUID := uuid.FromStringOrNil("dbd53bf1-d7f7-4f7a-ac27-fc0621be50ba")
+	archive := loaders.NewArchive(devUUID)
+	archive.Config = []byte("config")
+	for _, data := range []string{"first", "second", "third"} {
+		archive.Add(types.LogsType, uuid.Nil, []byte(data))
+	}
+	archive.Add(types.AppsType, appUUID, []byte("app"))
+
+	file := filepath.Join(t.TempDir(), "capture.tar.gz")
+	assert.NoError(t, archive.Save(file))
+
+	restored, err := loaders.OpenArchive(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, devUUID, restored.DevUUID)
+	assert.Equal(t, []byte("config"), restored.Config)
+	assert.Equal(t, 1, restored.Count(types.AppsType, appUUID))
+	assert.Equal(t, []uuid.UUID{appUUID}, restored.AppUUIDs())
+
+	loader := loaders.NewArchiveLoader(restored)
+	loader.SetUUID(devUUID)
+	var got []string
+	err = loader.ProcessExisting(func(data []byte) (bool, error) {
+		got = append(got, string(data))
+		return len(got) < 2, nil
+	}, types.LogsType)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, got)
+
+	// no objects for other devices
+	loader.SetUUID(appUUID)
+	err = loader.ProcessExisting(func(data []byte) (bool, error) {
+		t.Errorf("unexpected object %s", data)
+		return true, nil
+	}, types.LogsType)
+	assert.NoError(t, err)
+}
+
+func mustMarshal(t *testing.T, marshal func(proto.Message) ([]byte, error), msg proto.Message) []byte {
+	data, err := marshal(msg)
+	if err != nil {
+		t.Fatalf("failed to marshal %T: %v", msg, err)
+	}
+	return data
+}
+
+func TestArchiveTimeRange(t *testing.T) {
+	t.Parallel()
+
+	old := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	since := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	recent := time.Date(2024, 1, 1, 12, 0, 0, 500000000, time.UTC)
+
+	// objects are stored in the format used by the controller
+	objects := map[types.LoaderObjectType][][]byte{
+		types.LogsType: {
+			mustMarshal(t, protojson.Marshal, &logs.LogEntry{Content: "old", Timestamp: timestamppb.New(old)}),
+			mustMarshal(t, protojson.Marshal, &logs.LogEntry{Content: "new", Timestamp: timestamppb.New(recent)}),
+		},
+		types.InfoType: {
+			mustMarshal(t, proto.Marshal, &info.ZInfoMsg{DevId: "old", AtTimeStamp: timestamppb.New(old)}),
+			mustMarshal(t, proto.Marshal, &info.ZInfoMsg{DevId: "new", AtTimeStamp: timestamppb.New(recent)}),
+		},
+		types.MetricsType: {
+			mustMarshal(t, proto.Marshal, &metrics.ZMetricMsg{DevID: "old", AtTimeStamp: timestamppb.New(old)}),
+			mustMarshal(t, proto.Marshal, &metrics.ZMetricMsg{DevID: "new", AtTimeStamp: timestamppb.New(recent)}),
+		},
+		types.FlowLogType: {
+			mustMarshal(t, proto.Marshal, &flowlog.FlowMessage{DevId: "old",
+				Flows: []*flowlog.FlowRecord{{StartTime: timestamppb.New(old)}}}),
+			mustMarshal(t, proto.Marshal, &flowlog.FlowMessage{DevId: "new",
+				DnsReqs: []*flowlog.DnsRequest{{RequestTime: timestamppb.New(recent)}}}),
+			// no timestamp, not filtered
+			mustMarshal(t, proto.Marshal, &flowlog.FlowMessage{DevId: "none"}),
+		},
+	}
+	for _, ts := range []time.Time{old, recent} {
+		data, err := json.Marshal(types.APIRequest{Timestamp: ts, URL: ts.String()})
+		if !assert.NoError(t, err) {
+			return
+		}
+		objects[types.RequestType] = append(objects[types.RequestType], data)
+	}
+
+	devUUID := uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f")
+	archive := loaders.NewArchive(devUUID)
+	for objType, data := range objects {
+		for _, obj := range data {
+			archive.Add(objType, uuid.Nil, obj)
+		}
+	}
+	loader := loaders.NewArchiveLoader(archive)
+	loader.SetUUID(devUUID)
+	loader.SetTimeRange(types.TimeRange{Since: since})
+
+	tests := []struct {
+		objType  types.LoaderObjectType
+		expected [][]byte
+	}{
+		{objType: types.LogsType, expected: objects[types.LogsType][1:]},
+		{objType: types.InfoType, expected: objects[types.InfoType][1:]},
+		{objType: types.MetricsType, expected: objects[types.MetricsType][1:]},
+		{objType: types.FlowLogType, expected: objects[types.FlowLogType][1:]},
+		{objType: types.RequestType, expected: objects[types.RequestType][1:]},
+	}
+	for _, tt := range tests {
+		var got [][]byte
+		// time range is kept by Clone
+		err := loader.Clone().ProcessExisting(func(data []byte) (bool, error) {
+			got = append(got, data)
+			return true, nil
+		}, tt.objType)
+		if assert.NoError(t, err) {
+			assert.Equal(t, tt.expected, got, "objects of type %d", tt.objType)
+		}
+	}
+}
+
+func TestArchiveProcessedOnce(t *testing.T) {
+	t.Parallel()
+
+	devUUID := uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f")
+	archive := loaders.NewArchive(devUUID)
+	for i := 0; i < 3; i++ {
+		archive.Add(types.InfoType, uuid.Nil, mustMarshal(t, proto.Marshal,
+			&info.ZInfoMsg{Ztype: info.ZInfoTypes_ZiDevice, DevId: devUUID.String()}))
+	}
+	loader := loaders.NewArchiveLoader(archive)
+
+	var calls int
+	err := einfo.InfoChecker(loader, devUUID, nil, func(*info.ZInfoMsg) bool {
+		calls++
+		return false
+	}, einfo.InfoAny, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// stream has no new objects to process, it only waits for the timeout
+	calls = 0
+	err = einfo.InfoChecker(loader, devUUID, nil, func(*info.ZInfoMsg) bool {
+		calls++
+		return false
+	}, einfo.InfoNew, 100*time.Millisecond)
+	assert.EqualError(t, err, "timeout")
+	assert.Zero(t, calls)
+
+	// with zero timeout the exhausted stream returns instead of waiting forever
+	err = einfo.InfoChecker(loader, devUUID, nil, func(*info.ZInfoMsg) bool {
+		calls++
+		return false
+	}, einfo.InfoNew, 0)
+	assert.NoError(t, err)
+	assert.Zero(t, calls)
+}
@@ -14,8 +14,20 @@ type Loader interface {
 	ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error
 	ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error
 	SetRemoteCache(cache cachers.CacheProcessor)
+	SetTimeRange(timeRange types.TimeRange)
 	Clone() Loader
 }
 
 //ProcessFunction is prototype of processing function
 type ProcessFunction func(bytes []byte) (bool, error)
+
+// stopAtRangeEnd sends nil into done channel at the end of the time range
+// to stop processing of stream
+func stopAtRangeEnd(timeRange types.TimeRange, done chan error) {
+	if timeRange.Until.IsZero() {
+		return
+	}
+	time.AfterFunc(time.Until(timeRange.Until), func() {
+		done <- nil
+	})
+}
@@ -0,0 +1,8 @@
+package loaders
+
+// Exports of unexported helpers for tests in package loaders_test.
+
+// RangeIDs exports RedisLoader.rangeIDs.
+func (loader *RedisLoader) RangeIDs() (start, end string) {
+	return loader.rangeIDs()
+}
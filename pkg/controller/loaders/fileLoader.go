@@ -17,10 +17,11 @@ import (
 
 // FileLoader implements loader from file backend of controller
 type FileLoader struct {
-	appUUID uuid.UUID
-	devUUID uuid.UUID
-	getters types.DirGetters
-	cache   cachers.CacheProcessor
+	appUUID   uuid.UUID
+	devUUID   uuid.UUID
+	getters   types.DirGetters
+	cache     cachers.CacheProcessor
+	timeRange types.TimeRange
 }
 
 // NewFileLoader return loader from files
@@ -37,13 +38,19 @@ func (loader *FileLoader) SetRemoteCache(cache cachers.CacheProcessor) {
 // Clone create copy
 func (loader *FileLoader) Clone() Loader {
 	return &FileLoader{
-		getters: loader.getters,
-		devUUID: loader.devUUID,
-		appUUID: loader.appUUID,
-		cache:   loader.cache,
+		getters:   loader.getters,
+		devUUID:   loader.devUUID,
+		appUUID:   loader.appUUID,
+		cache:     loader.cache,
+		timeRange: loader.timeRange,
 	}
 }
 
+// SetTimeRange limits processing to files modified inside of the timeRange
+func (loader *FileLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
 func (loader *FileLoader) getFilePath(typeToProcess types.LoaderObjectType) string {
 	switch typeToProcess {
 	case types.LogsType:
@@ -95,6 +102,13 @@ func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess
 		if file.IsDir() {
 			continue
 		}
+		if !loader.timeRange.Until.IsZero() && file.ModTime().After(loader.timeRange.Until) {
+			continue
+		}
+		if !loader.timeRange.Since.IsZero() && file.ModTime().Before(loader.timeRange.Since) {
+			// files are sorted from the newest one, so all others are older
+			break
+		}
 		fileFullPath := path.Join(loader.getFilePath(typeToProcess), file.Name())
 		log.Debugf("local controller parse %s", fileFullPath)
 		data, err := os.ReadFile(fileFullPath)
@@ -132,6 +146,7 @@ func (loader *FileLoader) ProcessStream(process ProcessFunction, typeToProcess t
 			done <- fmt.Errorf("timeout")
 		})
 	}
+	stopAtRangeEnd(loader.timeRange, done)
 	go func() {
 		for {
 			select {
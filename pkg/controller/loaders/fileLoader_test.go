@@ -0,0 +1,70 @@
+package loaders_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLoaderTimeRange(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	// file name -> modification time
+	files := map[string]time.Time{
+		"1": base,
+		"2": base.Add(time.Minute),
+		"3": base.Add(2 * time.Minute),
+		"4": base.Add(3 * time.Minute),
+	}
+	for name, modTime := range files {
+		file := filepath.Join(dir, name)
+		if !assert.NoError(t, os.WriteFile(file, []byte(name), 0644)) ||
+			!assert.NoError(t, os.Chtimes(file, modTime, modTime)) {
+			return
+		}
+	}
+
+	tests := []struct {
+		name      string
+		timeRange types.TimeRange
+		expected  []string
+	}{
+		{name: "unlimited", expected: []string{"4", "3", "2", "1"}},
+		{name: "since", timeRange: types.TimeRange{Since: files["2"].Add(time.Second)},
+			expected: []string{"4", "3"}},
+		{name: "until", timeRange: types.TimeRange{Until: files["3"].Add(-time.Second)},
+			expected: []string{"2", "1"}},
+		{name: "closed range with boundaries at modification times",
+			timeRange: types.TimeRange{Since: files["2"], Until: files["3"]},
+			expected:  []string{"3", "2"}},
+		{name: "empty range between files",
+			timeRange: types.TimeRange{Since: files["2"].Add(time.Second), Until: files["3"].Add(-time.Second)}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			loader := loaders.NewFileLoader(types.DirGetters{
+				LogsGetter: func(uuid.UUID) string { return dir },
+			})
+			loader.SetTimeRange(tt.timeRange)
+			var processed []string
+			err := loader.ProcessExisting(func(data []byte) (bool, error) {
+				processed = append(processed, string(data))
+				return true, nil
+			}, types.LogsType)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.expected, processed)
+			}
+		})
+	}
+}
@@ -26,6 +26,7 @@ type RedisLoader struct {
 	cache         cachers.CacheProcessor
 	devUUID       uuid.UUID
 	appUUID       uuid.UUID
+	timeRange     types.TimeRange
 }
 
 // NewRedisLoader return loader from redis
@@ -55,9 +56,28 @@ func (loader *RedisLoader) Clone() Loader {
 		cache:         loader.cache,
 		devUUID:       loader.devUUID,
 		appUUID:       loader.appUUID,
+		timeRange:     loader.timeRange,
 	}
 }
 
+// SetTimeRange limits processing to stream entries with IDs inside of the timeRange
+func (loader *RedisLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
+// rangeIDs returns first and last stream IDs for XRANGE according to the timeRange.
+// Stream IDs of redis start with the milliseconds of the time of entry creation.
+func (loader *RedisLoader) rangeIDs() (start, end string) {
+	start, end = "-", "+"
+	if !loader.timeRange.Since.IsZero() {
+		start = strconv.FormatInt(loader.timeRange.Since.UnixMilli(), 10)
+	}
+	if !loader.timeRange.Until.IsZero() {
+		end = strconv.FormatInt(loader.timeRange.Until.UnixMilli(), 10)
+	}
+	return start, end
+}
+
 func (loader *RedisLoader) getStream(typeToProcess types.LoaderObjectType) string {
 	switch typeToProcess {
 	case types.LogsType:
@@ -91,9 +111,9 @@ func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.
 	OrderStream := loader.getStream(typeToProcess)
 	log.Debugf("XRead from %s", OrderStream)
 	if !stream {
-		start := "-"
+		start, end := loader.rangeIDs()
 		for {
-			rr, err := loader.client.XRangeN(context.Background(), OrderStream, start, "+", 10).Result()
+			rr, err := loader.client.XRangeN(context.Background(), OrderStream, start, end, 10).Result()
 			if err != nil {
 				return false, false, fmt.Errorf("XRange error: %s", err)
 			}
@@ -235,6 +255,7 @@ func (loader *RedisLoader) ProcessStream(process ProcessFunction, typeToProcess
 			done <- fmt.Errorf("timeout")
 		})
 	}
+	stopAtRangeEnd(loader.timeRange, done)
 
 	go func() {
 		done <- loader.repeatableConnection(process, typeToProcess, true)
@@ -0,0 +1,44 @@
+package loaders_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisLoaderRangeIDs(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	until := since.Add(time.Hour + 123*time.Millisecond + 999*time.Microsecond)
+
+	tests := []struct {
+		name      string
+		timeRange types.TimeRange
+		start     string
+		end       string
+	}{
+		{name: "unlimited", start: "-", end: "+"},
+		{name: "since only", timeRange: types.TimeRange{Since: since}, start: "1682935200000", end: "+"},
+		{name: "until only", timeRange: types.TimeRange{Until: until}, start: "-", end: "1682938800123"},
+		{name: "closed range", timeRange: types.TimeRange{Since: since, Until: until},
+			start: "1682935200000", end: "1682938800123"},
+		{name: "single millisecond", timeRange: types.TimeRange{Since: since, Until: since},
+			start: "1682935200000", end: "1682935200000"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			loader := loaders.NewRedisLoader("", "", 0, types.StreamGetters{})
+			loader.SetTimeRange(tt.timeRange)
+			start, end := loader.RangeIDs()
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+}
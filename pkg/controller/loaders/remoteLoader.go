@@ -39,6 +39,7 @@ type RemoteLoader struct {
 	getClient    getClient
 	client       *http.Client
 	cache        cachers.CacheProcessor
+	timeRange    types.TimeRange
 }
 
 // NewRemoteLoader return loader from files
@@ -69,9 +70,15 @@ func (loader *RemoteLoader) Clone() Loader {
 		appUUID:      loader.appUUID,
 		client:       loader.getClient(),
 		cache:        loader.cache,
+		timeRange:    loader.timeRange,
 	}
 }
 
+// SetTimeRange limits processing to objects with timestamps inside of the timeRange
+func (loader *RemoteLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
 func (loader *RemoteLoader) getURL(typeToProcess types.LoaderObjectType) string {
 	switch typeToProcess {
 	case types.LogsType:
@@ -103,6 +110,7 @@ func (loader *RemoteLoader) SetAppUUID(appUUID uuid.UUID) {
 
 func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, tocontinue bool, err error) {
 	var buf []byte
+	var timestamp *timestamppb.Timestamp
 	switch typeToProcess {
 	case types.LogsType:
 		var emp logs.LogBundle
@@ -114,6 +122,7 @@ func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFu
 		if buf, err = protojson.Marshal(&emp); err != nil {
 			return false, false, err
 		}
+		timestamp = emp.GetTimestamp()
 	case types.InfoType:
 		var emp info.ZInfoMsg
 		if err := decoder.Decode(&emp); err == io.EOF {
@@ -124,6 +133,7 @@ func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFu
 		if buf, err = protojson.Marshal(&emp); err != nil {
 			return false, false, err
 		}
+		timestamp = emp.GetAtTimeStamp()
 	}
 	if loader.cache != nil {
 		if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, buf); err != nil {
@@ -134,6 +144,11 @@ func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFu
 		loader.curCount++
 		return false, true, nil
 	}
+	if timestamp != nil && !loader.timeRange.Contains(timestamp.AsTime()) {
+		loader.curCount++
+		loader.lastCount = loader.curCount
+		return false, true, nil
+	}
 	tocontinue, err = process(buf)
 	if stream {
 		time.Sleep(1 * time.Second) //wait for load all data from buffer
@@ -230,6 +245,7 @@ func (loader *RemoteLoader) ProcessStream(process ProcessFunction, typeToProcess
 			done <- fmt.Errorf("timeout")
 		})
 	}
+	stopAtRangeEnd(loader.timeRange, done)
 
 	go func() {
 		done <- loader.repeatableConnection(process, typeToProcess, true)
@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/cachers"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+// objectKey identifies list of objects of one type
+type objectKey struct {
+	devUUID       uuid.UUID
+	appUUID       uuid.UUID
+	typeToProcess types.LoaderObjectType
+}
+
+// object is stored message with the time of receiving
+type object struct {
+	received time.Time
+	data     []byte
+}
+
+// subscription delivers new objects to ProcessStream
+type subscription struct {
+	ch   chan object
+	done chan struct{}
+}
+
+// storage keeps objects and notifies subscribers about new ones
+type storage struct {
+	mu          sync.Mutex
+	objects     map[objectKey][]object
+	subscribers map[objectKey][]*subscription
+}
+
+func newStorage() *storage {
+	return &storage{
+		objects:     map[objectKey][]object{},
+		subscribers: map[objectKey][]*subscription{},
+	}
+}
+
+func (s *storage) add(key objectKey, data []byte) {
+	s.mu.Lock()
+	obj := object{received: time.Now(), data: append([]byte{}, data...)}
+	s.objects[key] = append(s.objects[key], obj)
+	subscribers := append([]*subscription{}, s.subscribers[key]...)
+	s.mu.Unlock()
+	// send outside of the lock to allow handlers to add objects
+	for _, sub := range subscribers {
+		select {
+		case sub.ch <- obj:
+		case <-sub.done:
+		}
+	}
+}
+
+func (s *storage) list(key objectKey) []object {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]object{}, s.objects[key]...)
+}
+
+func (s *storage) remove(devUUID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.objects {
+		if uuid.Equal(key.devUUID, devUUID) {
+			delete(s.objects, key)
+		}
+	}
+}
+
+// subscribe returns channel to receive new objects and function to cancel subscription
+func (s *storage) subscribe(key objectKey) (<-chan object, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// buffered channel allows adding of objects while subscriber is busy with processing
+	sub := &subscription{ch: make(chan object, 100), done: make(chan struct{})}
+	s.subscribers[key] = append(s.subscribers[key], sub)
+	return sub.ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		close(sub.done)
+		subscribers := s.subscribers[key]
+		for i, el := range subscribers {
+			if el == sub {
+				s.subscribers[key] = append(subscribers[:i:i], subscribers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// loader implements loaders.Loader on top of storage
+type loader struct {
+	storage   *storage
+	devUUID   uuid.UUID
+	appUUID   uuid.UUID
+	timeRange types.TimeRange
+}
+
+// SetRemoteCache does nothing as objects are already in memory
+func (l *loader) SetRemoteCache(_ cachers.CacheProcessor) {
+}
+
+// SetTimeRange limits processing to objects received inside of the timeRange
+func (l *loader) SetTimeRange(timeRange types.TimeRange) {
+	l.timeRange = timeRange
+}
+
+// Clone create copy
+func (l *loader) Clone() loaders.Loader {
+	return &loader{
+		storage:   l.storage,
+		devUUID:   l.devUUID,
+		appUUID:   l.appUUID,
+		timeRange: l.timeRange,
+	}
+}
+
+// SetUUID set device UUID
+func (l *loader) SetUUID(devUUID uuid.UUID) {
+	l.devUUID = devUUID
+}
+
+// SetAppUUID set app UUID
+func (l *loader) SetAppUUID(appUUID uuid.UUID) {
+	l.appUUID = appUUID
+}
+
+func (l *loader) key(typeToProcess types.LoaderObjectType) objectKey {
+	key := objectKey{devUUID: l.devUUID, typeToProcess: typeToProcess}
+	if typeToProcess == types.AppsType {
+		key.appUUID = l.appUUID
+	}
+	return key
+}
+
+// ProcessExisting for observe existing objects from the oldest one
+func (l *loader) ProcessExisting(process loaders.ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	for _, obj := range l.storage.list(l.key(typeToProcess)) {
+		if !l.timeRange.Contains(obj.received) {
+			continue
+		}
+		doContinue, err := process(obj.data)
+		if err != nil {
+			return err
+		}
+		if !doContinue {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ProcessStream for observe new objects
+func (l *loader) ProcessStream(process loaders.ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error {
+	ch, cancel := l.storage.subscribe(l.key(typeToProcess))
+	defer cancel()
+	var timeout <-chan time.Time
+	if timeoutSeconds != 0 {
+		timeout = time.After(timeoutSeconds)
+	}
+	var rangeEnd <-chan time.Time
+	if !l.timeRange.Until.IsZero() {
+		rangeEnd = time.After(time.Until(l.timeRange.Until))
+	}
+	for {
+		select {
+		case obj := <-ch:
+			doContinue, err := process(obj.data)
+			if err != nil {
+				return err
+			}
+			if !doContinue {
+				return nil
+			}
+		case <-timeout:
+			return fmt.Errorf("timeout")
+		case <-rangeEnd:
+			return nil
+		}
+	}
+}
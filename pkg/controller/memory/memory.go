@@ -0,0 +1,499 @@
+// Package memory implements in-process stand-in of Adam controller which keeps
+// everything in memory. It allows to test logic built on top of controller.Controller
+// without running of Adam and EVE: tests set config with ConfigSet and inject
+// info, logs and metrics with Publish* methods to run through the same handlers.
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/eapps"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/flowlog"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Ctx stores state of in-memory controller
+type Ctx struct {
+	mu            sync.Mutex
+	dir           string
+	configs       map[uuid.UUID][]byte
+	devices       map[uuid.UUID]*types.DeviceCert
+	onboards      map[uuid.UUID][]byte
+	deviceOptions map[uuid.UUID]*types.DeviceOptions
+	ecdhCerts     map[uuid.UUID][]byte
+	globalOptions *types.GlobalOptions
+	signingCert   []byte
+	timeRange     types.TimeRange
+	storage       *storage
+}
+
+// New creates empty in-memory controller
+func New() *Ctx {
+	return &Ctx{
+		configs:       map[uuid.UUID][]byte{},
+		devices:       map[uuid.UUID]*types.DeviceCert{},
+		onboards:      map[uuid.UUID][]byte{},
+		deviceOptions: map[uuid.UUID]*types.DeviceOptions{},
+		ecdhCerts:     map[uuid.UUID][]byte{},
+		globalOptions: &types.GlobalOptions{},
+		storage:       newStorage(),
+	}
+}
+
+// getLoader return loader of objects from memory
+func (ctx *Ctx) getLoader() loaders.Loader {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return &loader{storage: ctx.storage, timeRange: ctx.timeRange}
+}
+
+// Publish stores object of typeToProcess as if it was received from device
+func (ctx *Ctx) Publish(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) {
+	key := objectKey{devUUID: devUUID, typeToProcess: typeToProcess}
+	if typeToProcess == types.AppsType {
+		key.appUUID = appUUID
+	}
+	ctx.storage.add(key, data)
+}
+
+// PublishInfo stores info message from device
+func (ctx *Ctx) PublishInfo(devUUID uuid.UUID, im *info.ZInfoMsg) error {
+	data, err := proto.Marshal(im)
+	if err != nil {
+		return err
+	}
+	ctx.Publish(devUUID, uuid.Nil, types.InfoType, data)
+	return nil
+}
+
+// PublishMetric stores metric message from device
+func (ctx *Ctx) PublishMetric(devUUID uuid.UUID, mm *metrics.ZMetricMsg) error {
+	data, err := proto.Marshal(mm)
+	if err != nil {
+		return err
+	}
+	ctx.Publish(devUUID, uuid.Nil, types.MetricsType, data)
+	return nil
+}
+
+// PublishLog stores log entry from device
+func (ctx *Ctx) PublishLog(devUUID uuid.UUID, le *logs.LogEntry) error {
+	data, err := protojson.Marshal(le)
+	if err != nil {
+		return err
+	}
+	ctx.Publish(devUUID, uuid.Nil, types.LogsType, data)
+	return nil
+}
+
+// PublishAppLog stores log entry of app from device
+func (ctx *Ctx) PublishAppLog(devUUID uuid.UUID, appUUID uuid.UUID, le *logs.LogEntry) error {
+	data, err := protojson.Marshal(le)
+	if err != nil {
+		return err
+	}
+	ctx.Publish(devUUID, appUUID, types.AppsType, data)
+	return nil
+}
+
+// PublishFlowLog stores flow message from device
+func (ctx *Ctx) PublishFlowLog(devUUID uuid.UUID, fm *flowlog.FlowMessage) error {
+	data, err := proto.Marshal(fm)
+	if err != nil {
+		return err
+	}
+	ctx.Publish(devUUID, uuid.Nil, types.FlowLogType, data)
+	return nil
+}
+
+// PublishRequest stores request of device to controller
+func (ctx *Ctx) PublishRequest(devUUID uuid.UUID, req *types.APIRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	ctx.Publish(devUUID, uuid.Nil, types.RequestType, data)
+	return nil
+}
+
+// SetECDHCert sets certificate for ECDH exchange of device
+func (ctx *Ctx) SetECDHCert(devUUID uuid.UUID, cert []byte) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.ecdhCerts[devUUID] = cert
+}
+
+// SetSigningCert sets signing certificate of controller
+func (ctx *Ctx) SetSigningCert(cert []byte) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.signingCert = cert
+}
+
+// InitWithVars use variables from viper for init controller
+func (ctx *Ctx) InitWithVars(vars *utils.ConfigVars) error {
+	ctx.dir = vars.AdamDir
+	return nil
+}
+
+// GetDir return dir
+func (ctx *Ctx) GetDir() (dir string) {
+	return ctx.dir
+}
+
+// Register onboards device immediately as there is no EVE to wait for
+func (ctx *Ctx) Register(device *device.Ctx) error {
+	b, err := os.ReadFile(device.GetOnboardKey())
+	if err != nil {
+		return err
+	}
+	cert, err := utils.ParseFirstCertFromBlock(b)
+	if err != nil {
+		return err
+	}
+	onboardUUID, err := uuid.FromString(cert.Subject.CommonName)
+	if err != nil {
+		return err
+	}
+	devUUID := device.GetID()
+	if devUUID == uuid.Nil {
+		if devUUID, err = uuid.NewV4(); err != nil {
+			return err
+		}
+		device.SetID(devUUID)
+	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.onboards[onboardUUID] = b
+	ctx.devices[devUUID] = &types.DeviceCert{Onboard: b, Serial: device.GetSerial()}
+	return nil
+}
+
+// DeviceList return device list
+func (ctx *Ctx) DeviceList(filter types.DeviceStateFilter) (out []string, err error) {
+	if filter != types.RegisteredDeviceFilter && filter != types.AllDevicesFilter {
+		return []string{}, nil
+	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	out = []string{}
+	for devUUID := range ctx.devices {
+		out = append(out, devUUID.String())
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// ConfigSet set config for devID
+func (ctx *Ctx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.configs[devUUID] = append([]byte{}, devConfig...)
+	return nil
+}
+
+// ConfigGet get config for devID
+func (ctx *Ctx) ConfigGet(devUUID uuid.UUID) (out string, err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	devConfig, ok := ctx.configs[devUUID]
+	if !ok {
+		return "", fmt.Errorf("no config for device %s", devUUID)
+	}
+	return string(devConfig), nil
+}
+
+// SetTimeRange limits logs, info, metrics and requests processed by checkers to the timeRange
+func (ctx *Ctx) SetTimeRange(timeRange types.TimeRange) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.timeRange = timeRange
+}
+
+// SetArchive loads config and objects of device and its apps from the archive into memory
+func (ctx *Ctx) SetArchive(archive *loaders.Archive) {
+	if len(archive.Config) > 0 {
+		_ = ctx.ConfigSet(archive.DevUUID, archive.Config)
+	}
+	archiveLoader := loaders.NewArchiveLoader(archive)
+	archiveLoader.SetUUID(archive.DevUUID)
+	for _, objType := range []types.LoaderObjectType{types.LogsType, types.InfoType, types.MetricsType,
+		types.FlowLogType, types.RequestType} {
+		objType := objType
+		_ = archiveLoader.ProcessExisting(func(data []byte) (bool, error) {
+			ctx.Publish(archive.DevUUID, uuid.Nil, objType, data)
+			return true, nil
+		}, objType)
+	}
+	for _, appUUID := range archive.AppUUIDs() {
+		appUUID := appUUID
+		archiveLoader.SetAppUUID(appUUID)
+		_ = archiveLoader.ProcessExisting(func(data []byte) (bool, error) {
+			ctx.Publish(archive.DevUUID, appUUID, types.AppsType, data)
+			return true, nil
+		}, types.AppsType)
+	}
+}
+
+// CaptureRecord captures config and all objects of the device and its apps kept in memory
+func (ctx *Ctx) CaptureRecord(devUUID uuid.UUID, appUUIDs []uuid.UUID) (*loaders.Archive, error) {
+	archive := loaders.NewArchive(devUUID)
+	devConfig, err := ctx.ConfigGet(devUUID)
+	if err != nil {
+		return nil, err
+	}
+	archive.Config = []byte(devConfig)
+	loader := ctx.getLoader()
+	for _, objType := range []types.LoaderObjectType{types.LogsType, types.InfoType, types.MetricsType,
+		types.FlowLogType, types.RequestType} {
+		if err := archive.Record(loader, objType, uuid.Nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, appUUID := range appUUIDs {
+		if err := archive.Record(loader, types.AppsType, appUUID); err != nil {
+			return nil, err
+		}
+	}
+	return archive, nil
+}
+
+// GetECDHCert get cert for ECDH exchange for devID
+func (ctx *Ctx) GetECDHCert(devUUID uuid.UUID) ([]byte, error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	cert, ok := ctx.ecdhCerts[devUUID]
+	if !ok {
+		return nil, fmt.Errorf("no DEVICE_ECDH_EXCHANGE certificate")
+	}
+	return cert, nil
+}
+
+// SigningCertGet gets signing certificate
+func (ctx *Ctx) SigningCertGet() (signCert []byte, err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.signingCert == nil {
+		return nil, fmt.Errorf("no signing certificate found")
+	}
+	return ctx.signingCert, nil
+}
+
+// RequestLastCallback check request by pattern from existing objects with callback
+func (ctx *Ctx) RequestLastCallback(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return erequest.RequestLast(loader, q, handler)
+}
+
+// LogAppsChecker check app logs by pattern from existing objects with LogLast and wait for new ones with timeout
+func (ctx *Ctx) LogAppsChecker(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc, mode eapps.LogCheckerMode, timeout time.Duration) (err error) {
+	return eapps.LogChecker(ctx.getLoader(), devUUID, appUUID, q, handler, mode, timeout)
+}
+
+// LogAppsLastCallback check app logs by pattern from existing objects with callback
+func (ctx *Ctx) LogAppsLastCallback(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	loader.SetAppUUID(appUUID)
+	return eapps.LogLast(loader, q, handler)
+}
+
+// LogChecker check logs by pattern from existing objects with LogLast and wait for new ones with timeout
+func (ctx *Ctx) LogChecker(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc, mode elog.LogCheckerMode, timeout time.Duration) (err error) {
+	return elog.LogChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// LogLastCallback check logs by pattern from existing objects with callback
+func (ctx *Ctx) LogLastCallback(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return elog.LogLast(loader, q, handler)
+}
+
+// FlowLogChecker check FlowLogs by pattern from existing objects with FlowLogLast and wait for new ones with timeout
+func (ctx *Ctx) FlowLogChecker(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc, mode eflowlog.FlowLogCheckerMode, timeout time.Duration) (err error) {
+	return eflowlog.FlowLogChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// FlowLogLastCallback check FlowLogs by pattern from existing objects with callback
+func (ctx *Ctx) FlowLogLastCallback(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return eflowlog.FlowLogLast(loader, q, handler)
+}
+
+// InfoChecker checks info by pattern from existing objects (mode=einfo.InfoExist), new ones (mode=einfo.InfoNew) or any of them (mode=einfo.InfoAny) with timeout.
+func (ctx *Ctx) InfoChecker(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc, mode einfo.InfoCheckerMode, timeout time.Duration) (err error) {
+	return einfo.InfoChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// InfoLastCallback check info by pattern from existing objects with callback
+func (ctx *Ctx) InfoLastCallback(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return einfo.InfoLast(loader, q, handler)
+}
+
+// MetricChecker check metrics by pattern from existing objects with MetricLast and wait for new ones with timeout
+func (ctx *Ctx) MetricChecker(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc, mode emetric.MetricCheckerMode, timeout time.Duration) (err error) {
+	return emetric.MetricChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// MetricLastCallback check metrics by pattern from existing objects with callback
+func (ctx *Ctx) MetricLastCallback(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return emetric.MetricLast(loader, q, handler)
+}
+
+// OnboardRemove remove onboard by onboardUUID
+func (ctx *Ctx) OnboardRemove(onboardUUID string) (err error) {
+	id, err := uuid.FromString(onboardUUID)
+	if err != nil {
+		return err
+	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if _, ok := ctx.onboards[id]; !ok {
+		return fmt.Errorf("onboard %s not found", onboardUUID)
+	}
+	delete(ctx.onboards, id)
+	return nil
+}
+
+// DeviceRemove remove device by devUUID
+func (ctx *Ctx) DeviceRemove(devUUID uuid.UUID) (err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if _, ok := ctx.devices[devUUID]; !ok {
+		return fmt.Errorf("device %s not found", devUUID)
+	}
+	delete(ctx.devices, devUUID)
+	delete(ctx.configs, devUUID)
+	delete(ctx.deviceOptions, devUUID)
+	ctx.storage.remove(devUUID)
+	return nil
+}
+
+// DeviceGetOnboard get device onboardUUID for devUUID
+func (ctx *Ctx) DeviceGetOnboard(devUUID uuid.UUID) (onboardUUID uuid.UUID, err error) {
+	ctx.mu.Lock()
+	devCert, ok := ctx.devices[devUUID]
+	ctx.mu.Unlock()
+	if !ok {
+		return uuid.Nil, fmt.Errorf("device %s not found", devUUID)
+	}
+	cert, err := utils.ParseFirstCertFromBlock(devCert.Onboard)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.FromString(cert.Subject.CommonName)
+}
+
+// DeviceGetByOnboard try to get device by onboard eveCert
+func (ctx *Ctx) DeviceGetByOnboard(eveCert string) (devUUID uuid.UUID, err error) {
+	b, err := os.ReadFile(eveCert)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	cert, err := utils.ParseFirstCertFromBlock(b)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return ctx.DeviceGetByOnboardUUID(cert.Subject.CommonName)
+}
+
+// DeviceGetByOnboardUUID try to get device by onboard uuid
+func (ctx *Ctx) DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID, err error) {
+	devIDs, err := ctx.DeviceList(types.RegisteredDeviceFilter)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, devID := range devIDs {
+		devUUID, err := uuid.FromString(devID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if id, err := ctx.DeviceGetOnboard(devUUID); err == nil && id.String() == onboardUUID {
+			return devUUID, nil
+		}
+	}
+	return uuid.Nil, fmt.Errorf("no device found")
+}
+
+// GetDeviceCert gets deviceCert contains certificates and serial
+func (ctx *Ctx) GetDeviceCert(device *device.Ctx) (deviceCert *types.DeviceCert, err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	devCert, ok := ctx.devices[device.GetID()]
+	if !ok {
+		return nil, fmt.Errorf("device %s not found", device.GetID())
+	}
+	return devCert, nil
+}
+
+// UploadDeviceCert registers device with deviceCert under new UUID
+func (ctx *Ctx) UploadDeviceCert(deviceCert types.DeviceCert) error {
+	devUUID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.devices[devUUID] = &deviceCert
+	return nil
+}
+
+// SetDeviceOptions sets options for provided devUUID
+func (ctx *Ctx) SetDeviceOptions(devUUID uuid.UUID, options *types.DeviceOptions) error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.deviceOptions[devUUID] = options
+	return nil
+}
+
+// GetDeviceOptions returns DeviceOptions for provided devUUID
+func (ctx *Ctx) GetDeviceOptions(devUUID uuid.UUID) (*types.DeviceOptions, error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	options, ok := ctx.deviceOptions[devUUID]
+	if !ok {
+		return &types.DeviceOptions{}, nil
+	}
+	return options, nil
+}
+
+// SetGlobalOptions sets global options for controller
+func (ctx *Ctx) SetGlobalOptions(options *types.GlobalOptions) error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.globalOptions = options
+	return nil
+}
+
+// GetGlobalOptions returns global options from controller
+func (ctx *Ctx) GetGlobalOptions() (*types.GlobalOptions, error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return ctx.globalOptions, nil
+}
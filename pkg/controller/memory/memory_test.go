@@ -0,0 +1,96 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/memory"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var devUUID = uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f")
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	ctrl := &controller.CloudCtx{Controller: memory.New()}
+	_, err := ctrl.ConfigGet(devUUID)
+	assert.Error(t, err)
+
+	dev := device.CreateEdgeNode()
+	dev.SetID(devUUID)
+	dev.SetConfigItem("timer.config.interval", "5")
+	devConfig, err := ctrl.GetConfigBytes(dev, false)
+	assert.NoError(t, err)
+	assert.NoError(t, ctrl.ConfigSet(devUUID, devConfig))
+	out, err := ctrl.ConfigGet(devUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(devConfig), out)
+}
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	ctrl := memory.New()
+	assert.NoError(t, ctrl.PublishLog(devUUID, &logs.LogEntry{Severity: "error", Content: "existing"}))
+
+	var found []string
+	assert.NoError(t, ctrl.LogLastCallback(devUUID, map[string]string{"severity": "error"}, func(le *elog.FullLogEntry) bool {
+		found = append(found, le.Content)
+		return false
+	}))
+	assert.Equal(t, []string{"existing"}, found)
+
+	done := make(chan error)
+	go func() {
+		done <- ctrl.InfoChecker(devUUID, map[string]string{"$query": "ztype = ZiApp"}, func(im *info.ZInfoMsg) bool {
+			return true
+		}, einfo.InfoNew, 5*time.Second)
+	}()
+	// checker subscribes asynchronously, so publish until it gets the message
+	assert.NoError(t, ctrl.PublishInfo(devUUID, &info.ZInfoMsg{Ztype: info.ZInfoTypes_ZiDevice}))
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, ctrl.PublishInfo(devUUID, &info.ZInfoMsg{Ztype: info.ZInfoTypes_ZiApp}))
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSetArchive(t *testing.T) {
+	t.Parallel()
+
+	appUUID := uuid.FromStringOrNil("dbd53bf1-d7f7-4f7a-ac27-fc0621be50ba")
+	recorded := memory.New()
+	assert.NoError(t, recorded.ConfigSet(devUUID, []byte("config")))
+	assert.NoError(t, recorded.PublishLog(devUUID, &logs.LogEntry{Content: "device"}))
+	assert.NoError(t, recorded.PublishAppLog(devUUID, appUUID, &logs.LogEntry{Content: "app"}))
+	archive, err := recorded.CaptureRecord(devUUID, []uuid.UUID{appUUID})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctrl := memory.New()
+	ctrl.SetArchive(archive)
+	out, err := ctrl.ConfigGet(devUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "config", out)
+
+	var found []string
+	assert.NoError(t, ctrl.LogAppsLastCallback(devUUID, appUUID, map[string]string{}, func(le *logs.LogEntry) bool {
+		found = append(found, le.Content)
+		return false
+	}))
+	assert.Equal(t, []string{"app"}, found)
+}
@@ -0,0 +1,222 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// severityLevels defines ordering of log severities
+var severityLevels = map[string]int{
+	"trace":     0,
+	"debug":     1,
+	"info":      2,
+	"notice":    3,
+	"warn":      4,
+	"warning":   4,
+	"err":       5,
+	"error":     5,
+	"crit":      6,
+	"critical":  6,
+	"alert":     7,
+	"fatal":     8,
+	"emerg":     9,
+	"emergency": 9,
+	"panic":     9,
+}
+
+// timeLayouts are accepted for comparison with timestamps
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// valueString returns string representation of value found in the object
+func valueString(inp reflect.Value) string {
+	if !inp.IsValid() || !inp.CanInterface() {
+		return ""
+	}
+	if b, ok := inp.Interface().([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(inp)
+}
+
+// parseTime parses absolute time or time relative to now, e.g. now-10m
+func parseTime(s string) (time.Time, error) {
+	if strings.HasPrefix(strings.ToLower(s), "now") {
+		rest := s[len("now"):]
+		if rest == "" {
+			return time.Now(), nil
+		}
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Now().Add(d), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time %q", s)
+}
+
+func cmpInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compare compares value found in the object with the literal from query.
+// It returns false as second value if they cannot be compared.
+func compare(inp reflect.Value, literal string) (int, bool) {
+	if !inp.IsValid() || !inp.CanInterface() {
+		return 0, false
+	}
+	if inp.Kind() == reflect.Struct {
+		// lookup returns messages by value, use pointer to reach their methods
+		ptr := reflect.New(inp.Type())
+		ptr.Elem().Set(inp)
+		inp = ptr
+	}
+	switch v := inp.Interface().(type) {
+	case *timestamppb.Timestamp:
+		t, err := parseTime(literal)
+		if err != nil || v == nil {
+			return 0, false
+		}
+		return v.AsTime().Compare(t), true
+	case time.Time:
+		t, err := parseTime(literal)
+		if err != nil {
+			return 0, false
+		}
+		return v.Compare(t), true
+	case protoreflect.Enum:
+		values := v.Descriptor().Values()
+		if n, err := strconv.ParseInt(literal, 10, 32); err == nil {
+			return cmpInt(int64(v.Number()), n), true
+		}
+		for i := 0; i < values.Len(); i++ {
+			if strings.EqualFold(string(values.Get(i).Name()), literal) {
+				return cmpInt(int64(v.Number()), int64(values.Get(i).Number())), true
+			}
+		}
+		return 0, false
+	}
+	switch inp.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return 0, false
+		}
+		return cmpFloat(float64(inp.Int()), f), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return 0, false
+		}
+		return cmpFloat(float64(inp.Uint()), f), true
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return 0, false
+		}
+		return cmpFloat(inp.Float(), f), true
+	case reflect.Bool:
+		b, err := strconv.ParseBool(literal)
+		if err != nil {
+			return 0, false
+		}
+		if inp.Bool() == b {
+			return 0, true
+		}
+		if b {
+			return -1, true
+		}
+		return 1, true
+	}
+	s := valueString(inp)
+	if a, ok := severityLevels[strings.ToLower(s)]; ok {
+		if b, ok := severityLevels[strings.ToLower(literal)]; ok {
+			return cmpInt(int64(a), int64(b)), true
+		}
+	}
+	if a, err := strconv.ParseFloat(s, 64); err == nil {
+		if b, err := strconv.ParseFloat(literal, 64); err == nil {
+			return cmpFloat(a, b), true
+		}
+	}
+	return strings.Compare(s, literal), true
+}
+
+// equal checks equality of value and literal. Strings are compared as is,
+// other types are compared by the value the literal is converted into.
+func equal(inp reflect.Value, literal string) bool {
+	if valueString(inp) == literal {
+		return true
+	}
+	if inp.Kind() == reflect.String {
+		return false
+	}
+	res, ok := compare(inp, literal)
+	return ok && res == 0
+}
+
+func (t *Term) matchValue(inp reflect.Value) bool {
+	switch t.Op {
+	case OpMatch:
+		return t.re.MatchString(valueString(inp))
+	case OpNotMatch:
+		return !t.re.MatchString(valueString(inp))
+	case OpEq:
+		return equal(inp, t.Values[0])
+	case OpNotEq:
+		return !equal(inp, t.Values[0])
+	case OpIn:
+		for _, v := range t.Values {
+			if equal(inp, v) {
+				return true
+			}
+		}
+		return false
+	}
+	res, ok := compare(inp, t.Values[0])
+	if !ok {
+		return false
+	}
+	switch t.Op {
+	case OpLess:
+		return res < 0
+	case OpLessEq:
+		return res <= 0
+	case OpGreater:
+		return res > 0
+	case OpGreatEq:
+		return res >= 0
+	}
+	return false
+}
@@ -0,0 +1,354 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+	tokLegacy
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	field string // for tokLegacy only
+	pos   int
+}
+
+// legacyRe matches beginning of the 'field:regexp' form
+var legacyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\[\]]*:`)
+
+const specialChars = "()=!<>~,\"'"
+
+type lexer struct {
+	input  string
+	pos    int
+	inList bool
+	tokens []token
+}
+
+// legacyAllowed returns false if the field:regexp form cannot start
+// at the current position, e.g. right after operator or inside of in list
+func (l *lexer) legacyAllowed() bool {
+	if l.inList {
+		return false
+	}
+	return len(l.tokens) == 0 || l.tokens[len(l.tokens)-1].kind != tokOp
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("query: position %d: %s", l.pos, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) readQuoted() (string, error) {
+	quote := l.input[l.pos]
+	end := l.pos + 1
+	for end < len(l.input) && l.input[end] != quote {
+		if l.input[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end >= len(l.input) {
+		return "", l.errorf("unterminated string")
+	}
+	raw := l.input[l.pos : end+1]
+	l.pos = end + 1
+	if quote == '\'' {
+		return raw[1 : len(raw)-1], nil
+	}
+	s, err := strconv.Unquote(raw)
+	if err != nil {
+		return "", l.errorf("bad string %s: %s", raw, err)
+	}
+	return s, nil
+}
+
+func (l *lexer) readWord(stop func(r rune) bool) string {
+	start := l.pos
+	for l.pos < len(l.input) {
+		r := rune(l.input[l.pos])
+		if unicode.IsSpace(r) || stop(r) {
+			break
+		}
+		l.pos++
+	}
+	return l.input[start:l.pos]
+}
+
+func (l *lexer) run() error {
+	for {
+		for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+			l.pos++
+		}
+		if l.pos >= len(l.input) {
+			l.tokens = append(l.tokens, token{kind: tokEOF, pos: l.pos})
+			return nil
+		}
+		start := l.pos
+		rest := l.input[l.pos:]
+		if m := legacyRe.FindString(rest); m != "" && l.legacyAllowed() {
+			l.pos += len(m)
+			var value string
+			if l.pos < len(l.input) && (l.input[l.pos] == '"' || l.input[l.pos] == '\'') {
+				s, err := l.readQuoted()
+				if err != nil {
+					return err
+				}
+				value = s
+			} else {
+				value = l.readWord(func(rune) bool { return false })
+			}
+			l.tokens = append(l.tokens, token{kind: tokLegacy, field: strings.TrimSuffix(m, ":"), value: value, pos: start})
+			continue
+		}
+		switch c := l.input[l.pos]; c {
+		case '(':
+			l.pos++
+			if n := len(l.tokens); n > 0 && isKeyword(l.tokens[n-1], "in") {
+				l.inList = true
+			}
+			l.tokens = append(l.tokens, token{kind: tokLParen, value: "(", pos: start})
+		case ')':
+			l.pos++
+			l.inList = false
+			l.tokens = append(l.tokens, token{kind: tokRParen, value: ")", pos: start})
+		case ',':
+			l.pos++
+			l.tokens = append(l.tokens, token{kind: tokComma, value: ",", pos: start})
+		case '"', '\'':
+			s, err := l.readQuoted()
+			if err != nil {
+				return err
+			}
+			l.tokens = append(l.tokens, token{kind: tokString, value: s, pos: start})
+		case '=', '!', '<', '>', '~':
+			op := string(c)
+			l.pos++
+			if l.pos < len(l.input) {
+				next := l.input[l.pos]
+				switch {
+				case c == '=' && next == '=':
+					l.pos++
+				case c == '!' && (next == '=' || next == '~'):
+					op += string(next)
+					l.pos++
+				case (c == '<' || c == '>') && next == '=':
+					op += string(next)
+					l.pos++
+				}
+			}
+			l.tokens = append(l.tokens, token{kind: tokOp, value: op, pos: start})
+		default:
+			word := l.readWord(func(r rune) bool { return strings.ContainsRune(specialChars, r) })
+			l.tokens = append(l.tokens, token{kind: tokWord, value: word, pos: start})
+		}
+	}
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("query: position %d: %s", t.pos, fmt.Sprintf(format, args...))
+}
+
+func isKeyword(t token, keyword string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.value, keyword)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	result := Or{left}
+	for isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, right)
+	}
+	if len(result) == 1 {
+		return left, nil
+	}
+	return result, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	result := And{left}
+	for {
+		t := p.peek()
+		if t.kind == tokEOF || t.kind == tokRParen || isKeyword(t, "or") {
+			break
+		}
+		if isKeyword(t, "and") {
+			p.next()
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, right)
+	}
+	if len(result) == 1 {
+		return left, nil
+	}
+	return result, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	t := p.peek()
+	if isKeyword(t, "not") || (t.kind == tokOp && t.value == "!") {
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parseValue() (string, error) {
+	t := p.next()
+	if t.kind != tokWord && t.kind != tokString {
+		return "", p.errorf(t, "expected value, got %q", t.value)
+	}
+	return t.value, nil
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected ')'")
+		}
+		return expr, nil
+	case tokLegacy:
+		term, err := NewTerm(t.field, OpMatch, t.value)
+		if err != nil {
+			return nil, p.errorf(t, "%s", err)
+		}
+		return term, nil
+	case tokWord:
+	default:
+		return nil, p.errorf(t, "expected field name, got %q", t.value)
+	}
+	field := t.value
+	opToken := p.next()
+	if isKeyword(opToken, "in") {
+		if lp := p.next(); lp.kind != tokLParen {
+			return nil, p.errorf(lp, "expected '(' after in")
+		}
+		var values []string
+		for {
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			sep := p.next()
+			if sep.kind == tokRParen {
+				break
+			}
+			if sep.kind != tokComma {
+				return nil, p.errorf(sep, "expected ',' or ')'")
+			}
+		}
+		return NewTerm(field, OpIn, values...)
+	}
+	if opToken.kind != tokOp || opToken.value == "!" {
+		return nil, p.errorf(opToken, "expected operator after %s", field)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	term, err := NewTerm(field, Operator(opToken.value), value)
+	if err != nil {
+		return nil, p.errorf(opToken, "%s", err)
+	}
+	return term, nil
+}
+
+// Parse parses the expression. Empty string produces expression
+// which matches everything.
+func Parse(s string) (Expr, error) {
+	l := &lexer{input: s}
+	if err := l.run(); err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: l.tokens}
+	if p.peek().kind == tokEOF {
+		return And{}, nil
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %q", t.value)
+	}
+	return expr, nil
+}
+
+// JoinArgs joins command-line arguments into single expression.
+// Arguments in the legacy 'field:regexp' form are quoted
+// to keep spaces and special characters of regexp.
+func JoinArgs(args []string) string {
+	var parts []string
+	for _, arg := range args {
+		if m := legacyRe.FindString(arg); m != "" {
+			value := strings.TrimPrefix(arg, m)
+			if strings.IndexFunc(value, unicode.IsSpace) >= 0 || strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "'") {
+				arg = m + strconv.Quote(value)
+			}
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseArgs parses expression from command-line arguments
+func ParseArgs(args []string) (Expr, error) {
+	return Parse(JoinArgs(args))
+}
@@ -0,0 +1,226 @@
+// Package query provides a small expression language used to filter
+// logs, info, metrics and flow logs received from the controller.
+//
+// Expression consists of terms combined with boolean operators:
+//
+//	severity >= warning and not source = newlogd
+//	(dm.memory.usedPercentage > 80 or dm.cpuMetric.total > 1000) and atTimeStamp > now-10m
+//	ztype in (ZiApp, ZiDevice) content ~ "failed.*"
+//
+// Supported comparison operators are '=', '!=', '~' (regexp match), '!~',
+// '<', '<=', '>', '>=' and 'in (...)'. Terms written one after another
+// are combined with 'and'. The legacy form 'field:regexp' is accepted as
+// a shorthand for 'field ~ regexp'.
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Key is the key of the query map which holds the whole expression
+// instead of single field:regexp pair
+const Key = "$query"
+
+// Operator of comparison term
+type Operator string
+
+// Supported operators
+const (
+	OpEq       Operator = "="
+	OpNotEq    Operator = "!="
+	OpMatch    Operator = "~"
+	OpNotMatch Operator = "!~"
+	OpLess     Operator = "<"
+	OpLessEq   Operator = "<="
+	OpGreater  Operator = ">"
+	OpGreatEq  Operator = ">="
+	OpIn       Operator = "in"
+)
+
+// Expr is a parsed query expression
+type Expr interface {
+	// Match returns true if the object satisfies the expression
+	Match(obj interface{}) bool
+	String() string
+}
+
+// And is a conjunction of expressions
+type And []Expr
+
+// Or is a disjunction of expressions
+type Or []Expr
+
+// Not negates expression
+type Not struct {
+	Expr Expr
+}
+
+// Term compares values found by Field path with Values
+type Term struct {
+	Field  string
+	Op     Operator
+	Values []string
+	re     *regexp.Regexp
+}
+
+// Match returns true if all expressions match
+func (e And) Match(obj interface{}) bool {
+	for _, el := range e {
+		if !el.Match(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e And) String() string {
+	return joinExpr(e, " and ")
+}
+
+// Match returns true if any of expressions matches
+func (e Or) Match(obj interface{}) bool {
+	for _, el := range e {
+		if el.Match(obj) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Or) String() string {
+	return joinExpr(e, " or ")
+}
+
+// Match returns true if underlying expression does not match
+func (e *Not) Match(obj interface{}) bool {
+	return !e.Expr.Match(obj)
+}
+
+func (e *Not) String() string {
+	return fmt.Sprintf("not %s", e.Expr)
+}
+
+// Match returns true if any value found by the Field path satisfies the term
+func (t *Term) Match(obj interface{}) bool {
+	matched := false
+	clb := func(inp reflect.Value) {
+		if matched {
+			return
+		}
+		matched = t.matchValue(inp)
+	}
+	utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(obj)).Interface(), FieldPath(t.Field), clb)
+	return matched
+}
+
+func (t *Term) String() string {
+	if t.Op == OpIn {
+		var values []string
+		for _, v := range t.Values {
+			values = append(values, strconv.Quote(v))
+		}
+		return fmt.Sprintf("%s in (%s)", t.Field, strings.Join(values, ", "))
+	}
+	return fmt.Sprintf("%s %s %s", t.Field, t.Op, strconv.Quote(t.Values[0]))
+}
+
+func joinExpr(exprs []Expr, sep string) string {
+	var parts []string
+	for _, el := range exprs {
+		switch el.(type) {
+		case And, Or:
+			parts = append(parts, fmt.Sprintf("(%s)", el))
+		default:
+			parts = append(parts, el.String())
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// NewTerm creates term and checks its values
+func NewTerm(field string, op Operator, values ...string) (*Term, error) {
+	if field == "" {
+		return nil, fmt.Errorf("empty field name")
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values for field %s", field)
+	}
+	t := &Term{Field: field, Op: op, Values: values}
+	switch op {
+	case OpMatch, OpNotMatch:
+		re, err := regexp.Compile(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("bad regexp for field %s: %w", field, err)
+		}
+		t.re = re
+	case OpEq, OpNotEq, OpLess, OpLessEq, OpGreater, OpGreatEq, OpIn:
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+	return t, nil
+}
+
+// FieldPath converts the dotted path from the query into the path
+// of the structure fields by uppercasing the first letter of each element
+func FieldPath(field string) string {
+	var n []string
+	caser := cases.Title(language.English, cases.NoLower)
+	for _, pathElement := range strings.Split(field, ".") {
+		n = append(n, caser.String(pathElement))
+	}
+	return strings.Join(n, ".")
+}
+
+// Walk calls fn for every term of the expression
+func Walk(expr Expr, fn func(t *Term)) {
+	switch e := expr.(type) {
+	case And:
+		for _, el := range e {
+			Walk(el, fn)
+		}
+	case Or:
+		for _, el := range e {
+			Walk(el, fn)
+		}
+	case *Not:
+		Walk(e.Expr, fn)
+	case *Term:
+		fn(e)
+	}
+}
+
+// FromMap builds expression from the query map. Every field:regexp pair
+// of the map is ANDed with others and with the expression stored under Key.
+func FromMap(q map[string]string) (Expr, error) {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var result And
+	for _, k := range keys {
+		if k == Key {
+			expr, err := Parse(q[k])
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, expr)
+			continue
+		}
+		t, err := NewTerm(k, OpMatch, q[k])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package query_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/query"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	entry := &logs.LogEntry{
+		Severity:  "warning",
+		Source:    "zedagent",
+		Content:   "failed to publish info",
+		Msgid:     42,
+		Timestamp: timestamppb.New(time.Now().Add(-5 * time.Minute)),
+	}
+
+	testMatrix := map[string]bool{
+		"":                                        true,
+		"severity:warn":                           true,
+		"severity:error":                          false,
+		"severity >= warning":                     true,
+		"severity > warning":                      false,
+		"severity < error and source = zedagent":  true,
+		"source = newlogd or msgid > 40":          true,
+		"not source = zedagent":                   false,
+		"source in (newlogd, zedagent)":           true,
+		"source in (newlogd, nim)":                false,
+		"content ~ \"failed.*info\"":              true,
+		"content !~ failed":                       false,
+		"msgid >= 42 msgid <= 42":                 true,
+		"(msgid = 1 or msgid = 42) and !msgid=42": false,
+		"timestamp > now-10m":                     true,
+		"timestamp > now-1m":                      false,
+		"timestamp < 2000-01-01":                  false,
+		"content:\"to publish\"":                  true,
+		"unknown = value":                         false,
+	}
+
+	for q, expected := range testMatrix {
+		expr, err := query.Parse(q)
+		if !assert.NoError(t, err, q) {
+			continue
+		}
+		assert.Equal(t, expected, expr.Match(entry), q)
+	}
+}
+
+func TestMatchEnum(t *testing.T) {
+	t.Parallel()
+
+	im := &info.ZInfoMsg{Ztype: info.ZInfoTypes_ZiApp, DevId: "dev:1"}
+
+	testMatrix := map[string]bool{
+		"ztype = ZiApp":              true,
+		"ztype = ziapp":              true,
+		"ztype in (ZiDevice, ZiApp)": true,
+		"ztype != ZiApp":             false,
+		"devId:dev:1":                true,
+		"devId = \"dev:1\"":          true,
+	}
+
+	for q, expected := range testMatrix {
+		expr, err := query.Parse(q)
+		if !assert.NoError(t, err, q) {
+			continue
+		}
+		assert.Equal(t, expected, expr.Match(im), q)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, q := range []string{
+		"severity",
+		"severity >",
+		"(severity = error",
+		"source in (a, b",
+		"content ~ \"(\"",
+		"source = \"unterminated",
+	} {
+		_, err := query.Parse(q)
+		assert.Error(t, err, q)
+	}
+}
+
+func TestFromMapAndArgs(t *testing.T) {
+	t.Parallel()
+
+	entry := &logs.LogEntry{Severity: "error", Content: "some error here"}
+
+	expr, err := query.FromMap(map[string]string{
+		"severity": "err",
+		query.Key:  "content ~ here",
+	})
+	assert.NoError(t, err)
+	assert.True(t, expr.Match(entry))
+
+	expr, err = query.ParseArgs([]string{"content:some error", "or", "severity=info"})
+	assert.NoError(t, err)
+	assert.True(t, expr.Match(entry))
+}
@@ -123,6 +123,58 @@ var AppsType LoaderObjectType = 5
 // FlowLogType for observe FlowMessages
 var FlowLogType LoaderObjectType = 6
 
+// TimeRange limits objects processed by loaders to ones received inside of the range.
+// Zero Since or Until means that the range is not limited from the corresponding side.
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// IsZero returns true if the range is not limited
+func (tr TimeRange) IsZero() bool {
+	return tr.Since.IsZero() && tr.Until.IsZero()
+}
+
+// Contains returns true if t is inside of the range
+func (tr TimeRange) Contains(t time.Time) bool {
+	if !tr.Since.IsZero() && t.Before(tr.Since) {
+		return false
+	}
+	if !tr.Until.IsZero() && t.After(tr.Until) {
+		return false
+	}
+	return true
+}
+
+// ParseTimeRange parses since and until values from command line.
+// Every value may be a timestamp in RFC3339 format (2006-01-02T15:04:05Z07:00)
+// or a duration relative to the current time (e.g. 10m for 10 minutes ago).
+// Empty value means no limit.
+func ParseTimeRange(since, until string) (TimeRange, error) {
+	var tr TimeRange
+	var err error
+	if tr.Since, err = parseTimePoint(since); err != nil {
+		return tr, fmt.Errorf("cannot parse since: %w", err)
+	}
+	if tr.Until, err = parseTimePoint(until); err != nil {
+		return tr, fmt.Errorf("cannot parse until: %w", err)
+	}
+	if !tr.Since.IsZero() && !tr.Until.IsZero() && tr.Until.Before(tr.Since) {
+		return tr, fmt.Errorf("until (%s) is before since (%s)", tr.Until, tr.Since)
+	}
+	return tr, nil
+}
+
+func parseTimePoint(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
 // APIRequest stores information about requests from EVE
 type APIRequest struct {
 	Timestamp time.Time `json:"timestamp"`
@@ -0,0 +1,135 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	until := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		since     string
+		until     string
+		expected  types.TimeRange
+		expectErr string
+	}{
+		{
+			name: "unlimited",
+		},
+		{
+			name:     "since only",
+			since:    "2023-05-01T10:00:00Z",
+			expected: types.TimeRange{Since: since},
+		},
+		{
+			name:     "until only",
+			until:    "2023-05-01T12:00:00Z",
+			expected: types.TimeRange{Until: until},
+		},
+		{
+			name:     "closed range",
+			since:    "2023-05-01T10:00:00Z",
+			until:    "2023-05-01T14:00:00+02:00",
+			expected: types.TimeRange{Since: since, Until: until},
+		},
+		{
+			name:     "single point",
+			since:    "2023-05-01T10:00:00Z",
+			until:    "2023-05-01T10:00:00Z",
+			expected: types.TimeRange{Since: since, Until: since},
+		},
+		{
+			name:      "invalid since",
+			since:     "yesterday",
+			expectErr: "cannot parse since",
+		},
+		{
+			name:      "invalid until",
+			until:     "2023-05-01 12:00:00",
+			expectErr: "cannot parse until",
+		},
+		{
+			name:      "since after until",
+			since:     "2023-05-01T12:00:00Z",
+			until:     "2023-05-01T10:00:00Z",
+			expectErr: "is before since",
+		},
+		{
+			name:      "relative since after relative until",
+			since:     "5m",
+			until:     "10m",
+			expectErr: "is before since",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tr, err := types.ParseTimeRange(tt.since, tt.until)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.True(t, tt.expected.Since.Equal(tr.Since), "since %s, expected %s", tr.Since, tt.expected.Since)
+				assert.True(t, tt.expected.Until.Equal(tr.Until), "until %s, expected %s", tr.Until, tt.expected.Until)
+			}
+		})
+	}
+}
+
+func TestParseTimeRangeRelative(t *testing.T) {
+	t.Parallel()
+
+	before := time.Now()
+	tr, err := types.ParseTimeRange("1h", "30m")
+	after := time.Now()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.WithinRange(t, tr.Since, before.Add(-time.Hour), after.Add(-time.Hour))
+	assert.WithinRange(t, tr.Until, before.Add(-30*time.Minute), after.Add(-30*time.Minute))
+}
+
+func TestTimeRangeContains(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	until := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		tr       types.TimeRange
+		time     time.Time
+		expected bool
+	}{
+		{name: "unlimited", time: since, expected: true},
+		{name: "inside", tr: types.TimeRange{Since: since, Until: until}, time: since.Add(time.Hour), expected: true},
+		{name: "at since", tr: types.TimeRange{Since: since, Until: until}, time: since, expected: true},
+		{name: "at until", tr: types.TimeRange{Since: since, Until: until}, time: until, expected: true},
+		{name: "just before since", tr: types.TimeRange{Since: since, Until: until}, time: since.Add(-time.Nanosecond)},
+		{name: "just after until", tr: types.TimeRange{Since: since, Until: until}, time: until.Add(time.Nanosecond)},
+		{name: "open end", tr: types.TimeRange{Since: since}, time: until.AddDate(1, 0, 0), expected: true},
+		{name: "open start", tr: types.TimeRange{Until: until}, time: since.AddDate(-1, 0, 0), expected: true},
+		{name: "before open end", tr: types.TimeRange{Since: since}, time: since.Add(-time.Second)},
+		{name: "after open start", tr: types.TimeRange{Until: until}, time: until.Add(time.Second)},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, tt.tr.Contains(tt.time))
+			assert.Equal(t, tt.tr.Since.IsZero() && tt.tr.Until.IsZero(), tt.tr.IsZero())
+		})
+	}
+}
@@ -45,6 +45,7 @@ const (
 	DefaultTelnetPort           = 17777
 	DefaultQemuMonitorPort      = 7788
 	DefaultQemuNetdevSocketPort = 7790
+	DefaultEveInstancePortStep  = 100
 	DefaultSSHPort              = 2222
 	DefaultEVEHost              = "127.0.0.1"
 	DefaultRedisHost            = "localhost"
@@ -108,6 +109,7 @@ const (
 	DefaultAdamContainerName     = "eden_adam"
 	DefaultRegistryContainerName = "eden_registry"
 	DefaultEServerContainerName  = "eden_eserver"
+	DefaultSdnContainerName      = "eden_sdn"
 	DefaultDockerNetworkName     = "eden_network"
 	DefaultLogLevelToPrint       = log.InfoLevel
 	DefaultX509Country           = "RU"
@@ -149,6 +151,8 @@ const (
 
 	DefaultGeneralModel = "general"
 
+	DefaultSimModel = "sim"
+
 	DefaultEVERemote = false
 
 	DefaultEVEImageSize = 8192
@@ -195,12 +199,16 @@ const (
 	DefaultPerfScriptEVELocation = "/persist/perf.script.out"
 	DefaultHWEVELocation         = "/persist/lshw.out"
 
+	DefaultCollectInfoEVEScript   = "collect-info.sh"
+	DefaultCollectInfoEVELocation = "/persist/eve-info"
+
 	//defaults for SDN
 	DefaultSdnTelnetPort = 6623
 	DefaultSdnSSHPort    = 6622
 	DefaultSdnMgmtPort   = 6666
 	DefaultSdnCpus       = 2
 	DefaultSdnMemory     = 2048
+	DefaultSdnRunner     = "vm"
 )
 
 var (
@@ -181,6 +181,12 @@ eve:
     #additional disks count
     disks: {{parse "eve.disks"}}
 
+    #additional EVE instances connected to the same Eden-SDN and controller (selected with --eve-instance)
+    #certs, serial, ports and files of every instance are derived from the config above unless overridden
+    #(for example {"eve2":{"serial":"27182818","telnet-port":17877,"monitor-port":7888}})
+    #port-offset of instance is allocated and stored here on the first use of the instance
+    instances: {{ parsemap "eve.instances" }}
+
     #configuration specific to QEMU-emulated device
     qemu:
         #port for QEMU Monitor
@@ -278,6 +284,10 @@ sdn:
     #disable SDN
     disable: '{{parse "sdn.disable"}}'
 
+    #how to run SDN: "vm" (QEMU VM) or "container" (privileged docker container,
+    #connected with EVE VM using tap interfaces, requires root privileges)
+    runner: '{{parse "sdn.runner"}}'
+
     #directory with SDN source code
     source-dir: '{{parse "sdn.source-dir"}}'
 
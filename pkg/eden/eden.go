@@ -919,8 +919,10 @@ func StopSDN(devModel, sdnPidFile string) {
 		return
 	}
 	sdnConfig := edensdn.SdnVMConfig{
-		PidFile: sdnPidFile,
-		// Nothing else needed to stop the VM.
+		PidFile:        sdnPidFile,
+		Runner:         viper.GetString("sdn.runner"),
+		NetDevBasePort: uint16(viper.GetInt("eve.qemu.netdev-socket-port")),
+		// Nothing else needed to stop SDN.
 	}
 	sdnVmRunner, err := edensdn.GetSdnVMRunner(defaults.DefaultQemuModel, sdnConfig)
 	if err != nil {
@@ -74,7 +74,7 @@ func startQMPLogger(qmpSockFile string, qmpLogFile string) error {
 func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstaller bool,
 	qemuSMBIOSSerial string, eveTelnetPort, qemuMonitorPort, netDevBasePort int,
 	qemuHostFwd map[string]string, qemuAccel bool, qemuConfigFile, logFile, pidFile string,
-	netModel sdnapi.NetworkModel, withSDN bool, tapInterface, usbImagePath string,
+	netModel sdnapi.NetworkModel, withSDN bool, sdnRunner, eveInstance, tapInterface, usbImagePath string,
 	swtpm, foreground bool) (err error) {
 	var qemuCommand, qemuOptions string
 	qemuOptions += "-nodefaults -no-user-config "
@@ -121,15 +121,25 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 		qemuOptions += fmt.Sprintf("-monitor tcp:localhost:%d,server,nowait  ", qemuMonitorPort)
 	}
 
+	ethCount := len(netModel.Ports)
 	if withSDN {
-		// Ports connecting SDN VM with EVE VM.
-		socketPort := netDevBasePort
-		for i, port := range netModel.Ports {
-			qemuOptions += fmt.Sprintf("-netdev socket,id=eth%d,connect=:%d", i, socketPort)
+		// Ports connecting SDN with EVE VM.
+		// SDN VM listens on a separate socket port for every port of the network model,
+		// SDN container is connected with a separate tap interface instead.
+		// EVE instance connects only to those ports which are assigned to it.
+		instancePorts := edensdn.EVEInstancePorts(netModel, eveInstance)
+		if len(instancePorts) == 0 {
+			return fmt.Errorf("StartEVEQemu: no ports of the network model are connected to EVE instance %q",
+				eveInstance)
+		}
+		for i, portIdx := range instancePorts {
+			port := netModel.Ports[portIdx]
+			qemuOptions += fmt.Sprintf("-netdev %s,id=eth%d",
+				edensdn.EVEPortNetDev(sdnRunner, uint16(netDevBasePort), portIdx), i)
 			qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d,mac=%s ", netDev, i,
 				port.EVEConnect.MAC)
-			socketPort++
 		}
+		ethCount = len(instancePorts)
 	} else {
 		// Use SLIRP networking to connect QEMU VM with the host.
 		nets, err := utils.GetSubnetsNotUsed(1)
@@ -169,7 +179,7 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 	}
 
 	if tapInterface != "" {
-		tapIdx := len(netModel.Ports)
+		tapIdx := ethCount
 		qemuOptions += fmt.Sprintf("-netdev tap,id=eth%d,ifname=%s", tapIdx, tapInterface)
 		qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d ", netDev, tapIdx)
 	}
@@ -0,0 +1,27 @@
+package eden
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// StartEVESim function runs simulator of EVE in background with the provided config
+func StartEVESim(configName, logFile, pidFile string) (err error) {
+	edenProg, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot obtain executable path: %w", err)
+	}
+	return utils.RunCommandNohup(edenProg, logFile, pidFile, "eve", "simulate", "--config", configName)
+}
+
+// StopEVESim function stops simulator of EVE
+func StopEVESim(pidFile string) (err error) {
+	return utils.StopCommandWithPid(pidFile)
+}
+
+// StatusEVESim function get status of simulator of EVE
+func StatusEVESim(pidFile string) (status string, err error) {
+	return utils.StatusCommandWithPid(pidFile)
+}
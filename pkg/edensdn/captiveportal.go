@@ -0,0 +1,42 @@
+package edensdn
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	model "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// CaptivePortalLogin : log client (EVE) with the given IP address into the captive
+// portal of the network. With empty clientIP, all clients of the network are logged in.
+func (client *SdnClient) CaptivePortalLogin(network, clientIP string) (
+	status model.CaptivePortalStatus, err error) {
+	return client.updateCaptivePortal(network, "login", clientIP)
+}
+
+// CaptivePortalLogout : log client (EVE) with the given IP address out of the captive
+// portal of the network. With empty clientIP, all clients of the network are logged out.
+func (client *SdnClient) CaptivePortalLogout(network, clientIP string) (
+	status model.CaptivePortalStatus, err error) {
+	return client.updateCaptivePortal(network, "logout", clientIP)
+}
+
+// ListCaptivePortals : get status of all networks with captive portal.
+func (client *SdnClient) ListCaptivePortals() (statuses []model.CaptivePortalStatus, err error) {
+	err = client.doJSONRequest(http.MethodGet, "/captive-portals.json", nil, &statuses)
+	return
+}
+
+func (client *SdnClient) updateCaptivePortal(network, op, clientIP string) (
+	status model.CaptivePortalStatus, err error) {
+	body, err := json.Marshal(model.CaptivePortalLogin{ClientIP: clientIP})
+	if err != nil {
+		err = fmt.Errorf("failed to marshal captive portal %s request: %w", op, err)
+		return
+	}
+	err = client.doJSONRequest(http.MethodPut,
+		fmt.Sprintf("/captive-portal/%s/%s", url.PathEscape(network), op), body, &status)
+	return
+}
@@ -0,0 +1,64 @@
+package edensdn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	model "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// StartCapture : start packet capture on a port, bond, bridge, network or endpoint
+// with the given logical label.
+func (client *SdnClient) StartCapture(label string,
+	captureReq model.CaptureRequest) (status model.CaptureStatus, err error) {
+	body, err := json.Marshal(captureReq)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal capture request: %w", err)
+		return
+	}
+	err = client.doJSONRequest(http.MethodPut, "/capture/"+url.PathEscape(label),
+		body, &status)
+	return
+}
+
+// StopCapture : stop packet capture running for the given logical label.
+// Returns the final capture status.
+func (client *SdnClient) StopCapture(label string) (status model.CaptureStatus, err error) {
+	err = client.doJSONRequest(http.MethodDelete, "/capture/"+url.PathEscape(label),
+		nil, &status)
+	return
+}
+
+// ListCaptures : get status of all packet captures (running or finished).
+func (client *SdnClient) ListCaptures() (statuses []model.CaptureStatus, err error) {
+	err = client.doJSONRequest(http.MethodGet, "/captures.json", nil, &statuses)
+	return
+}
+
+// GetCapture : download packets captured so far for the given logical label
+// (in the pcap format).
+func (client *SdnClient) GetCapture(label string, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("http://localhost:%d/capture/%s.pcap", client.MgmtPort,
+			url.PathEscape(label)), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build HTTP request: %w", err)
+	}
+	httpClient := &http.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to GET packet capture failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to GET packet capture failed with code=%d, "+
+			"response: %s", resp.StatusCode, readResponse(resp))
+	}
+	if _, err = io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read retrieved packet capture: %w", err)
+	}
+	return nil
+}
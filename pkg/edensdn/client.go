@@ -30,6 +30,9 @@ type SdnClient struct {
 	SSHPort    uint16
 	SSHKeyPath string
 	MgmtPort   uint16
+	// EVEInstance : EVE instance whose interfaces are referenced by GetEveIf* methods.
+	// Empty value refers to the default EVE instance.
+	EVEInstance string
 }
 
 // GetNetworkModel : get network model currently applied to Eden-SDN.
@@ -272,12 +275,13 @@ func (client *SdnClient) GetEveIfMAC(eveIfName string) (mac string, err error) {
 	if err != nil {
 		return
 	}
-	if eveIfIndex < 0 || eveIfIndex >= len(netModel.Ports) {
+	ports := EVEInstancePorts(netModel, client.EVEInstance)
+	if eveIfIndex < 0 || eveIfIndex >= len(ports) {
 		err = fmt.Errorf("EVE interface index is out-of-range: %d <%d-%d)",
-			eveIfIndex, 0, len(netModel.Ports))
+			eveIfIndex, 0, len(ports))
 		return
 	}
-	return netModel.Ports[eveIfIndex].EVEConnect.MAC, nil
+	return netModel.Ports[ports[eveIfIndex]].EVEConnect.MAC, nil
 }
 
 // GetEveIfIP : get IP address assigned to the given EVE interface.
@@ -307,3 +311,43 @@ func (client *SdnClient) getEveIfIndex(eveIfName string) (eveIfIndex int, err er
 	}
 	return
 }
+
+// doJSONRequest sends request to the SDN management agent and unmarshals
+// JSON-encoded response into respObj.
+func (client *SdnClient) doJSONRequest(method, path string, body []byte,
+	respObj interface{}) error {
+	req, err := http.NewRequest(method,
+		fmt.Sprintf("http://localhost:%d%s", client.MgmtPort, path), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build HTTP request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := &http.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s %s failed with code=%d, response: %s",
+			method, path, resp.StatusCode, readResponse(resp))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response to %s %s: %w", method, path, err)
+	}
+	if err = json.Unmarshal(data, respObj); err != nil {
+		return fmt.Errorf("failed to unmarshal response to %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func readResponse(resp *http.Response) string {
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Sprintf("failed to read response: %v", err)
+	}
+	return string(bytes.TrimSpace(respBytes))
+}
@@ -0,0 +1,51 @@
+package edensdn
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	model "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// ConntrackFilter : filter for connections listed by ListConntrack.
+// Empty attributes match any connection.
+type ConntrackFilter struct {
+	// Label : logical label of a network or endpoint to list connections
+	// tracked inside its network namespace. Without label, connections tracked
+	// by the firewall are listed.
+	Label string
+	// Proto : L4 protocol name (tcp, udp, icmp, ...).
+	Proto string
+	// Src : source IP address or subnet (original direction).
+	Src string
+	// Dst : destination IP address or subnet (original direction).
+	Dst string
+	// Port : source or destination port (original direction).
+	Port uint16
+}
+
+// ListConntrack : list connections tracked by netfilter inside Eden-SDN.
+func (client *SdnClient) ListConntrack(filter ConntrackFilter) (
+	entries []model.ConntrackEntry, err error) {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"label": filter.Label,
+		"proto": filter.Proto,
+		"src":   filter.Src,
+		"dst":   filter.Dst,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if filter.Port != 0 {
+		query.Set("port", strconv.Itoa(int(filter.Port)))
+	}
+	path := "/conntrack.json"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	err = client.doJSONRequest(http.MethodGet, path, nil, &entries)
+	return
+}
@@ -0,0 +1,236 @@
+package edensdn
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	model "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/netmodel"
+	log "github.com/sirupsen/logrus"
+)
+
+// Kinds of host interfaces created by SdnContainerRunner for every port.
+const (
+	tapLink      = "t" // tap interface used by EVE VM
+	bridgeLink   = "b" // bridge connecting tap with veth
+	hostVethLink = "h" // host side of the veth
+	sdnVethLink  = "c" // SDN side of the veth, moved into the container
+)
+
+// sdnAgentArgsEnv : environment variable with additional arguments for SDN agent
+// (see sdn/vm/scripts/init.sh).
+const sdnAgentArgsEnv = "SDN_AGENT_ARGS"
+
+// SdnContainerRunner implements Eden-SDN runner using a privileged docker container.
+// SDN agent runs inside the network namespace of the container, where it builds
+// the network model the same way as inside SDN VM. Every port is connected with EVE VM
+// using a tap interface, bridged on the host with a veth, which has the other side
+// inside the container. Requires root privileges to create host interfaces.
+type SdnContainerRunner struct {
+	SdnVMConfig
+}
+
+// NewSdnContainerRunner is constructor for SdnContainerRunner.
+func NewSdnContainerRunner(config SdnVMConfig) *SdnContainerRunner {
+	return &SdnContainerRunner{SdnVMConfig: config}
+}
+
+// Start Eden-SDN inside a privileged container.
+func (r *SdnContainerRunner) Start() error {
+	// Without br_netfilter SDN agent is not able to configure bridge sysctl settings.
+	if _, stderr, err := utils.RunCommandAndWait("modprobe", "br_netfilter"); err != nil {
+		log.Warnf("Failed to load br_netfilter kernel module: %v (%s)", err, stderr)
+	}
+	portMap := map[string]string{
+		"22":   strconv.Itoa(int(r.SSHPort)),
+		"6666": strconv.Itoa(int(r.MgmtPort)),
+	}
+	// IP address of the container interface connecting SDN with the host
+	// is assigned by docker, not by DHCP.
+	envs := []string{sdnAgentArgsEnv + "=-static-host-ip"}
+	err := utils.CreateAndRunPrivilegedContainer(defaults.DefaultSdnContainerName,
+		r.ContainerImage, portMap, GenerateSdnMgmtMAC(), envs)
+	if err != nil {
+		return fmt.Errorf("failed to start SDN container: %w", err)
+	}
+	_ = os.Chmod(r.SSHKeyPath, 0600)
+	pid, err := utils.GetContainerPid(defaults.DefaultSdnContainerName)
+	if err != nil {
+		return err
+	}
+	for i, port := range r.NetModel.Ports {
+		if err = r.addPort(pid, i, port.MAC); err != nil {
+			return err
+		}
+	}
+	log.Infof("Started SDN container %s (pid: %d)", defaults.DefaultSdnContainerName, pid)
+	return nil
+}
+
+// Stop Eden-SDN container and remove host interfaces created for ports.
+func (r *SdnContainerRunner) Stop() error {
+	// Remove host interfaces even if the container is already gone.
+	stopErr := utils.StopContainer(defaults.DefaultSdnContainerName, true)
+	if stopErr != nil {
+		stopErr = fmt.Errorf("failed to stop SDN container: %w", stopErr)
+	}
+	stdout, stderr, err := utils.RunCommandAndWait("ip", "-o", "link", "show")
+	if err != nil {
+		return fmt.Errorf("failed to list host interfaces: %v (%s)", err, stderr)
+	}
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ifName := strings.Split(strings.TrimSuffix(fields[1], ":"), "@")[0]
+		for _, kind := range []string{tapLink, bridgeLink, hostVethLink} {
+			prefix := containerLinkPrefix(r.NetDevBasePort, kind)
+			if !strings.HasPrefix(ifName, prefix) {
+				continue
+			}
+			if _, err := strconv.Atoi(strings.TrimPrefix(ifName, prefix)); err == nil {
+				r.delLink(ifName)
+			}
+		}
+	}
+	return stopErr
+}
+
+// Status returns state of the Eden-SDN container as reported by docker.
+func (r *SdnContainerRunner) Status() (string, error) {
+	state, err := utils.StateContainer(defaults.DefaultSdnContainerName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get state of SDN container: %w", err)
+	}
+	if state == "" {
+		return "container doesn't exist", nil
+	}
+	return state, nil
+}
+
+// RequiresVmRestart returns true only if ports as seen by a running EVE VM have changed.
+// SDN side of ports can be changed in run-time (see UpdatePorts).
+func (r *SdnContainerRunner) RequiresVmRestart(oldModel, newModel model.NetworkModel) bool {
+	for i := 0; i < len(oldModel.Ports) && i < len(newModel.Ports); i++ {
+		if oldModel.Ports[i].EVEConnect != newModel.Ports[i].EVEConnect {
+			return true
+		}
+	}
+	// Ports can be added or removed only for EVE instances which are not running
+	// with the old model or which will not be running with the new model.
+	oldPortCount := make(map[string]int)
+	for _, port := range oldModel.Ports {
+		oldPortCount[port.EVEConnect.EVEInstance]++
+	}
+	newPortCount := make(map[string]int)
+	for _, port := range newModel.Ports {
+		newPortCount[port.EVEConnect.EVEInstance]++
+	}
+	for instance, oldCount := range oldPortCount {
+		if newCount := newPortCount[instance]; newCount != 0 && newCount != oldCount {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdatePorts adds, removes and re-creates host interfaces and veths
+// to match ports of the new network model.
+func (r *SdnContainerRunner) UpdatePorts(oldModel, newModel model.NetworkModel) error {
+	pid, err := utils.GetContainerPid(defaults.DefaultSdnContainerName)
+	if err != nil {
+		return err
+	}
+	for i, port := range newModel.Ports {
+		if i >= len(oldModel.Ports) {
+			if err = r.addPort(pid, i, port.MAC); err != nil {
+				return err
+			}
+			continue
+		}
+		if oldModel.Ports[i].MAC != port.MAC {
+			// Re-create the veth so that SDN agent sees the port with the old MAC
+			// address disappearing and the new one appearing.
+			r.delLink(containerLinkName(r.NetDevBasePort, hostVethLink, i))
+			if err = r.addVeth(pid, i, port.MAC); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(newModel.Ports); i < len(oldModel.Ports); i++ {
+		r.delPort(i)
+	}
+	return nil
+}
+
+func (r *SdnContainerRunner) addPort(pid, portIdx int, mac string) error {
+	tap := containerLinkName(r.NetDevBasePort, tapLink, portIdx)
+	bridge := containerLinkName(r.NetDevBasePort, bridgeLink, portIdx)
+	mtu := strconv.Itoa(netmodel.MaxMTU)
+	cmds := [][]string{
+		{"tuntap", "add", "dev", tap, "mode", "tap", "user", strconv.Itoa(os.Getuid())},
+		{"link", "add", bridge, "type", "bridge"},
+		{"link", "set", "dev", tap, "mtu", mtu, "master", bridge, "up"},
+		{"link", "set", "dev", bridge, "mtu", mtu, "up"},
+	}
+	if err := r.runIPCmds(cmds); err != nil {
+		return fmt.Errorf("failed to create interfaces for port %d: %w", portIdx, err)
+	}
+	return r.addVeth(pid, portIdx, mac)
+}
+
+func (r *SdnContainerRunner) addVeth(pid, portIdx int, mac string) error {
+	bridge := containerLinkName(r.NetDevBasePort, bridgeLink, portIdx)
+	hostVeth := containerLinkName(r.NetDevBasePort, hostVethLink, portIdx)
+	sdnVeth := containerLinkName(r.NetDevBasePort, sdnVethLink, portIdx)
+	mtu := strconv.Itoa(netmodel.MaxMTU)
+	cmds := [][]string{
+		{"link", "add", hostVeth, "mtu", mtu, "type", "veth", "peer", "name", sdnVeth,
+			"mtu", mtu, "address", mac},
+		{"link", "set", "dev", hostVeth, "master", bridge, "up"},
+		// Admin state of the SDN side is managed by SDN agent.
+		{"link", "set", "dev", sdnVeth, "netns", strconv.Itoa(pid)},
+	}
+	if err := r.runIPCmds(cmds); err != nil {
+		return fmt.Errorf("failed to create veth for port %d: %w", portIdx, err)
+	}
+	return nil
+}
+
+func (r *SdnContainerRunner) delPort(portIdx int) {
+	// Removing host side of the veth removes also the side inside the container.
+	for _, kind := range []string{hostVethLink, tapLink, bridgeLink} {
+		r.delLink(containerLinkName(r.NetDevBasePort, kind, portIdx))
+	}
+}
+
+func (r *SdnContainerRunner) delLink(ifName string) {
+	if _, stderr, err := utils.RunCommandAndWait("ip", "link", "del", ifName); err != nil {
+		log.Warnf("Failed to delete interface %s: %v (%s)", ifName, err, stderr)
+	}
+}
+
+func (r *SdnContainerRunner) runIPCmds(cmds [][]string) error {
+	for _, args := range cmds {
+		if _, stderr, err := utils.RunCommandAndWait("ip", args...); err != nil {
+			return fmt.Errorf("ip %s: %v (%s)", strings.Join(args, " "), err,
+				strings.TrimSpace(stderr))
+		}
+	}
+	return nil
+}
+
+// containerLinkName returns name of an interface created by SdnContainerRunner
+// for the given port. Names are unique for every eden setup thanks to the base port.
+func containerLinkName(netDevBasePort uint16, kind string, portIdx int) string {
+	return containerLinkPrefix(netDevBasePort, kind) + strconv.Itoa(portIdx)
+}
+
+func containerLinkPrefix(netDevBasePort uint16, kind string) string {
+	return fmt.Sprintf("sdn%d%s", netDevBasePort, kind)
+}
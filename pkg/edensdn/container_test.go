@@ -0,0 +1,56 @@
+package edensdn_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/edensdn"
+	model "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSdnContainerRunnerRequiresVmRestart(t *testing.T) {
+	t.Parallel()
+
+	port := func(mac, eveMAC, instance string) model.Port {
+		return model.Port{
+			MAC: mac,
+			EVEConnect: model.EVEConnect{
+				MAC:         eveMAC,
+				EVEInstance: instance,
+			},
+		}
+	}
+	oldModel := model.NetworkModel{Ports: []model.Port{
+		port("02:00:00:00:00:01", "02:00:00:00:01:01", ""),
+		port("02:00:00:00:00:02", "02:00:00:00:01:02", ""),
+	}}
+	runner := edensdn.NewSdnContainerRunner(edensdn.SdnVMConfig{})
+
+	// SDN side of a port can change in run-time.
+	newModel := model.NetworkModel{Ports: []model.Port{
+		port("02:00:00:00:00:11", "02:00:00:00:01:01", ""),
+		port("02:00:00:00:00:02", "02:00:00:00:01:02", ""),
+	}}
+	assert.False(t, runner.RequiresVmRestart(oldModel, newModel))
+
+	// Ports can be added for another EVE instance.
+	newModel.Ports = append(newModel.Ports, port("02:00:00:00:00:03", "02:00:00:00:01:03", "eve2"))
+	assert.False(t, runner.RequiresVmRestart(oldModel, newModel))
+
+	// EVE side of a port cannot change.
+	newModel.Ports[1].EVEConnect.MAC = "02:00:00:00:01:12"
+	assert.True(t, runner.RequiresVmRestart(oldModel, newModel))
+
+	// Running EVE instance cannot get additional port.
+	newModel.Ports[1].EVEConnect.MAC = "02:00:00:00:01:02"
+	newModel.Ports[2].EVEConnect.EVEInstance = ""
+	assert.True(t, runner.RequiresVmRestart(oldModel, newModel))
+}
+
+func TestEVEPortNetDev(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "socket,connect=:6001", edensdn.EVEPortNetDev(edensdn.RunnerVM, 6000, 1))
+	assert.Equal(t, "tap,ifname=sdn6000t1,script=no,downscript=no",
+		edensdn.EVEPortNetDev(edensdn.RunnerContainer, 6000, 1))
+}
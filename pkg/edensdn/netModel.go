@@ -122,7 +122,7 @@ func GenerateSdnMgmtMAC() string {
 	hash := h.Sum32()
 	hwAddr := make(net.HardwareAddr, 6)
 	// 08:33:33 is prefix expected by SDN to be used for the management interface
-	// See hostPortMACPrefix in ./sdn/cmd/sdnagent/agent.go
+	// See HostPortMACPrefix in ./sdn/vm/pkg/netmodel/validate.go
 	hwAddr[0] = 0x08
 	hwAddr[1] = 0x33
 	hwAddr[2] = 0x33
@@ -180,3 +180,15 @@ func addMissingHostConfig(netModel *sdnapi.NetworkModel) error {
 	}
 	return nil
 }
+
+// EVEInstancePorts returns indexes of ports from the network model which are
+// connected to the given EVE instance. Empty instance name refers to the default
+// EVE instance.
+func EVEInstancePorts(model sdnapi.NetworkModel, eveInstance string) (ports []int) {
+	for i, port := range model.Ports {
+		if port.EVEConnect.EVEInstance == eveInstance {
+			ports = append(ports, i)
+		}
+	}
+	return ports
+}
@@ -140,3 +140,8 @@ func (vm *SdnVMQemuRunner) RequiresVmRestart(oldModel, newModel model.NetworkMod
 	}
 	return false
 }
+
+// UpdatePorts does nothing, ports of SDN VM cannot change without restart.
+func (vm *SdnVMQemuRunner) UpdatePorts(oldModel, newModel model.NetworkModel) error {
+	return nil
+}
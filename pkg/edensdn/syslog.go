@@ -0,0 +1,77 @@
+package edensdn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	model "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// SyslogFilter : filter for messages retrieved by GetEndpointLogs.
+// Empty attributes match any message.
+type SyslogFilter struct {
+	// Regex : regular expression matched against the message as received.
+	Regex string
+	// Since : only messages received at or after this time are returned.
+	Since time.Time
+}
+
+// GetEndpointLogs : retrieve messages received by a syslog server endpoint
+// deployed inside Eden-SDN. Each message passing the filter is given to the handler,
+// which can return false to stop the retrieval.
+// With follow enabled, new messages are streamed as they arrive until the handler
+// returns false or the context is done (context error is then returned).
+func (client *SdnClient) GetEndpointLogs(ctx context.Context, label string,
+	filter SyslogFilter, follow bool, handler func(model.SyslogMessage) bool) error {
+	query := url.Values{}
+	if filter.Regex != "" {
+		query.Set("regex", filter.Regex)
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if follow {
+		query.Set("follow", "true")
+	}
+	path := fmt.Sprintf("/endpoint/%s/logs", url.PathEscape(label))
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("http://localhost:%d%s", client.MgmtPort, path), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build HTTP request: %w", err)
+	}
+	httpClient := &http.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to GET endpoint logs failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to GET endpoint logs failed with code=%d, "+
+			"response: %s", resp.StatusCode, readResponse(resp))
+	}
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var msg model.SyslogMessage
+		err = decoder.Decode(&msg)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to read endpoint logs: %w", err)
+		}
+		if !handler(msg) {
+			return nil
+		}
+	}
+}
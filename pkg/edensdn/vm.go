@@ -8,8 +8,16 @@ import (
 	model "github.com/lf-edge/eden/sdn/vm/api"
 )
 
+const (
+	// RunnerVM : run Eden-SDN as a VM.
+	RunnerVM = "vm"
+	// RunnerContainer : run Eden-SDN as a privileged container.
+	RunnerContainer = "container"
+)
+
 // SdnVMRunner is implemented for every virtualization technology on which Eden-SDN
-// is supported. Currently only QEMU is supported.
+// is supported. Currently only QEMU is supported, with Eden-SDN running either
+// as a separate VM or as a privileged container.
 type SdnVMRunner interface {
 	// Start Eden-SDN VM.
 	Start() error
@@ -18,6 +26,9 @@ type SdnVMRunner interface {
 	// RequiresVmRestart should return true if going from oldModel to newModel requires
 	// to restart EVE VM and SDN VM.
 	RequiresVmRestart(oldModel, newModel model.NetworkModel) bool
+	// UpdatePorts updates ports connecting Eden-SDN with EVE VM(s) in run-time.
+	// Called only if RequiresVmRestart returned false.
+	UpdatePorts(oldModel, newModel model.NetworkModel) error
 }
 
 // SdnVMConfig : configuration for Eden-SDN VM.
@@ -39,6 +50,8 @@ type SdnVMConfig struct {
 	NetDevBasePort uint16 // QEMU-specific
 	PidFile        string
 	ConsoleLogFile string
+	Runner         string // vm or container, defaults to vm
+	ContainerImage string // container-specific
 }
 
 // SdnMgmtSubnet : IP configuration for Eden-SDN management network.
@@ -51,7 +64,23 @@ type SdnMgmtSubnet struct {
 func GetSdnVMRunner(devModelType string, config SdnVMConfig) (SdnVMRunner, error) {
 	switch devModelType {
 	case defaults.DefaultQemuModel:
-		return NewSdnVMQemuRunner(config), nil
+		switch config.Runner {
+		case "", RunnerVM:
+			return NewSdnVMQemuRunner(config), nil
+		case RunnerContainer:
+			return NewSdnContainerRunner(config), nil
+		}
+		return nil, fmt.Errorf("unknown SDN runner: %s", config.Runner)
 	}
 	return nil, fmt.Errorf("not implemented for type: %s", devModelType)
 }
+
+// EVEPortNetDev returns QEMU netdev backend options (without ID) which EVE VM should use
+// to connect with the given port of Eden-SDN.
+func EVEPortNetDev(runner string, netDevBasePort uint16, portIdx int) string {
+	if runner == RunnerContainer {
+		return fmt.Sprintf("tap,ifname=%s,script=no,downscript=no",
+			containerLinkName(netDevBasePort, tapLink, portIdx))
+	}
+	return fmt.Sprintf("socket,connect=:%d", int(netDevBasePort)+portIdx)
+}
@@ -40,6 +40,7 @@ type AppInstState struct {
 	prevCPUNSTime time.Time
 	deleted       bool
 	infoTime      time.Time
+	history       stateHistory
 }
 
 func appStateHeader() string {
@@ -204,6 +205,8 @@ func (ctx *State) processApplicationsByInfo(im *info.ZInfoMsg) {
 			//if AppErr, show them
 			appStateObj.EVEState = fmt.Sprintf("%s: %s", im.GetAinfo().State.String(), im.GetAinfo().AppErr)
 		}
+		appStateObj.history.record(im.GetAinfo().State.String(),
+			errorInfoString(im.GetAinfo().AppErr...), im.AtTimeStamp.AsTime())
 		if len(im.GetAinfo().Network) != 0 && len(im.GetAinfo().Network[0].IPAddrs) != 0 {
 			if len(im.GetAinfo().Network) > 1 {
 				appStateObj.InternalIP = []string{}
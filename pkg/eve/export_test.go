@@ -0,0 +1,33 @@
+package eve
+
+import (
+	"io"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+)
+
+// Exports of unexported helpers for tests in package eve_test.
+
+// StateHistory exports stateHistory.
+type StateHistory = stateHistory
+
+// Record exports stateHistory.record.
+func (h *stateHistory) Record(state, errStr string, at time.Time) {
+	h.record(state, errStr, at)
+}
+
+// PrintHistory exports printHistory.
+func PrintHistory(out io.Writer, history StateHistory, outputFormat types.OutputFormat) error {
+	return printHistory(out, history, outputFormat)
+}
+
+// AppHistory returns state transitions recorded for the app.
+func AppHistory(app *AppInstState) StateHistory {
+	return app.history
+}
+
+// VolumeHistory returns state transitions recorded for the volume and its content tree.
+func VolumeHistory(volume *VolInstState) StateHistory {
+	return volume.timeline()
+}
@@ -0,0 +1,148 @@
+package eve
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/info"
+)
+
+// StateTransition stores transition of an object into a new state reported by EVE
+type StateTransition struct {
+	State string
+	Error string
+	// Time is the timestamp of the info message which reported the new state
+	Time time.Time
+}
+
+// stateHistory stores state transitions ordered by time
+type stateHistory []StateTransition
+
+// record inserts transition at its place by time if the state or the error differs
+// from the preceding one. Loaders may process info messages newest-first, so
+// the transition can be older than the ones already recorded.
+func (h *stateHistory) record(state, errStr string, at time.Time) {
+	i := sort.Search(len(*h), func(i int) bool { return (*h)[i].Time.After(at) })
+	if i > 0 {
+		prev := (*h)[i-1]
+		if prev.State == state && prev.Error == errStr {
+			return
+		}
+	}
+	if i < len(*h) {
+		next := &(*h)[i]
+		if next.State == state && next.Error == errStr {
+			// the same state was reached earlier
+			next.Time = at
+			return
+		}
+	}
+	*h = append(*h, StateTransition{})
+	copy((*h)[i+1:], (*h)[i:])
+	(*h)[i] = StateTransition{State: state, Error: errStr, Time: at}
+}
+
+// mergeHistory joins transitions of several objects into one timeline ordered by time,
+// states of every object except the first one are marked with the object kind
+func mergeHistory(main stateHistory, kind string, other stateHistory) stateHistory {
+	merged := append(stateHistory{}, main...)
+	for _, el := range other {
+		el.State = fmt.Sprintf("%s (%s)", el.State, kind)
+		merged = append(merged, el)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	return merged
+}
+
+// errorInfoString joins descriptions of reported errors
+func errorInfoString(errs ...*info.ErrorInfo) string {
+	var descriptions []string
+	for _, el := range errs {
+		if el.GetDescription() != "" {
+			descriptions = append(descriptions, el.GetDescription())
+		}
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+func historyHeader() string {
+	return "TIME\tSTATE\tDURATION\tERROR"
+}
+
+func printHistoryLines(out io.Writer, history stateHistory) error {
+	w := new(tabwriter.Writer)
+	w.Init(out, 0, 8, 1, '\t', 0)
+	if _, err := fmt.Fprintln(w, historyHeader()); err != nil {
+		return err
+	}
+	for i, el := range history {
+		// time spent in the last state is counted until now
+		var duration string
+		if i+1 < len(history) {
+			duration = history[i+1].Time.Sub(el.Time).Round(time.Second).String()
+		} else {
+			duration = fmt.Sprintf("%s (current)", time.Since(el.Time).Round(time.Second))
+		}
+		errStr := el.Error
+		if errStr == "" {
+			errStr = "-"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			el.Time.Format(time.RFC3339), el.State, duration, errStr); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func printHistory(out io.Writer, history stateHistory, outputFormat types.OutputFormat) error {
+	switch outputFormat {
+	case types.OutputFormatLines:
+		return printHistoryLines(out, history)
+	case types.OutputFormatJSON:
+		result, err := json.MarshalIndent(history, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(result))
+		return err
+	}
+	return fmt.Errorf("unimplemented output format")
+}
+
+// PodHistory prints state transitions of the application with the given name or UUID
+func (ctx *State) PodHistory(name string, outputFormat types.OutputFormat) error {
+	for _, el := range ctx.applications {
+		if el.Name == name || el.UUID == name {
+			return printHistory(os.Stdout, el.history, outputFormat)
+		}
+	}
+	return fmt.Errorf("no app found with name %s", name)
+}
+
+// NetHistory prints state transitions of the network instance with the given name or UUID
+func (ctx *State) NetHistory(name string, outputFormat types.OutputFormat) error {
+	for _, el := range ctx.networks {
+		if el.Name == name || el.UUID == name {
+			return printHistory(os.Stdout, el.history, outputFormat)
+		}
+	}
+	return fmt.Errorf("no network found with name %s", name)
+}
+
+// VolumeHistory prints state transitions of the volume with the given name or UUID
+func (ctx *State) VolumeHistory(name string, outputFormat types.OutputFormat) error {
+	for _, el := range ctx.volumes {
+		if el.Name == name || el.UUID == name {
+			return printHistory(os.Stdout, el.timeline(), outputFormat)
+		}
+	}
+	return fmt.Errorf("no volume found with name %s", name)
+}
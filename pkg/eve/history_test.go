@@ -0,0 +1,259 @@
+package eve_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/memory"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var historyStart = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func at(seconds int) time.Time {
+	return historyStart.Add(time.Duration(seconds) * time.Second)
+}
+
+type recordArgs struct {
+	state  string
+	errStr string
+	at     time.Time
+}
+
+func TestStateHistoryRecord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		records  []recordArgs
+		expected eve.StateHistory
+	}{
+		{
+			name: "oldest first",
+			records: []recordArgs{
+				{"INSTALLED", "", at(0)},
+				{"BOOTING", "", at(10)},
+				{"RUNNING", "", at(20)},
+			},
+			expected: eve.StateHistory{
+				{State: "INSTALLED", Time: at(0)},
+				{State: "BOOTING", Time: at(10)},
+				{State: "RUNNING", Time: at(20)},
+			},
+		},
+		{
+			name: "newest first",
+			records: []recordArgs{
+				{"RUNNING", "", at(20)},
+				{"BOOTING", "", at(10)},
+				{"INSTALLED", "", at(0)},
+			},
+			expected: eve.StateHistory{
+				{State: "INSTALLED", Time: at(0)},
+				{State: "BOOTING", Time: at(10)},
+				{State: "RUNNING", Time: at(20)},
+			},
+		},
+		{
+			name: "repeated state keeps the first time",
+			records: []recordArgs{
+				{"RUNNING", "", at(30)},
+				{"RUNNING", "", at(20)},
+				{"BOOTING", "", at(10)},
+				{"RUNNING", "", at(40)},
+			},
+			expected: eve.StateHistory{
+				{State: "BOOTING", Time: at(10)},
+				{State: "RUNNING", Time: at(20)},
+			},
+		},
+		{
+			name: "error change is a transition",
+			records: []recordArgs{
+				{"RUNNING", "", at(0)},
+				{"RUNNING", "disk full", at(10)},
+				{"RUNNING", "disk full", at(20)},
+				{"RUNNING", "", at(30)},
+			},
+			expected: eve.StateHistory{
+				{State: "RUNNING", Time: at(0)},
+				{State: "RUNNING", Error: "disk full", Time: at(10)},
+				{State: "RUNNING", Time: at(30)},
+			},
+		},
+		{
+			name: "state inserted in the middle",
+			records: []recordArgs{
+				{"INSTALLED", "", at(0)},
+				{"RUNNING", "", at(20)},
+				{"BOOTING", "", at(10)},
+			},
+			expected: eve.StateHistory{
+				{State: "INSTALLED", Time: at(0)},
+				{State: "BOOTING", Time: at(10)},
+				{State: "RUNNING", Time: at(20)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var history eve.StateHistory
+			for _, r := range tt.records {
+				history.Record(r.state, r.errStr, r.at)
+			}
+			assert.Equal(t, tt.expected, history)
+		})
+	}
+}
+
+func TestPrintHistory(t *testing.T) {
+	t.Parallel()
+
+	history := eve.StateHistory{
+		{State: "BOOTING", Time: at(0)},
+		{State: "RUNNING", Error: "disk full", Time: at(90)},
+	}
+
+	var lines bytes.Buffer
+	assert.NoError(t, eve.PrintHistory(&lines, history, types.OutputFormatLines))
+	rows := strings.Split(strings.TrimSpace(lines.String()), "\n")
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, []string{"TIME", "STATE", "DURATION", "ERROR"}, strings.Fields(rows[0]))
+		assert.Equal(t, []string{"2024-05-01T10:00:00Z", "BOOTING", "1m30s", "-"},
+			strings.Fields(rows[1]))
+		// time in the last state is counted until now
+		last := strings.Fields(rows[2])
+		assert.Equal(t, "RUNNING", last[1])
+		assert.Equal(t, "(current)", last[3])
+		assert.Equal(t, "disk full", strings.Join(last[4:], " "))
+	}
+
+	var jsonOut bytes.Buffer
+	assert.NoError(t, eve.PrintHistory(&jsonOut, history, types.OutputFormatJSON))
+	var parsed eve.StateHistory
+	assert.NoError(t, json.Unmarshal(jsonOut.Bytes(), &parsed))
+	assert.Equal(t, history, parsed)
+
+	assert.Error(t, eve.PrintHistory(&bytes.Buffer{}, history, types.OutputFormat(100)))
+}
+
+func appInfo(appID string, state info.ZSwState, seconds int) *info.ZInfoMsg {
+	return &info.ZInfoMsg{
+		Ztype: info.ZInfoTypes_ZiApp,
+		InfoContent: &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{
+			AppID:   appID,
+			AppName: "app",
+			State:   state,
+		}},
+		AtTimeStamp: timestamppb.New(at(seconds)),
+	}
+}
+
+func TestAppHistoryNewestFirst(t *testing.T) {
+	t.Parallel()
+
+	ctrl := &controller.CloudCtx{Controller: memory.New()}
+	ctrl.SetVars(&utils.ConfigVars{ZArch: "amd64"})
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f"))
+	state := eve.Init(ctrl, dev)
+
+	// order in which loaders process existing info messages
+	appID := "1b7ee8b4-d5c7-4f3a-a5d8-8f3b2b8f1f6e"
+	callback := state.InfoCallback()
+	for _, msg := range []*info.ZInfoMsg{
+		appInfo(appID, info.ZSwState_RUNNING, 30),
+		appInfo(appID, info.ZSwState_RUNNING, 20),
+		appInfo(appID, info.ZSwState_BOOTING, 10),
+		appInfo(appID, info.ZSwState_INSTALLED, 0),
+	} {
+		callback(msg)
+	}
+	apps := state.Applications()
+	if !assert.Len(t, apps, 1) {
+		return
+	}
+	assert.Equal(t, eve.StateHistory{
+		{State: "INSTALLED", Time: at(0)},
+		{State: "BOOTING", Time: at(10)},
+		{State: "RUNNING", Time: at(20)},
+	}, eve.AppHistory(apps[0]))
+}
+
+func TestDownloadedVolumeHistory(t *testing.T) {
+	t.Parallel()
+
+	const (
+		volumeID      = "6b1e0c6c-2f7d-4f44-9d3a-9f5a2e0f3b11"
+		contentTreeID = "0f9a7d1e-5c3b-4c1a-8e2d-7b6a5c4d3e2f"
+	)
+	ctrl := &controller.CloudCtx{Controller: memory.New()}
+	ctrl.SetVars(&utils.ConfigVars{ZArch: "amd64"})
+	assert.NoError(t, ctrl.AddContentTree(&config.ContentTree{Uuid: contentTreeID, URL: "nginx:1"}))
+	assert.NoError(t, ctrl.AddVolume(&config.Volume{
+		Uuid:        volumeID,
+		DisplayName: "vol",
+		Origin: &config.VolumeContentOrigin{
+			Type:                  config.VolumeContentOriginType_VCOT_DOWNLOAD,
+			DownloadContentTreeID: contentTreeID,
+		},
+	}))
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f"))
+	dev.SetVolumeConfigs([]string{volumeID})
+	state := eve.Init(ctrl, dev)
+
+	contentTreeInfo := func(state info.ZSwState, seconds int) *info.ZInfoMsg {
+		return &info.ZInfoMsg{
+			Ztype:       info.ZInfoTypes_ZiContentTree,
+			InfoContent: &info.ZInfoMsg_Cinfo{Cinfo: &info.ZInfoContentTree{Uuid: contentTreeID, State: state}},
+			AtTimeStamp: timestamppb.New(at(seconds)),
+		}
+	}
+	volumeInfo := func(state info.ZSwState, seconds int) *info.ZInfoMsg {
+		return &info.ZInfoMsg{
+			Ztype: info.ZInfoTypes_ZiVolume,
+			InfoContent: &info.ZInfoMsg_Vinfo{Vinfo: &info.ZInfoVolume{
+				Uuid: volumeID, DisplayName: "vol", State: state}},
+			AtTimeStamp: timestamppb.New(at(seconds)),
+		}
+	}
+	callback := state.InfoCallback()
+	for _, msg := range []*info.ZInfoMsg{
+		contentTreeInfo(info.ZSwState_DOWNLOAD_STARTED, 0),
+		volumeInfo(info.ZSwState_DOWNLOAD_STARTED, 5),
+		contentTreeInfo(info.ZSwState_LOADED, 20),
+		volumeInfo(info.ZSwState_CREATING_VOLUME, 25),
+		// content tree keeps reporting the same state
+		contentTreeInfo(info.ZSwState_LOADED, 30),
+		volumeInfo(info.ZSwState_CREATED_VOLUME, 40),
+	} {
+		callback(msg)
+	}
+	volumes := state.Volumes()
+	if !assert.Len(t, volumes, 1) {
+		return
+	}
+	assert.Equal(t, eve.StateHistory{
+		{State: "DOWNLOAD_STARTED (content tree)", Time: at(0)},
+		{State: "DOWNLOAD_STARTED", Time: at(5)},
+		{State: "LOADED (content tree)", Time: at(20)},
+		{State: "CREATING_VOLUME", Time: at(25)},
+		{State: "CREATED_VOLUME", Time: at(40)},
+	}, eve.VolumeHistory(volumes[0]))
+}
@@ -26,6 +26,7 @@ type NetInstState struct {
 	EveState    string
 	Activated   bool
 	deleted     bool
+	history     stateHistory
 }
 
 func netInstStateHeader() string {
@@ -97,6 +98,8 @@ func (ctx *State) processNetworksByInfo(im *info.ZInfoMsg) {
 				netInstStateObj.EveState = "ERROR"
 			}
 		}
+		netInstStateObj.history.record(im.GetNiinfo().GetState().String(),
+			errorInfoString(im.GetNiinfo().GetNetworkErr()...), im.AtTimeStamp.AsTime())
 		// XXX Guard against old EVE which doesn't send state
 		// sends INIT state when deleting network instance
 		if !netInstStateObj.Activated &&
@@ -33,6 +33,14 @@ type VolInstState struct {
 	MountPoint    string
 	OriginType    string
 	deleted       bool
+	history       stateHistory
+	// contentTreeHistory stores transitions of the content tree the volume is downloaded from
+	contentTreeHistory stateHistory
+}
+
+// timeline returns transitions of the volume together with transitions of its content tree
+func (volInstStateObj *VolInstState) timeline() stateHistory {
+	return mergeHistory(volInstStateObj.history, "content tree", volInstStateObj.contentTreeHistory)
 }
 
 func volInstStateHeader() string {
@@ -141,11 +149,15 @@ func (ctx *State) processVolumesByInfo(im *info.ZInfoMsg) {
 		if volInstStateObj.OriginType == config.VolumeContentOriginType_VCOT_BLANK.String() {
 			volInstStateObj.EveState = infoObject.GetState().String()
 		}
+		volInstStateObj.history.record(infoObject.GetState().String(),
+			errorInfoString(infoObject.GetVolumeErr()), im.AtTimeStamp.AsTime())
 	case info.ZInfoTypes_ZiContentTree:
 		infoObject := im.GetCinfo()
 		for _, el := range ctx.volumes {
 			if infoObject.Uuid == el.contentTreeID {
 				el.EveState = infoObject.GetState().String()
+				el.contentTreeHistory.record(el.EveState, errorInfoString(infoObject.GetErr()),
+					im.AtTimeStamp.AsTime())
 				if infoObject.GetErr() != nil {
 					el.LastError = infoObject.GetErr().String()
 					continue
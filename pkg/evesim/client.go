@@ -0,0 +1,169 @@
+package evesim
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/auth"
+	"github.com/lf-edge/eve-api/go/evecommon"
+	"google.golang.org/protobuf/proto"
+)
+
+const contentType = "application/x-proto-binary"
+
+// identity is certificate with key used to sign requests
+type identity struct {
+	cert    tls.Certificate
+	certPEM []byte
+}
+
+func loadIdentity(certFile, keyFile string) (*identity, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load key pair %s: %w", certFile, err)
+	}
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cert.PrivateKey.(*ecdsa.PrivateKey); !ok {
+		return nil, fmt.Errorf("only ECDSA keys are supported: %s", keyFile)
+	}
+	return &identity{cert: cert, certPEM: certPEM}, nil
+}
+
+// loadOrCreateDeviceIdentity loads device certificate or generates self-signed one
+// as EVE does on the first boot
+func loadOrCreateDeviceIdentity(certFile, keyFile, serial string) (*identity, error) {
+	if _, err := os.Stat(certFile); err == nil {
+		return loadIdentity(certFile, keyFile)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: serial},
+		NotBefore:    time.Now().Add(-10 * time.Second),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create device certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.WriteToFiles(cert, key, certFile, keyFile); err != nil {
+		return nil, fmt.Errorf("cannot save device certificate: %w", err)
+	}
+	return loadIdentity(certFile, keyFile)
+}
+
+// seal wraps payload into AuthContainer signed with the identity
+func (id *identity) seal(payload []byte, withCert bool) ([]byte, error) {
+	hash := sha256.Sum256(payload)
+	key := id.cert.PrivateKey.(*ecdsa.PrivateKey)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign payload: %w", err)
+	}
+	// signature is r and s padded to the size of the curve
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	certHash := sha256.Sum256(id.certPEM)
+	container := &auth.AuthContainer{
+		ProtectedPayload: &auth.AuthBody{Payload: payload},
+		Algo:             evecommon.HashAlgorithm_HASH_ALGORITHM_SHA256_32BYTES,
+		SenderCertHash:   certHash[:],
+		SignatureHash:    signature,
+	}
+	if withCert {
+		container.SenderCert = id.certPEM
+	}
+	return proto.Marshal(container)
+}
+
+// client sends requests to controller
+type client struct {
+	url  string
+	http *http.Client
+	id   *identity
+}
+
+func newClient(url, serverName string, rootCAs *x509.CertPool, id *identity) *client {
+	return &client{
+		url: url,
+		id:  id,
+		http: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs:      rootCAs,
+					ServerName:   serverName,
+					Certificates: []tls.Certificate{id.cert},
+					MinVersion:   tls.VersionTLS12,
+				},
+			},
+		},
+	}
+}
+
+// post sends payload wrapped into AuthContainer and returns status code and unwrapped response
+func (c *client) post(path string, payload []byte, withCert bool) (int, []byte, error) {
+	body, err := c.id.seal(payload, withCert)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp, err := c.http.Post(c.url+path, contentType, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	if resp.StatusCode != http.StatusOK || len(data) == 0 {
+		return resp.StatusCode, nil, nil
+	}
+	var container auth.AuthContainer
+	if err := proto.Unmarshal(data, &container); err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("cannot unmarshal AuthContainer: %w", err)
+	}
+	return resp.StatusCode, container.GetProtectedPayload().GetPayload(), nil
+}
+
+// postMessage marshals and posts message expecting one of successful codes
+func (c *client) postMessage(path string, msg proto.Message) error {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	code, _, err := c.post(path, payload, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if code != http.StatusOK && code != http.StatusCreated {
+		return fmt.Errorf("%s: unexpected status code %d", path, code)
+	}
+	return nil
+}
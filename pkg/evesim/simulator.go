@@ -0,0 +1,254 @@
+// Package evesim implements lightweight simulator of EVE device which speaks
+// the controller API. It onboards with certificates generated by eden, polls
+// config and reflects it back as info, metrics and logs without running any
+// workload, so controller-side logic can be tested without KVM.
+package evesim
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lf-edge/eve-api/go/config"
+	eveuuid "github.com/lf-edge/eve-api/go/eveuuid"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/register"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const apiPrefix = "/api/v2/edgedevice"
+
+// Config of simulator
+type Config struct {
+	// CertsDir contains onboard.cert.pem, onboard.key.pem and root-certificate.pem,
+	// device certificate is generated there on the first run
+	CertsDir string
+	// ControllerURL is base URL of controller, e.g. https://192.168.0.1:3333
+	ControllerURL string
+	// ServerName is expected name in certificate of controller
+	ServerName string
+	// Serial is serial number of device used for onboarding
+	Serial string
+	// Version is EVE version to report
+	Version string
+	// Interval of config polling and info publishing
+	Interval time.Duration
+}
+
+// Simulator of EVE device
+type Simulator struct {
+	cfg     Config
+	onboard *client
+	device  *client
+	devID   string
+	state   *state
+	// last applied config and its hash
+	config     *config.EdgeDevConfig
+	configHash string
+}
+
+// New creates simulator loading certificates from cfg.CertsDir
+func New(cfg Config) (*Simulator, error) {
+	if cfg.Interval == 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	rootCA, err := os.ReadFile(filepath.Join(cfg.CertsDir, "root-certificate.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read root certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(rootCA) {
+		return nil, fmt.Errorf("cannot parse root certificate")
+	}
+	onboardID, err := loadIdentity(filepath.Join(cfg.CertsDir, "onboard.cert.pem"),
+		filepath.Join(cfg.CertsDir, "onboard.key.pem"))
+	if err != nil {
+		return nil, err
+	}
+	deviceID, err := loadOrCreateDeviceIdentity(filepath.Join(cfg.CertsDir, "device.cert.pem"),
+		filepath.Join(cfg.CertsDir, "device.key.pem"), cfg.Serial)
+	if err != nil {
+		return nil, err
+	}
+	return &Simulator{
+		cfg:     cfg,
+		onboard: newClient(cfg.ControllerURL, cfg.ServerName, pool, onboardID),
+		device:  newClient(cfg.ControllerURL, cfg.ServerName, pool, deviceID),
+		config:  &config.EdgeDevConfig{},
+	}, nil
+}
+
+// Run onboards device and publishes its state until ctx is done
+func (s *Simulator) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for s.devID == "" {
+		if err := s.onboarding(); err != nil {
+			log.Warnf("onboarding: %s", err)
+		}
+		if s.devID != "" {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+	log.Infof("device onboarded with UUID %s", s.devID)
+	s.state = newState(s.devID, s.cfg.Version, time.Now())
+	for {
+		if err := s.iteration(time.Now()); err != nil {
+			log.Warnf("iteration: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// onboarding registers device certificate and obtains UUID of device
+func (s *Simulator) onboarding() error {
+	if err := s.requestUUID(); err == nil {
+		return nil
+	}
+	msg := &register.ZRegisterMsg{
+		PemCert: []byte(base64.StdEncoding.EncodeToString(s.device.id.certPEM)),
+		Serial:  s.cfg.Serial,
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	code, _, err := s.onboard.post(apiPrefix+"/register", payload, true)
+	if err != nil {
+		return fmt.Errorf("register: %w", err)
+	}
+	if code != http.StatusOK && code != http.StatusCreated && code != http.StatusConflict {
+		return fmt.Errorf("register: unexpected status code %d", code)
+	}
+	return s.requestUUID()
+}
+
+func (s *Simulator) requestUUID() error {
+	payload, err := proto.Marshal(&eveuuid.UuidRequest{})
+	if err != nil {
+		return err
+	}
+	code, data, err := s.device.post(apiPrefix+"/uuid", payload, false)
+	if err != nil {
+		return fmt.Errorf("uuid: %w", err)
+	}
+	if code != http.StatusOK {
+		return fmt.Errorf("uuid: unexpected status code %d", code)
+	}
+	var resp eveuuid.UuidResponse
+	if err := proto.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("uuid: %w", err)
+	}
+	s.devID = resp.GetUuid()
+	return nil
+}
+
+// iteration polls config and publishes info, metrics and logs
+func (s *Simulator) iteration(now time.Time) error {
+	if err := s.pollConfig(); err != nil {
+		return err
+	}
+	for _, msg := range s.state.reflect(s.config, now) {
+		if err := s.device.postMessage(s.path("info"), msg); err != nil {
+			return err
+		}
+	}
+	if err := s.device.postMessage(s.path("metrics"), s.state.metrics(now)); err != nil {
+		return err
+	}
+	return s.sendLogs(now)
+}
+
+func (s *Simulator) path(endpoint string) string {
+	return fmt.Sprintf("%s/id/%s/%s", apiPrefix, s.devID, endpoint)
+}
+
+// pollConfig requests config and stores it if changed
+func (s *Simulator) pollConfig() error {
+	payload, err := proto.Marshal(&config.ConfigRequest{ConfigHash: s.configHash})
+	if err != nil {
+		return err
+	}
+	code, data, err := s.device.post(s.path("config"), payload, false)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	switch code {
+	case http.StatusNotModified:
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("config: unexpected status code %d", code)
+	}
+	var resp config.ConfigResponse
+	if err := proto.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if resp.GetConfigHash() != s.configHash {
+		s.state.log(time.Now(), "info", "applying config with hash %s", resp.GetConfigHash())
+	}
+	s.config = resp.GetConfig()
+	s.configHash = resp.GetConfigHash()
+	return nil
+}
+
+// sendLogs publishes accumulated logs as gzip bundle like newlogd does:
+// bundle metadata in the gzip header comment and entry per line in the body
+func (s *Simulator) sendLogs(now time.Time) error {
+	entries := s.state.takeLogs()
+	if len(entries) == 0 {
+		return nil
+	}
+	meta, err := protojson.Marshal(&logs.LogBundle{
+		DevID:      s.devID,
+		Image:      "IMGA",
+		EveVersion: s.cfg.Version,
+		Timestamp:  timestamppb.New(now),
+	})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Name = "log-bundle"
+	gw.Comment = string(meta)
+	for _, entry := range entries {
+		line, err := protojson.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := gw.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	code, _, err := s.device.post(s.path("newlogs"), buf.Bytes(), false)
+	if err != nil {
+		return fmt.Errorf("newlogs: %w", err)
+	}
+	if code != http.StatusOK && code != http.StatusCreated {
+		return fmt.Errorf("newlogs: unexpected status code %d", code)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package evesim_test
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/evesim"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/auth"
+	"github.com/lf-edge/eve-api/go/config"
+	eveuuid "github.com/lf-edge/eve-api/go/eveuuid"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+const devUUID = "a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f"
+
+// fakeController serves minimal subset of controller API
+type fakeController struct {
+	mu        sync.Mutex
+	appStates []info.ZSwState
+}
+
+func (c *fakeController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var container auth.AuthContainer
+	if err := proto.Unmarshal(body, &container); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var resp proto.Message
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/register"):
+		w.WriteHeader(http.StatusCreated)
+		return
+	case strings.HasSuffix(r.URL.Path, "/uuid"):
+		resp = &eveuuid.UuidResponse{Uuid: devUUID}
+	case strings.HasSuffix(r.URL.Path, "/config"):
+		resp = &config.ConfigResponse{ConfigHash: "1", Config: &config.EdgeDevConfig{
+			Apps: []*config.AppInstanceConfig{{
+				Uuidandversion: &config.UUIDandVersion{Uuid: "dbd53bf1-d7f7-4f7a-ac27-fc0621be50ba"},
+				Displayname:    "app",
+				Activate:       true,
+			}},
+		}}
+	case strings.HasSuffix(r.URL.Path, "/info"):
+		var msg info.ZInfoMsg
+		if err := proto.Unmarshal(container.GetProtectedPayload().GetPayload(), &msg); err == nil &&
+			msg.GetZtype() == info.ZInfoTypes_ZiApp {
+			c.mu.Lock()
+			c.appStates = append(c.appStates, msg.GetAinfo().GetState())
+			c.mu.Unlock()
+		}
+	}
+	if resp == nil {
+		return
+	}
+	payload, _ := proto.Marshal(resp)
+	data, _ := proto.Marshal(&auth.AuthContainer{ProtectedPayload: &auth.AuthBody{Payload: payload}})
+	_, _ = w.Write(data)
+}
+
+func (c *fakeController) states() []info.ZSwState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]info.ZSwState{}, c.appStates...)
+}
+
+func TestSimulator(t *testing.T) {
+	t.Parallel()
+
+	certsDir := t.TempDir()
+	rootCert, rootKey := utils.GenCARoot()
+	assert.NoError(t, utils.WriteToFiles(rootCert, rootKey,
+		filepath.Join(certsDir, "root-certificate.pem"), filepath.Join(certsDir, "root-key.pem")))
+	onboardCert, onboardKey := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(2), nil, nil, "onboard")
+	assert.NoError(t, utils.WriteToFiles(onboardCert, onboardKey,
+		filepath.Join(certsDir, "onboard.cert.pem"), filepath.Join(certsDir, "onboard.key.pem")))
+	serverCert, serverKey := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(3),
+		[]net.IP{net.ParseIP("127.0.0.1")}, []string{"mydomain.adam"}, "mydomain.adam")
+	serverCertFile, serverKeyFile := filepath.Join(certsDir, "server.pem"), filepath.Join(certsDir, "server-key.pem")
+	assert.NoError(t, utils.WriteToFiles(serverCert, serverKey, serverCertFile, serverKeyFile))
+	pair, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctrl := &fakeController{}
+	server := httptest.NewUnstartedServer(ctrl)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{pair}, ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	sim, err := evesim.New(evesim.Config{
+		CertsDir:      certsDir,
+		ControllerURL: server.URL,
+		ServerName:    "mydomain.adam",
+		Serial:        "31415926",
+		Version:       "0.0.0-sim",
+		Interval:      50 * time.Millisecond,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		assert.NoError(t, sim.Run(ctx))
+	}()
+	assert.Eventually(t, func() bool {
+		states := ctrl.states()
+		return len(states) > 1 && states[len(states)-1] == info.ZSwState_RUNNING
+	}, 10*time.Second, 50*time.Millisecond)
+	assert.Equal(t, info.ZSwState_INSTALLED, ctrl.states()[0])
+}
@@ -0,0 +1,286 @@
+package evesim
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// simulated resources of device
+const (
+	simCPUs      = 2
+	simMemoryMB  = 4096
+	simStorageMB = 8192
+	simAppMemMB  = 256
+)
+
+// appState is simulated state of app instance
+type appState struct {
+	state    info.ZSwState
+	bootTime time.Time
+}
+
+// state keeps simulated state of objects from the applied config
+type state struct {
+	devID    string
+	version  string
+	bootTime time.Time
+	apps     map[string]*appState
+	// reported holds keys of objects reported in info to notify controller about removal
+	reported map[string]bool
+	logs     []*logs.LogEntry
+}
+
+func newState(devID, version string, bootTime time.Time) *state {
+	return &state{
+		devID:    devID,
+		version:  version,
+		bootTime: bootTime,
+		apps:     map[string]*appState{},
+		reported: map[string]bool{},
+	}
+}
+
+// log adds entry into queue of logs to send
+func (s *state) log(now time.Time, severity, format string, args ...interface{}) {
+	s.logs = append(s.logs, &logs.LogEntry{
+		Severity:  severity,
+		Source:    "zedmanager",
+		Content:   fmt.Sprintf(format, args...),
+		Timestamp: timestamppb.New(now),
+	})
+}
+
+// takeLogs returns accumulated logs and cleans the queue
+func (s *state) takeLogs() []*logs.LogEntry {
+	result := s.logs
+	s.logs = nil
+	return result
+}
+
+// advanceApp moves app one step to the state expected by config
+func (s *state) advanceApp(app *config.AppInstanceConfig, now time.Time) *appState {
+	id := app.GetUuidandversion().GetUuid()
+	current, ok := s.apps[id]
+	if !ok {
+		current = &appState{state: info.ZSwState_INSTALLED}
+		s.apps[id] = current
+		s.log(now, "info", "app %s (%s) installed", app.GetDisplayname(), id)
+		return current
+	}
+	next := info.ZSwState_HALTED
+	if app.GetActivate() {
+		next = info.ZSwState_RUNNING
+	}
+	if current.state != next {
+		current.state = next
+		if next == info.ZSwState_RUNNING {
+			current.bootTime = now
+		}
+		s.log(now, "info", "app %s (%s) state changed to %s", app.GetDisplayname(), id, next)
+	}
+	return current
+}
+
+// reflect returns info messages describing config as applied on device.
+// Apps advance one step on every call: they are INSTALLED first and
+// RUNNING (or HALTED if not activated) on the next call.
+// Objects removed from config are reported once with empty info.
+func (s *state) reflect(cfg *config.EdgeDevConfig, now time.Time) []*info.ZInfoMsg {
+	var result []*info.ZInfoMsg
+	seen := map[string]bool{}
+	add := func(key string, msg *info.ZInfoMsg) {
+		seen[key] = true
+		s.reported[key] = true
+		msg.DevId = s.devID
+		msg.AtTimeStamp = timestamppb.New(now)
+		result = append(result, msg)
+	}
+
+	add("device", &info.ZInfoMsg{
+		Ztype:       info.ZInfoTypes_ZiDevice,
+		InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: s.deviceInfo(cfg)},
+	})
+	for _, nic := range cfg.GetNetworkInstances() {
+		id := nic.GetUuidandversion().GetUuid()
+		add("ni/"+id, &info.ZInfoMsg{
+			Ztype: info.ZInfoTypes_ZiNetworkInstance,
+			InfoContent: &info.ZInfoMsg_Niinfo{Niinfo: &info.ZInfoNetworkInstance{
+				NetworkID:      id,
+				NetworkVersion: nic.GetUuidandversion().GetVersion(),
+				InstType:       uint32(nic.GetInstType()),
+				Displayname:    nic.GetDisplayname(),
+				Activated:      true,
+				UpTimeStamp:    timestamppb.New(s.bootTime),
+				Ports:          []string{nic.GetPort().GetName()},
+			}},
+		})
+	}
+	for _, ct := range cfg.GetContentInfo() {
+		add("ct/"+ct.GetUuid(), &info.ZInfoMsg{
+			Ztype: info.ZInfoTypes_ZiContentTree,
+			InfoContent: &info.ZInfoMsg_Cinfo{Cinfo: &info.ZInfoContentTree{
+				Uuid:               ct.GetUuid(),
+				DisplayName:        ct.GetDisplayName(),
+				Sha256:             ct.GetSha256(),
+				State:              info.ZSwState_LOADED,
+				ProgressPercentage: 100,
+			}},
+		})
+	}
+	for _, vol := range cfg.GetVolumes() {
+		add("volume/"+vol.GetUuid(), &info.ZInfoMsg{
+			Ztype: info.ZInfoTypes_ZiVolume,
+			InfoContent: &info.ZInfoMsg_Vinfo{Vinfo: &info.ZInfoVolume{
+				Uuid:               vol.GetUuid(),
+				DisplayName:        vol.GetDisplayName(),
+				State:              info.ZSwState_CREATED_VOLUME,
+				ProgressPercentage: 100,
+				GenerationCount:    vol.GetGenerationCount(),
+			}},
+		})
+	}
+	for _, app := range cfg.GetApps() {
+		id := app.GetUuidandversion().GetUuid()
+		current := s.advanceApp(app, now)
+		ainfo := &info.ZInfoApp{
+			AppID:      id,
+			AppVersion: app.GetUuidandversion().GetVersion(),
+			AppName:    app.GetDisplayname(),
+			State:      current.state,
+		}
+		if !current.bootTime.IsZero() {
+			ainfo.BootTime = timestamppb.New(current.bootTime)
+		}
+		for _, ref := range app.GetVolumeRefList() {
+			ainfo.VolumeRefs = append(ainfo.VolumeRefs, ref.GetUuid())
+		}
+		add("app/"+id, &info.ZInfoMsg{
+			Ztype:       info.ZInfoTypes_ZiApp,
+			InfoContent: &info.ZInfoMsg_Ainfo{Ainfo: ainfo},
+		})
+	}
+	result = append(result, s.removed(seen, now)...)
+	return result
+}
+
+// removed returns empty info messages for objects not in config anymore
+func (s *state) removed(seen map[string]bool, now time.Time) []*info.ZInfoMsg {
+	var keys []string
+	for key := range s.reported {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var result []*info.ZInfoMsg
+	for _, key := range keys {
+		delete(s.reported, key)
+		msg := &info.ZInfoMsg{DevId: s.devID, AtTimeStamp: timestamppb.New(now)}
+		kind, id, _ := strings.Cut(key, "/")
+		switch kind {
+		case "app":
+			delete(s.apps, id)
+			s.log(now, "info", "app %s removed", id)
+			msg.Ztype = info.ZInfoTypes_ZiApp
+			msg.InfoContent = &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{AppID: id}}
+		case "volume":
+			msg.Ztype = info.ZInfoTypes_ZiVolume
+			msg.InfoContent = &info.ZInfoMsg_Vinfo{Vinfo: &info.ZInfoVolume{Uuid: id}}
+		case "ct":
+			msg.Ztype = info.ZInfoTypes_ZiContentTree
+			msg.InfoContent = &info.ZInfoMsg_Cinfo{Cinfo: &info.ZInfoContentTree{Uuid: id}}
+		case "ni":
+			msg.Ztype = info.ZInfoTypes_ZiNetworkInstance
+			msg.InfoContent = &info.ZInfoMsg_Niinfo{Niinfo: &info.ZInfoNetworkInstance{NetworkID: id}}
+		default:
+			continue
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
+// deviceInfo returns info about device with ports from config
+func (s *state) deviceInfo(cfg *config.EdgeDevConfig) *info.ZInfoDevice {
+	dinfo := &info.ZInfoDevice{
+		MachineArch: runtime.GOARCH,
+		CpuArch:     runtime.GOARCH,
+		Ncpu:        simCPUs,
+		Memory:      simMemoryMB,
+		Storage:     simStorageMB,
+		BootTime:    timestamppb.New(s.bootTime),
+		HostName:    "eve-sim",
+		State:       info.ZDeviceState_ZDEVICE_STATE_ONLINE,
+		SwList: []*info.ZInfoDevSW{{
+			Activated:      true,
+			PartitionLabel: "IMGA",
+			PartitionState: "active",
+			Status:         info.ZSwState_RUNNING,
+			ShortVersion:   s.version,
+			LongVersion:    s.version,
+		}},
+	}
+	for i, adapter := range cfg.GetSystemAdapterList() {
+		dinfo.Network = append(dinfo.Network, &info.ZInfoNetwork{
+			DevName: adapter.GetName(),
+			Alias:   adapter.GetAlias(),
+			// addresses from TEST-NET-1 range as ports are not real
+			IPAddrs: []string{fmt.Sprintf("192.0.2.%d", i+10)},
+			MacAddr: fmt.Sprintf("02:00:00:00:00:%02x", i),
+			Ipv4Up:  true,
+			Uplink:  adapter.GetUplink(),
+		})
+	}
+	return dinfo
+}
+
+// metrics returns metrics of device and running apps
+func (s *state) metrics(now time.Time) *metrics.ZMetricMsg {
+	upTime := timestamppb.New(s.bootTime)
+	msg := &metrics.ZMetricMsg{
+		DevID:       s.devID,
+		AtTimeStamp: timestamppb.New(now),
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory: &metrics.MemoryMetric{
+				UsedMem:         simMemoryMB / 4,
+				AvailMem:        simMemoryMB * 3 / 4,
+				UsedPercentage:  25,
+				AvailPercentage: 75,
+			},
+			CpuMetric: &metrics.AppCpuMetric{
+				UpTime: upTime,
+				Total:  uint64(now.Sub(s.bootTime).Seconds()),
+			},
+		}},
+	}
+	var ids []string
+	for id, app := range s.apps {
+		if app.state == info.ZSwState_RUNNING {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		msg.Am = append(msg.Am, &metrics.AppMetric{
+			AppID: id,
+			Cpu: &metrics.AppCpuMetric{
+				UpTime: timestamppb.New(s.apps[id].bootTime),
+				Total:  uint64(now.Sub(s.apps[id].bootTime).Seconds()),
+			},
+			Memory: &metrics.MemoryMetric{
+				UsedMem:  simAppMemMB / 2,
+				AvailMem: simAppMemMB / 2,
+			},
+		})
+	}
+	return msg
+}
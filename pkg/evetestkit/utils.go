@@ -225,6 +225,29 @@ func (node *EveNode) EveDeleteFile(fileName string) error {
 	return err
 }
 
+// EveCollectInfo runs collect-info on the EVE node, downloads the bundle
+// into outputDir and returns index of the unpacked bundle
+func (node *EveNode) EveCollectInfo(outputDir string) (*openevec.CollectInfoIndex, error) {
+	return node.controller.DebugCollect(outputDir, "", false)
+}
+
+// EveCollectInfoOnFailure registers cleanup function which collects device
+// diagnostics into outputDir if the test fails
+func (node *EveNode) EveCollectInfoOnFailure(t *testing.T, outputDir string) {
+	t.Cleanup(func() {
+		if !t.Failed() {
+			return
+		}
+		index, err := node.EveCollectInfo(outputDir)
+		if err != nil {
+			t.Logf("failed to collect info from EVE: %v", err)
+			return
+		}
+		t.Logf("EVE collect-info bundle for failed test %s: %s (unpacked into %s)",
+			t.Name(), index.Tarball, index.Dir)
+	})
+}
+
 // AppWaitForRunningState waits for an app to start and become running on the EVE node
 func (node *EveNode) AppWaitForRunningState(appName string, timeoutSeconds uint) error {
 	start := time.Now()
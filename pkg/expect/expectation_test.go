@@ -0,0 +1,41 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/memory"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/expect"
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplication(t *testing.T) {
+	t.Parallel()
+
+	ctrl := &controller.CloudCtx{Controller: memory.New()}
+	ctrl.SetVars(&utils.ConfigVars{ZArch: "amd64"})
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f"))
+
+	app := expect.AppExpectationFromURL(ctrl, dev, "docker://127.0.0.1/test/nginx:1", "nginx",
+		expect.WithPortsPublish([]string{"8027:80"})).Application()
+	assert.Equal(t, "nginx", app.Displayname)
+	stored, err := ctrl.GetApplicationInstanceConfig(app.Uuidandversion.Uuid)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, app, stored)
+	if assert.Len(t, dev.GetVolumes(), 1) {
+		_, err = ctrl.GetVolume(dev.GetVolumes()[0])
+		assert.NoError(t, err)
+	}
+	dev.SetApplicationInstanceConfig([]string{app.Uuidandversion.Uuid})
+
+	// expectation of app with the same name returns the app already in controller
+	again := expect.AppExpectationFromURL(ctrl, dev, "docker://127.0.0.1/test/nginx:1", "nginx").Application()
+	assert.Equal(t, app.Uuidandversion.Uuid, again.Uuidandversion.Uuid)
+	assert.Len(t, dev.GetVolumes(), 1)
+}
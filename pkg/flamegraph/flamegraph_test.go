@@ -0,0 +1,107 @@
+package flamegraph_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/lf-edge/eden/pkg/flamegraph"
+	"github.com/stretchr/testify/assert"
+)
+
+const perfScript = `# ========
+# captured on: Thu Jan  1 00:00:00 2024
+# ========
+swapper     0 [000] 12345.678901:   10101010 cpu-clock:pppH:
+	ffffffff8106a0b6 native_safe_halt+0x6 ([kernel.kallsyms])
+	ffffffff81038f2e default_idle+0x1e ([kernel.kallsyms])
+	ffffffff810b1e2c do_idle+0x1dc ([kernel.kallsyms])
+
+swapper     0 [001] 12345.688901:   10101010 cpu-clock:pppH:
+	ffffffff8106a0b6 native_safe_halt+0x6 ([kernel.kallsyms])
+	ffffffff81038f2e default_idle+0x1e ([kernel.kallsyms])
+	ffffffff810b1e2c do_idle+0x1dc ([kernel.kallsyms])
+
+kworker/u8:2 1234/1235 [002] 12345.698901:   10101010 cpu-clock:pppH:
+	7f1c2d3e4f50 [unknown] (/usr/lib/libc.so.6)
+	55d0c0ffee00 main+0x20 (/usr/bin/zedbox)
+
+`
+
+func TestParsePerfScript(t *testing.T) {
+	t.Parallel()
+
+	profile, err := flamegraph.ParsePerfScript(strings.NewReader(perfScript))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, flamegraph.Profile{
+		"swapper;do_idle;default_idle;native_safe_halt": 2,
+		"kworker/u8:2;main;[libc.so.6]":                 1,
+	}, profile)
+	assert.Equal(t, int64(3), profile.Total())
+
+	var folded bytes.Buffer
+	assert.NoError(t, profile.WriteFolded(&folded))
+	parsed, err := flamegraph.ParseFolded(&folded)
+	assert.NoError(t, err)
+	assert.Equal(t, profile, parsed)
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	perfFile := filepath.Join(dir, "perf.out")
+	foldedFile := filepath.Join(dir, "perf.folded")
+	assert.NoError(t, os.WriteFile(perfFile, []byte(perfScript), 0644))
+	assert.NoError(t, os.WriteFile(foldedFile, []byte("a;b 3\na;c 1\n"), 0644))
+
+	profile, err := flamegraph.Load(perfFile)
+	assert.NoError(t, err)
+	assert.Len(t, profile, 2)
+	profile, err = flamegraph.Load(foldedFile)
+	assert.NoError(t, err)
+	assert.Equal(t, flamegraph.Profile{"a;b": 3, "a;c": 1}, profile)
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	profile := flamegraph.Profile{"main;work;<compute>": 30, "main;idle": 10}
+	baseline := flamegraph.Profile{"main;work;<compute>": 10, "main;idle": 30}
+	for _, format := range []flamegraph.Format{flamegraph.FormatSVG, flamegraph.FormatHTML} {
+		for _, opts := range []flamegraph.Options{{}, {Baseline: baseline}} {
+			var out bytes.Buffer
+			if !assert.NoError(t, flamegraph.Render(&out, profile, format, opts)) {
+				continue
+			}
+			rendered := out.String()
+			assert.Contains(t, rendered, "&lt;compute&gt; (30 samples, 75.00%")
+			if format == flamegraph.FormatSVG {
+				// SVG must be a well-formed XML document.
+				var doc struct{}
+				assert.NoError(t, xml.Unmarshal(out.Bytes(), &doc))
+			}
+			if opts.Baseline != nil {
+				assert.Contains(t, rendered, "Differential Flame Graph")
+				assert.Contains(t, rendered, "idle (10 samples, 25.00%; -50.00%)")
+				assert.Contains(t, rendered, "fill=\"rgb(0,0,255)\"")
+			}
+		}
+	}
+	assert.Error(t, flamegraph.Render(&bytes.Buffer{}, flamegraph.Profile{},
+		flamegraph.FormatSVG, flamegraph.Options{}))
+	// names of narrow frames are truncated without breaking multibyte characters
+	var out bytes.Buffer
+	assert.NoError(t, flamegraph.Render(&out, flamegraph.Profile{
+		"main;" + strings.Repeat("ж", 200): 1, "main;x": 3}, flamegraph.FormatSVG, flamegraph.Options{}))
+	assert.True(t, utf8.Valid(out.Bytes()))
+	assert.Contains(t, out.String(), "жж..")
+	assert.Equal(t, flamegraph.FormatHTML, flamegraph.FormatFromPath("out.HTML"))
+	assert.Equal(t, flamegraph.FormatSVG, flamegraph.FormatFromPath("out"))
+}
@@ -0,0 +1,206 @@
+// Package flamegraph folds stacks sampled by perf and renders them as flame graphs
+// (https://www.brendangregg.com/flamegraphs.html) in SVG or HTML.
+package flamegraph
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxLineLen is the maximum length of a single line of the parsed input.
+const maxLineLen = 1024 * 1024
+
+// Profile maps folded stacks (frames from the root separated by ';')
+// to the number of samples.
+type Profile map[string]int64
+
+var (
+	pidRe    = regexp.MustCompile(`^\d+(/\d+)?$`)
+	offsetRe = regexp.MustCompile(`\+0x[0-9a-fA-F]+$`)
+)
+
+// Total returns the number of samples in the profile.
+func (p Profile) Total() (total int64) {
+	for _, count := range p {
+		total += count
+	}
+	return total
+}
+
+// WriteFolded writes the profile in the folded format, one stack per line,
+// followed by the number of samples.
+func (p Profile) WriteFolded(w io.Writer) error {
+	stacks := make([]string, 0, len(p))
+	for stack := range p {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	for _, stack := range stacks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, p[stack]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParsePerfScript folds stacks from the output of "perf script".
+// Every event starts with a header line (process name, PID, CPU, time, event)
+// followed by indented stack frames (leaf first) and ends with an empty line.
+// The process name is used as the root frame.
+func ParsePerfScript(r io.Reader) (Profile, error) {
+	profile := make(Profile)
+	var comm string
+	var frames []string
+	inEvent := false
+	flush := func() {
+		if inEvent {
+			stack := make([]string, 0, len(frames)+1)
+			stack = append(stack, comm)
+			for i := len(frames) - 1; i >= 0; i-- {
+				stack = append(stack, frames[i])
+			}
+			profile[strings.Join(stack, ";")]++
+		}
+		inEvent = false
+		frames = frames[:0]
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLen)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.TrimSpace(line) == "":
+			flush()
+		case line[0] == ' ' || line[0] == '\t':
+			if inEvent {
+				if frame := parseFrame(line); frame != "" {
+					frames = append(frames, frame)
+				}
+			}
+		default:
+			flush()
+			comm = parseComm(line)
+			inEvent = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return profile, nil
+}
+
+// parseComm returns process name from the event header line,
+// i.e. all fields preceding the PID (or PID/TID).
+func parseComm(line string) string {
+	fields := strings.Fields(line)
+	for i := 1; i < len(fields); i++ {
+		if pidRe.MatchString(fields[i]) {
+			return sanitizeFrame(strings.Join(fields[:i], "_"))
+		}
+	}
+	return sanitizeFrame(fields[0])
+}
+
+// parseFrame returns function name from the stack frame line
+// "<address> <symbol>+<offset> (<module>)".
+// Unknown symbols are replaced with the module name.
+func parseFrame(line string) string {
+	line = strings.TrimSpace(line)
+	// Skip the address.
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		line = strings.TrimSpace(line[i:])
+	} else {
+		return ""
+	}
+	symbol, module := line, ""
+	if strings.HasSuffix(line, ")") {
+		if i := strings.LastIndex(line, " ("); i >= 0 {
+			symbol = strings.TrimSpace(line[:i])
+			module = line[i+2 : len(line)-1]
+		} else if strings.HasPrefix(line, "(") {
+			symbol, module = "", line[1:len(line)-1]
+		}
+	}
+	symbol = offsetRe.ReplaceAllString(symbol, "")
+	if symbol == "" || symbol == "[unknown]" {
+		if module == "" || module == "unknown" {
+			return "[unknown]"
+		}
+		if i := strings.LastIndex(module, "/"); i >= 0 {
+			module = module[i+1:]
+		}
+		return sanitizeFrame("[" + strings.Trim(module, "[]") + "]")
+	}
+	return sanitizeFrame(symbol)
+}
+
+// sanitizeFrame removes characters with special meaning in the folded format.
+func sanitizeFrame(frame string) string {
+	return strings.NewReplacer(";", ":", "\n", " ").Replace(frame)
+}
+
+// ParseFolded reads profile in the folded format ("frame1;frame2;... count").
+func ParseFolded(r io.Reader) (Profile, error) {
+	profile := make(Profile)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLen)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing sample count", lineNum)
+		}
+		count, err := strconv.ParseInt(line[i+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid sample count: %w", lineNum, err)
+		}
+		profile[strings.TrimSpace(line[:i])] += count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Load reads profile from the file with either perf script output
+// or already folded stacks (detected by the presence of indented stack frames).
+func Load(path string) (Profile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var profile Profile
+	if isPerfScript(content) {
+		profile, err = ParsePerfScript(bytes.NewReader(content))
+	} else {
+		profile, err = ParseFolded(bytes.NewReader(content))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return profile, nil
+}
+
+func isPerfScript(content []byte) bool {
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			return true
+		}
+	}
+	return false
+}
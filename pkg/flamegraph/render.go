@@ -0,0 +1,298 @@
+package flamegraph
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Format of the rendered flame graph.
+type Format string
+
+const (
+	// FormatSVG is a standalone interactive SVG image.
+	FormatSVG Format = "svg"
+	// FormatHTML is an HTML page with the embedded interactive SVG image.
+	FormatHTML Format = "html"
+	// FormatFolded are folded stacks, which can be used as a baseline later.
+	FormatFolded Format = "folded"
+)
+
+const (
+	defaultWidth  = 1200
+	frameHeight   = 16
+	fontSize      = 12
+	fontWidth     = 0.59
+	xPad          = 10
+	topPad        = fontSize * 3
+	bottomPad     = fontSize*2 + 10
+	minFrameWidth = 0.1
+)
+
+// ParseFormat checks that the given string is a supported format.
+func ParseFormat(format string) (Format, error) {
+	switch Format(strings.ToLower(format)) {
+	case FormatSVG:
+		return FormatSVG, nil
+	case FormatHTML:
+		return FormatHTML, nil
+	case FormatFolded:
+		return FormatFolded, nil
+	}
+	return "", fmt.Errorf("unsupported flame graph format %q (expected svg, html or folded)", format)
+}
+
+// FormatFromPath returns format based on the extension of the output file (SVG by default).
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return FormatHTML
+	case ".folded":
+		return FormatFolded
+	}
+	return FormatSVG
+}
+
+// Options for rendering of a flame graph.
+type Options struct {
+	// Title of the flame graph.
+	Title string
+	// Width of the image in pixels (1200 by default).
+	Width int
+	// Baseline, if not nil, turns the output into a differential flame graph.
+	// Frame widths are taken from the rendered profile, colors show the difference
+	// against the baseline normalized to the same number of samples:
+	// red frames grew, blue frames shrank.
+	Baseline Profile
+}
+
+// frame is a node in the tree of folded stacks.
+type frame struct {
+	name     string
+	samples  int64
+	baseline float64
+	children map[string]*frame
+}
+
+func (f *frame) child(name string) *frame {
+	if f.children == nil {
+		f.children = make(map[string]*frame)
+	}
+	child := f.children[name]
+	if child == nil {
+		child = &frame{name: name}
+		f.children[name] = child
+	}
+	return child
+}
+
+// sortedChildren returns children in the alphabetical order.
+func (f *frame) sortedChildren() []*frame {
+	children := make([]*frame, 0, len(f.children))
+	for _, child := range f.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].name < children[j].name
+	})
+	return children
+}
+
+func buildTree(profile, baseline Profile) *frame {
+	root := &frame{name: "all"}
+	for stack, count := range profile {
+		node := root
+		node.samples += count
+		for _, name := range strings.Split(stack, ";") {
+			node = node.child(name)
+			node.samples += count
+		}
+	}
+	baselineTotal := baseline.Total()
+	if baselineTotal == 0 {
+		return root
+	}
+	ratio := float64(root.samples) / float64(baselineTotal)
+	for stack, count := range baseline {
+		node := root
+		node.baseline += float64(count) * ratio
+		for _, name := range strings.Split(stack, ";") {
+			node = node.child(name)
+			node.baseline += float64(count) * ratio
+		}
+	}
+	return root
+}
+
+// Render writes the profile into w in the given format.
+func Render(w io.Writer, profile Profile, format Format, opts Options) error {
+	switch format {
+	case FormatFolded:
+		return profile.WriteFolded(w)
+	case FormatSVG:
+		bw := bufio.NewWriter(w)
+		if err := writeSVG(bw, profile, opts, true); err != nil {
+			return err
+		}
+		return bw.Flush()
+	case FormatHTML:
+		bw := bufio.NewWriter(w)
+		fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+		fmt.Fprintf(bw, "<title>%s</title>\n", html.EscapeString(title(opts)))
+		fmt.Fprintf(bw, "<style>body { margin: 0; } svg { display: block; }</style>\n")
+		fmt.Fprintf(bw, "</head>\n<body>\n")
+		if err := writeSVG(bw, profile, opts, false); err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "</body>\n</html>\n")
+		return bw.Flush()
+	}
+	return fmt.Errorf("unsupported flame graph format %q", format)
+}
+
+func title(opts Options) string {
+	if opts.Title != "" {
+		return opts.Title
+	}
+	if opts.Baseline != nil {
+		return "Differential Flame Graph"
+	}
+	return "Flame Graph"
+}
+
+// frameRect is a frame placed into the image.
+type frameRect struct {
+	frame *frame
+	x     float64
+	width float64
+	depth int
+}
+
+func writeSVG(w io.Writer, profile Profile, opts Options, standalone bool) error {
+	root := buildTree(profile, opts.Baseline)
+	if root.samples == 0 {
+		return errors.New("no samples in the profile")
+	}
+	width := opts.Width
+	if width <= 0 {
+		width = defaultWidth
+	}
+	scale := float64(width-2*xPad) / float64(root.samples)
+	var rects []frameRect
+	var maxDepth int
+	var maxDelta float64
+	var layout func(f *frame, x float64, depth int)
+	layout = func(f *frame, x float64, depth int) {
+		rectWidth := float64(f.samples) * scale
+		if rectWidth < minFrameWidth {
+			return
+		}
+		rects = append(rects, frameRect{frame: f, x: x, width: rectWidth, depth: depth})
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		if delta := math.Abs(float64(f.samples) - f.baseline); delta > maxDelta {
+			maxDelta = delta
+		}
+		for _, child := range f.sortedChildren() {
+			layout(child, x, depth+1)
+			x += float64(child.samples) * scale
+		}
+	}
+	layout(root, xPad, 0)
+	height := topPad + (maxDepth+1)*frameHeight + bottomPad
+
+	if standalone {
+		fmt.Fprintf(w, "<?xml version=\"1.0\" standalone=\"no\"?>\n")
+	}
+	fmt.Fprintf(w, "<svg version=\"1.1\" id=\"flamegraph\" width=\"%d\" height=\"%d\" "+
+		"viewBox=\"0 0 %d %d\" xmlns=\"http://www.w3.org/2000/svg\">\n",
+		width, height, width, height)
+	fmt.Fprintf(w, "<defs><linearGradient id=\"background\" y1=\"0\" y2=\"1\" x1=\"0\" x2=\"0\">"+
+		"<stop stop-color=\"#eeeeee\" offset=\"5%%\"/><stop stop-color=\"#eeeeb0\" offset=\"95%%\"/>"+
+		"</linearGradient></defs>\n")
+	fmt.Fprintf(w, "<style type=\"text/css\">text { font-family: Verdana, sans-serif; "+
+		"font-size: %dpx; fill: rgb(0,0,0); } #title { text-anchor: middle; font-size: 17px; } "+
+		"#frames > g:hover { stroke: black; stroke-width: 0.5; cursor: pointer; }</style>\n", fontSize)
+	fmt.Fprintf(w, "<rect x=\"0\" y=\"0\" width=\"%d\" height=\"%d\" fill=\"url(#background)\"/>\n",
+		width, height)
+	fmt.Fprintf(w, "<text id=\"title\" x=\"%d\" y=\"24\">%s</text>\n",
+		width/2, html.EscapeString(title(opts)))
+	fmt.Fprintf(w, "<text id=\"details\" x=\"%d\" y=\"%d\"> </text>\n", xPad, height-17)
+	fmt.Fprintf(w, "<text id=\"unzoom\" x=\"%d\" y=\"24\" style=\"opacity: 0; cursor: pointer\">"+
+		"Reset Zoom</text>\n", xPad)
+	fmt.Fprintf(w, "<text id=\"search\" x=\"%d\" y=\"24\" style=\"cursor: pointer\">Search</text>\n",
+		width-xPad-100)
+	fmt.Fprintf(w, "<text id=\"matched\" x=\"%d\" y=\"%d\"> </text>\n", width-xPad-100, height-17)
+	fmt.Fprintf(w, "<g id=\"frames\">\n")
+	for _, rect := range rects {
+		f := rect.frame
+		y := height - bottomPad - (rect.depth+1)*frameHeight
+		info := fmt.Sprintf("%s (%d samples, %.2f%%", f.name, f.samples,
+			100*float64(f.samples)/float64(root.samples))
+		color := hotColor(f.name)
+		if opts.Baseline != nil {
+			delta := float64(f.samples) - f.baseline
+			info += fmt.Sprintf("; %+.2f%%", 100*delta/float64(root.samples))
+			color = diffColor(delta, maxDelta)
+		}
+		info += ")"
+		name := html.EscapeString(f.name)
+		fmt.Fprintf(w, "<g data-n=\"%s\" data-x=\"%.2f\" data-w=\"%.2f\" data-c=\"%s\">"+
+			"<title>%s</title>", name, rect.x, rect.width, color, html.EscapeString(info))
+		fmt.Fprintf(w, "<rect x=\"%.2f\" y=\"%d\" width=\"%.2f\" height=\"%d\" fill=\"%s\" "+
+			"rx=\"2\" ry=\"2\"/>", rect.x, y, rect.width, frameHeight-1, color)
+		fmt.Fprintf(w, "<text x=\"%.2f\" y=\"%d\">%s</text></g>\n", rect.x+3,
+			y+frameHeight-5, html.EscapeString(fitText(f.name, rect.width)))
+	}
+	fmt.Fprintf(w, "</g>\n")
+	fmt.Fprintf(w, "<script type=\"text/ecmascript\"><![CDATA[\n")
+	fmt.Fprintf(w, "var fgWidth = %d, fgPad = %d, fgFontSize = %d, fgFontWidth = %v;\n",
+		width, xPad, fontSize, fontWidth)
+	fmt.Fprintf(w, "%s]]></script>\n", script)
+	_, err := fmt.Fprintf(w, "</svg>\n")
+	return err
+}
+
+// fitText truncates frame name to fit into the frame of the given width.
+func fitText(name string, width float64) string {
+	chars := int((width - 6) / (fontSize * fontWidth))
+	if chars < 3 {
+		return ""
+	}
+	if runes := []rune(name); len(runes) > chars {
+		return string(runes[:chars-2]) + ".."
+	}
+	return name
+}
+
+// hotColor returns a color from the "hot" palette, stable for the given frame name.
+func hotColor(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	sum := h.Sum32()
+	v1 := float64(sum&0xff) / 255
+	v2 := float64((sum>>8)&0xff) / 255
+	v3 := float64((sum>>16)&0xff) / 255
+	return fmt.Sprintf("rgb(%d,%d,%d)", 205+int(50*v3), int(230*v1), int(55*v2))
+}
+
+// diffColor returns red for frames that grew, blue for frames that shrank
+// with intensity relative to the largest difference.
+func diffColor(delta, maxDelta float64) string {
+	if maxDelta == 0 || delta == 0 {
+		return "rgb(250,250,250)"
+	}
+	shade := int(210 * (1 - math.Abs(delta)/maxDelta))
+	if delta > 0 {
+		return fmt.Sprintf("rgb(255,%d,%d)", shade, shade)
+	}
+	return fmt.Sprintf("rgb(%d,%d,255)", shade, shade)
+}
@@ -0,0 +1,109 @@
+package flamegraph
+
+// script implements zoom (click on a frame), search and frame details
+// in the rendered SVG. Expects fgWidth, fgPad, fgFontSize and fgFontWidth
+// to be defined.
+const script = `(function () {
+	var frames = document.querySelectorAll("#frames > g");
+	var details = document.getElementById("details");
+	var unzoomButton = document.getElementById("unzoom");
+	var searchButton = document.getElementById("search");
+	var matched = document.getElementById("matched");
+	var searching = false;
+
+	function attr(g, name) {
+		return parseFloat(g.getAttribute(name));
+	}
+
+	function fitText(g, width) {
+		var text = g.querySelector("text");
+		var name = g.getAttribute("data-n");
+		var chars = Math.floor((width - 6) / (fgFontSize * fgFontWidth));
+		if (chars < 3) {
+			text.textContent = "";
+		} else if (name.length > chars) {
+			text.textContent = name.substring(0, chars - 2) + "..";
+		} else {
+			text.textContent = name;
+		}
+	}
+
+	function zoom(zoomX, zoomWidth) {
+		var scale = (fgWidth - 2 * fgPad) / zoomWidth;
+		var eps = 0.0001;
+		for (var i = 0; i < frames.length; i++) {
+			var g = frames[i];
+			var x = attr(g, "data-x"), width = attr(g, "data-w");
+			if (x + width <= zoomX + eps || x >= zoomX + zoomWidth - eps) {
+				g.style.display = "none";
+				continue;
+			}
+			g.style.display = "";
+			var newX = Math.max(x, zoomX);
+			var newWidth = (Math.min(x + width, zoomX + zoomWidth) - newX) * scale;
+			newX = fgPad + (newX - zoomX) * scale;
+			g.querySelector("rect").setAttribute("x", newX);
+			g.querySelector("rect").setAttribute("width", newWidth);
+			g.querySelector("text").setAttribute("x", newX + 3);
+			fitText(g, newWidth);
+		}
+	}
+
+	function search(term) {
+		var re = term ? new RegExp(term) : null;
+		var matches = [];
+		for (var i = 0; i < frames.length; i++) {
+			var g = frames[i];
+			var rect = g.querySelector("rect");
+			if (re && re.test(g.getAttribute("data-n"))) {
+				rect.setAttribute("fill", "rgb(230,0,230)");
+				matches.push([attr(g, "data-x"), attr(g, "data-x") + attr(g, "data-w")]);
+			} else {
+				rect.setAttribute("fill", g.getAttribute("data-c"));
+			}
+		}
+		matches.sort(function (a, b) { return a[0] - b[0]; });
+		var covered = 0, end = 0;
+		for (var j = 0; j < matches.length; j++) {
+			if (matches[j][0] >= end) {
+				covered += matches[j][1] - matches[j][0];
+				end = matches[j][1];
+			} else if (matches[j][1] > end) {
+				covered += matches[j][1] - end;
+				end = matches[j][1];
+			}
+		}
+		searching = re !== null;
+		searchButton.textContent = searching ? "Reset Search" : "Search";
+		matched.textContent = searching ?
+			"Matched: " + (100 * covered / (fgWidth - 2 * fgPad)).toFixed(1) + "%" : " ";
+	}
+
+	for (var i = 0; i < frames.length; i++) {
+		frames[i].addEventListener("click", function () {
+			zoom(attr(this, "data-x"), attr(this, "data-w"));
+			unzoomButton.style.opacity = "1";
+		});
+		frames[i].addEventListener("mouseover", function () {
+			details.textContent = this.querySelector("title").textContent;
+		});
+		frames[i].addEventListener("mouseout", function () {
+			details.textContent = " ";
+		});
+	}
+	unzoomButton.addEventListener("click", function () {
+		zoom(fgPad, fgWidth - 2 * fgPad);
+		unzoomButton.style.opacity = "0";
+	});
+	searchButton.addEventListener("click", function () {
+		if (searching) {
+			search("");
+			return;
+		}
+		var term = prompt("Enter a search term (regexp allowed):", "");
+		if (term) {
+			search(term);
+		}
+	});
+})();
+`
@@ -121,6 +121,8 @@ func GetDevModel(devModelType devModelType) (DevModel, error) {
 		return createVBox()
 	case devModelTypeParallels:
 		return createParallels()
+	case devModelTypeSim:
+		return createSim()
 
 	}
 	return nil, fmt.Errorf("not implemented type: %s", devModelType)
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// devModelTypeSim is model type for simulator of EVE
+const devModelTypeSim devModelType = defaults.DefaultSimModel
+
+// DevModelSim is dev model fields
+type DevModelSim struct {
+	//physicalIOs is PhysicalIO slice for DevModel
+	physicalIOs []*config.PhysicalIO
+	//networks is NetworkConfig slice for DevModel
+	networks []*config.NetworkConfig
+	//adapters is SystemAdapter slice for DevModel
+	adapters     []*config.SystemAdapter
+	vlanAdapters []*config.VlanAdapter
+	bondAdapters []*config.BondAdapter
+	//adapterForSwitches is name of adapter for use in switch
+	adapterForSwitches []string
+}
+
+// Config returns map with config overwrites
+func (ctx *DevModelSim) Config() map[string]interface{} {
+	return nil
+}
+
+// DiskReadyMessage to show when image is ready
+func (ctx *DevModelSim) DiskReadyMessage() string {
+	return "EVE simulator does not use image: %s"
+}
+
+// DiskFormat to use for build image
+func (ctx *DevModelSim) DiskFormat() string {
+	return "raw"
+}
+
+// GetPortConfig returns PortConfig overwrite
+func (ctx *DevModelSim) GetPortConfig(_ string, _ string) string {
+	return ""
+}
+
+// SetWiFiParams not implemented for simulator
+func (ctx *DevModelSim) SetWiFiParams(_ string, _ string) {
+	log.Warning("not implemented for simulator")
+}
+
+// Adapters returns adapters of devModel
+func (ctx *DevModelSim) Adapters() []*config.SystemAdapter {
+	return ctx.adapters
+}
+
+// SetAdapters sets systems adapters of devModel
+func (ctx *DevModelSim) SetAdapters(adapters []*config.SystemAdapter) {
+	ctx.adapters = adapters
+}
+
+// Networks returns networks of devModel
+func (ctx *DevModelSim) Networks() []*config.NetworkConfig {
+	return ctx.networks
+}
+
+// SetNetworks sets networks of devModel
+func (ctx *DevModelSim) SetNetworks(networks []*config.NetworkConfig) {
+	ctx.networks = networks
+}
+
+// PhysicalIOs returns physicalIOs of devModel
+func (ctx *DevModelSim) PhysicalIOs() []*config.PhysicalIO {
+	return ctx.physicalIOs
+}
+
+// SetPhysicalIOs sets physicalIOs of devModel
+func (ctx *DevModelSim) SetPhysicalIOs(physicalIOs []*config.PhysicalIO) {
+	ctx.physicalIOs = physicalIOs
+}
+
+// VlanAdapters returns Vlan adapters of devModel
+func (ctx *DevModelSim) VlanAdapters() []*config.VlanAdapter {
+	return ctx.vlanAdapters
+}
+
+// SetVlanAdapters sets Vlan adapters of devModel
+func (ctx *DevModelSim) SetVlanAdapters(vlans []*config.VlanAdapter) {
+	ctx.vlanAdapters = vlans
+}
+
+// BondAdapters returns Bond adapters of devModel
+func (ctx *DevModelSim) BondAdapters() []*config.BondAdapter {
+	return ctx.bondAdapters
+}
+
+// SetBondAdapters sets Bond adapters of devModel
+func (ctx *DevModelSim) SetBondAdapters(bonds []*config.BondAdapter) {
+	ctx.bondAdapters = bonds
+}
+
+// AdapterForSwitches returns adapterForSwitches of devModel
+func (ctx *DevModelSim) AdapterForSwitches() []string {
+	return ctx.adapterForSwitches
+}
+
+// DevModelType returns devModelType of devModel
+func (ctx *DevModelSim) DevModelType() string {
+	return string(devModelTypeSim)
+}
+
+func createSim() (DevModel, error) {
+	return &DevModelSim{
+			physicalIOs:        generatePhysicalIOs(2, 0, 0),
+			networks:           generateNetworkConfigs(2, 0),
+			adapters:           generateSystemAdapters(2, 0),
+			adapterForSwitches: []string{"eth1"}},
+		nil
+}
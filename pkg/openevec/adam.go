@@ -34,20 +34,30 @@ func (openEVEC *OpenEVEC) AdamStart() error {
 
 // ChangeSigningCert uploads the provided signing certificate to the OpenEVEC controller.
 func (openEVEC *OpenEVEC) ChangeSigningCert(newSignCert []byte) error {
-	changer := &adamChanger{}
+	return openEVEC.changeSigningCertAndKey(newSignCert, nil)
+}
+
+// changeSigningCertAndKey re-encrypts configs for the new signing certificate and key
+// (nil to keep the current key) and stores them for the controller.
+func (openEVEC *OpenEVEC) changeSigningCertAndKey(newSignCert, newSignKey []byte) error {
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
 
 	// we need to re-encrypt existing configs with the new certificate because EVE has support only for one server signing certificate
-	err = reencryptConfigs(ctrl, dev, newSignCert)
+	err = reencryptConfigs(ctrl, dev, newSignCert, newSignKey)
 	if err != nil {
 		return fmt.Errorf("failed to reencrypt existing configs: %w", err)
 	}
 
-	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
-		return fmt.Errorf("setControllerAndDev: %w", err)
+	if openEVEC.cfg.DryRun {
+		if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+			return fmt.Errorf("setControllerAndDev: %w", err)
+		}
+		log.Info("dry run: signing cert will not be changed")
+		return nil
 	}
 
 	edenHome, err := utils.DefaultEdenDir()
@@ -56,16 +66,58 @@ func (openEVEC *OpenEVEC) ChangeSigningCert(newSignCert []byte) error {
 	}
 	globalCertsDir := filepath.Join(edenHome, defaults.DefaultCertsDist)
 	signingCertPath := filepath.Join(globalCertsDir, "signing.pem")
+	signingKeyPath := filepath.Join(globalCertsDir, "signing-key.pem")
+	oldSignCert, err := os.ReadFile(signingCertPath)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", signingCertPath, err)
+	}
+	var oldSignKey []byte
+	if newSignKey != nil {
+		if oldSignKey, err = readSigningKey(); err != nil {
+			return err
+		}
+	}
 
-	if err = os.WriteFile(signingCertPath, newSignCert, 0644); err != nil {
-		return fmt.Errorf("cannot write signing cert to %s: %w", signingCertPath, err)
+	// store the new cert and key before sending re-encrypted configs,
+	// so the local files always match what the device may receive
+	if err = writeSigningCertAndKey(signingCertPath, signingKeyPath, newSignCert, newSignKey); err != nil {
+		if restoreErr := writeSigningCertAndKey(signingCertPath, signingKeyPath,
+			oldSignCert, oldSignKey); restoreErr != nil {
+			log.Errorf("cannot restore signing cert and key: %v", restoreErr)
+		}
+		return err
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		if restoreErr := writeSigningCertAndKey(signingCertPath, signingKeyPath,
+			oldSignCert, oldSignKey); restoreErr != nil {
+			log.Errorf("cannot restore signing cert and key: %v", restoreErr)
+		}
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	if newSignKey != nil {
+		log.Infof("Signing key changed successfully")
 	}
 
 	log.Infof("Signing cert changed successfully")
 	return nil
 }
 
-func reencryptConfigs(ctrl controller.Cloud, dev *device.Ctx, newSignCert []byte) error {
+// writeSigningCertAndKey writes signing cert and key (if not nil) into the given paths.
+func writeSigningCertAndKey(certPath, keyPath string, cert, key []byte) error {
+	if err := os.WriteFile(certPath, cert, 0644); err != nil {
+		return fmt.Errorf("cannot write signing cert to %s: %w", certPath, err)
+	}
+	if key != nil {
+		if err := os.WriteFile(keyPath, key, 0600); err != nil {
+			return fmt.Errorf("cannot write signing key to %s: %w", keyPath, err)
+		}
+	}
+	return nil
+}
+
+// reencryptConfigs re-encrypts all encrypted config data for the new signing certificate
+// and key (nil to keep the current key).
+func reencryptConfigs(ctrl controller.Cloud, dev *device.Ctx, newSignCert, newSignKey []byte) error {
 	// get device certificate from the controller
 	devCert, err := ctrl.GetECDHCert(dev.GetID())
 	if err != nil {
@@ -79,14 +131,12 @@ func reencryptConfigs(ctrl controller.Cloud, dev *device.Ctx, newSignCert []byte
 		return nil
 	}
 
-	edenHome, err := utils.DefaultEdenDir()
+	ctrlPrivKey, err := readSigningKey()
 	if err != nil {
-		return fmt.Errorf("DefaultEdenDir: %w", err)
+		return err
 	}
-	keyPath := filepath.Join(edenHome, defaults.DefaultCertsDist, "signing-key.pem")
-	ctrlPrivKey, err := os.ReadFile(keyPath)
-	if err != nil {
-		return fmt.Errorf("cannot read %s: %w", keyPath, err)
+	if newSignKey == nil {
+		newSignKey = ctrlPrivKey
 	}
 
 	oldCryptoConfig, err := utils.GetCommonCryptoConfig(devCert, oldSignCert, ctrlPrivKey)
@@ -94,7 +144,7 @@ func reencryptConfigs(ctrl controller.Cloud, dev *device.Ctx, newSignCert []byte
 		return fmt.Errorf("GetCommonCryptoConfig: %w", err)
 	}
 
-	newCryptoConfig, err := utils.GetCommonCryptoConfig(devCert, newSignCert, ctrlPrivKey)
+	newCryptoConfig, err := utils.GetCommonCryptoConfig(devCert, newSignCert, newSignKey)
 	if err != nil {
 		return fmt.Errorf("GetCommonCryptoConfig: %w", err)
 	}
@@ -106,43 +156,11 @@ func reencryptConfigs(ctrl controller.Cloud, dev *device.Ctx, newSignCert []byte
 	// add cipher context to device or return a matching existing one
 	cipherCtx = utils.AddCipherCtxToDev(dev, cipherCtx)
 
-	// re-encrypt all app configs with the new signing certificate
-	appConfigs := ctrl.ListApplicationInstanceConfig()
-	for _, config := range appConfigs {
-		if err = utils.ReencryptConfigData(config, oldCryptoConfig, newCryptoConfig, cipherCtx); err != nil {
-			return fmt.Errorf("reencryptConfigData: %w", err)
-		}
-	}
-
-	// re-encrypt all datastore configs with the new signing certificate
-	dsConfigs := ctrl.ListDataStore()
-	for _, config := range dsConfigs {
-		if err = utils.ReencryptConfigData(config, oldCryptoConfig, newCryptoConfig, cipherCtx); err != nil {
-			return fmt.Errorf("reencryptConfigData: %w", err)
-		}
-	}
-
-	// re-encrypt all wireless configs with the new signing certificate
-	for _, networkConfigID := range dev.GetNetworks() {
-		networkConfig, err := ctrl.GetNetworkConfig(networkConfigID)
-		if err != nil {
-			return fmt.Errorf("GetNetworkConfig: %w", err)
-		}
-		if networkConfig != nil && networkConfig.Wireless != nil {
-			for _, config := range networkConfig.Wireless.CellularCfg {
-				for _, ap := range config.AccessPoints {
-					if err = utils.ReencryptConfigData(ap, oldCryptoConfig, newCryptoConfig, cipherCtx); err != nil {
-						return fmt.Errorf("reencryptConfigData: %w", err)
-					}
-				}
-			}
-			for _, config := range networkConfig.Wireless.WifiCfg {
-				if err = utils.ReencryptConfigData(config, oldCryptoConfig, newCryptoConfig, cipherCtx); err != nil {
-					return fmt.Errorf("reencryptConfigData: %w", err)
-				}
-			}
+	// re-encrypt all app, datastore and wireless configs with the new signing certificate
+	return forEachCipherHolder(ctrl, dev, func(kind, name string, holder utils.CipherDataHolder) error {
+		if err := utils.ReencryptConfigData(holder, oldCryptoConfig, newCryptoConfig, cipherCtx); err != nil {
+			return fmt.Errorf("reencryptConfigData of %s %s: %w", kind, name, err)
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
@@ -0,0 +1,69 @@
+package openevec
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// getControllerAndDev returns controller and device which serve objects from
+// the capture archive if fromArchive is set or from Adam otherwise
+func (openEVEC *OpenEVEC) getControllerAndDev(fromArchive string) (controller.Cloud, *device.Ctx, error) {
+	if fromArchive != "" {
+		archive, err := loaders.OpenArchive(fromArchive)
+		if err != nil {
+			return nil, nil, fmt.Errorf("OpenArchive: %w", err)
+		}
+		ctrl, dev, err := controller.ArchivePrepare(archive)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ArchivePrepare: %w", err)
+		}
+		return ctrl, dev, nil
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	return ctrl, dev, nil
+}
+
+// CaptureRecord saves config, info, metrics, logs, app logs, flow logs and requests
+// of the current device available in Adam into archive file
+func (openEVEC *OpenEVEC) CaptureRecord(archiveFile string, timeRange types.TimeRange) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	ctrl.SetTimeRange(timeRange)
+	var appUUIDs []uuid.UUID
+	for _, appID := range dev.GetApplicationInstances() {
+		appUUID, err := uuid.FromString(appID)
+		if err != nil {
+			return fmt.Errorf("cannot parse app UUID %s: %w", appID, err)
+		}
+		appUUIDs = append(appUUIDs, appUUID)
+	}
+	archive, err := ctrl.CaptureRecord(dev.GetID(), appUUIDs)
+	if err != nil {
+		return fmt.Errorf("CaptureRecord: %w", err)
+	}
+	if err = archive.Save(archiveFile); err != nil {
+		return fmt.Errorf("cannot save archive %s: %w", archiveFile, err)
+	}
+	log.Infof("Captured device %s: %d info, %d metrics, %d logs, %d flow logs, %d requests",
+		dev.GetID(),
+		archive.Count(types.InfoType, uuid.Nil),
+		archive.Count(types.MetricsType, uuid.Nil),
+		archive.Count(types.LogsType, uuid.Nil),
+		archive.Count(types.FlowLogType, uuid.Nil),
+		archive.Count(types.RequestType, uuid.Nil))
+	log.Infof("Capture saved into %s", archiveFile)
+	return nil
+}
@@ -7,10 +7,12 @@ import (
 	"os"
 
 	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/configdiff"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/projects"
 	"github.com/lf-edge/eve-api/go/config"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
 )
 
 type configChanger interface {
@@ -22,11 +24,13 @@ type configChanger interface {
 type fileChanger struct {
 	fileConfig string
 	oldHash    [32]byte
+	oldConfig  []byte
+	dryRun     bool
 }
 
-func changerByControllerMode(controllerMode string) (configChanger, error) {
+func changerByControllerMode(controllerMode string, dryRun bool) (configChanger, error) {
 	if controllerMode == "" {
-		return &adamChanger{}, nil
+		return &adamChanger{dryRun: dryRun}, nil
 	}
 	modeType, modeURL, err := projects.GetControllerMode(controllerMode)
 	if err != nil {
@@ -37,9 +41,9 @@ func changerByControllerMode(controllerMode string) (configChanger, error) {
 	var changer configChanger
 	switch modeType {
 	case "file":
-		changer = &fileChanger{fileConfig: modeURL}
+		changer = &fileChanger{fileConfig: modeURL, dryRun: dryRun}
 	case "adam":
-		changer = &adamChanger{adamURL: modeURL}
+		changer = &adamChanger{adamURL: modeURL, dryRun: dryRun}
 
 	default:
 		return nil, fmt.Errorf("not implemented type: %s", modeType)
@@ -56,6 +60,9 @@ func (ctx *fileChanger) setControllerAndDev(ctrl controller.Cloud, dev *device.C
 	if err != nil {
 		return fmt.Errorf("GetConfigBytes error: %w", err)
 	}
+	if ctx.dryRun {
+		return printConfigDiff(ctx.oldConfig, res)
+	}
 	if ctx.oldHash == sha256.Sum256(res) {
 		log.Debug("config not modified")
 		return nil
@@ -106,11 +113,13 @@ func (ctx *fileChanger) getControllerAndDevFromConfig(cfg *EdenSetupArgs) (contr
 		return nil, nil, fmt.Errorf("GetConfigBytes error: %w", err)
 	}
 	ctx.oldHash = sha256.Sum256(res)
+	ctx.oldConfig = res
 	return ctrl, dev, nil
 }
 
 type adamChanger struct {
 	adamURL string
+	dryRun  bool
 }
 
 func (ctx *adamChanger) getController() (controller.Cloud, error) {
@@ -151,8 +160,32 @@ func (ctx *adamChanger) getControllerAndDevFromConfig(cfg *EdenSetupArgs) (contr
 }
 
 func (ctx *adamChanger) setControllerAndDev(ctrl controller.Cloud, dev *device.Ctx) error {
+	if ctx.dryRun {
+		current, err := ctrl.ConfigGet(dev.GetID())
+		if err != nil {
+			return fmt.Errorf("ConfigGet error: %w", err)
+		}
+		res, err := ctrl.GetConfigBytes(dev, false)
+		if err != nil {
+			return fmt.Errorf("GetConfigBytes error: %w", err)
+		}
+		return printConfigDiff([]byte(current), res)
+	}
 	if err := ctrl.ConfigSync(dev); err != nil {
 		return fmt.Errorf("configSync error: %w", err)
 	}
 	return nil
 }
+
+// printConfigDiff prints changes between current and new config of device instead of applying them
+func printConfigDiff(current, res []byte) error {
+	var currentConfig, newConfig config.EdgeDevConfig
+	if err := proto.Unmarshal(current, &currentConfig); err != nil {
+		return fmt.Errorf("unmarshal current config error: %w", err)
+	}
+	if err := proto.Unmarshal(res, &newConfig); err != nil {
+		return fmt.Errorf("unmarshal new config error: %w", err)
+	}
+	log.Info("dry run: config will not be sent, changes are:")
+	return configdiff.Print(os.Stdout, configdiff.Diff(&currentConfig, &newConfig))
+}
@@ -0,0 +1,54 @@
+package openevec_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/memory"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingController counts config writes into controller
+type countingController struct {
+	controller.Controller
+	configSets int
+}
+
+func (c *countingController) ConfigSet(devUUID uuid.UUID, devConfig []byte) error {
+	c.configSets++
+	return c.Controller.ConfigSet(devUUID, devConfig)
+}
+
+func TestSetControllerAndDevDryRun(t *testing.T) {
+	t.Parallel()
+
+	counting := &countingController{Controller: memory.New()}
+	ctrl := &controller.CloudCtx{Controller: counting}
+	ctrl.SetVars(&utils.ConfigVars{ZArch: "amd64"})
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f"))
+
+	current, err := ctrl.GetConfigBytes(dev, false)
+	assert.NoError(t, err)
+	// store current config bypassing the counter
+	assert.NoError(t, counting.Controller.ConfigSet(dev.GetID(), current))
+
+	netID := "3a4a2b6f-9b1e-4b8c-8f0e-2b8f3c6d1e01"
+	assert.NoError(t, ctrl.AddNetworkInstanceConfig(&config.NetworkInstanceConfig{
+		Uuidandversion: &config.UUIDandVersion{Uuid: netID, Version: "1"},
+		Displayname:    "net",
+		InstType:       config.ZNetworkInstType_ZnetInstLocal,
+	}))
+	dev.SetNetworkInstanceConfig([]string{netID})
+
+	assert.NoError(t, openevec.SetControllerAndDev(true, ctrl, dev))
+	assert.Zero(t, counting.configSets)
+	stored, err := ctrl.ConfigGet(dev.GetID())
+	assert.NoError(t, err)
+	assert.Equal(t, string(current), stored)
+}
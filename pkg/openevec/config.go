@@ -134,6 +134,23 @@ type EveConfig struct {
 	BootstrapFile  string `mapstructure:"bootstrap-file" cobraflag:"eve-bootstrap-file"`
 	UsbNetConfFile string `mapstructure:"usbnetconf-file" cobraflag:"eve-usbnetconf-file"`
 	TPM            bool   `mapstructure:"tpm" cobraflag:"tpm"`
+
+	// Instances describes additional EVE VMs attached to the same SDN and controller
+	Instances map[string]EveInstanceConfig `mapstructure:"instances"`
+	// Instance is the name of the EVE instance selected with --eve-instance
+	Instance string `mapstructure:"-"`
+}
+
+// EveInstanceConfig overrides per-instance settings of an additional EVE VM.
+// Empty fields are derived from the default instance.
+type EveInstanceConfig struct {
+	CertsUUID   string `mapstructure:"uuid"`
+	Serial      string `mapstructure:"serial"`
+	TelnetPort  int    `mapstructure:"telnet-port"`
+	MonitorPort int    `mapstructure:"monitor-port"`
+	// PortOffset is added to ports of the default instance, it is allocated
+	// and stored into config on the first use of the instance
+	PortOffset int `mapstructure:"port-offset"`
 }
 
 type RegistryConfig struct {
@@ -165,6 +182,7 @@ type SdnConfig struct {
 	MgmtPort       int    `mapstructure:"mgmt-port" cobraflag:"sdn-mgmt-port"`
 	PidFile        string `mapstructure:"pid" cobraflag:"sdn-pid" resolvepath:""`
 	SSHPort        int    `mapstructure:"ssh-port" cobraflag:"sdn-ssh-port"`
+	Runner         string `mapstructure:"runner" cobraflag:"sdn-runner"`
 }
 
 type EdenSetupArgs struct {
@@ -179,37 +197,39 @@ type EdenSetupArgs struct {
 
 	ConfigFile string
 	ConfigName string
+	// DryRun prints changes of config instead of sending it to controller
+	DryRun bool
 }
 
 // PodConfig store configuration for Pod deployment
 type PodConfig struct {
-	Name              string
-	Metadata          string
-	Registry          string
-	Networks          []string
-	PortPublish       []string
-	ACL               []string
-	Vlans             []string
-	Mount             []string
-	Disks             []string
-	Profiles          []string
-	AppAdapters       []string
-	NoHyper           bool
-	VncDisplay        uint32
-	VncPassword       string
-	DiskSize          string
-	VolumeSize        string
-	AppMemory         string
-	VolumeType        string
-	AppCpus           uint32
-	StartDelay        uint32
-	PinCpus           bool
-	ImageFormat       string
-	SftpLoad          bool
-	DirectLoad        bool
-	OpenStackMetadata bool
-	DatastoreOverride string
-	ACLOnlyHost       bool
+	Name              string   `yaml:"name,omitempty"`
+	Metadata          string   `yaml:"metadata,omitempty"`
+	Registry          string   `yaml:"registry,omitempty"`
+	Networks          []string `yaml:"networks,omitempty"`
+	PortPublish       []string `yaml:"publish,omitempty"`
+	ACL               []string `yaml:"acl,omitempty"`
+	Vlans             []string `yaml:"vlans,omitempty"`
+	Mount             []string `yaml:"mount,omitempty"`
+	Disks             []string `yaml:"disks,omitempty"`
+	Profiles          []string `yaml:"profiles,omitempty"`
+	AppAdapters       []string `yaml:"adapters,omitempty"`
+	NoHyper           bool     `yaml:"no-hyper,omitempty"`
+	VncDisplay        uint32   `yaml:"vnc-display,omitempty"`
+	VncPassword       string   `yaml:"vnc-password,omitempty"`
+	DiskSize          string   `yaml:"disk-size,omitempty"`
+	VolumeSize        string   `yaml:"volume-size,omitempty"`
+	AppMemory         string   `yaml:"memory,omitempty"`
+	VolumeType        string   `yaml:"volume-type,omitempty"`
+	AppCpus           uint32   `yaml:"cpus,omitempty"`
+	StartDelay        uint32   `yaml:"start-delay,omitempty"`
+	PinCpus           bool     `yaml:"pin-cpus,omitempty"`
+	ImageFormat       string   `yaml:"format,omitempty"`
+	SftpLoad          bool     `yaml:"sftp,omitempty"`
+	DirectLoad        bool     `yaml:"direct"`
+	OpenStackMetadata bool     `yaml:"openstack-metadata,omitempty"`
+	DatastoreOverride string   `yaml:"datastore-override,omitempty"`
+	ACLOnlyHost       bool     `yaml:"only-host,omitempty"`
 }
 
 func Merge(dst, src reflect.Value, flags *pflag.FlagSet) {
@@ -0,0 +1,394 @@
+package openevec
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/evecommon"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Kinds of config objects with encrypted data.
+const (
+	CipherHolderApp        = "app"
+	CipherHolderDatastore  = "datastore"
+	CipherHolderWifi       = "wifi"
+	CipherHolderCellularAP = "cellular-ap"
+)
+
+// CipherContextInfo describes cipher context of the device.
+type CipherContextInfo struct {
+	ContextID          string `json:"contextId"`
+	HashScheme         string `json:"hashScheme"`
+	KeyExchangeScheme  string `json:"keyExchangeScheme"`
+	EncryptionScheme   string `json:"encryptionScheme"`
+	DeviceCertHash     string `json:"deviceCertHash"`
+	ControllerCertHash string `json:"controllerCertHash"`
+	// Current is set if the context matches the current device and signing certificates
+	Current bool `json:"current"`
+	// Missing is set if the context is referenced by encrypted data but not present in the config
+	Missing bool `json:"missing,omitempty"`
+	// Users lists config objects (<kind>/<name>) with data encrypted using the context
+	Users []string `json:"users"`
+}
+
+// DecryptedData is encrypted data of a config object shown in plaintext.
+type DecryptedData struct {
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	ContextID string            `json:"contextId"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// forEachCipherHolder calls handler for every config object which may hold encrypted data:
+// app instances (cloud-init user data), datastores (credentials)
+// and wireless networks (Wi-Fi and cellular secrets).
+func forEachCipherHolder(ctrl controller.Cloud, dev *device.Ctx,
+	handler func(kind, name string, holder utils.CipherDataHolder) error) error {
+	for _, config := range ctrl.ListApplicationInstanceConfig() {
+		if err := handler(CipherHolderApp, config.GetDisplayname(), config); err != nil {
+			return err
+		}
+	}
+	for _, config := range ctrl.ListDataStore() {
+		if err := handler(CipherHolderDatastore, config.GetId(), config); err != nil {
+			return err
+		}
+	}
+	for _, networkConfigID := range dev.GetNetworks() {
+		networkConfig, err := ctrl.GetNetworkConfig(networkConfigID)
+		if err != nil {
+			return fmt.Errorf("GetNetworkConfig: %w", err)
+		}
+		if networkConfig == nil || networkConfig.Wireless == nil {
+			continue
+		}
+		for _, config := range networkConfig.Wireless.CellularCfg {
+			for _, ap := range config.AccessPoints {
+				name := fmt.Sprintf("%s/%s", networkConfigID, ap.GetApn())
+				if err = handler(CipherHolderCellularAP, name, ap); err != nil {
+					return err
+				}
+			}
+		}
+		for _, config := range networkConfig.Wireless.WifiCfg {
+			name := fmt.Sprintf("%s/%s", networkConfigID, config.GetWifiSSID())
+			if err = handler(CipherHolderWifi, name, config); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readSigningKey() ([]byte, error) {
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return nil, fmt.Errorf("DefaultEdenDir: %w", err)
+	}
+	keyPath := filepath.Join(edenHome, defaults.DefaultCertsDist, "signing-key.pem")
+	ctrlPrivKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", keyPath, err)
+	}
+	return ctrlPrivKey, nil
+}
+
+// currentCryptoConfig returns crypto config for the current device certificate,
+// controller signing certificate and the given controller key.
+func currentCryptoConfig(ctrl controller.Cloud, dev *device.Ctx, ctrlPrivKey []byte) (*utils.CommonCryptoConfig, error) {
+	devCert, err := ctrl.GetECDHCert(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("cannot get device certificate from cloud: %w", err)
+	}
+	signCert, err := ctrl.SigningCertGet()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get cloud's signing certificate: %w", err)
+	}
+	return utils.GetCommonCryptoConfig(devCert, signCert, ctrlPrivKey)
+}
+
+// CryptoListContexts lists cipher contexts of the device together with config objects
+// using them. Contexts referenced by encrypted data but missing in the config are listed too.
+func (openEVEC *OpenEVEC) CryptoListContexts() ([]*CipherContextInfo, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	var currentCtx *evecommon.CipherContext
+	ctrlPrivKey, err := readSigningKey()
+	if err == nil {
+		var cryptoConfig *utils.CommonCryptoConfig
+		cryptoConfig, err = currentCryptoConfig(ctrl, dev, ctrlPrivKey)
+		if err == nil {
+			currentCtx, err = utils.CreateCipherCtx(cryptoConfig)
+		}
+	}
+	if err != nil {
+		log.Warnf("Cannot determine the current cipher context: %v", err)
+	}
+	return listCipherContexts(ctrl, dev, currentCtx)
+}
+
+// listCipherContexts lists cipher contexts of the device and config objects using them.
+// Contexts matching certificate hashes of currentCtx (if not nil) are marked as current.
+func listCipherContexts(ctrl controller.Cloud, dev *device.Ctx,
+	currentCtx *evecommon.CipherContext) ([]*CipherContextInfo, error) {
+	var contexts []*CipherContextInfo
+	contextByID := make(map[string]*CipherContextInfo)
+	for _, c := range dev.GetCipherContexts() {
+		info := &CipherContextInfo{
+			ContextID:          c.GetContextId(),
+			HashScheme:         c.GetHashScheme().String(),
+			KeyExchangeScheme:  c.GetKeyExchangeScheme().String(),
+			EncryptionScheme:   c.GetEncryptionScheme().String(),
+			DeviceCertHash:     hex.EncodeToString(c.GetDeviceCertHash()),
+			ControllerCertHash: hex.EncodeToString(c.GetControllerCertHash()),
+			Users:              []string{},
+		}
+		if currentCtx != nil {
+			info.Current = utils.CompareSlices(c.GetDeviceCertHash(), currentCtx.GetDeviceCertHash()) &&
+				utils.CompareSlices(c.GetControllerCertHash(), currentCtx.GetControllerCertHash())
+		}
+		contexts = append(contexts, info)
+		contextByID[info.ContextID] = info
+	}
+	err := forEachCipherHolder(ctrl, dev, func(kind, name string, holder utils.CipherDataHolder) error {
+		cipherData := holder.GetCipherData()
+		if cipherData == nil {
+			return nil
+		}
+		info, ok := contextByID[cipherData.GetCipherContextId()]
+		if !ok {
+			info = &CipherContextInfo{ContextID: cipherData.GetCipherContextId(), Missing: true}
+			contexts = append(contexts, info)
+			contextByID[info.ContextID] = info
+		}
+		info.Users = append(info.Users, fmt.Sprintf("%s/%s", kind, name))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contexts, nil
+}
+
+// CryptoDecrypt decrypts all encrypted config data using the given controller key
+// (the current signing key if empty). Failures to decrypt are reported in the result.
+func (openEVEC *OpenEVEC) CryptoDecrypt(keyFile string) ([]*DecryptedData, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	var ctrlPrivKey []byte
+	if keyFile == "" {
+		ctrlPrivKey, err = readSigningKey()
+	} else {
+		ctrlPrivKey, err = os.ReadFile(keyFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cryptoConfig, err := currentCryptoConfig(ctrl, dev, ctrlPrivKey)
+	if err != nil {
+		return nil, err
+	}
+	var result []*DecryptedData
+	err = forEachCipherHolder(ctrl, dev, func(kind, name string, holder utils.CipherDataHolder) error {
+		cipherData := holder.GetCipherData()
+		if cipherData == nil {
+			return nil
+		}
+		data := &DecryptedData{Kind: kind, Name: name, ContextID: cipherData.GetCipherContextId()}
+		result = append(result, data)
+		encBlock, err := utils.CryptoConfigUnwrapper(cipherData, cryptoConfig)
+		if err != nil {
+			data.Error = err.Error()
+			return nil
+		}
+		fields, err := encryptionBlockFields(encBlock)
+		if err != nil {
+			data.Error = err.Error()
+			return nil
+		}
+		data.Fields = fields
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// encryptionBlockFields returns non-empty fields of the encryption block.
+// Cloud-init user data is base64-decoded.
+func encryptionBlockFields(encBlock *evecommon.EncryptionBlock) (map[string]string, error) {
+	encBytes, err := protojson.Marshal(encBlock)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string)
+	if err = json.Unmarshal(encBytes, &fields); err != nil {
+		return nil, err
+	}
+	if userData := encBlock.GetProtectedUserData(); userData != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(userData); err == nil {
+			fields["protectedUserData"] = string(decoded)
+		}
+	}
+	return fields, nil
+}
+
+// CryptoList prints cipher contexts of the device in the given format.
+func (openEVEC *OpenEVEC) CryptoList(outputFormat types.OutputFormat) error {
+	contexts, err := openEVEC.CryptoListContexts()
+	if err != nil {
+		return err
+	}
+	if outputFormat == types.OutputFormatJSON {
+		return utils.PrintJSON(contexts)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTEXT ID\tDEVICE CERT HASH\tCONTROLLER CERT HASH\tSTATE\tUSERS")
+	for _, c := range contexts {
+		state := ""
+		switch {
+		case c.Missing:
+			state = "missing"
+		case c.Current:
+			state = "current"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.ContextID, c.DeviceCertHash,
+			c.ControllerCertHash, state, strings.Join(c.Users, ","))
+	}
+	return w.Flush()
+}
+
+// CryptoShowDecrypted prints decrypted config data in the given format.
+func (openEVEC *OpenEVEC) CryptoShowDecrypted(keyFile string, outputFormat types.OutputFormat) error {
+	decrypted, err := openEVEC.CryptoDecrypt(keyFile)
+	if err != nil {
+		return err
+	}
+	if outputFormat == types.OutputFormatJSON {
+		return utils.PrintJSON(decrypted)
+	}
+	for _, data := range decrypted {
+		fmt.Printf("%s %s (context %s):\n", data.Kind, data.Name, data.ContextID)
+		if data.Error != "" {
+			fmt.Printf("\terror: %s\n", data.Error)
+			continue
+		}
+		var names []string
+		for name := range data.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("\t%s: %s\n", name, data.Fields[name])
+		}
+	}
+	return nil
+}
+
+// CryptoRotate changes the controller signing certificate (and key if newKeyFile is set
+// or generateKey is true) and re-encrypts all encrypted config data for it.
+// If newCertFile is empty, a new certificate is generated, keeping the current key
+// unless generateKey is set.
+func (openEVEC *OpenEVEC) CryptoRotate(newCertFile, newKeyFile string, generateKey bool) error {
+	if newCertFile == "" {
+		// generate into temporary directory to not leave the new certificate
+		// and private key next to the ones in use
+		tmpDir, err := os.MkdirTemp("", "eden-signing-")
+		if err != nil {
+			return fmt.Errorf("cannot create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		newCertFile = filepath.Join(tmpDir, "signing-new.pem")
+		if generateKey {
+			newKeyFile = filepath.Join(tmpDir, "signing-new-key.pem")
+			err = utils.GenServerCertAndKeyFromPrevCert(newCertFile, newKeyFile)
+		} else {
+			err = utils.GenServerCertFromPrevCertAndKey(newCertFile)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot generate signing cert: %w", err)
+		}
+		log.Info("New signing certificate generated")
+	}
+	newCert, err := os.ReadFile(newCertFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", newCertFile, err)
+	}
+	var newKey []byte
+	if newKeyFile != "" {
+		if newKey, err = os.ReadFile(newKeyFile); err != nil {
+			return fmt.Errorf("cannot read %s: %w", newKeyFile, err)
+		}
+	}
+	return openEVEC.changeSigningCertAndKey(newCert, newKey)
+}
+
+// CryptoReencrypt re-encrypts all encrypted config data using the cipher context
+// of the current certificates. With prune, cipher contexts not used anymore are removed.
+func (openEVEC *OpenEVEC) CryptoReencrypt(prune bool) error {
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	signCert, err := ctrl.SigningCertGet()
+	if err != nil {
+		return fmt.Errorf("cannot get cloud's signing certificate: %w", err)
+	}
+	if err = reencryptConfigs(ctrl, dev, signCert, nil); err != nil {
+		return fmt.Errorf("failed to reencrypt configs: %w", err)
+	}
+	if prune {
+		used := make(map[string]bool)
+		err = forEachCipherHolder(ctrl, dev, func(_, _ string, holder utils.CipherDataHolder) error {
+			if cipherData := holder.GetCipherData(); cipherData != nil {
+				used[cipherData.GetCipherContextId()] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		var contexts []*evecommon.CipherContext
+		var removed []string
+		for _, c := range dev.GetCipherContexts() {
+			if used[c.GetContextId()] {
+				contexts = append(contexts, c)
+			} else {
+				removed = append(removed, c.GetContextId())
+			}
+		}
+		sort.Strings(removed)
+		for _, id := range removed {
+			log.Infof("Removing unused cipher context %s", id)
+		}
+		dev.SetCipherContexts(contexts)
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Info("Encrypted config data re-encrypted successfully")
+	return nil
+}
@@ -0,0 +1,183 @@
+package openevec_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/memory"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/evecommon"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptionBlockFields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		encBlock *evecommon.EncryptionBlock
+		expected map[string]string
+	}{
+		{
+			name:     "empty block",
+			encBlock: &evecommon.EncryptionBlock{},
+			expected: map[string]string{},
+		},
+		{
+			name: "datastore credentials",
+			encBlock: &evecommon.EncryptionBlock{
+				DsAPIKey:   "user",
+				DsPassword: "secret",
+			},
+			expected: map[string]string{
+				"dsAPIKey":   "user",
+				"dsPassword": "secret",
+			},
+		},
+		{
+			name: "cellular credentials",
+			encBlock: &evecommon.EncryptionBlock{
+				CellularNetUsername: "apn-user",
+				CellularNetPassword: "apn-pass",
+			},
+			expected: map[string]string{
+				"cellularNetUsername": "apn-user",
+				"cellularNetPassword": "apn-pass",
+			},
+		},
+		{
+			name: "base64 user data is decoded",
+			encBlock: &evecommon.EncryptionBlock{
+				ProtectedUserData: base64.StdEncoding.EncodeToString([]byte("#cloud-config\n")),
+			},
+			expected: map[string]string{
+				"protectedUserData": "#cloud-config\n",
+			},
+		},
+		{
+			name: "invalid base64 user data is kept",
+			encBlock: &evecommon.EncryptionBlock{
+				ProtectedUserData: "not base64!",
+			},
+			expected: map[string]string{
+				"protectedUserData": "not base64!",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			fields, err := openevec.EncryptionBlockFields(test.encBlock)
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.expected, fields)
+			}
+		})
+	}
+}
+
+func cipherBlock(contextID string) *evecommon.CipherBlock {
+	return &evecommon.CipherBlock{CipherContextId: contextID, CipherData: []byte("encrypted")}
+}
+
+func TestListCipherContexts(t *testing.T) {
+	t.Parallel()
+
+	ctrl := &controller.CloudCtx{Controller: memory.New()}
+	ctrl.SetVars(&utils.ConfigVars{ZArch: "amd64"})
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f"))
+	dev.SetCipherContexts([]*evecommon.CipherContext{
+		{
+			ContextId:          "old",
+			DeviceCertHash:     []byte{0x01},
+			ControllerCertHash: []byte{0x02},
+		},
+		{
+			ContextId:          "new",
+			HashScheme:         evecommon.HashAlgorithm_HASH_ALGORITHM_SHA256_16BYTES,
+			DeviceCertHash:     []byte{0x01},
+			ControllerCertHash: []byte{0x03},
+		},
+		{
+			ContextId:          "unused",
+			DeviceCertHash:     []byte{0x04},
+			ControllerCertHash: []byte{0x05},
+		},
+	})
+	assert.NoError(t, ctrl.AddApplicationInstanceConfig(&config.AppInstanceConfig{
+		Uuidandversion: &config.UUIDandVersion{Uuid: "11111111-1111-1111-1111-111111111111"},
+		Displayname:    "app1",
+		CipherData:     cipherBlock("new"),
+	}))
+	assert.NoError(t, ctrl.AddApplicationInstanceConfig(&config.AppInstanceConfig{
+		Uuidandversion: &config.UUIDandVersion{Uuid: "22222222-2222-2222-2222-222222222222"},
+		Displayname:    "plain",
+	}))
+	assert.NoError(t, ctrl.AddDataStore(&config.DatastoreConfig{Id: "ds1", CipherData: cipherBlock("old")}))
+	assert.NoError(t, ctrl.AddNetworkConfig(&config.NetworkConfig{
+		Id: "net1",
+		Wireless: &config.WirelessConfig{
+			WifiCfg: []*config.WifiConfig{{WifiSSID: "ssid1", CipherData: cipherBlock("new")}},
+			CellularCfg: []*config.CellularConfig{{
+				AccessPoints: []*config.CellularAccessPoint{{Apn: "internet", CipherData: cipherBlock("gone")}},
+			}},
+		},
+	}))
+	dev.SetNetworkConfig([]string{"net1"})
+
+	current := &evecommon.CipherContext{DeviceCertHash: []byte{0x01}, ControllerCertHash: []byte{0x03}}
+	contexts, err := openevec.ListCipherContexts(ctrl, dev, current)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []*openevec.CipherContextInfo{
+		{
+			ContextID:          "old",
+			HashScheme:         "HASH_ALGORITHM_INVALID",
+			KeyExchangeScheme:  "KEA_NONE",
+			EncryptionScheme:   "SA_NONE",
+			DeviceCertHash:     "01",
+			ControllerCertHash: "02",
+			Users:              []string{"datastore/ds1"},
+		},
+		{
+			ContextID:          "new",
+			HashScheme:         "HASH_ALGORITHM_SHA256_16BYTES",
+			KeyExchangeScheme:  "KEA_NONE",
+			EncryptionScheme:   "SA_NONE",
+			DeviceCertHash:     "01",
+			ControllerCertHash: "03",
+			Current:            true,
+			Users:              []string{"app/app1", "wifi/net1/ssid1"},
+		},
+		{
+			ContextID:          "unused",
+			HashScheme:         "HASH_ALGORITHM_INVALID",
+			KeyExchangeScheme:  "KEA_NONE",
+			EncryptionScheme:   "SA_NONE",
+			DeviceCertHash:     "04",
+			ControllerCertHash: "05",
+			Users:              []string{},
+		},
+		{
+			ContextID: "gone",
+			Missing:   true,
+			Users:     []string{"cellular-ap/net1/internet"},
+		},
+	}, contexts)
+
+	// Without the current context, no context is marked as current.
+	contexts, err = openevec.ListCipherContexts(ctrl, dev, nil)
+	if assert.NoError(t, err) {
+		for _, c := range contexts {
+			assert.False(t, c.Current, c.ContextID)
+		}
+	}
+}
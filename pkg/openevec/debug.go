@@ -0,0 +1,295 @@
+package openevec
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/flamegraph"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// collectInfoLink is a symlink on EVE pointing to the last bundle created by DebugCollect.
+const collectInfoLink = "eden-collect-info.tar.gz"
+
+// collectInfoCommands lists diagnostic commands with outputs included in collect-info bundle.
+// Output files are recognized by the command name used as a prefix of the file name.
+var collectInfoCommands = []string{
+	"arp", "brctl", "conntrack", "df", "free", "ifconfig", "ip", "ip6tables", "iptables",
+	"lsblk", "lsof", "lspci", "lsusb", "mount", "netstat", "ps", "route", "ss", "tc",
+	"top", "uptime", "vmstat", "zfs", "zpool",
+}
+
+// CollectInfoIndex is an index of files found inside unpacked collect-info bundle.
+// All paths are relative to Dir.
+type CollectInfoIndex struct {
+	// Tarball is the downloaded bundle
+	Tarball string `json:"tarball"`
+	// Dir is the directory with the unpacked bundle
+	Dir string `json:"dir"`
+	// PubSub lists pubsub publications dumped by EVE microservices
+	PubSub []PubSubDump `json:"pubsub"`
+	// Logs lists log files
+	Logs []string `json:"logs"`
+	// Commands maps diagnostic commands (ps, netstat, ...) to files with their outputs
+	Commands map[string][]string `json:"commands"`
+	// Other lists all remaining files
+	Other []string `json:"other"`
+}
+
+// PubSubDump is a single pubsub publication found inside collect-info bundle
+type PubSubDump struct {
+	Agent string `json:"agent"`
+	Topic string `json:"topic"`
+	Key   string `json:"key"`
+	Path  string `json:"path"`
+}
+
+// FindPubSub returns publications of the given agent and topic (empty matches any)
+func (index *CollectInfoIndex) FindPubSub(agent, topic string) (dumps []PubSubDump) {
+	for _, dump := range index.PubSub {
+		if (agent == "" || dump.Agent == agent) && (topic == "" || dump.Topic == topic) {
+			dumps = append(dumps, dump)
+		}
+	}
+	return dumps
+}
+
+// DebugCollect runs collect-info script on EVE, downloads the resulting bundle into
+// outputDir, unpacks it and indexes the content. Index is also saved as JSON next
+// to the bundle. Unless keepOnEve is set, the bundle is removed from EVE afterwards.
+func (openEVEC *OpenEVEC) DebugCollect(outputDir, collectOptions string, keepOnEve bool) (*CollectInfoIndex, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+	}
+	eveDir := defaults.DefaultCollectInfoEVELocation
+	eveLink := filepath.Join(eveDir, collectInfoLink)
+	log.Info("Running collect-info on EVE, this may take a few minutes")
+	commandToRun := fmt.Sprintf("%s %s > /dev/null && "+
+		"ln -sf \"$(ls -t %s/eve-info-*.tar.gz | head -n 1)\" %s",
+		defaults.DefaultCollectInfoEVEScript, collectOptions, eveDir, eveLink)
+	// The same SSH access is used to run collect-info, download the bundle
+	// and remove it from EVE.
+	if err := openEVEC.enableEveSSH(); err != nil {
+		return nil, fmt.Errorf("failed to enable SSH access into EVE: %w", err)
+	}
+	if err := openEVEC.SdnForwardSSHToEve(commandToRun); err != nil {
+		return nil, fmt.Errorf("failed to run collect-info on EVE: %w", err)
+	}
+	name := fmt.Sprintf("eve-info-%s", time.Now().Format("2006-01-02-15-04-05"))
+	tarball := filepath.Join(outputDir, name+".tar.gz")
+	log.Infof("Downloading collect-info bundle into %s", tarball)
+	if err := openEVEC.SdnForwardSCPFromEve(eveLink, tarball); err != nil {
+		return nil, fmt.Errorf("failed to download collect-info bundle: %w", err)
+	}
+	if !keepOnEve {
+		commandToRun = fmt.Sprintf("rm -f \"$(readlink %s)\" %s", eveLink, eveLink)
+		if err := openEVEC.SdnForwardSSHToEve(commandToRun); err != nil {
+			log.Warnf("Failed to remove collect-info bundle from EVE: %v", err)
+		}
+	}
+	dir := filepath.Join(outputDir, name)
+	if err := UnpackCollectInfo(tarball, dir); err != nil {
+		return nil, fmt.Errorf("failed to unpack collect-info bundle: %w", err)
+	}
+	index, err := IndexCollectInfo(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index collect-info bundle: %w", err)
+	}
+	index.Tarball = tarball
+	indexBytes, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal collect-info index: %w", err)
+	}
+	indexFile := filepath.Join(outputDir, name+".index.json")
+	if err = os.WriteFile(indexFile, indexBytes, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write collect-info index %s: %w", indexFile, err)
+	}
+	return index, nil
+}
+
+// UnpackCollectInfo extracts regular files and directories from collect-info
+// bundle (tar.gz) into dir. Other entries (symlinks, devices, ...) are skipped.
+func UnpackCollectInfo(tarball, dir string) error {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gzf, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gzf.Close()
+	dir = filepath.Clean(dir)
+	tarReader := tar.NewReader(gzf)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, header.Name)
+		if target != dir && !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			log.Warnf("Skipping %s from collect-info bundle: path outside of %s",
+				header.Name, dir)
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err = extractRegularFile(tarReader, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractRegularFile(reader io.Reader, target string) error {
+	outFile, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	// Limit the size of the extracted file to prevent decompression bomb
+	limitReader := io.LimitReader(reader, utils.MaxDecompressedContentSize+1)
+	bytesCopied, err := io.Copy(outFile, limitReader)
+	if err != nil {
+		return err
+	}
+	if bytesCopied > utils.MaxDecompressedContentSize {
+		return fmt.Errorf("maximum decompressed content size exceeded for %s", target)
+	}
+	return nil
+}
+
+// IndexCollectInfo indexes files of unpacked collect-info bundle.
+// JSON files are pubsub publications (stored as <agent>/<topic>/<key>.json),
+// files named after a diagnostic command (e.g. "ps-ef", "netstat.txt") are command
+// outputs, files inside log directories or with ".log" extension are logs.
+func IndexCollectInfo(dir string) (*CollectInfoIndex, error) {
+	index := &CollectInfoIndex{Dir: dir, Commands: make(map[string][]string)}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if dump, ok := parsePubSubDump(relPath); ok {
+			index.PubSub = append(index.PubSub, dump)
+		} else if command := collectInfoCommand(relPath); command != "" {
+			index.Commands[command] = append(index.Commands[command], relPath)
+		} else if isCollectInfoLog(relPath) {
+			index.Logs = append(index.Logs, relPath)
+		} else {
+			index.Other = append(index.Other, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(index.PubSub, func(i, j int) bool {
+		return index.PubSub[i].Path < index.PubSub[j].Path
+	})
+	return index, nil
+}
+
+func parsePubSubDump(relPath string) (PubSubDump, bool) {
+	if filepath.Ext(relPath) != ".json" {
+		return PubSubDump{}, false
+	}
+	dump := PubSubDump{
+		Path: relPath,
+		Key:  strings.TrimSuffix(filepath.Base(relPath), ".json"),
+	}
+	parent := filepath.Dir(relPath)
+	if parent != "." {
+		dump.Topic = filepath.Base(parent)
+		if grandParent := filepath.Dir(parent); grandParent != "." {
+			dump.Agent = filepath.Base(grandParent)
+		}
+	}
+	return dump, true
+}
+
+func collectInfoCommand(relPath string) string {
+	name := strings.ToLower(filepath.Base(relPath))
+	if i := strings.IndexAny(name, "-_. "); i >= 0 {
+		name = name[:i]
+	}
+	for _, command := range collectInfoCommands {
+		if name == command {
+			return command
+		}
+	}
+	return ""
+}
+
+func isCollectInfoLog(relPath string) bool {
+	if filepath.Ext(relPath) == ".log" {
+		return true
+	}
+	if strings.HasPrefix(filepath.Base(relPath), "dmesg") {
+		return true
+	}
+	for _, segment := range strings.Split(filepath.Dir(relPath), string(os.PathSeparator)) {
+		if strings.Contains(strings.ToLower(segment), "log") {
+			return true
+		}
+	}
+	return false
+}
+
+// DebugFlamegraph renders flame graph from perf script output (or folded stacks)
+// in input file into output file. Format is detected from the output file extension
+// if not set. If baseline is set, differential flame graph against it is rendered.
+func DebugFlamegraph(input, output, baseline, format, title string) error {
+	fgFormat := flamegraph.FormatFromPath(output)
+	if format != "" {
+		var err error
+		if fgFormat, err = flamegraph.ParseFormat(format); err != nil {
+			return err
+		}
+	}
+	profile, err := flamegraph.Load(input)
+	if err != nil {
+		return err
+	}
+	opts := flamegraph.Options{Title: title}
+	if baseline != "" {
+		if opts.Baseline, err = flamegraph.Load(baseline); err != nil {
+			return err
+		}
+	}
+	err = utils.WriteFileAtomic(output, func(w io.Writer) error {
+		return flamegraph.Render(w, profile, fgFormat, opts)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render flame graph: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package openevec_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestBundle(t *testing.T, tarball string, files map[string]string) {
+	f, err := os.Create(tarball)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer f.Close()
+	gzw := gzip.NewWriter(f)
+	defer gzw.Close()
+	tw := tar.NewWriter(gzw)
+	defer tw.Close()
+	for name, content := range files {
+		err = tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		assert.NoError(t, err)
+		_, err = tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "eve-info/link",
+		Linkname: "/etc/passwd",
+		Typeflag: tar.TypeSymlink,
+	}))
+}
+
+func TestUnpackAndIndexCollectInfo(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	tarball := filepath.Join(tmpDir, "eve-info.tar.gz")
+	writeTestBundle(t, tarball, map[string]string{
+		"eve-info/root-run/nim/DeviceNetworkStatus/global.json":     "{}",
+		"eve-info/root-run/zedagent/DevicePortConfig/zedagent.json": "{}",
+		"eve-info/ps-eo":                                     "PID USER",
+		"eve-info/netstat-tuanp":                             "Active Internet connections",
+		"eve-info/network/ip-route":                          "default via 10.0.0.1",
+		"eve-info/persist/newlog/keepSentQueue/dev.log.1.gz": "",
+		"eve-info/dmesg":                                     "",
+		"eve-info/version":                                   "0.0.0",
+		"../escape":                                          "",
+	})
+	dir := filepath.Join(tmpDir, "unpacked")
+	if !assert.NoError(t, openevec.UnpackCollectInfo(tarball, dir)) {
+		return
+	}
+	assert.NoFileExists(t, filepath.Join(tmpDir, "escape"))
+	assert.NoFileExists(t, filepath.Join(dir, "eve-info", "link"))
+
+	index, err := openevec.IndexCollectInfo(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	dumps := index.FindPubSub("nim", "DeviceNetworkStatus")
+	if assert.Len(t, dumps, 1) {
+		assert.Equal(t, "global", dumps[0].Key)
+	}
+	assert.Len(t, index.PubSub, 2)
+	assert.Equal(t, []string{filepath.Join("eve-info", "ps-eo")}, index.Commands["ps"])
+	assert.Equal(t, []string{filepath.Join("eve-info", "netstat-tuanp")}, index.Commands["netstat"])
+	assert.Equal(t, []string{filepath.Join("eve-info", "network", "ip-route")}, index.Commands["ip"])
+	assert.ElementsMatch(t, []string{
+		filepath.Join("eve-info", "persist", "newlog", "keepSentQueue", "dev.log.1.gz"),
+		filepath.Join("eve-info", "dmesg"),
+	}, index.Logs)
+	assert.Equal(t, []string{filepath.Join("eve-info", "version")}, index.Other)
+}
+
+func TestDebugFlamegraph(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "perf.folded")
+	output := filepath.Join(dir, "perf.svg")
+	assert.NoError(t, os.WriteFile(input, []byte("main;work 3\nmain;idle 1\n"), 0644))
+	assert.NoError(t, openevec.DebugFlamegraph(input, output, "", "", ""))
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "<svg")
+
+	// failed render must not leave empty file behind
+	empty := filepath.Join(dir, "empty.folded")
+	failed := filepath.Join(dir, "failed.svg")
+	assert.NoError(t, os.WriteFile(empty, nil, 0644))
+	assert.Error(t, openevec.DebugFlamegraph(empty, failed, "", "", ""))
+	assert.NoFileExists(t, failed)
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+}
@@ -87,6 +87,7 @@ func GetDefaultConfig() *EdenSetupArgs {
 			MgmtPort:       defaults.DefaultSdnMgmtPort,
 			PidFile:        filepath.Join(currentPath, defaults.DefaultDist, "sdn.pid"),
 			SSHPort:        defaults.DefaultSdnSSHPort,
+			Runner:         defaults.DefaultSdnRunner,
 		},
 
 		ConfigName: defaults.DefaultContext,
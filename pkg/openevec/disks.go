@@ -38,7 +38,7 @@ func (openEVEC *OpenEVEC) GetDisksLayout() (device.DisksLayout, error) {
 }
 
 func (openEVEC *OpenEVEC) SetDiskLayout(dc *DisksConfig) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
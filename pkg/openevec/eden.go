@@ -8,6 +8,7 @@ import (
 	"html/template"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path"
@@ -20,14 +21,19 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/einfo"
 	"github.com/lf-edge/eden/pkg/controller/elog"
 	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/exporter"
+	"github.com/lf-edge/eden/pkg/controller/query"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/edensdn"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/flowlog"
 	"github.com/lf-edge/eve-api/go/info"
 	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -137,6 +143,10 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 	if err != nil {
 		return fmt.Errorf("GetDevModelByName: %w", err)
 	}
+	if cfg.Eve.DevModel == defaults.DefaultSimModel {
+		log.Info("EVE simulator does not use image")
+		return nil
+	}
 	imageFormat := model.DiskFormat()
 	eveDesc := utils.EVEDescription{
 		ConfigPath:  cfg.Eden.CertsDir,
@@ -421,11 +431,8 @@ func setupConfigDir(cfg EdenSetupArgs, eveConfigDir, softSerial, zedControlURL s
 	return nil
 }
 
-func setupSdn(cfg EdenSetupArgs) error {
-	if err := os.MkdirAll(cfg.Sdn.ConfigDir, 0777); err != nil {
-		return fmt.Errorf("failed to create directory for SDN config files: %w", err)
-	}
-	// Get Eden-Sdn version.
+// sdnContainerImage returns Eden-SDN version tag and reference of the eden-sdn container image.
+func sdnContainerImage(cfg EdenSetupArgs) (tag, imageRef string, err error) {
 	sdnVmSrcDir := filepath.Join(cfg.Sdn.SourceDir, "vm")
 	cmdArgs := []string{"pkg", "show-tag", sdnVmSrcDir}
 	output, err := exec.Command(cfg.Sdn.LinuxkitBin, cmdArgs...).Output()
@@ -436,20 +443,32 @@ func setupSdn(cfg EdenSetupArgs) error {
 		} else {
 			stderr = err.Error()
 		}
-		return fmt.Errorf("linuxkit pkg show-tag failed: %v", stderr)
+		return "", "", fmt.Errorf("linuxkit pkg show-tag failed: %v", stderr)
+	}
+	tag = strings.Split(string(output), ":")[1]
+	tag = strings.TrimSpace(tag)
+	imageRef = fmt.Sprintf("%s:%s-%s", defaults.DefaultEdenSDNContainerRef, tag, cfg.Eve.Arch)
+	return tag, imageRef, nil
+}
+
+func setupSdn(cfg EdenSetupArgs) error {
+	if err := os.MkdirAll(cfg.Sdn.ConfigDir, 0777); err != nil {
+		return fmt.Errorf("failed to create directory for SDN config files: %w", err)
+	}
+	sdnVmSrcDir := filepath.Join(cfg.Sdn.SourceDir, "vm")
+	sdnTag, sdnImage, err := sdnContainerImage(cfg)
+	if err != nil {
+		return err
 	}
-	sdnTag := strings.Split(string(output), ":")[1]
-	sdnTag = strings.TrimSpace(sdnTag)
 	// Build or preferably pull eden-sdn container.
 	homeDir := filepath.Join(cfg.Eden.Root, "linuxkit-home")
 	envVars := append(os.Environ(), fmt.Sprintf("HOME=%s", homeDir))
-	sdnImage := fmt.Sprintf("%s:%s-%s", defaults.DefaultEdenSDNContainerRef, sdnTag, cfg.Eve.Arch)
 	err = utils.PullImage(sdnImage)
 	if err != nil {
 		log.Warnf("failed to pull eden-sdn image (%s, err: %v), "+
 			"trying to build locally instead...", sdnImage, err)
 		platform := fmt.Sprintf("%s/%s", cfg.Eve.QemuOS, cfg.Eve.Arch)
-		cmdArgs = []string{"pkg", "build", "--force", "--platforms", platform,
+		cmdArgs := []string{"pkg", "build", "--force", "--platforms", platform,
 			"--docker", "--build-yml", "build.yml", sdnVmSrcDir}
 		err := utils.RunCommandForegroundWithOpts(cfg.Sdn.LinuxkitBin, cmdArgs,
 			utils.SetCommandEnvVars(envVars))
@@ -457,6 +476,10 @@ func setupSdn(cfg EdenSetupArgs) error {
 			return fmt.Errorf("failed to build eden-sdn container: %w", err)
 		}
 	}
+	if cfg.Sdn.Runner == edensdn.RunnerContainer {
+		// SDN VM image is not needed.
+		return nil
+	}
 	// Build Eden-SDN VM qcow2 image.
 	imageDir := filepath.Dir(cfg.Sdn.ImageFile)
 	_ = os.MkdirAll(imageDir, 0777)
@@ -465,7 +488,7 @@ func setupSdn(cfg EdenSetupArgs) error {
 		return fmt.Errorf("failed to read eden-sdn vm.yml.in: %w", err)
 	}
 	vmYml := strings.ReplaceAll(string(vmYmlIn), "SDN_TAG", sdnTag)
-	cmdArgs = []string{"build", "--arch", cfg.Eve.Arch, "--format", "qcow2-efi",
+	cmdArgs := []string{"build", "--arch", cfg.Eve.Arch, "--format", "qcow2-efi",
 		"--docker", "--dir", imageDir, "--name", "sdn", "-"}
 	err = utils.RunCommandForegroundWithOpts(cfg.Sdn.LinuxkitBin, cmdArgs,
 		utils.SetCommandStdin(vmYml), utils.SetCommandEnvVars(envVars))
@@ -527,17 +550,29 @@ func (openEVEC *OpenEVEC) EdenClean(configName, configDist, vmName string, curre
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uint, follow bool, printFields []string, args []string) error {
-	changer := &adamChanger{}
-	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+// queryFromArgs builds the query for controller checkers from expression in args
+func queryFromArgs(args []string) (map[string]string, error) {
+	expr := query.JoinArgs(args)
+	if _, err := query.Parse(expr); err != nil {
+		return nil, fmt.Errorf("cannot parse query: %w", err)
+	}
+	return map[string]string{query.Key: expr}, nil
+}
+
+func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uint, follow bool, printFields []string, args []string, timeRange types.TimeRange, fromArchive string) error {
+	ctrl, devFirst, err := openEVEC.getControllerAndDev(fromArchive)
 	if err != nil {
-		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+		return err
 	}
+	if fromArchive != "" {
+		// archive gets no new objects, so following ends with the recorded ones
+		follow = false
+	}
+	ctrl.SetTimeRange(timeRange)
 	devUUID := devFirst.GetID()
-	q := make(map[string]string)
-	for _, a := range args[0:] {
-		s := strings.Split(a, ":")
-		q[s[0]] = s[1]
+	q, err := queryFromArgs(args)
+	if err != nil {
+		return err
 	}
 
 	handleInfo := func(im *info.ZInfoMsg) bool {
@@ -566,19 +601,21 @@ func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uin
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool, logTail uint, printFields, args []string) error {
-	changer := &adamChanger{}
-	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool, logTail uint, printFields, args []string, timeRange types.TimeRange, fromArchive string) error {
+	ctrl, devFirst, err := openEVEC.getControllerAndDev(fromArchive)
 	if err != nil {
-		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+		return err
 	}
+	if fromArchive != "" {
+		// archive gets no new objects, so following ends with the recorded ones
+		follow = false
+	}
+	ctrl.SetTimeRange(timeRange)
 	devUUID := devFirst.GetID()
 
-	q := make(map[string]string)
-
-	for _, a := range args[0:] {
-		s := strings.Split(a, ":")
-		q[s[0]] = s[1]
+	q, err := queryFromArgs(args)
+	if err != nil {
+		return err
 	}
 
 	handleFunc := func(le *elog.FullLogEntry) bool {
@@ -609,19 +646,18 @@ func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool,
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenNetStat(outputFormat types.OutputFormat, follow bool, logTail uint, printFields, args []string) error {
+func (openEVEC *OpenEVEC) EdenNetStat(outputFormat types.OutputFormat, follow bool, logTail uint, printFields, args []string, timeRange types.TimeRange) error {
 	changer := &adamChanger{}
 	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
+	ctrl.SetTimeRange(timeRange)
 	devUUID := devFirst.GetID()
 
-	q := make(map[string]string)
-
-	for _, a := range args[0:] {
-		s := strings.Split(a, ":")
-		q[s[0]] = s[1]
+	q, err := queryFromArgs(args)
+	if err != nil {
+		return err
 	}
 
 	handleFunc := func(le *flowlog.FlowMessage) bool {
@@ -652,19 +688,21 @@ func (openEVEC *OpenEVEC) EdenNetStat(outputFormat types.OutputFormat, follow bo
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow bool, metricTail uint, printFields, args []string) error {
-	changer := &adamChanger{}
-	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow bool, metricTail uint, printFields, args []string, timeRange types.TimeRange, fromArchive string) error {
+	ctrl, devFirst, err := openEVEC.getControllerAndDev(fromArchive)
 	if err != nil {
-		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+		return err
+	}
+	if fromArchive != "" {
+		// archive gets no new objects, so following ends with the recorded ones
+		follow = false
 	}
+	ctrl.SetTimeRange(timeRange)
 	devUUID := devFirst.GetID()
 
-	q := make(map[string]string)
-
-	for _, a := range args[0:] {
-		s := strings.Split(a, ":")
-		q[s[0]] = s[1]
+	q, err := queryFromArgs(args)
+	if err != nil {
+		return err
 	}
 
 	handleFunc := func(le *metrics.ZMetricMsg) bool {
@@ -695,6 +733,39 @@ func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow boo
 	return nil
 }
 
+// EdenMetricExport follows metrics of the device and exposes them
+// in Prometheus format on the listen address
+func (openEVEC *OpenEVEC) EdenMetricExport(listen string) error {
+	changer := &adamChanger{}
+	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	devUUID := devFirst.GetID()
+
+	collector := exporter.NewCollector()
+	registry := prometheus.NewRegistry()
+	if err = registry.Register(collector); err != nil {
+		return fmt.Errorf("cannot register collector: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+
+	done := make(chan error, 2)
+	go func() {
+		done <- http.ListenAndServe(listen, mux)
+	}()
+	// fill collector with the last received metric before waiting for the new ones
+	if err = ctrl.MetricChecker(devUUID, map[string]string{}, collector.HandlerFunc(), emetric.MetricTail(1), 0); err != nil {
+		return fmt.Errorf("MetricChecker: %w", err)
+	}
+	log.Infof("Exporting metrics of device %s on %s/metrics", devUUID, listen)
+	go func() {
+		done <- ctrl.MetricChecker(devUUID, map[string]string{}, collector.HandlerFunc(), emetric.MetricNew, 0)
+	}()
+	return <-done
+}
+
 func (openEVEC *OpenEVEC) EdenExport(tarFile string) error {
 	cfg := openEVEC.cfg
 	changer := &adamChanger{}
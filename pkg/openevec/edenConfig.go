@@ -14,7 +14,7 @@ import (
 )
 
 // optionsWithMapTypeList contains options that should be encoded
-var optionsWithMapTypeList = []string{"eve.hostfwd"}
+var optionsWithMapTypeList = []string{"eve.hostfwd", "eve.instances"}
 
 func isEncodingNeeded(contextKeySet string) bool {
 	for _, k := range optionsWithMapTypeList {
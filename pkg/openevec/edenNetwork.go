@@ -3,16 +3,19 @@ package openevec
 import (
 	"fmt"
 
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/eflowlog"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/expect"
 	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
 	log "github.com/sirupsen/logrus"
 )
 
-func (openEVEC *OpenEVEC) NetworkLs(outputFormat types.OutputFormat) error {
+func (openEVEC *OpenEVEC) NetworkLs(outputFormat types.OutputFormat, watch bool, history string) error {
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -25,14 +28,20 @@ func (openEVEC *OpenEVEC) NetworkLs(outputFormat types.OutputFormat) error {
 	if err := ctrl.MetricLastCallback(dev.GetID(), nil, state.MetricCallback()); err != nil {
 		return fmt.Errorf("fail in get MetricLastCallback: %w", err)
 	}
-	if err := state.NetList(outputFormat); err != nil {
-		return err
+	printState := func() error {
+		if history != "" {
+			return state.NetHistory(history, outputFormat)
+		}
+		return state.NetList(outputFormat)
 	}
-	return nil
+	if watch {
+		return watchEveState(ctrl, dev, state, outputFormat == types.OutputFormatLines, printState)
+	}
+	return printState()
 }
 
 func (openEVEC *OpenEVEC) NetworkDelete(niName string) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -95,17 +104,37 @@ func (openEVEC *OpenEVEC) NetworkNetstat(niName string, outputFormat types.Outpu
 
 func (openEVEC *OpenEVEC) NetworkCreate(subnet, networkType, networkName, uplinkAdapter string,
 	staticDNSEntries []string, enableFlowlog bool) error {
-	if networkType != "local" && networkType != "switch" {
-		return fmt.Errorf("network type %s not supported now", networkType)
-	}
-	if networkType == "local" && subnet == "" {
-		return fmt.Errorf("you must define subnet as first arg for local network")
-	}
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
+	added, err := addNetwork(ctrl, dev, subnet, networkType, networkName, uplinkAdapter, staticDNSEntries, enableFlowlog)
+	if err != nil {
+		return err
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	if openEVEC.cfg.DryRun {
+		return nil
+	}
+	for _, el := range added {
+		log.Infof("deploy network %s with name %s request sent", el.Uuidandversion.Uuid, el.Displayname)
+	}
+	return nil
+}
+
+// addNetwork adds network instance into device config if not exists
+// and returns network instances it added
+func addNetwork(ctrl controller.Cloud, dev *device.Ctx, subnet, networkType, networkName, uplinkAdapter string,
+	staticDNSEntries []string, enableFlowlog bool) ([]*config.NetworkInstanceConfig, error) {
+	if networkType != "local" && networkType != "switch" {
+		return nil, fmt.Errorf("network type %s not supported now", networkType)
+	}
+	if networkType == "local" && subnet == "" {
+		return nil, fmt.Errorf("you must define subnet as first arg for local network")
+	}
 	var opts []expect.ExpectationOption
 	opts = append(opts, expect.AddNetInstanceAndPortPublish(subnet, networkType, networkName, nil, uplinkAdapter))
 	opts = append(opts, expect.WithStaticDNSEntries(networkName, staticDNSEntries))
@@ -114,6 +143,7 @@ func (openEVEC *OpenEVEC) NetworkCreate(subnet, networkType, networkName, uplink
 	}
 	expectation := expect.AppExpectationFromURL(ctrl, dev, defaults.DefaultDummyExpect, "", opts...)
 	netInstancesConfigs := expectation.NetworkInstances()
+	var added []*config.NetworkInstanceConfig
 mainloop:
 	for _, el := range netInstancesConfigs {
 		for _, element := range dev.GetNetworkInstances() {
@@ -123,11 +153,7 @@ mainloop:
 			}
 		}
 		dev.SetNetworkInstanceConfig(append(dev.GetNetworkInstances(), el.Uuidandversion.Uuid))
-		log.Infof("deploy network %s with name %s request sent", el.Uuidandversion.Uuid, el.Displayname)
-	}
-	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
-		return fmt.Errorf("setControllerAndDev: %w", err)
+		added = append(added, el)
 	}
-
-	return nil
+	return added, nil
 }
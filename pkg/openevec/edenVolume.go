@@ -7,7 +7,9 @@ import (
 
 	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/expect"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -16,7 +18,7 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func (openEVEC *OpenEVEC) VolumeLs(outputFormat types.OutputFormat) error {
+func (openEVEC *OpenEVEC) VolumeLs(outputFormat types.OutputFormat, watch bool, history string) error {
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -29,31 +31,52 @@ func (openEVEC *OpenEVEC) VolumeLs(outputFormat types.OutputFormat) error {
 	if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
 		return fmt.Errorf("fail in get InfoLastCallback: %w", err)
 	}
-	if err := state.VolumeList(outputFormat); err != nil {
-		return err
+	printState := func() error {
+		if history != "" {
+			return state.VolumeHistory(history, outputFormat)
+		}
+		return state.VolumeList(outputFormat)
 	}
-	return nil
+	if watch {
+		return watchEveState(ctrl, dev, state, outputFormat == types.OutputFormatLines, printState)
+	}
+	return printState()
 }
 
 func (openEVEC *OpenEVEC) VolumeCreate(appLink, registry, diskSize, volumeName, volumeType, datastoreOverride string, sftpLoad, directLoad bool) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
+	volumeConfig, err := addVolume(ctrl, dev, appLink, registry, diskSize, volumeName, volumeType, datastoreOverride, sftpLoad, directLoad, openEVEC.cfg)
+	if err != nil {
+		return err
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	if !openEVEC.cfg.DryRun {
+		log.Infof("create volume %s with %s request sent", volumeConfig.DisplayName, appLink)
+	}
+	return nil
+}
+
+// addVolume adds volume into device config and returns its config
+func addVolume(ctrl controller.Cloud, dev *device.Ctx, appLink, registry, diskSize, volumeName, volumeType, datastoreOverride string, sftpLoad, directLoad bool, cfg *EdenSetupArgs) (*config.Volume, error) {
 	var opts []expect.ExpectationOption
 	diskSizeParsed, err := humanize.ParseBytes(diskSize)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// special case for blank volumes
 	if appLink == "blank" {
 		if diskSizeParsed == 0 {
-			return fmt.Errorf("cannot create blank volume with 0 size, please provide --disk-size")
+			return nil, fmt.Errorf("cannot create blank volume with 0 size, please provide --disk-size")
 		}
 		id, err := uuid.NewV4()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if volumeName == "" {
 			// generate random name
@@ -71,34 +94,22 @@ func (openEVEC *OpenEVEC) VolumeCreate(appLink, registry, diskSize, volumeName,
 		}
 		_ = ctrl.AddVolume(volume)
 		dev.SetVolumeConfigs(append(dev.GetVolumes(), id.String()))
-	} else {
-		opts = append(opts, expect.WithDiskSize(int64(diskSizeParsed)))
-		opts = append(opts, expect.WithImageFormat(volumeType))
-		opts = append(opts, expect.WithSFTPLoad(sftpLoad))
-		if !sftpLoad {
-			opts = append(opts, expect.WithHTTPDirectLoad(directLoad))
-		}
-		opts = append(opts, expect.WithDatastoreOverride(datastoreOverride))
-		registryToUse := registry
-		switch registry {
-		case "local":
-			registryToUse = fmt.Sprintf("%s:%d", openEVEC.cfg.Registry.IP, openEVEC.cfg.Registry.Port)
-		case "remote":
-			registryToUse = ""
-		}
-		opts = append(opts, expect.WithRegistry(registryToUse))
-		expectation := expect.AppExpectationFromURL(ctrl, dev, appLink, volumeName, opts...)
-		volumeConfig := expectation.Volume()
-		log.Infof("create volume %s with %s request sent", volumeConfig.DisplayName, appLink)
+		return volume, nil
 	}
-	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
-		return fmt.Errorf("setControllerAndDev: %w", err)
+	opts = append(opts, expect.WithDiskSize(int64(diskSizeParsed)))
+	opts = append(opts, expect.WithImageFormat(volumeType))
+	opts = append(opts, expect.WithSFTPLoad(sftpLoad))
+	if !sftpLoad {
+		opts = append(opts, expect.WithHTTPDirectLoad(directLoad))
 	}
-	return nil
+	opts = append(opts, expect.WithDatastoreOverride(datastoreOverride))
+	opts = append(opts, expect.WithRegistry(resolveRegistry(registry, cfg)))
+	expectation := expect.AppExpectationFromURL(ctrl, dev, appLink, volumeName, opts...)
+	return expectation.Volume(), nil
 }
 
 func (openEVEC *OpenEVEC) VolumeDelete(volumeName string) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -124,7 +135,7 @@ func (openEVEC *OpenEVEC) VolumeDelete(volumeName string) error {
 }
 
 func (openEVEC *OpenEVEC) VolumeDetach(volumeName string) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -167,7 +178,7 @@ func (openEVEC *OpenEVEC) VolumeDetach(volumeName string) error {
 }
 
 func (openEVEC *OpenEVEC) VolumeAttach(appName, volumeName, mountPoint string) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
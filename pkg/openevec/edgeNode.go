@@ -23,7 +23,7 @@ import (
 )
 
 func (openEVEC *OpenEVEC) EdgeNodeReboot(controllerMode string) error {
-	changer, err := changerByControllerMode(controllerMode)
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
 	if err != nil {
 		return err
 	}
@@ -41,7 +41,7 @@ func (openEVEC *OpenEVEC) EdgeNodeReboot(controllerMode string) error {
 }
 
 func (openEVEC *OpenEVEC) EdgeNodeShutdown(controllerMode string) error {
-	changer, err := changerByControllerMode(controllerMode)
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
 	if err != nil {
 		return err
 	}
@@ -62,7 +62,7 @@ func (openEVEC *OpenEVEC) EdgeNodeEVEImageUpdate(baseOSImage, baseOSVersion, reg
 	baseOSImageActivate, baseOSVDrive bool) error {
 
 	var opts []expect.ExpectationOption
-	changer, err := changerByControllerMode(controllerMode)
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
 	if err != nil {
 		return err
 	}
@@ -97,7 +97,7 @@ func (openEVEC *OpenEVEC) EdgeNodeEVEImageUpdate(baseOSImage, baseOSVersion, reg
 }
 
 func (openEVEC *OpenEVEC) EdgeNodeEVEImageUpdateRetry(controllerMode string) error {
-	changer, err := changerByControllerMode(controllerMode)
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
 	if err != nil {
 		return err
 	}
@@ -172,7 +172,7 @@ func (openEVEC *OpenEVEC) EdgeNodeEVEImageRemove(controllerMode, baseOSVersion,
 			return fmt.Errorf("unknown URI scheme: %s", r.Scheme)
 		}
 	}
-	changer, err := changerByControllerMode(controllerMode)
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
 	if err != nil {
 		return err
 	}
@@ -222,7 +222,7 @@ func (openEVEC *OpenEVEC) EdgeNodeEVEImageRemove(controllerMode, baseOSVersion,
 }
 
 func (openEVEC *OpenEVEC) EdgeNodeUpdate(controllerMode string, deviceItems, configItems map[string]string) error {
-	changer, err := changerByControllerMode(controllerMode)
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
 	if err != nil {
 		return err
 	}
@@ -248,7 +248,7 @@ func (openEVEC *OpenEVEC) EdgeNodeUpdate(controllerMode string, deviceItems, con
 }
 
 func (openEVEC *OpenEVEC) EdgeNodeGetConfig(controllerMode, fileWithConfig string) error {
-	changer, err := changerByControllerMode(controllerMode)
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
 	if err != nil {
 		return err
 	}
@@ -311,6 +311,13 @@ func (openEVEC *OpenEVEC) EdgeNodeSetConfig(fileWithConfig string) error {
 	if err != nil {
 		return fmt.Errorf("cannot marshal config: %w", err)
 	}
+	if openEVEC.cfg.DryRun {
+		current, err := ctrl.ConfigGet(devUUID)
+		if err != nil {
+			return fmt.Errorf("ConfigGet: %w", err)
+		}
+		return printConfigDiff([]byte(current), cfg)
+	}
 	if err = ctrl.ConfigSet(devUUID, cfg); err != nil {
 		return fmt.Errorf("ConfigSet: %w", err)
 	}
@@ -319,7 +326,7 @@ func (openEVEC *OpenEVEC) EdgeNodeSetConfig(fileWithConfig string) error {
 }
 
 func (openEVEC *OpenEVEC) EdgeNodeGetOptions(controllerMode, fileWithConfig string) error {
-	changer, err := changerByControllerMode(controllerMode)
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
 	if err != nil {
 		return err
 	}
@@ -347,7 +354,7 @@ func (openEVEC *OpenEVEC) EdgeNodeGetOptions(controllerMode, fileWithConfig stri
 }
 
 func (openEVEC *OpenEVEC) EdgeNodeSetOptions(controllerMode, fileWithConfig string) error {
-	changer, err := changerByControllerMode(controllerMode)
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
 	if err != nil {
 		return err
 	}
@@ -373,6 +380,10 @@ func (openEVEC *OpenEVEC) EdgeNodeSetOptions(controllerMode, fileWithConfig stri
 	if err := json.Unmarshal(newOptionsBytes, &devOptions); err != nil {
 		return fmt.Errorf("cannot unmarshal: %w", err)
 	}
+	if openEVEC.cfg.DryRun {
+		log.Info("dry run: device options will not be sent")
+		return nil
+	}
 	if err := ctrl.SetDeviceOptions(dev.GetID(), &devOptions); err != nil {
 		return fmt.Errorf("cannot set device options: %w", err)
 	}
@@ -437,6 +448,10 @@ func (openEVEC *OpenEVEC) ControllerSetOptions(fileWithConfig string) error {
 	if err := json.Unmarshal(newOptionsBytes, &globalOptions); err != nil {
 		return fmt.Errorf("cannot unmarshal: %w", err)
 	}
+	if openEVEC.cfg.DryRun {
+		log.Info("dry run: global options will not be sent")
+		return nil
+	}
 	if err := ctrl.SetGlobalOptions(&globalOptions); err != nil {
 		return fmt.Errorf("cannot set global options: %w", err)
 	}
@@ -16,6 +16,7 @@ import (
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/utils"
 	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/netmodel"
 	"github.com/lf-edge/eve-api/go/info"
 	log "github.com/sirupsen/logrus"
 )
@@ -23,6 +24,12 @@ import (
 const SdnStartTimeout = 3 * time.Minute
 
 func (openEVEC *OpenEVEC) StartEve(vmName, tapInterface string) error {
+	return openEVEC.startEve(vmName, tapInterface, false)
+}
+
+// startEve starts local EVE VM. With sdnRunning, EVE is connected to the already
+// running Eden-SDN instead of starting it for the default EVE instance.
+func (openEVEC *OpenEVEC) startEve(vmName, tapInterface string, sdnRunning bool) error {
 	cfg := openEVEC.cfg
 	if cfg.Eve.Remote {
 		return nil
@@ -41,8 +48,12 @@ func (openEVEC *OpenEVEC) StartEve(vmName, tapInterface string) error {
 		} else {
 			log.Infof("EVE is starting in Virtual Box")
 		}
+	case cfg.Eve.DevModel == defaults.DefaultSimModel:
+		if err := openEVEC.StartEveSim(); err != nil {
+			return err
+		}
 	default:
-		if err := openEVEC.StartEveQemu(tapInterface); err != nil {
+		if err := openEVEC.startEveQemu(tapInterface, sdnRunning); err != nil {
 			return err
 		}
 	}
@@ -50,19 +61,75 @@ func (openEVEC *OpenEVEC) StartEve(vmName, tapInterface string) error {
 }
 
 func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
+	return openEVEC.startEveQemu(tapInterface, false)
+}
+
+func (openEVEC *OpenEVEC) startEveQemu(tapInterface string, sdnRunning bool) error {
 	cfg := openEVEC.cfg
-	// Load network model and prepare SDN config.
 	var err error
 	var netModel sdnapi.NetworkModel
+	switch {
+	case cfg.Eve.Instance == "" && (!sdnRunning || !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel)):
+		netModel, err = startSdn(cfg)
+	default:
+		netModel, err = attachToSdn(cfg)
+	}
+	if err != nil {
+		return err
+	}
+	// Create USB network config override image if requested.
+	var usbImagePath string
+	if cfg.Eve.UsbNetConfFile != "" {
+		currentPath, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		usbImagePath = filepath.Join(currentPath, defaults.DefaultDist, "usb.img")
+		err = utils.CreateUsbNetConfImg(cfg.Eve.UsbNetConfFile, usbImagePath)
+		if err != nil {
+			return err
+		}
+	}
+	// Prepare for EVE installation if requested.
+	isInstaller := false
+	imageFile := cfg.Eve.ImageFile
+	imageFormat := "qcow2"
+	if cfg.Eve.CustomInstaller.Path != "" {
+		isInstaller = true
+		imageFile = cfg.Eve.CustomInstaller.Path
+		imageFormat = cfg.Eve.CustomInstaller.Format
+	}
+	// Start vTPM.
+	if cfg.Eve.TPM {
+		err = eden.StartSWTPM(filepath.Join(filepath.Dir(imageFile), "swtpm"))
+		if err != nil {
+			log.Errorf("cannot start swtpm: %s", err.Error())
+		} else {
+			log.Infof("swtpm is starting")
+		}
+	}
+	// Start EVE VM.
+	if err = eden.StartEVEQemu(cfg.Eve.Arch, cfg.Eve.QemuOS, imageFile, imageFormat, isInstaller, cfg.Eve.Serial, cfg.Eve.TelnetPort,
+		cfg.Eve.QemuConfig.MonitorPort, cfg.Eve.QemuConfig.NetDevSocketPort, cfg.Eve.HostFwd, cfg.Eve.Accel, cfg.Eve.QemuFileToSave, cfg.Eve.Log,
+		cfg.Eve.Pid, netModel, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel), cfg.Sdn.Runner, cfg.Eve.Instance, tapInterface, usbImagePath, cfg.Eve.TPM, false); err != nil {
+		return fmt.Errorf("cannot start eve: %w", err)
+	}
+	log.Infof("EVE is starting")
+	return nil
+}
+
+// startSdn loads the network model and starts Eden-SDN (if enabled) with the model applied.
+func startSdn(cfg *EdenSetupArgs) (netModel sdnapi.NetworkModel, err error) {
+	// Load network model and prepare SDN config.
 	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) || cfg.Sdn.NetModelFile == "" {
 		netModel, err = edensdn.GetDefaultNetModel()
 		if err != nil {
-			return err
+			return netModel, err
 		}
 	} else {
 		netModel, err = edensdn.LoadNetModeFromFile(cfg.Sdn.NetModelFile)
 		if err != nil {
-			return fmt.Errorf("failed to load network model from file '%s': %w",
+			return netModel, fmt.Errorf("failed to load network model from file '%s': %w",
 				cfg.Sdn.NetModelFile, err)
 		}
 	}
@@ -75,9 +142,12 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 		netModel.Host.ControllerPort = 443
 	}
 	if isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		if _, err = netmodel.Parse(netModel); err != nil {
+			return netModel, fmt.Errorf("network model is invalid: %w", err)
+		}
 		nets, err := utils.GetSubnetsNotUsed(1)
 		if err != nil {
-			return fmt.Errorf("failed to get unused IP subnet: %w", err)
+			return netModel, fmt.Errorf("failed to get unused IP subnet: %w", err)
 		}
 		imageDir := filepath.Dir(cfg.Sdn.ImageFile)
 		firmware := []string{"OVMF_CODE.fd", "OVMF_VARS.fd"}
@@ -106,15 +176,21 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 			NetDevBasePort: uint16(cfg.Eve.QemuConfig.NetDevSocketPort),
 			PidFile:        cfg.Sdn.PidFile,
 			ConsoleLogFile: cfg.Sdn.ConsoleLogFile,
+			Runner:         cfg.Sdn.Runner,
+		}
+		if cfg.Sdn.Runner == edensdn.RunnerContainer {
+			if _, sdnConfig.ContainerImage, err = sdnContainerImage(*cfg); err != nil {
+				return netModel, err
+			}
 		}
 		sdnVmRunner, err := edensdn.GetSdnVMRunner(cfg.Eve.DevModel, sdnConfig)
 		if err != nil {
-			return fmt.Errorf("failed to get SDN VM runner: %w", err)
+			return netModel, fmt.Errorf("failed to get SDN VM runner: %w", err)
 		}
 		// Start SDN.
 		err = sdnVmRunner.Start()
 		if err != nil {
-			return fmt.Errorf("cannot start SDN: %w", err)
+			return netModel, fmt.Errorf("cannot start SDN: %w", err)
 		}
 		log.Infof("SDN is starting")
 		// Wait for SDN to start and apply network model.
@@ -130,54 +206,39 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 			}
 		}
 		if err != nil {
-			return fmt.Errorf("timeout waiting for SDN to start: %w", err)
+			return netModel, fmt.Errorf("timeout waiting for SDN to start: %w", err)
 		}
 		err = client.ApplyNetworkModel(netModel)
 		if err != nil {
-			return fmt.Errorf("failed to apply network model: %w", err)
+			return netModel, fmt.Errorf("failed to apply network model: %w", err)
 		}
 		log.Infof("SDN started, network model was submitted.")
 	}
-	// Create USB network config override image if requested.
-	var usbImagePath string
-	if cfg.Eve.UsbNetConfFile != "" {
-		currentPath, err := os.Getwd()
-		if err != nil {
-			return err
-		}
-		usbImagePath = filepath.Join(currentPath, defaults.DefaultDist, "usb.img")
-		err = utils.CreateUsbNetConfImg(cfg.Eve.UsbNetConfFile, usbImagePath)
-		if err != nil {
-			return err
-		}
+	return netModel, nil
+}
+
+// attachToSdn returns the network model of the already running Eden-SDN,
+// to which an additional EVE instance should be connected.
+func attachToSdn(cfg *EdenSetupArgs) (netModel sdnapi.NetworkModel, err error) {
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return netModel, fmt.Errorf("EVE instance %s requires Eden-SDN", cfg.Eve.Instance)
 	}
-	// Prepare for EVE installation if requested.
-	isInstaller := false
-	imageFile := cfg.Eve.ImageFile
-	imageFormat := "qcow2"
-	if cfg.Eve.CustomInstaller.Path != "" {
-		isInstaller = true
-		imageFile = cfg.Eve.CustomInstaller.Path
-		imageFormat = cfg.Eve.CustomInstaller.Format
+	client := &edensdn.SdnClient{
+		SSHPort:  uint16(cfg.Sdn.SSHPort),
+		MgmtPort: uint16(cfg.Sdn.MgmtPort),
 	}
-	// Start vTPM.
-	if cfg.Eve.TPM {
-		err = eden.StartSWTPM(filepath.Join(filepath.Dir(imageFile), "swtpm"))
-		if err != nil {
-			log.Errorf("cannot start swtpm: %s", err.Error())
-		} else {
-			log.Infof("swtpm is starting")
-		}
+	if _, err = client.GetSdnStatus(); err != nil {
+		return netModel, fmt.Errorf("SDN is not running, start the default EVE instance first: %w", err)
 	}
-	// Start EVE VM.
-	if err = eden.StartEVEQemu(cfg.Eve.Arch, cfg.Eve.QemuOS, imageFile, imageFormat, isInstaller, cfg.Eve.Serial, cfg.Eve.TelnetPort,
-		cfg.Eve.QemuConfig.MonitorPort, cfg.Eve.QemuConfig.NetDevSocketPort, cfg.Eve.HostFwd, cfg.Eve.Accel, cfg.Eve.QemuFileToSave, cfg.Eve.Log,
-		cfg.Eve.Pid, netModel, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel), tapInterface, usbImagePath, cfg.Eve.TPM, false); err != nil {
-		log.Errorf("cannot start eve: %s", err.Error())
-	} else {
-		log.Infof("EVE is starting")
+	netModel, err = client.GetNetworkModel()
+	if err != nil {
+		return netModel, fmt.Errorf("failed to get network model: %w", err)
 	}
-	return nil
+	if len(edensdn.EVEInstancePorts(netModel, cfg.Eve.Instance)) == 0 {
+		return netModel, fmt.Errorf("network model has no ports connected to EVE instance %s",
+			cfg.Eve.Instance)
+	}
+	return netModel, nil
 }
 
 func (openEVEC *OpenEVEC) StopEve(vmName string) error {
@@ -186,34 +247,49 @@ func (openEVEC *OpenEVEC) StopEve(vmName string) error {
 		log.Debug("Cannot stop remote EVE")
 		return nil
 	}
-	if cfg.Eve.DevModel == defaults.DefaultVBoxModel {
+	if err := openEVEC.stopEveVM(vmName); err != nil {
+		log.Errorf("cannot stop eve: %s", err.Error())
+	}
+	if cfg.Eve.Instance == "" {
+		// Eden-SDN is shared by all EVE instances and stopped with the default one.
+		eden.StopSDN(cfg.Eve.DevModel, cfg.Sdn.PidFile)
+	}
+	return nil
+}
+
+// stopEveVM stops local EVE VM (and its vTPM), Eden-SDN is left running.
+func (openEVEC *OpenEVEC) stopEveVM(vmName string) error {
+	cfg := openEVEC.cfg
+	switch cfg.Eve.DevModel {
+	case defaults.DefaultVBoxModel:
 		if err := eden.StopEVEVBox(vmName); err != nil {
-			log.Errorf("cannot stop eve: %s", err.Error())
-		} else {
-			log.Infof("EVE is stopping in Virtual Box")
+			return err
 		}
-	} else if cfg.Eve.DevModel == defaults.DefaultParallelsModel {
+		log.Infof("EVE is stopping in Virtual Box")
+	case defaults.DefaultParallelsModel:
 		if err := eden.StopEVEParallels(vmName); err != nil {
-			log.Errorf("cannot stop eve: %s", err.Error())
-		} else {
-			log.Infof("EVE is stopping in Virtual Box")
+			return err
 		}
-	} else {
-		if err := eden.StopEVEQemu(cfg.Eve.Pid); err != nil {
-			log.Errorf("cannot stop eve: %s", err.Error())
-		} else {
+		log.Infof("EVE is stopping in Virtual Box")
+	case defaults.DefaultSimModel:
+		if err := eden.StopEVESim(cfg.Eve.Pid); err != nil {
+			return err
+		}
+		log.Infof("EVE simulator is stopping")
+	default:
+		err := eden.StopEVEQemu(cfg.Eve.Pid)
+		if err == nil {
 			log.Infof("EVE is stopping")
 		}
 		if cfg.Eve.TPM {
-			err := eden.StopSWTPM(filepath.Join(filepath.Dir(cfg.Eve.ImageFile), "swtpm"))
-			if err != nil {
+			if err := eden.StopSWTPM(filepath.Join(filepath.Dir(cfg.Eve.ImageFile), "swtpm")); err != nil {
 				log.Errorf("cannot stop swtpm: %s", err.Error())
 			} else {
 				log.Infof("swtpm is stopping")
 			}
 		}
+		return err
 	}
-	eden.StopSDN(cfg.Eve.DevModel, cfg.Sdn.PidFile)
 	return nil
 }
 
@@ -258,6 +334,8 @@ func (openEVEC *OpenEVEC) StatusEve(vmName string) error {
 			openEVEC.eveStatusVBox(vmName)
 		case cfg.Eve.DevModel == defaults.DefaultParallelsModel:
 			openEVEC.eveStatusParallels(vmName)
+		case cfg.Eve.DevModel == defaults.DefaultSimModel:
+			openEVEC.eveStatusSim(cfg.ConfigName, cfg.Eve.Pid)
 		default:
 			openEVEC.eveStatusQEMU(cfg.ConfigName, cfg.Eve.Pid)
 		}
@@ -276,9 +354,10 @@ func (openEVEC *OpenEVEC) GetEveIP(ifName string) string {
 			ifName = "eth0"
 		}
 		client := &edensdn.SdnClient{
-			SSHPort:    uint16(cfg.Sdn.SSHPort),
-			SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
-			MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+			SSHPort:     uint16(cfg.Sdn.SSHPort),
+			SSHKeyPath:  sdnSSHKeyPath(cfg.Sdn.SourceDir),
+			MgmtPort:    uint16(cfg.Sdn.MgmtPort),
+			EVEInstance: cfg.Eve.Instance,
 		}
 		ip, err := client.GetEveIfIP(ifName)
 		if err != nil {
@@ -339,33 +418,43 @@ func (openEVEC *OpenEVEC) ConsoleEve(host string) error {
 }
 
 func (openEVEC *OpenEVEC) SSHEve(commandToRun string) error {
+	if err := openEVEC.enableEveSSH(); err != nil {
+		return err
+	}
+	return openEVEC.SdnForwardSSHToEve(commandToRun)
+}
+
+// enableEveSSH pushes the Eden SSH public key into the device config
+// to allow SSH access into EVE.
+func (openEVEC *OpenEVEC) enableEveSSH() error {
 	cfg := openEVEC.cfg
-	if _, err := os.Stat(cfg.Eden.SSHKey); !os.IsNotExist(err) {
-		changer := &adamChanger{}
-		ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
-		if err != nil {
-			return fmt.Errorf("cannot get controller or dev, please start them and onboard: %w", err)
-		}
-		b, err := os.ReadFile(ctrl.GetVars().SSHKey)
-		switch {
-		case err != nil:
-			return fmt.Errorf("error reading sshKey file %s: %w", ctrl.GetVars().SSHKey, err)
-		}
-		dev.SetConfigItem("debug.enable.ssh", string(b))
-		if err = ctrl.ConfigSync(dev); err != nil {
-			return err
-		}
-		if err = openEVEC.SdnForwardSSHToEve(commandToRun); err != nil {
-			return err
-		}
-	} else {
+	if _, err := os.Stat(cfg.Eden.SSHKey); os.IsNotExist(err) {
 		return fmt.Errorf("SSH key problem: %w", err)
 	}
-
-	return nil
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("cannot get controller or dev, please start them and onboard: %w", err)
+	}
+	b, err := os.ReadFile(ctrl.GetVars().SSHKey)
+	if err != nil {
+		return fmt.Errorf("error reading sshKey file %s: %w", ctrl.GetVars().SSHKey, err)
+	}
+	dev.SetConfigItem("debug.enable.ssh", string(b))
+	return changer.setControllerAndDev(ctrl, dev)
 }
 
 func (openEVEC *OpenEVEC) ResetEve() error {
+	if openEVEC.cfg.DryRun {
+		// only print changes of config, device is not re-onboarded
+		changer := &adamChanger{dryRun: true}
+		ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+		if err != nil {
+			return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+		}
+		ctrl.ResetDevConfig(dev)
+		return changer.setControllerAndDev(ctrl, dev)
+	}
 	certsUUID := openEVEC.cfg.Eve.CertsUUID
 	edenDir, err := utils.DefaultEdenDir()
 	if err != nil {
@@ -396,7 +485,7 @@ func (openEVEC *OpenEVEC) ResetEve() error {
 }
 
 func (openEVEC *OpenEVEC) NewEpochEve(eveConfigFromFile bool) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -414,7 +503,7 @@ func (openEVEC *OpenEVEC) NewEpochEve(eveConfigFromFile bool) error {
 		dev = devFromFile
 	}
 	dev.SetEpoch(dev.GetEpoch() + 1)
-	if err = ctrl.ConfigSync(dev); err != nil {
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
 		return err
 	}
 	log.Infof("new epoch %d sent", dev.GetEpoch())
@@ -444,7 +533,7 @@ func (openEVEC *OpenEVEC) NewLinkEve(command, eveInterfaceName, vmName string) e
 			if err != nil {
 				return fmt.Errorf("failed to get network model: %w", err)
 			}
-			for i := range netModel.Ports {
+			for i := range edensdn.EVEInstancePorts(netModel, cfg.Eve.Instance) {
 				eveIfNames = append(eveIfNames, fmt.Sprintf("eth%d", i))
 			}
 		} else {
@@ -0,0 +1,108 @@
+package openevec
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/viper"
+)
+
+// SelectEveInstance switches config to the additional EVE instance with the given name.
+// Every instance has its own certs, serial, image, ports and files, derived from
+// the default instance unless overridden inside "eve.instances" of the config.
+func (cfg *EdenSetupArgs) SelectEveInstance(name string) error {
+	instance, ok := cfg.Eve.Instances[name]
+	if !ok {
+		return fmt.Errorf("EVE instance %s is not defined in eve.instances of config", name)
+	}
+	if instance.PortOffset == 0 {
+		// allocate offset once and keep it, so ports of running instances
+		// do not change when other instances are added
+		instance.PortOffset = freeInstancePortOffset(cfg.Eve.Instances)
+		cfg.Eve.Instances[name] = instance
+		if cfg.ConfigFile != "" && !cfg.DryRun {
+			if err := saveInstancePortOffset(name, instance.PortOffset); err != nil {
+				return fmt.Errorf("cannot save port offset of EVE instance %s: %w", name, err)
+			}
+		}
+	}
+	portOffset := instance.PortOffset
+
+	if instance.CertsUUID == "" {
+		instance.CertsUUID = uuid.NewV5(uuid.FromStringOrNil(cfg.Eve.CertsUUID), name).String()
+	}
+	if instance.Serial == "" {
+		instance.Serial = fmt.Sprintf("%s-%s", cfg.Eve.Serial, name)
+	}
+	if instance.TelnetPort == 0 {
+		instance.TelnetPort = cfg.Eve.TelnetPort + portOffset
+	}
+	if instance.MonitorPort == 0 {
+		instance.MonitorPort = cfg.Eve.QemuConfig.MonitorPort + portOffset
+	}
+
+	cfg.Eve.Instance = name
+	cfg.Eve.Name = fmt.Sprintf("%s-%s", cfg.Eve.Name, name)
+	cfg.Eve.CertsUUID = instance.CertsUUID
+	cfg.Eve.Serial = instance.Serial
+	cfg.Eve.TelnetPort = instance.TelnetPort
+	cfg.Eve.QemuConfig.MonitorPort = instance.MonitorPort
+
+	cfg.Eden.CertsDir = fmt.Sprintf("%s-%s", cfg.Eden.CertsDir, name)
+	cfg.Eve.QemuConfigPath = cfg.Eden.CertsDir
+	cfg.Eve.Cert = filepath.Join(cfg.Eden.CertsDir, filepath.Base(cfg.Eve.Cert))
+	cfg.Eve.DeviceCert = filepath.Join(cfg.Eden.CertsDir, filepath.Base(cfg.Eve.DeviceCert))
+	cfg.Eve.ImageFile = filepath.Join(filepath.Dir(cfg.Eve.ImageFile), "instances", name,
+		filepath.Base(cfg.Eve.ImageFile))
+	cfg.Eve.Pid = instanceFileName(cfg.Eve.Pid, name)
+	cfg.Eve.Log = instanceFileName(cfg.Eve.Log, name)
+	cfg.Eve.QemuFileToSave = instanceFileName(cfg.Eve.QemuFileToSave, name)
+	return nil
+}
+
+// freeInstancePortOffset returns the lowest port offset not used by instances.
+func freeInstancePortOffset(instances map[string]EveInstanceConfig) int {
+	used := map[int]bool{}
+	for _, instance := range instances {
+		used[instance.PortOffset] = true
+	}
+	offset := defaults.DefaultEveInstancePortStep
+	for used[offset] {
+		offset += defaults.DefaultEveInstancePortStep
+	}
+	return offset
+}
+
+// saveInstancePortOffset stores port offset of the instance into the loaded config file.
+// The file is left untouched if it already has the same offset.
+func saveInstancePortOffset(name string, portOffset int) error {
+	instances := viper.GetStringMap("eve.instances")
+	instance := map[string]interface{}{}
+	switch el := instances[name].(type) {
+	case map[string]interface{}:
+		for k, v := range el {
+			instance[k] = v
+		}
+	case map[interface{}]interface{}:
+		for k, v := range el {
+			instance[fmt.Sprint(k)] = v
+		}
+	}
+	if saved, ok := instance["port-offset"]; ok && fmt.Sprint(saved) == fmt.Sprint(portOffset) {
+		return nil
+	}
+	instance["port-offset"] = portOffset
+	instances[name] = instance
+	viper.Set("eve.instances", instances)
+	return utils.GenerateConfigFileFromViper()
+}
+
+// instanceFileName inserts the instance name before the file extension.
+func instanceFileName(file, instance string) string {
+	ext := filepath.Ext(file)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(file, ext), instance, ext)
+}
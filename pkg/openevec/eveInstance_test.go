@@ -0,0 +1,72 @@
+package openevec_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectEveInstance(t *testing.T) {
+	t.Parallel()
+
+	cfg := &openevec.EdenSetupArgs{}
+	cfg.Eden.CertsDir = "/eden/dist/default-certs"
+	cfg.Eve.Name = "default"
+	cfg.Eve.CertsUUID = "1c1f5b28-0a8f-4ec5-a6b6-2d41bb1dd0f4"
+	cfg.Eve.Serial = "31415926"
+	cfg.Eve.TelnetPort = 17777
+	cfg.Eve.QemuConfig.MonitorPort = 7788
+	cfg.Eve.Cert = "/eden/dist/default-certs/onboard.cert.pem"
+	cfg.Eve.ImageFile = "/eden/dist/default-images/eve/live.img"
+	cfg.Eve.Pid = "/eden/dist/default-eve.pid"
+	cfg.Eve.Instances = map[string]openevec.EveInstanceConfig{
+		"eve1": {},
+		"eve2": {PortOffset: 100},
+		"eve3": {Serial: "27182818"},
+	}
+
+	assert.Error(t, cfg.SelectEveInstance("eve4"))
+
+	assert.NoError(t, cfg.SelectEveInstance("eve3"))
+	assert.Equal(t, "eve3", cfg.Eve.Instance)
+	assert.Equal(t, "default-eve3", cfg.Eve.Name)
+	assert.Equal(t, "27182818", cfg.Eve.Serial)
+	assert.NotEqual(t, "1c1f5b28-0a8f-4ec5-a6b6-2d41bb1dd0f4", cfg.Eve.CertsUUID)
+	// offset 100 is already used by eve2
+	assert.Equal(t, 200, cfg.Eve.Instances["eve3"].PortOffset)
+	assert.Equal(t, 17977, cfg.Eve.TelnetPort)
+	assert.Equal(t, 7988, cfg.Eve.QemuConfig.MonitorPort)
+	assert.Equal(t, "/eden/dist/default-certs-eve3", cfg.Eden.CertsDir)
+	assert.Equal(t, "/eden/dist/default-certs-eve3/onboard.cert.pem", cfg.Eve.Cert)
+	assert.Equal(t, "/eden/dist/default-images/eve/instances/eve3/live.img", cfg.Eve.ImageFile)
+	assert.Equal(t, "/eden/dist/default-eve-eve3.pid", cfg.Eve.Pid)
+}
+
+func TestSelectEveInstanceKeepsPortOffset(t *testing.T) {
+	t.Parallel()
+
+	cfg := &openevec.EdenSetupArgs{}
+	cfg.Eve.TelnetPort = 17777
+	cfg.Eve.Instances = map[string]openevec.EveInstanceConfig{
+		"b": {PortOffset: 100},
+		// instance sorted before the running one must not shift its ports
+		"a": {},
+	}
+	assert.NoError(t, cfg.SelectEveInstance("b"))
+	assert.Equal(t, 17877, cfg.Eve.TelnetPort)
+}
+
+func TestSelectEveInstanceDryRun(t *testing.T) {
+	t.Parallel()
+
+	cfg := &openevec.EdenSetupArgs{}
+	cfg.ConfigFile = filepath.Join(t.TempDir(), "default.yml")
+	cfg.DryRun = true
+	cfg.Eve.Instances = map[string]openevec.EveInstanceConfig{"a": {}}
+	assert.NoError(t, cfg.SelectEveInstance("a"))
+	assert.NotZero(t, cfg.Eve.Instances["a"].PortOffset)
+	// allocated offset is not saved into config under dry-run
+	assert.NoFileExists(t, cfg.ConfigFile)
+}
@@ -0,0 +1,52 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/evesim"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// StartEveSim starts simulator of EVE in background
+func (openEVEC *OpenEVEC) StartEveSim() error {
+	cfg := openEVEC.cfg
+	if err := eden.StartEVESim(cfg.ConfigName, cfg.Eve.Log, cfg.Eve.Pid); err != nil {
+		return fmt.Errorf("cannot start eve simulator: %w", err)
+	}
+	log.Infof("EVE simulator is starting")
+	return nil
+}
+
+// RunEveSim runs simulator of EVE in foreground until interrupted
+func (openEVEC *OpenEVEC) RunEveSim() error {
+	cfg := openEVEC.cfg
+	sim, err := evesim.New(evesim.Config{
+		CertsDir:      cfg.Eden.CertsDir,
+		ControllerURL: fmt.Sprintf("https://%s:%d", cfg.Adam.CertsIP, cfg.Adam.Port),
+		ServerName:    cfg.Adam.CertsDomain,
+		Serial:        cfg.Eve.Serial,
+		Version:       cfg.Eve.Tag,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create eve simulator: %w", err)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return sim.Run(ctx)
+}
+
+func (openEVEC *OpenEVEC) eveStatusSim(configName, evePidFile string) {
+	statusEVE, err := eden.StatusEVESim(evePidFile)
+	if err != nil {
+		log.Errorf("%s cannot obtain status of EVE simulator process: %s", statusWarn(), err)
+		return
+	}
+	fmt.Printf("%s EVE simulator status: %s\n", representProcessStatus(statusEVE), statusEVE)
+	fmt.Printf("\tLogs for EVE simulator at: %s\n", utils.ResolveAbsPath(configName+"-"+"eve.log"))
+}
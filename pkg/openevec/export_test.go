@@ -0,0 +1,63 @@
+package openevec
+
+import (
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/info"
+)
+
+// Exports of unexported helpers for tests in package openevec_test.
+
+// PartitionsCheck exports partitionsCheck.
+type PartitionsCheck = partitionsCheck
+
+var (
+	ExpectRunning      = expectRunning
+	ExpectOther        = expectOther
+	AllOf              = allOf
+	PartitionsFromInfo = partitionsFromInfo
+)
+
+// PartitionTracker exports partitionTracker without following any device.
+type PartitionTracker struct {
+	tracker *partitionTracker
+}
+
+// NewPartitionTracker returns tracker fed only by calls of HandleInfo.
+func NewPartitionTracker() *PartitionTracker {
+	return &PartitionTracker{tracker: newPartitionTracker()}
+}
+
+// HandleInfo exports partitionTracker.handleInfo.
+func (t *PartitionTracker) HandleInfo(msg *info.ZInfoMsg) bool {
+	return t.tracker.handleInfo(msg)
+}
+
+// Wait exports partitionTracker.wait.
+func (t *PartitionTracker) Wait(check PartitionsCheck, timeout time.Duration) ([]PartitionState, error) {
+	return t.tracker.wait(check, timeout)
+}
+
+// WaitInfo exports partitionTracker.waitInfo.
+func (t *PartitionTracker) WaitInfo(since time.Time, timeout time.Duration) error {
+	return t.tracker.waitInfo(since, timeout)
+}
+
+// Stop exports partitionTracker.stop.
+func (t *PartitionTracker) Stop() []PartitionObservation {
+	return t.tracker.stop()
+}
+
+var (
+	EncryptionBlockFields = encryptionBlockFields
+	ListCipherContexts    = listCipherContexts
+)
+
+// SetControllerAndDev exports adamChanger.setControllerAndDev.
+func SetControllerAndDev(dryRun bool, ctrl controller.Cloud, dev *device.Ctx) error {
+	return (&adamChanger{dryRun: dryRun}).setControllerAndDev(ctrl, dev)
+}
+
+var WatchEveState = watchEveState
@@ -0,0 +1,949 @@
+package openevec
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/expect"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/evecommon"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest describes desired state of edge node
+type Manifest struct {
+	Apps             []ManifestApp     `yaml:"apps,omitempty"`
+	NetworkInstances []ManifestNetwork `yaml:"network-instances,omitempty"`
+	Volumes          []ManifestVolume  `yaml:"volumes,omitempty"`
+	ConfigItems      map[string]string `yaml:"config-items,omitempty"`
+	BaseOS           *ManifestBaseOS   `yaml:"base-os,omitempty"`
+	Disks            *ManifestDisks    `yaml:"disks,omitempty"`
+}
+
+// ManifestApp describes app to deploy with the same fields as `eden pod deploy`
+type ManifestApp struct {
+	Link      string `yaml:"link"`
+	PodConfig `yaml:",inline"`
+}
+
+// ManifestNetwork describes network instance
+type ManifestNetwork struct {
+	Name             string   `yaml:"name"`
+	Type             string   `yaml:"type,omitempty"`
+	Subnet           string   `yaml:"subnet,omitempty"`
+	Uplink           string   `yaml:"uplink,omitempty"`
+	StaticDNSEntries []string `yaml:"static-dns-entries,omitempty"`
+	EnableFlowlog    bool     `yaml:"enable-flowlog,omitempty"`
+}
+
+// ManifestVolume describes volume not attached to apps directly
+type ManifestVolume struct {
+	Name              string `yaml:"name"`
+	Link              string `yaml:"link"`
+	Registry          string `yaml:"registry,omitempty"`
+	DiskSize          string `yaml:"disk-size,omitempty"`
+	Format            string `yaml:"format,omitempty"`
+	DatastoreOverride string `yaml:"datastore-override,omitempty"`
+	SftpLoad          bool   `yaml:"sftp,omitempty"`
+	DirectLoad        bool   `yaml:"direct,omitempty"`
+}
+
+// ManifestBaseOS describes EVE image to run
+type ManifestBaseOS struct {
+	Image    string `yaml:"image"`
+	Version  string `yaml:"version"`
+	Registry string `yaml:"registry,omitempty"`
+}
+
+// ManifestDisks describes disk layout
+type ManifestDisks struct {
+	Layout       string `yaml:"layout,omitempty"`
+	DiskType     string `yaml:"disk-type,omitempty"`
+	OfflineDisks []uint `yaml:"offline,omitempty"`
+	UnusedDisks  []uint `yaml:"unused,omitempty"`
+	ReplaceDisks []uint `yaml:"replace,omitempty"`
+	PartDisks    []uint `yaml:"part,omitempty"`
+}
+
+// UnmarshalYAML fills defaults of `eden pod deploy` for fields not set in manifest
+func (app *ManifestApp) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*app = ManifestApp{PodConfig: PodConfig{
+		Registry:   "remote",
+		DiskSize:   humanize.Bytes(0),
+		VolumeSize: humanize.IBytes(defaults.DefaultVolumeSize),
+		AppMemory:  humanize.Bytes(defaults.DefaultAppMem * 1024),
+		VolumeType: "qcow2",
+		AppCpus:    defaults.DefaultAppCPU,
+		DirectLoad: true,
+	}}
+	type plain ManifestApp
+	return unmarshal((*plain)(app))
+}
+
+// UnmarshalYAML fills defaults of `eden network create` for fields not set in manifest
+func (ni *ManifestNetwork) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*ni = ManifestNetwork{Type: "local", Uplink: "eth0"}
+	type plain ManifestNetwork
+	return unmarshal((*plain)(ni))
+}
+
+// UnmarshalYAML fills defaults of `eden volume create` for fields not set in manifest
+func (vol *ManifestVolume) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*vol = ManifestVolume{Registry: "remote", DiskSize: humanize.Bytes(0), DirectLoad: true}
+	type plain ManifestVolume
+	return unmarshal((*plain)(vol))
+}
+
+// ReadManifest loads manifest from file and checks names of objects
+func ReadManifest(file string) (*Manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("cannot parse manifest %s: %w", file, err)
+	}
+	names := map[string]bool{}
+	for _, app := range m.Apps {
+		if app.Name == "" || app.Link == "" {
+			return nil, fmt.Errorf("name and link must be set for every app")
+		}
+		if names["app/"+app.Name] {
+			return nil, fmt.Errorf("duplicate app %s", app.Name)
+		}
+		names["app/"+app.Name] = true
+	}
+	for _, ni := range m.NetworkInstances {
+		if ni.Name == "" {
+			return nil, fmt.Errorf("name must be set for every network instance")
+		}
+		if names["ni/"+ni.Name] {
+			return nil, fmt.Errorf("duplicate network instance %s", ni.Name)
+		}
+		names["ni/"+ni.Name] = true
+	}
+	for _, vol := range m.Volumes {
+		if vol.Name == "" || vol.Link == "" {
+			return nil, fmt.Errorf("name and link must be set for every volume")
+		}
+		if names["volume/"+vol.Name] {
+			return nil, fmt.Errorf("duplicate volume %s", vol.Name)
+		}
+		names["volume/"+vol.Name] = true
+	}
+	return &m, nil
+}
+
+// Apply reconciles config of edge node with manifest: objects missing
+// in manifest are removed, changed ones are re-created and new ones are added.
+// Config items are reconciled only if manifest has config-items section,
+// otherwise they are kept as is.
+func (openEVEC *OpenEVEC) Apply(file string) error {
+	m, err := ReadManifest(file)
+	if err != nil {
+		return err
+	}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	if err = ApplyManifest(ctrl, dev, m, openEVEC.cfg); err != nil {
+		return err
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("manifest %s applied", file)
+	return nil
+}
+
+// ApplyManifest modifies config of device inside of controller according to manifest
+// without sending it to the device.
+func ApplyManifest(ctrl controller.Cloud, dev *device.Ctx, m *Manifest, cfg *EdenSetupArgs) error {
+	if err := applyNetworks(ctrl, dev, m.NetworkInstances); err != nil {
+		return err
+	}
+	if err := applyApps(ctrl, dev, m.Apps, cfg); err != nil {
+		return err
+	}
+	if err := applyVolumes(ctrl, dev, m.Volumes, cfg); err != nil {
+		return err
+	}
+	// nil map means that manifest has no config-items section
+	if m.ConfigItems != nil {
+		for key := range dev.GetConfigItems() {
+			if _, ok := m.ConfigItems[key]; !ok {
+				dev.DelConfigItem(key)
+				log.Infof("remove config item %s", key)
+			}
+		}
+		for key, val := range m.ConfigItems {
+			dev.SetConfigItem(key, val)
+		}
+	}
+	if m.BaseOS != nil && m.BaseOS.Version != dev.GetBaseOSVersion() {
+		opts := []expect.ExpectationOption{expect.WithRegistry(resolveRegistry(m.BaseOS.Registry, cfg))}
+		expectation := expect.AppExpectationFromURL(ctrl, dev, m.BaseOS.Image, "", opts...)
+		baseOS := expectation.BaseOS(m.BaseOS.Version)
+		dev.SetBaseOSActivate(true)
+		dev.SetBaseOSContentTree(baseOS.ContentTreeUuid)
+		dev.SetBaseOSRetryCounter(0)
+		dev.SetBaseOSVersion(baseOS.BaseOsVersion)
+		log.Infof("update base OS to %s", m.BaseOS.Version)
+	}
+	if m.Disks != nil {
+		layout, err := m.Disks.layout()
+		if err != nil {
+			return err
+		}
+		dev.SetDiskLayout(layout)
+	}
+	return nil
+}
+
+func applyNetworks(ctrl controller.Cloud, dev *device.Ctx, networks []ManifestNetwork) error {
+	desired := map[string]ManifestNetwork{}
+	for _, ni := range networks {
+		desired[ni.Name] = ni
+	}
+	existing := map[string]bool{}
+	// iterate over copy as list is modified inside the loop
+	for _, id := range append([]string{}, dev.GetNetworkInstances()...) {
+		ni, err := ctrl.GetNetworkInstanceConfig(id)
+		if err != nil {
+			return fmt.Errorf("no network in cloud %s: %w", id, err)
+		}
+		if want, ok := desired[ni.Displayname]; ok && sameNetwork(ni, want) {
+			existing[ni.Displayname] = true
+			continue
+		}
+		removeID(dev.GetNetworkInstances, dev.SetNetworkInstanceConfig, id)
+		log.Infof("remove network %s", ni.Displayname)
+	}
+	for _, ni := range networks {
+		if existing[ni.Name] {
+			continue
+		}
+		if _, err := addNetwork(ctrl, dev, ni.Subnet, ni.Type, ni.Name, ni.Uplink, ni.StaticDNSEntries, ni.EnableFlowlog); err != nil {
+			return fmt.Errorf("network %s: %w", ni.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyApps re-creates config of every app from manifest and keeps the current one
+// only if the result is the same. So every field of manifest is taken into account and
+// apps are moved to network instances re-created by applyNetworks.
+func applyApps(ctrl controller.Cloud, dev *device.Ctx, apps []ManifestApp, cfg *EdenSetupArgs) error {
+	desired := map[string]bool{}
+	for _, app := range apps {
+		desired[app.Name] = true
+	}
+	current := map[string]*config.AppInstanceConfig{}
+	// iterate over copy as list is modified inside the loop
+	for _, id := range append([]string{}, dev.GetApplicationInstances()...) {
+		app, err := ctrl.GetApplicationInstanceConfig(id)
+		if err != nil {
+			return fmt.Errorf("no app in cloud %s: %w", id, err)
+		}
+		if desired[app.Displayname] {
+			current[app.Displayname] = app
+			continue
+		}
+		deleteAppVolumes(ctrl, dev, app)
+		removeID(dev.GetApplicationInstances, dev.SetApplicationInstanceConfig, id)
+		log.Infof("remove app %s", app.Displayname)
+	}
+	for _, app := range apps {
+		appsBefore := append([]string{}, dev.GetApplicationInstances()...)
+		volumesBefore := append([]string{}, dev.GetVolumes()...)
+		contentTreesBefore := append([]string{}, dev.GetContentTrees()...)
+		old := current[app.Name]
+		if old != nil {
+			// expectation returns existing app with the same name instead of creating a new one
+			deleteAppVolumes(ctrl, dev, old)
+			removeID(dev.GetApplicationInstances, dev.SetApplicationInstanceConfig, old.Uuidandversion.Uuid)
+		}
+		opts, err := podExpectationOptions(app.PodConfig, cfg)
+		if err != nil {
+			return fmt.Errorf("app %s: %w", app.Name, err)
+		}
+		expectation := expect.AppExpectationFromURL(ctrl, dev, app.Link, app.Name, opts...)
+		appInstanceConfig := expectation.Application()
+		if old != nil {
+			same, err := sameApp(ctrl, dev, old, appInstanceConfig)
+			if err != nil {
+				return fmt.Errorf("app %s: %w", app.Name, err)
+			}
+			if same {
+				dev.SetApplicationInstanceConfig(appsBefore)
+				dev.SetVolumeConfigs(volumesBefore)
+				dev.SetContentTreeConfig(contentTreesBefore)
+				continue
+			}
+			log.Infof("update app %s", app.Name)
+		} else {
+			log.Infof("deploy app %s with %s", app.Name, app.Link)
+		}
+		dev.SetApplicationInstanceConfig(append(dev.GetApplicationInstances(), appInstanceConfig.Uuidandversion.Uuid))
+	}
+	return nil
+}
+
+func applyVolumes(ctrl controller.Cloud, dev *device.Ctx, volumes []ManifestVolume, cfg *EdenSetupArgs) error {
+	desired := map[string]ManifestVolume{}
+	for _, vol := range volumes {
+		desired[vol.Name] = vol
+	}
+	standalone, err := standaloneVolumes(ctrl, dev)
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for _, vol := range standalone {
+		if want, ok := desired[vol.DisplayName]; ok {
+			same, err := sameVolume(ctrl, vol, want, resolveRegistry(want.Registry, cfg))
+			if err != nil {
+				return err
+			}
+			if same {
+				existing[vol.DisplayName] = true
+				continue
+			}
+		}
+		removeID(dev.GetVolumes, dev.SetVolumeConfigs, vol.Uuid)
+		log.Infof("remove volume %s", vol.DisplayName)
+	}
+	for _, vol := range volumes {
+		if existing[vol.Name] {
+			continue
+		}
+		if _, err := addVolume(ctrl, dev, vol.Link, vol.Registry, vol.DiskSize, vol.Name, vol.Format,
+			vol.DatastoreOverride, vol.SftpLoad, vol.DirectLoad, cfg); err != nil {
+			return fmt.Errorf("volume %s: %w", vol.Name, err)
+		}
+	}
+	return nil
+}
+
+// ExportManifest writes manifest describing current config of edge node into file.
+// With redact set, metadata and VNC passwords of apps are not exported.
+func (openEVEC *OpenEVEC) ExportManifest(file string, redact bool) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	m, err := ManifestFromConfig(ctrl, dev)
+	if err != nil {
+		return err
+	}
+	if redact {
+		m.RedactSecrets()
+	}
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// RedactSecrets removes VNC passwords and metadata of apps from manifest
+// to not store them in plain text
+func (m *Manifest) RedactSecrets() {
+	for i := range m.Apps {
+		app := &m.Apps[i]
+		if app.VncPassword != "" {
+			log.Warnf("VNC password of app %s is not exported", app.Name)
+			app.VncPassword = ""
+		}
+		if app.Metadata != "" {
+			log.Warnf("metadata of app %s is not exported", app.Name)
+			app.Metadata = ""
+		}
+	}
+}
+
+// ManifestFromConfig returns manifest describing config of device inside of controller
+func ManifestFromConfig(ctrl controller.Cloud, dev *device.Ctx) (*Manifest, error) {
+	m := &Manifest{ConfigItems: map[string]string{}}
+	for key, val := range dev.GetConfigItems() {
+		m.ConfigItems[key] = val
+	}
+	niNames := map[string]string{}
+	for _, id := range dev.GetNetworkInstances() {
+		ni, err := ctrl.GetNetworkInstanceConfig(id)
+		if err != nil {
+			return nil, fmt.Errorf("no network in cloud %s: %w", id, err)
+		}
+		niNames[id] = ni.Displayname
+		exported := networkToManifest(ni)
+		for _, entry := range ni.Dns {
+			exported.StaticDNSEntries = append(exported.StaticDNSEntries,
+				fmt.Sprintf("%s:%s", entry.HostName, strings.Join(entry.Address, ",")))
+		}
+		m.NetworkInstances = append(m.NetworkInstances, exported)
+	}
+	for _, id := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(id)
+		if err != nil {
+			return nil, fmt.Errorf("no app in cloud %s: %w", id, err)
+		}
+		exported, err := appToManifest(ctrl, app, niNames)
+		if err != nil {
+			return nil, err
+		}
+		m.Apps = append(m.Apps, exported)
+	}
+	standalone, err := standaloneVolumes(ctrl, dev)
+	if err != nil {
+		return nil, err
+	}
+	for _, vol := range standalone {
+		link, err := volumeLink(ctrl, vol)
+		if err != nil {
+			return nil, err
+		}
+		exported := ManifestVolume{
+			Name:       vol.DisplayName,
+			Link:       link,
+			Registry:   "remote",
+			DiskSize:   fmt.Sprintf("%dB", vol.Maxsizebytes),
+			DirectLoad: true,
+		}
+		if registry, err := volumeRegistry(ctrl, vol); err != nil {
+			return nil, err
+		} else if registry != "" {
+			exported.Registry = registry
+		}
+		m.Volumes = append(m.Volumes, exported)
+	}
+	if dev.GetBaseOSVersion() != "" && dev.GetBaseOSContentTree() != "" {
+		link, err := contentTreeLink(ctrl, dev.GetBaseOSContentTree())
+		if err != nil {
+			return nil, err
+		}
+		m.BaseOS = &ManifestBaseOS{Image: link, Version: dev.GetBaseOSVersion()}
+	}
+	if layout := dev.GetDiskLayout(); layout != nil {
+		m.Disks = &ManifestDisks{
+			Layout:       LayoutTypeIds[layout.LayoutType][0],
+			DiskType:     DiskTypeIds[layout.DiskType][0],
+			OfflineDisks: layout.OfflineDisks,
+			UnusedDisks:  layout.UnusedDisks,
+			ReplaceDisks: layout.ReplaceDisks,
+			PartDisks:    layout.PartDisks,
+		}
+	}
+	return m, nil
+}
+
+// appToManifest describes app with fields of manifest, so applying of the result
+// produces the same app config
+func appToManifest(ctrl controller.Cloud, app *config.AppInstanceConfig, niNames map[string]string) (ManifestApp, error) {
+	link, err := appLink(ctrl, app)
+	if err != nil {
+		return ManifestApp{}, err
+	}
+	resources := app.GetFixedresources()
+	exported := ManifestApp{Link: link, PodConfig: PodConfig{
+		Name:              app.Displayname,
+		Registry:          "remote",
+		DiskSize:          humanize.Bytes(0),
+		VolumeSize:        humanize.IBytes(defaults.DefaultVolumeSize),
+		AppMemory:         fmt.Sprintf("%dkB", resources.GetMemory()),
+		VolumeType:        string(expect.VolumeQcow2),
+		AppCpus:           resources.GetVcpus(),
+		StartDelay:        app.StartDelayInSeconds,
+		PinCpus:           resources.GetPinCpu(),
+		Profiles:          app.ProfileList,
+		NoHyper:           resources.GetVirtualizationMode() == config.VmMode_NOHYPER,
+		VncDisplay:        resources.GetVncDisplay(),
+		VncPassword:       resources.GetVncPasswd(),
+		OpenStackMetadata: app.MetaDataType == config.MetaDataType_MetaDataOpenStack,
+		DirectLoad:        true,
+	}}
+	if app.UserData != "" {
+		if metadata, err := base64.StdEncoding.DecodeString(app.UserData); err == nil {
+			exported.Metadata = string(metadata)
+		}
+	} else if app.CipherData != nil {
+		log.Warnf("cannot export encrypted metadata of app %s", app.Displayname)
+	}
+	for _, adapter := range app.Adapters {
+		exported.AppAdapters = append(exported.AppAdapters, adapter.Name)
+	}
+	for i, ref := range app.VolumeRefList {
+		vol, err := ctrl.GetVolume(ref.Uuid)
+		if err != nil {
+			return ManifestApp{}, fmt.Errorf("no volume in cloud %s: %w", ref.Uuid, err)
+		}
+		if i == 0 {
+			exported.DiskSize = fmt.Sprintf("%dB", vol.Maxsizebytes)
+			registry, err := volumeRegistry(ctrl, vol)
+			if err != nil {
+				return ManifestApp{}, err
+			}
+			if registry != "" {
+				exported.Registry = registry
+			}
+			continue
+		}
+		// volumes for mount points of image and additional disks are created with the same size
+		exported.VolumeSize = fmt.Sprintf("%dB", vol.Maxsizebytes)
+		link, err := volumeLink(ctrl, vol)
+		if err != nil {
+			return ManifestApp{}, err
+		}
+		if emptyVolumeLink(link) {
+			// volume for mount point of image is created from empty volume of the type
+			if ctID := vol.GetOrigin().GetDownloadContentTreeID(); ctID != "" {
+				ct, err := ctrl.GetContentTree(ctID)
+				if err != nil {
+					return ManifestApp{}, fmt.Errorf("no content tree in cloud %s: %w", ctID, err)
+				}
+				exported.VolumeType = volumeTypeName(ct.Iformat)
+			}
+			continue
+		}
+		if ref.MountDir != "" {
+			exported.Mount = append(exported.Mount, fmt.Sprintf("src=%s,dst=%s", link, ref.MountDir))
+		} else {
+			exported.Disks = append(exported.Disks, link)
+		}
+	}
+	for i, iface := range app.Interfaces {
+		niName, ok := niNames[iface.NetworkId]
+		if !ok {
+			continue
+		}
+		network := niName
+		if iface.MacAddress != "" {
+			network = fmt.Sprintf("%s:%s", niName, iface.MacAddress)
+		}
+		exported.Networks = append(exported.Networks, network)
+		if iface.AccessVlanId != 0 {
+			exported.Vlans = append(exported.Vlans, fmt.Sprintf("%s:%d", niName, iface.AccessVlanId))
+		}
+		ports, acls := interfaceACLs(niName, iface.Acls)
+		if i == 0 {
+			// ports are published on the first network only
+			exported.PortPublish = ports
+		}
+		exported.ACL = append(exported.ACL, acls...)
+	}
+	return exported, nil
+}
+
+// emptyVolumeLink checks if link points to one of empty volumes used for mount points of images
+func emptyVolumeLink(link string) bool {
+	for _, empty := range []string{defaults.DefaultEmptyVolumeLinkQcow2, defaults.DefaultEmptyVolumeLinkRaw,
+		defaults.DefaultEmptyVolumeLinkQcow, defaults.DefaultEmptyVolumeLinkVMDK, defaults.DefaultEmptyVolumeLinkVHDX} {
+		if path.Base(link) == empty {
+			return true
+		}
+	}
+	return sameLink(link, defaults.DefaultEmptyVolumeLinkDocker, "")
+}
+
+// interfaceACLs converts rules of app interface into port forwarding and ACL entries of manifest.
+// Single rule allowing all addresses is the default one and is not exported.
+func interfaceACLs(niName string, aces []*config.ACE) (ports, acls []string) {
+	var rules []*config.ACE
+	for _, ace := range aces {
+		var lport string
+		for _, match := range ace.Matches {
+			if match.Type == "lport" {
+				lport = match.Value
+			}
+		}
+		if len(ace.Actions) > 0 && ace.Actions[0].Portmap {
+			ports = append(ports, fmt.Sprintf("%s:%d", lport, ace.Actions[0].AppPort))
+			continue
+		}
+		rules = append(rules, ace)
+	}
+	sort.Strings(ports)
+	if len(rules) == 0 {
+		return ports, []string{niName + ":"}
+	}
+	if len(rules) == 1 && len(rules[0].Matches) == 1 && len(rules[0].Actions) == 0 &&
+		rules[0].Matches[0].Type == "ip" && rules[0].Matches[0].Value == "0.0.0.0/0" {
+		return ports, nil
+	}
+	for _, ace := range rules {
+		if len(ace.Matches) == 0 {
+			continue
+		}
+		endpoint := ace.Matches[0].Value
+		if ace.Matches[0].Type == "host" && endpoint == "" {
+			endpoint = defaults.DefaultHostOnlyNotation
+		}
+		entry := fmt.Sprintf("%s:%s", niName, endpoint)
+		if len(ace.Actions) > 0 && ace.Actions[0].Drop {
+			entry += ":drop"
+		}
+		acls = append(acls, entry)
+	}
+	return ports, acls
+}
+
+// volumeRegistry returns registry of docker image of volume or empty string for other volumes
+func volumeRegistry(ctrl controller.Cloud, vol *config.Volume) (string, error) {
+	ctID := vol.GetOrigin().GetDownloadContentTreeID()
+	if ctID == "" {
+		return "", nil
+	}
+	ct, err := ctrl.GetContentTree(ctID)
+	if err != nil {
+		return "", fmt.Errorf("no content tree in cloud %s: %w", ctID, err)
+	}
+	ds, err := ctrl.GetDataStore(ct.DsId)
+	if err != nil {
+		return "", fmt.Errorf("no datastore in cloud %s: %w", ct.DsId, err)
+	}
+	if ds.DType != config.DsType_DsContainerRegistry {
+		return "", nil
+	}
+	return strings.TrimPrefix(ds.Fqdn, "docker://"), nil
+}
+
+// volumeTypeName returns name of volume type used for image format
+func volumeTypeName(format config.Format) string {
+	if format == config.Format_CONTAINER {
+		return string(expect.VolumeOCI)
+	}
+	return strings.ToLower(format.String())
+}
+
+// layout converts names of types into DisksLayout
+func (disks *ManifestDisks) layout() (*device.DisksLayout, error) {
+	layout := &device.DisksLayout{
+		OfflineDisks: disks.OfflineDisks,
+		UnusedDisks:  disks.UnusedDisks,
+		ReplaceDisks: disks.ReplaceDisks,
+		PartDisks:    disks.PartDisks,
+	}
+	found := disks.Layout == ""
+	for layoutType, ids := range LayoutTypeIds {
+		if ids[0] == disks.Layout {
+			layout.LayoutType = layoutType
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown disks layout %s", disks.Layout)
+	}
+	found = disks.DiskType == ""
+	for diskType, ids := range DiskTypeIds {
+		if ids[0] == disks.DiskType {
+			layout.DiskType = diskType
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown disk type %s", disks.DiskType)
+	}
+	return layout, nil
+}
+
+// removeID removes id from list of device objects
+func removeID(get func() []string, set func([]string) *device.Ctx, id string) {
+	ids := get()
+	utils.DelEleInSliceByFunction(&ids, func(i interface{}) bool {
+		return i.(string) == id
+	})
+	set(ids)
+}
+
+// networkToManifest returns comparable fields of network instance
+func networkToManifest(ni *config.NetworkInstanceConfig) ManifestNetwork {
+	result := ManifestNetwork{
+		Name:          ni.Displayname,
+		Type:          "local",
+		Subnet:        ni.GetIp().GetSubnet(),
+		Uplink:        "none",
+		EnableFlowlog: !ni.DisableFlowlog,
+	}
+	if ni.InstType == config.ZNetworkInstType_ZnetInstSwitch {
+		result.Type = "switch"
+		result.Subnet = ""
+	}
+	if ni.Port != nil {
+		result.Uplink = ni.Port.Name
+	}
+	return result
+}
+
+// sameNetwork checks if network instance has the same type, subnet and uplink as described in manifest
+func sameNetwork(ni *config.NetworkInstanceConfig, want ManifestNetwork) bool {
+	current := networkToManifest(ni)
+	if want.Type == "switch" {
+		want.Subnet = ""
+	}
+	return current.Type == want.Type && current.Subnet == want.Subnet &&
+		current.Uplink == want.Uplink && current.EnableFlowlog == want.EnableFlowlog
+}
+
+// standaloneVolumes returns volumes not referenced by apps
+func standaloneVolumes(ctrl controller.Cloud, dev *device.Ctx) ([]*config.Volume, error) {
+	used := map[string]bool{}
+	for _, id := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(id)
+		if err != nil {
+			return nil, fmt.Errorf("no app in cloud %s: %w", id, err)
+		}
+		for _, ref := range app.VolumeRefList {
+			used[ref.Uuid] = true
+		}
+	}
+	var result []*config.Volume
+	for _, id := range dev.GetVolumes() {
+		if used[id] {
+			continue
+		}
+		vol, err := ctrl.GetVolume(id)
+		if err != nil {
+			return nil, fmt.Errorf("no volume in cloud %s: %w", id, err)
+		}
+		result = append(result, vol)
+	}
+	return result, nil
+}
+
+// sameApp checks if current config of app is the same as config created from manifest.
+// App referencing network instance not in device config anymore is treated as changed.
+func sameApp(ctrl controller.Cloud, dev *device.Ctx, current, created *config.AppInstanceConfig) (bool, error) {
+	networks := map[string]bool{}
+	for _, id := range dev.GetNetworkInstances() {
+		networks[id] = true
+	}
+	for _, iface := range current.Interfaces {
+		if !networks[iface.NetworkId] {
+			return false, nil
+		}
+	}
+	currentSpec, err := appSpec(ctrl, current)
+	if err != nil {
+		return false, err
+	}
+	createdSpec, err := appSpec(ctrl, created)
+	if err != nil {
+		return false, err
+	}
+	return proto.Equal(currentSpec, createdSpec), nil
+}
+
+// appSpec returns copy of app config with identifiers of referenced objects replaced
+// with hashes of their content and without state changed by `eden pod` commands
+func appSpec(ctrl controller.Cloud, app *config.AppInstanceConfig) (*config.AppInstanceConfig, error) {
+	spec := proto.Clone(app).(*config.AppInstanceConfig)
+	spec.Uuidandversion = nil
+	spec.Activate = false
+	spec.Restart = nil
+	spec.Purge = nil
+	clearCipherBlock(spec.CipherData)
+	for _, drive := range spec.Drives {
+		if drive.Image == nil {
+			continue
+		}
+		drive.Image.Uuidandversion = nil
+		dsKey, err := datastoreKey(ctrl, drive.Image.DsId)
+		if err != nil {
+			return nil, err
+		}
+		drive.Image.DsId = dsKey
+	}
+	for _, iface := range spec.Interfaces {
+		ni, err := ctrl.GetNetworkInstanceConfig(iface.NetworkId)
+		if err != nil {
+			return nil, fmt.Errorf("no network in cloud %s: %w", iface.NetworkId, err)
+		}
+		iface.NetworkId = ni.Displayname
+		// port forwarding rules are generated from map in random order
+		for _, ace := range iface.Acls {
+			ace.Id = 0
+		}
+		sort.Slice(iface.Acls, func(i, j int) bool {
+			return protoHash(iface.Acls[i]) < protoHash(iface.Acls[j])
+		})
+	}
+	for _, ref := range spec.VolumeRefList {
+		volKey, err := volumeKey(ctrl, ref.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		ref.Uuid = volKey
+	}
+	return spec, nil
+}
+
+// volumeKey returns hash of volume and its content tree without identifiers
+func volumeKey(ctrl controller.Cloud, id string) (string, error) {
+	vol, err := ctrl.GetVolume(id)
+	if err != nil {
+		return "", fmt.Errorf("no volume in cloud %s: %w", id, err)
+	}
+	vol = proto.Clone(vol).(*config.Volume)
+	vol.Uuid = ""
+	vol.GenerationCount = 0
+	if ctID := vol.GetOrigin().GetDownloadContentTreeID(); ctID != "" {
+		ct, err := ctrl.GetContentTree(ctID)
+		if err != nil {
+			return "", fmt.Errorf("no content tree in cloud %s: %w", ctID, err)
+		}
+		ct = proto.Clone(ct).(*config.ContentTree)
+		ct.Uuid = ""
+		ct.GenerationCount = 0
+		if ct.DsId, err = datastoreKey(ctrl, ct.DsId); err != nil {
+			return "", err
+		}
+		vol.Origin.DownloadContentTreeID = protoHash(ct)
+	}
+	return protoHash(vol), nil
+}
+
+// datastoreKey returns hash of datastore without identifier
+func datastoreKey(ctrl controller.Cloud, id string) (string, error) {
+	ds, err := ctrl.GetDataStore(id)
+	if err != nil {
+		return "", fmt.Errorf("no datastore in cloud %s: %w", id, err)
+	}
+	ds = proto.Clone(ds).(*config.DatastoreConfig)
+	ds.Id = ""
+	clearCipherBlock(ds.CipherData)
+	return protoHash(ds), nil
+}
+
+// clearCipherBlock keeps only hash of clear text as encryption uses random initial value
+func clearCipherBlock(block *evecommon.CipherBlock) {
+	if block == nil {
+		return
+	}
+	block.CipherContextId = ""
+	block.InitialValue = nil
+	block.CipherData = nil
+}
+
+func protoHash(m proto.Message) string {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		log.Errorf("cannot marshal %v: %v", m, err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// sameVolume checks if volume created with the same link and size as described in manifest
+func sameVolume(ctrl controller.Cloud, vol *config.Volume, want ManifestVolume, registry string) (bool, error) {
+	size, err := humanize.ParseBytes(want.DiskSize)
+	if err != nil {
+		return false, fmt.Errorf("volume %s: %w", want.Name, err)
+	}
+	if size != 0 && int64(size) != vol.Maxsizebytes {
+		return false, nil
+	}
+	link, err := volumeLink(ctrl, vol)
+	if err != nil {
+		return false, err
+	}
+	return sameLink(link, want.Link, datastoreRegistry(registry, want.DatastoreOverride)), nil
+}
+
+// datastoreRegistry returns registry which is used in datastore of docker images
+func datastoreRegistry(registry, datastoreOverride string) string {
+	if datastoreOverride != "" {
+		return strings.TrimPrefix(datastoreOverride, "docker://")
+	}
+	return registry
+}
+
+// appLink returns link to the image of the first volume of app
+func appLink(ctrl controller.Cloud, app *config.AppInstanceConfig) (string, error) {
+	if len(app.VolumeRefList) == 0 {
+		return "", nil
+	}
+	vol, err := ctrl.GetVolume(app.VolumeRefList[0].Uuid)
+	if err != nil {
+		return "", fmt.Errorf("no volume in cloud %s: %w", app.VolumeRefList[0].Uuid, err)
+	}
+	return volumeLink(ctrl, vol)
+}
+
+// volumeLink returns link to the content of volume
+func volumeLink(ctrl controller.Cloud, vol *config.Volume) (string, error) {
+	if vol.GetOrigin().GetType() == config.VolumeContentOriginType_VCOT_BLANK {
+		return "blank", nil
+	}
+	return contentTreeLink(ctrl, vol.GetOrigin().GetDownloadContentTreeID())
+}
+
+// contentTreeLink reconstructs link from content tree and its datastore.
+// Images loaded through eserver cannot be reconstructed, so only name of file is kept for them.
+func contentTreeLink(ctrl controller.Cloud, id string) (string, error) {
+	ct, err := ctrl.GetContentTree(id)
+	if err != nil {
+		return "", fmt.Errorf("no content tree in cloud %s: %w", id, err)
+	}
+	ds, err := ctrl.GetDataStore(ct.DsId)
+	if err != nil {
+		return "", fmt.Errorf("no datastore in cloud %s: %w", ct.DsId, err)
+	}
+	switch ds.DType {
+	case config.DsType_DsContainerRegistry:
+		return fmt.Sprintf("docker://%s/%s", strings.TrimPrefix(ds.Fqdn, "docker://"), ct.URL), nil
+	case config.DsType_DsHttp, config.DsType_DsHttps:
+		return fmt.Sprintf("%s/%s", strings.TrimSuffix(ds.Fqdn, "/"), ct.URL), nil
+	}
+	log.Warnf("cannot reconstruct link of %s loaded through eserver", ct.DisplayName)
+	return fmt.Sprintf("file://%s", path.Base(ct.URL)), nil
+}
+
+// normalizeLink returns docker links in form of docker://<registry>/<repository>:<tag>
+func normalizeLink(link, registry string) string {
+	scheme, ref, found := strings.Cut(link, "://")
+	if !found {
+		scheme, ref = "docker", link
+	}
+	if scheme != "docker" && scheme != "oci" {
+		return link
+	}
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		return link
+	}
+	if registry == "" {
+		registry = parsed.Context().RegistryStr()
+	}
+	return fmt.Sprintf("docker://%s/%s:%s", registry, parsed.Context().RepositoryStr(), parsed.Identifier())
+}
+
+// sameLink compares link from device config with link from manifest
+func sameLink(deviceLink, manifestLink, registry string) bool {
+	deviceLink, manifestLink = normalizeLink(deviceLink, ""), normalizeLink(manifestLink, registry)
+	if deviceLink == manifestLink {
+		return true
+	}
+	if strings.HasPrefix(deviceLink, "docker://") || strings.HasPrefix(manifestLink, "docker://") {
+		return false
+	}
+	return path.Base(deviceLink) == path.Base(manifestLink)
+}
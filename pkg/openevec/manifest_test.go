@@ -0,0 +1,225 @@
+package openevec_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/memory"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestReadManifest(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "device.yaml")
+	const manifest = `
+network-instances:
+  - name: n1
+    subnet: 10.11.12.0/24
+apps:
+  - name: nginx
+    link: docker://nginx
+    networks: [n1]
+    cpus: 2
+volumes:
+  - name: data
+    link: blank
+    disk-size: 100MB
+config-items:
+  timer.config.interval: "5"
+`
+	assert.NoError(t, os.WriteFile(file, []byte(manifest), 0644))
+	m, err := openevec.ReadManifest(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "local", m.NetworkInstances[0].Type)
+	assert.Equal(t, "eth0", m.NetworkInstances[0].Uplink)
+	assert.Equal(t, uint32(2), m.Apps[0].AppCpus)
+	assert.Equal(t, "remote", m.Apps[0].Registry)
+	assert.True(t, m.Apps[0].DirectLoad)
+	assert.Equal(t, []string{"n1"}, m.Apps[0].Networks)
+	assert.Equal(t, "100MB", m.Volumes[0].DiskSize)
+	assert.Equal(t, "5", m.ConfigItems["timer.config.interval"])
+
+	assert.NoError(t, os.WriteFile(file, []byte("apps:\n  - name: a\n    link: docker://a\n  - name: a\n    link: docker://b\n"), 0644))
+	_, err = openevec.ReadManifest(file)
+	assert.Error(t, err)
+}
+
+const applyManifestBase = `
+network-instances:
+  - name: n1
+    subnet: 10.11.12.0/24
+apps:
+  - name: nginx
+    link: docker://127.0.0.1/test/nginx:1
+    networks: [n1]
+    publish: ["8027:80"]
+    metadata: "#cloud-config"
+`
+
+func newManifestTestDevice() (controller.Cloud, *device.Ctx) {
+	ctrl := &controller.CloudCtx{Controller: memory.New()}
+	ctrl.SetVars(&utils.ConfigVars{ZArch: "amd64"})
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f"))
+	return ctrl, dev
+}
+
+func applyManifestString(t *testing.T, ctrl controller.Cloud, dev *device.Ctx, manifest string) {
+	file := filepath.Join(t.TempDir(), "device.yaml")
+	if !assert.NoError(t, os.WriteFile(file, []byte(manifest), 0644)) {
+		t.FailNow()
+	}
+	m, err := openevec.ReadManifest(file)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, openevec.ApplyManifest(ctrl, dev, m, &openevec.EdenSetupArgs{})) {
+		t.FailNow()
+	}
+}
+
+func TestApplyManifest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		manifest string
+		changed  bool
+	}{
+		{name: "unchanged", manifest: applyManifestBase, changed: false},
+		{name: "port publish", manifest: strings.Replace(applyManifestBase, "8027:80", "8028:80", 1), changed: true},
+		{name: "acl", manifest: applyManifestBase + "    acl: [\"n1:10.0.0.1\"]\n", changed: true},
+		{name: "vlan", manifest: applyManifestBase + "    vlans: [\"n1:100\"]\n", changed: true},
+		{name: "metadata", manifest: strings.Replace(applyManifestBase, "#cloud-config", "#changed", 1), changed: true},
+		{name: "network recreated", manifest: strings.Replace(applyManifestBase, "10.11.12.0/24", "10.11.13.0/24", 1), changed: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl, dev := newManifestTestDevice()
+			applyManifestString(t, ctrl, dev, applyManifestBase)
+			before := append([]string{}, dev.GetApplicationInstances()...)
+			volumes := len(dev.GetVolumes())
+
+			applyManifestString(t, ctrl, dev, tt.manifest)
+			if !assert.Len(t, dev.GetApplicationInstances(), 1) {
+				return
+			}
+			assert.Len(t, dev.GetVolumes(), volumes)
+			if tt.changed {
+				assert.NotEqual(t, before, dev.GetApplicationInstances())
+			} else {
+				assert.Equal(t, before, dev.GetApplicationInstances())
+			}
+			// app must reference network instances of device
+			app, err := ctrl.GetApplicationInstanceConfig(dev.GetApplicationInstances()[0])
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, iface := range app.Interfaces {
+				assert.Contains(t, dev.GetNetworkInstances(), iface.NetworkId)
+			}
+		})
+	}
+}
+
+func TestExportManifestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctrl, dev := newManifestTestDevice()
+	applyManifestString(t, ctrl, dev, applyManifestBase+`    acl: ["n1:10.0.0.1:drop", "n1:host-only-acl"]
+    vlans: ["n1:100"]
+    disk-size: 1GB
+    vnc-display: 1
+    vnc-password: secret
+    disks: ["docker://127.0.0.1/test/data:1"]
+    mount: ["src=docker://127.0.0.1/test/logs:1,dst=/logs"]
+`)
+	apps := append([]string{}, dev.GetApplicationInstances()...)
+	volumes := append([]string{}, dev.GetVolumes()...)
+
+	exported, err := openevec.ManifestFromConfig(ctrl, dev)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, exported.Apps, 1) {
+		return
+	}
+	app := exported.Apps[0]
+	assert.Equal(t, "127.0.0.1", app.Registry)
+	assert.Equal(t, "1000000000B", app.DiskSize)
+	assert.Equal(t, []string{"8027:80"}, app.PortPublish)
+	assert.Equal(t, []string{"n1:10.0.0.1:drop", "n1:host-only-acl"}, app.ACL)
+	assert.Equal(t, []string{"n1:100"}, app.Vlans)
+	assert.Equal(t, "#cloud-config", app.Metadata)
+	assert.Equal(t, "secret", app.VncPassword)
+	assert.Equal(t, []string{"docker://127.0.0.1/test/data:1"}, app.Disks)
+	assert.Equal(t, []string{"src=docker://127.0.0.1/test/logs:1,dst=/logs"}, app.Mount)
+
+	data, err := yaml.Marshal(exported)
+	if !assert.NoError(t, err) {
+		return
+	}
+	applyManifestString(t, ctrl, dev, string(data))
+	assert.Equal(t, apps, dev.GetApplicationInstances())
+	assert.Equal(t, volumes, dev.GetVolumes())
+}
+
+func TestApplyManifestConfigItems(t *testing.T) {
+	t.Parallel()
+
+	ctrl, dev := newManifestTestDevice()
+	dev.SetConfigItem("timer.config.interval", "10")
+	dev.SetConfigItem("debug.enable.ssh", "key")
+	dev.SetConfigItem("app.allow.vnc", "true")
+
+	applyManifestString(t, ctrl, dev, applyManifestBase+`config-items:
+  timer.config.interval: "5"
+  app.allow.vnc: "true"
+  newlog.allow.fastupload: "true"
+`)
+	assert.Equal(t, map[string]string{
+		"timer.config.interval":   "5",
+		"app.allow.vnc":           "true",
+		"newlog.allow.fastupload": "true",
+	}, dev.GetConfigItems())
+
+	applyManifestString(t, ctrl, dev, applyManifestBase)
+	assert.Len(t, dev.GetConfigItems(), 3)
+
+	applyManifestString(t, ctrl, dev, applyManifestBase+"config-items: {}\n")
+	assert.Empty(t, dev.GetConfigItems())
+}
+
+func TestManifestRedactSecrets(t *testing.T) {
+	t.Parallel()
+
+	ctrl, dev := newManifestTestDevice()
+	applyManifestString(t, ctrl, dev, applyManifestBase+"    vnc-display: 1\n    vnc-password: secret\n")
+
+	exported, err := openevec.ManifestFromConfig(ctrl, dev)
+	if !assert.NoError(t, err) || !assert.Len(t, exported.Apps, 1) {
+		return
+	}
+	assert.Equal(t, "secret", exported.Apps[0].VncPassword)
+	exported.RedactSecrets()
+	data, err := yaml.Marshal(exported)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "#cloud-config")
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/eapps"
 	"github.com/lf-edge/eden/pkg/controller/eflowlog"
 	"github.com/lf-edge/eden/pkg/controller/einfo"
@@ -16,6 +17,7 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/emetric"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/expect"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -66,12 +68,8 @@ func processVLANs(vlans []string) (map[string]int, error) {
 	return m, nil
 }
 
-func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetupArgs) error {
-	changer := &adamChanger{}
-	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
-	if err != nil {
-		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
-	}
+// podExpectationOptions converts PodConfig into options of expectation
+func podExpectationOptions(pc PodConfig, cfg *EdenSetupArgs) ([]expect.ExpectationOption, error) {
 	var opts []expect.ExpectationOption
 	opts = append(opts, expect.WithMetadata(pc.Metadata))
 	opts = append(opts, expect.WithVnc(pc.VncDisplay))
@@ -91,17 +89,17 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 	}
 	diskSizeParsed, err := humanize.ParseBytes(pc.DiskSize)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	opts = append(opts, expect.WithDiskSize(int64(diskSizeParsed)))
 	volumeSizeParsed, err := humanize.ParseBytes(pc.VolumeSize)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	opts = append(opts, expect.WithVolumeSize(int64(volumeSizeParsed)))
 	appMemoryParsed, err := humanize.ParseBytes(pc.AppMemory)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	opts = append(opts, expect.WithVolumeType(expect.VolumeTypeByName(pc.VolumeType)))
 	opts = append(opts, expect.WithResources(pc.AppCpus, uint32(appMemoryParsed/1000)))
@@ -115,7 +113,7 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 	}
 	vlansParsed, err := processVLANs(pc.Vlans)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	opts = append(opts, expect.WithVLANs(vlansParsed))
 	opts = append(opts, expect.WithSFTPLoad(pc.SftpLoad))
@@ -123,14 +121,7 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 		opts = append(opts, expect.WithHTTPDirectLoad(pc.DirectLoad))
 	}
 	opts = append(opts, expect.WithAdditionalDisks(append(pc.Disks, pc.Mount...)))
-	registryToUse := pc.Registry
-	switch pc.Registry {
-	case "local":
-		registryToUse = fmt.Sprintf("%s:%d", cfg.Registry.IP, cfg.Registry.Port)
-	case "remote":
-		registryToUse = ""
-	}
-	opts = append(opts, expect.WithRegistry(registryToUse))
+	opts = append(opts, expect.WithRegistry(resolveRegistry(pc.Registry, cfg)))
 	if pc.NoHyper {
 		opts = append(opts, expect.WithVirtualizationMode(config.VmMode_NOHYPER))
 	}
@@ -139,17 +130,43 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 	opts = append(opts, expect.WithDatastoreOverride(pc.DatastoreOverride))
 	opts = append(opts, expect.WithStartDelay(pc.StartDelay))
 	opts = append(opts, expect.WithPinCpus(pc.PinCpus))
+	return opts, nil
+}
+
+// resolveRegistry returns registry to use for "local" and "remote" notations
+func resolveRegistry(registry string, cfg *EdenSetupArgs) string {
+	switch registry {
+	case "local":
+		return fmt.Sprintf("%s:%d", cfg.Registry.IP, cfg.Registry.Port)
+	case "remote":
+		return ""
+	}
+	return registry
+}
+
+func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetupArgs) error {
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	opts, err := podExpectationOptions(pc, cfg)
+	if err != nil {
+		return err
+	}
 	expectation := expect.AppExpectationFromURL(ctrl, dev, appLink, pc.Name, opts...)
 	appInstanceConfig := expectation.Application()
 	dev.SetApplicationInstanceConfig(append(dev.GetApplicationInstances(), appInstanceConfig.Uuidandversion.Uuid))
 	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
 		return fmt.Errorf("setControllerAndDev: %w", err)
 	}
-	log.Infof("deploy pod %s with %s request sent", appInstanceConfig.Displayname, appLink)
+	if !openEVEC.cfg.DryRun {
+		log.Infof("deploy pod %s with %s request sent", appInstanceConfig.Displayname, appLink)
+	}
 	return nil
 }
 
-func (openEVEC *OpenEVEC) PodPs(outputFormat types.OutputFormat) error {
+func (openEVEC *OpenEVEC) PodPs(outputFormat types.OutputFormat, watch bool, history string) error {
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -162,14 +179,20 @@ func (openEVEC *OpenEVEC) PodPs(outputFormat types.OutputFormat) error {
 	if err := ctrl.MetricLastCallback(dev.GetID(), nil, state.MetricCallback()); err != nil {
 		return fmt.Errorf("fail in get MetricLastCallback: %w", err)
 	}
-	if err := state.PodsList(outputFormat); err != nil {
-		return err
+	printState := func() error {
+		if history != "" {
+			return state.PodHistory(history, outputFormat)
+		}
+		return state.PodsList(outputFormat)
 	}
-	return nil
+	if watch {
+		return watchEveState(ctrl, dev, state, outputFormat == types.OutputFormatLines, printState)
+	}
+	return printState()
 }
 
 func (openEVEC *OpenEVEC) PodStop(appName string) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -193,7 +216,7 @@ func (openEVEC *OpenEVEC) PodStop(appName string) error {
 }
 
 func (openEVEC *OpenEVEC) PodPurge(volumesToPurge []string, appName string, explicitVolumes bool) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -253,7 +276,7 @@ func (openEVEC *OpenEVEC) PodPurge(volumesToPurge []string, appName string, expl
 }
 
 func (openEVEC *OpenEVEC) PodRestart(appName string) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -280,7 +303,7 @@ func (openEVEC *OpenEVEC) PodRestart(appName string) error {
 }
 
 func (openEVEC *OpenEVEC) PodStart(appName string) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -304,7 +327,7 @@ func (openEVEC *OpenEVEC) PodStart(appName string) error {
 }
 
 func (openEVEC *OpenEVEC) PodDelete(appName string, deleteVolumes bool) (bool, error) {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return false, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -316,21 +339,7 @@ func (openEVEC *OpenEVEC) PodDelete(appName string, deleteVolumes bool) (bool, e
 		}
 		if app.Displayname == appName {
 			if deleteVolumes {
-				volumeIDs := dev.GetVolumes()
-				utils.DelEleInSliceByFunction(&volumeIDs, func(i interface{}) bool {
-					vol, err := ctrl.GetVolume(i.(string))
-					if err != nil {
-						log.Errorf("no volume in cloud %s: %s", i.(string), err.Error())
-						return false
-					}
-					for _, volRef := range app.VolumeRefList {
-						if vol.Uuid == volRef.Uuid {
-							return true
-						}
-					}
-					return false
-				})
-				dev.SetVolumeConfigs(volumeIDs)
+				deleteAppVolumes(ctrl, dev, app)
 			}
 			configs := dev.GetApplicationInstances()
 			utils.DelEleInSlice(&configs, id)
@@ -346,12 +355,32 @@ func (openEVEC *OpenEVEC) PodDelete(appName string, deleteVolumes bool) (bool, e
 	return false, nil
 }
 
-func (openEVEC *OpenEVEC) PodLogs(appName string, outputTail uint, outputFields []string, outputFormat types.OutputFormat) error {
+// deleteAppVolumes removes volumes referenced by app from device config
+func deleteAppVolumes(ctrl controller.Cloud, dev *device.Ctx, app *config.AppInstanceConfig) {
+	volumeIDs := dev.GetVolumes()
+	utils.DelEleInSliceByFunction(&volumeIDs, func(i interface{}) bool {
+		vol, err := ctrl.GetVolume(i.(string))
+		if err != nil {
+			log.Errorf("no volume in cloud %s: %s", i.(string), err.Error())
+			return false
+		}
+		for _, volRef := range app.VolumeRefList {
+			if vol.Uuid == volRef.Uuid {
+				return true
+			}
+		}
+		return false
+	})
+	dev.SetVolumeConfigs(volumeIDs)
+}
+
+func (openEVEC *OpenEVEC) PodLogs(appName string, outputTail uint, outputFields []string, outputFormat types.OutputFormat, timeRange types.TimeRange) error {
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
+	ctrl.SetTimeRange(timeRange)
 	for _, el := range dev.GetApplicationInstances() {
 		app, err := ctrl.GetApplicationInstanceConfig(el)
 		if err != nil {
@@ -468,7 +497,7 @@ func (openEVEC *OpenEVEC) PodLogs(appName string, outputTail uint, outputFields
 }
 
 func (openEVEC *OpenEVEC) PodModify(appName string, podNetworks, portPublish, acl, vlans []string, startDelay uint32) error {
-	changer := &adamChanger{}
+	changer := &adamChanger{dryRun: openEVEC.cfg.DryRun}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
@@ -1,16 +1,24 @@
 package openevec
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/edensdn"
 	"github.com/lf-edge/eden/pkg/utils"
 	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/netmodel"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -181,14 +189,28 @@ func (openEVEC *OpenEVEC) SdnStatus() error {
 	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
 		return fmt.Errorf("Sdn is not enabled")
 	}
-	processStatus, err := utils.StatusCommandWithPid(cfg.Sdn.PidFile)
-	if err != nil {
-		log.Errorf("%s cannot obtain status of SDN Qemu process: %s",
-			statusWarn(), err)
+	if cfg.Sdn.Runner == edensdn.RunnerContainer {
+		runner := edensdn.NewSdnContainerRunner(edensdn.SdnVMConfig{})
+		containerStatus, err := runner.Status()
+		if err != nil {
+			log.Errorf("%s cannot obtain status of SDN container: %s",
+				statusWarn(), err)
+		} else {
+			fmt.Printf("%s SDN container status: %s\n",
+				representContainerStatus(lastWord(containerStatus)), containerStatus)
+			fmt.Printf("\tFor SDN logs you can run 'docker logs %s'\n",
+				defaults.DefaultSdnContainerName)
+		}
 	} else {
-		fmt.Printf("%s SDN on Qemu status: %s\n",
-			representProcessStatus(processStatus), processStatus)
-		fmt.Printf("\tConsole logs for SDN at: %s\n", cfg.Sdn.ConsoleLogFile)
+		processStatus, err := utils.StatusCommandWithPid(cfg.Sdn.PidFile)
+		if err != nil {
+			log.Errorf("%s cannot obtain status of SDN Qemu process: %s",
+				statusWarn(), err)
+		} else {
+			fmt.Printf("%s SDN on Qemu status: %s\n",
+				representProcessStatus(processStatus), processStatus)
+			fmt.Printf("\tConsole logs for SDN at: %s\n", cfg.Sdn.ConsoleLogFile)
+		}
 	}
 	client := &edensdn.SdnClient{
 		SSHPort:    uint16(cfg.Sdn.SSHPort),
@@ -205,6 +227,15 @@ func (openEVEC *OpenEVEC) SdnStatus() error {
 		fmt.Printf("\tHave configuration errors: %v\n", status.ConfigErrors)
 	}
 	fmt.Printf("\tManagement IPs: %v\n", strings.Join(status.MgmtIPs, ", "))
+	for _, scenario := range status.Scenarios {
+		if scenario.Completed {
+			fmt.Printf("\tImpairment scenario for port %s: completed\n", scenario.Port)
+			continue
+		}
+		fmt.Printf("\tImpairment scenario for port %s: step %d/%d (iteration %d), running for %s\n",
+			scenario.Port, scenario.Step+1, scenario.StepCount, scenario.Iteration,
+			time.Since(scenario.StepStartedAt).Round(time.Second))
+	}
 	return nil
 }
 
@@ -248,6 +279,9 @@ func (openEVEC *OpenEVEC) SdnNetModelApply(ref string) error {
 		}
 	}
 	newNetModel.Host.ControllerPort = uint16(cfg.Adam.Port)
+	if _, err = netmodel.Parse(newNetModel); err != nil {
+		return fmt.Errorf("network model is invalid: %w", err)
+	}
 	client := &edensdn.SdnClient{
 		SSHPort:    uint16(cfg.Sdn.SSHPort),
 		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
@@ -257,7 +291,10 @@ func (openEVEC *OpenEVEC) SdnNetModelApply(ref string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get current network model: %w", err)
 	}
-	vmRunner, err := edensdn.GetSdnVMRunner(cfg.Eve.DevModel, edensdn.SdnVMConfig{})
+	vmRunner, err := edensdn.GetSdnVMRunner(cfg.Eve.DevModel, edensdn.SdnVMConfig{
+		Runner:         cfg.Sdn.Runner,
+		NetDevBasePort: uint16(cfg.Eve.QemuConfig.NetDevSocketPort),
+	})
 	if err != nil {
 		return fmt.Errorf("failed to get SDN VM runner: %w", err)
 	}
@@ -270,6 +307,9 @@ func (openEVEC *OpenEVEC) SdnNetModelApply(ref string) error {
 			"  eden eve stop\n" +
 			"  eden eve start --sdn-network-model " + ref + "\n")
 	}
+	if err = vmRunner.UpdatePorts(oldNetModel, newNetModel); err != nil {
+		return fmt.Errorf("failed to update SDN ports: %w", err)
+	}
 	err = client.ApplyNetworkModel(newNetModel)
 	if err != nil {
 		return fmt.Errorf("failed to apply network model: %w", err)
@@ -278,6 +318,33 @@ func (openEVEC *OpenEVEC) SdnNetModelApply(ref string) error {
 	return nil
 }
 
+// SdnNetModelValidate validates network model from the given file without submitting
+// it to Eden-SDN (which therefore does not need to be running). All errors and warnings
+// found are printed together with JSON paths of the affected model items.
+func (openEVEC *OpenEVEC) SdnNetModelValidate(ref string) error {
+	cfg := openEVEC.cfg
+	netModel, err := edensdn.LoadNetModeFromFile(ref)
+	if err != nil {
+		return fmt.Errorf("failed to load network model from file '%s': %w", ref, err)
+	}
+	if netModel.Host.ControllerPort == 0 {
+		netModel.Host.ControllerPort = uint16(cfg.Adam.Port)
+	}
+	_, report := netmodel.Validate(netModel)
+	for _, issue := range report.Errors {
+		fmt.Printf("ERROR: %s\n", issue)
+	}
+	for _, issue := range report.Warnings {
+		fmt.Printf("WARNING: %s\n", issue)
+	}
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("network model '%s' is invalid (%d errors, %d warnings)",
+			ref, len(report.Errors), len(report.Warnings))
+	}
+	fmt.Printf("Network model '%s' is valid (%d warnings)\n", ref, len(report.Warnings))
+	return nil
+}
+
 func (openEVEC *OpenEVEC) SdnNetConfigGraph() (string, error) {
 	cfg := openEVEC.cfg
 	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
@@ -364,3 +431,267 @@ func (openEVEC *OpenEVEC) SdnEpExec(epName, command string, args []string) error
 	}
 	return nil
 }
+
+func (openEVEC *OpenEVEC) sdnClient() (*edensdn.SdnClient, error) {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return nil, fmt.Errorf("SDN is not enabled")
+	}
+	return &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}, nil
+}
+
+// SdnCaptureStart starts packet capture on a port, bond, bridge, network or endpoint
+// deployed in Eden-SDN.
+func (openEVEC *OpenEVEC) SdnCaptureStart(label, filter string, maxSize uint64) error {
+	client, err := openEVEC.sdnClient()
+	if err != nil {
+		return err
+	}
+	status, err := client.StartCapture(label, sdnapi.CaptureRequest{
+		Filter:  filter,
+		MaxSize: maxSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start packet capture: %w", err)
+	}
+	fmt.Printf("Capturing packets on %s %s (interface %s in net namespace %s, size limit %d bytes)\n",
+		status.TargetType, status.Target, status.Interface, status.NetNamespace, status.MaxSize)
+	return nil
+}
+
+// SdnCaptureStop stops packet capture running for the given logical label.
+func (openEVEC *OpenEVEC) SdnCaptureStop(label string) error {
+	client, err := openEVEC.sdnClient()
+	if err != nil {
+		return err
+	}
+	status, err := client.StopCapture(label)
+	if err != nil {
+		return fmt.Errorf("failed to stop packet capture: %w", err)
+	}
+	printCaptureStatus(status)
+	return nil
+}
+
+// SdnCaptureGet downloads packets captured for the given logical label into a pcap file.
+func (openEVEC *OpenEVEC) SdnCaptureGet(label, outFile string) error {
+	client, err := openEVEC.sdnClient()
+	if err != nil {
+		return err
+	}
+	err = utils.WriteFileAtomic(outFile, func(w io.Writer) error {
+		return client.GetCapture(label, w)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get packet capture: %w", err)
+	}
+	log.Infof("Packet capture for %s saved into %s", label, outFile)
+	return nil
+}
+
+// SdnCaptureList prints status of all packet captures.
+func (openEVEC *OpenEVEC) SdnCaptureList() error {
+	client, err := openEVEC.sdnClient()
+	if err != nil {
+		return err
+	}
+	statuses, err := client.ListCaptures()
+	if err != nil {
+		return fmt.Errorf("failed to list packet captures: %w", err)
+	}
+	for _, status := range statuses {
+		printCaptureStatus(status)
+	}
+	return nil
+}
+
+func printCaptureStatus(status sdnapi.CaptureStatus) {
+	state := "stopped"
+	if status.Running {
+		state = "running"
+	}
+	fmt.Printf("%s %s: %s, %d packets, %d/%d bytes, started %s ago",
+		status.TargetType, status.Target, state, status.Packets, status.Size,
+		status.MaxSize, time.Since(status.StartedAt).Round(time.Second))
+	if status.Filter != "" {
+		fmt.Printf(", filter: %q", status.Filter)
+	}
+	if status.Error != "" {
+		fmt.Printf(", error: %s", status.Error)
+	}
+	fmt.Println()
+}
+
+// SdnCaptivePortalLogin logs EVE (or a client with the given IP address) into
+// the captive portal of a network deployed in Eden-SDN.
+// With empty clientIP, all clients of the network are logged in.
+func (openEVEC *OpenEVEC) SdnCaptivePortalLogin(network, clientIP string) error {
+	client, err := openEVEC.sdnClient()
+	if err != nil {
+		return err
+	}
+	status, err := client.CaptivePortalLogin(network, clientIP)
+	if err != nil {
+		return fmt.Errorf("failed to log into captive portal: %w", err)
+	}
+	printCaptivePortalStatus(status)
+	return nil
+}
+
+// SdnCaptivePortalLogout logs EVE (or a client with the given IP address) out of
+// the captive portal of a network deployed in Eden-SDN.
+// With empty clientIP, all clients of the network are logged out.
+func (openEVEC *OpenEVEC) SdnCaptivePortalLogout(network, clientIP string) error {
+	client, err := openEVEC.sdnClient()
+	if err != nil {
+		return err
+	}
+	status, err := client.CaptivePortalLogout(network, clientIP)
+	if err != nil {
+		return fmt.Errorf("failed to log out of captive portal: %w", err)
+	}
+	printCaptivePortalStatus(status)
+	return nil
+}
+
+// SdnCaptivePortalStatus prints clients logged into captive portals.
+func (openEVEC *OpenEVEC) SdnCaptivePortalStatus() error {
+	client, err := openEVEC.sdnClient()
+	if err != nil {
+		return err
+	}
+	statuses, err := client.ListCaptivePortals()
+	if err != nil {
+		return fmt.Errorf("failed to get captive portal status: %w", err)
+	}
+	for _, status := range statuses {
+		printCaptivePortalStatus(status)
+	}
+	return nil
+}
+
+func printCaptivePortalStatus(status sdnapi.CaptivePortalStatus) {
+	loggedIn := "none"
+	if len(status.LoggedIn) > 0 {
+		loggedIn = strings.Join(status.LoggedIn, ", ")
+	}
+	fmt.Printf("network %s (portal %s): logged in: %s", status.Network, status.Portal,
+		loggedIn)
+	if status.Error != "" {
+		fmt.Printf(", error: %s", status.Error)
+	}
+	fmt.Println()
+}
+
+// SdnConntrack prints connections tracked by netfilter inside Eden-SDN.
+// By default, connections tracked by the firewall are listed.
+func (openEVEC *OpenEVEC) SdnConntrack(filter edensdn.ConntrackFilter) error {
+	client, err := openEVEC.sdnClient()
+	if err != nil {
+		return err
+	}
+	entries, err := client.ListConntrack(filter)
+	if err != nil {
+		return fmt.Errorf("failed to list tracked connections: %w", err)
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err = fmt.Fprintln(w, "PROTO\tSOURCE\tDESTINATION\tREPLY-SOURCE\t"+
+		"REPLY-DESTINATION\tPACKETS\tBYTES\tTIMEOUT"); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d/%d\t%d/%d\t%ds\n",
+			entry.Protocol, conntrackEndpoint(entry.Forward.SrcIP, entry.Forward.SrcPort),
+			conntrackEndpoint(entry.Forward.DstIP, entry.Forward.DstPort),
+			conntrackEndpoint(entry.Reverse.SrcIP, entry.Reverse.SrcPort),
+			conntrackEndpoint(entry.Reverse.DstIP, entry.Reverse.DstPort),
+			entry.Forward.Packets, entry.Reverse.Packets,
+			entry.Forward.Bytes, entry.Reverse.Bytes, entry.Timeout)
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func conntrackEndpoint(ip string, port uint16) string {
+	if port == 0 {
+		return ip
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(int(port)))
+}
+
+// SdnEndpointLogs prints messages received by a syslog server endpoint.
+// With follow enabled, new messages are printed as they arrive until timeout
+// (zero means no timeout). If count is non-zero, the command stops after
+// printing count messages and fails if fewer messages were received.
+func (openEVEC *OpenEVEC) SdnEndpointLogs(label string, filter edensdn.SyslogFilter,
+	follow bool, count uint, timeout time.Duration, jsonOutput bool) error {
+	client, err := openEVEC.sdnClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var printed uint
+	var printErr error
+	err = client.GetEndpointLogs(ctx, label, filter, follow,
+		func(msg sdnapi.SyslogMessage) bool {
+			if jsonOutput {
+				var msgBytes []byte
+				msgBytes, printErr = json.Marshal(msg)
+				if printErr == nil {
+					fmt.Println(string(msgBytes))
+				}
+			} else {
+				fmt.Println(formatSyslogMessage(msg))
+			}
+			printed++
+			return printErr == nil && (count == 0 || printed < count)
+		})
+	if printErr != nil {
+		return fmt.Errorf("failed to print syslog message: %w", printErr)
+	}
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("failed to get logs of endpoint %s: %w", label, err)
+	}
+	if count > 0 && printed < count {
+		return fmt.Errorf("received only %d out of %d expected messages", printed, count)
+	}
+	return nil
+}
+
+// formatSyslogMessage formats syslog message similarly to traditional syslog daemons,
+// prefixed with the time of reception and the sender address.
+func formatSyslogMessage(msg sdnapi.SyslogMessage) string {
+	priority := fmt.Sprintf("%d.%d", msg.Facility, msg.Severity)
+	if int(msg.Facility) < len(sdnapi.SyslogFacilityNames) &&
+		int(msg.Severity) < len(sdnapi.SyslogSeverityNames) {
+		priority = sdnapi.SyslogFacilityNames[msg.Facility] + "." +
+			sdnapi.SyslogSeverityNames[msg.Severity]
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s/%s %s", msg.ReceivedAt.Format(time.RFC3339),
+		msg.Source, msg.Transport, priority))
+	if msg.Hostname != "" {
+		sb.WriteString(" " + msg.Hostname)
+	}
+	if msg.AppName != "" {
+		sb.WriteString(" " + msg.AppName)
+		if msg.ProcID != "" {
+			sb.WriteString("[" + msg.ProcID + "]")
+		}
+		sb.WriteString(":")
+	}
+	sb.WriteString(" " + msg.Message)
+	return sb.String()
+}
@@ -101,6 +101,8 @@ func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
 					localOpenEVEC.eveStatusVBox(vmName)
 				case localCfg.Eve.DevModel == defaults.DefaultParallelsModel:
 					localOpenEVEC.eveStatusParallels(vmName)
+				case localCfg.Eve.DevModel == defaults.DefaultSimModel:
+					localOpenEVEC.eveStatusSim(configName, cfg.Eve.Pid)
 				default:
 					localOpenEVEC.eveStatusQEMU(configName, cfg.Eve.Pid)
 				}
@@ -0,0 +1,577 @@
+package openevec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eve-api/go/info"
+	log "github.com/sirupsen/logrus"
+)
+
+// Partition states reported by EVE in ZInfoDevice.SwList.
+const (
+	partitionStateActive     = "active"
+	partitionStateInProgress = "inprogress"
+	partitionStateUnused     = "unused"
+	partitionStateFailed     = "failed"
+)
+
+// Methods to fail testing of the new image and force EVE to roll back.
+const (
+	// UpgradeFailPowerCycle stops and starts EVE VM during testing
+	UpgradeFailPowerCycle = "power-cycle"
+	// UpgradeFailReboot sends reboot command during testing
+	UpgradeFailReboot = "reboot"
+)
+
+const (
+	testingTimeConfigItem = "timer.test.baseimage.update"
+	// eveStopTimeout limits waiting for EVE VM to stop during power-cycle
+	eveStopTimeout = time.Minute
+)
+
+// UpgradePlanOptions configures EdgeNodeUpgradePlan.
+type UpgradePlanOptions struct {
+	// Image (B) to upgrade to and its version (detected from image if empty)
+	Image   string
+	Version string
+	// Registry to use for OCI images (local/remote)
+	Registry string
+	// Rollback fails testing of the image B to make EVE fall back to A.
+	// Otherwise, B is committed and BackImage is installed to return to A.
+	Rollback   bool
+	FailMethod string
+	// BackImage is the image of the running version (A) and its version
+	BackImage   string
+	BackVersion string
+	// TestingTime overrides timer.test.baseimage.update if not zero
+	TestingTime time.Duration
+	// StepTimeout limits waiting for EVE to reach the expected state in each step
+	StepTimeout time.Duration
+	// VMName and TapInterface of local EVE VM to power-cycle
+	VMName       string
+	TapInterface string
+}
+
+// UpgradePlanReport is a machine-readable result of EdgeNodeUpgradePlan.
+type UpgradePlanReport struct {
+	Image     string `json:"image"`
+	BackImage string `json:"backImage,omitempty"`
+	Rollback  bool   `json:"rollback"`
+	// FailMethod is the method used to fail testing of B to trigger rollback
+	FailMethod string            `json:"failMethod,omitempty"`
+	PartitionA string            `json:"partitionA"`
+	VersionA   string            `json:"versionA"`
+	PartitionB string            `json:"partitionB"`
+	VersionB   string            `json:"versionB"`
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt time.Time         `json:"finishedAt"`
+	Success    bool              `json:"success"`
+	Steps      []UpgradePlanStep `json:"steps"`
+	// Observations lists every change of partitions reported by EVE
+	Observations []PartitionObservation `json:"observations"`
+}
+
+// UpgradePlanStep is a single step of the upgrade plan.
+type UpgradePlanStep struct {
+	Name       string           `json:"name"`
+	Expected   string           `json:"expected"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	Passed     bool             `json:"passed"`
+	Error      string           `json:"error,omitempty"`
+	Partitions []PartitionState `json:"partitions,omitempty"`
+}
+
+// PartitionState is a state of EVE partition as reported in ZInfoDevice.SwList.
+// The first partition is the one EVE is running from.
+type PartitionState struct {
+	Label     string `json:"label"`
+	State     string `json:"state"`
+	Status    string `json:"status"`
+	Version   string `json:"version"`
+	Activated bool   `json:"activated"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PartitionObservation is a set of partition states received at the given time.
+type PartitionObservation struct {
+	Time       time.Time        `json:"time"`
+	Partitions []PartitionState `json:"partitions"`
+}
+
+func partitionsFromInfo(dinfo *info.ZInfoDevice) (partitions []PartitionState) {
+	for _, sw := range dinfo.GetSwList() {
+		partition := PartitionState{
+			Label:     sw.GetPartitionLabel(),
+			State:     sw.GetPartitionState(),
+			Status:    sw.GetStatus().String(),
+			Version:   sw.GetShortVersion(),
+			Activated: sw.GetActivated(),
+		}
+		if swErr := sw.GetSwErr(); swErr != nil {
+			partition.Error = swErr.GetDescription()
+		}
+		partitions = append(partitions, partition)
+	}
+	return partitions
+}
+
+func findPartition(partitions []PartitionState, label string) *PartitionState {
+	for i := range partitions {
+		if partitions[i].Label == label {
+			return &partitions[i]
+		}
+	}
+	return nil
+}
+
+// partitionsCheck returns nil if partitions are in the expected state.
+type partitionsCheck func(partitions []PartitionState) error
+
+// expectRunning checks that EVE runs from the given partition, which is in the given
+// state with the given version (any version if empty).
+func expectRunning(label, state, version string) partitionsCheck {
+	return func(partitions []PartitionState) error {
+		if len(partitions) == 0 {
+			return errors.New("no partitions reported")
+		}
+		current := partitions[0]
+		if label != "" && current.Label != label {
+			return fmt.Errorf("running from partition %s, expected %s", current.Label, label)
+		}
+		if current.State != state {
+			return fmt.Errorf("partition %s is %s, expected %s", current.Label, current.State, state)
+		}
+		if version != "" && current.Version != version {
+			return fmt.Errorf("partition %s has version %s, expected %s",
+				current.Label, current.Version, version)
+		}
+		return nil
+	}
+}
+
+// expectOther checks the version of the partition EVE is not running from
+// and that its state is one of the given states.
+func expectOther(label, version string, states ...string) partitionsCheck {
+	return func(partitions []PartitionState) error {
+		other := findPartition(partitions, label)
+		if other == nil {
+			return fmt.Errorf("partition %s not reported", label)
+		}
+		if other.Version != version {
+			return fmt.Errorf("partition %s has version %s, expected %s",
+				label, other.Version, version)
+		}
+		for _, state := range states {
+			if other.State == state {
+				return nil
+			}
+		}
+		return fmt.Errorf("partition %s is %s, expected one of %v", label, other.State, states)
+	}
+}
+
+func allOf(checks ...partitionsCheck) partitionsCheck {
+	return func(partitions []PartitionState) error {
+		for _, check := range checks {
+			if err := check(partitions); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// partitionTracker follows partitions reported by EVE in device info messages.
+type partitionTracker struct {
+	sync.Mutex
+	latest       []PartitionState
+	observations []PartitionObservation
+	// lastInfo is the time of receiving of the last device info
+	lastInfo time.Time
+	stopped  bool
+	updated  chan struct{}
+	done     chan error
+}
+
+func newPartitionTracker() *partitionTracker {
+	return &partitionTracker{
+		updated: make(chan struct{}, 1),
+		done:    make(chan error, 1),
+	}
+}
+
+// follow starts processing of new device info messages published by the device.
+func (tracker *partitionTracker) follow(ctrl controller.Cloud, dev *device.Ctx) {
+	go func() {
+		tracker.done <- ctrl.InfoChecker(dev.GetID(), nil, tracker.handleInfo, einfo.InfoNew, 0)
+	}()
+}
+
+func (tracker *partitionTracker) handleInfo(msg *info.ZInfoMsg) bool {
+	tracker.Lock()
+	defer tracker.Unlock()
+	if msg.GetZtype() != info.ZInfoTypes_ZiDevice {
+		return tracker.stopped
+	}
+	tracker.lastInfo = time.Now()
+	partitions := partitionsFromInfo(msg.GetDinfo())
+	if !reflect.DeepEqual(partitions, tracker.latest) {
+		tracker.latest = partitions
+		tracker.observations = append(tracker.observations, PartitionObservation{
+			Time:       msg.GetAtTimeStamp().AsTime(),
+			Partitions: partitions,
+		})
+		for _, partition := range partitions {
+			log.Infof("Partition %s: %s, status %s, version %s", partition.Label,
+				partition.State, partition.Status, partition.Version)
+		}
+	}
+	select {
+	case tracker.updated <- struct{}{}:
+	default:
+	}
+	return tracker.stopped
+}
+
+// wait waits until the latest reported partitions pass the check.
+func (tracker *partitionTracker) wait(check partitionsCheck, timeout time.Duration) ([]PartitionState, error) {
+	deadline := time.After(timeout)
+	for {
+		tracker.Lock()
+		partitions := tracker.latest
+		tracker.Unlock()
+		err := errors.New("no device info received")
+		if partitions != nil {
+			if err = check(partitions); err == nil {
+				return partitions, nil
+			}
+		}
+		select {
+		case <-tracker.updated:
+		case <-deadline:
+			return partitions, fmt.Errorf("timeout after %v: %w", timeout, err)
+		case err := <-tracker.done:
+			return partitions, fmt.Errorf("failed to follow device info: %w", err)
+		}
+	}
+}
+
+// waitInfo waits until device info is received after the given time.
+func (tracker *partitionTracker) waitInfo(since time.Time, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		tracker.Lock()
+		lastInfo := tracker.lastInfo
+		tracker.Unlock()
+		if lastInfo.After(since) {
+			return nil
+		}
+		select {
+		case <-tracker.updated:
+		case <-deadline:
+			return fmt.Errorf("no device info received within %v", timeout)
+		case err := <-tracker.done:
+			return fmt.Errorf("failed to follow device info: %w", err)
+		}
+	}
+}
+
+func (tracker *partitionTracker) stop() []PartitionObservation {
+	tracker.Lock()
+	defer tracker.Unlock()
+	tracker.stopped = true
+	return tracker.observations
+}
+
+// EdgeNodeUpgradePlan runs A->B->A upgrade cycle of EVE base OS: it updates EVE to the image B,
+// follows partition states reported by EVE and either fails testing of B to verify roll back
+// to A, or lets B to pass testing and then updates EVE back to A. Expected partition states
+// and versions are checked in every step. The returned report is valid also with error.
+func (openEVEC *OpenEVEC) EdgeNodeUpgradePlan(controllerMode string, opts UpgradePlanOptions) (*UpgradePlanReport, error) {
+	report := &UpgradePlanReport{
+		Image:     opts.Image,
+		BackImage: opts.BackImage,
+		Rollback:  opts.Rollback,
+		StartedAt: time.Now(),
+	}
+	if openEVEC.cfg.DryRun {
+		return report, errors.New("upgrade plan cannot run with --dry-run, EVE would never be updated")
+	}
+	if opts.Rollback {
+		report.FailMethod = opts.FailMethod
+	}
+	if !opts.Rollback && opts.BackImage == "" {
+		return report, errors.New("image to return to is required without rollback")
+	}
+	if opts.Rollback {
+		switch opts.FailMethod {
+		case UpgradeFailReboot:
+		case UpgradeFailPowerCycle:
+			if openEVEC.cfg.Eve.Remote {
+				return report, errors.New("cannot power-cycle remote EVE")
+			}
+		default:
+			return report, fmt.Errorf("unknown method to fail testing: %s", opts.FailMethod)
+		}
+	}
+	if opts.StepTimeout <= 0 {
+		return report, fmt.Errorf("step timeout must be positive, got %v", opts.StepTimeout)
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return report, fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+
+	var tracker *partitionTracker
+	runStep := func(name, expected string, action func() error, check partitionsCheck) error {
+		step := UpgradePlanStep{Name: name, Expected: expected, StartedAt: time.Now()}
+		log.Infof("Upgrade plan step %s: %s", name, expected)
+		var stepErr error
+		if action != nil {
+			stepErr = action()
+		}
+		if stepErr == nil && check != nil {
+			step.Partitions, stepErr = tracker.wait(check, opts.StepTimeout)
+		}
+		step.FinishedAt = time.Now()
+		step.Passed = stepErr == nil
+		if stepErr != nil {
+			step.Error = stepErr.Error()
+			stepErr = fmt.Errorf("step %s failed: %w", name, stepErr)
+		}
+		report.Steps = append(report.Steps, step)
+		return stepErr
+	}
+	defer func() {
+		if tracker != nil {
+			report.Observations = tracker.stop()
+		}
+		report.FinishedAt = time.Now()
+	}()
+
+	// Take the running partition from the last device info.
+	var baseline []PartitionState
+	err = runStep("baseline", "EVE runs from active partition", func() error {
+		var lastDInfo *info.ZInfoMsg
+		handleInfo := func(im *info.ZInfoMsg) bool {
+			if im.GetZtype() == info.ZInfoTypes_ZiDevice {
+				lastDInfo = im
+			}
+			return false
+		}
+		if err := ctrl.InfoLastCallback(dev.GetID(), map[string]string{"devId": dev.GetID().String()}, handleInfo); err != nil {
+			return fmt.Errorf("fail in get InfoLastCallback: %w", err)
+		}
+		if lastDInfo == nil {
+			return errors.New("no device info received")
+		}
+		baseline = partitionsFromInfo(lastDInfo.GetDinfo())
+		if err := expectRunning("", partitionStateActive, "")(baseline); err != nil {
+			return err
+		}
+		report.PartitionA = baseline[0].Label
+		report.VersionA = baseline[0].Version
+		return nil
+	}, nil)
+	report.Steps[len(report.Steps)-1].Partitions = baseline
+	if err != nil {
+		return report, err
+	}
+
+	if opts.TestingTime != 0 {
+		prevTestingTime, hadTestingTime := dev.GetConfigItems()[testingTimeConfigItem]
+		err = runStep("testing-time", fmt.Sprintf("testing window set to %v", opts.TestingTime),
+			func() error {
+				seconds := strconv.Itoa(int(opts.TestingTime.Seconds()))
+				return openEVEC.setConfigItem(controllerMode, testingTimeConfigItem, &seconds)
+			}, nil)
+		if err != nil {
+			return report, err
+		}
+		defer func() {
+			var prev *string
+			if hadTestingTime {
+				prev = &prevTestingTime
+			}
+			err := runStep("restore-testing-time", "testing window restored",
+				func() error {
+					return openEVEC.setConfigItem(controllerMode, testingTimeConfigItem, prev)
+				}, nil)
+			if err != nil {
+				log.Error(err)
+				report.Success = false
+			}
+		}()
+	}
+
+	tracker = newPartitionTracker()
+	tracker.follow(ctrl, dev)
+	err = runStep("update-b", fmt.Sprintf("base OS %s pushed to controller", opts.Image),
+		func() error {
+			return openEVEC.EdgeNodeEVEImageUpdate(opts.Image, opts.Version, opts.Registry,
+				controllerMode, true, true)
+		}, nil)
+	if err != nil {
+		return report, err
+	}
+
+	// EVE reboots into the other partition and starts testing the new image.
+	err = runStep("testing-b", "EVE runs B from the other partition in testing state, A stays active",
+		nil, func(partitions []PartitionState) error {
+			if len(partitions) > 0 && partitions[0].Label == report.PartitionA {
+				return fmt.Errorf("still running from partition %s", report.PartitionA)
+			}
+			return allOf(expectRunning("", partitionStateInProgress, opts.Version),
+				expectOther(report.PartitionA, report.VersionA, partitionStateActive))(partitions)
+		})
+	if err != nil {
+		return report, err
+	}
+	current := report.Steps[len(report.Steps)-1].Partitions[0]
+	report.PartitionB = current.Label
+	report.VersionB = current.Version
+
+	if opts.Rollback {
+		err = runStep("fail-testing-b", fmt.Sprintf("testing of B interrupted with %s", opts.FailMethod),
+			func() error {
+				switch opts.FailMethod {
+				case UpgradeFailReboot:
+					return openEVEC.EdgeNodeReboot(controllerMode)
+				case UpgradeFailPowerCycle:
+					startedAt, err := openEVEC.powerCycleEve(opts.VMName, opts.TapInterface)
+					if err != nil {
+						return err
+					}
+					return tracker.waitInfo(startedAt, opts.StepTimeout)
+				}
+				return fmt.Errorf("unknown method to fail testing: %s", opts.FailMethod)
+			}, nil)
+		if err != nil {
+			return report, err
+		}
+		err = runStep("rollback-a", "EVE falls back to active A, B is not active",
+			nil, allOf(expectRunning(report.PartitionA, partitionStateActive, report.VersionA),
+				expectOther(report.PartitionB, report.VersionB, partitionStateUnused, partitionStateFailed)))
+		if err != nil {
+			return report, err
+		}
+		report.Success = true
+		return report, nil
+	}
+
+	err = runStep("commit-b", "B passes testing and becomes active, A becomes unused",
+		nil, allOf(expectRunning(report.PartitionB, partitionStateActive, report.VersionB),
+			expectOther(report.PartitionA, report.VersionA, partitionStateUnused)))
+	if err != nil {
+		return report, err
+	}
+	err = runStep("update-a", fmt.Sprintf("base OS %s pushed to controller", opts.BackImage),
+		func() error {
+			return openEVEC.EdgeNodeEVEImageUpdate(opts.BackImage, opts.BackVersion, opts.Registry,
+				controllerMode, true, true)
+		}, nil)
+	if err != nil {
+		return report, err
+	}
+	err = runStep("testing-a", "EVE runs A from its original partition in testing state, B stays active",
+		nil, allOf(expectRunning(report.PartitionA, partitionStateInProgress, report.VersionA),
+			expectOther(report.PartitionB, report.VersionB, partitionStateActive)))
+	if err != nil {
+		return report, err
+	}
+	err = runStep("commit-a", "A passes testing and becomes active, B becomes unused",
+		nil, allOf(expectRunning(report.PartitionA, partitionStateActive, report.VersionA),
+			expectOther(report.PartitionB, report.VersionB, partitionStateUnused)))
+	if err != nil {
+		return report, err
+	}
+	report.Success = true
+	return report, nil
+}
+
+// setConfigItem sets config item of the edge node or removes it if value is nil.
+func (openEVEC *OpenEVEC) setConfigItem(controllerMode, key string, value *string) error {
+	changer, err := changerByControllerMode(controllerMode, openEVEC.cfg.DryRun)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	if value != nil {
+		dev.SetConfigItem(key, *value)
+	} else {
+		dev.DelConfigItem(key)
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev error: %w", err)
+	}
+	return nil
+}
+
+// powerCycleEve stops local EVE VM and starts it again connected to the running Eden-SDN.
+// It returns the time when EVE VM was started.
+func (openEVEC *OpenEVEC) powerCycleEve(vmName, tapInterface string) (time.Time, error) {
+	if vmName == "" {
+		vmName = defaults.DefaultVBoxVMName
+	}
+	if err := openEVEC.stopEveVM(vmName); err != nil {
+		return time.Time{}, fmt.Errorf("cannot stop EVE: %w", err)
+	}
+	if err := openEVEC.waitEveStopped(vmName, eveStopTimeout); err != nil {
+		return time.Time{}, err
+	}
+	time.Sleep(10 * time.Second)
+	startedAt := time.Now()
+	if err := openEVEC.startEve(vmName, tapInterface, true); err != nil {
+		return startedAt, err
+	}
+	return startedAt, nil
+}
+
+// eveVMStatus returns status of local EVE VM, which starts with "running" if it runs.
+func (openEVEC *OpenEVEC) eveVMStatus(vmName string) (string, error) {
+	cfg := openEVEC.cfg
+	switch cfg.Eve.DevModel {
+	case defaults.DefaultVBoxModel:
+		return eden.StatusEVEVBox(vmName)
+	case defaults.DefaultParallelsModel:
+		return eden.StatusEVEParallels(vmName)
+	case defaults.DefaultSimModel:
+		return eden.StatusEVESim(cfg.Eve.Pid)
+	default:
+		return eden.StatusEVEQemu(cfg.Eve.Pid)
+	}
+}
+
+// waitEveStopped waits until local EVE VM is not running.
+func (openEVEC *OpenEVEC) waitEveStopped(vmName string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := openEVEC.eveVMStatus(vmName)
+		if err != nil {
+			return fmt.Errorf("cannot obtain status of EVE: %w", err)
+		}
+		if !strings.HasPrefix(status, "running") {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("EVE is not stopped after %v: %s", timeout, status)
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,268 @@
+package openevec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func swPartition(label, state, version string) *info.ZInfoDevSW {
+	return &info.ZInfoDevSW{
+		PartitionLabel: label,
+		PartitionState: state,
+		ShortVersion:   version,
+		Status:         info.ZSwState_INSTALLED,
+	}
+}
+
+func partitions(swList ...*info.ZInfoDevSW) []openevec.PartitionState {
+	return openevec.PartitionsFromInfo(&info.ZInfoDevice{SwList: swList})
+}
+
+func deviceInfo(swList ...*info.ZInfoDevSW) *info.ZInfoMsg {
+	return &info.ZInfoMsg{
+		Ztype:       info.ZInfoTypes_ZiDevice,
+		AtTimeStamp: timestamppb.Now(),
+		InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: &info.ZInfoDevice{SwList: swList}},
+	}
+}
+
+func TestPartitionsFromInfo(t *testing.T) {
+	t.Parallel()
+
+	sw := swPartition("IMGB", "failed", "2.0")
+	sw.Activated = true
+	sw.SwErr = &info.ErrorInfo{Description: "testing failed"}
+	assert.Equal(t, []openevec.PartitionState{{
+		Label:     "IMGB",
+		State:     "failed",
+		Status:    "INSTALLED",
+		Version:   "2.0",
+		Activated: true,
+		Error:     "testing failed",
+	}}, partitions(sw))
+	assert.Empty(t, partitions())
+}
+
+func TestPartitionChecks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		check      openevec.PartitionsCheck
+		partitions []openevec.PartitionState
+		expectErr  string
+	}{
+		{
+			name:       "running as expected",
+			check:      openevec.ExpectRunning("IMGA", "active", "1.0"),
+			partitions: partitions(swPartition("IMGA", "active", "1.0"), swPartition("IMGB", "unused", "")),
+		},
+		{
+			name:       "running any version from any partition",
+			check:      openevec.ExpectRunning("", "inprogress", ""),
+			partitions: partitions(swPartition("IMGB", "inprogress", "2.0")),
+		},
+		{
+			name:      "running without partitions",
+			check:     openevec.ExpectRunning("IMGA", "active", "1.0"),
+			expectErr: "no partitions reported",
+		},
+		{
+			name:       "running from wrong partition",
+			check:      openevec.ExpectRunning("IMGB", "inprogress", "2.0"),
+			partitions: partitions(swPartition("IMGA", "active", "1.0"), swPartition("IMGB", "updating", "2.0")),
+			expectErr:  "running from partition IMGA, expected IMGB",
+		},
+		{
+			name:       "running in wrong state",
+			check:      openevec.ExpectRunning("IMGA", "active", "1.0"),
+			partitions: partitions(swPartition("IMGA", "inprogress", "1.0")),
+			expectErr:  "partition IMGA is inprogress, expected active",
+		},
+		{
+			name:       "running wrong version",
+			check:      openevec.ExpectRunning("IMGA", "active", "1.0"),
+			partitions: partitions(swPartition("IMGA", "active", "2.0")),
+			expectErr:  "partition IMGA has version 2.0, expected 1.0",
+		},
+		{
+			name:       "other in one of states",
+			check:      openevec.ExpectOther("IMGB", "2.0", "unused", "failed"),
+			partitions: partitions(swPartition("IMGA", "active", "1.0"), swPartition("IMGB", "failed", "2.0")),
+		},
+		{
+			name:       "other not reported",
+			check:      openevec.ExpectOther("IMGB", "2.0", "unused"),
+			partitions: partitions(swPartition("IMGA", "active", "1.0")),
+			expectErr:  "partition IMGB not reported",
+		},
+		{
+			name:       "other with wrong version",
+			check:      openevec.ExpectOther("IMGB", "2.0", "unused"),
+			partitions: partitions(swPartition("IMGA", "active", "1.0"), swPartition("IMGB", "unused", "1.0")),
+			expectErr:  "partition IMGB has version 1.0, expected 2.0",
+		},
+		{
+			name:       "other in unexpected state",
+			check:      openevec.ExpectOther("IMGB", "2.0", "unused", "failed"),
+			partitions: partitions(swPartition("IMGA", "active", "1.0"), swPartition("IMGB", "updating", "2.0")),
+			expectErr:  "partition IMGB is updating, expected one of [unused failed]",
+		},
+		{
+			name: "all of passes",
+			check: openevec.AllOf(
+				openevec.ExpectRunning("IMGA", "active", "1.0"),
+				openevec.ExpectOther("IMGB", "2.0", "unused")),
+			partitions: partitions(swPartition("IMGA", "active", "1.0"), swPartition("IMGB", "unused", "2.0")),
+		},
+		{
+			name: "all of fails on the first failed check",
+			check: openevec.AllOf(
+				openevec.ExpectRunning("IMGA", "active", "1.0"),
+				openevec.ExpectOther("IMGB", "2.0", "unused")),
+			partitions: partitions(swPartition("IMGA", "active", "1.0"), swPartition("IMGB", "failed", "2.0")),
+			expectErr:  "partition IMGB is failed, expected one of [unused]",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := test.check(test.partitions)
+			if test.expectErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expectErr)
+			}
+		})
+	}
+}
+
+func TestPartitionTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := openevec.NewPartitionTracker()
+	running := openevec.ExpectRunning("IMGB", "inprogress", "2.0")
+
+	_, err := tracker.Wait(running, 10*time.Millisecond)
+	assert.ErrorContains(t, err, "no device info received")
+
+	// Only device info is considered.
+	assert.False(t, tracker.HandleInfo(&info.ZInfoMsg{Ztype: info.ZInfoTypes_ZiApp}))
+	assert.Empty(t, tracker.Stop())
+
+	tracker = openevec.NewPartitionTracker()
+	assert.False(t, tracker.HandleInfo(deviceInfo(
+		swPartition("IMGA", "active", "1.0"), swPartition("IMGB", "updating", "2.0"))))
+	// Repeated info without change is not recorded.
+	assert.False(t, tracker.HandleInfo(deviceInfo(
+		swPartition("IMGA", "active", "1.0"), swPartition("IMGB", "updating", "2.0"))))
+
+	_, err = tracker.Wait(running, 10*time.Millisecond)
+	assert.ErrorContains(t, err, "timeout after 10ms: running from partition IMGA, expected IMGB")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tracker.HandleInfo(deviceInfo(
+			swPartition("IMGB", "inprogress", "2.0"), swPartition("IMGA", "active", "1.0")))
+	}()
+	current, err := tracker.Wait(running, time.Minute)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "IMGB", current[0].Label)
+	}
+
+	observations := tracker.Stop()
+	if assert.Len(t, observations, 2) {
+		assert.Equal(t, "IMGA", observations[0].Partitions[0].Label)
+		assert.Equal(t, "IMGB", observations[1].Partitions[0].Label)
+	}
+	// Stopped tracker ends processing of device info.
+	assert.True(t, tracker.HandleInfo(deviceInfo(swPartition("IMGA", "active", "1.0"))))
+}
+
+func TestPartitionTrackerWaitInfo(t *testing.T) {
+	t.Parallel()
+
+	tracker := openevec.NewPartitionTracker()
+	tracker.HandleInfo(deviceInfo(swPartition("IMGA", "active", "1.0")))
+	restarted := time.Now()
+
+	// Info received before restart does not count.
+	err := tracker.WaitInfo(restarted, 10*time.Millisecond)
+	assert.EqualError(t, err, "no device info received within 10ms")
+
+	// Info without change of partitions shows that EVE is back online.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tracker.HandleInfo(deviceInfo(swPartition("IMGA", "active", "1.0")))
+	}()
+	assert.NoError(t, tracker.WaitInfo(restarted, time.Minute))
+}
+
+func TestEdgeNodeUpgradePlanValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		remote    bool
+		dryRun    bool
+		opts      openevec.UpgradePlanOptions
+		expectErr string
+	}{
+		{
+			name: "dry run",
+			opts: openevec.UpgradePlanOptions{Rollback: true,
+				FailMethod: openevec.UpgradeFailReboot, StepTimeout: time.Minute},
+			dryRun:    true,
+			expectErr: "upgrade plan cannot run with --dry-run, EVE would never be updated",
+		},
+		{
+			name:      "no back image without rollback",
+			opts:      openevec.UpgradePlanOptions{StepTimeout: time.Minute},
+			expectErr: "image to return to is required without rollback",
+		},
+		{
+			name: "power-cycle of remote EVE",
+			opts: openevec.UpgradePlanOptions{Rollback: true,
+				FailMethod: openevec.UpgradeFailPowerCycle, StepTimeout: time.Minute},
+			remote:    true,
+			expectErr: "cannot power-cycle remote EVE",
+		},
+		{
+			name: "unknown fail method",
+			opts: openevec.UpgradePlanOptions{Rollback: true,
+				FailMethod: "unplug", StepTimeout: time.Minute},
+			expectErr: "unknown method to fail testing: unplug",
+		},
+		{
+			name: "zero step timeout",
+			opts: openevec.UpgradePlanOptions{Rollback: true,
+				FailMethod: openevec.UpgradeFailReboot},
+			expectErr: "step timeout must be positive, got 0s",
+		},
+		{
+			name:      "negative step timeout",
+			opts:      openevec.UpgradePlanOptions{BackImage: "a.qcow2", StepTimeout: -time.Second},
+			expectErr: "step timeout must be positive, got -1s",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &openevec.EdenSetupArgs{}
+			cfg.Eve.Remote = test.remote
+			cfg.DryRun = test.dryRun
+			report, err := openevec.CreateOpenEVEC(cfg).EdgeNodeUpgradePlan("", test.opts)
+			assert.EqualError(t, err, test.expectErr)
+			assert.Empty(t, report.Steps)
+		})
+	}
+}
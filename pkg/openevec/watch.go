@@ -0,0 +1,82 @@
+package openevec
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+)
+
+// stateRedrawInterval is the minimal interval between two redraws in the watch mode
+const stateRedrawInterval = time.Second
+
+// ANSI escape sequence to move cursor to the top-left corner and clear the screen
+const clearScreenSeq = "\033[H\033[2J"
+
+// watchEveState feeds new info and metric messages into the EVE state and calls
+// printState (first time immediately, then after every change, at most once
+// per stateRedrawInterval) until interrupted or an error occurs.
+// With clearScreen, the screen is cleared before each print.
+func watchEveState(ctrl controller.Cloud, dev *device.Ctx, state *eve.State,
+	clearScreen bool, printState func() error) error {
+	// State is not thread-safe, info and metric messages are processed concurrently.
+	var mu sync.Mutex
+	changed := true
+	done := make(chan error, 2)
+	infoCallback := state.InfoCallback()
+	go func() {
+		done <- ctrl.InfoChecker(dev.GetID(), nil, func(msg *info.ZInfoMsg) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			changed = true
+			return infoCallback(msg)
+		}, einfo.InfoNew, 0)
+	}()
+	metricCallback := state.MetricCallback()
+	go func() {
+		done <- ctrl.MetricChecker(dev.GetID(), nil, func(msg *metrics.ZMetricMsg) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			changed = true
+			return metricCallback(msg)
+		}, emetric.MetricNew, 0)
+	}()
+	redraw := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !changed {
+			return nil
+		}
+		changed = false
+		if clearScreen {
+			//nolint:forbidigo
+			fmt.Print(clearScreenSeq)
+		}
+		return printState()
+	}
+	if err := redraw(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(stateRedrawInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("failed to watch EVE state: %w", err)
+			}
+			return nil
+		case <-ticker.C:
+			if err := redraw(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package openevec_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/memory"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// watchController feeds infos into the handler once feed is closed
+// and returns from checkers once release is closed.
+type watchController struct {
+	controller.Controller
+	infos   []*info.ZInfoMsg
+	infoErr error
+	feed    chan struct{}
+	release chan struct{}
+}
+
+func (c *watchController) InfoChecker(_ uuid.UUID, _ map[string]string,
+	handler einfo.HandlerFunc, _ einfo.InfoCheckerMode, _ time.Duration) error {
+	if c.infoErr != nil {
+		return c.infoErr
+	}
+	<-c.feed
+	for _, msg := range c.infos {
+		handler(msg)
+	}
+	<-c.release
+	return nil
+}
+
+func (c *watchController) MetricChecker(_ uuid.UUID, _ map[string]string,
+	_ emetric.HandlerFunc, _ emetric.MetricCheckerMode, _ time.Duration) error {
+	<-c.release
+	return nil
+}
+
+func newWatchTest(c *watchController) (controller.Cloud, *device.Ctx, *eve.State) {
+	c.Controller = memory.New()
+	ctrl := &controller.CloudCtx{Controller: c}
+	ctrl.SetVars(&utils.ConfigVars{ZArch: "amd64"})
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f"))
+	return ctrl, dev, eve.Init(ctrl, dev)
+}
+
+func TestWatchEveStateRedraw(t *testing.T) {
+	t.Parallel()
+
+	c := &watchController{
+		infos: []*info.ZInfoMsg{{
+			Ztype: info.ZInfoTypes_ZiApp,
+			InfoContent: &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{
+				AppID:   "1b7ee8b4-d5c7-4f3a-a5d8-8f3b2b8f1f6e",
+				AppName: "app",
+				State:   info.ZSwState_RUNNING,
+			}},
+			AtTimeStamp: timestamppb.Now(),
+		}},
+		feed:    make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	ctrl, dev, state := newWatchTest(c)
+	var printed [][]string
+	printState := func() error {
+		var apps []string
+		for _, app := range state.Applications() {
+			apps = append(apps, app.Name+" "+app.EVEState)
+		}
+		printed = append(printed, apps)
+		switch len(printed) {
+		case 1:
+			// printed immediately, before any info
+			close(c.feed)
+		case 2:
+			close(c.release)
+		}
+		return nil
+	}
+	assert.NoError(t, openevec.WatchEveState(ctrl, dev, state, false, printState))
+	assert.Equal(t, [][]string{nil, {"app RUNNING"}}, printed)
+}
+
+func TestWatchEveStateErrors(t *testing.T) {
+	t.Parallel()
+
+	c := &watchController{infoErr: errors.New("broken loader"), release: make(chan struct{})}
+	defer close(c.release)
+	ctrl, dev, state := newWatchTest(c)
+	err := openevec.WatchEveState(ctrl, dev, state, false, func() error { return nil })
+	assert.ErrorContains(t, err, "failed to watch EVE state: broken loader")
+
+	c = &watchController{feed: make(chan struct{}), release: make(chan struct{})}
+	defer close(c.release)
+	defer close(c.feed)
+	ctrl, dev, state = newWatchTest(c)
+	err = openevec.WatchEveState(ctrl, dev, state, false, func() error {
+		return errors.New("print failed")
+	})
+	assert.EqualError(t, err, "print failed")
+}
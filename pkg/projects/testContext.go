@@ -14,6 +14,9 @@ import (
 
 	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/adam"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/query"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/edensdn"
@@ -127,6 +130,37 @@ func NewTestContext() *TestContext {
 	return tstCtx
 }
 
+//NewTestContextFromArchive creates TestContext which processes objects of device
+//captured with 'eden capture record' without connection to EVE and Adam
+func NewTestContextFromArchive(archiveFile string) (*TestContext, error) {
+	archive, err := loaders.OpenArchive(archiveFile)
+	if err != nil {
+		return nil, fmt.Errorf("OpenArchive: %w", err)
+	}
+	ctx, dev, err := controller.ArchivePrepare(archive)
+	if err != nil {
+		return nil, fmt.Errorf("ArchivePrepare: %w", err)
+	}
+	tstCtx := &TestContext{
+		cloud: ctx,
+		tests: map[*device.Ctx]*testing.T{},
+	}
+	tstCtx.procBus = initBus(tstCtx)
+	tstCtx.AddNode(dev)
+	return tstCtx, nil
+}
+
+//NewTestContextWithController creates TestContext which uses provided controller,
+//for example in-memory one to run tests without Adam
+func NewTestContextWithController(ctrl controller.Cloud) *TestContext {
+	tstCtx := &TestContext{
+		cloud: ctrl,
+		tests: map[*device.Ctx]*testing.T{},
+	}
+	tstCtx.procBus = initBus(tstCtx)
+	return tstCtx
+}
+
 //GetNodeDescriptions returns list of nodes from config
 func (tc *TestContext) GetNodeDescriptions() (nodes []*EdgeNodeDescription) {
 	if eveList := viper.GetStringMap("test.eve"); len(eveList) > 0 {
@@ -330,6 +364,46 @@ func (tc *TestContext) AddProcMetric(edgeNode *device.Ctx, processFunction ProcM
 	tc.procBus.addProc(edgeNode, processFunction)
 }
 
+//AddProcLogQuery add processFunction, that will get logs for edgeNode matched the query expression
+func (tc *TestContext) AddProcLogQuery(edgeNode *device.Ctx, q string, processFunction ProcLogFunc) error {
+	expr, err := query.Parse(q)
+	if err != nil {
+		return err
+	}
+	tc.procBus.addFilteredProc(edgeNode, processFunction, expr)
+	return nil
+}
+
+//AddProcFlowLogQuery add processFunction, that will get FlowLogs for edgeNode matched the query expression
+func (tc *TestContext) AddProcFlowLogQuery(edgeNode *device.Ctx, q string, processFunction ProcLogFlowFunc) error {
+	expr, err := query.Parse(q)
+	if err != nil {
+		return err
+	}
+	tc.procBus.addFilteredProc(edgeNode, processFunction, expr)
+	return nil
+}
+
+//AddProcInfoQuery add processFunction, that will get info for edgeNode matched the query expression
+func (tc *TestContext) AddProcInfoQuery(edgeNode *device.Ctx, q string, processFunction ProcInfoFunc) error {
+	expr, err := query.Parse(q)
+	if err != nil {
+		return err
+	}
+	tc.procBus.addFilteredProc(edgeNode, processFunction, expr)
+	return nil
+}
+
+//AddProcMetricQuery add processFunction, that will get metrics for edgeNode matched the query expression
+func (tc *TestContext) AddProcMetricQuery(edgeNode *device.Ctx, q string, processFunction ProcMetricFunc) error {
+	expr, err := emetric.MetricQuery(map[string]string{query.Key: q})
+	if err != nil {
+		return err
+	}
+	tc.procBus.addFilteredProc(edgeNode, processFunction, expr)
+	return nil
+}
+
 //AddProcTimer add processFunction, that will fire with time intervals for edgeNode
 func (tc *TestContext) AddProcTimer(edgeNode *device.Ctx, processFunction ProcTimerFunc) {
 	tc.procBus.addProc(edgeNode, processFunction)
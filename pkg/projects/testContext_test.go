@@ -0,0 +1,52 @@
+package projects_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/memory"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/projects"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcInfoQuery(t *testing.T) {
+	t.Parallel()
+
+	ctrl := memory.New()
+	tc := projects.NewTestContextWithController(&controller.CloudCtx{Controller: ctrl})
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.FromStringOrNil("a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f"))
+	tc.AddNode(dev)
+	tc.GetEdgeNode(tc.WithTest(t))
+
+	var found []info.ZInfoTypes
+	assert.NoError(t, tc.AddProcInfoQuery(dev, "ztype = ZiApp", func(im *info.ZInfoMsg) error {
+		found = append(found, im.Ztype)
+		return errors.New("app info received")
+	}))
+	assert.Error(t, tc.AddProcInfoQuery(dev, "ztype = ", func(im *info.ZInfoMsg) error {
+		return nil
+	}))
+
+	// checkers subscribe asynchronously, so publish until the proc is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+				_ = ctrl.PublishInfo(dev.GetID(), &info.ZInfoMsg{Ztype: info.ZInfoTypes_ZiDevice})
+				_ = ctrl.PublishInfo(dev.GetID(), &info.ZInfoMsg{Ztype: info.ZInfoTypes_ZiApp})
+			}
+		}
+	}()
+	tc.WaitForProc(10)
+	assert.Equal(t, []info.ZInfoTypes{info.ZInfoTypes_ZiApp}, found)
+}
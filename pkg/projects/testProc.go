@@ -13,6 +13,7 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/einfo"
 	"github.com/lf-edge/eden/pkg/controller/elog"
 	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/query"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -48,6 +49,7 @@ type absFunc struct {
 	states   bool
 	proc     interface{}
 	appUUID  uuid.UUID
+	filter   query.Expr
 }
 
 type processingBus struct {
@@ -72,6 +74,11 @@ func (lb *processingBus) clean() {
 	lb.wg = &sync.WaitGroup{}
 }
 
+// matches reports whether inp passes the optional filter of procFunc
+func (procFunc *absFunc) matches(inp interface{}) bool {
+	return procFunc.filter == nil || procFunc.filter.Match(inp)
+}
+
 func (lb *processingBus) processReturn(edgeNode *device.Ctx, procFunc *absFunc, result error) {
 	if result != nil {
 		if lb.tc.addTime != 0 {
@@ -96,22 +103,22 @@ func (lb *processingBus) process(edgeNode *device.Ctx, inp interface{}) bool {
 					switch pf := procFunc.proc.(type) {
 					case ProcInfoFunc:
 						el, match := inp.(*info.ZInfoMsg)
-						if match {
+						if match && procFunc.matches(el) {
 							lb.processReturn(edgeNode, procFunc, pf(el))
 						}
 					case ProcLogFunc:
 						el, match := inp.(*elog.FullLogEntry)
-						if match {
+						if match && procFunc.matches(el) {
 							lb.processReturn(edgeNode, procFunc, pf(el))
 						}
 					case ProcMetricFunc:
 						el, match := inp.(*metrics.ZMetricMsg)
-						if match {
+						if match && procFunc.matches(el) {
 							lb.processReturn(edgeNode, procFunc, pf(el))
 						}
 					case ProcLogFlowFunc:
 						el, match := inp.(*flowlog.FlowMessage)
-						if match {
+						if match && procFunc.matches(el) {
 							lb.processReturn(edgeNode, procFunc, pf(el))
 						}
 					}
@@ -241,10 +248,14 @@ func (lb *processingBus) initAppChecker(dev *device.Ctx, appUUID uuid.UUID) {
 }
 
 func (lb *processingBus) addProc(dev *device.Ctx, procFunc interface{}) {
+	lb.addFilteredProc(dev, procFunc, nil)
+}
+
+func (lb *processingBus) addFilteredProc(dev *device.Ctx, procFunc interface{}, filter query.Expr) {
 	if _, exists := lb.proc[dev]; !exists {
 		lb.initCheckers(dev)
 	}
-	lb.proc[dev] = append(lb.proc[dev], &absFunc{proc: procFunc, disabled: false})
+	lb.proc[dev] = append(lb.proc[dev], &absFunc{proc: procFunc, disabled: false, filter: filter})
 	lb.wg.Add(1)
 }
 
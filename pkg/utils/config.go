@@ -306,6 +306,8 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 				log.Fatal(err)
 			}
 			return string(result)
+		case "eve.instances":
+			return "{}"
 		default:
 			log.Fatalf("Not found argument %s in config", inp)
 		}
@@ -515,6 +517,8 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultSdnMgmtPort
 		case "sdn.network-model":
 			return ""
+		case "sdn.runner":
+			return defaults.DefaultSdnRunner
 
 		default:
 			log.Fatalf("Not found argument %s in config", inp)
@@ -561,6 +565,9 @@ func generateConfigFileFromViperTemplate(filePath string, templateString string)
 		return ""
 	}
 	parseMap := func(inp string) interface{} {
+		if viper.Get(inp) == nil {
+			return "{}"
+		}
 		result, err := json.Marshal(parse(inp))
 		if err != nil {
 			log.Fatalf("cannot parse %s: %s", inp, err)
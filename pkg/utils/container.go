@@ -14,6 +14,8 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/go-connections/nat"
@@ -336,6 +338,87 @@ func SaveImageToTar(image, tarFile string) error {
 	return nil
 }
 
+// CreateAndRunPrivilegedContainer runs privileged container with defined name from image
+// with port mapping and environment variables. Container is connected to the eden docker
+// network using an interface with the given MAC address.
+func CreateAndRunPrivilegedContainer(containerName, imageName string, portMap map[string]string,
+	macAddress string, envs []string) error {
+	log.Debugf("Try to start privileged container from image %s", imageName)
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("NewClientWithOpts: %w", err)
+	}
+	if err = PullImage(imageName); err != nil {
+		return err
+	}
+	portBinding := nat.PortMap{}
+	portExposed := nat.PortSet{}
+	for intport, binding := range portMap {
+		port, err := nat.NewPort("tcp", intport)
+		if err != nil {
+			return err
+		}
+		portExposed[port] = struct{}{}
+		portBinding[port] = []nat.PortBinding{
+			{
+				HostIP:   "0.0.0.0",
+				HostPort: binding,
+			},
+		}
+	}
+	if err = CreateDockerNetwork(defaults.DefaultDockerNetworkName); err != nil {
+		return fmt.Errorf("CreateDockerNetwork: %w", err)
+	}
+	endpoint := &network.EndpointSettings{}
+	if versions.GreaterThanOrEqualTo(cli.ClientVersion(), "1.44") {
+		endpoint.MacAddress = macAddress
+	}
+	resp, err := cli.ContainerCreate(ctx, &container.Config{
+		Hostname:     containerName,
+		Image:        imageName,
+		ExposedPorts: portExposed,
+		Env:          envs,
+		// Used with API older than v1.44, ignored otherwise.
+		MacAddress: macAddress,
+	}, &container.HostConfig{
+		PortBindings: portBinding,
+		Privileged:   true,
+		NetworkMode:  container.NetworkMode(defaults.DefaultDockerNetworkName),
+		// System services running inside expect /run to exist as on a regular host.
+		Tmpfs: map[string]string{"/run": ""},
+	}, &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			defaults.DefaultDockerNetworkName: endpoint,
+		},
+	}, nil, containerName)
+	if err != nil {
+		return fmt.Errorf("ContainerCreate: %w", err)
+	}
+	if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("ContainerStart: %w", err)
+	}
+	log.Infof("started privileged container: %s", resp.ID)
+	return nil
+}
+
+// GetContainerPid returns PID of the init process of a running container.
+func GetContainerPid(containerName string) (int, error) {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return 0, fmt.Errorf("NewClientWithOpts: %w", err)
+	}
+	info, err := cli.ContainerInspect(ctx, containerName)
+	if err != nil {
+		return 0, fmt.Errorf("ContainerInspect: %w", err)
+	}
+	if info.State == nil || !info.State.Running {
+		return 0, fmt.Errorf("container %s is not running", containerName)
+	}
+	return info.State.Pid, nil
+}
+
 // StopContainer stop container and remove if remove is true
 func StopContainer(containerName string, remove bool) error {
 	ctx := context.Background()
@@ -81,6 +81,32 @@ func CopyFile(src string, dst string) (err error) {
 	return
 }
 
+//WriteFileAtomic writes data produced by write into temporary file next to path
+//and renames it to path on success, so failed write does not leave empty
+//or partial file behind
+func WriteFileAtomic(path string, write func(w io.Writer) error) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if err = write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 //TouchFile create empty file
 func TouchFile(src string) (err error) {
 	if _, err := os.Stat(src); os.IsNotExist(err) {
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -16,3 +17,13 @@ func AddTimestampf(format string, args ...interface{}) string {
 	format = "time: %s out: " + format
 	return fmt.Sprintf(format, argsWithTimestamp...)
 }
+
+// PrintJSON prints obj as indented JSON
+func PrintJSON(obj interface{}) error {
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -165,6 +165,31 @@ func GenServerCertFromPrevCertAndKey(writePath string) error {
 	return certOut.Close()
 }
 
+// GenServerCertAndKeyFromPrevCert generate new signing certificate and key for the controller
+// with the same subject and addresses as the current signing certificate and saves them to given paths
+func GenServerCertAndKeyFromPrevCert(certPath, keyPath string) error {
+	edenHome, err := DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	rootCert, err := ParseCertificate(filepath.Join(edenHome, defaults.DefaultCertsDist, "root-certificate.pem"))
+	if err != nil {
+		return err
+	}
+	rootKey, err := ParsePrivateKey(filepath.Join(edenHome, defaults.DefaultCertsDist, "root-certificate-key.pem"))
+	if err != nil {
+		return err
+	}
+	oldServerCert, err := ParseCertificate(filepath.Join(edenHome, defaults.DefaultCertsDist, "signing.pem"))
+	if err != nil {
+		return err
+	}
+	serial := new(big.Int).Add(oldServerCert.SerialNumber, big.NewInt(1))
+	serverCert, serverKey := GenServerCertElliptic(rootCert, rootKey, serial, oldServerCert.IPAddresses,
+		oldServerCert.DNSNames, oldServerCert.Subject.CommonName)
+	return WriteToFiles(serverCert, serverKey, certPath, keyPath)
+}
+
 // WriteToFiles write cert and key
 func WriteToFiles(crt *x509.Certificate, key interface{}, certFile string, keyFile string) (err error) {
 	certOut, err := os.Create(certFile)
@@ -0,0 +1,21 @@
+package api
+
+// CaptivePortalLogin : request to log a client in or out of the captive portal
+// of a network.
+type CaptivePortalLogin struct {
+	// ClientIP : IP address of the client (EVE) to log in/out.
+	// Leave empty to log in the entire network subnet or to log out all clients.
+	ClientIP string `json:"clientIP,omitempty"`
+}
+
+// CaptivePortalStatus : status of a network with captive portal.
+type CaptivePortalStatus struct {
+	// Network : logical label of the network.
+	Network string `json:"network"`
+	// Portal : logical label of the CaptivePortal endpoint.
+	Portal string `json:"portal"`
+	// LoggedIn : IP addresses (or subnets) of clients logged in.
+	LoggedIn []string `json:"loggedIn"`
+	// Error : set if failed to obtain the list of logged-in clients.
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,40 @@
+package api
+
+import "time"
+
+// CaptureRequest : request to start packet capture on a port, bond, bridge, network
+// or endpoint, referenced by logical label.
+type CaptureRequest struct {
+	// Filter : BPF filter expression (as used with tcpdump) selecting packets to capture.
+	// Leave empty to capture all packets.
+	Filter string `json:"filter,omitempty"`
+	// MaxSize : maximum size of the capture in bytes. Capture stops when the limit
+	// is reached. If not defined (zero value), the default limit of 32MiB is used.
+	MaxSize uint64 `json:"maxSize,omitempty"`
+}
+
+// CaptureStatus : status of a packet capture.
+type CaptureStatus struct {
+	// Target : logical label of the captured port, bond, bridge, network or endpoint.
+	Target string `json:"target"`
+	// TargetType : type of the captured item (port, bond, bridge, network, endpoint).
+	TargetType string `json:"targetType"`
+	// Interface : name of the captured interface inside SDN.
+	Interface string `json:"interface"`
+	// NetNamespace : network namespace of the captured interface.
+	NetNamespace string `json:"netNamespace"`
+	// Filter : BPF filter used.
+	Filter string `json:"filter,omitempty"`
+	// MaxSize : size limit of the capture in bytes.
+	MaxSize uint64 `json:"maxSize"`
+	// Size : current size of the capture in bytes.
+	Size uint64 `json:"size"`
+	// Packets : number of captured packets.
+	Packets uint64 `json:"packets"`
+	// StartedAt : time when the capture was started.
+	StartedAt time.Time `json:"startedAt"`
+	// Running : true if the capture is still running.
+	Running bool `json:"running"`
+	// Error : set if capture failed or stopped unexpectedly.
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,29 @@
+package api
+
+// ConntrackEntry : connection tracked by netfilter (entry of the conntrack table).
+type ConntrackEntry struct {
+	// Family : "ipv4" or "ipv6".
+	Family string `json:"family"`
+	// Protocol : name (tcp, udp, icmp, icmpv6) or number of the L4 protocol.
+	Protocol string `json:"protocol"`
+	// Forward : original direction of the connection.
+	Forward ConntrackTuple `json:"forward"`
+	// Reverse : reply direction of the connection (differs from swapped Forward
+	// if the connection is NATed).
+	Reverse ConntrackTuple `json:"reverse"`
+	// Mark : connection mark.
+	Mark uint32 `json:"mark,omitempty"`
+	// Timeout : number of seconds until the entry expires (unless refreshed by traffic).
+	Timeout uint32 `json:"timeout"`
+}
+
+// ConntrackTuple : one direction of a tracked connection.
+type ConntrackTuple struct {
+	SrcIP   string `json:"srcIP"`
+	DstIP   string `json:"dstIP"`
+	SrcPort uint16 `json:"srcPort,omitempty"`
+	DstPort uint16 `json:"dstPort,omitempty"`
+	// Packets and Bytes are counted only with conntrack accounting enabled.
+	Packets uint64 `json:"packets,omitempty"`
+	Bytes   uint64 `json:"bytes,omitempty"`
+}
@@ -27,6 +27,16 @@ type Endpoints struct {
 	// NetbootServers : HTTP/TFTP servers providing artifacts needed to boot EVE OS
 	// over a network (using netboot/PXE + iPXE).
 	NetbootServers []NetbootServer `json:"netbootServers,omitempty"`
+	// WireGuardServers : VPN gateways running WireGuard server.
+	// Can be used to test EVE apps or edge-VPN network instances connecting
+	// to a remote site over VPN.
+	WireGuardServers []WireGuardServer `json:"wireguardServers,omitempty"`
+	// CaptivePortals : portals intercepting traffic of clients that have not logged in
+	// yet (see Network.CaptivePortal). Can be used to emulate hotel/airport networks.
+	CaptivePortals []CaptivePortal `json:"captivePortals,omitempty"`
+	// SyslogServers : remote logging receivers (syslog over UDP, TCP and/or TLS).
+	// Received messages can be retrieved with "eden sdn endpoint logs".
+	SyslogServers []SyslogServer `json:"syslogServers,omitempty"`
 }
 
 // GetAll : returns all endpoints as one list.
@@ -54,6 +64,15 @@ func (eps Endpoints) GetAll() (all []Endpoint) {
 	for _, netBootSrv := range eps.NetbootServers {
 		all = append(all, netBootSrv.Endpoint)
 	}
+	for _, wgSrv := range eps.WireGuardServers {
+		all = append(all, wgSrv.Endpoint)
+	}
+	for _, portal := range eps.CaptivePortals {
+		all = append(all, portal.Endpoint)
+	}
+	for _, syslogSrv := range eps.SyslogServers {
+		all = append(all, syslogSrv.Endpoint)
+	}
 	return all
 }
 
@@ -136,6 +155,12 @@ type DNSServer struct {
 	// UpstreamServers : list of IP addresses of public DNS servers to forward
 	// requests to (unless there is a static entry).
 	UpstreamServers []string `json:"upstreamServers"`
+	// FaultRules : rules injecting faults into DNS responses.
+	// Used to test how EVE handles a misbehaving resolver.
+	// Rules are evaluated in the given order and the first matching rule is applied.
+	// Queries not matched by any rule are answered normally (using static entries
+	// and upstream servers).
+	FaultRules []DNSFaultRule `json:"faultRules,omitempty"`
 }
 
 // ItemCategory
@@ -166,6 +191,29 @@ func (e DNSServer) ReferencesFromItem() []LogicalLabelRef {
 			})
 		}
 	}
+	for i, rule := range e.FaultRules {
+		if strings.HasPrefix(rule.Name, EndpointFQDNRefPrefix) {
+			refKey := fmt.Sprintf("dns-server-%s-fault-rule-%d-name", e.LogicalLabel, i)
+			logicalLabel := strings.TrimPrefix(rule.Name, EndpointFQDNRefPrefix)
+			refs = append(refs, LogicalLabelRef{
+				ItemType:         Endpoint{}.ItemType(),
+				ItemLogicalLabel: logicalLabel,
+				RefKey:           refKey,
+			})
+		}
+		for j, ip := range rule.RotateIPs {
+			if strings.HasPrefix(ip, EndpointIPRefPrefix) {
+				refKey := fmt.Sprintf("dns-server-%s-fault-rule-%d-ip-%d",
+					e.LogicalLabel, i, j)
+				logicalLabel := strings.TrimPrefix(ip, EndpointIPRefPrefix)
+				refs = append(refs, LogicalLabelRef{
+					ItemType:         Endpoint{}.ItemType(),
+					ItemLogicalLabel: logicalLabel,
+					RefKey:           refKey,
+				})
+			}
+		}
+	}
 	return refs
 }
 
@@ -196,6 +244,52 @@ type DNSEntry struct {
 	IP string `json:"ip"`
 }
 
+// DNSFaultAction : fault injected by DNS server into the response.
+type DNSFaultAction string
+
+const (
+	// DNSFaultNXDomain : respond with NXDOMAIN (name does not exist).
+	DNSFaultNXDomain DNSFaultAction = "nxdomain"
+	// DNSFaultServFail : respond with SERVFAIL (server failure).
+	DNSFaultServFail DNSFaultAction = "servfail"
+	// DNSFaultDrop : do not respond at all.
+	DNSFaultDrop DNSFaultAction = "drop"
+	// DNSFaultDelay : respond normally, but only after DNSFaultRule.Delay.
+	DNSFaultDelay DNSFaultAction = "delay"
+	// DNSFaultTruncate : respond to queries received over UDP with an empty answer
+	// and the TC (truncated) flag set, forcing the client to retry over TCP.
+	// Queries received over TCP are answered normally.
+	DNSFaultTruncate DNSFaultAction = "truncate"
+	// DNSFaultRotateIPs : answer A/AAAA queries with one IP address from
+	// DNSFaultRule.RotateIPs, moving to the next address with every query.
+	// Queries of other types are answered normally.
+	DNSFaultRotateIPs DNSFaultAction = "rotate-ips"
+)
+
+// DNSFaultRule : rule injecting a fault into DNS responses for matching queries.
+type DNSFaultRule struct {
+	// Name : domain name to match. Use "*." prefix to match any subdomain
+	// (e.g. "*.example.com") or just "*" to match every name.
+	// Can be a reference to endpoint FQDN:
+	//  - "endpoint-fqdn.<endpoint-logical-label>" - translated to endpoint's FQDN by Eden-SDN
+	Name string `json:"name"`
+	// QueryTypes : optional list of query types to match (e.g. "A", "AAAA", "SRV").
+	// Empty list matches queries of any type.
+	QueryTypes []string `json:"queryTypes,omitempty"`
+	// Action : fault to inject.
+	Action DNSFaultAction `json:"action"`
+	// Delay : time in milliseconds to wait before responding.
+	// Required for DNSFaultDelay, optional for other actions (except for DNSFaultDrop),
+	// where it can be used to delay e.g. NXDOMAIN response.
+	Delay uint32 `json:"delay,omitempty"`
+	// RotateIPs : IP addresses to rotate between with DNSFaultRotateIPs.
+	// Same special values as in DNSEntry.IP can be used.
+	RotateIPs []string `json:"rotateIPs,omitempty"`
+	// TTL : time-to-live in seconds of answers generated for DNSFaultRotateIPs.
+	// Zero TTL is used if not defined, preventing clients from caching the answers.
+	TTL uint32 `json:"ttl,omitempty"`
+}
+
 // HTTPServer : HTTP(s) server.
 type HTTPServer struct {
 	// Endpoint configuration.
@@ -416,6 +510,52 @@ func (e NetbootServer) ItemCategory() string {
 	return "netboot-server"
 }
 
+// WireGuardServer : VPN gateway endpoint running WireGuard server.
+// The server listens on the endpoint IP and terminates tunnels from the configured peers.
+// Subnets behind the VPN gateway are emulated by the server itself - it assigns
+// the first host IP of every private subnet to a dummy interface, which can be then
+// accessed (e.g. pinged) by peers through the tunnel.
+type WireGuardServer struct {
+	// Endpoint configuration.
+	Endpoint
+	// PrivateKey : base64-encoded private key of the server (as generated by "wg genkey").
+	// Peers should be configured with the corresponding public key ("wg pubkey").
+	PrivateKey string `json:"privateKey"`
+	// ListenPort : UDP port on which the server listens for tunnel traffic.
+	// If not defined (zero value), the default WireGuard port 51820 is used.
+	ListenPort uint16 `json:"listenPort"`
+	// TunnelIP : IP address of the server inside the tunnel, with the tunnel subnet
+	// (CIDR notation), e.g. "10.200.0.1/24".
+	TunnelIP string `json:"tunnelIP"`
+	// Peers : clients (EVE apps, edge-VPN network instances, etc.) allowed to connect.
+	Peers []WireGuardPeer `json:"peers"`
+	// PrivateSubnets : subnets behind the VPN gateway, i.e. routes that peers should
+	// direct into the tunnel (include them in AllowedIPs configured for the server).
+	PrivateSubnets []string `json:"privateSubnets,omitempty"`
+}
+
+// ItemCategory
+func (e WireGuardServer) ItemCategory() string {
+	return "wireguard-server"
+}
+
+// WireGuardPeer : peer of a WireGuard server.
+type WireGuardPeer struct {
+	// PublicKey : base64-encoded public key of the peer.
+	PublicKey string `json:"publicKey"`
+	// PresharedKey : optional base64-encoded pre-shared key (as generated by "wg genpsk").
+	PresharedKey string `json:"presharedKey,omitempty"`
+	// AllowedIPs : IP subnets (CIDR notation) of the peer, i.e. source IPs accepted from
+	// the peer and destinations routed to the peer. Typically just the peer's tunnel IP
+	// (e.g. "10.200.0.2/32"), but can include also subnets behind the peer.
+	// Default route (0.0.0.0/0 or ::/0) is not allowed, because it would be routed
+	// into the tunnel instead of the uplink of the endpoint.
+	AllowedIPs []string `json:"allowedIPs"`
+	// PersistentKeepalive : interval in seconds for sending keepalive packets to the peer.
+	// Zero value disables keepalives.
+	PersistentKeepalive uint16 `json:"persistentKeepalive,omitempty"`
+}
+
 // NetbootArtifact - one of the artifacts used to boot EVE OS over a network.
 type NetbootArtifact struct {
 	// Filename : name of the file.
@@ -527,3 +667,62 @@ func (s *ProxyListenProto) UnmarshalJSON(b []byte) error {
 	*s = ProxyListenProtoToID[j]
 	return nil
 }
+
+// CaptivePortal emulates a captive portal of a hotel/airport network.
+// Networks referencing the portal (see Network.CaptivePortal) redirect HTTP(S)
+// traffic of clients that have not logged in yet to the portal login page,
+// DNS queries are answered by the portal with its own IP address and all other
+// traffic is rejected.
+// Client logs in by submitting the login form (POST request to "/login" served
+// by the portal, e.g. "curl -X POST http://<any-host>/login"), or it can be logged
+// in from outside using "eden sdn captive-portal login".
+type CaptivePortal struct {
+	// Endpoint configuration.
+	Endpoint
+	// CertPEM : Portal certificate in the PEM format, used for HTTPS.
+	// If not defined, a self-signed certificate is generated.
+	CertPEM string `json:"certPEM,omitempty"`
+	// KeyPEM : Portal key in the PEM format, used for HTTPS.
+	KeyPEM string `json:"keyPEM,omitempty"`
+}
+
+// ItemCategory categorizes the item within Endpoints.
+func (e CaptivePortal) ItemCategory() string {
+	return "captive-portal"
+}
+
+// SyslogServer receives syslog messages (RFC 5424, older RFC 3164 format is also
+// accepted) over UDP, TCP and/or TLS. Over TCP and TLS, both octet-counting
+// and newline-delimited framing is supported (RFC 6587).
+// Received messages are stored inside the SDN VM and can be retrieved
+// (or followed) using "eden sdn endpoint logs <label>".
+type SyslogServer struct {
+	// Endpoint configuration.
+	Endpoint
+	// UDPPort : UDP port to listen on for syslog messages (typically 514).
+	// Zero value disables syslog over UDP.
+	UDPPort uint16 `json:"udpPort,omitempty"`
+	// TCPPort : TCP port to listen on for syslog messages (typically 514 or 601).
+	// Zero value disables syslog over TCP.
+	TCPPort uint16 `json:"tcpPort,omitempty"`
+	// TLSPort : TCP port to listen on for syslog messages over TLS (typically 6514).
+	// Zero value disables syslog over TLS.
+	TLSPort uint16 `json:"tlsPort,omitempty"`
+	// CertPEM : Server certificate in the PEM format, used for TLS.
+	// If not defined, a self-signed certificate is generated.
+	CertPEM string `json:"certPEM,omitempty"`
+	// KeyPEM : Server key in the PEM format, used for TLS.
+	KeyPEM string `json:"keyPEM,omitempty"`
+	// MaxMessages : maximum number of messages to keep stored (the oldest messages
+	// are discarded first). Zero value means that the default limit (10000) is used.
+	MaxMessages uint32 `json:"maxMessages,omitempty"`
+}
+
+// ItemCategory categorizes the item within Endpoints.
+func (e SyslogServer) ItemCategory() string {
+	return "syslog-server"
+}
+
+// DefaultSyslogMaxMessages : maximum number of stored syslog messages used
+// when SyslogServer.MaxMessages is not set.
+const DefaultSyslogMaxMessages = 10000
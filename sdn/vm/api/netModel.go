@@ -96,6 +96,36 @@ type Port struct {
 	EVEConnect EVEConnect `json:"eveConnect"`
 	// TC : traffic control.
 	TC TrafficControl `json:"trafficControl"`
+	// Scenario : optional time-based sequence of network impairments executed
+	// autonomously by Eden-SDN. While the scenario is running, the current step
+	// overrides TC of the port and can put the port DOWN, but never brings UP
+	// a port with AdminUP disabled.
+	Scenario *ImpairmentScenario `json:"scenario,omitempty"`
+}
+
+// ImpairmentScenario : time-based sequence of traffic control settings and link
+// state changes applied to a port.
+// Can be used to emulate flapping uplinks and other unstable connectivity
+// deterministically.
+// The scenario starts whenever the network model is (re)applied.
+type ImpairmentScenario struct {
+	// Steps : scenario steps, executed one after another.
+	Steps []ImpairmentStep `json:"steps"`
+	// Repeat : start the scenario again from the first step after the last step
+	// has completed. Otherwise, the port reverts to the static TC and AdminUP
+	// once the scenario ends.
+	Repeat bool `json:"repeat"`
+}
+
+// ImpairmentStep : single step of an impairment scenario.
+type ImpairmentStep struct {
+	// Duration of the step in seconds.
+	Duration uint32 `json:"duration"`
+	// LinkDown : put the port administratively DOWN for the duration of the step.
+	LinkDown bool `json:"linkDown"`
+	// TC : traffic control applied for the duration of the step.
+	// Leave empty to let traffic through unimpaired.
+	TC TrafficControl `json:"trafficControl"`
 }
 
 // TrafficControl allows to control traffic going through a port.
@@ -147,12 +177,10 @@ func (p Port) ReferencesFromItem() []LogicalLabelRef {
 // EVEConnect : connects Port to a given EVE instance.
 type EVEConnect struct {
 	// EVEInstance : name of the EVE instance to which a given port is connected.
-	// In the future, Eden may support running multiple EVE instances connected
-	// to the same SDN and controller. It is likely that each such instance
-	// will be assigned a unique logical label, which this field will reference.
-	// However, currently Eden is only able to manage a single EVE instance.
-	// For the time being it is therefore expected that this field is empty
-	// and EVEConnect refers to the one and only EVE instance.
+	// Eden is able to run multiple EVE instances connected to the same SDN
+	// and controller. Additional instances are declared in the Eden config
+	// under "eve.instances" and selected using the --eve-instance argument.
+	// Empty value refers to the default EVE instance.
 	EVEInstance string `json:"eveInstance"`
 	// MAC address assigned to the interface on the EVE side.
 	// If not specified by the user, Eden will generate a random MAC address.
@@ -233,6 +261,20 @@ type Network struct {
 	//        OR
 	//        -> Outside-of-SDN-VM
 	TransparentProxy string `json:"transparentProxy,omitempty"`
+	// CaptivePortal : Logical label of a CaptivePortal endpoint.
+	// Until a client (EVE) logs in, its HTTP(S) traffic is redirected to the portal,
+	// DNS queries are hijacked and everything else is rejected.
+	// Captive portal is only supported for IPv4 networks.
+	CaptivePortal string `json:"captivePortal,omitempty"`
+	// IPv6 : provisioning of hosts (EVE) connected to an IPv6 network.
+	// Applies only to networks with IPv6 subnet and enabled DHCP.
+	// If not defined, Router Advertisement with stateless DHCPv6 is used
+	// (both provided by dnsmasq).
+	IPv6 *IPv6Provisioning `json:"ipv6,omitempty"`
+	// NAT64 : translate traffic from an IPv6 network to IPv4 destinations
+	// (IPv4 endpoints, adam, the Internet), optionally with DNS64.
+	// Allows to run IPv6-only EVE on IPv4-only hosts.
+	NAT64 *NAT64 `json:"nat64,omitempty"`
 	// Router configuration. Every network has a separate routing context.
 	// Undefined (nil) means that everything should be routed and accessible.
 	// That includes all networks, endpoints and the outside of Eden SDN.
@@ -313,6 +355,13 @@ func (n Network) ReferencesFromItem() []LogicalLabelRef {
 				RefKey:           "reachable-by-network-" + n.LogicalLabel,
 			})
 		}
+		if n.Router.NAT != nil && n.Router.NAT.Via != "" {
+			refs = append(refs, LogicalLabelRef{
+				ItemType:         Network{}.ItemType(),
+				ItemLogicalLabel: n.Router.NAT.Via,
+				RefKey:           "nat-via-network-" + n.LogicalLabel,
+			})
+		}
 	}
 	// Reference to a TransparentProxy.
 	if n.TransparentProxy != "" {
@@ -323,18 +372,88 @@ func (n Network) ReferencesFromItem() []LogicalLabelRef {
 			RefKey:           "network-tproxy-" + n.LogicalLabel,
 		})
 	}
+	// Reference to a CaptivePortal.
+	if n.CaptivePortal != "" {
+		refs = append(refs, LogicalLabelRef{
+			ItemType:         Endpoint{}.ItemType(),
+			ItemCategory:     CaptivePortal{}.ItemCategory(),
+			ItemLogicalLabel: n.CaptivePortal,
+			RefKey:           "network-captive-portal-" + n.LogicalLabel,
+		})
+	}
 	return refs
 }
 
+// IPv6Mode : how hosts obtain IPv6 addresses.
+type IPv6Mode string
+
+const (
+	// IPv6ModeSLAAC : Router Advertisement only, hosts use SLAAC to generate addresses.
+	// DNS configuration can be announced only using RDNSS and DNSSL options of RA.
+	IPv6ModeSLAAC IPv6Mode = "slaac"
+	// IPv6ModeSLAACWithDHCPv6 : hosts use SLAAC to generate addresses and stateless
+	// DHCPv6 (RA with the "other configuration" flag) to obtain DNS servers,
+	// domain name and NTP server.
+	IPv6ModeSLAACWithDHCPv6 IPv6Mode = "slaac-dhcpv6"
+	// IPv6ModeDHCPv6 : stateful DHCPv6 (RA with the "managed" flag), addresses
+	// are allocated from DHCP.IPRange. SLAAC is disabled for the network prefix.
+	IPv6ModeDHCPv6 IPv6Mode = "dhcpv6"
+)
+
+// IPv6Provisioning : provisioning of hosts connected to an IPv6 network.
+// Router Advertisements are sent by radvd, DHCPv6 is provided by Kea.
+type IPv6Provisioning struct {
+	// Mode : how hosts obtain IPv6 addresses.
+	Mode IPv6Mode `json:"mode"`
+	// RDNSS : announce DNS servers (DHCP.PublicDNS and DHCP.PrivateDNS) using
+	// the RDNSS option of Router Advertisement (RFC 8106).
+	RDNSS bool `json:"rdnss"`
+	// DNSSL : announce DHCP.DomainName using the DNSSL option of Router
+	// Advertisement (RFC 8106).
+	DNSSL bool `json:"dnssl"`
+	// RAInterval : maximum time in seconds between unsolicited Router Advertisements.
+	// If not defined (zero value), the default interval of 600 seconds is used.
+	RAInterval uint16 `json:"raInterval,omitempty"`
+	// PrefixDelegation : delegate IPv6 prefixes to hosts using DHCPv6-PD.
+	// Delegated prefixes are routed towards the requesting host.
+	PrefixDelegation *IPv6PrefixDelegation `json:"prefixDelegation,omitempty"`
+}
+
+// IPv6PrefixDelegation : pool of prefixes delegated to hosts using DHCPv6-PD.
+type IPv6PrefixDelegation struct {
+	// Prefix : prefix from which prefixes are delegated (e.g. "2001:db8:100::/48").
+	// It should not overlap with the network subnet.
+	Prefix string `json:"prefix"`
+	// DelegatedLen : length of delegated prefixes (e.g. 56 or 64).
+	DelegatedLen uint8 `json:"delegatedLen"`
+}
+
+// NAT64 : translation of traffic from an IPv6 network to IPv4 destinations.
+type NAT64 struct {
+	// Prefix : /96 prefix used to represent IPv4 addresses inside IPv6.
+	// If not defined, the well-known prefix 64:ff9b::/96 is used.
+	// All networks with NAT64 have to use the same prefix.
+	Prefix string `json:"prefix,omitempty"`
+	// DNS64 : run DNS64 proxy on the network gateway IP, synthesizing AAAA records
+	// for IPv4-only domain names. When enabled, the proxy is announced to hosts
+	// as the only DNS server and DNS servers from the DHCP config are used
+	// as upstream servers.
+	DNS64 bool `json:"dns64"`
+}
+
+// DefaultNAT64Prefix : well-known NAT64 prefix (RFC 6052).
+const DefaultNAT64Prefix = "64:ff9b::/96"
+
 // DHCP configuration.
 // Note that for IPv6 we prefer to use Router Advertisement over DHCPv6 to publish
 // all this information to hosts on the network.
 // But DHCPv6 is still needed and used to convey NTP and netboot configuration (if provided).
+// See Network.IPv6 for explicit IPv6 provisioning modes.
 type DHCP struct {
 	// Enable DHCP. Set to false to use EVE with static IP addressing.
 	Enable bool `json:"enable"`
 	// IPRange : a range of IP addresses to allocate from.
-	// Not applicable for IPv6.
+	// For IPv6, applicable only with stateful DHCPv6 (see IPv6ModeDHCPv6).
 	IPRange IPRange `json:"ipRange"`
 	// StaticEntries : list of MAC->IP entries statically configured for the DHCP server.
 	StaticEntries []MACToIP `json:"staticEntries"`
@@ -402,6 +521,80 @@ type Router struct {
 	// for other apps. Static routes are then needed on the Eden-SDN side to route
 	// returning traffic back via that gw app.
 	RoutesTowardsEVE []IPRoute `json:"routesTowardsEVE"`
+	// NAT : network address translation applied to traffic leaving the network
+	// (optional). Without NAT, EVE IP addresses are visible to endpoints and other
+	// networks, and only traffic leaving SDN VM is S-NATed (masqueraded) by the host.
+	NAT *NAT `json:"nat,omitempty"`
+}
+
+// NAT : network address translation of traffic going from the network towards
+// endpoints, other networks and outside of SDN VM.
+// Used to emulate home routers, carrier-grade NAT (CGNAT) and their combinations
+// (double NAT).
+// Connections initiated from outside towards EVE are blocked (unless allowed
+// by PortForwarding or with full-cone NAT), except for connections opened by Eden
+// itself (e.g. "eden sdn fwd").
+// Note that firewall rules matching source IP addresses are applied to traffic
+// already NATed.
+// Currently supported only for IPv4 networks.
+type NAT struct {
+	// Type : NAT type (behaviour), see NATType. Defaults to port-restricted-cone.
+	Type NATType `json:"type"`
+	// OutsideSubnet : addresses into which the network is NATed (in the CIDR format).
+	// Typically a subnet from the CGNAT address space 100.64.0.0/10 (RFC 6598).
+	// It should not overlap with subnets of networks and endpoints.
+	// For full-cone NAT, OutsideSubnet must have the same size as the network subnet
+	// (every EVE IP address is statically mapped to one outside address).
+	// Otherwise, traffic of every EVE IP address is NATed into one of the addresses
+	// from the subnet (use /32 to NAT everything into a single address).
+	OutsideSubnet string `json:"outsideSubnet"`
+	// RandomizePorts : do not try to preserve source ports, instead allocate
+	// random ports for NATed connections.
+	// Applicable only to port-restricted-cone NAT (symmetric NAT always randomizes
+	// ports, full-cone NAT always preserves ports).
+	RandomizePorts bool `json:"randomizePorts,omitempty"`
+	// PortForwarding : ports of the outside address forwarded to EVE
+	// (e.g. for app port-maps).
+	// Not applicable to full-cone NAT, where all ports are forwarded.
+	PortForwarding []NATPortForward `json:"portForwarding,omitempty"`
+	// Via : logical label of another network to chain the NAT through (optional).
+	// Traffic NATed into OutsideSubnet is not routed directly, but forwarded into
+	// the intermediate network, where it is NATed again and routed according to the
+	// Router config of the intermediate network.
+	// This can be used to emulate double NAT, e.g. home router behind CGNAT.
+	// The intermediate network must have NAT enabled as well (other than full-cone).
+	Via string `json:"via,omitempty"`
+}
+
+// NATType : type of NAT as described by RFC 4787.
+type NATType string
+
+const (
+	// NATPortRestrictedCone : endpoint-independent mapping (source port is preserved
+	// unless RandomizePorts is enabled or the port is already in use),
+	// address and port-dependent filtering.
+	// This is the default behaviour of Linux-based routers.
+	NATPortRestrictedCone NATType = "port-restricted-cone"
+	// NATSymmetric : address and port-dependent mapping (every connection
+	// is NATed to a different random port), address and port-dependent filtering.
+	NATSymmetric NATType = "symmetric"
+	// NATFullCone : endpoint-independent mapping (static 1:1 mapping with ports
+	// preserved), endpoint-independent filtering (traffic from anywhere sent to the
+	// outside address is forwarded to EVE).
+	NATFullCone NATType = "full-cone"
+)
+
+// NATPortForward : port forwarding rule of NAT.
+type NATPortForward struct {
+	// Protocol : "tcp" or "udp".
+	Protocol string `json:"protocol"`
+	// OutsidePort : port of the (first) outside address.
+	OutsidePort uint16 `json:"outsidePort"`
+	// InsideIP : EVE IP address to forward traffic to.
+	InsideIP string `json:"insideIP"`
+	// InsidePort : port to forward traffic to.
+	// If not defined, OutsidePort is used.
+	InsidePort uint16 `json:"insidePort,omitempty"`
 }
 
 // IPRoute : a single IP route entry.
@@ -426,7 +619,31 @@ type Firewall struct {
 	//   - endpoint -> outside of SDN VM (controller, Internet)
 	// Note that once a connection is allowed, established and related traffic
 	// (going in the opposite direction) is automatically allowed as well.
+	// The only exception are rules with ConnState, which are evaluated before
+	// established and related traffic is allowed (in the order as configured).
 	Rules []FwRule `json:"rules"`
+	// ConnTrack : connection tracking settings (optional).
+	// Can be used to emulate firewalls that kill idle connections.
+	ConnTrack *FwConnTrack `json:"connTrack,omitempty"`
+}
+
+// FwConnTrack : connection tracking settings of the firewall.
+type FwConnTrack struct {
+	// TCPEstablishedTimeout : number of seconds after which an idle established
+	// TCP connection is removed from the connection tracking table.
+	// Zero value means that the kernel default (5 days) is used.
+	TCPEstablishedTimeout uint32 `json:"tcpEstablishedTimeout,omitempty"`
+	// UDPStreamTimeout : number of seconds after which an idle UDP stream
+	// (UDP flow with traffic seen in both directions) is removed from the connection
+	// tracking table.
+	// Zero value means that the kernel default is used.
+	UDPStreamTimeout uint32 `json:"udpStreamTimeout,omitempty"`
+	// StrictTCP : do not pick up already established TCP connections.
+	// With StrictTCP enabled, packets of a TCP connection removed from the connection
+	// tracking table (e.g. after TCPEstablishedTimeout) are matched as "invalid"
+	// (see FwRule.ConnState), otherwise the connection is tracked again as if it was
+	// a new one.
+	StrictTCP bool `json:"strictTCP,omitempty"`
 }
 
 // FwRule : a firewall rule.
@@ -443,10 +660,82 @@ type FwRule struct {
 	// For a non empty list, Protocol must be either TCP or UDP.
 	// Empty = any.
 	Ports []uint16 `json:"ports"`
+	// ConnState : match traffic by the state of the connection (optional).
+	// Rules are evaluated in the configured order. Established and related traffic
+	// is implicitly allowed right before the first rule without ConnState, therefore
+	// rules with non-empty ConnState listed before it can be used to block or limit
+	// already established connections.
+	// Rules without ConnState apply only to traffic starting new connections.
+	ConnState []FwConnState `json:"connState,omitempty"`
+	// RateLimit : match only traffic exceeding the given rate (optional).
+	// For example, use with FwDrop to limit the rate of traffic matched by the rule.
+	RateLimit *FwRateLimit `json:"rateLimit,omitempty"`
+	// ConnLimit : match only connections exceeding the given limit (optional).
+	// For example, use with FwReject to limit the number of parallel connections.
+	ConnLimit *FwConnLimit `json:"connLimit,omitempty"`
+	// Schedule : apply the rule only during the given time period (optional).
+	Schedule *FwSchedule `json:"schedule,omitempty"`
 	// Action to take.
 	Action FwAction `json:"action"`
 }
 
+// FwConnState : state of a connection tracked by the firewall.
+type FwConnState string
+
+const (
+	// FwConnNew : packet starts a new connection.
+	FwConnNew FwConnState = "new"
+	// FwConnEstablished : packet is part of an already established connection.
+	FwConnEstablished FwConnState = "established"
+	// FwConnRelated : packet starts a new connection related to an existing one
+	// (e.g. FTP data connection or ICMP error).
+	FwConnRelated FwConnState = "related"
+	// FwConnInvalid : packet which is not associated with any known connection.
+	FwConnInvalid FwConnState = "invalid"
+)
+
+// FwRateLimit : rate limit for a firewall rule.
+type FwRateLimit struct {
+	// Rate : number of packets allowed per Unit (before the rule starts to match).
+	Rate uint32 `json:"rate"`
+	// Unit : time unit of the rate, one of: "second", "minute", "hour", "day".
+	Unit string `json:"unit"`
+	// Burst : maximum number of packets allowed to exceed the rate in a burst.
+	// If not defined, default burst of 5 packets is used.
+	Burst uint32 `json:"burst,omitempty"`
+	// PerSource : apply the limit separately for every source IP address.
+	// Otherwise, the limit is applied to all traffic matched by the rule together.
+	PerSource bool `json:"perSource"`
+}
+
+// FwConnLimit : limit of parallel connections for a firewall rule.
+type FwConnLimit struct {
+	// MaxConns : maximum number of parallel connections (before the rule starts to match).
+	MaxConns uint32 `json:"maxConns"`
+	// PerSource : apply the limit separately for every source IP address.
+	// Otherwise, the limit is applied to all connections matched by the rule together.
+	PerSource bool `json:"perSource"`
+}
+
+// FwSchedule : time period when a firewall rule is applied.
+// All times are in UTC. Unset attributes do not restrict the period.
+type FwSchedule struct {
+	// TimeStart : time of the day when the rule starts to apply, in the format
+	// hh:mm[:ss].
+	TimeStart string `json:"timeStart,omitempty"`
+	// TimeStop : time of the day when the rule stops to apply, in the format hh:mm[:ss].
+	// TimeStop can be smaller than TimeStart (period crosses midnight and with
+	// Weekdays it ends on the next day).
+	TimeStop string `json:"timeStop,omitempty"`
+	// Weekdays : days of the week when the rule applies (Mon, Tue, Wed, Thu, Fri,
+	// Sat, Sun).
+	Weekdays []string `json:"weekdays,omitempty"`
+	// DateStart : date and time (RFC 3339) when the rule starts to apply.
+	DateStart string `json:"dateStart,omitempty"`
+	// DateStop : date and time (RFC 3339) when the rule stops to apply.
+	DateStop string `json:"dateStop,omitempty"`
+}
+
 // HostConfig : host configuration that Eden-SDN needs to be informed about.
 type HostConfig struct {
 	// HostIPs : list of IP addresses used by the host system (on top of which
@@ -530,21 +819,26 @@ const (
 	// FwDrop : drop traffic.
 	// Traffic is silently dropped.
 	FwDrop
+	// FwRejectWithTCPReset : reject TCP traffic with TCP RST packet.
+	// Applicable only to rules with Protocol TCP.
+	FwRejectWithTCPReset
 )
 
 // FwActionToString : convert FwAction to string representation used in JSON.
 var FwActionToString = map[FwAction]string{
-	FwAllow:  "allow",
-	FwReject: "reject",
-	FwDrop:   "drop",
+	FwAllow:              "allow",
+	FwReject:             "reject",
+	FwDrop:               "drop",
+	FwRejectWithTCPReset: "reject-with-tcp-reset",
 }
 
 // FwActionToID : get FwAction from a string representation.
 var FwActionToID = map[string]FwAction{
-	"":       FwAllow, // default value
-	"allow":  FwAllow,
-	"reject": FwReject,
-	"drop":   FwDrop,
+	"":                      FwAllow, // default value
+	"allow":                 FwAllow,
+	"reject":                FwReject,
+	"drop":                  FwDrop,
+	"reject-with-tcp-reset": FwRejectWithTCPReset,
 }
 
 // MarshalJSON marshals the enum as a quoted json string.
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"time"
+
 	"github.com/lf-edge/eve/libs/depgraph"
 )
 
@@ -10,6 +12,8 @@ type SDNStatus struct {
 	MgmtIPs []string `json:"mgmtIPs"`
 	// ConfigErrors : a set of current configuration errors. Normally this should be empty.
 	ConfigErrors []ConfigError `json:"configErrors,omitempty"`
+	// Scenarios : progress of impairment scenarios running on ports.
+	Scenarios []ScenarioStatus `json:"scenarios,omitempty"`
 	// TODO: more fields...
 }
 
@@ -20,3 +24,20 @@ type ConfigError struct {
 	// ErrMsg : error message
 	ErrMsg string
 }
+
+// ScenarioStatus : progress of the impairment scenario running on a port.
+type ScenarioStatus struct {
+	// Port : logical label of the port.
+	Port string `json:"port"`
+	// Step : index of the currently executed step.
+	Step int `json:"step"`
+	// StepCount : number of steps in the scenario.
+	StepCount int `json:"stepCount"`
+	// StepStartedAt : time when the current step started.
+	StepStartedAt time.Time `json:"stepStartedAt"`
+	// Iteration : number of times the scenario was started (more than 1 with Repeat).
+	Iteration int `json:"iteration"`
+	// Completed : true if the scenario has ended and the port reverted
+	// to the static configuration.
+	Completed bool `json:"completed"`
+}
@@ -0,0 +1,45 @@
+package api
+
+import "time"
+
+// SyslogMessage : syslog message received by SyslogServer endpoint.
+type SyslogMessage struct {
+	// ReceivedAt : time when the message was received.
+	ReceivedAt time.Time `json:"receivedAt"`
+	// Source : address of the sender (IP:port).
+	Source string `json:"source"`
+	// Transport : "udp", "tcp" or "tls".
+	Transport string `json:"transport"`
+	// Facility : syslog facility code (0-23).
+	Facility uint8 `json:"facility"`
+	// Severity : syslog severity code (0-7).
+	Severity uint8 `json:"severity"`
+	// Timestamp : timestamp from the message header (empty if missing).
+	Timestamp string `json:"timestamp,omitempty"`
+	// Hostname : hostname from the message header.
+	Hostname string `json:"hostname,omitempty"`
+	// AppName : application name (or TAG with RFC 3164).
+	AppName string `json:"appName,omitempty"`
+	// ProcID : process ID.
+	ProcID string `json:"procID,omitempty"`
+	// MsgID : message type identifier (RFC 5424 only).
+	MsgID string `json:"msgID,omitempty"`
+	// StructuredData : structured data as received (RFC 5424 only).
+	StructuredData string `json:"structuredData,omitempty"`
+	// Message : free-form message.
+	Message string `json:"message"`
+	// Raw : the message as received (without framing).
+	Raw string `json:"raw"`
+}
+
+// SyslogSeverityNames : keyword for each syslog severity (indexed by code).
+var SyslogSeverityNames = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+// SyslogFacilityNames : keyword for each syslog facility (indexed by code).
+var SyslogFacilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
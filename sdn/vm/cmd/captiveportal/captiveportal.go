@@ -0,0 +1,254 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/captiveportal/config"
+	"github.com/lf-edge/eden/sdn/vm/pkg/servercert"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	httpPort  = 80
+	httpsPort = 443
+	dnsPort   = 53
+)
+
+var portalPage = template.Must(template.New("portal").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Eden-SDN Captive Portal</title></head>
+<body>
+<h1>Eden-SDN Captive Portal</h1>
+{{if .LoggedIn}}
+<p>Client {{.ClientIP}} is logged in.</p>
+<form method="POST" action="/logout"><input type="submit" value="Logout"></form>
+{{else}}
+<p>Client {{.ClientIP}} must log in to access the network.</p>
+<form method="POST" action="/login"><input type="submit" value="Login"></form>
+{{end}}
+</body>
+</html>
+`))
+
+// Captive portal intercepting HTTP(S) and DNS traffic of clients that have not
+// logged in yet. Logged-in clients are recorded inside IP sets of their networks
+// (iptables rules inside network namespaces no longer redirect their traffic).
+type captivePortal struct {
+	config.CaptivePortalConfig
+	clientNetworks []clientNetwork
+	portalIP       net.IP
+	// runCmd executes command and returns its combined output.
+	runCmd func(name string, args ...string) ([]byte, error)
+}
+
+type clientNetwork struct {
+	config.ClientNetwork
+	subnet *net.IPNet
+}
+
+func main() {
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/captiveportal.conf", "Captive portal config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var portalConfig config.CaptivePortalConfig
+	if err = json.Unmarshal(configBytes, &portalConfig); err != nil {
+		log.Fatalf("failed to unmarshal captive portal config: %v", err)
+	}
+
+	// Process captive portal config.
+	if portalConfig.LogFile != "" {
+		logFile, err := os.OpenFile(portalConfig.LogFile, os.O_WRONLY|os.O_CREATE, 0755)
+		if err != nil {
+			log.Fatalf("failed to open log file %s: %v", portalConfig.LogFile, err)
+		}
+		log.SetOutput(logFile)
+	}
+	if portalConfig.Verbose {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+	portal := &captivePortal{
+		CaptivePortalConfig: portalConfig,
+		portalIP:            net.ParseIP(portalConfig.ListenIP),
+		runCmd:              runCmd,
+	}
+	if portal.portalIP == nil {
+		log.Fatalf("invalid listen IP: %s", portalConfig.ListenIP)
+	}
+	for _, clientNet := range portalConfig.ClientNetworks {
+		_, subnet, err := net.ParseCIDR(clientNet.Subnet)
+		if err != nil {
+			log.Fatalf("invalid subnet of client network %s: %v", clientNet.Subnet, err)
+		}
+		portal.clientNetworks = append(portal.clientNetworks,
+			clientNetwork{ClientNetwork: clientNet, subnet: subnet})
+	}
+	cert, err := servercert.GetCertificate(portal.CertPEM, portal.KeyPEM, portal.PortalHost, portal.portalIP)
+	if err != nil {
+		log.Fatalf("failed to prepare portal certificate: %v", err)
+	}
+
+	// Start listening before writing PID file.
+	udpConn, err := net.ListenPacket("udp", portal.listenAddr(dnsPort))
+	if err != nil {
+		log.Fatalf("failed to listen for DNS queries over UDP: %v", err)
+	}
+	dnsListener, err := net.Listen("tcp", portal.listenAddr(dnsPort))
+	if err != nil {
+		log.Fatalf("failed to listen for DNS queries over TCP: %v", err)
+	}
+	httpListener, err := net.Listen("tcp", portal.listenAddr(httpPort))
+	if err != nil {
+		log.Fatalf("failed to listen for HTTP requests: %v", err)
+	}
+	httpsListener, err := net.Listen("tcp", portal.listenAddr(httpsPort))
+	if err != nil {
+		log.Fatalf("failed to listen for HTTPS requests: %v", err)
+	}
+	if portalConfig.PidFile != "" {
+		pidBytes := []byte(fmt.Sprintf("%d", os.Getpid()))
+		err = os.WriteFile(portalConfig.PidFile, pidBytes, 0664)
+		if err != nil {
+			log.Fatalf("failed to write PID file %s: %v", portalConfig.PidFile, err)
+		}
+		defer os.Remove(portalConfig.PidFile)
+	}
+
+	go portal.serveDNSOverUDP(udpConn)
+	go portal.serveDNSOverTCP(dnsListener)
+	httpServer := &http.Server{Handler: portal}
+	go func() {
+		log.Debugf("HTTP server listening on %s", httpListener.Addr())
+		log.Fatalln(httpServer.Serve(httpListener))
+	}()
+	httpsServer := &http.Server{
+		Handler:   portal,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{*cert}},
+	}
+	go func() {
+		log.Debugf("HTTPS server listening on %s", httpsListener.Addr())
+		log.Fatalln(httpsServer.ServeTLS(httpsListener, "", ""))
+	}()
+
+	cancelChan := make(chan os.Signal, 1)
+	// Catch termination or interrupt signal.
+	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-cancelChan
+	log.Infof("Caught terimation/interrupt signal: %v, exiting...", sig)
+}
+
+func (p *captivePortal) listenAddr(port int) string {
+	return net.JoinHostPort(p.ListenIP, fmt.Sprintf("%d", port))
+}
+
+// ServeHTTP : login/logout requests are handled regardless of the requested host,
+// any other request not addressed to the portal is redirected to the login page.
+func (p *captivePortal) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Received request: %s %s%s from %s", r.Method, r.Host, r.URL.Path,
+		r.RemoteAddr)
+	clientIP := remoteIP(r)
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/login":
+		if err := p.updateClient(clientIP, true); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		p.writePortalPage(w, clientIP, true)
+	case r.Method == http.MethodPost && r.URL.Path == "/logout":
+		if err := p.updateClient(clientIP, false); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		p.writePortalPage(w, clientIP, false)
+	case p.isPortalHost(r.Host):
+		// Login page is served only to clients that have not logged in yet
+		// (traffic of logged-in clients is no longer redirected to the portal).
+		p.writePortalPage(w, clientIP, false)
+	default:
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		portalURL := fmt.Sprintf("%s://%s/", scheme, p.PortalHost)
+		http.Redirect(w, r, portalURL, http.StatusFound)
+	}
+}
+
+func (p *captivePortal) isPortalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.EqualFold(host, p.PortalHost) || host == p.ListenIP
+}
+
+func (p *captivePortal) writePortalPage(w http.ResponseWriter, clientIP net.IP,
+	loggedIn bool) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := portalPage.Execute(w, struct {
+		ClientIP net.IP
+		LoggedIn bool
+	}{ClientIP: clientIP, LoggedIn: loggedIn})
+	if err != nil {
+		log.Errorf("Failed to write portal page for %v: %v", clientIP, err)
+	}
+}
+
+// updateClient logs the client in (or out) by adding (or removing) its IP address
+// into (from) the IP set of its network.
+func (p *captivePortal) updateClient(clientIP net.IP, login bool) error {
+	if clientIP == nil {
+		return fmt.Errorf("unknown client IP address")
+	}
+	for _, clientNet := range p.clientNetworks {
+		if !clientNet.subnet.Contains(clientIP) {
+			continue
+		}
+		op, action := "del", "out"
+		if login {
+			op, action = "add", "in"
+		}
+		out, err := p.runCmd("ip", "netns", "exec", clientNet.NetNamespace,
+			"ipset", op, clientNet.IPSet, clientIP.String(), "-exist")
+		if err != nil {
+			err = fmt.Errorf("ipset %s %s %s failed: %s (%w)", op, clientNet.IPSet,
+				clientIP, strings.TrimSpace(string(out)), err)
+			log.Error(err)
+			return err
+		}
+		log.Infof("Client %v logged %s (network namespace %s)", clientIP, action,
+			clientNet.NetNamespace)
+		return nil
+	}
+	return fmt.Errorf("client %v is not from any network captured by the portal",
+		clientIP)
+}
+
+func runCmd(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/captiveportal/config"
+)
+
+// testPortal : captive portal for one client network, recording executed
+// commands instead of running them.
+type testPortal struct {
+	*captivePortal
+	sync.Mutex
+	cmds   [][]string
+	cmdErr error
+}
+
+func newTestPortal() *testPortal {
+	tp := &testPortal{}
+	_, subnet, _ := net.ParseCIDR("10.0.1.0/24")
+	tp.captivePortal = &captivePortal{
+		CaptivePortalConfig: config.CaptivePortalConfig{
+			ListenIP:   "10.10.10.10",
+			PortalHost: "portal.sdn",
+		},
+		clientNetworks: []clientNetwork{
+			{
+				ClientNetwork: config.ClientNetwork{
+					Subnet:       subnet.String(),
+					NetNamespace: "nw-net1",
+					IPSet:        "captive-portal-clients",
+				},
+				subnet: subnet,
+			},
+		},
+		portalIP: net.ParseIP("10.10.10.10"),
+		runCmd: func(name string, args ...string) ([]byte, error) {
+			tp.Lock()
+			defer tp.Unlock()
+			tp.cmds = append(tp.cmds, append([]string{name}, args...))
+			if tp.cmdErr != nil {
+				return []byte("ipset failed"), tp.cmdErr
+			}
+			return nil, nil
+		},
+	}
+	return tp
+}
+
+func TestServeHTTPRedirect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		tls      bool
+		status   int
+		location string
+		body     string
+	}{
+		{
+			name:     "HTTP request redirected to portal",
+			method:   http.MethodGet,
+			url:      "http://example.com/index.html",
+			status:   http.StatusFound,
+			location: "http://portal.sdn/",
+		},
+		{
+			name:     "HTTPS request redirected to portal",
+			method:   http.MethodGet,
+			url:      "https://example.com/",
+			tls:      true,
+			status:   http.StatusFound,
+			location: "https://portal.sdn/",
+		},
+		{
+			name:     "connectivity check redirected to portal",
+			method:   http.MethodGet,
+			url:      "http://connectivitycheck.gstatic.com/generate_204",
+			status:   http.StatusFound,
+			location: "http://portal.sdn/",
+		},
+		{
+			name:     "POST outside of login and logout redirected",
+			method:   http.MethodPost,
+			url:      "http://example.com/form",
+			status:   http.StatusFound,
+			location: "http://portal.sdn/",
+		},
+		{
+			name:   "portal host serves login page",
+			method: http.MethodGet,
+			url:    "http://PORTAL.sdn/",
+			status: http.StatusOK,
+			body:   "Client 10.0.1.5 must log in to access the network.",
+		},
+		{
+			name:   "portal IP with port serves login page",
+			method: http.MethodGet,
+			url:    "https://10.10.10.10:443/",
+			tls:    true,
+			status: http.StatusOK,
+			body:   `action="/login"`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			portal := newTestPortal()
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			req.RemoteAddr = "10.0.1.5:40000"
+			if !tt.tls {
+				req.TLS = nil
+			} else if req.TLS == nil {
+				req.TLS = &tls.ConnectionState{}
+			}
+			rec := httptest.NewRecorder()
+			portal.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, expected %d", rec.Code, tt.status)
+			}
+			if location := rec.Header().Get("Location"); location != tt.location {
+				t.Errorf("location = %q, expected %q", location, tt.location)
+			}
+			if !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("body %q does not contain %q", rec.Body.String(), tt.body)
+			}
+			if len(portal.cmds) != 0 {
+				t.Errorf("unexpected commands: %v", portal.cmds)
+			}
+		})
+	}
+}
+
+func TestServeHTTPLogin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		path       string
+		host       string
+		remoteAddr string
+		cmdErr     error
+		status     int
+		body       string
+		cmds       [][]string
+	}{
+		{
+			name:       "login adds client into IP set",
+			path:       "/login",
+			host:       "portal.sdn",
+			remoteAddr: "10.0.1.5:40000",
+			status:     http.StatusOK,
+			body:       "Client 10.0.1.5 is logged in.",
+			cmds: [][]string{{"ip", "netns", "exec", "nw-net1", "ipset", "add",
+				"captive-portal-clients", "10.0.1.5", "-exist"}},
+		},
+		{
+			name:       "login handled for any host",
+			path:       "/login",
+			host:       "example.com",
+			remoteAddr: "10.0.1.6:40000",
+			status:     http.StatusOK,
+			body:       "Client 10.0.1.6 is logged in.",
+			cmds: [][]string{{"ip", "netns", "exec", "nw-net1", "ipset", "add",
+				"captive-portal-clients", "10.0.1.6", "-exist"}},
+		},
+		{
+			name:       "logout removes client from IP set",
+			path:       "/logout",
+			host:       "portal.sdn",
+			remoteAddr: "10.0.1.5:40000",
+			status:     http.StatusOK,
+			body:       "Client 10.0.1.5 must log in to access the network.",
+			cmds: [][]string{{"ip", "netns", "exec", "nw-net1", "ipset", "del",
+				"captive-portal-clients", "10.0.1.5", "-exist"}},
+		},
+		{
+			name:       "client from unknown network",
+			path:       "/login",
+			host:       "portal.sdn",
+			remoteAddr: "192.168.1.5:40000",
+			status:     http.StatusInternalServerError,
+			body:       "is not from any network captured by the portal",
+		},
+		{
+			name:       "ipset failure",
+			path:       "/login",
+			host:       "portal.sdn",
+			remoteAddr: "10.0.1.5:40000",
+			cmdErr:     errors.New("exit status 1"),
+			status:     http.StatusInternalServerError,
+			body:       "ipset add captive-portal-clients 10.0.1.5 failed: ipset failed",
+			cmds: [][]string{{"ip", "netns", "exec", "nw-net1", "ipset", "add",
+				"captive-portal-clients", "10.0.1.5", "-exist"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			portal := newTestPortal()
+			portal.cmdErr = tt.cmdErr
+			req := httptest.NewRequest(http.MethodPost, "http://"+tt.host+tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			portal.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, expected %d (body: %s)", rec.Code, tt.status,
+					rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("body %q does not contain %q", rec.Body.String(), tt.body)
+			}
+			if !reflect.DeepEqual(portal.cmds, tt.cmds) {
+				t.Errorf("commands = %v, expected %v", portal.cmds, tt.cmds)
+			}
+		})
+	}
+}
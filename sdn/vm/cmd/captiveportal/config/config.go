@@ -0,0 +1,34 @@
+package config
+
+// CaptivePortalConfig : captive portal configuration formatted with JSON and passed
+// to captiveportal using the "-c" command line argument.
+type CaptivePortalConfig struct {
+	// ListenIP : IP address to listen on (for HTTP, HTTPS and DNS).
+	ListenIP string `json:"listenIP"`
+	// PortalHost : hostname (FQDN or IP address) of the portal, used to redirect
+	// clients to the login page.
+	PortalHost string `json:"portalHost"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// PidFile : file to write captiveportal process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all requests and queries logged.
+	Verbose bool `json:"verbose"`
+	// CertPEM : Portal certificate in the PEM format, used for HTTPS.
+	// If empty, a self-signed certificate is generated.
+	CertPEM string `json:"certPEM"`
+	// KeyPEM : Portal key in the PEM format, used for HTTPS.
+	KeyPEM string `json:"keyPEM"`
+	// ClientNetworks : networks with clients captured by the portal.
+	ClientNetworks []ClientNetwork `json:"clientNetworks"`
+}
+
+// ClientNetwork : network with clients captured by the portal.
+type ClientNetwork struct {
+	// Subnet : network subnet, used to find the network of a client.
+	Subnet string `json:"subnet"`
+	// NetNamespace : network namespace of the network.
+	NetNamespace string `json:"netNamespace"`
+	// IPSet : name of the IP set (inside NetNamespace) with logged-in clients.
+	IPSet string `json:"ipSet"`
+}
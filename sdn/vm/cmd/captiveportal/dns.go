@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// Maximum size of DNS message received over UDP.
+	maxUDPMsgLen = 65535
+	// Timeout for reading the next query from a TCP connection.
+	tcpIdleTimeout = 30 * time.Second
+	// TTL of hijacked DNS answers. Kept short so that clients do not continue
+	// using the portal IP for long after logging in.
+	hijackedTTL = 1
+)
+
+func (p *captivePortal) serveDNSOverUDP(conn net.PacketConn) {
+	for {
+		buf := make([]byte, maxUDPMsgLen)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			log.Fatalf("failed to read UDP query: %v", err)
+		}
+		resp := p.hijackQuery(buf[:n])
+		if resp == nil {
+			continue
+		}
+		if _, err = conn.WriteTo(resp, addr); err != nil {
+			log.Errorf("Failed to write UDP response to %v: %v", addr, err)
+		}
+	}
+}
+
+func (p *captivePortal) serveDNSOverTCP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Fatalf("failed to accept TCP connection: %v", err)
+		}
+		go p.serveDNSConn(conn)
+	}
+}
+
+func (p *captivePortal) serveDNSConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(tcpIdleTimeout))
+		// DNS messages sent over TCP are prefixed with two-byte length field.
+		var msgLen uint16
+		if err := binary.Read(conn, binary.BigEndian, &msgLen); err != nil {
+			return
+		}
+		query := make([]byte, msgLen)
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+		resp := p.hijackQuery(query)
+		if resp == nil {
+			continue
+		}
+		buf := make([]byte, 2+len(resp))
+		binary.BigEndian.PutUint16(buf, uint16(len(resp)))
+		copy(buf[2:], resp)
+		if _, err := conn.Write(buf); err != nil {
+			log.Errorf("Failed to write TCP response to %v: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// hijackQuery answers every A query with the portal IP address.
+// Queries of other types are answered with no records.
+func (p *captivePortal) hijackQuery(query []byte) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		log.Warnf("Failed to parse DNS query: %v", err)
+		return nil
+	}
+	question, err := parser.Question()
+	if err != nil {
+		log.Warnf("Failed to parse DNS question: %v", err)
+		return nil
+	}
+	log.Debugf("Hijacking DNS query %s %s", question.Type, question.Name)
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		OpCode:             header.OpCode,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              dnsmessage.RCodeSuccess,
+	})
+	builder.EnableCompression()
+	if err = builder.StartQuestions(); err == nil {
+		err = builder.Question(question)
+	}
+	if err == nil && question.Type == dnsmessage.TypeA {
+		if err = builder.StartAnswers(); err == nil {
+			var resource dnsmessage.AResource
+			copy(resource.A[:], p.portalIP.To4())
+			err = builder.AResource(dnsmessage.ResourceHeader{
+				Name:  question.Name,
+				Type:  question.Type,
+				Class: question.Class,
+				TTL:   hijackedTTL,
+			}, resource)
+		}
+	}
+	if err != nil {
+		log.Errorf("Failed to build DNS response: %v", err)
+		return nil
+	}
+	resp, err := builder.Finish()
+	if err != nil {
+		log.Errorf("Failed to build DNS response: %v", err)
+		return nil
+	}
+	return resp
+}
@@ -0,0 +1,21 @@
+package config
+
+// DNS64ProxyConfig : DNS64 proxy configuration formatted with JSON and passed
+// to dns64proxy using the "-c" command line argument.
+type DNS64ProxyConfig struct {
+	// ListenIP : IP address to listen on (for both UDP and TCP).
+	ListenIP string `json:"listenIP"`
+	// ListenPort : port to listen on.
+	ListenPort uint16 `json:"listenPort"`
+	// Upstreams : addresses (<ip>:<port>) of DNS servers to forward queries to.
+	// Servers are tried in the given order.
+	Upstreams []string `json:"upstreams"`
+	// Prefix : NAT64 prefix (/96) used to synthesize AAAA records from A records.
+	Prefix string `json:"prefix"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// PidFile : file to write dns64proxy process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all queries logged.
+	Verbose bool `json:"verbose"`
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// handleQuery forwards query to upstream servers. If the query is for AAAA records
+// and the name has none, A records are requested and AAAA records are synthesized
+// from them by embedding IPv4 addresses into the NAT64 prefix.
+func (p *dns64Proxy) handleQuery(query []byte, overTCP bool) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil {
+		log.Warnf("Failed to parse DNS query: %v", err)
+		return nil
+	}
+	resp := p.Forward(query, overTCP)
+	if resp == nil || len(msg.Questions) != 1 {
+		return resp
+	}
+	question := msg.Questions[0]
+	log.Debugf("Received DNS query %s %s", question.Type, question.Name)
+	if question.Type != dnsmessage.TypeAAAA {
+		return resp
+	}
+	var aaaaResp dnsmessage.Message
+	if err := aaaaResp.Unpack(resp); err != nil {
+		log.Warnf("Failed to parse DNS response: %v", err)
+		return resp
+	}
+	if aaaaResp.RCode != dnsmessage.RCodeSuccess || hasAnswer(aaaaResp, dnsmessage.TypeAAAA) {
+		return resp
+	}
+	// No AAAA records, try to synthesize them from A records.
+	aQuery := msg
+	aQuery.Questions = []dnsmessage.Question{{
+		Name:  question.Name,
+		Type:  dnsmessage.TypeA,
+		Class: question.Class,
+	}}
+	aQueryBytes, err := aQuery.Pack()
+	if err != nil {
+		log.Errorf("Failed to build A query: %v", err)
+		return resp
+	}
+	aRespBytes := p.Forward(aQueryBytes, overTCP)
+	if aRespBytes == nil {
+		return resp
+	}
+	var aResp dnsmessage.Message
+	if err = aResp.Unpack(aRespBytes); err != nil {
+		log.Warnf("Failed to parse DNS response: %v", err)
+		return resp
+	}
+	if aResp.RCode != dnsmessage.RCodeSuccess || !hasAnswer(aResp, dnsmessage.TypeA) {
+		return resp
+	}
+	synthesized := aaaaResp
+	synthesized.Answers = nil
+	for _, answer := range aResp.Answers {
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			header := answer.Header
+			header.Type = dnsmessage.TypeAAAA
+			aaaa := &dnsmessage.AAAAResource{}
+			copy(aaaa.AAAA[:], p.synthesizeIPv6(body.A[:]))
+			synthesized.Answers = append(synthesized.Answers, dnsmessage.Resource{
+				Header: header,
+				Body:   aaaa,
+			})
+			log.Debugf("Synthesized AAAA record for %s: %v -> %v",
+				header.Name, net.IP(body.A[:]), net.IP(aaaa.AAAA[:]))
+		case *dnsmessage.CNAMEResource:
+			synthesized.Answers = append(synthesized.Answers, answer)
+		}
+	}
+	// Authority section of the original response (SOA for the negative answer)
+	// does not apply to the synthesized answer.
+	synthesized.Authorities = nil
+	synthesizedBytes, err := synthesized.Pack()
+	if err != nil {
+		log.Errorf("Failed to build synthesized response: %v", err)
+		return resp
+	}
+	return synthesizedBytes
+}
+
+func hasAnswer(msg dnsmessage.Message, rrType dnsmessage.Type) bool {
+	for _, answer := range msg.Answers {
+		if answer.Header.Type == rrType {
+			return true
+		}
+	}
+	return false
+}
+
+// synthesizeIPv6 embeds IPv4 address into the /96 NAT64 prefix (RFC 6052).
+func (p *dns64Proxy) synthesizeIPv6(ipv4 net.IP) net.IP {
+	ipv6 := make(net.IP, net.IPv6len)
+	copy(ipv6, p.prefix.IP.To16())
+	copy(ipv6[12:], ipv4.To4())
+	return ipv6
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/lf-edge/eden/sdn/vm/pkg/dnsproxy"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// upstreamRecords : records of a name known to the fake upstream server.
+type upstreamRecords struct {
+	cname string
+	a     [][4]byte
+	aaaa  [][16]byte
+}
+
+var upstreamZone = map[string]upstreamRecords{
+	"ipv4only.example.": {a: [][4]byte{{192, 0, 2, 33}, {192, 0, 2, 34}}},
+	"dualstack.example.": {
+		a:    [][4]byte{{192, 0, 2, 1}},
+		aaaa: [][16]byte{{0x20, 0x01, 0x0d, 0xb8, 15: 1}},
+	},
+	"alias.example.":  {cname: "ipv4only.example."},
+	"noaddr.example.": {},
+}
+
+// startUpstream runs UDP DNS server answering queries from upstreamZone.
+// Negative answers contain SOA record in the authority section.
+func startUpstream(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start upstream: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var msg dnsmessage.Message
+			if err = msg.Unpack(buf[:n]); err != nil || len(msg.Questions) != 1 {
+				continue
+			}
+			msg.Header.Response = true
+			msg.Answers = upstreamAnswers(msg.Questions[0])
+			if _, known := upstreamZone[msg.Questions[0].Name.String()]; !known {
+				msg.Header.RCode = dnsmessage.RCodeNameError
+			}
+			if len(msg.Answers) == 0 {
+				msg.Authorities = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{
+						Name:  dnsmessage.MustNewName("example."),
+						Type:  dnsmessage.TypeSOA,
+						Class: dnsmessage.ClassINET,
+					},
+					Body: &dnsmessage.SOAResource{
+						NS:   dnsmessage.MustNewName("ns.example."),
+						MBox: dnsmessage.MustNewName("admin.example."),
+					},
+				}}
+			}
+			resp, err := msg.Pack()
+			if err == nil {
+				_, _ = conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func upstreamAnswers(q dnsmessage.Question) (answers []dnsmessage.Resource) {
+	name := q.Name
+	records := upstreamZone[name.String()]
+	if records.cname != "" {
+		target := dnsmessage.MustNewName(records.cname)
+		answers = append(answers, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeCNAME,
+				Class: dnsmessage.ClassINET, TTL: 60},
+			Body: &dnsmessage.CNAMEResource{CNAME: target},
+		})
+		name = target
+		records = upstreamZone[records.cname]
+	}
+	header := dnsmessage.ResourceHeader{Name: name, Type: q.Type,
+		Class: dnsmessage.ClassINET, TTL: 300}
+	switch q.Type {
+	case dnsmessage.TypeA:
+		for _, a := range records.a {
+			answers = append(answers, dnsmessage.Resource{
+				Header: header, Body: &dnsmessage.AResource{A: a}})
+		}
+	case dnsmessage.TypeAAAA:
+		for _, aaaa := range records.aaaa {
+			answers = append(answers, dnsmessage.Resource{
+				Header: header, Body: &dnsmessage.AAAAResource{AAAA: aaaa}})
+		}
+	}
+	return answers
+}
+
+func newTestProxy(t *testing.T, prefix string) *dns64Proxy {
+	_, nat64Prefix, err := net.ParseCIDR(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dns64Proxy{
+		Proxy:  &dnsproxy.Proxy{Upstreams: []string{startUpstream(t)}},
+		prefix: nat64Prefix,
+	}
+}
+
+func TestHandleQuery(t *testing.T) {
+	t.Parallel()
+
+	type answer struct {
+		rrType dnsmessage.Type
+		value  string
+	}
+	tests := []struct {
+		name        string
+		qName       string
+		qType       dnsmessage.Type
+		rCode       dnsmessage.RCode
+		answers     []answer
+		authorities int
+	}{
+		{
+			name:  "A-only name gets synthesized AAAA",
+			qName: "ipv4only.example.",
+			qType: dnsmessage.TypeAAAA,
+			answers: []answer{
+				{dnsmessage.TypeAAAA, "64:ff9b::c000:221"},
+				{dnsmessage.TypeAAAA, "64:ff9b::c000:222"},
+			},
+		},
+		{
+			name:  "existing AAAA is not synthesized",
+			qName: "dualstack.example.",
+			qType: dnsmessage.TypeAAAA,
+			answers: []answer{
+				{dnsmessage.TypeAAAA, "2001:db8::1"},
+			},
+		},
+		{
+			name:  "A query is forwarded unchanged",
+			qName: "ipv4only.example.",
+			qType: dnsmessage.TypeA,
+			answers: []answer{
+				{dnsmessage.TypeA, "192.0.2.33"},
+				{dnsmessage.TypeA, "192.0.2.34"},
+			},
+		},
+		{
+			name:  "CNAME is kept in synthesized answer",
+			qName: "alias.example.",
+			qType: dnsmessage.TypeAAAA,
+			answers: []answer{
+				{dnsmessage.TypeCNAME, "ipv4only.example."},
+				{dnsmessage.TypeAAAA, "64:ff9b::c000:221"},
+				{dnsmessage.TypeAAAA, "64:ff9b::c000:222"},
+			},
+		},
+		{
+			name:        "name without addresses",
+			qName:       "noaddr.example.",
+			qType:       dnsmessage.TypeAAAA,
+			authorities: 1,
+		},
+		{
+			name:        "non-existent name",
+			qName:       "missing.example.",
+			qType:       dnsmessage.TypeAAAA,
+			rCode:       dnsmessage.RCodeNameError,
+			authorities: 1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			proxy := newTestProxy(t, "64:ff9b::/96")
+			query := dnsmessage.Message{
+				Header: dnsmessage.Header{ID: 4242, RecursionDesired: true},
+				Questions: []dnsmessage.Question{{
+					Name:  dnsmessage.MustNewName(tt.qName),
+					Type:  tt.qType,
+					Class: dnsmessage.ClassINET,
+				}},
+			}
+			queryBytes, err := query.Pack()
+			if err != nil {
+				t.Fatal(err)
+			}
+			respBytes := proxy.handleQuery(queryBytes, false)
+			if respBytes == nil {
+				t.Fatal("no response")
+			}
+			var resp dnsmessage.Message
+			if err = resp.Unpack(respBytes); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if resp.ID != query.ID || !reflect.DeepEqual(resp.Questions, query.Questions) {
+				t.Errorf("response does not match query: %+v", resp)
+			}
+			if resp.RCode != tt.rCode {
+				t.Errorf("rcode = %v, expected %v", resp.RCode, tt.rCode)
+			}
+			var answers []answer
+			for _, rr := range resp.Answers {
+				switch body := rr.Body.(type) {
+				case *dnsmessage.AResource:
+					answers = append(answers, answer{rr.Header.Type, net.IP(body.A[:]).String()})
+				case *dnsmessage.AAAAResource:
+					answers = append(answers, answer{rr.Header.Type, net.IP(body.AAAA[:]).String()})
+				case *dnsmessage.CNAMEResource:
+					answers = append(answers, answer{rr.Header.Type, body.CNAME.String()})
+				}
+			}
+			if !reflect.DeepEqual(answers, tt.answers) {
+				t.Errorf("answers = %v, expected %v", answers, tt.answers)
+			}
+			if len(resp.Authorities) != tt.authorities {
+				t.Errorf("%d authority records, expected %d", len(resp.Authorities),
+					tt.authorities)
+			}
+		})
+	}
+}
+
+func TestSynthesizeIPv6(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prefix   string
+		ipv4     net.IP
+		expected string
+	}{
+		{prefix: "64:ff9b::/96", ipv4: net.IPv4(192, 0, 2, 33), expected: "64:ff9b::c000:221"},
+		{prefix: "64:ff9b::/96", ipv4: net.IP{10, 1, 2, 3}, expected: "64:ff9b::a01:203"},
+		{prefix: "2001:db8:64::/96", ipv4: net.IPv4(198, 51, 100, 7),
+			expected: "2001:db8:64::c633:6407"},
+	}
+	for _, tt := range tests {
+		_, prefix, err := net.ParseCIDR(tt.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proxy := &dns64Proxy{prefix: prefix}
+		if ipv6 := proxy.synthesizeIPv6(tt.ipv4); ipv6.String() != tt.expected {
+			t.Errorf("synthesizeIPv6(%s, %s) = %s, expected %s", tt.prefix, tt.ipv4,
+				ipv6, tt.expected)
+		}
+	}
+}
@@ -1,13 +1,64 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
-	"time"
+	"net"
+	"os"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/dns64proxy/config"
+	"github.com/lf-edge/eden/sdn/vm/pkg/dnsproxy"
+	log "github.com/sirupsen/logrus"
 )
 
-// TODO: Implement DNS64 Proxy
-// It will be used to enable running Eden-SDN with IPv6 networks on IPv4-only hosts.
+// DNS64 proxy (RFC 6147) forwarding queries to upstream DNS servers and synthesizing
+// AAAA records from A records for domain names without IPv6 addresses.
+// Used to enable running Eden-SDN with IPv6 networks on IPv4-only hosts
+// (together with NAT64).
+type dns64Proxy struct {
+	*dnsproxy.Proxy
+	prefix *net.IPNet
+}
+
 func main() {
-	fmt.Println("DNS64 Proxy: hello world")
-	time.Sleep(time.Hour)
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/dns64proxy.conf", "DNS64 proxy config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var proxyConfig config.DNS64ProxyConfig
+	if err = json.Unmarshal(configBytes, &proxyConfig); err != nil {
+		log.Fatalf("failed to unmarshal DNS64 proxy config: %v", err)
+	}
+
+	// Process DNS64 proxy config.
+	if err = dnsproxy.SetupLogging(proxyConfig.LogFile, proxyConfig.Verbose); err != nil {
+		log.Fatal(err)
+	}
+	_, prefix, err := net.ParseCIDR(proxyConfig.Prefix)
+	if err != nil {
+		log.Fatalf("invalid NAT64 prefix %s: %v", proxyConfig.Prefix, err)
+	}
+	if ones, bits := prefix.Mask.Size(); ones != 96 || bits != 128 {
+		log.Fatalf("NAT64 prefix %s is not /96", proxyConfig.Prefix)
+	}
+	if len(proxyConfig.Upstreams) == 0 {
+		log.Fatal("no upstream DNS servers configured")
+	}
+	proxy := &dns64Proxy{
+		Proxy:  &dnsproxy.Proxy{Upstreams: proxyConfig.Upstreams},
+		prefix: prefix,
+	}
+	proxy.HandleQuery = proxy.handleQuery
+
+	listenAddr := net.JoinHostPort(proxyConfig.ListenIP,
+		fmt.Sprintf("%d", proxyConfig.ListenPort))
+	if err = proxy.Run(listenAddr, proxyConfig.PidFile); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,26 @@
+package config
+
+import (
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// DNSFaultProxyConfig : DNS fault proxy configuration formatted with JSON and passed
+// to dnsfaultproxy using the "-c" command line argument.
+type DNSFaultProxyConfig struct {
+	// ListenIP : IP address to listen on (for both UDP and TCP).
+	ListenIP string `json:"listenIP"`
+	// ListenPort : port to listen on.
+	ListenPort uint16 `json:"listenPort"`
+	// UpstreamAddr : address (<ip>:<port>) of the DNS server to forward queries to
+	// when no fault is injected.
+	UpstreamAddr string `json:"upstreamAddr"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// PidFile : file to write dnsfaultproxy process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all queries logged.
+	Verbose bool `json:"verbose"`
+	// Rules : fault rules, evaluated in the given order.
+	// Symbolic references (endpoint FQDN/IP, adam IP) are already translated.
+	Rules []sdnapi.DNSFaultRule `json:"rules"`
+}
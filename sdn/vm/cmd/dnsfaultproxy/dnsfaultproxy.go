@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/dnsfaultproxy/config"
+	"github.com/lf-edge/eden/sdn/vm/pkg/dnsproxy"
+	log "github.com/sirupsen/logrus"
+)
+
+// DNS proxy injecting faults into responses for queries matching configured rules.
+// Queries not matched by any rule are forwarded to the upstream (dnsmasq running
+// in the same endpoint).
+type dnsFaultProxy struct {
+	*dnsproxy.Proxy
+	rules []*faultRule
+}
+
+func main() {
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/dnsfaultproxy.conf", "DNS fault proxy config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var proxyConfig config.DNSFaultProxyConfig
+	if err = json.Unmarshal(configBytes, &proxyConfig); err != nil {
+		log.Fatalf("failed to unmarshal DNS fault proxy config: %v", err)
+	}
+
+	// Process DNS fault proxy config.
+	if err = dnsproxy.SetupLogging(proxyConfig.LogFile, proxyConfig.Verbose); err != nil {
+		log.Fatal(err)
+	}
+	proxy := &dnsFaultProxy{
+		Proxy: &dnsproxy.Proxy{Upstreams: []string{proxyConfig.UpstreamAddr}},
+	}
+	proxy.HandleQuery = proxy.handleQuery
+	for _, rule := range proxyConfig.Rules {
+		proxy.rules = append(proxy.rules, newFaultRule(rule))
+	}
+
+	listenAddr := net.JoinHostPort(proxyConfig.ListenIP,
+		fmt.Sprintf("%d", proxyConfig.ListenPort))
+	if err = proxy.Run(listenAddr, proxyConfig.PidFile); err != nil {
+		log.Fatal(err)
+	}
+}
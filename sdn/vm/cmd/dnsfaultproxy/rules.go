@@ -0,0 +1,212 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var queryTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"NS":    dnsmessage.TypeNS,
+	"CNAME": dnsmessage.TypeCNAME,
+	"SOA":   dnsmessage.TypeSOA,
+	"PTR":   dnsmessage.TypePTR,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"SRV":   dnsmessage.TypeSRV,
+	"ANY":   dnsmessage.TypeALL,
+}
+
+// faultRule : DNS fault rule with the state of IP rotation.
+type faultRule struct {
+	sdnapi.DNSFaultRule
+	sync.Mutex
+	ipv4, ipv6   []net.IP
+	nextV4Index  int
+	nextV6Index  int
+	matchTypeSet map[dnsmessage.Type]struct{}
+}
+
+func newFaultRule(rule sdnapi.DNSFaultRule) *faultRule {
+	r := &faultRule{DNSFaultRule: rule}
+	for _, ipStr := range rule.RotateIPs {
+		ip := net.ParseIP(ipStr)
+		if ip == nil {
+			log.Warnf("Skipping invalid IP %s of DNS fault rule for %s", ipStr, rule.Name)
+			continue
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			r.ipv4 = append(r.ipv4, ip4)
+		} else {
+			r.ipv6 = append(r.ipv6, ip)
+		}
+	}
+	if len(rule.QueryTypes) > 0 {
+		r.matchTypeSet = make(map[dnsmessage.Type]struct{})
+		for _, queryType := range rule.QueryTypes {
+			if t, ok := queryTypes[strings.ToUpper(queryType)]; ok {
+				r.matchTypeSet[t] = struct{}{}
+			}
+		}
+	}
+	return r
+}
+
+func (r *faultRule) matches(q dnsmessage.Question) bool {
+	if r.matchTypeSet != nil {
+		if _, match := r.matchTypeSet[q.Type]; !match {
+			return false
+		}
+	}
+	name := normalizeName(q.Name.String())
+	ruleName := normalizeName(r.Name)
+	switch {
+	case ruleName == "*":
+		return true
+	case strings.HasPrefix(ruleName, "*."):
+		return strings.HasSuffix(name, ruleName[1:])
+	default:
+		return name == ruleName
+	}
+}
+
+// nextIP returns the next IP address to answer with, or nil if there is no IP
+// of the requested family.
+func (r *faultRule) nextIP(ipv6 bool) (ip net.IP) {
+	r.Lock()
+	defer r.Unlock()
+	if ipv6 {
+		if len(r.ipv6) == 0 {
+			return nil
+		}
+		ip = r.ipv6[r.nextV6Index%len(r.ipv6)]
+		r.nextV6Index++
+		return ip
+	}
+	if len(r.ipv4) == 0 {
+		return nil
+	}
+	ip = r.ipv4[r.nextV4Index%len(r.ipv4)]
+	r.nextV4Index++
+	return ip
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// handleQuery returns response for the given query, or nil if the query
+// should be dropped.
+func (p *dnsFaultProxy) handleQuery(query []byte, overTCP bool) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		log.Warnf("Failed to parse DNS query: %v", err)
+		return nil
+	}
+	question, err := parser.Question()
+	if err != nil {
+		// Not a standard query, let upstream deal with it.
+		return p.Forward(query, overTCP)
+	}
+	var rule *faultRule
+	for _, r := range p.rules {
+		if r.matches(question) {
+			rule = r
+			break
+		}
+	}
+	if rule == nil {
+		return p.Forward(query, overTCP)
+	}
+	log.Debugf("Query %s %s matched fault rule for %s (action: %s)",
+		question.Type, question.Name, rule.Name, rule.Action)
+	if rule.Action == sdnapi.DNSFaultDrop {
+		return nil
+	}
+	if rule.Delay > 0 {
+		time.Sleep(time.Duration(rule.Delay) * time.Millisecond)
+	}
+	switch rule.Action {
+	case sdnapi.DNSFaultNXDomain:
+		return p.buildResponse(header, question, dnsmessage.RCodeNameError, false, nil, 0)
+	case sdnapi.DNSFaultServFail:
+		return p.buildResponse(header, question, dnsmessage.RCodeServerFailure, false, nil, 0)
+	case sdnapi.DNSFaultTruncate:
+		if !overTCP {
+			return p.buildResponse(header, question, dnsmessage.RCodeSuccess, true, nil, 0)
+		}
+	case sdnapi.DNSFaultRotateIPs:
+		switch question.Type {
+		case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+			ip := rule.nextIP(question.Type == dnsmessage.TypeAAAA)
+			return p.buildResponse(header, question, dnsmessage.RCodeSuccess, false,
+				ip, rule.TTL)
+		}
+	}
+	return p.Forward(query, overTCP)
+}
+
+// buildResponse builds response with the given rcode and optionally with a single
+// A/AAAA answer.
+func (p *dnsFaultProxy) buildResponse(query dnsmessage.Header, question dnsmessage.Question,
+	rcode dnsmessage.RCode, truncated bool, answer net.IP, ttl uint32) []byte {
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 query.ID,
+		Response:           true,
+		OpCode:             query.OpCode,
+		Authoritative:      true,
+		Truncated:          truncated,
+		RecursionDesired:   query.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              rcode,
+	})
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		log.Errorf("Failed to build DNS response: %v", err)
+		return nil
+	}
+	if err := builder.Question(question); err != nil {
+		log.Errorf("Failed to build DNS response: %v", err)
+		return nil
+	}
+	if answer != nil {
+		if err := builder.StartAnswers(); err != nil {
+			log.Errorf("Failed to build DNS response: %v", err)
+			return nil
+		}
+		rrHeader := dnsmessage.ResourceHeader{
+			Name:  question.Name,
+			Type:  question.Type,
+			Class: question.Class,
+			TTL:   ttl,
+		}
+		var err error
+		if ip4 := answer.To4(); ip4 != nil {
+			var resource dnsmessage.AResource
+			copy(resource.A[:], ip4)
+			err = builder.AResource(rrHeader, resource)
+		} else {
+			var resource dnsmessage.AAAAResource
+			copy(resource.AAAA[:], answer.To16())
+			err = builder.AAAAResource(rrHeader, resource)
+		}
+		if err != nil {
+			log.Errorf("Failed to build DNS response: %v", err)
+			return nil
+		}
+	}
+	resp, err := builder.Finish()
+	if err != nil {
+		log.Errorf("Failed to build DNS response: %v", err)
+		return nil
+	}
+	return resp
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/dnsproxy"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// upstreamIP is returned in every A answer of the fake upstream server.
+var upstreamIP = [4]byte{192, 0, 2, 1}
+
+// startUpstream runs UDP DNS server answering every query with upstreamIP.
+func startUpstream(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start upstream: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var msg dnsmessage.Message
+			if err = msg.Unpack(buf[:n]); err != nil || len(msg.Questions) != 1 {
+				continue
+			}
+			msg.Header.Response = true
+			msg.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{
+					Name:  msg.Questions[0].Name,
+					Type:  dnsmessage.TypeA,
+					Class: dnsmessage.ClassINET,
+				},
+				Body: &dnsmessage.AResource{A: upstreamIP},
+			}}
+			resp, err := msg.Pack()
+			if err == nil {
+				_, _ = conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func question(name string, qType dnsmessage.Type) dnsmessage.Question {
+	return dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  qType,
+		Class: dnsmessage.ClassINET,
+	}
+}
+
+func packQuery(t *testing.T, q dnsmessage.Question) []byte {
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 4242, RecursionDesired: true},
+		Questions: []dnsmessage.Question{q},
+	}
+	query, err := msg.Pack()
+	if err != nil {
+		t.Fatalf("failed to pack query: %v", err)
+	}
+	return query
+}
+
+func TestFaultRuleMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		rule     sdnapi.DNSFaultRule
+		question dnsmessage.Question
+		expected bool
+	}{
+		{
+			name:     "exact name",
+			rule:     sdnapi.DNSFaultRule{Name: "example.com"},
+			question: question("example.com.", dnsmessage.TypeA),
+			expected: true,
+		},
+		{
+			name:     "name is case insensitive",
+			rule:     sdnapi.DNSFaultRule{Name: "Example.COM."},
+			question: question("example.com.", dnsmessage.TypeA),
+			expected: true,
+		},
+		{
+			name:     "different name",
+			rule:     sdnapi.DNSFaultRule{Name: "example.com"},
+			question: question("www.example.com.", dnsmessage.TypeA),
+		},
+		{
+			name:     "wildcard subdomain",
+			rule:     sdnapi.DNSFaultRule{Name: "*.example.com"},
+			question: question("www.example.com.", dnsmessage.TypeA),
+			expected: true,
+		},
+		{
+			name:     "wildcard does not match the parent domain",
+			rule:     sdnapi.DNSFaultRule{Name: "*.example.com"},
+			question: question("example.com.", dnsmessage.TypeA),
+		},
+		{
+			name:     "wildcard does not match name with the same suffix",
+			rule:     sdnapi.DNSFaultRule{Name: "*.example.com"},
+			question: question("www.badexample.com.", dnsmessage.TypeA),
+		},
+		{
+			name:     "match everything",
+			rule:     sdnapi.DNSFaultRule{Name: "*"},
+			question: question("anything.org.", dnsmessage.TypeMX),
+			expected: true,
+		},
+		{
+			name:     "matching query type",
+			rule:     sdnapi.DNSFaultRule{Name: "example.com", QueryTypes: []string{"a", "AAAA"}},
+			question: question("example.com.", dnsmessage.TypeAAAA),
+			expected: true,
+		},
+		{
+			name:     "other query type",
+			rule:     sdnapi.DNSFaultRule{Name: "example.com", QueryTypes: []string{"A"}},
+			question: question("example.com.", dnsmessage.TypeTXT),
+		},
+		{
+			name:     "only unknown query types",
+			rule:     sdnapi.DNSFaultRule{Name: "example.com", QueryTypes: []string{"BOGUS"}},
+			question: question("example.com.", dnsmessage.TypeA),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if match := newFaultRule(test.rule).matches(test.question); match != test.expected {
+				t.Errorf("matches(%v) = %t, want %t", test.question, match, test.expected)
+			}
+		})
+	}
+}
+
+func TestFaultRuleNextIP(t *testing.T) {
+	t.Parallel()
+
+	rule := newFaultRule(sdnapi.DNSFaultRule{
+		Name:      "example.com",
+		RotateIPs: []string{"10.0.0.1", "2001:db8::1", "invalid", "10.0.0.2"},
+	})
+	for _, expected := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"} {
+		if ip := rule.nextIP(false); !ip.Equal(net.ParseIP(expected)) {
+			t.Errorf("nextIP(false) = %v, want %s", ip, expected)
+		}
+	}
+	for _, expected := range []string{"2001:db8::1", "2001:db8::1"} {
+		if ip := rule.nextIP(true); !ip.Equal(net.ParseIP(expected)) {
+			t.Errorf("nextIP(true) = %v, want %s", ip, expected)
+		}
+	}
+	rule = newFaultRule(sdnapi.DNSFaultRule{Name: "example.com", RotateIPs: []string{"10.0.0.1"}})
+	if ip := rule.nextIP(true); ip != nil {
+		t.Errorf("nextIP(true) without IPv6 addresses = %v, want nil", ip)
+	}
+}
+
+func TestHandleQuery(t *testing.T) {
+	t.Parallel()
+
+	proxy := &dnsFaultProxy{
+		Proxy: &dnsproxy.Proxy{Upstreams: []string{startUpstream(t)}},
+	}
+	for _, rule := range []sdnapi.DNSFaultRule{
+		{Name: "nxdomain.test", Action: sdnapi.DNSFaultNXDomain},
+		{Name: "servfail.test", Action: sdnapi.DNSFaultServFail},
+		{Name: "drop.test", Action: sdnapi.DNSFaultDrop},
+		{Name: "truncate.test", Action: sdnapi.DNSFaultTruncate},
+		{Name: "rotate.test", Action: sdnapi.DNSFaultRotateIPs, TTL: 30,
+			RotateIPs: []string{"10.0.0.1", "10.0.0.2"}},
+		{Name: "*.rotate.test", Action: sdnapi.DNSFaultNXDomain},
+	} {
+		proxy.rules = append(proxy.rules, newFaultRule(rule))
+	}
+
+	tests := []struct {
+		name        string
+		question    dnsmessage.Question
+		overTCP     bool
+		dropped     bool
+		rcode       dnsmessage.RCode
+		truncated   bool
+		expectedIPs []string
+		expectedTTL uint32
+	}{
+		{
+			name:        "no rule matched",
+			question:    question("other.test.", dnsmessage.TypeA),
+			expectedIPs: []string{"192.0.2.1"},
+		},
+		{
+			name:     "nxdomain",
+			question: question("nxdomain.test.", dnsmessage.TypeA),
+			rcode:    dnsmessage.RCodeNameError,
+		},
+		{
+			name:     "servfail",
+			question: question("servfail.test.", dnsmessage.TypeA),
+			rcode:    dnsmessage.RCodeServerFailure,
+		},
+		{
+			name:     "drop",
+			question: question("drop.test.", dnsmessage.TypeA),
+			dropped:  true,
+		},
+		{
+			name:      "truncate over UDP",
+			question:  question("truncate.test.", dnsmessage.TypeA),
+			truncated: true,
+		},
+		{
+			name:     "rotate IPs for A query",
+			question: question("rotate.test.", dnsmessage.TypeA),
+			// Queries of this test are the only ones using the rule.
+			expectedIPs: []string{"10.0.0.1"},
+			expectedTTL: 30,
+		},
+		{
+			name:        "rotate IPs does not apply to other query types",
+			question:    question("rotate.test.", dnsmessage.TypeTXT),
+			expectedIPs: []string{"192.0.2.1"},
+		},
+		{
+			name:     "first matching rule applies",
+			question: question("www.rotate.test.", dnsmessage.TypeA),
+			rcode:    dnsmessage.RCodeNameError,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			resp := proxy.handleQuery(packQuery(t, test.question), test.overTCP)
+			if test.dropped {
+				if resp != nil {
+					t.Errorf("expected query to be dropped")
+				}
+				return
+			}
+			var msg dnsmessage.Message
+			if err := msg.Unpack(resp); err != nil {
+				t.Fatalf("failed to unpack response: %v", err)
+			}
+			if msg.ID != 4242 || !msg.Response {
+				t.Errorf("unexpected response header: %+v", msg.Header)
+			}
+			if msg.RCode != test.rcode {
+				t.Errorf("rcode = %v, want %v", msg.RCode, test.rcode)
+			}
+			if msg.Truncated != test.truncated {
+				t.Errorf("truncated = %t, want %t", msg.Truncated, test.truncated)
+			}
+			var ips []string
+			for _, answer := range msg.Answers {
+				if a, ok := answer.Body.(*dnsmessage.AResource); ok {
+					ips = append(ips, net.IP(a.A[:]).String())
+					if answer.Header.TTL != test.expectedTTL {
+						t.Errorf("TTL = %d, want %d", answer.Header.TTL, test.expectedTTL)
+					}
+				}
+			}
+			if len(ips) != len(test.expectedIPs) {
+				t.Fatalf("answered IPs %v, want %v", ips, test.expectedIPs)
+			}
+			for i := range ips {
+				if ips[i] != test.expectedIPs[i] {
+					t.Errorf("answered IPs %v, want %v", ips, test.expectedIPs)
+				}
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/lf-edge/eden/sdn/vm/api"
 	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
 	"github.com/lf-edge/eden/sdn/vm/pkg/maclookup"
+	"github.com/lf-edge/eden/sdn/vm/pkg/netmodel"
 	dg "github.com/lf-edge/eve/libs/depgraph"
 	"github.com/lf-edge/eve/libs/reconciler"
 	log "github.com/sirupsen/logrus"
@@ -27,17 +28,17 @@ const (
 	hostPortLogicalLabel = "Host-Port"
 )
 
-// suffix is randomly generated by Eden
-var hostPortMACPrefix = net.HardwareAddr{0x08, 0x33, 0x33}
-
 type agent struct {
 	sync.Mutex
 	ctx       context.Context
 	macLookup *maclookup.MacLookup
 
+	// IP of the host port is not assigned by DHCP (SDN running in a container).
+	staticHostIP bool
+
 	// Network model
-	netModel    parsedNetModel
-	newNetModel chan parsedNetModel
+	netModel    netmodel.ParsedNetModel
+	newNetModel chan netmodel.ParsedNetModel
 
 	// Configuration state
 	currentState  dg.Graph
@@ -46,6 +47,14 @@ type agent struct {
 	failingItems  map[dg.ItemRef]error
 	networkIndex  map[string]int // key: network logical label
 
+	// Impairment scenarios
+	scenarios     map[string]*scenarioState // key: port logical label
+	scenarioTimer *time.Timer
+	scenarioTick  <-chan time.Time // nil if no scenario is running
+
+	// Packet captures
+	captures map[string]*capture // key: logical label of the captured item
+
 	// Asynchronous operations
 	resumeReconciliation <-chan string      // nil if no async ops
 	cancelAsyncOps       context.CancelFunc // nil if no async ops
@@ -62,12 +71,12 @@ func (a *agent) init() error {
 		return err
 	}
 	a.registry = registry
-	a.newNetModel = make(chan parsedNetModel, 10)
+	a.newNetModel = make(chan netmodel.ParsedNetModel, 10)
 	a.failingItems = make(map[dg.ItemRef]error)
 	// Initially start with an empty network model.
 	// Ever-present config items will get created.
 	// (e.g. DHCP client for the interface connecting SDN with the host)
-	a.netModel = parsedNetModel{}
+	a.netModel = netmodel.ParsedNetModel{}
 	a.updateCurrentState()
 	a.updateIntendedState()
 	a.reconcile()
@@ -82,11 +91,20 @@ func (a *agent) run(linkChan chan netlink.LinkUpdate) {
 			// Network model is already validated, applying...
 			a.Lock()
 			a.netModel = netModel
+			a.restartScenarios()
 			a.updateCurrentState()
 			a.updateIntendedState()
 			a.reconcile()
 			a.Unlock()
 
+		case <-a.scenarioTick:
+			a.Lock()
+			if a.advanceScenarios() {
+				a.updateIntendedState()
+				a.reconcile()
+			}
+			a.Unlock()
+
 		case <-a.resumeReconciliation:
 			a.Lock()
 			a.reconcile()
@@ -109,7 +127,7 @@ func (a *agent) run(linkChan chan netlink.LinkUpdate) {
 				a.macLookup.RefreshCache()
 				changed := a.updateCurrentState()
 				mac := linkUpdate.Attrs().HardwareAddr
-				if bytes.HasPrefix(mac, hostPortMACPrefix) {
+				if bytes.HasPrefix(mac, netmodel.HostPortMACPrefix) {
 					// Intended state for SDN<->Host connectivity changes
 					// when the "host port" (dis)appears.
 					a.updateIntendedState()
@@ -274,7 +292,7 @@ func (a *agent) applyNetModel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, errMsg, http.StatusBadRequest)
 		return
 	}
-	parsedNetModel, err := a.parseNetModel(netModel)
+	parsedNetModel, err := netmodel.Parse(netModel)
 	if err != nil {
 		errMsg := fmt.Sprintf("Network model is invalid: %v", err)
 		log.Error(errMsg)
@@ -315,6 +333,7 @@ func (a *agent) getSDNStatus(w http.ResponseWriter, r *http.Request) {
 			ErrMsg:  err.Error(),
 		})
 	}
+	status.Scenarios = a.getScenarioStatus()
 	a.Unlock()
 	resp, err := json.Marshal(status)
 	if err != nil {
@@ -331,7 +350,7 @@ func (a *agent) getSDNStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *agent) getMgmtIPs() (ips []string) {
-	hostNetIf, found := a.macLookup.GetInterfaceByMAC(hostPortMACPrefix, true)
+	hostNetIf, found := a.macLookup.GetInterfaceByMAC(netmodel.HostPortMACPrefix, true)
 	if !found {
 		log.Warnf("failed to find port connecting SDN with the host")
 		return
@@ -366,7 +385,7 @@ func (a *agent) getMgmtIPs() (ips []string) {
 
 // Gateway to use to route traffic towards host OS.
 func (a *agent) getHostGwIP(ipv6 bool) net.IP {
-	hostPort, found := a.macLookup.GetInterfaceByMAC(hostPortMACPrefix, true)
+	hostPort, found := a.macLookup.GetInterfaceByMAC(netmodel.HostPortMACPrefix, true)
 	if !found {
 		return nil
 	}
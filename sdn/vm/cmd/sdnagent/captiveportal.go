@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	log "github.com/sirupsen/logrus"
+)
+
+// getCaptivePortalRules returns iptables rules which redirect DNS, HTTP(S) and controller
+// traffic of clients not logged into the captive portal (running at portalIP)
+// into the portal and reject any other traffic of theirs.
+// Clients logged in are recorded inside CaptivePortalIPSet and pass through.
+func (a *agent) getCaptivePortalRules(brInIfName, portalIP string) (
+	dnat, forward []configitems.IptablesRule) {
+	notLoggedIn := []string{"-i", brInIfName, "-m", "set", "!", "--match-set",
+		configitems.CaptivePortalIPSet, "src"}
+	type capturedPort struct {
+		proto      string
+		port       uint16
+		portalPort uint16
+	}
+	capturedPorts := []capturedPort{
+		{proto: "udp", port: 53, portalPort: 53},
+		{proto: "tcp", port: 53, portalPort: 53},
+		{proto: "tcp", port: 80, portalPort: 80},
+		{proto: "tcp", port: 443, portalPort: 443},
+	}
+	controllerPort := a.netModel.Host.ControllerPort
+	if controllerPort != 443 && controllerPort != 80 {
+		// Controller traffic ends up with a TLS error, just like in a real
+		// network with a captive portal.
+		capturedPorts = append(capturedPorts,
+			capturedPort{proto: "tcp", port: controllerPort, portalPort: 443})
+	}
+	for _, captured := range capturedPorts {
+		args := append([]string{}, notLoggedIn...)
+		args = append(args, "-p", captured.proto, "--dport",
+			strconv.Itoa(int(captured.port)), "-j", "DNAT", "--to-destination",
+			fmt.Sprintf("%s:%d", portalIP, captured.portalPort))
+		dnat = append(dnat, configitems.IptablesRule{
+			Args: args,
+			Description: fmt.Sprintf("Redirect %s port %d of clients not logged in "+
+				"into the captive portal", captured.proto, captured.port),
+		})
+	}
+	// Reject any other traffic from clients not logged in.
+	fwdArgs := append([]string{}, notLoggedIn...)
+	fwdArgs = append(fwdArgs, "!", "-d", portalIP, "-j", "REJECT")
+	forward = append(forward, configitems.IptablesRule{
+		Args:        fwdArgs,
+		Description: "Reject traffic of clients not logged into the captive portal",
+	})
+	return dnat, forward
+}
+
+// getCaptivePortalNetwork returns network with the given label, which must have
+// captive portal enabled.
+func (a *agent) getCaptivePortalNetwork(label string) (api.Network, error) {
+	item := a.netModel.Items.GetItem(api.Network{}.ItemType(), label)
+	if item == nil {
+		return api.Network{}, fmt.Errorf("no network with logical label %s", label)
+	}
+	network := item.LabeledItem.(api.Network)
+	if network.CaptivePortal == "" {
+		return api.Network{}, fmt.Errorf("network %s does not have captive portal", label)
+	}
+	return network, nil
+}
+
+func (a *agent) getCaptivePortalStatus(network api.Network) api.CaptivePortalStatus {
+	status := api.CaptivePortalStatus{
+		Network: network.LogicalLabel,
+		Portal:  network.CaptivePortal,
+	}
+	loggedIn, err := configitems.ListIPSetEntries(
+		a.networkNsName(network.LogicalLabel), configitems.CaptivePortalIPSet)
+	if err != nil {
+		status.Error = err.Error()
+	}
+	status.LoggedIn = loggedIn
+	return status
+}
+
+// captivePortalLogin logs client in (or out) by adding (or removing) its IP address
+// into (from) the IP set of the network.
+// Without client IP, the entire network subnet is logged in (or all clients are
+// logged out).
+func (a *agent) captivePortalLogin(label string, req api.CaptivePortalLogin,
+	login bool) (api.CaptivePortalStatus, error) {
+	a.Lock()
+	defer a.Unlock()
+	network, err := a.getCaptivePortalNetwork(label)
+	if err != nil {
+		return api.CaptivePortalStatus{}, err
+	}
+	nsName := a.networkNsName(label)
+	entry := network.Subnet
+	if req.ClientIP != "" {
+		ip := net.ParseIP(req.ClientIP)
+		if ip == nil {
+			return api.CaptivePortalStatus{}, fmt.Errorf("invalid client IP: %s",
+				req.ClientIP)
+		}
+		entry = ip.String()
+	}
+	switch {
+	case login:
+		err = configitems.AddIPSetEntry(nsName, configitems.CaptivePortalIPSet, entry)
+	case req.ClientIP != "":
+		err = configitems.DelIPSetEntry(nsName, configitems.CaptivePortalIPSet, entry)
+	default:
+		err = configitems.FlushIPSet(nsName, configitems.CaptivePortalIPSet)
+	}
+	if err != nil {
+		return api.CaptivePortalStatus{}, err
+	}
+	return a.getCaptivePortalStatus(network), nil
+}
+
+func (a *agent) listCaptivePortals(w http.ResponseWriter, r *http.Request) {
+	statuses := []api.CaptivePortalStatus{}
+	a.Lock()
+	for _, network := range a.netModel.Networks {
+		if network.CaptivePortal != "" {
+			statuses = append(statuses, a.getCaptivePortalStatus(network))
+		}
+	}
+	a.Unlock()
+	a.writeCaptivePortalResp(w, statuses)
+}
+
+func (a *agent) loginCaptivePortal(w http.ResponseWriter, r *http.Request) {
+	a.handleCaptivePortalLogin(w, r, true)
+}
+
+func (a *agent) logoutCaptivePortal(w http.ResponseWriter, r *http.Request) {
+	a.handleCaptivePortalLogin(w, r, false)
+}
+
+func (a *agent) handleCaptivePortalLogin(w http.ResponseWriter, r *http.Request,
+	login bool) {
+	label := mux.Vars(r)["network"]
+	var req api.CaptivePortalLogin
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to read captive portal request from HTTP request: %v",
+			err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &req); err != nil {
+			errMsg := fmt.Sprintf("Failed to unmarshal captive portal request from JSON: %v",
+				err)
+			log.Error(errMsg)
+			http.Error(w, errMsg, http.StatusBadRequest)
+			return
+		}
+	}
+	status, err := a.captivePortalLogin(label, req, login)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to update captive portal clients of network %s: %v",
+			label, err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	a.writeCaptivePortalResp(w, status)
+}
+
+func (a *agent) writeCaptivePortalResp(w http.ResponseWriter, obj interface{}) {
+	resp, err := json.Marshal(obj)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to marshal captive portal status to JSON: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(resp); err != nil {
+		log.Errorf("Failed to write captive portal status to HTTP response: %v", err)
+	}
+}
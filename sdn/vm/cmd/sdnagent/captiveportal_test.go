@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+)
+
+func TestGetCaptivePortalRules(t *testing.T) {
+	t.Parallel()
+
+	notLoggedIn := []string{"-i", "br-in", "-m", "set", "!", "--match-set",
+		configitems.CaptivePortalIPSet, "src"}
+	redirect := func(proto, port, portalPort string) []string {
+		args := append([]string{}, notLoggedIn...)
+		return append(args, "-p", proto, "--dport", port, "-j", "DNAT",
+			"--to-destination", "10.10.10.10:"+portalPort)
+	}
+	reject := append(append([]string{}, notLoggedIn...),
+		"!", "-d", "10.10.10.10", "-j", "REJECT")
+
+	tests := []struct {
+		name           string
+		controllerPort uint16
+		dnat           [][]string
+	}{
+		{
+			name:           "controller on custom port",
+			controllerPort: 3333,
+			dnat: [][]string{
+				redirect("udp", "53", "53"),
+				redirect("tcp", "53", "53"),
+				redirect("tcp", "80", "80"),
+				redirect("tcp", "443", "443"),
+				redirect("tcp", "3333", "443"),
+			},
+		},
+		{
+			name:           "controller on HTTPS port",
+			controllerPort: 443,
+			dnat: [][]string{
+				redirect("udp", "53", "53"),
+				redirect("tcp", "53", "53"),
+				redirect("tcp", "80", "80"),
+				redirect("tcp", "443", "443"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := &agent{}
+			a.netModel.Host = &api.HostConfig{ControllerPort: tt.controllerPort}
+			dnat, forward := a.getCaptivePortalRules("br-in", "10.10.10.10")
+			// Every rule matches only clients not in the IP set, so traffic
+			// of logged-in clients passes through.
+			var dnatArgs [][]string
+			for _, rule := range dnat {
+				dnatArgs = append(dnatArgs, rule.Args)
+			}
+			if !reflect.DeepEqual(dnatArgs, tt.dnat) {
+				t.Errorf("DNAT rules = %v, expected %v", dnatArgs, tt.dnat)
+			}
+			if len(forward) != 1 || !reflect.DeepEqual(forward[0].Args, reject) {
+				t.Errorf("forward rules = %v, expected [%v]", forward, reject)
+			}
+		})
+	}
+}
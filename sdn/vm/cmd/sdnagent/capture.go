@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Directory where packet captures are stored.
+	captureDir = "/run/captures"
+	// Used when CaptureRequest.MaxSize is not defined.
+	defaultCaptureMaxSize = 32 << 20
+	// Size of the pcap global header and of the per-packet record header.
+	pcapGlobalHdrLen = 24
+	pcapRecordHdrLen = 16
+)
+
+// capture : packet capture running (or finished) for a labeled item.
+type capture struct {
+	sync.Mutex
+	status  api.CaptureStatus
+	cmd     *exec.Cmd
+	stderr  bytes.Buffer
+	stopped bool          // stop was requested
+	done    chan struct{} // closed when tcpdump has exited and the file is closed
+}
+
+func (c *capture) getStatus() api.CaptureStatus {
+	c.Lock()
+	defer c.Unlock()
+	return c.status
+}
+
+func capturePath(label string) string {
+	return filepath.Join(captureDir, label+".pcap")
+}
+
+// resolveCaptureTarget returns type, network namespace and interface name
+// of the item with the given logical label.
+// Called with agent in locked state.
+func (a *agent) resolveCaptureTarget(label string) (typename, netNs, ifName string, err error) {
+	var found []string
+	for _, t := range []string{api.Port{}.ItemType(), api.Bond{}.ItemType(),
+		api.Bridge{}.ItemType(), api.Network{}.ItemType(), api.Endpoint{}.ItemType()} {
+		if a.netModel.Items.GetItem(t, label) != nil {
+			found = append(found, t)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", "", "", fmt.Errorf("no port, bond, bridge, network or endpoint "+
+			"with logical label %s", label)
+	case 1:
+		typename = found[0]
+	default:
+		return "", "", "", fmt.Errorf("logical label %s is ambiguous (used by: %v)",
+			label, found)
+	}
+	netNs = configitems.MainNsName
+	switch typename {
+	case api.Port{}.ItemType():
+		port := a.netModel.Items.GetItem(typename, label).LabeledItem.(api.Port)
+		// MAC address is already validated
+		mac, _ := net.ParseMAC(port.MAC)
+		netIf, found := a.macLookup.GetInterfaceByMAC(mac, false)
+		if !found {
+			return "", "", "", fmt.Errorf("missing interface for port %s (MAC: %s)",
+				label, port.MAC)
+		}
+		ifName = netIf.IfName
+	case api.Bond{}.ItemType():
+		ifName = a.bondIfName(label)
+	case api.Bridge{}.ItemType():
+		ifName = a.bridgeIfName(label)
+	case api.Network{}.ItemType():
+		// Capture on the network side of the veth connecting network with its bridge.
+		netNs = a.networkNsName(label)
+		_, ifName, _ = a.networkBrVethName(label)
+	case api.Endpoint{}.ItemType():
+		netNs = a.endpointNsName(label)
+		_, ifName, _ = a.endpointVethName(label)
+	}
+	return typename, netNs, ifName, nil
+}
+
+// startCapture starts tcpdump capturing packets into a file until stopped
+// or until the size limit is reached.
+func (a *agent) startCapture(label string, req api.CaptureRequest) (api.CaptureStatus, error) {
+	a.Lock()
+	defer a.Unlock()
+	if c := a.captures[label]; c != nil && c.getStatus().Running {
+		return api.CaptureStatus{}, fmt.Errorf("capture for %s is already running", label)
+	}
+	typename, netNs, ifName, err := a.resolveCaptureTarget(label)
+	if err != nil {
+		return api.CaptureStatus{}, err
+	}
+	if req.MaxSize == 0 {
+		req.MaxSize = defaultCaptureMaxSize
+	}
+	if err = os.MkdirAll(captureDir, 0755); err != nil {
+		return api.CaptureStatus{}, err
+	}
+	file, err := os.Create(capturePath(label))
+	if err != nil {
+		return api.CaptureStatus{}, err
+	}
+	// Packets are written to stdout unbuffered and copied to the file by the agent,
+	// which enforces the size limit.
+	args := []string{"-i", ifName, "-n", "-U", "-w", "-"}
+	if req.Filter != "" {
+		args = append(args, req.Filter)
+	}
+	c := &capture{
+		status: api.CaptureStatus{
+			Target:       label,
+			TargetType:   typename,
+			Interface:    ifName,
+			NetNamespace: netNs,
+			Filter:       req.Filter,
+			MaxSize:      req.MaxSize,
+			StartedAt:    time.Now(),
+			Running:      true,
+		},
+		done: make(chan struct{}),
+	}
+	if netNs == configitems.MainNsName {
+		c.cmd = exec.Command("tcpdump", args...)
+	} else {
+		c.cmd = exec.Command("ip", append([]string{"netns", "exec", netNs, "tcpdump"},
+			args...)...)
+	}
+	c.cmd.Stderr = &c.stderr
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		file.Close()
+		return api.CaptureStatus{}, err
+	}
+	if err = c.cmd.Start(); err != nil {
+		file.Close()
+		return api.CaptureStatus{}, fmt.Errorf("failed to start tcpdump: %w", err)
+	}
+	if a.captures == nil {
+		a.captures = make(map[string]*capture)
+	}
+	a.captures[label] = c
+	go c.run(stdout, file)
+	log.Infof("Started packet capture for %s %s (netns: %s, interface: %s, filter: %q)",
+		typename, label, netNs, ifName, req.Filter)
+	return c.getStatus(), nil
+}
+
+// run copies packets from tcpdump to the capture file, packet by packet,
+// so that the file remains a valid pcap even when the size limit is hit.
+func (c *capture) run(stdout io.ReadCloser, file *os.File) {
+	copyErr := c.copyPackets(stdout, file)
+	if copyErr != nil {
+		// Size limit reached or broken output, stop tcpdump.
+		_ = c.cmd.Process.Kill()
+	}
+	// Drain whatever is left so that tcpdump is not blocked on write.
+	_, _ = io.Copy(io.Discard, stdout)
+	waitErr := c.cmd.Wait()
+	file.Close()
+	c.Lock()
+	c.status.Running = false
+	switch {
+	case c.stopped:
+	case errors.Is(copyErr, errCaptureSizeLimit):
+		log.Infof("Packet capture for %s reached the size limit", c.status.Target)
+	case copyErr != nil:
+		c.status.Error = copyErr.Error()
+	case waitErr != nil:
+		c.status.Error = fmt.Sprintf("tcpdump failed: %v (%s)", waitErr,
+			bytes.TrimSpace(c.stderr.Bytes()))
+	}
+	if c.status.Error != "" {
+		log.Errorf("Packet capture for %s failed: %s", c.status.Target, c.status.Error)
+	}
+	c.Unlock()
+	close(c.done)
+}
+
+var errCaptureSizeLimit = errors.New("capture size limit reached")
+
+func (c *capture) copyPackets(src io.Reader, dst io.Writer) error {
+	globalHdr := make([]byte, pcapGlobalHdrLen)
+	if _, err := io.ReadFull(src, globalHdr); err != nil {
+		if errors.Is(err, io.EOF) {
+			// tcpdump exited without producing any output.
+			return nil
+		}
+		return fmt.Errorf("failed to read pcap header: %w", err)
+	}
+	var byteOrder binary.ByteOrder
+	switch binary.LittleEndian.Uint32(globalHdr) {
+	case 0xa1b2c3d4, 0xa1b23c4d:
+		byteOrder = binary.LittleEndian
+	case 0xd4c3b2a1, 0x4d3cb2a1:
+		byteOrder = binary.BigEndian
+	default:
+		return fmt.Errorf("unexpected pcap magic number: %x", globalHdr[:4])
+	}
+	if err := c.write(dst, globalHdr, false); err != nil {
+		return err
+	}
+	recordHdr := make([]byte, pcapRecordHdrLen)
+	for {
+		if _, err := io.ReadFull(src, recordHdr); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read pcap record: %w", err)
+		}
+		record := make([]byte, pcapRecordHdrLen+int(byteOrder.Uint32(recordHdr[8:12])))
+		copy(record, recordHdr)
+		if _, err := io.ReadFull(src, record[pcapRecordHdrLen:]); err != nil {
+			return fmt.Errorf("failed to read pcap record: %w", err)
+		}
+		if err := c.write(dst, record, true); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *capture) write(dst io.Writer, data []byte, packet bool) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.status.Size+uint64(len(data)) > c.status.MaxSize {
+		return errCaptureSizeLimit
+	}
+	if _, err := dst.Write(data); err != nil {
+		return fmt.Errorf("failed to write capture file: %w", err)
+	}
+	c.status.Size += uint64(len(data))
+	if packet {
+		c.status.Packets++
+	}
+	return nil
+}
+
+// stopCapture stops capture (if still running) and returns the final status.
+func (a *agent) stopCapture(label string) (api.CaptureStatus, error) {
+	a.Lock()
+	c := a.captures[label]
+	a.Unlock()
+	if c == nil {
+		return api.CaptureStatus{}, fmt.Errorf("no capture for %s", label)
+	}
+	c.Lock()
+	if c.status.Running {
+		c.stopped = true
+		// tcpdump flushes its output on SIGINT.
+		_ = c.cmd.Process.Signal(os.Interrupt)
+	}
+	c.Unlock()
+	select {
+	case <-c.done:
+	case <-time.After(10 * time.Second):
+		_ = c.cmd.Process.Kill()
+		<-c.done
+	}
+	log.Infof("Stopped packet capture for %s", label)
+	return c.getStatus(), nil
+}
+
+func (a *agent) listCaptures(w http.ResponseWriter, r *http.Request) {
+	statuses := []api.CaptureStatus{}
+	a.Lock()
+	for _, c := range a.captures {
+		statuses = append(statuses, c.getStatus())
+	}
+	a.Unlock()
+	a.writeCaptureResp(w, statuses)
+}
+
+func (a *agent) putCapture(w http.ResponseWriter, r *http.Request) {
+	label := mux.Vars(r)["label"]
+	var req api.CaptureRequest
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to read capture request from HTTP request: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &req); err != nil {
+			errMsg := fmt.Sprintf("Failed to unmarshal capture request from JSON: %v", err)
+			log.Error(errMsg)
+			http.Error(w, errMsg, http.StatusBadRequest)
+			return
+		}
+	}
+	status, err := a.startCapture(label, req)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to start capture for %s: %v", label, err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	a.writeCaptureResp(w, status)
+}
+
+func (a *agent) deleteCapture(w http.ResponseWriter, r *http.Request) {
+	label := mux.Vars(r)["label"]
+	status, err := a.stopCapture(label)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	a.writeCaptureResp(w, status)
+}
+
+func (a *agent) getCapture(w http.ResponseWriter, r *http.Request) {
+	label := mux.Vars(r)["label"]
+	a.Lock()
+	c := a.captures[label]
+	a.Unlock()
+	if c == nil {
+		http.Error(w, fmt.Sprintf("no capture for %s", label), http.StatusNotFound)
+		return
+	}
+	file, err := os.Open(capturePath(label))
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to open capture file: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	// Capture may still be running. Serve only complete packets written so far.
+	status := c.getStatus()
+	w.Header().Set("Content-Type", "application/vnd.tcpdump.pcap")
+	http.ServeContent(w, r, label+".pcap", status.StartedAt,
+		io.NewSectionReader(file, 0, int64(status.Size)))
+}
+
+func (a *agent) writeCaptureResp(w http.ResponseWriter, obj interface{}) {
+	resp, err := json.Marshal(obj)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to marshal capture status to JSON: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(resp); err != nil {
+		log.Errorf("Failed to write capture status to HTTP response: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// pcapStream builds tcpdump output with the given packets.
+func pcapStream(byteOrder binary.ByteOrder, packets ...[]byte) []byte {
+	var buf bytes.Buffer
+	hdr := make([]byte, pcapGlobalHdrLen)
+	byteOrder.PutUint32(hdr[0:4], 0xa1b2c3d4)
+	byteOrder.PutUint16(hdr[4:6], 2)
+	byteOrder.PutUint16(hdr[6:8], 4)
+	byteOrder.PutUint32(hdr[16:20], 262144)
+	byteOrder.PutUint32(hdr[20:24], 1) // Ethernet
+	buf.Write(hdr)
+	for i, packet := range packets {
+		record := make([]byte, pcapRecordHdrLen)
+		byteOrder.PutUint32(record[0:4], uint32(1700000000+i))
+		byteOrder.PutUint32(record[8:12], uint32(len(packet)))
+		byteOrder.PutUint32(record[12:16], uint32(len(packet)))
+		buf.Write(record)
+		buf.Write(packet)
+	}
+	return buf.Bytes()
+}
+
+func TestCopyPackets(t *testing.T) {
+	t.Parallel()
+
+	packet1 := bytes.Repeat([]byte{0x11}, 60)
+	packet2 := bytes.Repeat([]byte{0x22}, 100)
+	record1Len := pcapRecordHdrLen + len(packet1)
+	record2Len := pcapRecordHdrLen + len(packet2)
+	le := pcapStream(binary.LittleEndian, packet1, packet2)
+	be := pcapStream(binary.BigEndian, packet1, packet2)
+
+	tests := []struct {
+		name        string
+		input       []byte
+		maxSize     uint64
+		expectedErr string
+		output      []byte
+		packets     uint64
+	}{
+		{
+			name:    "no output",
+			input:   nil,
+			maxSize: 1 << 20,
+			output:  nil,
+		},
+		{
+			name:    "little endian",
+			input:   le,
+			maxSize: 1 << 20,
+			output:  le,
+			packets: 2,
+		},
+		{
+			name:    "big endian",
+			input:   be,
+			maxSize: 1 << 20,
+			output:  be,
+			packets: 2,
+		},
+		{
+			name:    "exactly at size limit",
+			input:   le,
+			maxSize: uint64(len(le)),
+			output:  le,
+			packets: 2,
+		},
+		{
+			name:        "stop at size limit",
+			input:       le,
+			maxSize:     uint64(pcapGlobalHdrLen + record1Len + record2Len - 1),
+			expectedErr: errCaptureSizeLimit.Error(),
+			output:      le[:pcapGlobalHdrLen+record1Len],
+			packets:     1,
+		},
+		{
+			name:        "size limit below global header",
+			input:       le,
+			maxSize:     pcapGlobalHdrLen - 1,
+			expectedErr: errCaptureSizeLimit.Error(),
+			output:      nil,
+		},
+		{
+			name:        "unexpected magic number",
+			input:       append([]byte{1, 2, 3, 4}, le[4:]...),
+			maxSize:     1 << 20,
+			expectedErr: "unexpected pcap magic number",
+			output:      nil,
+		},
+		{
+			name:        "truncated global header",
+			input:       le[:10],
+			maxSize:     1 << 20,
+			expectedErr: "failed to read pcap header",
+			output:      nil,
+		},
+		{
+			name:        "truncated packet",
+			input:       le[:len(le)-1],
+			maxSize:     1 << 20,
+			expectedErr: "failed to read pcap record",
+			output:      le[:pcapGlobalHdrLen+record1Len],
+			packets:     1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &capture{}
+			c.status.MaxSize = tt.maxSize
+			var dst bytes.Buffer
+			err := c.copyPackets(bytes.NewReader(tt.input), &dst)
+			switch {
+			case tt.expectedErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedErr)):
+				t.Fatalf("expected error %q, got: %v", tt.expectedErr, err)
+			}
+			if tt.expectedErr == errCaptureSizeLimit.Error() && !errors.Is(err, errCaptureSizeLimit) {
+				t.Errorf("expected errCaptureSizeLimit, got: %v", err)
+			}
+			if !bytes.Equal(dst.Bytes(), tt.output) {
+				t.Errorf("written %d bytes, expected %d bytes", dst.Len(), len(tt.output))
+			}
+			if c.status.Size != uint64(len(tt.output)) {
+				t.Errorf("size %d, expected %d", c.status.Size, len(tt.output))
+			}
+			if c.status.Packets != tt.packets {
+				t.Errorf("packets %d, expected %d", c.status.Packets, tt.packets)
+			}
+		})
+	}
+}
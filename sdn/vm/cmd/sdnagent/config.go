@@ -7,9 +7,12 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/lf-edge/eden/sdn/vm/api"
+	portalcfg "github.com/lf-edge/eden/sdn/vm/cmd/captiveportal/config"
 	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	"github.com/lf-edge/eden/sdn/vm/pkg/netmodel"
 	dg "github.com/lf-edge/eve/libs/depgraph"
 	"github.com/lf-edge/eve/libs/reconciler"
 	log "github.com/sirupsen/logrus"
@@ -54,7 +57,7 @@ func (a *agent) updateCurrentState() (changed bool) {
 	}
 	currentPhysIfs := dg.New(dg.InitArgs{Name: physicalIfsSG})
 	// Port connecting SDN VM with the host.
-	if netIf, found := a.macLookup.GetInterfaceByMAC(hostPortMACPrefix, true); found {
+	if netIf, found := a.macLookup.GetInterfaceByMAC(netmodel.HostPortMACPrefix, true); found {
 		currentPhysIfs.PutItem(configitems.PhysIf{
 			LogicalLabel: hostPortLogicalLabel,
 			MAC:          netIf.MAC,
@@ -114,6 +117,15 @@ func (a *agent) updateIntendedState() {
 	for _, httpSrv := range a.netModel.Endpoints.HTTPServers {
 		a.intendedState.PutSubGraph(a.getIntendedHttpSrvEp(httpSrv))
 	}
+	for _, wgSrv := range a.netModel.Endpoints.WireGuardServers {
+		a.intendedState.PutSubGraph(a.getIntendedWireGuardSrvEp(wgSrv))
+	}
+	for _, portal := range a.netModel.Endpoints.CaptivePortals {
+		a.intendedState.PutSubGraph(a.getIntendedCaptivePortalEp(portal))
+	}
+	for _, syslogSrv := range a.netModel.Endpoints.SyslogServers {
+		a.intendedState.PutSubGraph(a.getIntendedSyslogSrvEp(syslogSrv))
+	}
 
 	// TODO (ntp servers, netboot servers)
 }
@@ -121,7 +133,7 @@ func (a *agent) updateIntendedState() {
 func (a *agent) getIntendedPhysIfs() dg.Graph {
 	graphArgs := dg.InitArgs{Name: physicalIfsSG}
 	intendedCfg := dg.New(graphArgs)
-	if netIf, found := a.macLookup.GetInterfaceByMAC(hostPortMACPrefix, true); found {
+	if netIf, found := a.macLookup.GetInterfaceByMAC(netmodel.HostPortMACPrefix, true); found {
 		intendedCfg.PutItem(configitems.PhysIf{
 			LogicalLabel: hostPortLogicalLabel,
 			MAC:          netIf.MAC,
@@ -141,7 +153,7 @@ func (a *agent) getIntendedPhysIfs() dg.Graph {
 func (a *agent) getIntendedHostConnectivity() dg.Graph {
 	graphArgs := dg.InitArgs{Name: hostConnectivitySG}
 	intendedCfg := dg.New(graphArgs)
-	netIf, found := a.macLookup.GetInterfaceByMAC(hostPortMACPrefix, true)
+	netIf, found := a.macLookup.GetInterfaceByMAC(netmodel.HostPortMACPrefix, true)
 	if !found {
 		// Without interface connecting SDN with the host it is clearly
 		// not possible to establish host connectivity.
@@ -157,21 +169,29 @@ func (a *agent) getIntendedHostConnectivity() dg.Graph {
 		},
 		Usage:   configitems.IfUsageL3,
 		AdminUP: true,
-		MTU:     maxMTU,
+		MTU:     netmodel.MaxMTU,
 	}, nil)
-	intendedCfg.PutItem(configitems.Sysctl{
+	sysctl := configitems.Sysctl{
 		EnableIPv4Forwarding:  true,
 		EnableIPv6Forwarding:  true,
 		BridgeNfCallIptables:  false,
 		BridgeNfCallIp6tables: false,
-	}, nil)
-	intendedCfg.PutItem(configitems.DhcpClient{
-		PhysIf: configitems.PhysIf{
-			MAC:          netIf.MAC,
-			LogicalLabel: hostPortLogicalLabel,
-		},
-		LogFile: "/run/dhcpcd.log",
-	}, nil)
+	}
+	if connTrack := a.netModel.Firewall.ConnTrack; connTrack != nil {
+		sysctl.ConntrackTCPEstablishedTimeout = connTrack.TCPEstablishedTimeout
+		sysctl.ConntrackUDPStreamTimeout = connTrack.UDPStreamTimeout
+		sysctl.ConntrackStrictTCP = connTrack.StrictTCP
+	}
+	intendedCfg.PutItem(sysctl, nil)
+	if !a.staticHostIP {
+		intendedCfg.PutItem(configitems.DhcpClient{
+			PhysIf: configitems.PhysIf{
+				MAC:          netIf.MAC,
+				LogicalLabel: hostPortLogicalLabel,
+			},
+			LogFile: "/run/dhcpcd.log",
+		}, nil)
+	}
 	intendedCfg.PutItem(configitems.IptablesChain{
 		ChainName: "POSTROUTING",
 		Table:     "nat",
@@ -183,6 +203,7 @@ func (a *agent) getIntendedHostConnectivity() dg.Graph {
 			},
 		},
 	}, nil)
+	a.putIntendedNAT64(intendedCfg)
 	return intendedCfg
 }
 
@@ -191,13 +212,14 @@ func (a *agent) getIntendedTrafficControl() dg.Graph {
 	intendedCfg := dg.New(graphArgs)
 	emptyTC := api.TrafficControl{}
 	for _, port := range a.netModel.Ports {
-		if port.TC == emptyTC {
+		tc := a.portTC(port)
+		if tc == emptyTC {
 			continue
 		}
 		// MAC address is already validated
 		mac, _ := net.ParseMAC(port.MAC)
 		intendedCfg.PutItem(configitems.TrafficControl{
-			TrafficControl: port.TC,
+			TrafficControl: tc,
 			PhysIf: configitems.PhysIf{
 				LogicalLabel: port.LogicalLabel,
 				MAC:          mac,
@@ -211,15 +233,15 @@ func (a *agent) getIntendedBridges() dg.Graph {
 	graphArgs := dg.InitArgs{Name: bridgesSG}
 	intendedCfg := dg.New(graphArgs)
 	for _, port := range a.netModel.Ports {
-		labeledItem := a.netModel.items.getItem(api.Port{}.ItemType(), port.LogicalLabel)
-		masterID, hasMaster := labeledItem.referencedBy[api.PortMasterRef]
+		labeledItem := a.netModel.Items.GetItem(api.Port{}.ItemType(), port.LogicalLabel)
+		masterID, hasMaster := labeledItem.ReferencedBy[api.PortMasterRef]
 		if !hasMaster {
 			// Port is not really used.
 			continue
 		}
 		mac, _ := net.ParseMAC(port.MAC) // already validated
 		var usage configitems.IfUsage
-		switch masterID.typename {
+		switch masterID.Typename {
 		case api.Bridge{}.ItemType():
 			usage = configitems.IfUsageBridged
 		case api.Bond{}.ItemType():
@@ -230,22 +252,22 @@ func (a *agent) getIntendedBridges() dg.Graph {
 				MAC:          mac,
 				LogicalLabel: port.LogicalLabel,
 			},
-			ParentLL: masterID.logicalLabel,
+			ParentLL: masterID.LogicalLabel,
 			Usage:    usage,
-			AdminUP:  port.AdminUP,
-			MTU:      maxMTU,
+			AdminUP:  a.portAdminUP(port),
+			MTU:      netmodel.MaxMTU,
 		}, nil)
 	}
 	for _, bond := range a.netModel.Bonds {
-		labeledItem := a.netModel.items.getItem(api.Bond{}.ItemType(), bond.LogicalLabel)
+		labeledItem := a.netModel.Items.GetItem(api.Bond{}.ItemType(), bond.LogicalLabel)
 		var aggrPhysIfs []configitems.PhysIf
-		for _, ref := range labeledItem.referencing {
-			if ref.refKey == api.PortMasterRef {
-				port := a.netModel.items[ref.itemID].LabeledItem
+		for _, ref := range labeledItem.Referencing {
+			if ref.RefKey == api.PortMasterRef {
+				port := a.netModel.Items[ref.ItemID].LabeledItem
 				mac, _ := net.ParseMAC(port.(api.Port).MAC)
 				aggrPhysIfs = append(aggrPhysIfs, configitems.PhysIf{
 					MAC:          mac,
-					LogicalLabel: ref.logicalLabel,
+					LogicalLabel: ref.LogicalLabel,
 				})
 			}
 		}
@@ -253,20 +275,20 @@ func (a *agent) getIntendedBridges() dg.Graph {
 			Bond:              bond,
 			IfName:            a.bondIfName(bond.LogicalLabel),
 			AggregatedPhysIfs: aggrPhysIfs,
-			MTU:               maxMTU,
+			MTU:               netmodel.MaxMTU,
 		}, nil)
 	}
 	for _, bridge := range a.netModel.Bridges {
 		vlans := make(map[uint16]struct{})
-		labeledItem := a.netModel.items.getItem(api.Bridge{}.ItemType(), bridge.LogicalLabel)
-		for refKey, refBy := range labeledItem.referencedBy {
+		labeledItem := a.netModel.Items.GetItem(api.Bridge{}.ItemType(), bridge.LogicalLabel)
+		for refKey, refBy := range labeledItem.ReferencedBy {
 			if strings.HasPrefix(refKey, api.NetworkBridgeRefPrefix) {
-				network := a.netModel.items[refBy].LabeledItem
+				network := a.netModel.Items[refBy].LabeledItem
 				if vlanID := network.(api.Network).VlanID; vlanID != 0 {
 					vlans[vlanID] = struct{}{}
 				}
 			} else if strings.HasPrefix(refKey, api.EndpointBridgeRefPrefix) {
-				endpoint := a.getEndpoint(refBy.logicalLabel)
+				endpoint := a.getEndpoint(refBy.LogicalLabel)
 				if vlanID := endpoint.DirectL2Connect.VlanID; vlanID != 0 {
 					vlans[vlanID] = struct{}{}
 				}
@@ -278,20 +300,20 @@ func (a *agent) getIntendedBridges() dg.Graph {
 		}
 		var physIfs []configitems.PhysIf
 		var bonds []string
-		for _, ref := range labeledItem.referencing {
-			if ref.refKey != api.PortMasterRef {
+		for _, ref := range labeledItem.Referencing {
+			if ref.RefKey != api.PortMasterRef {
 				continue
 			}
-			switch ref.typename {
+			switch ref.Typename {
 			case api.Port{}.ItemType():
-				port := a.netModel.items[ref.itemID].LabeledItem
+				port := a.netModel.Items[ref.ItemID].LabeledItem
 				mac, _ := net.ParseMAC(port.(api.Port).MAC)
 				physIfs = append(physIfs, configitems.PhysIf{
 					MAC:          mac,
-					LogicalLabel: ref.logicalLabel,
+					LogicalLabel: ref.LogicalLabel,
 				})
 			case api.Bond{}.ItemType():
-				bonds = append(bonds, a.bondIfName(ref.logicalLabel))
+				bonds = append(bonds, a.bondIfName(ref.LogicalLabel))
 			}
 		}
 		intendedCfg.PutItem(configitems.Bridge{
@@ -300,7 +322,7 @@ func (a *agent) getIntendedBridges() dg.Graph {
 			PhysIfs:      physIfs,
 			BondIfs:      bonds,
 			VLANs:        vlanList,
-			MTU:          maxMTU,
+			MTU:          netmodel.MaxMTU,
 			WithSTP:      bridge.WithSTP,
 		}, nil)
 	}
@@ -362,6 +384,14 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 			MTU:          network.MTU,
 		},
 	}, nil)
+	if isIPv6 {
+		// IPv6 forwarding is disabled by default in new network namespaces.
+		intendedCfg.PutItem(configitems.Sysctl{
+			NetNamespace:         nsName,
+			EnableIPv4Forwarding: true,
+			EnableIPv6Forwarding: true,
+		}, nil)
+	}
 
 	// DHCP server.
 	dhcp := network.DHCP
@@ -388,28 +418,42 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 		if dhcp.WithoutDefaultRoute {
 			gatewayIP = nil
 		}
-		var staticEntries []configitems.MACToIP
-		for _, entry := range dhcp.StaticEntries {
-			mac, _ := net.ParseMAC(entry.MAC)
-			staticEntries = append(staticEntries, configitems.MACToIP{
-				MAC: mac,
-				IP:  net.ParseIP(entry.IP),
+		opts := a.putIntendedNetworkNAT64(intendedCfg, network, gwIP.IP, inIP,
+			dhcpOptions{
+				dnsServers: dnsServers,
+				ntpServer:  ntpServer,
+				domainName: dhcp.DomainName,
+				gatewayIP:  gatewayIP,
 			})
+		if isIPv6 && network.IPv6 != nil {
+			a.putIntendedIPv6Provisioning(intendedCfg, network, opts)
+		} else {
+			var staticEntries []configitems.MACToIP
+			for _, entry := range dhcp.StaticEntries {
+				mac, _ := net.ParseMAC(entry.MAC)
+				staticEntries = append(staticEntries, configitems.MACToIP{
+					MAC: mac,
+					IP:  net.ParseIP(entry.IP),
+				})
+			}
+			intendedCfg.PutItem(configitems.DhcpServer{
+				ServerName:     network.LogicalLabel,
+				NetNamespace:   nsName,
+				VethName:       brVethName,
+				VethPeerIfName: brInIfName,
+				Subnet:         subnet,
+				IPRange:        ipRange,
+				StaticEntries:  staticEntries,
+				GatewayIP:      opts.gatewayIP,
+				DomainName:     opts.domainName,
+				DNSServers:     opts.dnsServers,
+				NTPServer:      opts.ntpServer,
+				WPAD:           network.DHCP.WPAD,
+			}, nil)
 		}
-		intendedCfg.PutItem(configitems.DhcpServer{
-			ServerName:     network.LogicalLabel,
-			NetNamespace:   nsName,
-			VethName:       brVethName,
-			VethPeerIfName: brInIfName,
-			Subnet:         subnet,
-			IPRange:        ipRange,
-			StaticEntries:  staticEntries,
-			GatewayIP:      gatewayIP,
-			DomainName:     dhcp.DomainName,
-			DNSServers:     dnsServers,
-			NTPServer:      ntpServer,
-			WPAD:           network.DHCP.WPAD,
-		}, nil)
+	} else {
+		// NAT64 without DNS64 (no DHCP means no DNS servers to proxy).
+		a.putIntendedNetworkNAT64(intendedCfg, network, gwIP.IP, inIP, dhcpOptions{})
 	}
 
 	// Routing.
@@ -424,6 +468,34 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 		Table:    rt,
 		Priority: networkIPRulePriority,
 	}, nil)
+	// - subnet into which the network is NATed
+	outsideSubnet := natOutsideSubnet(network)
+	if outsideSubnet != nil {
+		intendedCfg.PutItem(configitems.IPRule{
+			SrcNet:   outsideSubnet,
+			Table:    rt,
+			Priority: networkIPRulePriority,
+		}, nil)
+		intendedCfg.PutItem(configitems.IPRule{
+			DstNet:   outsideSubnet,
+			Table:    rt,
+			Priority: networkIPRulePriority,
+		}, nil)
+	}
+	// - prefix delegated to hosts (routes inside the network namespace are managed
+	//   by the DHCPv6 server)
+	if pdPrefix := ipv6PDPrefix(network); pdPrefix != nil {
+		intendedCfg.PutItem(configitems.IPRule{
+			SrcNet:   pdPrefix,
+			Table:    rt,
+			Priority: networkIPRulePriority,
+		}, nil)
+		intendedCfg.PutItem(configitems.IPRule{
+			DstNet:   pdPrefix,
+			Table:    rt,
+			Priority: networkIPRulePriority,
+		}, nil)
+	}
 	defaultDst := allIPv4
 	if isIPv6 {
 		defaultDst = allIPv6
@@ -439,21 +511,44 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 		},
 		GwIP: outIP.IP,
 	}, nil)
+	// - with chained NAT, traffic leaving the network is routed via the intermediate
+	//   network
+	var viaOutIf configitems.RouteOutIf
+	var viaGwIP net.IP
+	viaNetwork := a.natViaNetwork(network)
+	if viaNetwork != nil {
+		viaVethName, _, viaOutIfName := a.networkRtVethName(viaNetwork.LogicalLabel)
+		viaInIP, _ := a.genVethIPsForNetwork(viaNetwork.LogicalLabel, false)
+		viaOutIf = configitems.RouteOutIf{
+			VethName:       viaVethName,
+			VethPeerIfName: viaOutIfName,
+		}
+		viaGwIP = viaInIP.IP
+	}
 	// - route for every L3-connected endpoint
 	epTypename := api.Endpoint{}.ItemType()
-	for itemID, item := range a.netModel.items {
-		if itemID.typename != epTypename {
+	for itemID, item := range a.netModel.Items {
+		if itemID.Typename != epTypename {
 			continue
 		}
-		ep := a.labeledItemToEndpoint(item)
+		ep := item.ToEndpoint()
 		if ep.DirectL2Connect.Bridge != "" {
 			// This endpoint has direct L2 connection to EVE, skip.
 			continue
 		}
 		_, epSubnet, _ := net.ParseCIDR(ep.Subnet)
 		reachable := network.Router == nil ||
+			network.CaptivePortal == ep.LogicalLabel ||
 			strListContains(network.Router.ReachableEndpoints, ep.LogicalLabel)
-		if reachable {
+		if reachable && viaNetwork != nil {
+			intendedCfg.PutItem(configitems.Route{
+				NetNamespace: configitems.MainNsName,
+				Table:        rt,
+				DstNet:       epSubnet,
+				OutputIf:     viaOutIf,
+				GwIP:         viaGwIP,
+			}, nil)
+		} else if reachable {
 			epVethName, _, epOutIfName := a.endpointVethName(ep.LogicalLabel)
 			intendedCfg.PutItem(configitems.Route{
 				NetNamespace: configitems.MainNsName,
@@ -476,35 +571,61 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 	// - route for every other network (including itself)
 	for _, network2 := range a.netModel.Networks {
 		_, net2Subnet, _ := net.ParseCIDR(network2.Subnet)
-		reachable := network.Router == nil ||
-			network2.LogicalLabel == network.LogicalLabel ||
+		isNet2IPv6 := len(net2Subnet.IP) == net.IPv6len
+		net2DstNets := []*net.IPNet{net2Subnet}
+		if pdPrefix := ipv6PDPrefix(network2); pdPrefix != nil {
+			net2DstNets = append(net2DstNets, pdPrefix)
+		}
+		if net2OutsideSubnet := natOutsideSubnet(network2); net2OutsideSubnet != nil {
+			net2DstNets = append(net2DstNets, net2OutsideSubnet)
+		}
+		isSelf := network2.LogicalLabel == network.LogicalLabel
+		reachable := network.Router == nil || isSelf ||
 			strListContains(network.Router.ReachableNetworks, network2.LogicalLabel)
-		if reachable {
-			net2VethName, _, net2OutIfName := a.networkRtVethName(network2.LogicalLabel)
-			net2InIP, _ := a.genVethIPsForNetwork(network2.LogicalLabel, isIPv6)
-			intendedCfg.PutItem(configitems.Route{
-				NetNamespace: configitems.MainNsName,
-				Table:        rt,
-				DstNet:       net2Subnet,
-				OutputIf: configitems.RouteOutIf{
-					VethName:       net2VethName,
-					VethPeerIfName: net2OutIfName,
-				},
-				GwIP: net2InIP.IP,
-			}, nil)
-		} else {
-			intendedCfg.PutItem(configitems.Route{
-				NetNamespace: configitems.MainNsName,
-				Table:        rt,
-				DstNet:       net2Subnet,
-			}, nil)
+		for _, net2DstNet := range net2DstNets {
+			if reachable && !isSelf && viaNetwork != nil {
+				intendedCfg.PutItem(configitems.Route{
+					NetNamespace: configitems.MainNsName,
+					Table:        rt,
+					DstNet:       net2DstNet,
+					OutputIf:     viaOutIf,
+					GwIP:         viaGwIP,
+				}, nil)
+			} else if reachable {
+				net2VethName, _, net2OutIfName := a.networkRtVethName(network2.LogicalLabel)
+				net2InIP, _ := a.genVethIPsForNetwork(network2.LogicalLabel, isNet2IPv6)
+				intendedCfg.PutItem(configitems.Route{
+					NetNamespace: configitems.MainNsName,
+					Table:        rt,
+					DstNet:       net2DstNet,
+					OutputIf: configitems.RouteOutIf{
+						VethName:       net2VethName,
+						VethPeerIfName: net2OutIfName,
+					},
+					GwIP: net2InIP.IP,
+				}, nil)
+			} else {
+				intendedCfg.PutItem(configitems.Route{
+					NetNamespace: configitems.MainNsName,
+					Table:        rt,
+					DstNet:       net2DstNet,
+				}, nil)
+			}
 		}
 	}
 	// - route for the outside world if enabled
 	outsideRechability := network.Router == nil || network.Router.OutsideReachability
-	hostPort, hostPortfound := a.macLookup.GetInterfaceByMAC(hostPortMACPrefix, true)
+	hostPort, hostPortfound := a.macLookup.GetInterfaceByMAC(netmodel.HostPortMACPrefix, true)
 	hostGwIP := a.getHostGwIP(isIPv6)
-	if outsideRechability && hostPortfound && hostGwIP != nil {
+	if outsideRechability && viaNetwork != nil {
+		intendedCfg.PutItem(configitems.Route{
+			NetNamespace: configitems.MainNsName,
+			Table:        rt,
+			DstNet:       defaultDst,
+			OutputIf:     viaOutIf,
+			GwIP:         viaGwIP,
+		}, nil)
+	} else if outsideRechability && hostPortfound && hostGwIP != nil {
 		intendedCfg.PutItem(configitems.Route{
 			NetNamespace: configitems.MainNsName,
 			Table:        rt,
@@ -565,6 +686,18 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 		Metric:       ^uint32(0), // Lowest prio.
 	}, nil)
 
+	// Captive portal.
+	var dnatRules, fwdRules []configitems.IptablesRule
+	if network.CaptivePortal != "" {
+		ep := a.getEndpoint(network.CaptivePortal)
+		intendedCfg.PutItem(configitems.IPSet{
+			NetNamespace: nsName,
+			SetName:      configitems.CaptivePortalIPSet,
+			SetType:      "hash:net",
+		}, nil)
+		dnatRules, fwdRules = a.getCaptivePortalRules(brInIfName, ep.IP)
+	}
+
 	// Transparent proxy.
 	if network.TransparentProxy != "" {
 		ep := a.getEndpoint(network.TransparentProxy)
@@ -574,13 +707,11 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 			httpsPorts = append(httpsPorts, api.ProxyPort{Port: controllerPort})
 		}
 		// iptables to transparently redirect traffic into the proxy
-		dnatRules := []configitems.IptablesRule{
-			{
-				Args: []string{"-p", "tcp", "--dport", "80", "-j", "DNAT",
-					"--to-destination", ep.IP},
-				Description: "Send HTTP traffic into the proxy",
-			},
-		}
+		dnatRules = append(dnatRules, configitems.IptablesRule{
+			Args: []string{"-p", "tcp", "--dport", "80", "-j", "DNAT",
+				"--to-destination", ep.IP},
+			Description: "Send HTTP traffic into the proxy",
+		})
 		for _, httpsPort := range httpsPorts {
 			dnatRules = append(dnatRules, configitems.IptablesRule{
 				Args: []string{"-p", "tcp", "--dport", strconv.Itoa(int(httpsPort.Port)),
@@ -589,32 +720,62 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 					httpsPort.Port),
 			})
 		}
+	}
+
+	// NAT.
+	var refersIPSets, refersVeths []string
+	if network.CaptivePortal != "" {
+		refersIPSets = []string{configitems.CaptivePortalIPSet}
+		refersVeths = []string{brVethName}
+	}
+	natRules := a.getNATRules(network, subnet, brInIfName, rtInIfName)
+	dnatRules = append(dnatRules, natRules.dnat...)
+	fwdRules = append(fwdRules, natRules.forward...)
+	if len(natRules.dnat) > 0 || len(natRules.forward) > 0 {
+		refersVeths = []string{brVethName, rtVethName}
+	}
+	if len(dnatRules) > 0 {
 		intendedCfg.PutItem(configitems.IptablesChain{
 			NetNamespace: nsName,
 			ChainName:    "PREROUTING",
 			Table:        "nat",
 			ForIPv6:      false,
+			RefersVeths:  refersVeths,
+			RefersIPSets: refersIPSets,
 			Rules:        dnatRules,
 		}, nil)
 	}
+	if len(fwdRules) > 0 {
+		intendedCfg.PutItem(configitems.IptablesChain{
+			NetNamespace: nsName,
+			ChainName:    "FORWARD",
+			Table:        "filter",
+			ForIPv6:      false,
+			RefersVeths:  refersVeths,
+			RefersIPSets: refersIPSets,
+			Rules:        fwdRules,
+		}, nil)
+	}
 
 	// When user is accessing EVE using "sdn fwd" command, the source IP
 	// is from the internal IP subnet.
 	// Make sure that the IP address is S-NATed before sending packets to EVE.
 	// Otherwise, the responses could be routed out via wrong EVE network ports.
+	snatRules := []configitems.IptablesRule{
+		{
+			Args: []string{"-o", brInIfName, "-s", internalIPv4Subnet.String(),
+				"-j", "MASQUERADE"},
+			Description: "S-NAT traffic leaving SDN VM towards EVE with internal source IP",
+		},
+	}
+	snatRules = append(snatRules, natRules.snat...)
 	intendedCfg.PutItem(configitems.IptablesChain{
 		NetNamespace: nsName,
 		ChainName:    "POSTROUTING",
 		Table:        "nat",
 		ForIPv6:      false,
 		RefersVeths:  []string{rtVethName},
-		Rules: []configitems.IptablesRule{
-			{
-				Args: []string{"-o", brInIfName, "-s", internalIPv4Subnet.String(),
-					"-j", "MASQUERADE"},
-				Description: "S-NAT traffic leaving SDN VM towards EVE with internal source IP",
-			},
-		},
+		Rules:        snatRules,
 	}, nil)
 	return intendedCfg
 }
@@ -624,16 +785,27 @@ func (a *agent) getIntendedFirewall() dg.Graph {
 	intendedCfg := dg.New(graphArgs)
 	iptablesRules := make([]configitems.IptablesRule, 0, 2+len(a.netModel.Firewall.Rules))
 	// Allow any subsequent traffic that results from an already allowed connection.
-	iptablesRules = append(iptablesRules, configitems.IptablesRule{
-		Args: []string{"-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
-	})
-	// Add explicitly configured firewall rules.
-	for _, rule := range a.netModel.Firewall.Rules {
-		iptablesRules = append(iptablesRules, a.getIntendedFwRule(rule))
+	allowEstablished := configitems.IptablesRule{
+		Args:        []string{"-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
+		Description: "Allow established and related traffic",
+	}
+	// Rules are applied in the configured order. Leading rules matching connection
+	// state are evaluated before the rule allowing established and related traffic,
+	// otherwise they would never match.
+	var addedAllowEstablished bool
+	for i, rule := range a.netModel.Firewall.Rules {
+		if len(rule.ConnState) == 0 && !addedAllowEstablished {
+			iptablesRules = append(iptablesRules, allowEstablished)
+			addedAllowEstablished = true
+		}
+		iptablesRules = append(iptablesRules, a.getIntendedFwRule(i, rule))
+	}
+	if !addedAllowEstablished {
+		iptablesRules = append(iptablesRules, allowEstablished)
 	}
 	// Implicitly allow everything not matched by the rules above.
 	allowTheRest := api.FwRule{Action: api.FwAllow}
-	iptablesRules = append(iptablesRules, a.getIntendedFwRule(allowTheRest))
+	iptablesRules = append(iptablesRules, a.getIntendedFwRule(-1, allowTheRest))
 	intendedCfg.PutItem(configitems.IptablesChain{
 		NetNamespace: configitems.MainNsName,
 		ChainName:    fwIptablesChain,
@@ -670,7 +842,10 @@ func (a *agent) getIntendedFirewall() dg.Graph {
 	return intendedCfg
 }
 
-func (a *agent) getIntendedFwRule(rule api.FwRule) configitems.IptablesRule {
+// getIntendedFwRule converts firewall rule into iptables rule.
+// Index is used to name rate-limiting hash tables and to describe the rule
+// (negative index is used for implicit rules).
+func (a *agent) getIntendedFwRule(index int, rule api.FwRule) configitems.IptablesRule {
 	var ruleArgs []string
 	if rule.SrcSubnet != "" {
 		ruleArgs = append(ruleArgs, "-s", rule.SrcSubnet)
@@ -696,6 +871,41 @@ func (a *agent) getIntendedFwRule(rule api.FwRule) configitems.IptablesRule {
 		ruleArgs = append(ruleArgs, "--match", "multiport",
 			"--dport", strings.Join(ports, ","))
 	}
+	if len(rule.ConnState) > 0 {
+		var states []string
+		for _, state := range rule.ConnState {
+			states = append(states, strings.ToUpper(string(state)))
+		}
+		ruleArgs = append(ruleArgs, "-m", "conntrack",
+			"--ctstate", strings.Join(states, ","))
+	}
+	if rule.Schedule != nil {
+		ruleArgs = append(ruleArgs, getFwScheduleArgs(*rule.Schedule)...)
+	}
+	if rule.ConnLimit != nil {
+		mask := "0"
+		if rule.ConnLimit.PerSource {
+			mask = "32"
+		}
+		ruleArgs = append(ruleArgs, "-m", "connlimit",
+			"--connlimit-above", strconv.Itoa(int(rule.ConnLimit.MaxConns)),
+			"--connlimit-mask", mask)
+	}
+	if rule.RateLimit != nil {
+		// Rate limit is checked last, so that only packets matched by all other
+		// criteria are counted.
+		rateLimit := rule.RateLimit
+		ruleArgs = append(ruleArgs, "-m", "hashlimit",
+			"--hashlimit-name", fmt.Sprintf("fw-rule-%d", index),
+			"--hashlimit-above", fmt.Sprintf("%d/%s", rateLimit.Rate, rateLimit.Unit))
+		if rateLimit.Burst != 0 {
+			ruleArgs = append(ruleArgs, "--hashlimit-burst",
+				strconv.Itoa(int(rateLimit.Burst)))
+		}
+		if rateLimit.PerSource {
+			ruleArgs = append(ruleArgs, "--hashlimit-mode", "srcip")
+		}
+	}
 	switch rule.Action {
 	case api.FwAllow:
 		ruleArgs = append(ruleArgs, "-j", "ACCEPT")
@@ -703,10 +913,48 @@ func (a *agent) getIntendedFwRule(rule api.FwRule) configitems.IptablesRule {
 		ruleArgs = append(ruleArgs, "-j", "REJECT")
 	case api.FwDrop:
 		ruleArgs = append(ruleArgs, "-j", "DROP")
+	case api.FwRejectWithTCPReset:
+		ruleArgs = append(ruleArgs, "-j", "REJECT", "--reject-with", "tcp-reset")
+	}
+	description := "Implicitly allow the rest"
+	if index >= 0 {
+		description = fmt.Sprintf("Firewall rule #%d", index)
 	}
 	return configitems.IptablesRule{
-		Args: ruleArgs,
+		Args:        ruleArgs,
+		Description: description,
+	}
+}
+
+// getFwScheduleArgs returns arguments for the iptables time match.
+func getFwScheduleArgs(schedule api.FwSchedule) []string {
+	args := []string{"-m", "time"}
+	if schedule.TimeStart != "" {
+		args = append(args, "--timestart", schedule.TimeStart)
+	}
+	if schedule.TimeStop != "" {
+		args = append(args, "--timestop", schedule.TimeStop)
+	}
+	if len(schedule.Weekdays) > 0 {
+		args = append(args, "--weekdays", strings.Join(schedule.Weekdays, ","))
+	}
+	// iptables expects dates without the timezone (always interpreted as UTC).
+	const iptablesDateFormat = "2006-01-02T15:04:05"
+	if schedule.DateStart != "" {
+		dateStart, _ := time.Parse(time.RFC3339, schedule.DateStart) // validated
+		args = append(args, "--datestart", dateStart.UTC().Format(iptablesDateFormat))
 	}
+	if schedule.DateStop != "" {
+		dateStop, _ := time.Parse(time.RFC3339, schedule.DateStop) // validated
+		args = append(args, "--datestop", dateStop.UTC().Format(iptablesDateFormat))
+	}
+	// Period crossing midnight (e.g. Fri 22:00 - Sat 02:00) should not be split
+	// into two distinct intervals of the same day.
+	if schedule.TimeStart != "" && schedule.TimeStop != "" &&
+		schedule.TimeStop < schedule.TimeStart {
+		args = append(args, "--contiguous")
+	}
+	return args
 }
 
 func (a *agent) getIntendedClientEp(client api.Client) dg.Graph {
@@ -743,7 +991,7 @@ func (a *agent) getIntendedDNSSrvEp(dnsSrv api.DNSServer) dg.Graph {
 		}
 		switch {
 		case staticEntry.IP == api.AdamIPRef:
-			ip = a.netModel.hostIP
+			ip = a.netModel.HostIP
 		case strings.HasPrefix(staticEntry.IP, api.EndpointIPRefPrefix):
 			epLL := strings.TrimPrefix(staticEntry.IP, api.EndpointIPRefPrefix)
 			ep := a.getEndpoint(epLL)
@@ -756,6 +1004,26 @@ func (a *agent) getIntendedDNSSrvEp(dnsSrv api.DNSServer) dg.Graph {
 			IP:   ip,
 		})
 	}
+	var faultRules []api.DNSFaultRule
+	for _, rule := range dnsSrv.FaultRules {
+		if strings.HasPrefix(rule.Name, api.EndpointFQDNRefPrefix) {
+			epLL := strings.TrimPrefix(rule.Name, api.EndpointFQDNRefPrefix)
+			rule.Name = a.getEndpoint(epLL).FQDN
+		}
+		var rotateIPs []string
+		for _, ip := range rule.RotateIPs {
+			switch {
+			case ip == api.AdamIPRef:
+				ip = a.netModel.HostIP.String()
+			case strings.HasPrefix(ip, api.EndpointIPRefPrefix):
+				epLL := strings.TrimPrefix(ip, api.EndpointIPRefPrefix)
+				ip = a.getEndpoint(epLL).IP
+			}
+			rotateIPs = append(rotateIPs, ip)
+		}
+		rule.RotateIPs = rotateIPs
+		faultRules = append(faultRules, rule)
+	}
 	intendedCfg.PutItem(configitems.DnsServer{
 		ServerName:      dnsSrv.LogicalLabel,
 		NetNamespace:    nsName,
@@ -763,6 +1031,8 @@ func (a *agent) getIntendedDNSSrvEp(dnsSrv api.DNSServer) dg.Graph {
 		VethPeerIfName:  inIfName,
 		StaticEntries:   staticEntries,
 		UpstreamServers: upstreamServers,
+		ListenIP:        net.ParseIP(dnsSrv.IP),
+		FaultRules:      faultRules,
 	}, nil)
 	return intendedCfg
 }
@@ -839,6 +1109,111 @@ func (a *agent) getIntendedHttpSrvEp(httpSrv api.HTTPServer) dg.Graph {
 	return intendedCfg
 }
 
+func (a *agent) getIntendedCaptivePortalEp(portal api.CaptivePortal) dg.Graph {
+	graphArgs := dg.InitArgs{Name: endpointSGPrefix + portal.LogicalLabel}
+	intendedCfg := dg.New(graphArgs)
+	a.putEpCommonConfig(intendedCfg, portal.Endpoint, nil)
+	nsName := a.endpointNsName(portal.LogicalLabel)
+	vethName, _, _ := a.endpointVethName(portal.LogicalLabel)
+	portalHost := portal.FQDN
+	if portalHost == "" {
+		portalHost = portal.IP
+	}
+	var clientNetworks []portalcfg.ClientNetwork
+	for _, network := range a.netModel.Networks {
+		if network.CaptivePortal != portal.LogicalLabel {
+			continue
+		}
+		clientNetworks = append(clientNetworks, portalcfg.ClientNetwork{
+			Subnet:       network.Subnet,
+			NetNamespace: a.networkNsName(network.LogicalLabel),
+			IPSet:        configitems.CaptivePortalIPSet,
+		})
+	}
+	intendedCfg.PutItem(configitems.CaptivePortal{
+		PortalName:     portal.LogicalLabel,
+		NetNamespace:   nsName,
+		VethName:       vethName,
+		ListenIP:       net.ParseIP(portal.IP),
+		PortalHost:     portalHost,
+		CertPEM:        portal.CertPEM,
+		KeyPEM:         portal.KeyPEM,
+		ClientNetworks: clientNetworks,
+	}, nil)
+	return intendedCfg
+}
+
+func (a *agent) getIntendedSyslogSrvEp(syslogSrv api.SyslogServer) dg.Graph {
+	graphArgs := dg.InitArgs{Name: endpointSGPrefix + syslogSrv.LogicalLabel}
+	intendedCfg := dg.New(graphArgs)
+	a.putEpCommonConfig(intendedCfg, syslogSrv.Endpoint, nil)
+	nsName := a.endpointNsName(syslogSrv.LogicalLabel)
+	vethName, _, _ := a.endpointVethName(syslogSrv.LogicalLabel)
+	serverHost := syslogSrv.FQDN
+	if serverHost == "" {
+		serverHost = syslogSrv.IP
+	}
+	maxMessages := syslogSrv.MaxMessages
+	if maxMessages == 0 {
+		maxMessages = api.DefaultSyslogMaxMessages
+	}
+	intendedCfg.PutItem(configitems.SyslogServer{
+		ServerName:   syslogSrv.LogicalLabel,
+		NetNamespace: nsName,
+		VethName:     vethName,
+		ListenIP:     net.ParseIP(syslogSrv.IP),
+		ServerHost:   serverHost,
+		UDPPort:      syslogSrv.UDPPort,
+		TCPPort:      syslogSrv.TCPPort,
+		TLSPort:      syslogSrv.TLSPort,
+		CertPEM:      syslogSrv.CertPEM,
+		KeyPEM:       syslogSrv.KeyPEM,
+		MaxMessages:  maxMessages,
+	}, nil)
+	return intendedCfg
+}
+
+func (a *agent) getIntendedWireGuardSrvEp(wgSrv api.WireGuardServer) dg.Graph {
+	graphArgs := dg.InitArgs{Name: endpointSGPrefix + wgSrv.LogicalLabel}
+	intendedCfg := dg.New(graphArgs)
+	a.putEpCommonConfig(intendedCfg, wgSrv.Endpoint, nil)
+	nsName := a.endpointNsName(wgSrv.LogicalLabel)
+	vethName, _, _ := a.endpointVethName(wgSrv.LogicalLabel)
+	// IP addresses and subnets are already validated.
+	tunnelIP, tunnelSubnet, _ := net.ParseCIDR(wgSrv.TunnelIP)
+	tunnelSubnet.IP = tunnelIP
+	var peers []configitems.WireGuardPeer
+	for _, peer := range wgSrv.Peers {
+		var allowedIPs []*net.IPNet
+		for _, allowedIP := range peer.AllowedIPs {
+			_, subnet, _ := net.ParseCIDR(allowedIP)
+			allowedIPs = append(allowedIPs, subnet)
+		}
+		peers = append(peers, configitems.WireGuardPeer{
+			PublicKey:           peer.PublicKey,
+			PresharedKey:        peer.PresharedKey,
+			AllowedIPs:          allowedIPs,
+			PersistentKeepalive: peer.PersistentKeepalive,
+		})
+	}
+	var privateSubnets []*net.IPNet
+	for _, privateSubnet := range wgSrv.PrivateSubnets {
+		_, subnet, _ := net.ParseCIDR(privateSubnet)
+		privateSubnets = append(privateSubnets, subnet)
+	}
+	intendedCfg.PutItem(configitems.WireGuardServer{
+		ServerName:     wgSrv.LogicalLabel,
+		NetNamespace:   nsName,
+		VethName:       vethName,
+		PrivateKey:     wgSrv.PrivateKey,
+		ListenPort:     wgSrv.ListenPort,
+		TunnelIP:       tunnelSubnet,
+		Peers:          peers,
+		PrivateSubnets: privateSubnets,
+	}, nil)
+	return intendedCfg
+}
+
 func (a *agent) putEpCommonConfig(graph dg.Graph, ep api.Endpoint, dnsClient *api.DNSClientConfig) {
 	vethName, inIfName, outIfName := a.endpointVethName(ep.LogicalLabel)
 	nsName := a.endpointNsName(ep.LogicalLabel)
@@ -958,36 +1333,13 @@ func (a *agent) endpointVethName(logicalLabel string) (
 }
 
 func (a *agent) getNetwork(logicalLabel string) api.Network {
-	item := a.netModel.items.getItem(api.Network{}.ItemType(), logicalLabel)
+	item := a.netModel.Items.GetItem(api.Network{}.ItemType(), logicalLabel)
 	return item.LabeledItem.(api.Network)
 }
 
 func (a *agent) getEndpoint(logicalLabel string) api.Endpoint {
-	item := a.netModel.items.getItem(api.Endpoint{}.ItemType(), logicalLabel)
-	return a.labeledItemToEndpoint(item)
-}
-
-// XXX With Go 1.18 and generics we can do better.
-func (a *agent) labeledItemToEndpoint(item *labeledItem) api.Endpoint {
-	switch item.category {
-	case api.Client{}.ItemCategory():
-		return item.LabeledItem.(api.Client).Endpoint
-	case api.DNSServer{}.ItemCategory():
-		return item.LabeledItem.(api.DNSServer).Endpoint
-	case api.NTPServer{}.ItemCategory():
-		return item.LabeledItem.(api.NTPServer).Endpoint
-	case api.HTTPServer{}.ItemCategory():
-		return item.LabeledItem.(api.HTTPServer).Endpoint
-	case api.ExplicitProxy{}.ItemCategory():
-		return item.LabeledItem.(api.ExplicitProxy).Endpoint
-	case api.TransparentProxy{}.ItemCategory():
-		return item.LabeledItem.(api.TransparentProxy).Endpoint
-	case api.NetbootServer{}.ItemCategory():
-		return item.LabeledItem.(api.NetbootServer).Endpoint
-	default:
-		log.Fatalf("Unexpected endpoint category: %s", item.category)
-	}
-	return api.Endpoint{} // unreachable
+	item := a.netModel.Items.GetItem(api.Endpoint{}.ItemType(), logicalLabel)
+	return item.ToEndpoint()
 }
 
 func (a *agent) genIfName(prefix, logicalLabel string) string {
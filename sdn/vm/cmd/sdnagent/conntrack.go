@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// conntrackFilter : filter applied to listed conntrack entries.
+// Empty filter attributes match any entry.
+type conntrackFilter struct {
+	proto string
+	src   *net.IPNet
+	dst   *net.IPNet
+	port  uint16
+}
+
+// parseConntrackFilter parses filter from URL query parameters.
+func parseConntrackFilter(r *http.Request) (filter conntrackFilter, err error) {
+	query := r.URL.Query()
+	filter.proto = strings.ToLower(query.Get("proto"))
+	if filter.src, err = parseIPOrSubnet(query.Get("src")); err != nil {
+		return filter, err
+	}
+	if filter.dst, err = parseIPOrSubnet(query.Get("dst")); err != nil {
+		return filter, err
+	}
+	if port := query.Get("port"); port != "" {
+		portNum, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return filter, fmt.Errorf("invalid port %s: %w", port, err)
+		}
+		filter.port = uint16(portNum)
+	}
+	return filter, nil
+}
+
+// parseIPOrSubnet parses subnet in the CIDR notation or a single IP address.
+func parseIPOrSubnet(value string) (*net.IPNet, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if strings.Contains(value, "/") {
+		_, subnet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnet %s: %w", value, err)
+		}
+		return subnet, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %s", value)
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// matches returns true if the entry passes the filter.
+// Source and destination are matched against the original direction
+// of the connection, port can be either source or destination port.
+func (f conntrackFilter) matches(entry api.ConntrackEntry) bool {
+	if f.proto != "" && entry.Protocol != f.proto {
+		return false
+	}
+	if f.src != nil && !f.src.Contains(net.ParseIP(entry.Forward.SrcIP)) {
+		return false
+	}
+	if f.dst != nil && !f.dst.Contains(net.ParseIP(entry.Forward.DstIP)) {
+		return false
+	}
+	if f.port != 0 && entry.Forward.SrcPort != f.port && entry.Forward.DstPort != f.port {
+		return false
+	}
+	return true
+}
+
+// resolveConntrackNs returns network namespace of the network or endpoint with
+// the given logical label.
+// Without label, the main network namespace (where the firewall is applied)
+// is returned.
+// Called with agent in locked state.
+func (a *agent) resolveConntrackNs(label string) (string, error) {
+	if label == "" {
+		return configitems.MainNsName, nil
+	}
+	if a.netModel.Items.GetItem(api.Network{}.ItemType(), label) != nil {
+		return a.networkNsName(label), nil
+	}
+	if a.netModel.Items.GetItem(api.Endpoint{}.ItemType(), label) != nil {
+		return a.endpointNsName(label), nil
+	}
+	return "", fmt.Errorf("no network or endpoint with logical label %s", label)
+}
+
+func conntrackFlowToEntry(flow *netlink.ConntrackFlow) api.ConntrackEntry {
+	entry := api.ConntrackEntry{
+		Family:   "ipv4",
+		Protocol: conntrackProtoName(flow.Forward.Protocol),
+		Forward: api.ConntrackTuple{
+			SrcIP:   flow.Forward.SrcIP.String(),
+			DstIP:   flow.Forward.DstIP.String(),
+			SrcPort: flow.Forward.SrcPort,
+			DstPort: flow.Forward.DstPort,
+			Packets: flow.Forward.Packets,
+			Bytes:   flow.Forward.Bytes,
+		},
+		Reverse: api.ConntrackTuple{
+			SrcIP:   flow.Reverse.SrcIP.String(),
+			DstIP:   flow.Reverse.DstIP.String(),
+			SrcPort: flow.Reverse.SrcPort,
+			DstPort: flow.Reverse.DstPort,
+			Packets: flow.Reverse.Packets,
+			Bytes:   flow.Reverse.Bytes,
+		},
+		Mark:    flow.Mark,
+		Timeout: flow.TimeOut,
+	}
+	if flow.FamilyType == syscall.AF_INET6 {
+		entry.Family = "ipv6"
+	}
+	return entry
+}
+
+func conntrackProtoName(proto uint8) string {
+	switch proto {
+	case syscall.IPPROTO_TCP:
+		return "tcp"
+	case syscall.IPPROTO_UDP:
+		return "udp"
+	case syscall.IPPROTO_ICMP:
+		return "icmp"
+	case syscall.IPPROTO_ICMPV6:
+		return "icmpv6"
+	case syscall.IPPROTO_SCTP:
+		return "sctp"
+	}
+	return strconv.Itoa(int(proto))
+}
+
+func (a *agent) listConntrack(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseConntrackFilter(r)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid conntrack filter: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	a.Lock()
+	netNs, err := a.resolveConntrackNs(r.URL.Query().Get("label"))
+	a.Unlock()
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to list conntrack entries: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusNotFound)
+		return
+	}
+	flows, err := configitems.ListConntrackFlows(netNs)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to list conntrack entries: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+	entries := []api.ConntrackEntry{}
+	for _, flow := range flows {
+		entry := conntrackFlowToEntry(flow)
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	resp, err := json.Marshal(entries)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to marshal conntrack entries to JSON: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(resp); err != nil {
+		log.Errorf("Failed to write conntrack entries to HTTP response: %v", err)
+	}
+}
@@ -9,13 +9,15 @@ import (
 )
 
 var intOne = big.NewInt(1)
-var internalIPv4Base *ipAsInt
+var internalIPv4Base, internalIPv6Base *ipAsInt
 var internalIPv4Subnet *net.IPNet
 
 func init() {
 	// 240.0.0.0/4 is reserved
 	internalIPv4Base = ipToInt(net.ParseIP("240.0.0.0"))
 	_, internalIPv4Subnet, _ = net.ParseCIDR("240.0.0.0/4")
+	// Randomly generated ULA prefix (fd5d:ede0::/32).
+	internalIPv6Base = ipToInt(net.ParseIP("fd5d:ede0::"))
 }
 
 type ipAsInt struct {
@@ -93,17 +95,17 @@ func (ip *ipAsInt) ToIP() net.IP {
 }
 
 func (a *agent) genVethIPsForNetwork(logicalLabel string, ipv6 bool) (ip1, ip2 *net.IPNet) {
-	if ipv6 {
-		// TODO
-		log.Fatal("IPv6 is not yet implemented")
-	}
 	index, hasIndex := a.networkIndex[logicalLabel]
 	if !hasIndex {
 		log.Fatalf("missing index for network %s", logicalLabel)
 	}
-	// Each network is allocated /30 subnet for internally used veths.
+	// Each network is allocated /30 (/126 for IPv6) subnet for internally used veths.
 	mask := net.CIDRMask(30, 32)
 	base := internalIPv4Base.Copy()
+	if ipv6 {
+		mask = net.CIDRMask(126, 128)
+		base = internalIPv6Base.Copy()
+	}
 	base.Inc(4 * index)
 	ip1 = &net.IPNet{IP: base.Inc(1).ToIP(), Mask: mask}
 	ip2 = &net.IPNet{IP: base.Inc(1).ToIP(), Mask: mask}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	dg "github.com/lf-edge/eve/libs/depgraph"
+)
+
+var nat64IPv4Addr, nat64IPv6Addr net.IP
+var nat64DynamicPool *net.IPNet
+
+func init() {
+	// 198.18.0.0/15 is reserved for benchmarking, it should not be used
+	// by the host network nor by the network model.
+	nat64IPv4Addr = net.ParseIP("198.18.0.1")
+	_, nat64DynamicPool, _ = net.ParseCIDR("198.18.0.0/16")
+	// Taken from the end of the internal IPv6 subnet (see ipam.go).
+	nat64IPv6Addr = net.ParseIP("fd5d:ede0:ffff:ffff::1")
+}
+
+// DHCP/DNS configuration common to IPv6 provisioning and DHCP server.
+type dhcpOptions struct {
+	dnsServers []net.IP
+	ntpServer  string
+	domainName string
+	gatewayIP  net.IP
+}
+
+// nat64Prefix returns the NAT64 prefix used by the network (nil if NAT64 is disabled).
+func nat64Prefix(network api.Network) *net.IPNet {
+	if network.NAT64 == nil {
+		return nil
+	}
+	prefix := network.NAT64.Prefix
+	if prefix == "" {
+		prefix = api.DefaultNAT64Prefix
+	}
+	_, prefixNet, _ := net.ParseCIDR(prefix) // already validated
+	return prefixNet
+}
+
+// mapToNAT64 embeds IPv4 address into the NAT64 prefix.
+// IPv6 address is returned unchanged.
+func mapToNAT64(prefix *net.IPNet, ip net.IP) net.IP {
+	ipv4 := ip.To4()
+	if ipv4 == nil {
+		return ip
+	}
+	ipv6 := make(net.IP, net.IPv6len)
+	copy(ipv6, prefix.IP.To16())
+	copy(ipv6[12:], ipv4)
+	return ipv6
+}
+
+// putIntendedNAT64 puts NAT64 config, shared by all networks with NAT64 enabled
+// (all of them use the same prefix, see netmodel validation).
+func (a *agent) putIntendedNAT64(graph dg.Graph) {
+	for _, network := range a.netModel.Networks {
+		prefix := nat64Prefix(network)
+		if prefix == nil {
+			continue
+		}
+		graph.PutItem(configitems.NAT64{
+			Prefix:      prefix,
+			IPv4Addr:    nat64IPv4Addr,
+			IPv6Addr:    nat64IPv6Addr,
+			DynamicPool: nat64DynamicPool,
+		}, nil)
+		return
+	}
+}
+
+// putIntendedNetworkNAT64 puts items needed to translate traffic of the given network
+// with NAT64 and to run DNS64 proxy on the network gateway.
+// Returns DHCP options with DNS servers replaced by the proxy if DNS64 is enabled.
+func (a *agent) putIntendedNetworkNAT64(graph dg.Graph, network api.Network,
+	gwIP net.IP, rtInIP *net.IPNet, opts dhcpOptions) dhcpOptions {
+	prefix := nat64Prefix(network)
+	if prefix == nil {
+		return opts
+	}
+	_, subnet, _ := net.ParseCIDR(network.Subnet) // already validated
+	_, internalSubnet, _ := net.ParseCIDR(rtInIP.String())
+	// Route traffic destined to the NAT64 prefix using the main routing table,
+	// where it is routed into the NAT64 TUN interface.
+	// This includes DNS queries forwarded by DNS64 proxy from the internal veth IP.
+	for _, srcNet := range []*net.IPNet{subnet, internalSubnet} {
+		graph.PutItem(configitems.IPRule{
+			SrcNet:   srcNet,
+			DstNet:   prefix,
+			Table:    syscall.RT_TABLE_MAIN,
+			Priority: networkIPRulePriority - 1,
+		}, nil)
+	}
+	if !network.NAT64.DNS64 {
+		return opts
+	}
+	brVethName, _, _ := a.networkBrVethName(network.LogicalLabel)
+	var upstreams []net.IP
+	for _, dnsServer := range opts.dnsServers {
+		upstreams = append(upstreams, mapToNAT64(prefix, dnsServer))
+	}
+	graph.PutItem(configitems.Dns64Proxy{
+		ProxyName:    network.LogicalLabel,
+		NetNamespace: a.networkNsName(network.LogicalLabel),
+		VethName:     brVethName,
+		ListenIP:     gwIP,
+		Upstreams:    upstreams,
+		Prefix:       prefix,
+	}, nil)
+	opts.dnsServers = []net.IP{gwIP}
+	return opts
+}
+
+// putIntendedIPv6Provisioning puts radvd and (if needed) Kea DHCPv6 server
+// configured according to the explicit IPv6 provisioning mode of the network.
+func (a *agent) putIntendedIPv6Provisioning(graph dg.Graph, network api.Network,
+	opts dhcpOptions) {
+	ipv6 := network.IPv6
+	_, subnet, _ := net.ParseCIDR(network.Subnet) // already validated
+	brVethName, brInIfName, _ := a.networkBrVethName(network.LogicalLabel)
+	nsName := a.networkNsName(network.LogicalLabel)
+	prefix := nat64Prefix(network)
+	// All IPv4 addresses are either mapped into the NAT64 prefix or skipped.
+	var dnsServers []net.IP
+	for _, dnsServer := range opts.dnsServers {
+		if dnsServer.To4() != nil && prefix == nil {
+			continue
+		}
+		if prefix != nil {
+			dnsServer = mapToNAT64(prefix, dnsServer)
+		}
+		dnsServers = append(dnsServers, dnsServer)
+	}
+	ntpServer := net.ParseIP(opts.ntpServer)
+	if ntpServer != nil && ntpServer.To4() != nil {
+		if prefix == nil {
+			ntpServer = nil
+		} else {
+			ntpServer = mapToNAT64(prefix, ntpServer)
+		}
+	}
+	withKea := ipv6.Mode != api.IPv6ModeSLAAC || ipv6.PrefixDelegation != nil
+	radvd := configitems.Radvd{
+		DaemonName:          network.LogicalLabel,
+		NetNamespace:        nsName,
+		VethName:            brVethName,
+		VethPeerIfName:      brInIfName,
+		Prefix:              subnet,
+		Autonomous:          ipv6.Mode != api.IPv6ModeDHCPv6,
+		ManagedFlag:         ipv6.Mode == api.IPv6ModeDHCPv6,
+		OtherConfigFlag:     withKea,
+		WithoutDefaultRoute: opts.gatewayIP == nil,
+		MaxInterval:         time.Duration(ipv6.RAInterval) * time.Second,
+	}
+	if ipv6.RDNSS {
+		radvd.RDNSS = dnsServers
+	}
+	if ipv6.DNSSL && opts.domainName != "" {
+		radvd.DNSSL = []string{opts.domainName}
+	}
+	graph.PutItem(radvd, nil)
+	if !withKea {
+		return
+	}
+	kea := configitems.KeaDhcp6{
+		ServerName:     network.LogicalLabel,
+		NetNamespace:   nsName,
+		VethName:       brVethName,
+		VethPeerIfName: brInIfName,
+		Subnet:         subnet,
+		DomainName:     opts.domainName,
+		DNSServers:     dnsServers,
+		NTPServer:      ntpServer,
+	}
+	if ipv6.Mode == api.IPv6ModeDHCPv6 {
+		dhcp := network.DHCP
+		kea.IPRange = configitems.IPRange{
+			FromIP: net.ParseIP(dhcp.IPRange.FromIP),
+			ToIP:   net.ParseIP(dhcp.IPRange.ToIP),
+		}
+		for _, entry := range dhcp.StaticEntries {
+			mac, _ := net.ParseMAC(entry.MAC)
+			kea.StaticEntries = append(kea.StaticEntries, configitems.MACToIP{
+				MAC: mac,
+				IP:  net.ParseIP(entry.IP),
+			})
+		}
+	}
+	if pdPrefix := ipv6PDPrefix(network); pdPrefix != nil {
+		kea.PDPrefix = pdPrefix
+		kea.PDDelegatedLen = ipv6.PrefixDelegation.DelegatedLen
+	}
+	graph.PutItem(kea, nil)
+}
+
+// ipv6PDPrefix returns prefix delegated to hosts of the network using DHCPv6-PD
+// (nil if prefix delegation is disabled).
+func ipv6PDPrefix(network api.Network) *net.IPNet {
+	if network.IPv6 == nil || network.IPv6.PrefixDelegation == nil {
+		return nil
+	}
+	_, prefix, _ := net.ParseCIDR(network.IPv6.PrefixDelegation.Prefix) // validated
+	return prefix
+}
@@ -25,6 +25,8 @@ func main() {
 	debug := flag.Bool("debug", false, "Set Debug log level")
 	port := flag.Uint("port", defaultPort, "Port on which to listen")
 	ip := flag.String("ip", defaultIP, "IP address on which to listen")
+	staticHostIP := flag.Bool("static-host-ip", false,
+		"IP address of the host port is configured statically (e.g. by container runtime)")
 	flag.Parse()
 
 	if *debug {
@@ -34,7 +36,7 @@ func main() {
 	}
 	log.SetReportCaller(true)
 
-	agent := &agent{}
+	agent := &agent{staticHostIP: *staticHostIP}
 	if err := agent.init(); err != nil {
 		log.Fatal(err)
 	}
@@ -46,6 +48,17 @@ func main() {
 	router.HandleFunc("/net-model.json", agent.applyNetModel).Methods("PUT")
 	router.HandleFunc("/net-config.gv", agent.getNetConfig).Methods("GET")
 	router.HandleFunc("/sdn-status.json", agent.getSDNStatus).Methods("GET")
+	router.HandleFunc("/captures.json", agent.listCaptures).Methods("GET")
+	router.HandleFunc("/capture/{label}", agent.putCapture).Methods("PUT")
+	router.HandleFunc("/capture/{label}", agent.deleteCapture).Methods("DELETE")
+	router.HandleFunc("/capture/{label}.pcap", agent.getCapture).Methods("GET")
+	router.HandleFunc("/conntrack.json", agent.listConntrack).Methods("GET")
+	router.HandleFunc("/endpoint/{label}/logs", agent.getEndpointLogs).Methods("GET")
+	router.HandleFunc("/captive-portals.json", agent.listCaptivePortals).Methods("GET")
+	router.HandleFunc("/captive-portal/{network}/login",
+		agent.loginCaptivePortal).Methods("PUT")
+	router.HandleFunc("/captive-portal/{network}/logout",
+		agent.logoutCaptivePortal).Methods("PUT")
 	// TODO: metrics?
 
 	srv := &http.Server{
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+)
+
+// networkNAT returns NAT config of the network (nil if NAT is disabled).
+func networkNAT(network api.Network) *api.NAT {
+	if network.Router == nil {
+		return nil
+	}
+	return network.Router.NAT
+}
+
+// natOutsideSubnet returns subnet into which the network is NATed
+// (nil if NAT is disabled).
+func natOutsideSubnet(network api.Network) *net.IPNet {
+	nat := networkNAT(network)
+	if nat == nil {
+		return nil
+	}
+	_, outsideSubnet, _ := net.ParseCIDR(nat.OutsideSubnet) // already validated
+	return outsideSubnet
+}
+
+// natViaNetwork returns the intermediate network through which the NAT
+// of the given network is chained (nil if NAT is not chained).
+func (a *agent) natViaNetwork(network api.Network) *api.Network {
+	nat := networkNAT(network)
+	if nat == nil || nat.Via == "" {
+		return nil
+	}
+	viaNetwork := a.getNetwork(nat.Via)
+	return &viaNetwork
+}
+
+// natTransitSubnets returns outside subnets of all networks which chain their NAT
+// through the given network.
+func (a *agent) natTransitSubnets(network api.Network) (subnets []*net.IPNet) {
+	for _, network2 := range a.netModel.Networks {
+		nat := networkNAT(network2)
+		if nat != nil && nat.Via == network.LogicalLabel {
+			subnets = append(subnets, natOutsideSubnet(network2))
+		}
+	}
+	return subnets
+}
+
+// natRules : iptables rules implementing NAT inside the network namespace.
+type natRules struct {
+	// snat : rules for the POSTROUTING chain of the nat table.
+	snat []configitems.IptablesRule
+	// dnat : rules for the PREROUTING chain of the nat table.
+	dnat []configitems.IptablesRule
+	// forward : rules for the FORWARD chain of the filter table.
+	forward []configitems.IptablesRule
+}
+
+// getNATRules returns iptables rules implementing NAT of the network and NAT
+// of networks chained through this network.
+// NAT is applied to traffic leaving the network namespace via the veth interface
+// connecting network with the main "router" (rtInIfName).
+func (a *agent) getNATRules(network api.Network, subnet *net.IPNet,
+	brInIfName, rtInIfName string) (rules natRules) {
+	nat := networkNAT(network)
+	if nat == nil {
+		return rules
+	}
+	// NAT of transit traffic from networks chained through this network.
+	// This network cannot use full-cone NAT (see validation), therefore
+	// the outside subnet is used as a pool of addresses for SNAT.
+	for _, transitSubnet := range a.natTransitSubnets(network) {
+		rules.snat = append(rules.snat, configitems.IptablesRule{
+			Args: a.getSNATArgs(nat, transitSubnet, rtInIfName),
+			Description: fmt.Sprintf("S-NAT transit traffic from %s (chained NAT)",
+				transitSubnet),
+		})
+	}
+	outsideSubnet := natOutsideSubnet(network)
+	if nat.Type == api.NATFullCone {
+		// Static 1:1 mapping between the network subnet and the outside subnet
+		// (ports are preserved).
+		rules.snat = append(rules.snat, configitems.IptablesRule{
+			Args: []string{"-o", rtInIfName, "-s", subnet.String(),
+				"-j", "NETMAP", "--to", outsideSubnet.String()},
+			Description: "Full-cone NAT: map network subnet to the outside subnet",
+		})
+		rules.dnat = append(rules.dnat, configitems.IptablesRule{
+			Args: []string{"-i", rtInIfName, "-d", outsideSubnet.String(),
+				"-j", "NETMAP", "--to", subnet.String()},
+			Description: "Full-cone NAT: map outside subnet to the network subnet",
+		})
+	} else {
+		rules.snat = append(rules.snat, configitems.IptablesRule{
+			Args:        a.getSNATArgs(nat, subnet, rtInIfName),
+			Description: fmt.Sprintf("S-NAT traffic leaving the network (%s NAT)", nat.Type),
+		})
+		outsideIP := a.subnetToHostIPRange(outsideSubnet).FromIP
+		for _, fwd := range nat.PortForwarding {
+			insidePort := fwd.InsidePort
+			if insidePort == 0 {
+				insidePort = fwd.OutsidePort
+			}
+			rules.dnat = append(rules.dnat, configitems.IptablesRule{
+				Args: []string{"-i", rtInIfName, "-d", outsideIP.String(),
+					"-p", fwd.Protocol, "--dport", strconv.Itoa(int(fwd.OutsidePort)),
+					"-j", "DNAT", "--to-destination",
+					net.JoinHostPort(fwd.InsideIP, strconv.Itoa(int(insidePort)))},
+				Description: fmt.Sprintf("Forward %s port %d to %s", fwd.Protocol,
+					fwd.OutsidePort, fwd.InsideIP),
+			})
+		}
+	}
+	// Block connections initiated from outside towards EVE, unless they were
+	// D-NATed (port forwarding, full-cone NAT) or opened by Eden itself
+	// (with source IP from the internal subnet).
+	rules.forward = append(rules.forward,
+		configitems.IptablesRule{
+			Args: []string{"-i", rtInIfName, "-o", brInIfName,
+				"-m", "conntrack", "--ctstate", "DNAT", "-j", "ACCEPT"},
+			Description: "Allow connections forwarded by NAT",
+		},
+		configitems.IptablesRule{
+			Args: []string{"-i", rtInIfName, "-o", brInIfName,
+				"!", "-s", internalIPv4Subnet.String(),
+				"-m", "conntrack", "--ctstate", "NEW,INVALID", "-j", "DROP"},
+			Description: "Block connections initiated from outside of the NAT",
+		})
+	return rules
+}
+
+// getSNATArgs returns iptables arguments to S-NAT traffic from the given subnet
+// into the outside subnet of the NAT.
+func (a *agent) getSNATArgs(nat *api.NAT, srcSubnet *net.IPNet,
+	outIfName string) []string {
+	_, outsideSubnet, _ := net.ParseCIDR(nat.OutsideSubnet) // already validated
+	outsideRange := a.subnetToHostIPRange(outsideSubnet)
+	toSource := outsideRange.FromIP.String()
+	if !outsideRange.FromIP.Equal(outsideRange.ToIP) {
+		toSource += "-" + outsideRange.ToIP.String()
+	}
+	args := []string{"-o", outIfName, "-s", srcSubnet.String(),
+		"-j", "SNAT", "--to-source", toSource}
+	if nat.Type == api.NATSymmetric {
+		args = append(args, "--random-fully")
+		return args
+	}
+	// Endpoint-independent mapping requires every EVE IP address to be always
+	// NATed into the same outside address, not one picked per connection.
+	if !outsideRange.FromIP.Equal(outsideRange.ToIP) {
+		args = append(args, "--persistent")
+	}
+	if nat.RandomizePorts {
+		args = append(args, "--random")
+	}
+	return args
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+)
+
+func TestGetSNATArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		nat      api.NAT
+		expected []string
+	}{
+		{
+			name: "port-restricted cone keeps outside address per EVE IP",
+			nat:  api.NAT{Type: api.NATPortRestrictedCone, OutsideSubnet: "100.64.1.0/24"},
+			expected: []string{"-o", "rt-in", "-s", "172.22.1.0/24", "-j", "SNAT",
+				"--to-source", "100.64.1.1-100.64.1.254", "--persistent"},
+		},
+		{
+			name: "default type is port-restricted cone",
+			nat:  api.NAT{OutsideSubnet: "100.64.1.0/29"},
+			expected: []string{"-o", "rt-in", "-s", "172.22.1.0/24", "-j", "SNAT",
+				"--to-source", "100.64.1.1-100.64.1.6", "--persistent"},
+		},
+		{
+			name: "port-restricted cone with randomized ports",
+			nat: api.NAT{Type: api.NATPortRestrictedCone, OutsideSubnet: "100.64.1.0/24",
+				RandomizePorts: true},
+			expected: []string{"-o", "rt-in", "-s", "172.22.1.0/24", "-j", "SNAT",
+				"--to-source", "100.64.1.1-100.64.1.254", "--persistent", "--random"},
+		},
+		{
+			name: "port-restricted cone into single address",
+			nat:  api.NAT{Type: api.NATPortRestrictedCone, OutsideSubnet: "100.64.1.5/32"},
+			expected: []string{"-o", "rt-in", "-s", "172.22.1.0/24", "-j", "SNAT",
+				"--to-source", "100.64.1.5"},
+		},
+		{
+			name: "symmetric",
+			nat:  api.NAT{Type: api.NATSymmetric, OutsideSubnet: "100.64.1.0/24"},
+			expected: []string{"-o", "rt-in", "-s", "172.22.1.0/24", "-j", "SNAT",
+				"--to-source", "100.64.1.1-100.64.1.254", "--random-fully"},
+		},
+	}
+	_, srcSubnet, _ := net.ParseCIDR("172.22.1.0/24")
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := &agent{}
+			args := a.getSNATArgs(&tt.nat, srcSubnet, "rt-in")
+			if !reflect.DeepEqual(args, tt.expected) {
+				t.Errorf("SNAT args = %v, expected %v", args, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetNATRules(t *testing.T) {
+	t.Parallel()
+
+	homeNAT := &api.NAT{
+		Type:          api.NATPortRestrictedCone,
+		OutsideSubnet: "100.64.1.0/30",
+		Via:           "cgnat",
+		PortForwarding: []api.NATPortForward{
+			{Protocol: "tcp", OutsidePort: 8080, InsideIP: "172.22.1.10", InsidePort: 80},
+			{Protocol: "udp", OutsidePort: 5000, InsideIP: "172.22.1.11"},
+		},
+	}
+	cgNAT := &api.NAT{Type: api.NATSymmetric, OutsideSubnet: "100.64.100.0/24"}
+	fullConeNAT := &api.NAT{Type: api.NATFullCone, OutsideSubnet: "100.64.2.0/24"}
+	networks := []api.Network{
+		{LogicalLabel: "home", Subnet: "172.22.1.0/24", Router: &api.Router{NAT: homeNAT}},
+		{LogicalLabel: "cgnat", Subnet: "172.22.2.0/24", Router: &api.Router{NAT: cgNAT}},
+		{LogicalLabel: "fullcone", Subnet: "172.22.3.0/24", Router: &api.Router{NAT: fullConeNAT}},
+		{LogicalLabel: "nonat", Subnet: "172.22.4.0/24", Router: &api.Router{}},
+	}
+	forward := [][]string{
+		{"-i", "rt-in", "-o", "br-in", "-m", "conntrack", "--ctstate", "DNAT", "-j", "ACCEPT"},
+		{"-i", "rt-in", "-o", "br-in", "!", "-s", "240.0.0.0/4",
+			"-m", "conntrack", "--ctstate", "NEW,INVALID", "-j", "DROP"},
+	}
+
+	tests := []struct {
+		network string
+		snat    [][]string
+		dnat    [][]string
+		forward [][]string
+	}{
+		{
+			network: "home",
+			snat: [][]string{
+				{"-o", "rt-in", "-s", "172.22.1.0/24", "-j", "SNAT",
+					"--to-source", "100.64.1.1-100.64.1.2", "--persistent"},
+			},
+			dnat: [][]string{
+				{"-i", "rt-in", "-d", "100.64.1.1", "-p", "tcp", "--dport", "8080",
+					"-j", "DNAT", "--to-destination", "172.22.1.10:80"},
+				{"-i", "rt-in", "-d", "100.64.1.1", "-p", "udp", "--dport", "5000",
+					"-j", "DNAT", "--to-destination", "172.22.1.11:5000"},
+			},
+			forward: forward,
+		},
+		{
+			network: "cgnat",
+			snat: [][]string{
+				// transit traffic of the chained home network
+				{"-o", "rt-in", "-s", "100.64.1.0/30", "-j", "SNAT",
+					"--to-source", "100.64.100.1-100.64.100.254", "--random-fully"},
+				{"-o", "rt-in", "-s", "172.22.2.0/24", "-j", "SNAT",
+					"--to-source", "100.64.100.1-100.64.100.254", "--random-fully"},
+			},
+			forward: forward,
+		},
+		{
+			network: "fullcone",
+			snat: [][]string{
+				{"-o", "rt-in", "-s", "172.22.3.0/24", "-j", "NETMAP", "--to", "100.64.2.0/24"},
+			},
+			dnat: [][]string{
+				{"-i", "rt-in", "-d", "100.64.2.0/24", "-j", "NETMAP", "--to", "172.22.3.0/24"},
+			},
+			forward: forward,
+		},
+		{
+			network: "nonat",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.network, func(t *testing.T) {
+			t.Parallel()
+
+			a := &agent{}
+			a.netModel.Networks = networks
+			var network api.Network
+			for _, n := range networks {
+				if n.LogicalLabel == tt.network {
+					network = n
+				}
+			}
+			_, subnet, _ := net.ParseCIDR(network.Subnet)
+			rules := a.getNATRules(network, subnet, "br-in", "rt-in")
+			for _, chain := range []struct {
+				name     string
+				rules    []configitems.IptablesRule
+				expected [][]string
+			}{
+				{"snat", rules.snat, tt.snat},
+				{"dnat", rules.dnat, tt.dnat},
+				{"forward", rules.forward, tt.forward},
+			} {
+				var args [][]string
+				for _, rule := range chain.rules {
+					args = append(args, rule.Args)
+				}
+				if !reflect.DeepEqual(args, chain.expected) {
+					t.Errorf("%s rules = %v, expected %v", chain.name, args, chain.expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// scenarioState : state of the impairment scenario running on a port.
+type scenarioState struct {
+	scenario      api.ImpairmentScenario
+	step          int
+	stepStartedAt time.Time
+	iteration     int
+	completed     bool
+}
+
+func (s *scenarioState) stepEnd() time.Time {
+	duration := time.Duration(s.scenario.Steps[s.step].Duration) * time.Second
+	return s.stepStartedAt.Add(duration)
+}
+
+// restartScenarios starts impairment scenarios of the (newly applied) network model
+// from the first step.
+// Called with agent in locked state.
+func (a *agent) restartScenarios() {
+	a.scenarios = make(map[string]*scenarioState)
+	now := time.Now()
+	for _, port := range a.netModel.Ports {
+		if port.Scenario == nil {
+			continue
+		}
+		a.scenarios[port.LogicalLabel] = &scenarioState{
+			scenario:      *port.Scenario,
+			stepStartedAt: now,
+			iteration:     1,
+		}
+		log.Infof("Started impairment scenario for port %s", port.LogicalLabel)
+	}
+	a.scheduleScenarioStep()
+}
+
+// advanceScenarios moves scenarios to the next step if the current one has elapsed.
+// Returns true if any scenario has changed its step.
+// Called with agent in locked state.
+func (a *agent) advanceScenarios() (changed bool) {
+	now := time.Now()
+	for port, state := range a.scenarios {
+		for !state.completed && !now.Before(state.stepEnd()) {
+			changed = true
+			state.stepStartedAt = state.stepEnd()
+			state.step++
+			if state.step == len(state.scenario.Steps) {
+				if !state.scenario.Repeat {
+					state.step--
+					state.completed = true
+					log.Infof("Impairment scenario for port %s has completed", port)
+					break
+				}
+				state.step = 0
+				state.iteration++
+			}
+			log.Infof("Impairment scenario for port %s moved to step %d (iteration %d)",
+				port, state.step, state.iteration)
+		}
+	}
+	a.scheduleScenarioStep()
+	return changed
+}
+
+// scheduleScenarioStep arms timer to fire when the earliest of running scenario
+// steps ends.
+// Called with agent in locked state.
+func (a *agent) scheduleScenarioStep() {
+	if a.scenarioTimer != nil {
+		a.scenarioTimer.Stop()
+		a.scenarioTimer = nil
+		a.scenarioTick = nil
+	}
+	var next time.Time
+	for _, state := range a.scenarios {
+		if state.completed {
+			continue
+		}
+		if stepEnd := state.stepEnd(); next.IsZero() || stepEnd.Before(next) {
+			next = stepEnd
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+	a.scenarioTimer = time.NewTimer(time.Until(next))
+	a.scenarioTick = a.scenarioTimer.C
+}
+
+// currentScenarioStep returns the scenario step currently applied to the port.
+// Returns nil if there is no scenario running for the port.
+func (a *agent) currentScenarioStep(portLL string) *api.ImpairmentStep {
+	state, hasScenario := a.scenarios[portLL]
+	if !hasScenario || state.completed {
+		return nil
+	}
+	return &state.scenario.Steps[state.step]
+}
+
+// portTC returns traffic control to apply for the port.
+func (a *agent) portTC(port api.Port) api.TrafficControl {
+	if step := a.currentScenarioStep(port.LogicalLabel); step != nil {
+		return step.TC
+	}
+	return port.TC
+}
+
+// portAdminUP returns true if the port should be administratively UP.
+// Scenario step can only put the port DOWN, not override static AdminUP=false.
+func (a *agent) portAdminUP(port api.Port) bool {
+	if step := a.currentScenarioStep(port.LogicalLabel); step != nil {
+		return port.AdminUP && !step.LinkDown
+	}
+	return port.AdminUP
+}
+
+// getScenarioStatus returns progress of impairment scenarios, sorted by port.
+// Called with agent in locked state.
+func (a *agent) getScenarioStatus() (status []api.ScenarioStatus) {
+	for port, state := range a.scenarios {
+		status = append(status, api.ScenarioStatus{
+			Port:          port,
+			Step:          state.step,
+			StepCount:     len(state.scenario.Steps),
+			StepStartedAt: state.stepStartedAt,
+			Iteration:     state.iteration,
+			Completed:     state.completed,
+		})
+	}
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Port < status[j].Port
+	})
+	return status
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+)
+
+func twoStepScenario(repeat bool) api.ImpairmentScenario {
+	return api.ImpairmentScenario{
+		Steps: []api.ImpairmentStep{
+			{Duration: 10, LinkDown: true},
+			{Duration: 20, TC: api.TrafficControl{Delay: 100}},
+		},
+		Repeat: repeat,
+	}
+}
+
+func TestPortAdminUP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		adminUP   bool
+		linkDown  bool
+		scenario  bool
+		completed bool
+		expected  bool
+	}{
+		{name: "no scenario, up", adminUP: true, expected: true},
+		{name: "no scenario, down", adminUP: false, expected: false},
+		{name: "step link up", adminUP: true, scenario: true, expected: true},
+		{name: "step link down", adminUP: true, linkDown: true, scenario: true, expected: false},
+		{name: "step does not bring up port configured down", adminUP: false, scenario: true, expected: false},
+		{name: "step link down, port configured down", adminUP: false, linkDown: true, scenario: true, expected: false},
+		{name: "completed scenario reverts to static", adminUP: true, linkDown: true, scenario: true,
+			completed: true, expected: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := &agent{scenarios: map[string]*scenarioState{}}
+			if tt.scenario {
+				a.scenarios["eth0"] = &scenarioState{
+					scenario: api.ImpairmentScenario{
+						Steps: []api.ImpairmentStep{{Duration: 10, LinkDown: tt.linkDown}},
+					},
+					stepStartedAt: time.Now(),
+					iteration:     1,
+					completed:     tt.completed,
+				}
+			}
+			port := api.Port{LogicalLabel: "eth0", AdminUP: tt.adminUP}
+			if got := a.portAdminUP(port); got != tt.expected {
+				t.Errorf("portAdminUP() = %t, expected %t", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAdvanceScenarios(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		repeat        bool
+		elapsed       time.Duration
+		changed       bool
+		step          int
+		iteration     int
+		completed     bool
+		stepStartedAt time.Duration // relative to the scenario start
+	}{
+		{name: "within first step", elapsed: 5 * time.Second,
+			changed: false, step: 0, iteration: 1},
+		{name: "first step ended", elapsed: 15 * time.Second,
+			changed: true, step: 1, iteration: 1, stepStartedAt: 10 * time.Second},
+		{name: "several steps skipped", repeat: true, elapsed: 45 * time.Second,
+			changed: true, step: 1, iteration: 2, stepStartedAt: 40 * time.Second},
+		{name: "repeated", repeat: true, elapsed: 35 * time.Second,
+			changed: true, step: 0, iteration: 2, stepStartedAt: 30 * time.Second},
+		// stepStartedAt of completed scenario is when it has completed
+		{name: "completed", elapsed: 35 * time.Second,
+			changed: true, step: 1, iteration: 1, completed: true, stepStartedAt: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			start := time.Now().Add(-tt.elapsed)
+			state := &scenarioState{
+				scenario:      twoStepScenario(tt.repeat),
+				stepStartedAt: start,
+				iteration:     1,
+			}
+			a := &agent{scenarios: map[string]*scenarioState{"eth0": state}}
+			changed := a.advanceScenarios()
+			if a.scenarioTimer != nil {
+				a.scenarioTimer.Stop()
+			}
+			if changed != tt.changed {
+				t.Errorf("advanceScenarios() = %t, expected %t", changed, tt.changed)
+			}
+			if state.step != tt.step || state.iteration != tt.iteration || state.completed != tt.completed {
+				t.Errorf("got step %d, iteration %d, completed %t; expected step %d, iteration %d, completed %t",
+					state.step, state.iteration, state.completed, tt.step, tt.iteration, tt.completed)
+			}
+			if expected := start.Add(tt.stepStartedAt); !state.stepStartedAt.Equal(expected) {
+				t.Errorf("step started at %v, expected %v", state.stepStartedAt, expected)
+			}
+			if tt.completed != (a.scenarioTick == nil) {
+				t.Errorf("timer must be armed only while scenario is running, tick: %v", a.scenarioTick)
+			}
+		})
+	}
+}
+
+func TestScheduleScenarioStep(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	a := &agent{scenarios: map[string]*scenarioState{
+		// step ends in an hour
+		"eth0": {
+			scenario:      twoStepScenario(false),
+			stepStartedAt: now.Add(time.Hour - 10*time.Second),
+			iteration:     1,
+		},
+		// step ends in 50ms
+		"eth1": {
+			scenario:      twoStepScenario(false),
+			stepStartedAt: now.Add(50*time.Millisecond - 10*time.Second),
+			iteration:     1,
+		},
+		// completed scenario is not scheduled
+		"eth2": {
+			scenario:      twoStepScenario(false),
+			stepStartedAt: now.Add(-time.Hour),
+			iteration:     1,
+			completed:     true,
+		},
+	}}
+	a.scheduleScenarioStep()
+	previousTimer := a.scenarioTimer
+	// re-scheduling replaces the timer
+	a.scheduleScenarioStep()
+	if previousTimer.Stop() {
+		t.Error("previous timer must be stopped")
+	}
+	select {
+	case <-a.scenarioTick:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire at the end of the earliest step")
+	}
+
+	a.scenarios["eth0"].completed = true
+	a.scenarios["eth1"].completed = true
+	a.scheduleScenarioStep()
+	if a.scenarioTimer != nil || a.scenarioTick != nil {
+		t.Error("timer must not be armed without running scenarios")
+	}
+}
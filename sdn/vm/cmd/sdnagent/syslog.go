@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	log "github.com/sirupsen/logrus"
+)
+
+// How often to check for new syslog messages when following.
+const syslogFollowInterval = 500 * time.Millisecond
+
+// syslogFilter : filter applied to syslog messages.
+type syslogFilter struct {
+	regex *regexp.Regexp
+	since time.Time
+}
+
+// parseSyslogFilter parses filter from URL query parameters.
+func parseSyslogFilter(r *http.Request) (filter syslogFilter, err error) {
+	query := r.URL.Query()
+	if regex := query.Get("regex"); regex != "" {
+		if filter.regex, err = regexp.Compile(regex); err != nil {
+			return filter, fmt.Errorf("invalid regex %s: %w", regex, err)
+		}
+	}
+	if since := query.Get("since"); since != "" {
+		if filter.since, err = time.Parse(time.RFC3339, since); err != nil {
+			return filter, fmt.Errorf("invalid time %s: %w", since, err)
+		}
+	}
+	return filter, nil
+}
+
+// matches returns true if the message passes the filter.
+// Regex is matched against the message as received.
+func (f syslogFilter) matches(msg api.SyslogMessage) bool {
+	if !f.since.IsZero() && msg.ReceivedAt.Before(f.since) {
+		return false
+	}
+	if f.regex != nil && !f.regex.MatchString(msg.Raw) {
+		return false
+	}
+	return true
+}
+
+// syslogMsgReader reads messages stored by syslogsrv, possibly following
+// the messages file as it grows and gets rotated.
+type syslogMsgReader struct {
+	path    string
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64
+	partial string
+}
+
+func newSyslogMsgReader(path string) (*syslogMsgReader, error) {
+	r := &syslogMsgReader{path: path}
+	if err := r.open(path); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *syslogMsgReader) open(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if r.file != nil {
+		r.file.Close()
+	}
+	r.file = file
+	r.reader = bufio.NewReader(file)
+	r.offset = 0
+	r.partial = ""
+	return nil
+}
+
+func (r *syslogMsgReader) close() {
+	if r.file != nil {
+		r.file.Close()
+	}
+}
+
+// readAvailable reads all complete messages currently available in the file.
+func (r *syslogMsgReader) readAvailable(callback func(api.SyslogMessage)) error {
+	for {
+		line, err := r.reader.ReadString('\n')
+		r.offset += int64(len(line))
+		if err == io.EOF {
+			// Incomplete line, wait for the rest.
+			r.partial += line
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = r.partial + line
+		r.partial = ""
+		var msg api.SyslogMessage
+		if err = json.Unmarshal([]byte(strings.TrimSpace(line)), &msg); err != nil {
+			log.Warnf("Failed to unmarshal stored syslog message: %v", err)
+			continue
+		}
+		callback(msg)
+	}
+}
+
+// reopenIfReplaced checks if the file was rotated or truncated (syslogsrv
+// restarted) and if so, switches to the new file.
+// Remaining messages of the rotated file should be read before calling this.
+func (r *syslogMsgReader) reopenIfReplaced() error {
+	pathInfo, err := os.Stat(r.path)
+	if err != nil {
+		// Not yet re-created after rotation.
+		return nil
+	}
+	fileInfo, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+	if os.SameFile(pathInfo, fileInfo) && fileInfo.Size() >= r.offset {
+		return nil
+	}
+	return r.open(r.path)
+}
+
+// getSyslogServer returns syslog server endpoint with the given label.
+// Called with agent in locked state.
+func (a *agent) getSyslogServer(label string) (api.SyslogServer, error) {
+	for _, syslogSrv := range a.netModel.Endpoints.SyslogServers {
+		if syslogSrv.LogicalLabel == label {
+			return syslogSrv, nil
+		}
+	}
+	if a.netModel.Items.GetItem(api.Endpoint{}.ItemType(), label) == nil {
+		return api.SyslogServer{}, fmt.Errorf("no endpoint with logical label %s", label)
+	}
+	return api.SyslogServer{}, fmt.Errorf("endpoint %s is not a syslog server", label)
+}
+
+// getEndpointLogs streams messages received by a syslog server endpoint,
+// formatted as newline-delimited JSON (one api.SyslogMessage per line).
+// With "follow=true", the response is kept open and new messages are streamed
+// as they arrive, until the client closes the connection.
+func (a *agent) getEndpointLogs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	label := vars["label"]
+	filter, err := parseSyslogFilter(r)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid log filter: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	follow := r.URL.Query().Get("follow") == "true"
+	a.Lock()
+	_, err = a.getSyslogServer(label)
+	a.Unlock()
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to get endpoint logs: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusNotFound)
+		return
+	}
+	// Start with older messages rotated out of the messages file. These are read
+	// before the messages file is opened, otherwise rotation in between would
+	// make the same messages to be read twice.
+	var rotatedMsgs []api.SyslogMessage
+	rotated, err := newSyslogMsgReader(configitems.SyslogRotatedMessagesFile(label))
+	if err == nil {
+		err = rotated.readAvailable(func(msg api.SyslogMessage) {
+			rotatedMsgs = append(rotatedMsgs, msg)
+		})
+		rotated.close()
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("Failed to read rotated messages of endpoint %s: %v", label, err)
+	}
+	msgReader, err := newSyslogMsgReader(configitems.SyslogMessagesFile(label))
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to open stored messages of endpoint %s: %v",
+			label, err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusServiceUnavailable)
+		return
+	}
+	defer msgReader.close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	var writeErr error
+	writeMsg := func(msg api.SyslogMessage) {
+		if writeErr == nil && filter.matches(msg) {
+			writeErr = encoder.Encode(msg)
+		}
+	}
+	for _, msg := range rotatedMsgs {
+		writeMsg(msg)
+	}
+	for {
+		if err = msgReader.readAvailable(writeMsg); err != nil {
+			log.Errorf("Failed to read messages of endpoint %s: %v", label, err)
+			return
+		}
+		if writeErr != nil {
+			log.Errorf("Failed to write messages of endpoint %s to HTTP response: %v",
+				label, writeErr)
+			return
+		}
+		if !follow {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(syslogFollowInterval):
+		}
+		// Read the rest of the file before switching to the new one.
+		if err = msgReader.readAvailable(writeMsg); err != nil {
+			log.Errorf("Failed to read messages of endpoint %s: %v", label, err)
+			return
+		}
+		if err = msgReader.reopenIfReplaced(); err != nil {
+			log.Errorf("Failed to reopen messages file of endpoint %s: %v", label, err)
+			return
+		}
+	}
+}
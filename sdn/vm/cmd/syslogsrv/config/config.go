@@ -0,0 +1,34 @@
+package config
+
+// SyslogServerConfig : syslog server configuration formatted with JSON and passed
+// to syslogsrv using the "-c" command line argument.
+type SyslogServerConfig struct {
+	// ListenIP : IP address to listen on.
+	ListenIP string `json:"listenIP"`
+	// UDPPort : UDP port to listen on (0 to disable).
+	UDPPort uint16 `json:"udpPort"`
+	// TCPPort : TCP port to listen on (0 to disable).
+	TCPPort uint16 `json:"tcpPort"`
+	// TLSPort : TCP port to listen on for TLS connections (0 to disable).
+	TLSPort uint16 `json:"tlsPort"`
+	// CertPEM : Server certificate in the PEM format, used for TLS.
+	// If empty, a self-signed certificate is generated.
+	CertPEM string `json:"certPEM"`
+	// KeyPEM : Server key in the PEM format, used for TLS.
+	KeyPEM string `json:"keyPEM"`
+	// ServerName : hostname (FQDN or IP address) of the server, used for
+	// the self-signed certificate.
+	ServerName string `json:"serverName"`
+	// MessagesFile : file to store received messages into (one JSON-encoded
+	// api.SyslogMessage per line). When the file reaches half of MaxMessages,
+	// it is rotated to "<MessagesFile>.1".
+	MessagesFile string `json:"messagesFile"`
+	// MaxMessages : maximum number of stored messages.
+	MaxMessages uint32 `json:"maxMessages"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// PidFile : file to write syslogsrv process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all received messages logged.
+	Verbose bool `json:"verbose"`
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+)
+
+const (
+	// Facility and severity used for messages without (valid) PRI (RFC 3164).
+	defaultFacility = 1 // user
+	defaultSeverity = 5 // notice
+	// Length of the RFC 3164 timestamp ("Mmm dd hh:mm:ss").
+	rfc3164TimestampLen = 15
+	// Nil value of RFC 5424 header fields.
+	nilValue = "-"
+	// UTF-8 byte order mark, which may precede RFC 5424 message.
+	utf8BOM = "\xef\xbb\xbf"
+)
+
+// parseSyslogMsg parses syslog message formatted according to RFC 5424
+// or according to the older BSD syslog format (RFC 3164).
+// Message which cannot be parsed is stored as a whole into Message.
+func parseSyslogMsg(raw string) (msg api.SyslogMessage) {
+	msg.Raw = raw
+	msg.Facility = defaultFacility
+	msg.Severity = defaultSeverity
+	msg.Message = raw
+	rest, ok := parsePRI(raw, &msg)
+	if !ok {
+		return msg
+	}
+	msg.Message = rest
+	if strings.HasPrefix(rest, "1 ") {
+		parseRFC5424(rest[2:], &msg)
+	} else {
+		parseRFC3164(rest, &msg)
+	}
+	return msg
+}
+
+// parsePRI parses "<PRI>" prefix and returns the rest of the message.
+func parsePRI(raw string, msg *api.SyslogMessage) (rest string, ok bool) {
+	if !strings.HasPrefix(raw, "<") {
+		return raw, false
+	}
+	end := strings.IndexByte(raw, '>')
+	if end < 2 || end > 4 {
+		return raw, false
+	}
+	pri, err := strconv.Atoi(raw[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return raw, false
+	}
+	msg.Facility = uint8(pri / 8)
+	msg.Severity = uint8(pri % 8)
+	return raw[end+1:], true
+}
+
+// parseRFC5424 parses message header (after VERSION), structured data and message:
+// TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]
+func parseRFC5424(rest string, msg *api.SyslogMessage) {
+	var fields [5]string
+	for i := range fields {
+		var field string
+		field, rest, _ = strings.Cut(rest, " ")
+		if field != nilValue {
+			fields[i] = field
+		}
+	}
+	msg.Timestamp = fields[0]
+	msg.Hostname = fields[1]
+	msg.AppName = fields[2]
+	msg.ProcID = fields[3]
+	msg.MsgID = fields[4]
+	if strings.HasPrefix(rest, nilValue) {
+		rest = rest[len(nilValue):]
+	} else {
+		sdLen := structuredDataLen(rest)
+		msg.StructuredData = rest[:sdLen]
+		rest = rest[sdLen:]
+	}
+	rest = strings.TrimPrefix(rest, " ")
+	msg.Message = strings.TrimPrefix(rest, utf8BOM)
+}
+
+// structuredDataLen returns the length of structured data (sequence
+// of SD-ELEMENTs enclosed in square brackets) at the beginning of the string.
+// Inside (quoted) parameter values, characters '"', '\' and ']' are escaped
+// with backslash.
+func structuredDataLen(rest string) int {
+	var inElement, inValue, escaped bool
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inValue && c == '\\':
+			escaped = true
+		case inElement && c == '"':
+			inValue = !inValue
+		case inValue:
+		case !inElement && c == '[':
+			inElement = true
+		case inElement && c == ']':
+			inElement = false
+		case !inElement:
+			return i
+		}
+	}
+	return len(rest)
+}
+
+// parseRFC3164 parses BSD syslog header and message:
+// [TIMESTAMP SP HOSTNAME SP] TAG[PID]: MSG
+func parseRFC3164(rest string, msg *api.SyslogMessage) {
+	if len(rest) > rfc3164TimestampLen && rest[rfc3164TimestampLen] == ' ' &&
+		rest[3] == ' ' && rest[9] == ':' && rest[12] == ':' {
+		msg.Timestamp = rest[:rfc3164TimestampLen]
+		rest = rest[rfc3164TimestampLen+1:]
+		// Hostname follows the timestamp, unless it is directly the tag.
+		if host, afterHost, found := strings.Cut(rest, " "); found &&
+			!strings.ContainsAny(host, ":[") {
+			msg.Hostname = host
+			rest = afterHost
+		}
+	}
+	tagEnd := strings.IndexAny(rest, ":[ ")
+	if tagEnd <= 0 || rest[tagEnd] == ' ' {
+		msg.Message = rest
+		return
+	}
+	msg.AppName = rest[:tagEnd]
+	rest = rest[tagEnd:]
+	if strings.HasPrefix(rest, "[") {
+		if pidEnd := strings.IndexByte(rest, ']'); pidEnd > 0 {
+			msg.ProcID = rest[1:pidEnd]
+			rest = rest[pidEnd+1:]
+		}
+	}
+	rest = strings.TrimPrefix(rest, ":")
+	msg.Message = strings.TrimPrefix(rest, " ")
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+)
+
+func TestParseSyslogMsg(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		raw      string
+		expected api.SyslogMessage
+	}{
+		{
+			name: "RFC 5424 with all fields",
+			raw: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 ` +
+				`[exampleSDID@32473 iut="3" eventSource="Application"] An application event`,
+			expected: api.SyslogMessage{
+				Facility:       20,
+				Severity:       5,
+				Timestamp:      "2003-10-11T22:14:15.003Z",
+				Hostname:       "mymachine.example.com",
+				AppName:        "evntslog",
+				ProcID:         "1234",
+				MsgID:          "ID47",
+				StructuredData: `[exampleSDID@32473 iut="3" eventSource="Application"]`,
+				Message:        "An application event",
+			},
+		},
+		{
+			name: "RFC 5424 with nil values and BOM",
+			raw:  "<34>1 - - su - ID47 - \xef\xbb\xbf'su root' failed",
+			expected: api.SyslogMessage{
+				Facility: 4,
+				Severity: 2,
+				AppName:  "su",
+				MsgID:    "ID47",
+				Message:  "'su root' failed",
+			},
+		},
+		{
+			name: "RFC 5424 with escaped structured data and no message",
+			raw:  `<13>1 2023-01-01T00:00:00Z host app - - [a@1 x="q\"]\\"][b@1]`,
+			expected: api.SyslogMessage{
+				Facility:       1,
+				Severity:       5,
+				Timestamp:      "2023-01-01T00:00:00Z",
+				Hostname:       "host",
+				AppName:        "app",
+				StructuredData: `[a@1 x="q\"]\\"][b@1]`,
+			},
+		},
+		{
+			name: "RFC 3164 with timestamp, hostname and PID",
+			raw:  "<38>Oct 11 22:14:15 mymachine sshd[1234]: Accepted publickey for root",
+			expected: api.SyslogMessage{
+				Facility:  4,
+				Severity:  6,
+				Timestamp: "Oct 11 22:14:15",
+				Hostname:  "mymachine",
+				AppName:   "sshd",
+				ProcID:    "1234",
+				Message:   "Accepted publickey for root",
+			},
+		},
+		{
+			name: "RFC 3164 without hostname",
+			raw:  "<13>Feb  5 17:32:18 kernel: link eth0 is up",
+			expected: api.SyslogMessage{
+				Facility:  1,
+				Severity:  5,
+				Timestamp: "Feb  5 17:32:18",
+				AppName:   "kernel",
+				Message:   "link eth0 is up",
+			},
+		},
+		{
+			name: "RFC 3164 without header",
+			raw:  "<14>just a message",
+			expected: api.SyslogMessage{
+				Facility: 1,
+				Severity: 6,
+				Message:  "just a message",
+			},
+		},
+		{
+			name: "missing PRI",
+			raw:  "Oct 11 22:14:15 mymachine sshd: no PRI",
+			expected: api.SyslogMessage{
+				Facility: defaultFacility,
+				Severity: defaultSeverity,
+				Message:  "Oct 11 22:14:15 mymachine sshd: no PRI",
+			},
+		},
+		{
+			name: "PRI out of range",
+			raw:  "<192>1 - - - - - - message",
+			expected: api.SyslogMessage{
+				Facility: defaultFacility,
+				Severity: defaultSeverity,
+				Message:  "<192>1 - - - - - - message",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			test.expected.Raw = test.raw
+			if msg := parseSyslogMsg(test.raw); msg != test.expected {
+				t.Errorf("parseSyslogMsg(%q) =\n%+v\nwant\n%+v", test.raw, msg, test.expected)
+			}
+		})
+	}
+}
+
+func TestReadFramedMsg(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		stream    string
+		expected  []string
+		expectErr bool // error (other than EOF) after the expected messages
+	}{
+		{
+			name:     "octet counting",
+			stream:   "11 <13>1 - - -16 <13>hello\nworld\n",
+			expected: []string{"<13>1 - - -", "<13>hello\nworld"},
+		},
+		{
+			name:     "LF framing",
+			stream:   "<13>first\n<13>second\r\n<13>last without LF",
+			expected: []string{"<13>first", "<13>second", "<13>last without LF"},
+		},
+		{
+			name:     "mixed framing",
+			stream:   "<13>lf framed\n9 <13>octet<13>lf again\n",
+			expected: []string{"<13>lf framed", "<13>octet", "<13>lf again"},
+		},
+		{
+			name:      "invalid length",
+			stream:    "9x <13>octet",
+			expectErr: true,
+		},
+		{
+			name:      "length over limit",
+			stream:    "99999999 <13>octet",
+			expectErr: true,
+		},
+		{
+			name:      "truncated octet-counted message",
+			stream:    "<13>complete\n20 <13>short",
+			expected:  []string{"<13>complete"},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			reader := bufio.NewReader(strings.NewReader(test.stream))
+			var msgs []string
+			var err error
+			for {
+				var msg string
+				msg, err = readFramedMsg(reader)
+				if err != nil {
+					break
+				}
+				msgs = append(msgs, msg)
+			}
+			if len(msgs) != len(test.expected) {
+				t.Fatalf("read messages %q, want %q", msgs, test.expected)
+			}
+			for i := range msgs {
+				if msgs[i] != test.expected[i] {
+					t.Errorf("message %d = %q, want %q", i, msgs[i], test.expected[i])
+				}
+			}
+			if gotErr := err != io.EOF; gotErr != test.expectErr {
+				t.Errorf("unexpected final error: %v", err)
+			}
+		})
+	}
+}
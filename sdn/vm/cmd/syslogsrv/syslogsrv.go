@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/syslogsrv/config"
+	"github.com/lf-edge/eden/sdn/vm/pkg/servercert"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Maximum size of a syslog message received over UDP.
+	maxUDPMsgLen = 65535
+	// Maximum size of a syslog message received over TCP/TLS.
+	maxTCPMsgLen = 1 << 20
+	// Timeout for reading the next message from a TCP/TLS connection.
+	tcpIdleTimeout = 10 * time.Minute
+)
+
+// Syslog server storing all received messages into a file, one JSON-encoded
+// api.SyslogMessage per line. Sdnagent reads the file to serve messages
+// to "eden sdn endpoint logs".
+type syslogServer struct {
+	config.SyslogServerConfig
+	sync.Mutex
+	msgFile    *os.File
+	msgCount   uint32
+	maxPerFile uint32
+	listenIP   net.IP
+}
+
+func main() {
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/syslogsrv.conf", "Syslog server config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var srvConfig config.SyslogServerConfig
+	if err = json.Unmarshal(configBytes, &srvConfig); err != nil {
+		log.Fatalf("failed to unmarshal syslog server config: %v", err)
+	}
+
+	// Process syslog server config.
+	if srvConfig.LogFile != "" {
+		logFile, err := os.OpenFile(srvConfig.LogFile, os.O_WRONLY|os.O_CREATE, 0755)
+		if err != nil {
+			log.Fatalf("failed to open log file %s: %v", srvConfig.LogFile, err)
+		}
+		log.SetOutput(logFile)
+	}
+	if srvConfig.Verbose {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+	srv := &syslogServer{
+		SyslogServerConfig: srvConfig,
+		listenIP:           net.ParseIP(srvConfig.ListenIP),
+		maxPerFile:         srvConfig.MaxMessages / 2,
+	}
+	if srv.listenIP == nil {
+		log.Fatalf("invalid listen IP: %s", srvConfig.ListenIP)
+	}
+	if srv.maxPerFile == 0 {
+		srv.maxPerFile = 1
+	}
+	if srvConfig.MessagesFile == "" {
+		log.Fatal("messages file is not configured")
+	}
+	// Start with empty storage.
+	_ = os.Remove(srv.rotatedMessagesFile())
+	srv.msgFile, err = os.Create(srvConfig.MessagesFile)
+	if err != nil {
+		log.Fatalf("failed to create messages file %s: %v", srvConfig.MessagesFile, err)
+	}
+
+	// Start listening before writing PID file.
+	if srvConfig.UDPPort != 0 {
+		udpConn, err := net.ListenPacket("udp", srv.listenAddr(srvConfig.UDPPort))
+		if err != nil {
+			log.Fatalf("failed to listen for syslog messages over UDP: %v", err)
+		}
+		go srv.serveUDP(udpConn)
+	}
+	if srvConfig.TCPPort != 0 {
+		tcpListener, err := net.Listen("tcp", srv.listenAddr(srvConfig.TCPPort))
+		if err != nil {
+			log.Fatalf("failed to listen for syslog messages over TCP: %v", err)
+		}
+		go srv.serveTCP(tcpListener, "tcp")
+	}
+	if srvConfig.TLSPort != 0 {
+		cert, err := servercert.GetCertificate(srv.CertPEM, srv.KeyPEM, srv.ServerName, srv.listenIP)
+		if err != nil {
+			log.Fatalf("failed to prepare server certificate: %v", err)
+		}
+		tlsConfig := &tls.Config{Certificates: []tls.Certificate{*cert}}
+		tlsListener, err := tls.Listen("tcp", srv.listenAddr(srvConfig.TLSPort), tlsConfig)
+		if err != nil {
+			log.Fatalf("failed to listen for syslog messages over TLS: %v", err)
+		}
+		go srv.serveTCP(tlsListener, "tls")
+	}
+	if srvConfig.PidFile != "" {
+		pidBytes := []byte(fmt.Sprintf("%d", os.Getpid()))
+		err = os.WriteFile(srvConfig.PidFile, pidBytes, 0664)
+		if err != nil {
+			log.Fatalf("failed to write PID file %s: %v", srvConfig.PidFile, err)
+		}
+		defer os.Remove(srvConfig.PidFile)
+	}
+
+	cancelChan := make(chan os.Signal, 1)
+	// Catch termination or interrupt signal.
+	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-cancelChan
+	log.Infof("Caught terimation/interrupt signal: %v, exiting...", sig)
+}
+
+func (s *syslogServer) listenAddr(port uint16) string {
+	return net.JoinHostPort(s.ListenIP, strconv.Itoa(int(port)))
+}
+
+func (s *syslogServer) rotatedMessagesFile() string {
+	return s.MessagesFile + ".1"
+}
+
+func (s *syslogServer) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, maxUDPMsgLen)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			log.Fatalf("failed to read UDP message: %v", err)
+		}
+		// Some senders terminate datagrams with newline or NUL.
+		raw := strings.TrimRight(string(buf[:n]), "\r\n\x00")
+		s.storeMessage(raw, addr.String(), "udp")
+	}
+}
+
+func (s *syslogServer) serveTCP(listener net.Listener, transport string) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Fatalf("failed to accept %s connection: %v", transport, err)
+		}
+		go s.serveTCPConn(conn, transport)
+	}
+}
+
+func (s *syslogServer) serveTCPConn(conn net.Conn, transport string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(tcpIdleTimeout))
+		raw, err := readFramedMsg(reader)
+		if err != nil {
+			if err != io.EOF {
+				log.Debugf("Closing %s connection from %v: %v",
+					transport, conn.RemoteAddr(), err)
+			}
+			return
+		}
+		if raw == "" {
+			continue
+		}
+		s.storeMessage(raw, conn.RemoteAddr().String(), transport)
+	}
+}
+
+// readFramedMsg reads the next syslog message from TCP/TLS stream.
+// Both octet-counting ("<length> <message>") and non-transparent framing
+// (message terminated by LF) are supported (RFC 6587). Framing is detected
+// for every message separately.
+func readFramedMsg(reader *bufio.Reader) (string, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return "", err
+	}
+	if first[0] >= '1' && first[0] <= '9' {
+		lenStr, err := reader.ReadString(' ')
+		if err != nil {
+			return "", err
+		}
+		msgLen, err := strconv.Atoi(strings.TrimSuffix(lenStr, " "))
+		if err != nil || msgLen > maxTCPMsgLen {
+			return "", fmt.Errorf("invalid message length: %q", lenStr)
+		}
+		msg := make([]byte, msgLen)
+		if _, err = io.ReadFull(reader, msg); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(msg), "\r\n"), nil
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n\x00"), nil
+}
+
+// storeMessage parses and appends message into the messages file.
+func (s *syslogServer) storeMessage(raw, source, transport string) {
+	msg := parseSyslogMsg(raw)
+	msg.ReceivedAt = time.Now()
+	msg.Source = source
+	msg.Transport = transport
+	log.Debugf("Received syslog message from %s over %s: %s", source, transport, raw)
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		log.Errorf("Failed to marshal syslog message: %v", err)
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.msgCount >= s.maxPerFile {
+		if err = s.rotate(); err != nil {
+			log.Errorf("Failed to rotate messages file: %v", err)
+			return
+		}
+	}
+	if _, err = s.msgFile.Write(append(msgBytes, '\n')); err != nil {
+		log.Errorf("Failed to store syslog message: %v", err)
+		return
+	}
+	s.msgCount++
+}
+
+// rotate moves the current messages file to "<MessagesFile>.1"
+// (replacing older messages) and opens a new one.
+func (s *syslogServer) rotate() error {
+	_ = s.msgFile.Close()
+	if err := os.Rename(s.MessagesFile, s.rotatedMessagesFile()); err != nil {
+		return err
+	}
+	msgFile, err := os.Create(s.MessagesFile)
+	if err != nil {
+		return err
+	}
+	s.msgFile = msgFile
+	s.msgCount = 0
+	return nil
+}
@@ -0,0 +1,201 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	portalcfg "github.com/lf-edge/eden/sdn/vm/cmd/captiveportal/config"
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	captivePortalBinary  = "/bin/captiveportal"
+	captivePortalConfDir = "/etc/captiveportal"
+	captivePortalRunDir  = "/run/captiveportal"
+
+	captivePortalStartTimeout = 3 * time.Second
+	captivePortalStopTimeout  = 10 * time.Second
+
+	// CaptivePortalIPSet : name of the IP set with clients logged into captive portal.
+	// Created inside the network namespace of every network with captive portal.
+	CaptivePortalIPSet = "captive-portal-clients"
+)
+
+// CaptivePortal : captive portal intercepting HTTP(S) and DNS traffic of clients
+// that have not logged in yet.
+type CaptivePortal struct {
+	// PortalName : logical name for the captive portal.
+	PortalName string
+	// NetNamespace : network namespace where the portal should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the portal operates.
+	VethName string
+	// ListenIP : IP address on which the portal should listen.
+	ListenIP net.IP
+	// PortalHost : hostname (FQDN or IP address) of the portal, used to redirect
+	// clients to the login page.
+	PortalHost string
+	// CertPEM : Portal certificate in the PEM format (optional).
+	CertPEM string
+	// KeyPEM : Portal key in the PEM format (optional).
+	KeyPEM string
+	// ClientNetworks : networks with clients captured by the portal.
+	ClientNetworks []portalcfg.ClientNetwork
+}
+
+// Name
+func (p CaptivePortal) Name() string {
+	return p.PortalName
+}
+
+// Label
+func (p CaptivePortal) Label() string {
+	return p.PortalName + " (captive portal)"
+}
+
+// Type
+func (p CaptivePortal) Type() string {
+	return CaptivePortalTypename
+}
+
+// Equal is a comparison method for two equally-named CaptivePortal instances.
+func (p CaptivePortal) Equal(other depgraph.Item) bool {
+	p2 := other.(CaptivePortal)
+	return reflect.DeepEqual(p, p2)
+}
+
+// External returns false.
+func (p CaptivePortal) External() bool {
+	return false
+}
+
+// String describes the captive portal.
+func (p CaptivePortal) String() string {
+	return fmt.Sprintf("Captive portal: {PortalName: %s, NetNamespace: %s, "+
+		"VethName: %s, ListenIP: %v, PortalHost: %s, ClientNetworks: %+v}",
+		p.PortalName, p.NetNamespace, p.VethName, p.ListenIP, p.PortalHost,
+		p.ClientNetworks)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (p CaptivePortal) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(p.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: p.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+	}
+}
+
+// CaptivePortalConfigurator implements Configurator interface for CaptivePortal.
+type CaptivePortalConfigurator struct{}
+
+// Create starts captiveportal (see sdn/cmd/captiveportal).
+func (c *CaptivePortalConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(CaptivePortal)
+	if err := c.createConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		if err := ensureDir(captivePortalRunDir); err != nil {
+			done(err)
+			return
+		}
+		args := []string{"-c", captivePortalConfigPath(config.PortalName)}
+		pidFile := captivePortalPidFile(config.PortalName)
+		err := startProcess(config.NetNamespace, captivePortalBinary, args, pidFile,
+			captivePortalStartTimeout, true)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *CaptivePortalConfigurator) createConfFile(portal CaptivePortal) error {
+	if err := ensureDir(captivePortalConfDir); err != nil {
+		return err
+	}
+	config := portalcfg.CaptivePortalConfig{
+		ListenIP:       portal.ListenIP.String(),
+		PortalHost:     portal.PortalHost,
+		LogFile:        captivePortalLogFile(portal.PortalName),
+		PidFile:        captivePortalPidFile(portal.PortalName),
+		Verbose:        true,
+		CertPEM:        portal.CertPEM,
+		KeyPEM:         portal.KeyPEM,
+		ClientNetworks: portal.ClientNetworks,
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	cfgPath := captivePortalConfigPath(portal.PortalName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *CaptivePortalConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops captiveportal.
+func (c *CaptivePortalConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(CaptivePortal)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		pidFile := captivePortalPidFile(config.PortalName)
+		err := stopProcess(pidFile, captivePortalStopTimeout)
+		if err == nil {
+			// ignore errors from here
+			_ = os.Remove(captivePortalConfigPath(config.PortalName))
+			_ = os.Remove(captivePortalLogFile(config.PortalName))
+			_ = os.Remove(pidFile)
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *CaptivePortalConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func captivePortalConfigPath(portalName string) string {
+	return filepath.Join(captivePortalConfDir, portalName+".conf")
+}
+
+func captivePortalPidFile(portalName string) string {
+	return filepath.Join(captivePortalRunDir, portalName+".pid")
+}
+
+func captivePortalLogFile(portalName string) string {
+	return filepath.Join(captivePortalRunDir, portalName+".log")
+}
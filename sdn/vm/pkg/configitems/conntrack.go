@@ -0,0 +1,38 @@
+package configitems
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// ListConntrackFlows returns all IPv4 and IPv6 connections tracked by netfilter
+// inside the given network namespace.
+func ListConntrackFlows(netNs string) (flows []*netlink.ConntrackFlow, err error) {
+	handle := &netlink.Handle{}
+	if normNetNsName(netNs) != MainNsName {
+		nsHandle, err := netns.GetFromName(netNs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get handle for network namespace %s: %w",
+				netNs, err)
+		}
+		defer nsHandle.Close()
+		handle, err = netlink.NewHandleAt(nsHandle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create netlink handle for network "+
+				"namespace %s: %w", netNs, err)
+		}
+		defer handle.Delete()
+	}
+	for _, family := range []netlink.InetFamily{syscall.AF_INET, syscall.AF_INET6} {
+		familyFlows, err := handle.ConntrackTableList(netlink.ConntrackTable, family)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list conntrack table (family %d) "+
+				"in network namespace %s: %w", family, normNetNsName(netNs), err)
+		}
+		flows = append(flows, familyFlows...)
+	}
+	return flows, nil
+}
@@ -0,0 +1,194 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	dns64cfg "github.com/lf-edge/eden/sdn/vm/cmd/dns64proxy/config"
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	dns64ProxyBinary  = "/bin/dns64proxy"
+	dns64ProxyConfDir = "/etc/dns64proxy"
+	dns64ProxyRunDir  = "/run/dns64proxy"
+
+	dns64ProxyStartTimeout = 3 * time.Second
+	dns64ProxyStopTimeout  = 10 * time.Second
+)
+
+// Dns64Proxy : DNS64 proxy synthesizing AAAA records for IPv4-only domain names
+// (see sdn/cmd/dns64proxy).
+type Dns64Proxy struct {
+	// ProxyName : logical name for the DNS64 proxy.
+	ProxyName string
+	// NetNamespace : network namespace where the proxy should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the proxy operates.
+	VethName string
+	// ListenIP : IP address on which the proxy should listen.
+	ListenIP net.IP
+	// Upstreams : DNS servers to forward queries to.
+	Upstreams []net.IP
+	// Prefix : NAT64 prefix used to synthesize AAAA records.
+	Prefix *net.IPNet
+}
+
+// Name
+func (p Dns64Proxy) Name() string {
+	return p.ProxyName
+}
+
+// Label
+func (p Dns64Proxy) Label() string {
+	return p.ProxyName + " (DNS64 proxy)"
+}
+
+// Type
+func (p Dns64Proxy) Type() string {
+	return Dns64ProxyTypename
+}
+
+// Equal is a comparison method for two equally-named Dns64Proxy instances.
+func (p Dns64Proxy) Equal(other depgraph.Item) bool {
+	p2 := other.(Dns64Proxy)
+	return reflect.DeepEqual(p, p2)
+}
+
+// External returns false.
+func (p Dns64Proxy) External() bool {
+	return false
+}
+
+// String describes the DNS64 proxy.
+func (p Dns64Proxy) String() string {
+	return fmt.Sprintf("DNS64 proxy: {ProxyName: %s, NetNamespace: %s, "+
+		"VethName: %s, ListenIP: %v, Upstreams: %v, Prefix: %v}",
+		p.ProxyName, p.NetNamespace, p.VethName, p.ListenIP, p.Upstreams, p.Prefix)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (p Dns64Proxy) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(p.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: p.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+	}
+}
+
+// Dns64ProxyConfigurator implements Configurator interface for Dns64Proxy.
+type Dns64ProxyConfigurator struct{}
+
+// Create starts dns64proxy.
+func (c *Dns64ProxyConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(Dns64Proxy)
+	if err := c.createConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		if err := ensureDir(dns64ProxyRunDir); err != nil {
+			done(err)
+			return
+		}
+		args := []string{"-c", dns64ProxyConfigPath(config.ProxyName)}
+		pidFile := dns64ProxyPidFile(config.ProxyName)
+		err := startProcess(config.NetNamespace, dns64ProxyBinary, args, pidFile,
+			dns64ProxyStartTimeout, true)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *Dns64ProxyConfigurator) createConfFile(proxy Dns64Proxy) error {
+	if err := ensureDir(dns64ProxyConfDir); err != nil {
+		return err
+	}
+	var upstreams []string
+	for _, upstream := range proxy.Upstreams {
+		upstreams = append(upstreams, net.JoinHostPort(upstream.String(), "53"))
+	}
+	config := dns64cfg.DNS64ProxyConfig{
+		ListenIP:   proxy.ListenIP.String(),
+		ListenPort: 53,
+		Upstreams:  upstreams,
+		Prefix:     proxy.Prefix.String(),
+		LogFile:    dns64ProxyLogFile(proxy.ProxyName),
+		PidFile:    dns64ProxyPidFile(proxy.ProxyName),
+		Verbose:    true,
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	cfgPath := dns64ProxyConfigPath(proxy.ProxyName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *Dns64ProxyConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops dns64proxy.
+func (c *Dns64ProxyConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(Dns64Proxy)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		pidFile := dns64ProxyPidFile(config.ProxyName)
+		err := stopProcess(pidFile, dns64ProxyStopTimeout)
+		if err == nil {
+			// ignore errors from here
+			_ = os.Remove(dns64ProxyConfigPath(config.ProxyName))
+			_ = os.Remove(dns64ProxyLogFile(config.ProxyName))
+			_ = os.Remove(pidFile)
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *Dns64ProxyConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func dns64ProxyConfigPath(proxyName string) string {
+	return filepath.Join(dns64ProxyConfDir, proxyName+".conf")
+}
+
+func dns64ProxyPidFile(proxyName string) string {
+	return filepath.Join(dns64ProxyRunDir, proxyName+".pid")
+}
+
+func dns64ProxyLogFile(proxyName string) string {
+	return filepath.Join(dns64ProxyRunDir, proxyName+".log")
+}
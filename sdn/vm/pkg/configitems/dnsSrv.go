@@ -2,17 +2,37 @@ package configitems
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"time"
 
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	dnsfaultcfg "github.com/lf-edge/eden/sdn/vm/cmd/dnsfaultproxy/config"
 	"github.com/lf-edge/eve/libs/depgraph"
 	"github.com/lf-edge/eve/libs/reconciler"
 	log "github.com/sirupsen/logrus"
 )
 
-const dnsSrvNamePrefix = "dnssrv-"
+const (
+	dnsSrvNamePrefix = "dnssrv-"
+
+	dnsFaultProxyBinary  = "/bin/dnsfaultproxy"
+	dnsFaultProxyConfDir = "/etc/dnsfaultproxy"
+	dnsFaultProxyRunDir  = "/run/dnsfaultproxy"
+
+	dnsFaultProxyStartTimeout = 3 * time.Second
+	dnsFaultProxyStopTimeout  = 10 * time.Second
+
+	// With fault rules, dnsmasq is moved to this port and dnsfaultproxy
+	// listens on the standard DNS port instead.
+	dnsmasqBehindProxyPort = 5353
+)
 
 // DnsServer : DNS server.
 type DnsServer struct {
@@ -31,6 +51,13 @@ type DnsServer struct {
 	// UpstreamServers : list of IP addresses of public DNS servers to forward
 	// requests to (unless there is a static entry).
 	UpstreamServers []net.IP
+	// ListenIP : IP address of the server. Used only with FaultRules.
+	ListenIP net.IP
+	// FaultRules : rules injecting faults into DNS responses.
+	// With symbolic references already translated to FQDNs and IPs.
+	// If non-empty, dnsfaultproxy (see sdn/cmd/dnsfaultproxy) is started in front
+	// of dnsmasq.
+	FaultRules []sdnapi.DNSFaultRule
 }
 
 // DnsEntry : Mapping between FQDN and an IP address.
@@ -76,7 +103,9 @@ func (s DnsServer) Equal(other depgraph.Item) bool {
 	}
 	return s.NetNamespace == s2.NetNamespace &&
 		s.VethName == s2.VethName &&
-		s.VethPeerIfName == s2.VethPeerIfName
+		s.VethPeerIfName == s2.VethPeerIfName &&
+		s.ListenIP.Equal(s2.ListenIP) &&
+		reflect.DeepEqual(s.FaultRules, s2.FaultRules)
 }
 
 // External returns false.
@@ -112,15 +141,25 @@ func (s DnsServer) Dependencies() (deps []depgraph.Dependency) {
 // DnsServerConfigurator implements Configurator interface for DnsServer.
 type DnsServerConfigurator struct{}
 
-// Create starts dnsmasq (in DNS-only mode).
+// Create starts dnsmasq (in DNS-only mode), with dnsfaultproxy in front of it
+// if there are any fault rules.
 func (c *DnsServerConfigurator) Create(ctx context.Context, item depgraph.Item) error {
 	config := item.(DnsServer)
 	if err := c.createDnsmasqConfFile(config); err != nil {
 		return err
 	}
+	withProxy := len(config.FaultRules) > 0
+	if withProxy {
+		if err := c.createDNSFaultProxyConfFile(config); err != nil {
+			return err
+		}
+	}
 	done := reconciler.ContinueInBackground(ctx)
 	go func() {
 		err := startDnsmasq(dnsSrvNamePrefix+config.ServerName, config.NetNamespace)
+		if err == nil && withProxy {
+			err = startDNSFaultProxy(config.ServerName, config.NetNamespace)
+		}
 		done(err)
 	}()
 	return nil
@@ -145,6 +184,10 @@ func (c *DnsServerConfigurator) createDnsmasqConfFile(server DnsServer) error {
 	file.WriteString(fmt.Sprintf("interface=%s\n", server.VethPeerIfName))
 	// Disable DHCP.
 	file.WriteString(fmt.Sprintf("no-dhcp-interface=%s\n", server.VethPeerIfName))
+	if len(server.FaultRules) > 0 {
+		// Queries are received by dnsfaultproxy first.
+		file.WriteString(fmt.Sprintf("port=%d\n", dnsmasqBehindProxyPort))
+	}
 	// Logging.
 	file.WriteString("log-queries\n")
 	file.WriteString(fmt.Sprintf("log-facility=%s\n", dnsmasqLogFile(srvName)))
@@ -166,6 +209,37 @@ func (c *DnsServerConfigurator) createDnsmasqConfFile(server DnsServer) error {
 	return nil
 }
 
+func (c *DnsServerConfigurator) createDNSFaultProxyConfFile(server DnsServer) error {
+	if err := ensureDir(dnsFaultProxyConfDir); err != nil {
+		return err
+	}
+	serverName := server.ServerName
+	config := dnsfaultcfg.DNSFaultProxyConfig{
+		ListenIP:   server.ListenIP.String(),
+		ListenPort: 53,
+		UpstreamAddr: net.JoinHostPort(server.ListenIP.String(),
+			strconv.Itoa(dnsmasqBehindProxyPort)),
+		LogFile: dnsFaultProxyLogFile(serverName),
+		PidFile: dnsFaultProxyPidFile(serverName),
+		Verbose: true,
+		Rules:   server.FaultRules,
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	cfgPath := dnsFaultProxyConfigPath(serverName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
 // Modify is not implemented.
 func (c *DnsServerConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
 	return errors.New("not implemented")
@@ -176,6 +250,14 @@ func (c *DnsServerConfigurator) Delete(ctx context.Context, item depgraph.Item)
 	config := item.(DnsServer)
 	done := reconciler.ContinueInBackground(ctx)
 	go func() {
+		if len(config.FaultRules) > 0 {
+			// Stop dnsmasq even if the proxy is already gone (error is logged).
+			_ = stopProcess(dnsFaultProxyPidFile(config.ServerName),
+				dnsFaultProxyStopTimeout)
+			_ = os.Remove(dnsFaultProxyConfigPath(config.ServerName))
+			_ = os.Remove(dnsFaultProxyLogFile(config.ServerName))
+			_ = os.Remove(dnsFaultProxyPidFile(config.ServerName))
+		}
 		srvName := dnsSrvNamePrefix + config.ServerName
 		err := stopDnsmasq(srvName)
 		if err == nil {
@@ -193,3 +275,28 @@ func (c *DnsServerConfigurator) Delete(ctx context.Context, item depgraph.Item)
 func (c *DnsServerConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
 	return true
 }
+
+func dnsFaultProxyConfigPath(srvName string) string {
+	return filepath.Join(dnsFaultProxyConfDir, srvName+".conf")
+}
+
+func dnsFaultProxyPidFile(srvName string) string {
+	return filepath.Join(dnsFaultProxyRunDir, srvName+".pid")
+}
+
+func dnsFaultProxyLogFile(srvName string) string {
+	return filepath.Join(dnsFaultProxyRunDir, srvName+".log")
+}
+
+func startDNSFaultProxy(srvName, netNamespace string) error {
+	if err := ensureDir(dnsFaultProxyRunDir); err != nil {
+		return err
+	}
+	args := []string{
+		"-c",
+		dnsFaultProxyConfigPath(srvName),
+	}
+	pidFile := dnsFaultProxyPidFile(srvName)
+	return startProcess(netNamespace, dnsFaultProxyBinary, args, pidFile,
+		dnsFaultProxyStartTimeout, true)
+}
@@ -0,0 +1,158 @@
+package configitems
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lf-edge/eve/libs/depgraph"
+	log "github.com/sirupsen/logrus"
+)
+
+const ipsetBinary = "ipset"
+
+// IPSet : IP set (see "man ipset") that can be referenced from iptables rules.
+// Only the set itself is managed by the reconciler, members can be added and removed
+// in run-time (e.g. by a captive portal).
+type IPSet struct {
+	// NetNamespace : network namespace where the set should be created.
+	NetNamespace string
+	// SetName : name of the IP set.
+	SetName string
+	// SetType : type of the IP set, e.g. "hash:ip" or "hash:net".
+	SetType string
+	// ForIPv6 : create set for IPv6 addresses (family inet6).
+	ForIPv6 bool
+}
+
+// Name
+func (s IPSet) Name() string {
+	return fmt.Sprintf("%s/%s", normNetNsName(s.NetNamespace), s.SetName)
+}
+
+// Label
+func (s IPSet) Label() string {
+	return s.Name() + " (IP set)"
+}
+
+// Type
+func (s IPSet) Type() string {
+	return IPSetTypename
+}
+
+// Equal is a comparison method for two equally-named IPSet instances.
+func (s IPSet) Equal(other depgraph.Item) bool {
+	s2 := other.(IPSet)
+	return reflect.DeepEqual(s, s2)
+}
+
+// External returns false.
+func (s IPSet) External() bool {
+	return false
+}
+
+// String describes the IP set.
+func (s IPSet) String() string {
+	return fmt.Sprintf("IP set: {NetNamespace: %s, SetName: %s, SetType: %s, ForIPv6: %t}",
+		normNetNsName(s.NetNamespace), s.SetName, s.SetType, s.ForIPv6)
+}
+
+// Dependencies lists the network namespace as the only dependency.
+func (s IPSet) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(s.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+	}
+}
+
+// IPSetConfigurator implements Configurator interface for IPSet.
+type IPSetConfigurator struct{}
+
+// Create creates an empty IP set.
+func (c *IPSetConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(IPSet)
+	family := "inet"
+	if config.ForIPv6 {
+		family = "inet6"
+	}
+	_, err := runIPSetCmd(config.NetNamespace, "create", config.SetName, config.SetType,
+		"family", family)
+	return err
+}
+
+// Modify is not implemented.
+func (c *IPSetConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete destroys the IP set.
+func (c *IPSetConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(IPSet)
+	_, err := runIPSetCmd(config.NetNamespace, "destroy", config.SetName)
+	return err
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *IPSetConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+// AddIPSetEntry adds IP address or subnet into the given IP set.
+// It is not an error if the entry is already added.
+func AddIPSetEntry(netNs, setName, entry string) error {
+	_, err := runIPSetCmd(netNs, "add", setName, entry, "-exist")
+	return err
+}
+
+// DelIPSetEntry removes IP address or subnet from the given IP set.
+// It is not an error if the entry is not in the set.
+func DelIPSetEntry(netNs, setName, entry string) error {
+	_, err := runIPSetCmd(netNs, "del", setName, entry, "-exist")
+	return err
+}
+
+// FlushIPSet removes all entries from the given IP set.
+func FlushIPSet(netNs, setName string) error {
+	_, err := runIPSetCmd(netNs, "flush", setName)
+	return err
+}
+
+// ListIPSetEntries returns all entries of the given IP set.
+func ListIPSetEntries(netNs, setName string) (entries []string, err error) {
+	out, err := runIPSetCmd(netNs, "list", setName, "-output", "plain")
+	if err != nil {
+		return nil, err
+	}
+	var members bool
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if members && line != "" {
+			entries = append(entries, line)
+		}
+		if line == "Members:" {
+			members = true
+		}
+	}
+	return entries, nil
+}
+
+func runIPSetCmd(netNs string, args ...string) ([]byte, error) {
+	out, err := namespacedCmd(netNs, ipsetBinary, args...).CombinedOutput()
+	if err != nil {
+		err = fmt.Errorf("ipset %s failed: %s (%w)", strings.Join(args, " "),
+			strings.TrimSpace(string(out)), err)
+		log.Error(err)
+		return nil, err
+	}
+	return out, nil
+}
@@ -24,6 +24,8 @@ type IptablesChain struct {
 	RefersChains []string
 	// RefersVeths : names of VETH interfaces referred from rules.
 	RefersVeths []string
+	// RefersIPSets : names of IP sets (from the same namespace) referred from rules.
+	RefersIPSets []string
 	// PreCreated : a custom chain which already exists (as empty).
 	PreCreated bool
 }
@@ -82,7 +84,8 @@ func (ch IptablesChain) String() string {
 	return str
 }
 
-// Dependencies lists all referenced chains + net namespace as dependencies.
+// Dependencies lists all referenced chains, veths, IP sets + net namespace
+// as dependencies.
 func (ch IptablesChain) Dependencies() (deps []depgraph.Dependency) {
 	for _, referredChain := range ch.RefersChains {
 		deps = append(deps, depgraph.Dependency{
@@ -103,6 +106,15 @@ func (ch IptablesChain) Dependencies() (deps []depgraph.Dependency) {
 			Description: "veth interface must exist",
 		})
 	}
+	for _, referredIPSet := range ch.RefersIPSets {
+		deps = append(deps, depgraph.Dependency{
+			RequiredItem: depgraph.Reference(IPSet{
+				NetNamespace: ch.NetNamespace,
+				SetName:      referredIPSet,
+			}),
+			Description: "IP set must exist",
+		})
+	}
 	deps = append(deps, depgraph.Dependency{
 		RequiredItem: depgraph.ItemRef{
 			ItemType: NetNamespaceTypename,
@@ -0,0 +1,391 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	keaDhcp6Binary       = "kea-dhcp6"
+	keaRunScriptHook     = "/usr/lib/kea/hooks/libdhcp_run_script.so"
+	keaDhcp6StartTimeout = 5 * time.Second
+	keaDhcp6StopTimeout  = 10 * time.Second
+	keaConfDir           = "/etc/kea"
+	keaRunDir            = "/run/kea"
+)
+
+// Script executed by Kea (using the run_script hook) whenever an IPv6 lease
+// is committed, released or expires. It maintains routes for delegated prefixes,
+// pointing to the (link-local) address of the requesting router.
+const keaPDRouteScript = `#!/bin/sh
+# Generated by SDN agent
+# Do not edit
+IFNAME="%s"
+case "$1" in
+  leases6_committed)
+    i=0
+    while [ "$i" -lt "${LEASES6_SIZE:-0}" ]; do
+      eval TYPE="\$LEASES6_AT${i}_TYPE"
+      eval PREFIX="\$LEASES6_AT${i}_ADDRESS"
+      eval PREFIX_LEN="\$LEASES6_AT${i}_PREFIX_LEN"
+      if [ "$TYPE" = "IA_PD" ] && [ -n "$QUERY6_REMOTE_ADDR" ]; then
+        ip -6 route replace "$PREFIX/$PREFIX_LEN" via "$QUERY6_REMOTE_ADDR" dev "$IFNAME"
+      fi
+      i=$((i+1))
+    done
+    ;;
+  lease6_release|lease6_expire)
+    if [ "$LEASE6_TYPE" = "IA_PD" ]; then
+      ip -6 route del "$LEASE6_ADDRESS/$LEASE6_PREFIX_LEN" dev "$IFNAME"
+    fi
+    ;;
+esac
+exit 0
+`
+
+// KeaDhcp6 : DHCPv6 server (Kea), used for stateless/stateful DHCPv6
+// and for DHCPv6 prefix delegation.
+type KeaDhcp6 struct {
+	// ServerName : logical name for the DHCPv6 server.
+	ServerName string
+	// NetNamespace : network namespace where the server should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the server operates.
+	VethName string
+	// VethPeerIfName : interface name of that side of the veth pair on which
+	// the server should listen. It should be inside NetNamespace.
+	VethPeerIfName string
+	// Subnet : IPv6 network address + prefix length.
+	Subnet *net.IPNet
+	// IPRange : a range of IPv6 addresses to allocate from (stateful DHCPv6).
+	// Leave empty to run stateless DHCPv6 server.
+	IPRange IPRange
+	// StaticEntries : list of MAC->IP entries statically configured for the server.
+	StaticEntries []MACToIP
+	// DomainName : name of the domain assigned to the network (option 24).
+	DomainName string
+	// DNSServers : list of IPv6 addresses of DNS servers (option 23).
+	DNSServers []net.IP
+	// NTPServer : IPv6 address of NTP server (option 31).
+	NTPServer net.IP
+	// PDPrefix : prefix from which prefixes are delegated (DHCPv6-PD).
+	// Leave nil to disable prefix delegation.
+	PDPrefix *net.IPNet
+	// PDDelegatedLen : length of delegated prefixes.
+	PDDelegatedLen uint8
+}
+
+// Name
+func (s KeaDhcp6) Name() string {
+	return s.ServerName
+}
+
+// Label
+func (s KeaDhcp6) Label() string {
+	return s.ServerName + " (DHCPv6 server)"
+}
+
+// Type
+func (s KeaDhcp6) Type() string {
+	return KeaDhcp6Typename
+}
+
+// Equal is a comparison method for two equally-named KeaDhcp6 instances.
+func (s KeaDhcp6) Equal(other depgraph.Item) bool {
+	s2 := other.(KeaDhcp6)
+	return reflect.DeepEqual(s, s2)
+}
+
+// External returns false.
+func (s KeaDhcp6) External() bool {
+	return false
+}
+
+// String describes the DHCPv6 server config.
+func (s KeaDhcp6) String() string {
+	return fmt.Sprintf("Kea DHCPv6 Server: %#+v", s)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (s KeaDhcp6) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(s.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: s.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+	}
+}
+
+// KeaDhcp6Configurator implements Configurator interface for KeaDhcp6.
+type KeaDhcp6Configurator struct{}
+
+// Kea configuration (only the subset used by SDN agent).
+type keaConfig struct {
+	Dhcp6 keaDhcp6Config `json:"Dhcp6"`
+}
+
+type keaDhcp6Config struct {
+	InterfacesConfig keaInterfaces `json:"interfaces-config"`
+	LeaseDatabase    keaLeaseDB    `json:"lease-database"`
+	OptionData       []keaOption   `json:"option-data,omitempty"`
+	Subnet6          []keaSubnet6  `json:"subnet6"`
+	HooksLibraries   []keaHookLib  `json:"hooks-libraries,omitempty"`
+	Loggers          []keaLogger   `json:"loggers"`
+}
+
+type keaInterfaces struct {
+	Interfaces []string `json:"interfaces"`
+}
+
+type keaLeaseDB struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Persist bool   `json:"persist"`
+}
+
+type keaOption struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+type keaSubnet6 struct {
+	ID           uint32           `json:"id"`
+	Subnet       string           `json:"subnet"`
+	Interface    string           `json:"interface"`
+	Pools        []keaPool        `json:"pools,omitempty"`
+	PDPools      []keaPDPool      `json:"pd-pools,omitempty"`
+	Reservations []keaReservation `json:"reservations,omitempty"`
+}
+
+type keaPool struct {
+	Pool string `json:"pool"`
+}
+
+type keaPDPool struct {
+	Prefix       string `json:"prefix"`
+	PrefixLen    int    `json:"prefix-len"`
+	DelegatedLen uint8  `json:"delegated-len"`
+}
+
+type keaReservation struct {
+	HWAddress   string   `json:"hw-address"`
+	IPAddresses []string `json:"ip-addresses"`
+}
+
+type keaHookLib struct {
+	Library    string                 `json:"library"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+type keaLogger struct {
+	Name          string            `json:"name"`
+	OutputOptions []keaOutputOption `json:"output_options"`
+	Severity      string            `json:"severity"`
+	DebugLevel    int               `json:"debuglevel"`
+}
+
+type keaOutputOption struct {
+	Output string `json:"output"`
+}
+
+// Create starts kea-dhcp6.
+func (c *KeaDhcp6Configurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(KeaDhcp6)
+	if err := ensureDir(keaRunDir); err != nil {
+		return err
+	}
+	if err := c.createConfFiles(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		args := []string{
+			"KEA_PIDFILE_DIR=" + keaRunDir,
+			"KEA_LOCKFILE_DIR=" + keaRunDir,
+			keaDhcp6Binary, "-c", keaDhcp6ConfigPath(config.ServerName),
+		}
+		err := startProcess(config.NetNamespace, "env", args,
+			keaDhcp6PidFile(config.ServerName), keaDhcp6StartTimeout, true)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *KeaDhcp6Configurator) createConfFiles(server KeaDhcp6) error {
+	if err := ensureDir(keaConfDir); err != nil {
+		return err
+	}
+	subnet := keaSubnet6{
+		ID:        1,
+		Subnet:    server.Subnet.String(),
+		Interface: server.VethPeerIfName,
+	}
+	if server.IPRange.FromIP != nil && server.IPRange.ToIP != nil {
+		subnet.Pools = append(subnet.Pools, keaPool{
+			Pool: fmt.Sprintf("%s-%s", server.IPRange.FromIP, server.IPRange.ToIP),
+		})
+		for _, entry := range server.StaticEntries {
+			subnet.Reservations = append(subnet.Reservations, keaReservation{
+				HWAddress:   entry.MAC.String(),
+				IPAddresses: []string{entry.IP.String()},
+			})
+		}
+	}
+	var hooks []keaHookLib
+	if server.PDPrefix != nil {
+		prefixLen, _ := server.PDPrefix.Mask.Size()
+		subnet.PDPools = append(subnet.PDPools, keaPDPool{
+			Prefix:       server.PDPrefix.IP.String(),
+			PrefixLen:    prefixLen,
+			DelegatedLen: server.PDDelegatedLen,
+		})
+		scriptPath := keaDhcp6ScriptPath(server.ServerName)
+		script := fmt.Sprintf(keaPDRouteScript, server.VethPeerIfName)
+		if err := os.WriteFile(scriptPath, []byte(script), 0755); err != nil {
+			err = fmt.Errorf("failed to create script %s: %w", scriptPath, err)
+			log.Error(err)
+			return err
+		}
+		hooks = append(hooks, keaHookLib{
+			Library: keaRunScriptHook,
+			Parameters: map[string]interface{}{
+				"name": scriptPath,
+				"sync": false,
+			},
+		})
+	}
+	var options []keaOption
+	if len(server.DNSServers) > 0 {
+		var servers []string
+		for _, srvIP := range server.DNSServers {
+			servers = append(servers, srvIP.String())
+		}
+		options = append(options, keaOption{
+			Name: "dns-servers",
+			Data: strings.Join(servers, ", "),
+		})
+	}
+	if server.DomainName != "" {
+		options = append(options, keaOption{
+			Name: "domain-search",
+			Data: server.DomainName,
+		})
+	}
+	if server.NTPServer != nil {
+		options = append(options, keaOption{
+			Name: "sntp-servers",
+			Data: server.NTPServer.String(),
+		})
+	}
+	config := keaConfig{
+		Dhcp6: keaDhcp6Config{
+			InterfacesConfig: keaInterfaces{
+				Interfaces: []string{server.VethPeerIfName},
+			},
+			LeaseDatabase: keaLeaseDB{
+				Type:    "memfile",
+				Name:    keaDhcp6LeaseFile(server.ServerName),
+				Persist: true,
+			},
+			OptionData:     options,
+			Subnet6:        []keaSubnet6{subnet},
+			HooksLibraries: hooks,
+			Loggers: []keaLogger{
+				{
+					Name: keaDhcp6Binary,
+					OutputOptions: []keaOutputOption{
+						{Output: keaDhcp6LogFile(server.ServerName)},
+					},
+					Severity:   "DEBUG",
+					DebugLevel: 40,
+				},
+			},
+		},
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal Kea config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	cfgPath := keaDhcp6ConfigPath(server.ServerName)
+	if err = os.WriteFile(cfgPath, configBytes, 0644); err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *KeaDhcp6Configurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops kea-dhcp6.
+func (c *KeaDhcp6Configurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(KeaDhcp6)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		pidFile := keaDhcp6PidFile(config.ServerName)
+		err := stopProcess(pidFile, keaDhcp6StopTimeout)
+		if err == nil {
+			// ignore errors from here
+			_ = os.Remove(keaDhcp6ConfigPath(config.ServerName))
+			_ = os.Remove(keaDhcp6ScriptPath(config.ServerName))
+			_ = os.Remove(keaDhcp6LeaseFile(config.ServerName))
+			_ = os.Remove(keaDhcp6LogFile(config.ServerName))
+			_ = os.Remove(pidFile)
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *KeaDhcp6Configurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func keaDhcp6ConfigPath(srvName string) string {
+	return filepath.Join(keaConfDir, srvName+".json")
+}
+
+func keaDhcp6ScriptPath(srvName string) string {
+	return filepath.Join(keaConfDir, srvName+"-pd-routes.sh")
+}
+
+// Kea derives the PID file name from the config file name.
+func keaDhcp6PidFile(srvName string) string {
+	return filepath.Join(keaRunDir, srvName+".kea-dhcp6.pid")
+}
+
+func keaDhcp6LogFile(srvName string) string {
+	return filepath.Join(keaRunDir, srvName+".log")
+}
+
+func keaDhcp6LeaseFile(srvName string) string {
+	return filepath.Join(keaRunDir, srvName+".leases6")
+}
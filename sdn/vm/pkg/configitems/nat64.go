@@ -0,0 +1,202 @@
+package configitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	taygaBinary       = "tayga"
+	taygaStartTimeout = 3 * time.Second
+	taygaStopTimeout  = 10 * time.Second
+	taygaConfDir      = "/etc/tayga"
+	taygaRunDir       = "/run/tayga"
+
+	// NAT64IfName : name of the TUN interface used by Tayga.
+	NAT64IfName = "nat64"
+)
+
+// NAT64 : stateless NAT64 (Tayga) running in the main network namespace.
+// IPv6 packets routed into the NAT64 TUN interface and destined to Prefix are
+// translated to IPv4 packets with source address allocated from DynamicPool.
+// From there, packets are routed using the main routing table (and S-NATed
+// when leaving SDN VM).
+type NAT64 struct {
+	// Prefix : NAT64 /96 prefix.
+	Prefix *net.IPNet
+	// IPv4Addr : IPv4 address of Tayga itself (used e.g. for ICMP errors).
+	IPv4Addr net.IP
+	// IPv6Addr : IPv6 address of Tayga itself (required with the well-known prefix).
+	IPv6Addr net.IP
+	// DynamicPool : IPv4 addresses dynamically mapped to IPv6 hosts.
+	DynamicPool *net.IPNet
+}
+
+// Name returns the interface name (there is at most one NAT64 instance).
+func (n NAT64) Name() string {
+	return NAT64IfName
+}
+
+// Label
+func (n NAT64) Label() string {
+	return "NAT64 (tayga)"
+}
+
+// Type
+func (n NAT64) Type() string {
+	return NAT64Typename
+}
+
+// Equal is a comparison method for two equally-named NAT64 instances.
+func (n NAT64) Equal(other depgraph.Item) bool {
+	n2 := other.(NAT64)
+	return reflect.DeepEqual(n, n2)
+}
+
+// External returns false.
+func (n NAT64) External() bool {
+	return false
+}
+
+// String describes the NAT64 config.
+func (n NAT64) String() string {
+	return fmt.Sprintf("NAT64: {Prefix: %v, IPv4Addr: %v, IPv6Addr: %v, "+
+		"DynamicPool: %v}", n.Prefix, n.IPv4Addr, n.IPv6Addr, n.DynamicPool)
+}
+
+// Dependencies lists the main network namespace as the only dependency.
+func (n NAT64) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: MainNsName,
+			},
+			Description: "Network namespace must exist",
+		},
+	}
+}
+
+// NAT64Configurator implements Configurator interface for NAT64.
+type NAT64Configurator struct{}
+
+// Create creates the NAT64 TUN interface, routes it and starts Tayga.
+func (c *NAT64Configurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(NAT64)
+	if err := c.createConfFile(config); err != nil {
+		return err
+	}
+	cfgPath := taygaConfigPath()
+	if err := runNAT64Cmd(taygaBinary, "--config", cfgPath, "--mktun"); err != nil {
+		return err
+	}
+	if err := runNAT64Cmd("ip", "link", "set", NAT64IfName, "up"); err != nil {
+		return err
+	}
+	err := runNAT64Cmd("ip", "route", "add", config.DynamicPool.String(),
+		"dev", NAT64IfName)
+	if err != nil {
+		return err
+	}
+	err = runNAT64Cmd("ip", "-6", "route", "add", config.Prefix.String(),
+		"dev", NAT64IfName)
+	if err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		if err := ensureDir(taygaRunDir); err != nil {
+			done(err)
+			return
+		}
+		pidFile := taygaPidFile()
+		args := []string{"--config", cfgPath, "--pidfile", pidFile}
+		// Do not run in background - tayga will detach itself.
+		err := startProcess(MainNsName, taygaBinary, args, pidFile,
+			taygaStartTimeout, false)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *NAT64Configurator) createConfFile(config NAT64) error {
+	if err := ensureDir(taygaConfDir); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("tun-device %s\n", NAT64IfName))
+	sb.WriteString(fmt.Sprintf("ipv4-addr %s\n", config.IPv4Addr))
+	sb.WriteString(fmt.Sprintf("ipv6-addr %s\n", config.IPv6Addr))
+	sb.WriteString(fmt.Sprintf("prefix %s\n", config.Prefix))
+	// Allow to translate private IPv4 addresses (used by Eden-SDN endpoints)
+	// even with the well-known prefix.
+	sb.WriteString("wkpf-strict no\n")
+	sb.WriteString(fmt.Sprintf("dynamic-pool %s\n", config.DynamicPool))
+	sb.WriteString(fmt.Sprintf("data-dir %s\n", taygaRunDir))
+	cfgPath := taygaConfigPath()
+	if err := os.WriteFile(cfgPath, []byte(sb.String()), 0644); err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *NAT64Configurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops Tayga and removes the NAT64 TUN interface (together with routes).
+func (c *NAT64Configurator) Delete(ctx context.Context, item depgraph.Item) error {
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		pidFile := taygaPidFile()
+		err := stopProcess(pidFile, taygaStopTimeout)
+		if err == nil {
+			err = runNAT64Cmd(taygaBinary, "--config", taygaConfigPath(), "--rmtun")
+		}
+		if err == nil {
+			// ignore errors from here
+			_ = os.Remove(taygaConfigPath())
+			_ = os.Remove(pidFile)
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *NAT64Configurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func runNAT64Cmd(cmd string, args ...string) error {
+	out, err := namespacedCmd(MainNsName, cmd, args...).CombinedOutput()
+	if err != nil {
+		err = fmt.Errorf("%s %s failed: %s (%w)", cmd, strings.Join(args, " "),
+			strings.TrimSpace(string(out)), err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func taygaConfigPath() string {
+	return filepath.Join(taygaConfDir, NAT64IfName+".conf")
+}
+
+func taygaPidFile() string {
+	return filepath.Join(taygaRunDir, NAT64IfName+".pid")
+}
@@ -0,0 +1,237 @@
+package configitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	radvdBinary       = "radvd"
+	radvdStartTimeout = 3 * time.Second
+	radvdStopTimeout  = 10 * time.Second
+	radvdConfDir      = "/etc/radvd"
+	radvdRunDir       = "/run/radvd"
+)
+
+// Radvd : Router Advertisement daemon (radvd) announcing IPv6 prefix, default router
+// and optionally DNS configuration to hosts on the network.
+type Radvd struct {
+	// DaemonName : logical name for the radvd instance.
+	DaemonName string
+	// NetNamespace : network namespace where radvd should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which radvd operates.
+	VethName string
+	// VethPeerIfName : interface name of that side of the veth pair on which
+	// radvd should send Router Advertisements. It should be inside NetNamespace.
+	VethPeerIfName string
+	// Prefix : IPv6 prefix to announce.
+	Prefix *net.IPNet
+	// Autonomous : allow hosts to use the prefix for SLAAC.
+	Autonomous bool
+	// ManagedFlag : tell hosts to obtain addresses using stateful DHCPv6.
+	ManagedFlag bool
+	// OtherConfigFlag : tell hosts to obtain other configuration
+	// (DNS, NTP, etc.) using DHCPv6.
+	OtherConfigFlag bool
+	// WithoutDefaultRoute : do not announce this router as the default router.
+	WithoutDefaultRoute bool
+	// MaxInterval : maximum time between unsolicited Router Advertisements.
+	// Zero value means that the default interval of radvd is used.
+	MaxInterval time.Duration
+	// RDNSS : DNS servers to announce using the RDNSS option.
+	RDNSS []net.IP
+	// DNSSL : DNS search list to announce using the DNSSL option.
+	DNSSL []string
+}
+
+// Name
+func (r Radvd) Name() string {
+	return r.DaemonName
+}
+
+// Label
+func (r Radvd) Label() string {
+	return r.DaemonName + " (radvd)"
+}
+
+// Type
+func (r Radvd) Type() string {
+	return RadvdTypename
+}
+
+// Equal is a comparison method for two equally-named Radvd instances.
+func (r Radvd) Equal(other depgraph.Item) bool {
+	r2 := other.(Radvd)
+	return reflect.DeepEqual(r, r2)
+}
+
+// External returns false.
+func (r Radvd) External() bool {
+	return false
+}
+
+// String describes the radvd config.
+func (r Radvd) String() string {
+	return fmt.Sprintf("Radvd: {DaemonName: %s, NetNamespace: %s, VethName: %s, "+
+		"VethPeerIfName: %s, Prefix: %v, Autonomous: %t, ManagedFlag: %t, "+
+		"OtherConfigFlag: %t, WithoutDefaultRoute: %t, MaxInterval: %v, "+
+		"RDNSS: %v, DNSSL: %v}", r.DaemonName, r.NetNamespace, r.VethName,
+		r.VethPeerIfName, r.Prefix, r.Autonomous, r.ManagedFlag, r.OtherConfigFlag,
+		r.WithoutDefaultRoute, r.MaxInterval, r.RDNSS, r.DNSSL)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (r Radvd) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(r.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: r.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+	}
+}
+
+// RadvdConfigurator implements Configurator interface for Radvd.
+type RadvdConfigurator struct{}
+
+// Create starts radvd.
+func (c *RadvdConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(Radvd)
+	if err := c.createConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		if err := ensureDir(radvdRunDir); err != nil {
+			done(err)
+			return
+		}
+		pidFile := radvdPidFile(config.DaemonName)
+		args := []string{
+			"-n", // do not daemonize, process is started in background
+			"-C", radvdConfigPath(config.DaemonName),
+			"-p", pidFile,
+			"-m", "logfile",
+			"-l", radvdLogFile(config.DaemonName),
+		}
+		err := startProcess(config.NetNamespace, radvdBinary, args, pidFile,
+			radvdStartTimeout, true)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *RadvdConfigurator) createConfFile(config Radvd) error {
+	if err := ensureDir(radvdConfDir); err != nil {
+		return err
+	}
+	onOff := func(enabled bool) string {
+		if enabled {
+			return "on"
+		}
+		return "off"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("interface %s {\n", config.VethPeerIfName))
+	sb.WriteString("\tAdvSendAdvert on;\n")
+	if config.MaxInterval != 0 {
+		maxInterval := int(config.MaxInterval.Seconds())
+		sb.WriteString(fmt.Sprintf("\tMaxRtrAdvInterval %d;\n", maxInterval))
+		// radvd requires MinRtrAdvInterval <= 0.75 * MaxRtrAdvInterval.
+		minInterval := maxInterval / 3
+		if minInterval < 3 {
+			minInterval = 3
+		}
+		sb.WriteString(fmt.Sprintf("\tMinRtrAdvInterval %d;\n", minInterval))
+	}
+	sb.WriteString(fmt.Sprintf("\tAdvManagedFlag %s;\n", onOff(config.ManagedFlag)))
+	sb.WriteString(fmt.Sprintf("\tAdvOtherConfigFlag %s;\n",
+		onOff(config.OtherConfigFlag)))
+	if config.WithoutDefaultRoute {
+		sb.WriteString("\tAdvDefaultLifetime 0;\n")
+	}
+	sb.WriteString(fmt.Sprintf("\tprefix %s {\n", config.Prefix))
+	sb.WriteString("\t\tAdvOnLink on;\n")
+	sb.WriteString(fmt.Sprintf("\t\tAdvAutonomous %s;\n", onOff(config.Autonomous)))
+	sb.WriteString("\t};\n")
+	if len(config.RDNSS) > 0 {
+		var servers []string
+		for _, server := range config.RDNSS {
+			servers = append(servers, server.String())
+		}
+		sb.WriteString(fmt.Sprintf("\tRDNSS %s {\n\t};\n", strings.Join(servers, " ")))
+	}
+	if len(config.DNSSL) > 0 {
+		sb.WriteString(fmt.Sprintf("\tDNSSL %s {\n\t};\n",
+			strings.Join(config.DNSSL, " ")))
+	}
+	sb.WriteString("};\n")
+	cfgPath := radvdConfigPath(config.DaemonName)
+	if err := os.WriteFile(cfgPath, []byte(sb.String()), 0644); err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *RadvdConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops radvd.
+func (c *RadvdConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(Radvd)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		pidFile := radvdPidFile(config.DaemonName)
+		err := stopProcess(pidFile, radvdStopTimeout)
+		if err == nil {
+			// ignore errors from here
+			_ = os.Remove(radvdConfigPath(config.DaemonName))
+			_ = os.Remove(radvdLogFile(config.DaemonName))
+			_ = os.Remove(pidFile)
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *RadvdConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func radvdConfigPath(daemonName string) string {
+	return filepath.Join(radvdConfDir, daemonName+".conf")
+}
+
+func radvdPidFile(daemonName string) string {
+	return filepath.Join(radvdRunDir, daemonName+".pid")
+}
+
+func radvdLogFile(daemonName string) string {
+	return filepath.Join(radvdRunDir, daemonName+".log")
+}
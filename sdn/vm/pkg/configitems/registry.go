@@ -29,6 +29,14 @@ func RegisterItems(
 		{c: &HttpProxyConfigurator{}, t: HTTPProxyTypename},
 		{c: &HttpServerConfigurator{}, t: HTTPServerTypename},
 		{c: &TrafficControlConfigurator{MacLookup: macLookup}, t: TrafficControlTypename},
+		{c: &WireGuardServerConfigurator{}, t: WireGuardServerTypename},
+		{c: &IPSetConfigurator{}, t: IPSetTypename},
+		{c: &CaptivePortalConfigurator{}, t: CaptivePortalTypename},
+		{c: &RadvdConfigurator{}, t: RadvdTypename},
+		{c: &KeaDhcp6Configurator{}, t: KeaDhcp6Typename},
+		{c: &NAT64Configurator{}, t: NAT64Typename},
+		{c: &Dns64ProxyConfigurator{}, t: Dns64ProxyTypename},
+		{c: &SyslogServerConfigurator{}, t: SyslogServerTypename},
 	}
 	for _, configurator := range configurators {
 		err := registry.Register(configurator.c, configurator.t)
@@ -12,6 +12,14 @@ const (
 	ipv6ForwardingKey  = "net.ipv6.conf.all.forwarding"
 	bridgeIptablesKey  = "net.bridge.bridge-nf-call-iptables"
 	bridgeIp6tablesKey = "net.bridge.bridge-nf-call-ip6tables"
+
+	conntrackTCPEstablishedKey = "net.netfilter.nf_conntrack_tcp_timeout_established"
+	conntrackUDPStreamKey      = "net.netfilter.nf_conntrack_udp_timeout_stream"
+	conntrackTCPLooseKey       = "net.netfilter.nf_conntrack_tcp_loose"
+
+	// Kernel defaults for conntrack timeouts (in seconds).
+	defaultConntrackTCPEstablished = 432000
+	defaultConntrackUDPStream      = 120
 )
 
 // Sysctl : item representing kernel parameters set using sysctl.
@@ -22,6 +30,11 @@ type Sysctl struct {
 	EnableIPv6Forwarding  bool
 	BridgeNfCallIptables  bool
 	BridgeNfCallIp6tables bool
+	// Connection tracking settings (applied only in the main namespace).
+	// Zero timeout means that the kernel default is used.
+	ConntrackTCPEstablishedTimeout uint32
+	ConntrackUDPStreamTimeout      uint32
+	ConntrackStrictTCP             bool
 }
 
 // Name
@@ -53,9 +66,12 @@ func (f Sysctl) External() bool {
 // String prints sysctl settings.
 func (f Sysctl) String() string {
 	return fmt.Sprintf("Namespace: %s\nIPv4 Forwarding: %v\nIPv6 Forwarding: %v\n"+
-		"Bridge uses Iptables: %v\nBridge uses Ip6tables: %v",
+		"Bridge uses Iptables: %v\nBridge uses Ip6tables: %v\n"+
+		"Conntrack TCP established timeout: %d\nConntrack UDP stream timeout: %d\n"+
+		"Conntrack strict TCP: %v",
 		normNetNsName(f.NetNamespace), f.EnableIPv4Forwarding, f.EnableIPv6Forwarding,
-		f.BridgeNfCallIptables, f.BridgeNfCallIp6tables)
+		f.BridgeNfCallIptables, f.BridgeNfCallIp6tables, f.ConntrackTCPEstablishedTimeout,
+		f.ConntrackUDPStreamTimeout, f.ConntrackStrictTCP)
 }
 
 // Dependencies returns dependency on the network namespace.
@@ -81,7 +97,16 @@ func (c *SysctlConfigurator) Create(ctx context.Context, item depgraph.Item) err
 	if err != nil {
 		return err
 	}
-	return c.setBridgeIptables(f.NetNamespace, f.BridgeNfCallIptables, f.BridgeNfCallIp6tables)
+	if normNetNsName(f.NetNamespace) != MainNsName {
+		// Bridges are created only in the main namespace.
+		return nil
+	}
+	err = c.setBridgeIptables(f.NetNamespace, f.BridgeNfCallIptables, f.BridgeNfCallIp6tables)
+	if err != nil {
+		return err
+	}
+	return c.setConntrack(f.NetNamespace, f.ConntrackTCPEstablishedTimeout,
+		f.ConntrackUDPStreamTimeout, f.ConntrackStrictTCP)
 }
 
 // Modify updates sysctl settings.
@@ -91,7 +116,16 @@ func (c *SysctlConfigurator) Modify(ctx context.Context, oldItem, newItem depgra
 	if err != nil {
 		return err
 	}
-	return c.setBridgeIptables(f.NetNamespace, f.BridgeNfCallIptables, f.BridgeNfCallIp6tables)
+	if normNetNsName(f.NetNamespace) != MainNsName {
+		// Bridges are created only in the main namespace.
+		return nil
+	}
+	err = c.setBridgeIptables(f.NetNamespace, f.BridgeNfCallIptables, f.BridgeNfCallIp6tables)
+	if err != nil {
+		return err
+	}
+	return c.setConntrack(f.NetNamespace, f.ConntrackTCPEstablishedTimeout,
+		f.ConntrackUDPStreamTimeout, f.ConntrackStrictTCP)
 }
 
 // Delete sets default sysctl settings.
@@ -101,7 +135,14 @@ func (c *SysctlConfigurator) Delete(ctx context.Context, item depgraph.Item) err
 	if err != nil {
 		return err
 	}
-	return c.setBridgeIptables(f.NetNamespace, true, true)
+	if normNetNsName(f.NetNamespace) != MainNsName {
+		return nil
+	}
+	err = c.setBridgeIptables(f.NetNamespace, true, true)
+	if err != nil {
+		return err
+	}
+	return c.setConntrack(f.NetNamespace, 0, 0, false)
 }
 
 func (c *SysctlConfigurator) setIPForwarding(netNs string, v4, v6 bool) error {
@@ -140,6 +181,40 @@ func (c *SysctlConfigurator) setBridgeIptables(netNs string, v4, v6 bool) error
 	return nil
 }
 
+func (c *SysctlConfigurator) setConntrack(netNs string,
+	tcpEstablishedTimeout, udpStreamTimeout uint32, strictTCP bool) error {
+	if tcpEstablishedTimeout == 0 {
+		tcpEstablishedTimeout = defaultConntrackTCPEstablished
+	}
+	if udpStreamTimeout == 0 {
+		udpStreamTimeout = defaultConntrackUDPStream
+	}
+	// Conntrack sysctl keys are available only after the module is loaded.
+	out, err := namespacedCmd(netNs, "modprobe", "nf_conntrack").CombinedOutput()
+	if err != nil {
+		// Could be built into the kernel.
+		log.Warnf("failed to load nf_conntrack module: %s", out)
+	}
+	settings := []struct {
+		key   string
+		value string
+	}{
+		{key: conntrackTCPEstablishedKey, value: fmt.Sprintf("%d", tcpEstablishedTimeout)},
+		{key: conntrackUDPStreamKey, value: fmt.Sprintf("%d", udpStreamTimeout)},
+		{key: conntrackTCPLooseKey, value: c.boolValueToStr(!strictTCP)},
+	}
+	for _, setting := range settings {
+		sysctlKV := fmt.Sprintf("%s=%s", setting.key, setting.value)
+		out, err = namespacedCmd(netNs, "sysctl", "-w", sysctlKV).CombinedOutput()
+		if err != nil {
+			err = fmt.Errorf("failed to set %s: %s", setting.key, out)
+			log.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
 // NeedsRecreate returns false - Modify is able to apply any change.
 func (c *SysctlConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
 	return false
@@ -0,0 +1,220 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	syslogcfg "github.com/lf-edge/eden/sdn/vm/cmd/syslogsrv/config"
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	syslogSrvBinary  = "/bin/syslogsrv"
+	syslogSrvConfDir = "/etc/syslogsrv"
+	syslogSrvRunDir  = "/run/syslogsrv"
+
+	syslogSrvStartTimeout = 3 * time.Second
+	syslogSrvStopTimeout  = 10 * time.Second
+)
+
+// SyslogServer : syslog server receiving messages over UDP, TCP and/or TLS.
+type SyslogServer struct {
+	// ServerName : logical name for the syslog server.
+	ServerName string
+	// NetNamespace : network namespace where the server should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the server operates.
+	VethName string
+	// ListenIP : IP address on which the server should listen.
+	ListenIP net.IP
+	// ServerHost : hostname (FQDN or IP address) of the server, used for
+	// the self-signed certificate.
+	ServerHost string
+	// UDPPort : UDP port to listen on (0 to disable).
+	UDPPort uint16
+	// TCPPort : TCP port to listen on (0 to disable).
+	TCPPort uint16
+	// TLSPort : TCP port to listen on for TLS connections (0 to disable).
+	TLSPort uint16
+	// CertPEM : Server certificate in the PEM format (optional).
+	CertPEM string
+	// KeyPEM : Server key in the PEM format (optional).
+	KeyPEM string
+	// MaxMessages : maximum number of stored messages.
+	MaxMessages uint32
+}
+
+// Name
+func (s SyslogServer) Name() string {
+	return s.ServerName
+}
+
+// Label
+func (s SyslogServer) Label() string {
+	return s.ServerName + " (syslog server)"
+}
+
+// Type
+func (s SyslogServer) Type() string {
+	return SyslogServerTypename
+}
+
+// Equal is a comparison method for two equally-named SyslogServer instances.
+func (s SyslogServer) Equal(other depgraph.Item) bool {
+	s2 := other.(SyslogServer)
+	return reflect.DeepEqual(s, s2)
+}
+
+// External returns false.
+func (s SyslogServer) External() bool {
+	return false
+}
+
+// String describes the syslog server.
+func (s SyslogServer) String() string {
+	return fmt.Sprintf("Syslog server: {ServerName: %s, NetNamespace: %s, "+
+		"VethName: %s, ListenIP: %v, ServerHost: %s, UDPPort: %d, TCPPort: %d, "+
+		"TLSPort: %d, MaxMessages: %d}", s.ServerName, s.NetNamespace, s.VethName,
+		s.ListenIP, s.ServerHost, s.UDPPort, s.TCPPort, s.TLSPort, s.MaxMessages)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (s SyslogServer) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(s.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: s.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+	}
+}
+
+// SyslogServerConfigurator implements Configurator interface for SyslogServer.
+type SyslogServerConfigurator struct{}
+
+// Create starts syslogsrv (see sdn/cmd/syslogsrv).
+func (c *SyslogServerConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(SyslogServer)
+	if err := c.createConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		if err := ensureDir(syslogSrvRunDir); err != nil {
+			done(err)
+			return
+		}
+		args := []string{"-c", syslogSrvConfigPath(config.ServerName)}
+		pidFile := syslogSrvPidFile(config.ServerName)
+		err := startProcess(config.NetNamespace, syslogSrvBinary, args, pidFile,
+			syslogSrvStartTimeout, true)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *SyslogServerConfigurator) createConfFile(server SyslogServer) error {
+	if err := ensureDir(syslogSrvConfDir); err != nil {
+		return err
+	}
+	config := syslogcfg.SyslogServerConfig{
+		ListenIP:     server.ListenIP.String(),
+		UDPPort:      server.UDPPort,
+		TCPPort:      server.TCPPort,
+		TLSPort:      server.TLSPort,
+		CertPEM:      server.CertPEM,
+		KeyPEM:       server.KeyPEM,
+		ServerName:   server.ServerHost,
+		MessagesFile: SyslogMessagesFile(server.ServerName),
+		MaxMessages:  server.MaxMessages,
+		LogFile:      syslogSrvLogFile(server.ServerName),
+		PidFile:      syslogSrvPidFile(server.ServerName),
+		Verbose:      true,
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	cfgPath := syslogSrvConfigPath(server.ServerName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *SyslogServerConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops syslogsrv and removes stored messages.
+func (c *SyslogServerConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(SyslogServer)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		pidFile := syslogSrvPidFile(config.ServerName)
+		err := stopProcess(pidFile, syslogSrvStopTimeout)
+		if err == nil {
+			// ignore errors from here
+			_ = os.Remove(syslogSrvConfigPath(config.ServerName))
+			_ = os.Remove(syslogSrvLogFile(config.ServerName))
+			_ = os.Remove(SyslogMessagesFile(config.ServerName))
+			_ = os.Remove(SyslogRotatedMessagesFile(config.ServerName))
+			_ = os.Remove(pidFile)
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *SyslogServerConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+// SyslogMessagesFile returns path to the file with messages received by the given
+// syslog server (one JSON-encoded api.SyslogMessage per line).
+func SyslogMessagesFile(serverName string) string {
+	return filepath.Join(syslogSrvRunDir, serverName+".messages")
+}
+
+// SyslogRotatedMessagesFile returns path to the file with older messages received
+// by the given syslog server (rotated out of SyslogMessagesFile).
+func SyslogRotatedMessagesFile(serverName string) string {
+	return SyslogMessagesFile(serverName) + ".1"
+}
+
+func syslogSrvConfigPath(serverName string) string {
+	return filepath.Join(syslogSrvConfDir, serverName+".conf")
+}
+
+func syslogSrvPidFile(serverName string) string {
+	return filepath.Join(syslogSrvRunDir, serverName+".pid")
+}
+
+func syslogSrvLogFile(serverName string) string {
+	return filepath.Join(syslogSrvRunDir, serverName+".log")
+}
@@ -38,4 +38,20 @@ const (
 	HTTPServerTypename = "HTTP-Server"
 	// TrafficControlTypename : typename for TC rules applied to physical interface.
 	TrafficControlTypename = "Traffic-Control"
+	// WireGuardServerTypename : typename for WireGuard VPN server.
+	WireGuardServerTypename = "WireGuard-Server"
+	// IPSetTypename : typename for IP set.
+	IPSetTypename = "IP-Set"
+	// CaptivePortalTypename : typename for captive portal.
+	CaptivePortalTypename = "Captive-Portal"
+	// RadvdTypename : typename for Router Advertisement daemon.
+	RadvdTypename = "Radvd"
+	// KeaDhcp6Typename : typename for Kea DHCPv6 server.
+	KeaDhcp6Typename = "Kea-DHCPv6-Server"
+	// NAT64Typename : typename for NAT64.
+	NAT64Typename = "NAT64"
+	// Dns64ProxyTypename : typename for DNS64 proxy.
+	Dns64ProxyTypename = "DNS64-Proxy"
+	// SyslogServerTypename : typename for syslog server.
+	SyslogServerTypename = "Syslog-Server"
 )
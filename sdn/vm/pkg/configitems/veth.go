@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"syscall"
 
 	"github.com/lf-edge/eve/libs/depgraph"
 	log "github.com/sirupsen/logrus"
@@ -217,6 +218,11 @@ func (c *VethConfigurator) configurePeer(peer VethPeer) error {
 	// Assign IP addresses.
 	for _, ipNet := range peer.IPAddresses {
 		addr := &netlink.Addr{IPNet: ipNet}
+		if ipNet.IP.To4() == nil {
+			// Skip Duplicate Address Detection, otherwise the address
+			// would not be usable for a couple of seconds.
+			addr.Flags = syscall.IFA_F_NODAD
+		}
 		if err := netlink.AddrAdd(link, addr); err != nil {
 			return fmt.Errorf("failed to add addr %v to veth peer %s: %v",
 				ipNet, peer.IfName, err)
@@ -0,0 +1,304 @@
+package configitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eve/libs/depgraph"
+	log "github.com/sirupsen/logrus"
+)
+
+// wgRunDir : directory with files of keys passed to the wg tool.
+var wgRunDir = "/run/wireguard"
+
+const (
+	wgBinary = "wg"
+
+	// Interface names used inside the network namespace of the server.
+	wgIfName      = "wg0"
+	wgPrivIfName  = "wg-private"
+	wgDefaultPort = 51820
+)
+
+// WireGuardServer : WireGuard VPN server.
+type WireGuardServer struct {
+	// ServerName : logical name for the WireGuard server.
+	ServerName string
+	// NetNamespace : network namespace where the server should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair through which the tunnel traffic flows.
+	// (other types of interfaces are currently not supported)
+	VethName string
+	// PrivateKey : base64-encoded private key of the server.
+	PrivateKey string
+	// ListenPort : UDP port on which the server listens.
+	ListenPort uint16
+	// TunnelIP : IP address of the server inside the tunnel, with the tunnel subnet mask.
+	TunnelIP *net.IPNet
+	// Peers : clients allowed to connect.
+	Peers []WireGuardPeer
+	// PrivateSubnets : subnets emulated behind the VPN gateway.
+	PrivateSubnets []*net.IPNet
+}
+
+// WireGuardPeer : peer of a WireGuard server.
+type WireGuardPeer struct {
+	PublicKey           string
+	PresharedKey        string
+	AllowedIPs          []*net.IPNet
+	PersistentKeepalive uint16
+}
+
+// Name
+func (s WireGuardServer) Name() string {
+	return s.ServerName
+}
+
+// Label
+func (s WireGuardServer) Label() string {
+	return s.ServerName + " (WireGuard server)"
+}
+
+// Type
+func (s WireGuardServer) Type() string {
+	return WireGuardServerTypename
+}
+
+// Equal is a comparison method for two equally-named WireGuardServer instances.
+func (s WireGuardServer) Equal(other depgraph.Item) bool {
+	s2 := other.(WireGuardServer)
+	return reflect.DeepEqual(s, s2)
+}
+
+// External returns false.
+func (s WireGuardServer) External() bool {
+	return false
+}
+
+// String describes the WireGuard server.
+func (s WireGuardServer) String() string {
+	// Do not print the private key.
+	var peers []string
+	for _, peer := range s.Peers {
+		peers = append(peers, fmt.Sprintf("{PublicKey: %s, AllowedIPs: %v, "+
+			"PersistentKeepalive: %d}", peer.PublicKey, peer.AllowedIPs,
+			peer.PersistentKeepalive))
+	}
+	return fmt.Sprintf("WireGuard server: {ServerName: %s, NetNamespace: %s, "+
+		"VethName: %s, ListenPort: %d, TunnelIP: %v, Peers: [%s], PrivateSubnets: %v}",
+		s.ServerName, s.NetNamespace, s.VethName, s.ListenPort, s.TunnelIP,
+		strings.Join(peers, ", "), s.PrivateSubnets)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (s WireGuardServer) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(s.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: s.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+	}
+}
+
+// WireGuardServerConfigurator implements Configurator interface for WireGuardServer.
+type WireGuardServerConfigurator struct{}
+
+// Create creates WireGuard interface, configures it using the wg tool and emulates
+// private subnets using a dummy interface.
+func (c *WireGuardServerConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(WireGuardServer)
+	netNs := config.NetNamespace
+	if err := c.runCmd(netNs, "ip", "link", "add", wgIfName, "type", "wireguard"); err != nil {
+		return err
+	}
+	if err := c.configureWg(config); err != nil {
+		c.cleanup(config)
+		return err
+	}
+	for _, args := range wgIPCommands(config) {
+		if err := c.runCmd(netNs, "ip", args...); err != nil {
+			c.cleanup(config)
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanup removes interfaces and key files left behind by a failed Create,
+// so that it can be retried.
+func (c *WireGuardServerConfigurator) cleanup(config WireGuardServer) {
+	// ignore errors, interfaces may not exist
+	_ = namespacedCmd(config.NetNamespace, "ip", "link", "del", wgPrivIfName).Run()
+	_ = namespacedCmd(config.NetNamespace, "ip", "link", "del", wgIfName).Run()
+	removeWgKeyFiles(config.ServerName)
+}
+
+func (c *WireGuardServerConfigurator) configureWg(config WireGuardServer) error {
+	if err := ensureDir(wgRunDir); err != nil {
+		return err
+	}
+	args, keyFiles := wgSetArgs(config)
+	if err := writeWgKeyFiles(keyFiles); err != nil {
+		log.Error(err)
+		return err
+	}
+	return c.runCmd(config.NetNamespace, wgBinary, args...)
+}
+
+// writeWgKeyFiles writes all key files or none of them.
+func writeWgKeyFiles(keyFiles []wgKeyFile) error {
+	for i, keyFile := range keyFiles {
+		if err := os.WriteFile(keyFile.path, []byte(keyFile.key), 0600); err != nil {
+			for _, written := range keyFiles[:i] {
+				_ = os.Remove(written.path)
+			}
+			return fmt.Errorf("failed to write WireGuard %s: %w", keyFile.desc, err)
+		}
+	}
+	return nil
+}
+
+// removeWgKeyFiles removes all key files of the server, ignoring errors.
+func removeWgKeyFiles(srvName string) {
+	keyFiles, _ := filepath.Glob(wgKeyPath(srvName, "*"))
+	for _, keyFile := range keyFiles {
+		_ = os.Remove(keyFile)
+	}
+}
+
+func (c *WireGuardServerConfigurator) runCmd(netNs, cmd string, args ...string) error {
+	out, err := namespacedCmd(netNs, cmd, args...).CombinedOutput()
+	if err != nil {
+		err = fmt.Errorf("%s %s failed: %s (%w)", cmd, strings.Join(args, " "),
+			strings.TrimSpace(string(out)), err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *WireGuardServerConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete removes WireGuard and dummy interfaces (routes and addresses are removed
+// with them).
+func (c *WireGuardServerConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(WireGuardServer)
+	if len(config.PrivateSubnets) > 0 {
+		if err := c.runCmd(config.NetNamespace, "ip", "link", "del", wgPrivIfName); err != nil {
+			return err
+		}
+	}
+	if err := c.runCmd(config.NetNamespace, "ip", "link", "del", wgIfName); err != nil {
+		return err
+	}
+	removeWgKeyFiles(config.ServerName)
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *WireGuardServerConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+// wgKeyFile : key passed to the wg tool using a file.
+type wgKeyFile struct {
+	path string
+	key  string
+	desc string
+}
+
+// wgSetArgs returns arguments for "wg set" configuring the server interface
+// and files with keys which the arguments refer to.
+func wgSetArgs(config WireGuardServer) (args []string, keyFiles []wgKeyFile) {
+	// Keys are passed to the wg tool using files.
+	privKeyFile := wgKeyPath(config.ServerName, "private")
+	keyFiles = append(keyFiles, wgKeyFile{
+		path: privKeyFile, key: config.PrivateKey, desc: "private key"})
+	listenPort := config.ListenPort
+	if listenPort == 0 {
+		listenPort = wgDefaultPort
+	}
+	args = []string{"set", wgIfName, "listen-port", strconv.Itoa(int(listenPort)),
+		"private-key", privKeyFile}
+	for i, peer := range config.Peers {
+		args = append(args, "peer", peer.PublicKey)
+		if peer.PresharedKey != "" {
+			pskFile := wgKeyPath(config.ServerName, fmt.Sprintf("psk-%d", i))
+			keyFiles = append(keyFiles, wgKeyFile{
+				path: pskFile, key: peer.PresharedKey, desc: "pre-shared key"})
+			args = append(args, "preshared-key", pskFile)
+		}
+		var allowedIPs []string
+		for _, allowedIP := range peer.AllowedIPs {
+			allowedIPs = append(allowedIPs, allowedIP.String())
+		}
+		args = append(args, "allowed-ips", strings.Join(allowedIPs, ","))
+		if peer.PersistentKeepalive != 0 {
+			args = append(args, "persistent-keepalive",
+				strconv.Itoa(int(peer.PersistentKeepalive)))
+		}
+	}
+	return args, keyFiles
+}
+
+// wgIPCommands returns arguments for "ip" commands which assign the tunnel IP,
+// route peer subnets into the tunnel and emulate private subnets.
+func wgIPCommands(config WireGuardServer) [][]string {
+	cmds := [][]string{
+		{"addr", "add", config.TunnelIP.String(), "dev", wgIfName},
+		{"link", "set", "dev", wgIfName, "up"},
+	}
+	// Route traffic for peer subnets outside of the tunnel subnet into the tunnel.
+	for _, peer := range config.Peers {
+		for _, allowedIP := range peer.AllowedIPs {
+			if config.TunnelIP.Contains(allowedIP.IP) {
+				continue
+			}
+			cmds = append(cmds, []string{"route", "add", allowedIP.String(), "dev", wgIfName})
+		}
+	}
+	if len(config.PrivateSubnets) > 0 {
+		cmds = append(cmds, []string{"link", "add", wgPrivIfName, "type", "dummy"})
+		for _, subnet := range config.PrivateSubnets {
+			cmds = append(cmds, []string{"addr", "add", firstHostIP(subnet).String(),
+				"dev", wgPrivIfName})
+		}
+		cmds = append(cmds, []string{"link", "set", "dev", wgPrivIfName, "up"})
+	}
+	return cmds
+}
+
+func wgKeyPath(srvName, keyName string) string {
+	return filepath.Join(wgRunDir, srvName+"-"+keyName+".key")
+}
+
+// firstHostIP returns the first host IP of the subnet, with the subnet mask.
+// For a single-address subnet (/32 or /128) the address itself is returned.
+func firstHostIP(subnet *net.IPNet) *net.IPNet {
+	ip := make(net.IP, len(subnet.IP))
+	copy(ip, subnet.IP)
+	if ones, bits := subnet.Mask.Size(); ones < bits {
+		ip[len(ip)-1]++
+	}
+	return &net.IPNet{IP: ip, Mask: subnet.Mask}
+}
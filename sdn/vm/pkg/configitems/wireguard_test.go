@@ -0,0 +1,187 @@
+package configitems
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testWgPrivKey = "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk="
+	testWgPubKey1 = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+	testWgPubKey2 = "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0="
+	testWgPSK     = "FpCyhws9cxwWoV4xELtfJvjJN+zQVRPISllRWgeopVE="
+)
+
+func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
+	ip, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subnet.IP = ip
+	return subnet
+}
+
+func testWireGuardServer(t *testing.T) WireGuardServer {
+	return WireGuardServer{
+		ServerName:   "vpn0",
+		NetNamespace: "ep-vpn0",
+		VethName:     "veth-vpn0",
+		PrivateKey:   testWgPrivKey,
+		TunnelIP:     mustParseCIDR(t, "10.200.0.1/24"),
+		Peers: []WireGuardPeer{
+			{
+				PublicKey:  testWgPubKey1,
+				AllowedIPs: []*net.IPNet{mustParseCIDR(t, "10.200.0.2/32")},
+			},
+			{
+				PublicKey:    testWgPubKey2,
+				PresharedKey: testWgPSK,
+				AllowedIPs: []*net.IPNet{mustParseCIDR(t, "10.200.0.3/32"),
+					mustParseCIDR(t, "192.168.50.0/24")},
+				PersistentKeepalive: 25,
+			},
+		},
+		PrivateSubnets: []*net.IPNet{mustParseCIDR(t, "10.201.0.0/24"),
+			mustParseCIDR(t, "fd00:201::/64")},
+	}
+}
+
+func TestWgSetArgs(t *testing.T) {
+	t.Parallel()
+
+	server := testWireGuardServer(t)
+	args, keyFiles := wgSetArgs(server)
+	expectedArgs := []string{"set", "wg0", "listen-port", "51820",
+		"private-key", "/run/wireguard/vpn0-private.key",
+		"peer", testWgPubKey1, "allowed-ips", "10.200.0.2/32",
+		"peer", testWgPubKey2, "preshared-key", "/run/wireguard/vpn0-psk-1.key",
+		"allowed-ips", "10.200.0.3/32,192.168.50.0/24", "persistent-keepalive", "25"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("wg args = %v, expected %v", args, expectedArgs)
+	}
+	expectedKeyFiles := []wgKeyFile{
+		{path: "/run/wireguard/vpn0-private.key", key: testWgPrivKey, desc: "private key"},
+		{path: "/run/wireguard/vpn0-psk-1.key", key: testWgPSK, desc: "pre-shared key"},
+	}
+	if !reflect.DeepEqual(keyFiles, expectedKeyFiles) {
+		t.Errorf("key files = %+v, expected %+v", keyFiles, expectedKeyFiles)
+	}
+	// Keys are never passed on the command line.
+	for _, arg := range args {
+		if arg == testWgPrivKey || arg == testWgPSK {
+			t.Errorf("secret key passed as argument: %v", args)
+		}
+	}
+
+	server.ListenPort = 443
+	server.Peers = nil
+	args, keyFiles = wgSetArgs(server)
+	expectedArgs = []string{"set", "wg0", "listen-port", "443",
+		"private-key", "/run/wireguard/vpn0-private.key"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("wg args = %v, expected %v", args, expectedArgs)
+	}
+	if len(keyFiles) != 1 {
+		t.Errorf("expected only private key file, got: %+v", keyFiles)
+	}
+}
+
+func TestWgIPCommands(t *testing.T) {
+	t.Parallel()
+
+	server := testWireGuardServer(t)
+	expected := [][]string{
+		{"addr", "add", "10.200.0.1/24", "dev", "wg0"},
+		{"link", "set", "dev", "wg0", "up"},
+		// peer IPs inside the tunnel subnet are routed by the address above
+		{"route", "add", "192.168.50.0/24", "dev", "wg0"},
+		{"link", "add", "wg-private", "type", "dummy"},
+		{"addr", "add", "10.201.0.1/24", "dev", "wg-private"},
+		{"addr", "add", "fd00:201::1/64", "dev", "wg-private"},
+		{"link", "set", "dev", "wg-private", "up"},
+	}
+	if cmds := wgIPCommands(server); !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("ip commands = %v, expected %v", cmds, expected)
+	}
+
+	server.PrivateSubnets = nil
+	server.Peers = server.Peers[:1]
+	expected = expected[:2]
+	if cmds := wgIPCommands(server); !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("ip commands = %v, expected %v", cmds, expected)
+	}
+}
+
+func TestFirstHostIP(t *testing.T) {
+	t.Parallel()
+
+	subnet := mustParseCIDR(t, "10.201.0.0/24")
+	if ip := firstHostIP(subnet); ip.String() != "10.201.0.1/24" {
+		t.Errorf("first host IP = %s, expected 10.201.0.1/24", ip)
+	}
+	if subnet.String() != "10.201.0.0/24" {
+		t.Errorf("subnet was modified: %s", subnet)
+	}
+	// single-address subnet has no other host IP
+	for _, cidr := range []string{"10.201.0.5/32", "fd00:201::5/128"} {
+		subnet = mustParseCIDR(t, cidr)
+		if ip := firstHostIP(subnet); ip.String() != cidr || !subnet.Contains(ip.IP) {
+			t.Errorf("first host IP = %s, expected %s", ip, cidr)
+		}
+	}
+}
+
+func TestWgKeyFiles(t *testing.T) {
+	// not parallel, changes directory of key files
+	defer func(dir string) { wgRunDir = dir }(wgRunDir)
+	wgRunDir = t.TempDir()
+
+	server := testWireGuardServer(t)
+	_, keyFiles := wgSetArgs(server)
+	if err := writeWgKeyFiles(keyFiles); err != nil {
+		t.Fatal(err)
+	}
+	for _, keyFile := range keyFiles {
+		info, err := os.Stat(keyFile.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("key file %s has permissions %v", keyFile.path, info.Mode().Perm())
+		}
+	}
+	removeWgKeyFiles(server.ServerName)
+	if files, _ := os.ReadDir(wgRunDir); len(files) != 0 {
+		t.Errorf("key files left after removal: %v", files)
+	}
+
+	// failure to write a later file removes the already written ones
+	keyFiles = append(keyFiles, wgKeyFile{
+		path: filepath.Join(wgRunDir, "missing", "key"), key: testWgPSK, desc: "pre-shared key"})
+	if err := writeWgKeyFiles(keyFiles); err == nil {
+		t.Error("expected error writing key into missing directory")
+	}
+	if files, _ := os.ReadDir(wgRunDir); len(files) != 0 {
+		t.Errorf("key files left after failed write: %v", files)
+	}
+}
+
+func TestWireGuardServerString(t *testing.T) {
+	t.Parallel()
+
+	str := testWireGuardServer(t).String()
+	for _, secret := range []string{testWgPrivKey, testWgPSK} {
+		if strings.Contains(str, secret) {
+			t.Errorf("secret key printed: %s", str)
+		}
+	}
+	for _, public := range []string{testWgPubKey1, testWgPubKey2} {
+		if !strings.Contains(str, public) {
+			t.Errorf("public key %s not printed: %s", public, str)
+		}
+	}
+}
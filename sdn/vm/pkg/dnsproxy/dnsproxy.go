@@ -0,0 +1,230 @@
+// Package dnsproxy implements DNS proxy server shared by DNS proxies running
+// inside Eden-SDN (dnsfaultproxy, dns64proxy). The proxy listens for queries
+// on both UDP and TCP and forwards them to upstream DNS servers. Every proxy
+// binary can alter responses (or answer without asking upstream) using QueryHandler.
+package dnsproxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Maximum size of DNS message received over UDP.
+	maxUDPMsgLen = 65535
+	// Timeout for queries forwarded to an upstream DNS server.
+	upstreamTimeout = 5 * time.Second
+	// Timeout for reading the next query from a TCP connection.
+	tcpIdleTimeout = 30 * time.Second
+)
+
+// QueryHandler returns response for the given query, or nil if the query
+// should be dropped (client will time out).
+// Use Proxy.Forward to obtain the response from upstream servers.
+type QueryHandler func(query []byte, overTCP bool) []byte
+
+// Proxy : DNS proxy forwarding queries to upstream DNS servers.
+type Proxy struct {
+	// Upstreams : addresses (<ip>:<port>) of DNS servers to forward queries to.
+	// Servers are tried in the given order.
+	Upstreams []string
+	// HandleQuery : handler called for every received query.
+	// If nil, queries are forwarded to upstream servers unchanged.
+	HandleQuery QueryHandler
+}
+
+// SetupLogging redirects log output into logFile (if not empty) and sets
+// the log level.
+func SetupLogging(logFile string, verbose bool) error {
+	if logFile != "" {
+		file, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to open log file %s: %w", logFile, err)
+		}
+		log.SetOutput(file)
+	}
+	if verbose {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+	return nil
+}
+
+// Run starts serving queries received on listenAddr (both UDP and TCP)
+// and blocks until the process receives termination or interrupt signal,
+// or until the proxy fails to receive queries, which is returned as error.
+// PID file (if not empty) is written only after the proxy is ready to serve queries.
+func (p *Proxy) Run(listenAddr, pidFile string) error {
+	udpConn, err := net.ListenPacket("udp", listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on UDP %s: %w", listenAddr, err)
+	}
+	defer udpConn.Close()
+	tcpListener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on TCP %s: %w", listenAddr, err)
+	}
+	defer tcpListener.Close()
+	if pidFile != "" {
+		pidBytes := []byte(fmt.Sprintf("%d", os.Getpid()))
+		if err = os.WriteFile(pidFile, pidBytes, 0664); err != nil {
+			return fmt.Errorf("failed to write PID file %s: %w", pidFile, err)
+		}
+		defer os.Remove(pidFile)
+	}
+	log.Debugf("DNS proxy listening on %s", listenAddr)
+	serveErr := make(chan error, 2)
+	go func() { serveErr <- p.serveUDP(udpConn) }()
+	go func() { serveErr <- p.serveTCP(tcpListener) }()
+
+	cancelChan := make(chan os.Signal, 1)
+	// Catch termination or interrupt signal.
+	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(cancelChan)
+	select {
+	case sig := <-cancelChan:
+		log.Infof("Caught termination/interrupt signal: %v, exiting...", sig)
+		return nil
+	case err = <-serveErr:
+		return err
+	}
+}
+
+func (p *Proxy) handleQuery(query []byte, overTCP bool) []byte {
+	if p.HandleQuery == nil {
+		return p.Forward(query, overTCP)
+	}
+	return p.HandleQuery(query, overTCP)
+}
+
+func (p *Proxy) serveUDP(conn net.PacketConn) error {
+	for {
+		buf := make([]byte, maxUDPMsgLen)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return fmt.Errorf("failed to read UDP query: %w", err)
+		}
+		go func() {
+			resp := p.handleQuery(buf[:n], false)
+			if resp == nil {
+				return
+			}
+			if _, err := conn.WriteTo(resp, addr); err != nil {
+				log.Errorf("Failed to write UDP response to %v: %v", addr, err)
+			}
+		}()
+	}
+}
+
+func (p *Proxy) serveTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return fmt.Errorf("failed to accept TCP connection: %w", err)
+		}
+		go p.serveTCPConn(conn)
+	}
+}
+
+func (p *Proxy) serveTCPConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(tcpIdleTimeout))
+		query, err := readTCPMsg(conn)
+		if err != nil {
+			if err != io.EOF {
+				log.Debugf("Closing TCP connection from %v: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		resp := p.handleQuery(query, true)
+		if resp == nil {
+			// Dropped or failed query, client will time out.
+			continue
+		}
+		if err = writeTCPMsg(conn, resp); err != nil {
+			log.Errorf("Failed to write TCP response to %v: %v",
+				conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// Forward sends query to upstream DNS servers (in the configured order)
+// and returns the first received response. Returns nil if no upstream
+// server responded.
+func (p *Proxy) Forward(query []byte, overTCP bool) []byte {
+	for _, upstream := range p.Upstreams {
+		if resp := forwardTo(upstream, query, overTCP); resp != nil {
+			return resp
+		}
+	}
+	return nil
+}
+
+func forwardTo(upstream string, query []byte, overTCP bool) []byte {
+	network := "udp"
+	if overTCP {
+		network = "tcp"
+	}
+	conn, err := net.DialTimeout(network, upstream, upstreamTimeout)
+	if err != nil {
+		log.Errorf("Failed to connect to upstream %s: %v", upstream, err)
+		return nil
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(upstreamTimeout))
+	if overTCP {
+		if err = writeTCPMsg(conn, query); err != nil {
+			log.Errorf("Failed to forward query to upstream %s: %v", upstream, err)
+			return nil
+		}
+		resp, err := readTCPMsg(conn)
+		if err != nil {
+			log.Errorf("Failed to read response from upstream %s: %v", upstream, err)
+			return nil
+		}
+		return resp
+	}
+	if _, err = conn.Write(query); err != nil {
+		log.Errorf("Failed to forward query to upstream %s: %v", upstream, err)
+		return nil
+	}
+	buf := make([]byte, maxUDPMsgLen)
+	n, err := conn.Read(buf)
+	if err != nil {
+		log.Errorf("Failed to read response from upstream %s: %v", upstream, err)
+		return nil
+	}
+	return buf[:n]
+}
+
+// DNS messages sent over TCP are prefixed with two-byte length field.
+func readTCPMsg(conn net.Conn) ([]byte, error) {
+	var msgLen uint16
+	if err := binary.Read(conn, binary.BigEndian, &msgLen); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, msgLen)
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeTCPMsg(conn net.Conn, msg []byte) error {
+	buf := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	copy(buf[2:], msg)
+	_, err := conn.Write(buf)
+	return err
+}
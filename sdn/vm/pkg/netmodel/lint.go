@@ -0,0 +1,198 @@
+package netmodel
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// lint looks for parts of the network model which are valid but likely
+// do not work as the user intended. Issues are reported as warnings.
+func (v *validator) lint() {
+	v.lintUnusedItems()
+	v.lintEndpointReachability()
+	v.lintOverlappingSubnets()
+	v.lintDHCPRanges()
+}
+
+// Ports not used by any bridge or bond and bridges without networks and endpoints.
+func (v *validator) lintUnusedItems() {
+	for i, port := range v.model.Ports {
+		item := v.model.Items.GetItem(port.ItemType(), port.LogicalLabel)
+		if item != nil && len(item.ReferencedBy) == 0 {
+			v.warnf(fmt.Sprintf("ports[%d]", i),
+				"port %s is not used by any bridge or bond", port.LogicalLabel)
+		}
+	}
+	for i, bridge := range v.model.Bridges {
+		item := v.model.Items.GetItem(bridge.ItemType(), bridge.LogicalLabel)
+		if item != nil && len(item.ReferencedBy) == 0 {
+			v.warnf(fmt.Sprintf("bridges[%d]", i),
+				"bridge %s is not used by any network or endpoint", bridge.LogicalLabel)
+		}
+	}
+}
+
+// Endpoints which cannot be reached from EVE or other endpoints and private DNS/NTP/netboot servers
+// which are not reachable from the network that announces them with DHCP.
+func (v *validator) lintEndpointReachability() {
+	for _, item := range v.endpointItems() {
+		if item.Category == (api.Client{}).ItemCategory() {
+			// Clients initiate traffic, it is fine if they are not reachable.
+			continue
+		}
+		ep := item.ToEndpoint()
+		if ep.DirectL2Connect.Bridge != "" {
+			continue
+		}
+		var reachable bool
+		for _, refBy := range item.ReferencedBy {
+			if refBy.Typename == (api.Endpoint{}).ItemType() {
+				// Used by another endpoint (e.g. DNS server of a proxy).
+				reachable = true
+				break
+			}
+		}
+		for _, network := range v.model.Networks {
+			if v.networkReachesEndpoint(network, ep.LogicalLabel) {
+				reachable = true
+				break
+			}
+		}
+		if !reachable {
+			v.warnf(item.Path, "endpoint %s is not reachable from any network",
+				ep.LogicalLabel)
+		}
+	}
+	for i, network := range v.model.Networks {
+		if !network.DHCP.Enable || network.Router == nil {
+			continue
+		}
+		path := fmt.Sprintf("networks[%d].dhcp", i)
+		for j, dns := range network.DHCP.PrivateDNS {
+			if !v.networkReachesEndpoint(network, dns) {
+				v.warnf(fmt.Sprintf("%s.privateDNS[%d]", path, j),
+					"DNS server %s used by network %s is not among its reachable endpoints",
+					dns, network.LogicalLabel)
+			}
+		}
+		if ntp := network.DHCP.PrivateNTP; ntp != "" {
+			if !v.networkReachesEndpoint(network, ntp) {
+				v.warnf(path+".privateNTP",
+					"NTP server %s used by network %s is not among its reachable endpoints",
+					ntp, network.LogicalLabel)
+			}
+		}
+		if netboot := network.DHCP.NetbootServer; netboot != "" {
+			if !v.networkReachesEndpoint(network, netboot) {
+				v.warnf(path+".netbootServer",
+					"netboot server %s used by network %s is not among its reachable endpoints",
+					netboot, network.LogicalLabel)
+			}
+		}
+	}
+}
+
+func (v *validator) networkReachesEndpoint(network api.Network, epLabel string) bool {
+	if network.Router == nil || network.TransparentProxy == epLabel ||
+		network.CaptivePortal == epLabel {
+		return true
+	}
+	for _, reachEp := range network.Router.ReachableEndpoints {
+		if reachEp == epLabel {
+			return true
+		}
+	}
+	return false
+}
+
+// Subnets of networks and endpoints which overlap with each other.
+func (v *validator) lintOverlappingSubnets() {
+	type labeledSubnet struct {
+		path   string
+		label  string
+		subnet *net.IPNet
+	}
+	var subnets []labeledSubnet
+	for i, network := range v.model.Networks {
+		if _, subnet, err := net.ParseCIDR(network.Subnet); err == nil {
+			subnets = append(subnets, labeledSubnet{
+				path:   fmt.Sprintf("networks[%d].subnet", i),
+				label:  "network " + network.LogicalLabel,
+				subnet: subnet,
+			})
+		}
+	}
+	for _, item := range v.endpointItems() {
+		ep := item.ToEndpoint()
+		if ep.Subnet == "" {
+			continue
+		}
+		if _, subnet, err := net.ParseCIDR(ep.Subnet); err == nil {
+			subnets = append(subnets, labeledSubnet{
+				path:   item.Path + ".subnet",
+				label:  "endpoint " + ep.LogicalLabel,
+				subnet: subnet,
+			})
+		}
+	}
+	for i := range subnets {
+		for j := i + 1; j < len(subnets); j++ {
+			a, b := subnets[i], subnets[j]
+			if a.subnet.Contains(b.subnet.IP) || b.subnet.Contains(a.subnet.IP) {
+				v.warnf(b.path, "subnet of %s (%s) overlaps with subnet of %s (%s)",
+					b.label, b.subnet, a.label, a.subnet)
+			}
+		}
+	}
+}
+
+// endpointItems returns labeled items of all endpoints, ordered by their path
+// so that issues are reported in a deterministic order.
+func (v *validator) endpointItems() (items []*LabeledItem) {
+	epType := api.Endpoint{}.ItemType()
+	for id, item := range v.model.Items {
+		if id.Typename == epType {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Path < items[j].Path
+	})
+	return items
+}
+
+// Gateway and static DHCP entries with IPs allocated also dynamically from the DHCP range.
+func (v *validator) lintDHCPRanges() {
+	for i, network := range v.model.Networks {
+		dhcp := network.DHCP
+		if !dhcp.Enable || dhcp.IPRange.FromIP == "" {
+			continue
+		}
+		fromIP := net.ParseIP(dhcp.IPRange.FromIP)
+		toIP := net.ParseIP(dhcp.IPRange.ToIP)
+		if fromIP == nil || toIP == nil {
+			// Already reported.
+			continue
+		}
+		inRange := func(ip net.IP) bool {
+			return ip != nil && bytes.Compare(ip.To16(), fromIP.To16()) >= 0 &&
+				bytes.Compare(ip.To16(), toIP.To16()) <= 0
+		}
+		if inRange(net.ParseIP(network.GwIP)) {
+			v.warnf(fmt.Sprintf("networks[%d].gwIP", i),
+				"gateway IP (%s) of network %s is inside of the DHCP IP range",
+				network.GwIP, network.LogicalLabel)
+		}
+		for j, entry := range dhcp.StaticEntries {
+			if inRange(net.ParseIP(entry.IP)) {
+				v.warnf(fmt.Sprintf("networks[%d].dhcp.staticEntries[%d].ip", i, j),
+					"static DHCP entry IP (%s) of network %s is inside of the DHCP IP range "+
+						"and may be leased also to another client", entry.IP, network.LogicalLabel)
+			}
+		}
+	}
+}
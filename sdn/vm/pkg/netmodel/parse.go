@@ -0,0 +1,280 @@
+// Package netmodel implements parsing and validation of the Eden-SDN network model.
+// It is used by the SDN agent before a network model is applied, but also by eden
+// to validate network model offline, without SDN VM running.
+package netmodel
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MinMTU : minimum accepted MTU value.
+	// Eventually, Eden-SDN will incorporate IPv6 support.
+	// As per RFC 8200, the MTU must not be less than 1280 bytes to accommodate IPv6 packets.
+	MinMTU = 1280
+	// MaxMTU : maximum MTU supported by the e1000 driver (used for interfaces connecting
+	// Eden-SDN with the EVE VM).
+	MaxMTU = 16110
+)
+
+// ParsedNetModel : network model with parsed logical labels and references.
+type ParsedNetModel struct {
+	api.NetworkModel
+	Items  LabeledItems
+	HostIP net.IP
+}
+
+// LabeledItems : all labeled items of the network model.
+type LabeledItems map[ItemID]*LabeledItem
+
+// GetItem : lookup labeled item by type and logical label.
+func (li LabeledItems) GetItem(typename, logicalLabel string) *LabeledItem {
+	return li[ItemID{
+		Typename:     typename,
+		LogicalLabel: logicalLabel,
+	}]
+}
+
+// ItemID : unique identifier of a labeled item.
+type ItemID struct {
+	Typename     string
+	LogicalLabel string
+}
+
+// ItemRef : reference to a labeled item.
+type ItemRef struct {
+	ItemID
+	RefKey string
+}
+
+// LabeledItem : labeled item of the network model together with references
+// from and to other items.
+type LabeledItem struct {
+	api.LabeledItem
+	Category     string            // empty if not categorized
+	Path         string            // JSON path of the item inside the network model
+	Referencing  []ItemRef         // other items referenced by this item
+	ReferencedBy map[string]ItemID // RefKey -> item
+}
+
+// ToEndpoint returns Endpoint embedded inside the labeled item of the type endpoint.
+func (li *LabeledItem) ToEndpoint() api.Endpoint {
+	switch li.Category {
+	case api.Client{}.ItemCategory():
+		return li.LabeledItem.(api.Client).Endpoint
+	case api.DNSServer{}.ItemCategory():
+		return li.LabeledItem.(api.DNSServer).Endpoint
+	case api.NTPServer{}.ItemCategory():
+		return li.LabeledItem.(api.NTPServer).Endpoint
+	case api.HTTPServer{}.ItemCategory():
+		return li.LabeledItem.(api.HTTPServer).Endpoint
+	case api.ExplicitProxy{}.ItemCategory():
+		return li.LabeledItem.(api.ExplicitProxy).Endpoint
+	case api.TransparentProxy{}.ItemCategory():
+		return li.LabeledItem.(api.TransparentProxy).Endpoint
+	case api.NetbootServer{}.ItemCategory():
+		return li.LabeledItem.(api.NetbootServer).Endpoint
+	case api.WireGuardServer{}.ItemCategory():
+		return li.LabeledItem.(api.WireGuardServer).Endpoint
+	case api.CaptivePortal{}.ItemCategory():
+		return li.LabeledItem.(api.CaptivePortal).Endpoint
+	case api.SyslogServer{}.ItemCategory():
+		return li.LabeledItem.(api.SyslogServer).Endpoint
+	default:
+		log.Fatalf("Unexpected endpoint category: %s", li.Category)
+	}
+	return api.Endpoint{} // unreachable
+}
+
+// Issue : problem found in the network model.
+type Issue struct {
+	// Path : JSON path of the network model item with the issue (e.g. "ports[0].mac").
+	Path string `json:"path"`
+	// Message : description of the issue.
+	Message string `json:"message"`
+}
+
+// String returns issue description prefixed with the JSON path.
+func (i Issue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+// Report : result of the network model validation.
+type Report struct {
+	// Errors : issues which prevent the network model from being applied.
+	Errors []Issue `json:"errors,omitempty"`
+	// Warnings : issues which do not prevent the network model from being applied,
+	// but likely indicate a mistake.
+	Warnings []Issue `json:"warnings,omitempty"`
+}
+
+// Err returns all errors from the report combined into one, nil if there are none.
+func (r Report) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	var msgs []string
+	for _, issue := range r.Errors {
+		msgs = append(msgs, issue.String())
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// Parse parses and validates the network model.
+// Returns error describing all problems if the model is not valid.
+func Parse(netModel api.NetworkModel) (ParsedNetModel, error) {
+	parsedModel, report := Validate(netModel)
+	return parsedModel, report.Err()
+}
+
+// Validate parses and validates the network model and reports all errors
+// and warnings found.
+func Validate(netModel api.NetworkModel) (ParsedNetModel, Report) {
+	v := &validator{
+		model: ParsedNetModel{NetworkModel: netModel},
+	}
+	// Parse and validate logical labels and their referencing.
+	eps := netModel.Endpoints
+	var items []pathItem
+	items = appendPathItems(items, "ports", netModel.Ports)
+	items = appendPathItems(items, "bonds", netModel.Bonds)
+	items = appendPathItems(items, "bridges", netModel.Bridges)
+	items = appendPathItems(items, "networks", netModel.Networks)
+	items = appendPathItems(items, "endpoints.dnsServers", eps.DNSServers)
+	items = appendPathItems(items, "endpoints.ntpServers", eps.NTPServers)
+	items = appendPathItems(items, "endpoints.netbootServers", eps.NetbootServers)
+	items = appendPathItems(items, "endpoints.httpServers", eps.HTTPServers)
+	items = appendPathItems(items, "endpoints.explicitProxies", eps.ExplicitProxies)
+	items = appendPathItems(items, "endpoints.transparentProxies", eps.TransparentProxies)
+	items = appendPathItems(items, "endpoints.clients", eps.Clients)
+	items = appendPathItems(items, "endpoints.wireguardServers", eps.WireGuardServers)
+	items = appendPathItems(items, "endpoints.captivePortals", eps.CaptivePortals)
+	items = appendPathItems(items, "endpoints.syslogServers", eps.SyslogServers)
+	v.parseLabeledItems(items)
+
+	v.validatePorts()
+	v.validateHostConfig()
+	v.validateNetworks()
+	v.validateEndpoints()
+	v.validateFirewall()
+	v.lint()
+	return v.model, v.report
+}
+
+type validator struct {
+	model  ParsedNetModel
+	report Report
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.report.Errors = append(v.report.Errors, Issue{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) warnf(path, format string, args ...interface{}) {
+	v.report.Warnings = append(v.report.Warnings, Issue{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// pathItem : labeled item with its JSON path inside the network model.
+type pathItem struct {
+	path string
+	item api.LabeledItem
+}
+
+func appendPathItems(items []pathItem, path string, slice interface{}) []pathItem {
+	rv := reflect.ValueOf(slice)
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i)
+		if labeledItem, ok := item.Interface().(api.LabeledItem); ok {
+			items = append(items, pathItem{
+				path: fmt.Sprintf("%s[%d]", path, i),
+				item: labeledItem,
+			})
+		} else {
+			log.Warnf("Not an instance of labeled item: %+v", item)
+		}
+	}
+	return items
+}
+
+func (v *validator) parseLabeledItems(items []pathItem) {
+	parsedItems := make(LabeledItems)
+	for _, item := range items {
+		id := ItemID{
+			Typename:     item.item.ItemType(),
+			LogicalLabel: item.item.ItemLogicalLabel(),
+		}
+		if _, duplicate := parsedItems[id]; duplicate {
+			v.errorf(item.path, "duplicate logical label: %s/%s",
+				id.Typename, id.LogicalLabel)
+			continue
+		}
+		var category string
+		if categItem, withCategory := item.item.(api.LabeledItemWithCategory); withCategory {
+			category = categItem.ItemCategory()
+		}
+		parsedItems[id] = &LabeledItem{
+			LabeledItem:  item.item,
+			Category:     category,
+			Path:         item.path,
+			ReferencedBy: make(map[string]ItemID),
+		}
+	}
+	for _, item := range items {
+		id := ItemID{
+			Typename:     item.item.ItemType(),
+			LogicalLabel: item.item.ItemLogicalLabel(),
+		}
+		if parsedItems[id].Path != item.path {
+			// Duplicate, already reported.
+			continue
+		}
+		for _, ref := range item.item.ReferencesFromItem() {
+			refID := ItemID{
+				Typename:     ref.ItemType,
+				LogicalLabel: ref.ItemLogicalLabel,
+			}
+			refItem, exists := parsedItems[refID]
+			if !exists {
+				v.errorf(item.path, "referenced item %s/%s does not exist (ref-key: %s)",
+					refID.Typename, refID.LogicalLabel, ref.RefKey)
+				continue
+			}
+			if ref.ItemCategory != "" {
+				if refItem.Category != ref.ItemCategory {
+					v.errorf(item.path, "category mismatch for referenced item %s/%s "+
+						"(expected %s, has %s)", refID.Typename, refID.LogicalLabel,
+						ref.ItemCategory, refItem.Category)
+					continue
+				}
+			}
+			_, collision := refItem.ReferencedBy[ref.RefKey]
+			if collision {
+				v.errorf(item.path, "colliding referencing to logical label: %s/%s "+
+					"(ref-key: %s)", refID.Typename, refID.LogicalLabel, ref.RefKey)
+				continue
+			}
+			refItem.ReferencedBy[ref.RefKey] = id
+			parsedItems[id].Referencing = append(parsedItems[id].Referencing, ItemRef{
+				ItemID: refID,
+				RefKey: ref.RefKey,
+			})
+		}
+	}
+	v.model.Items = parsedItems
+}
@@ -0,0 +1,930 @@
+package netmodel
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// HostPortMACPrefix : MAC address prefix reserved for the port connecting SDN VM
+// with the host (suffix is randomly generated by Eden).
+var HostPortMACPrefix = net.HardwareAddr{0x08, 0x33, 0x33}
+
+func (v *validator) validatePorts() {
+	// Every port should have a valid and unique MAC address.
+	macs := make(map[string]struct{})
+	for i, port := range v.model.Ports {
+		path := fmt.Sprintf("ports[%d]", i)
+		if _, duplicate := macs[port.MAC]; duplicate {
+			v.errorf(path+".mac", "port %s has duplicate MAC address %s",
+				port.LogicalLabel, port.MAC)
+		}
+		macs[port.MAC] = struct{}{}
+		mac, err := net.ParseMAC(port.MAC)
+		if err != nil {
+			v.errorf(path+".mac", "port %s has invalid MAC address: %v",
+				port.LogicalLabel, err)
+		} else if bytes.HasPrefix(mac, HostPortMACPrefix) {
+			v.errorf(path+".mac", "port %s has MAC address with prefix reserved for the host port",
+				port.LogicalLabel)
+		}
+		if _, err = net.ParseMAC(port.EVEConnect.MAC); err != nil {
+			v.errorf(path+".eveConnect.mac", "EVE-side of port %s has invalid MAC address: %v",
+				port.LogicalLabel, err)
+		}
+	}
+
+	for i, port := range v.model.Ports {
+		path := fmt.Sprintf("ports[%d]", i)
+		v.validateTC(port.TC, path+".trafficControl", fmt.Sprintf("port %s", port.LogicalLabel))
+		if port.Scenario == nil {
+			continue
+		}
+		// Impairment scenario should have at least one step and every step
+		// should last for some time.
+		if len(port.Scenario.Steps) == 0 {
+			v.errorf(path+".scenario.steps", "impairment scenario for port %s has no steps",
+				port.LogicalLabel)
+		}
+		for j, step := range port.Scenario.Steps {
+			stepPath := fmt.Sprintf("%s.scenario.steps[%d]", path, j)
+			if step.Duration == 0 {
+				v.errorf(stepPath+".duration",
+					"step %d of impairment scenario for port %s has zero duration",
+					j, port.LogicalLabel)
+			}
+			v.validateTC(step.TC, stepPath+".trafficControl",
+				fmt.Sprintf("step %d of impairment scenario for port %s", j, port.LogicalLabel))
+		}
+	}
+}
+
+func (v *validator) validateTC(tc api.TrafficControl, path, owner string) {
+	// QueueLimit and BurstLimit are mandatory when RateLimit is set.
+	if tc.RateLimit != 0 {
+		if tc.QueueLimit == 0 {
+			v.errorf(path+".queueLimit", "RateLimit set for %s without QueueLimit", owner)
+		}
+		if tc.BurstLimit == 0 {
+			v.errorf(path+".burstLimit", "RateLimit set for %s without BurstLimit", owner)
+		}
+	}
+}
+
+func (v *validator) validateNetworks() {
+	// Validate network Subnet, gateway IP and VLANs.
+	subnets := make(map[string]*net.IPNet)
+	for i, network := range v.model.Networks {
+		path := fmt.Sprintf("networks[%d]", i)
+		_, subnet, err := net.ParseCIDR(network.Subnet)
+		if err != nil {
+			v.errorf(path+".subnet", "network %s has invalid subnet: %v",
+				network.LogicalLabel, err)
+		} else {
+			subnets[network.LogicalLabel] = subnet
+		}
+		if gwIP := net.ParseIP(network.GwIP); gwIP == nil {
+			v.errorf(path+".gwIP", "network %s has invalid gateway IP (%s)",
+				network.LogicalLabel, network.GwIP)
+		}
+	}
+
+	// Validate DHCP config.
+	for i, network := range v.model.Networks {
+		path := fmt.Sprintf("networks[%d].dhcp", i)
+		subnet := subnets[network.LogicalLabel]
+		dhcp := network.DHCP
+		if !dhcp.Enable {
+			continue
+		}
+		if dhcp.IPRange.FromIP != "" {
+			fromIP := net.ParseIP(dhcp.IPRange.FromIP)
+			if fromIP == nil {
+				v.errorf(path+".ipRange.fromIP", "network %s has invalid DHCP range FromIP (%s)",
+					network.LogicalLabel, dhcp.IPRange.FromIP)
+			}
+			toIP := net.ParseIP(dhcp.IPRange.ToIP)
+			if toIP == nil {
+				v.errorf(path+".ipRange.toIP", "network %s has invalid DHCP range ToIP (%s)",
+					network.LogicalLabel, dhcp.IPRange.ToIP)
+			}
+			if fromIP != nil && toIP != nil {
+				if subnet != nil && (!subnet.Contains(fromIP) || !subnet.Contains(toIP)) {
+					v.errorf(path+".ipRange", "network %s has DHCP IP range outside of the subnet",
+						network.LogicalLabel)
+				}
+				if bytes.Compare(fromIP, toIP) > 0 {
+					v.errorf(path+".ipRange", "network %s has DHCP IP range where FromIP > ToIP",
+						network.LogicalLabel)
+				}
+			}
+		}
+		for j, dns := range dhcp.PublicDNS {
+			if dnsIP := net.ParseIP(dns); dnsIP == nil {
+				v.errorf(fmt.Sprintf("%s.publicDNS[%d]", path, j),
+					"network %s has invalid public DNS server IP (%s)",
+					network.LogicalLabel, dns)
+			}
+		}
+		if dhcp.PrivateNTP != "" && dhcp.PublicNTP != "" {
+			v.errorf(path, "network %s has both public and private NTP configured",
+				network.LogicalLabel)
+		}
+		for j, entry := range dhcp.StaticEntries {
+			entryPath := fmt.Sprintf("%s.staticEntries[%d]", path, j)
+			if _, err := net.ParseMAC(entry.MAC); err != nil {
+				v.errorf(entryPath+".mac", "network %s has static DHCP entry with invalid MAC (%s)",
+					network.LogicalLabel, entry.MAC)
+			}
+			ip := net.ParseIP(entry.IP)
+			if ip == nil {
+				v.errorf(entryPath+".ip", "network %s has static DHCP entry with invalid IP (%s)",
+					network.LogicalLabel, entry.IP)
+			} else if subnet != nil && !subnet.Contains(ip) {
+				v.warnf(entryPath+".ip", "network %s has static DHCP entry with IP (%s) "+
+					"outside of the subnet", network.LogicalLabel, entry.IP)
+			}
+		}
+	}
+
+	// Do not mix VLAN and non-VLAN network/endpoint with the same bridge
+	for i, bridge := range v.model.Bridges {
+		var netWithVlan, netWithoutVlan bool
+		labeledItem := v.model.Items.GetItem(api.Bridge{}.ItemType(), bridge.LogicalLabel)
+		if labeledItem == nil || labeledItem.Path != fmt.Sprintf("bridges[%d]", i) {
+			// Duplicate, already reported.
+			continue
+		}
+		for refKey, refBy := range labeledItem.ReferencedBy {
+			var vlanID uint16
+			if strings.HasPrefix(refKey, api.NetworkBridgeRefPrefix) {
+				network := v.model.Items[refBy].LabeledItem
+				vlanID = network.(api.Network).VlanID
+			} else if strings.HasPrefix(refKey, api.EndpointBridgeRefPrefix) {
+				endpoint := v.model.Items[refBy].ToEndpoint()
+				vlanID = endpoint.DirectL2Connect.VlanID
+			}
+			if (vlanID == 0 && netWithVlan) || (vlanID != 0 && netWithoutVlan) {
+				v.errorf(labeledItem.Path, "bridge %s with both VLAN and non-VLAN networks/endpoints",
+					bridge.LogicalLabel)
+				break
+			}
+			if vlanID == 0 {
+				netWithoutVlan = true
+			} else {
+				netWithVlan = true
+			}
+		}
+	}
+
+	// Validate routes towards EVE.
+	for i, network := range v.model.Networks {
+		if network.Router == nil {
+			continue
+		}
+		subnet := subnets[network.LogicalLabel]
+		for j, route := range network.Router.RoutesTowardsEVE {
+			path := fmt.Sprintf("networks[%d].router.routesTowardsEVE[%d]", i, j)
+			if _, _, err := net.ParseCIDR(route.DstNetwork); err != nil {
+				v.errorf(path+".dstNetwork", "network %s route %+v has invalid destination: %v",
+					network.LogicalLabel, route, err)
+			}
+			gwIP := net.ParseIP(route.Gateway)
+			if gwIP == nil {
+				v.errorf(path+".gateway", "network %s route %+v has invalid gateway IP (%s)",
+					network.LogicalLabel, route, route.Gateway)
+			} else if subnet != nil && !subnet.Contains(gwIP) {
+				v.errorf(path+".gateway", "network %s route %+v has gateway IP (%s) "+
+					"which is not from within the network subnet",
+					network.LogicalLabel, route, route.Gateway)
+			}
+		}
+	}
+
+	// Validate MTU settings.
+	for i, network := range v.model.Networks {
+		path := fmt.Sprintf("networks[%d].mtu", i)
+		if network.MTU != 0 && network.MTU < MinMTU {
+			v.errorf(path, "MTU %d configured for network %s is too small",
+				network.MTU, network.LogicalLabel)
+		}
+		if network.MTU > MaxMTU {
+			v.errorf(path, "MTU %d configured for network %s is too large",
+				network.MTU, network.LogicalLabel)
+		}
+	}
+
+	// Validate captive portal settings.
+	for i, network := range v.model.Networks {
+		path := fmt.Sprintf("networks[%d].captivePortal", i)
+		subnet := subnets[network.LogicalLabel]
+		if network.CaptivePortal != "" && subnet != nil && subnet.IP.To4() == nil {
+			v.errorf(path, "captive portal is not supported for IPv6 network %s",
+				network.LogicalLabel)
+		}
+	}
+
+	// Validate IPv6 provisioning.
+	for i, network := range v.model.Networks {
+		if network.IPv6 == nil {
+			continue
+		}
+		path := fmt.Sprintf("networks[%d].ipv6", i)
+		subnet := subnets[network.LogicalLabel]
+		if subnet != nil && subnet.IP.To4() != nil {
+			v.errorf(path, "IPv6 provisioning configured for IPv4 network %s",
+				network.LogicalLabel)
+			continue
+		}
+		if !network.DHCP.Enable {
+			v.warnf(path, "IPv6 provisioning configured for network %s with disabled DHCP "+
+				"is ignored", network.LogicalLabel)
+		}
+		ipv6 := network.IPv6
+		switch ipv6.Mode {
+		case api.IPv6ModeSLAAC, api.IPv6ModeSLAACWithDHCPv6:
+		case api.IPv6ModeDHCPv6:
+			if network.DHCP.Enable && network.DHCP.IPRange.FromIP == "" {
+				v.errorf(path+".mode", "network %s with stateful DHCPv6 is missing DHCP "+
+					"IP range", network.LogicalLabel)
+			}
+		default:
+			v.errorf(path+".mode", "network %s has invalid IPv6 provisioning mode (%s)",
+				network.LogicalLabel, ipv6.Mode)
+		}
+		if ipv6.RAInterval != 0 && (ipv6.RAInterval < 4 || ipv6.RAInterval > 1800) {
+			v.errorf(path+".raInterval", "network %s has RA interval (%d) outside "+
+				"of the allowed range <4,1800>", network.LogicalLabel, ipv6.RAInterval)
+		}
+		if pd := ipv6.PrefixDelegation; pd != nil {
+			pdPath := path + ".prefixDelegation"
+			_, prefix, err := net.ParseCIDR(pd.Prefix)
+			if err != nil || prefix.IP.To4() != nil {
+				v.errorf(pdPath+".prefix", "network %s has invalid IPv6 prefix "+
+					"for delegation (%s)", network.LogicalLabel, pd.Prefix)
+			} else {
+				prefixLen, _ := prefix.Mask.Size()
+				if int(pd.DelegatedLen) <= prefixLen || pd.DelegatedLen > 64 {
+					v.errorf(pdPath+".delegatedLen", "network %s has invalid length of "+
+						"delegated prefixes (%d), expected value in range <%d,64>",
+						network.LogicalLabel, pd.DelegatedLen, prefixLen+1)
+				}
+				if subnet != nil && (subnet.Contains(prefix.IP) || prefix.Contains(subnet.IP)) {
+					v.errorf(pdPath+".prefix", "network %s has prefix for delegation "+
+						"overlapping with the network subnet", network.LogicalLabel)
+				}
+			}
+		}
+	}
+
+	// Validate NAT64 settings.
+	var nat64Prefix string
+	for i, network := range v.model.Networks {
+		if network.NAT64 == nil {
+			continue
+		}
+		path := fmt.Sprintf("networks[%d].nat64", i)
+		subnet := subnets[network.LogicalLabel]
+		if subnet != nil && subnet.IP.To4() != nil {
+			v.errorf(path, "NAT64 configured for IPv4 network %s", network.LogicalLabel)
+			continue
+		}
+		prefixStr := network.NAT64.Prefix
+		if prefixStr == "" {
+			prefixStr = api.DefaultNAT64Prefix
+		}
+		_, prefix, err := net.ParseCIDR(prefixStr)
+		if err != nil || prefix.IP.To4() != nil {
+			v.errorf(path+".prefix", "network %s has invalid NAT64 prefix (%s)",
+				network.LogicalLabel, prefixStr)
+		} else if ones, _ := prefix.Mask.Size(); ones != 96 {
+			v.errorf(path+".prefix", "network %s has NAT64 prefix (%s) which is not /96",
+				network.LogicalLabel, prefixStr)
+		} else if nat64Prefix != "" && nat64Prefix != prefix.String() {
+			v.errorf(path+".prefix", "network %s has NAT64 prefix (%s) different "+
+				"from other networks (%s)", network.LogicalLabel, prefix, nat64Prefix)
+		} else {
+			nat64Prefix = prefix.String()
+		}
+		dhcp := network.DHCP
+		if network.NAT64.DNS64 && (!dhcp.Enable ||
+			len(dhcp.PublicDNS)+len(dhcp.PrivateDNS) == 0) {
+			v.errorf(path+".dns64", "network %s with DNS64 is missing DNS servers "+
+				"in the DHCP config (used as upstream servers)", network.LogicalLabel)
+		}
+	}
+
+	// Validate NAT settings.
+	v.validateNAT(subnets)
+
+	// TODO: check that within a network it is IPv4 or IPv6, not both (for now)
+}
+
+func (v *validator) validateNAT(subnets map[string]*net.IPNet) {
+	natVia := make(map[string]string)
+	type labeledSubnet struct {
+		network string
+		subnet  *net.IPNet
+	}
+	var outsideSubnets []labeledSubnet
+	for i, network := range v.model.Networks {
+		if network.Router == nil || network.Router.NAT == nil {
+			continue
+		}
+		nat := network.Router.NAT
+		path := fmt.Sprintf("networks[%d].router.nat", i)
+		subnet := subnets[network.LogicalLabel]
+		if subnet != nil && subnet.IP.To4() == nil {
+			v.errorf(path, "NAT is not supported for IPv6 network %s", network.LogicalLabel)
+			continue
+		}
+		switch nat.Type {
+		case "", api.NATPortRestrictedCone, api.NATSymmetric:
+		case api.NATFullCone:
+			if nat.RandomizePorts {
+				v.errorf(path+".randomizePorts", "network %s with full-cone NAT cannot "+
+					"randomize ports", network.LogicalLabel)
+			}
+			if len(nat.PortForwarding) > 0 {
+				v.errorf(path+".portForwarding", "network %s with full-cone NAT does not "+
+					"need port forwarding (all ports are forwarded)", network.LogicalLabel)
+			}
+		default:
+			v.errorf(path+".type", "network %s has invalid NAT type (%s)",
+				network.LogicalLabel, nat.Type)
+		}
+		_, outsideSubnet, err := net.ParseCIDR(nat.OutsideSubnet)
+		if err != nil || outsideSubnet.IP.To4() == nil {
+			v.errorf(path+".outsideSubnet", "network %s has invalid NAT outside subnet (%s)",
+				network.LogicalLabel, nat.OutsideSubnet)
+		} else {
+			if nat.Type == api.NATFullCone && subnet != nil {
+				outsideLen, _ := outsideSubnet.Mask.Size()
+				subnetLen, _ := subnet.Mask.Size()
+				if outsideLen != subnetLen {
+					v.errorf(path+".outsideSubnet", "network %s with full-cone NAT has "+
+						"outside subnet (%s) of different size than the network subnet (%s)",
+						network.LogicalLabel, nat.OutsideSubnet, network.Subnet)
+				}
+			}
+			for _, network2 := range v.model.Networks {
+				subnet2 := subnets[network2.LogicalLabel]
+				if subnet2 != nil && subnetsOverlap(outsideSubnet, subnet2) {
+					v.errorf(path+".outsideSubnet", "network %s has NAT outside subnet (%s) "+
+						"overlapping with the subnet of network %s", network.LogicalLabel,
+						nat.OutsideSubnet, network2.LogicalLabel)
+				}
+			}
+			for _, ep := range v.model.Endpoints.GetAll() {
+				_, epSubnet, err := net.ParseCIDR(ep.Subnet)
+				if err == nil && subnetsOverlap(outsideSubnet, epSubnet) {
+					v.errorf(path+".outsideSubnet", "network %s has NAT outside subnet (%s) "+
+						"overlapping with the subnet of endpoint %s", network.LogicalLabel,
+						nat.OutsideSubnet, ep.LogicalLabel)
+				}
+			}
+			for _, prevOutside := range outsideSubnets {
+				if subnetsOverlap(outsideSubnet, prevOutside.subnet) {
+					v.errorf(path+".outsideSubnet", "network %s has NAT outside subnet (%s) "+
+						"overlapping with the NAT outside subnet of network %s",
+						network.LogicalLabel, nat.OutsideSubnet, prevOutside.network)
+				}
+			}
+			outsideSubnets = append(outsideSubnets, labeledSubnet{
+				network: network.LogicalLabel,
+				subnet:  outsideSubnet,
+			})
+		}
+		for j, fwd := range nat.PortForwarding {
+			fwdPath := fmt.Sprintf("%s.portForwarding[%d]", path, j)
+			if fwd.Protocol != "tcp" && fwd.Protocol != "udp" {
+				v.errorf(fwdPath+".protocol", "network %s has NAT port forwarding "+
+					"with invalid protocol (%s)", network.LogicalLabel, fwd.Protocol)
+			}
+			if fwd.OutsidePort == 0 {
+				v.errorf(fwdPath+".outsidePort", "network %s has NAT port forwarding "+
+					"with zero outside port", network.LogicalLabel)
+			}
+			insideIP := net.ParseIP(fwd.InsideIP)
+			if insideIP == nil {
+				v.errorf(fwdPath+".insideIP", "network %s has NAT port forwarding "+
+					"with invalid inside IP (%s)", network.LogicalLabel, fwd.InsideIP)
+			} else if subnet != nil && !subnet.Contains(insideIP) {
+				v.errorf(fwdPath+".insideIP", "network %s has NAT port forwarding "+
+					"with inside IP (%s) outside of the network subnet",
+					network.LogicalLabel, fwd.InsideIP)
+			}
+		}
+		if nat.Via != "" {
+			if nat.Via == network.LogicalLabel {
+				v.errorf(path+".via", "network %s cannot chain NAT through itself",
+					network.LogicalLabel)
+			} else {
+				natVia[network.LogicalLabel] = nat.Via
+			}
+		}
+	}
+	// Validate intermediate networks of chained NATs.
+	for i, network := range v.model.Networks {
+		via, hasVia := natVia[network.LogicalLabel]
+		if !hasVia {
+			continue
+		}
+		path := fmt.Sprintf("networks[%d].router.nat.via", i)
+		item := v.model.Items.GetItem(api.Network{}.ItemType(), via)
+		if item == nil {
+			// Dangling reference, already reported.
+			continue
+		}
+		viaNetwork := item.LabeledItem.(api.Network)
+		if viaNetwork.Router == nil || viaNetwork.Router.NAT == nil {
+			v.errorf(path, "network %s chains NAT through network %s without NAT",
+				network.LogicalLabel, via)
+		} else if viaNetwork.Router.NAT.Type == api.NATFullCone {
+			v.errorf(path, "network %s chains NAT through network %s with full-cone NAT, "+
+				"which is not supported", network.LogicalLabel, via)
+		}
+		if viaSubnet := subnets[via]; viaSubnet != nil && viaSubnet.IP.To4() == nil {
+			v.errorf(path, "network %s chains NAT through IPv6 network %s",
+				network.LogicalLabel, via)
+		}
+		// Detect loops.
+		visited := map[string]struct{}{network.LogicalLabel: {}}
+		for next, ok := via, true; ok; next, ok = natVia[next] {
+			if _, loop := visited[next]; loop {
+				v.errorf(path, "network %s has NAT chained in a loop", network.LogicalLabel)
+				break
+			}
+			visited[next] = struct{}{}
+		}
+	}
+}
+
+func subnetsOverlap(subnet1, subnet2 *net.IPNet) bool {
+	return subnet1.Contains(subnet2.IP) || subnet2.Contains(subnet1.IP)
+}
+
+func (v *validator) validateEndpoints() {
+	// TODO
+	//  - NetbootArtifacts:
+	//      - exactly one is entrypoint, Filename and URL are non-empty
+	eps := v.model.Endpoints
+	for i, client := range eps.Clients {
+		v.validateEndpoint(client.Endpoint, fmt.Sprintf("endpoints.clients[%d]", i))
+	}
+	for i, dnsSrv := range eps.DNSServers {
+		path := fmt.Sprintf("endpoints.dnsServers[%d]", i)
+		v.validateEndpoint(dnsSrv.Endpoint, path)
+		for j, upstreamSrv := range dnsSrv.UpstreamServers {
+			if ip := net.ParseIP(upstreamSrv); ip == nil {
+				v.errorf(fmt.Sprintf("%s.upstreamServers[%d]", path, j),
+					"DNS server %s has invalid upstream server IP (%s)",
+					dnsSrv.LogicalLabel, upstreamSrv)
+			}
+		}
+		for j, entry := range dnsSrv.StaticEntries {
+			entryPath := fmt.Sprintf("%s.staticEntries[%d]", path, j)
+			if entry.FQDN == "" {
+				v.errorf(entryPath+".fqdn", "DNS server %s has static entry with empty FQDN",
+					dnsSrv.LogicalLabel)
+			}
+			if strings.HasPrefix(entry.IP, api.EndpointIPRefPrefix) ||
+				strings.HasPrefix(entry.IP, api.AdamIPRef) {
+				// Do not try to parse IP, it is a symbolic reference.
+				continue
+			}
+			if ip := net.ParseIP(entry.IP); ip == nil {
+				v.errorf(entryPath+".ip", "DNS server %s has invalid static entry IP (%s)",
+					dnsSrv.LogicalLabel, entry.IP)
+			}
+		}
+		for j, rule := range dnsSrv.FaultRules {
+			v.validateDNSFaultRule(dnsSrv.LogicalLabel, rule,
+				fmt.Sprintf("%s.faultRules[%d]", path, j))
+		}
+	}
+	for i, proxy := range eps.ExplicitProxies {
+		path := fmt.Sprintf("endpoints.explicitProxies[%d]", i)
+		v.validateEndpoint(proxy.Endpoint, path)
+		v.validateProxy(proxy.LogicalLabel, proxy.Proxy, path)
+		if proxy.HTTPProxy.Port == 0 && proxy.HTTPSProxy.Port == 0 {
+			v.errorf(path, "proxy %s without port numbers", proxy.LogicalLabel)
+		}
+		if proxy.HTTPProxy.Port != 0 && proxy.HTTPSProxy.Port != 0 {
+			if proxy.HTTPProxy.Port == proxy.HTTPSProxy.Port {
+				v.errorf(path, "proxy %s with colliding ports", proxy.LogicalLabel)
+			}
+		}
+		for j, user := range proxy.Users {
+			if user.Username == "" {
+				v.errorf(fmt.Sprintf("%s.users[%d].username", path, j),
+					"proxy %s with empty username", proxy.LogicalLabel)
+			}
+		}
+	}
+	for i, proxy := range eps.TransparentProxies {
+		path := fmt.Sprintf("endpoints.transparentProxies[%d]", i)
+		v.validateEndpoint(proxy.Endpoint, path)
+		v.validateProxy(proxy.LogicalLabel, proxy.Proxy, path)
+	}
+	for i, httpSrv := range eps.HTTPServers {
+		path := fmt.Sprintf("endpoints.httpServers[%d]", i)
+		v.validateEndpoint(httpSrv.Endpoint, path)
+		if httpSrv.HTTPPort == 0 && httpSrv.HTTPSPort == 0 {
+			v.errorf(path, "HTTP server %s without port numbers", httpSrv.LogicalLabel)
+		}
+		if httpSrv.HTTPPort != 0 && httpSrv.HTTPSPort != 0 {
+			if httpSrv.HTTPPort == httpSrv.HTTPSPort {
+				v.errorf(path, "HTTP server %s with colliding ports", httpSrv.LogicalLabel)
+			}
+		}
+		if httpSrv.CertPEM != "" {
+			if err := validateCertPEM(httpSrv.CertPEM, httpSrv.KeyPEM, false); err != nil {
+				v.errorf(path+".certPEM", "HTTP server %s: %v", httpSrv.LogicalLabel, err)
+			}
+		} else if httpSrv.HTTPSPort != 0 {
+			v.errorf(path+".certPEM", "HTTPS server %s without certificate",
+				httpSrv.LogicalLabel)
+		}
+	}
+	for i, netbootSrv := range eps.NetbootServers {
+		v.validateEndpoint(netbootSrv.Endpoint, fmt.Sprintf("endpoints.netbootServers[%d]", i))
+	}
+	for i, ntpSrv := range eps.NTPServers {
+		v.validateEndpoint(ntpSrv.Endpoint, fmt.Sprintf("endpoints.ntpServers[%d]", i))
+	}
+	for i, wgSrv := range eps.WireGuardServers {
+		path := fmt.Sprintf("endpoints.wireguardServers[%d]", i)
+		v.validateEndpoint(wgSrv.Endpoint, path)
+		v.validateWireGuardServer(wgSrv, path)
+	}
+	for i, portal := range eps.CaptivePortals {
+		path := fmt.Sprintf("endpoints.captivePortals[%d]", i)
+		v.validateEndpoint(portal.Endpoint, path)
+		if ip := net.ParseIP(portal.IP); ip != nil && ip.To4() == nil {
+			v.errorf(path+".ip", "captive portal %s must have IPv4 address",
+				portal.LogicalLabel)
+		}
+		if portal.CertPEM != "" {
+			if err := validateCertPEM(portal.CertPEM, portal.KeyPEM, false); err != nil {
+				v.errorf(path+".certPEM", "captive portal %s: %v", portal.LogicalLabel, err)
+			}
+		} else if portal.KeyPEM != "" {
+			v.errorf(path+".keyPEM", "captive portal %s has key without certificate",
+				portal.LogicalLabel)
+		}
+	}
+	for i, syslogSrv := range eps.SyslogServers {
+		path := fmt.Sprintf("endpoints.syslogServers[%d]", i)
+		v.validateEndpoint(syslogSrv.Endpoint, path)
+		v.validateSyslogServer(syslogSrv, path)
+	}
+}
+
+func (v *validator) validateSyslogServer(syslogSrv api.SyslogServer, path string) {
+	label := syslogSrv.LogicalLabel
+	if syslogSrv.UDPPort == 0 && syslogSrv.TCPPort == 0 && syslogSrv.TLSPort == 0 {
+		v.errorf(path, "syslog server %s without port numbers", label)
+	}
+	if syslogSrv.TCPPort != 0 && syslogSrv.TCPPort == syslogSrv.TLSPort {
+		v.errorf(path+".tlsPort", "syslog server %s with colliding TCP ports", label)
+	}
+	if syslogSrv.CertPEM != "" {
+		if err := validateCertPEM(syslogSrv.CertPEM, syslogSrv.KeyPEM, false); err != nil {
+			v.errorf(path+".certPEM", "syslog server %s: %v", label, err)
+		}
+	} else if syslogSrv.KeyPEM != "" {
+		v.errorf(path+".keyPEM", "syslog server %s has key without certificate", label)
+	}
+}
+
+func (v *validator) validateWireGuardServer(wgSrv api.WireGuardServer, path string) {
+	label := wgSrv.LogicalLabel
+	if err := validateWireGuardKey(wgSrv.PrivateKey); err != nil {
+		v.errorf(path+".privateKey", "WireGuard server %s has invalid private key: %v",
+			label, err)
+	}
+	_, tunnelSubnet, err := net.ParseCIDR(wgSrv.TunnelIP)
+	if err != nil {
+		v.errorf(path+".tunnelIP", "WireGuard server %s has invalid tunnel IP (%s): %v",
+			label, wgSrv.TunnelIP, err)
+	}
+	if len(wgSrv.Peers) == 0 {
+		v.warnf(path+".peers", "WireGuard server %s has no peers", label)
+	}
+	publicKeys := make(map[string]struct{})
+	for i, peer := range wgSrv.Peers {
+		peerPath := fmt.Sprintf("%s.peers[%d]", path, i)
+		if err := validateWireGuardKey(peer.PublicKey); err != nil {
+			v.errorf(peerPath+".publicKey", "WireGuard server %s has peer with invalid "+
+				"public key: %v", label, err)
+		}
+		if _, duplicate := publicKeys[peer.PublicKey]; duplicate {
+			v.errorf(peerPath+".publicKey", "WireGuard server %s has duplicate peer %s",
+				label, peer.PublicKey)
+		}
+		publicKeys[peer.PublicKey] = struct{}{}
+		if peer.PresharedKey != "" {
+			if err := validateWireGuardKey(peer.PresharedKey); err != nil {
+				v.errorf(peerPath+".presharedKey", "WireGuard server %s has peer with "+
+					"invalid pre-shared key: %v", label, err)
+			}
+		}
+		if len(peer.AllowedIPs) == 0 {
+			v.warnf(peerPath+".allowedIPs", "WireGuard server %s has peer %s without "+
+				"allowed IPs", label, peer.PublicKey)
+		}
+		for j, allowedIP := range peer.AllowedIPs {
+			allowedIPPath := fmt.Sprintf("%s.allowedIPs[%d]", peerPath, j)
+			_, subnet, err := net.ParseCIDR(allowedIP)
+			if err != nil {
+				v.errorf(allowedIPPath,
+					"WireGuard server %s has peer with invalid allowed IP (%s): %v",
+					label, allowedIP, err)
+				continue
+			}
+			if ones, _ := subnet.Mask.Size(); ones == 0 {
+				// Route for allowed IPs would replace the default route of the endpoint.
+				v.errorf(allowedIPPath, "WireGuard server %s has peer with default route "+
+					"(%s) as allowed IP, which would override uplink of the endpoint",
+					label, allowedIP)
+			}
+		}
+	}
+	for i, privateSubnet := range wgSrv.PrivateSubnets {
+		subnetPath := fmt.Sprintf("%s.privateSubnets[%d]", path, i)
+		_, subnet, err := net.ParseCIDR(privateSubnet)
+		if err != nil {
+			v.errorf(subnetPath, "WireGuard server %s has invalid private subnet (%s): %v",
+				label, privateSubnet, err)
+			continue
+		}
+		if ones, bits := subnet.Mask.Size(); bits-ones < 2 {
+			v.errorf(subnetPath, "WireGuard server %s has private subnet %s without "+
+				"room for a host IP", label, privateSubnet)
+		}
+		if tunnelSubnet != nil && (subnet.Contains(tunnelSubnet.IP) ||
+			tunnelSubnet.Contains(subnet.IP)) {
+			v.errorf(subnetPath, "WireGuard server %s has private subnet %s overlapping "+
+				"with the tunnel subnet", label, privateSubnet)
+		}
+	}
+}
+
+// WireGuard keys are 32 bytes long, base64-encoded.
+func validateWireGuardKey(key string) error {
+	if key == "" {
+		return errors.New("key is empty")
+	}
+	keyBytes, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("key is not base64-encoded: %w", err)
+	}
+	if len(keyBytes) != 32 {
+		return fmt.Errorf("key has invalid length %d (expected 32 bytes)", len(keyBytes))
+	}
+	return nil
+}
+
+// Query types which can be matched by DNS fault rules.
+var dnsQueryTypes = map[string]struct{}{
+	"A": {}, "NS": {}, "CNAME": {}, "SOA": {}, "PTR": {}, "MX": {},
+	"TXT": {}, "AAAA": {}, "SRV": {}, "ANY": {},
+}
+
+func (v *validator) validateDNSFaultRule(label string, rule api.DNSFaultRule, path string) {
+	name := strings.TrimPrefix(rule.Name, "*.")
+	if rule.Name == "" {
+		v.errorf(path+".name", "DNS server %s has fault rule with empty name", label)
+	} else if rule.Name != "*" && strings.Contains(name, "*") {
+		v.errorf(path+".name", "DNS server %s has fault rule with invalid wildcard "+
+			"name (%s), only \"*\" or \"*.\" prefix is supported", label, rule.Name)
+	}
+	for i, queryType := range rule.QueryTypes {
+		if _, valid := dnsQueryTypes[strings.ToUpper(queryType)]; !valid {
+			v.errorf(fmt.Sprintf("%s.queryTypes[%d]", path, i),
+				"DNS server %s has fault rule with unsupported query type (%s)",
+				label, queryType)
+		}
+	}
+	switch rule.Action {
+	case api.DNSFaultNXDomain, api.DNSFaultServFail, api.DNSFaultTruncate:
+	case api.DNSFaultDrop:
+		if rule.Delay != 0 {
+			v.warnf(path+".delay", "DNS server %s has fault rule with delay "+
+				"which is ignored for action %s", label, rule.Action)
+		}
+	case api.DNSFaultDelay:
+		if rule.Delay == 0 {
+			v.errorf(path+".delay", "DNS server %s has fault rule with action %s "+
+				"but without delay", label, rule.Action)
+		}
+	case api.DNSFaultRotateIPs:
+		if len(rule.RotateIPs) == 0 {
+			v.errorf(path+".rotateIPs", "DNS server %s has fault rule with action %s "+
+				"but without IPs to rotate", label, rule.Action)
+		}
+		for i, ip := range rule.RotateIPs {
+			if strings.HasPrefix(ip, api.EndpointIPRefPrefix) || ip == api.AdamIPRef {
+				continue
+			}
+			if net.ParseIP(ip) == nil {
+				v.errorf(fmt.Sprintf("%s.rotateIPs[%d]", path, i),
+					"DNS server %s has fault rule with invalid IP (%s)", label, ip)
+			}
+		}
+	default:
+		v.errorf(path+".action", "DNS server %s has fault rule with unknown action (%s)",
+			label, rule.Action)
+	}
+}
+
+func (v *validator) validateProxy(label string, proxy api.Proxy, path string) {
+	for i, dns := range proxy.PublicDNS {
+		if dnsIP := net.ParseIP(dns); dnsIP == nil {
+			v.errorf(fmt.Sprintf("%s.publicDNS[%d]", path, i),
+				"proxy %s has invalid public DNS server IP (%s)", label, dns)
+		}
+	}
+	if proxy.CACertPEM != "" {
+		if err := validateCertPEM(proxy.CACertPEM, proxy.CAKeyPEM, true); err != nil {
+			v.errorf(path+".caCertPEM", "proxy %s: %v", label, err)
+		}
+	}
+	ruleHosts := make(map[string]struct{})
+	for i, rule := range proxy.ProxyRules {
+		if _, duplicate := ruleHosts[rule.ReqHost]; duplicate {
+			v.errorf(fmt.Sprintf("%s.proxyRules[%d]", path, i),
+				"proxy %s has duplicate rules", label)
+		}
+		ruleHosts[rule.ReqHost] = struct{}{}
+	}
+}
+
+func (v *validator) validateEndpoint(endpoint api.Endpoint, path string) {
+	// Validate Subnet.
+	if endpoint.Subnet != "" {
+		_, subnet, err := net.ParseCIDR(endpoint.Subnet)
+		if err != nil {
+			v.errorf(path+".subnet", "endpoint %s with invalid subnet '%s': %v",
+				endpoint.LogicalLabel, endpoint.Subnet, err)
+		} else {
+			ones, bits := subnet.Mask.Size()
+			if bits-ones < 2 {
+				v.errorf(path+".subnet", "endpoint %s uses subnet with less than 2 host IPs (%s)",
+					endpoint.LogicalLabel, endpoint.Subnet)
+			}
+			// Validate IP address.
+			if endpoint.IP != "" {
+				ip := net.ParseIP(endpoint.IP)
+				if ip == nil {
+					v.errorf(path+".ip", "endpoint %s with invalid IP address (%s)",
+						endpoint.LogicalLabel, endpoint.IP)
+				} else if !subnet.Contains(ip) {
+					v.errorf(path+".ip", "endpoint %s has IP (%s) address outside of the configured "+
+						"subnet (%s)", endpoint.LogicalLabel, endpoint.IP, endpoint.Subnet)
+				}
+			}
+		}
+	}
+	// Validate MTU settings.
+	if endpoint.MTU != 0 && endpoint.MTU < MinMTU {
+		v.errorf(path+".mtu", "MTU %d configured for endpoint %s is too small",
+			endpoint.MTU, endpoint.LogicalLabel)
+	}
+	if endpoint.MTU > MaxMTU {
+		v.errorf(path+".mtu", "MTU %d configured for endpoint %s is too large",
+			endpoint.MTU, endpoint.LogicalLabel)
+	}
+}
+
+func (v *validator) validateFirewall() {
+	var statelessRule bool
+	for i, rule := range v.model.Firewall.Rules {
+		path := fmt.Sprintf("firewall.rules[%d]", i)
+		if len(rule.ConnState) == 0 {
+			statelessRule = true
+		} else if statelessRule {
+			// Established and related traffic is already allowed at this point.
+			v.warnf(path+".connState", "firewall rule matching connection state "+
+				"follows a rule without connection state and will not match "+
+				"established or related traffic")
+		}
+		if rule.SrcSubnet != "" {
+			if _, _, err := net.ParseCIDR(rule.SrcSubnet); err != nil {
+				v.errorf(path+".srcSubnet", "firewall rule with invalid subnet '%s': %v",
+					rule.SrcSubnet, err)
+			}
+		}
+		if rule.DstSubnet != "" {
+			if _, _, err := net.ParseCIDR(rule.DstSubnet); err != nil {
+				v.errorf(path+".dstSubnet", "firewall rule with invalid subnet '%s': %v",
+					rule.DstSubnet, err)
+			}
+		}
+		if len(rule.Ports) > 0 {
+			if rule.Protocol != api.TCP && rule.Protocol != api.UDP {
+				v.errorf(path+".ports", "firewall rule with non-empty set of ports (%v) "+
+					"but protocol is neither TCP nor UDP (%v)", rule.Ports, rule.Protocol)
+			}
+		}
+		for j, state := range rule.ConnState {
+			switch state {
+			case api.FwConnNew, api.FwConnEstablished, api.FwConnRelated, api.FwConnInvalid:
+			default:
+				v.errorf(fmt.Sprintf("%s.connState[%d]", path, j),
+					"firewall rule with invalid connection state '%s'", state)
+			}
+		}
+		if rule.RateLimit != nil {
+			if rule.RateLimit.Rate == 0 {
+				v.errorf(path+".rateLimit.rate", "firewall rule with zero rate limit")
+			}
+			switch rule.RateLimit.Unit {
+			case "second", "minute", "hour", "day":
+			default:
+				v.errorf(path+".rateLimit.unit", "firewall rule with invalid rate unit '%s' "+
+					"(expected second, minute, hour or day)", rule.RateLimit.Unit)
+			}
+		}
+		if rule.ConnLimit != nil && rule.ConnLimit.MaxConns == 0 {
+			v.errorf(path+".connLimit.maxConns", "firewall rule with zero connection limit")
+		}
+		if rule.Schedule != nil {
+			v.validateFwSchedule(path+".schedule", *rule.Schedule)
+		}
+		if rule.Action == api.FwRejectWithTCPReset && rule.Protocol != api.TCP {
+			v.errorf(path+".action", "firewall rule can reject with TCP reset only "+
+				"TCP traffic (protocol is %v)", api.FwProtoToString[rule.Protocol])
+		}
+	}
+}
+
+var fwScheduleTimeRegex = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9](:[0-5][0-9])?$`)
+
+func (v *validator) validateFwSchedule(path string, schedule api.FwSchedule) {
+	times := []struct{ attr, value string }{
+		{attr: "timeStart", value: schedule.TimeStart},
+		{attr: "timeStop", value: schedule.TimeStop},
+	}
+	for _, t := range times {
+		if t.value != "" && !fwScheduleTimeRegex.MatchString(t.value) {
+			v.errorf(path+"."+t.attr, "firewall schedule with invalid time '%s' "+
+				"(expected hh:mm[:ss])", t.value)
+		}
+	}
+	dates := []struct{ attr, value string }{
+		{attr: "dateStart", value: schedule.DateStart},
+		{attr: "dateStop", value: schedule.DateStop},
+	}
+	for _, d := range dates {
+		if d.value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, d.value); err != nil {
+			v.errorf(path+"."+d.attr, "firewall schedule with invalid date '%s': %v",
+				d.value, err)
+		}
+	}
+	for i, weekday := range schedule.Weekdays {
+		switch weekday {
+		case "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun":
+		default:
+			v.errorf(fmt.Sprintf("%s.weekdays[%d]", path, i),
+				"firewall schedule with invalid weekday '%s' (expected Mon, Tue, ..., Sun)",
+				weekday)
+		}
+	}
+}
+
+func (v *validator) validateHostConfig() {
+	// Eden SDN requires at least one routable host IP address.
+	if v.model.Host == nil {
+		v.errorf("host", "missing host configuration")
+		return
+	}
+	for i, hostIP := range v.model.Host.HostIPs {
+		ip := net.ParseIP(hostIP)
+		if ip == nil {
+			v.errorf(fmt.Sprintf("host.hostIPs[%d]", i), "failed to parse host IP address %s",
+				hostIP)
+			continue
+		}
+		if ip.IsGlobalUnicast() && v.model.HostIP == nil {
+			v.model.HostIP = ip
+		}
+	}
+	if v.model.HostIP == nil {
+		v.errorf("host.hostIPs", "eden SDN requires at least one routable host IP address")
+	}
+	if v.model.Host.ControllerPort == 0 {
+		v.errorf("host.controllerPort", "missing controller port")
+	}
+}
@@ -0,0 +1,620 @@
+package netmodel_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/netmodel"
+)
+
+// validModel returns a minimal network model which passes validation
+// without errors and warnings: one port, one bridge and two VLAN networks
+// with routers.
+func validModel() api.NetworkModel {
+	return api.NetworkModel{
+		Ports: []api.Port{
+			{
+				LogicalLabel: "eth0",
+				MAC:          "02:fd:00:00:00:01",
+				AdminUP:      true,
+				EVEConnect:   api.EVEConnect{MAC: "02:fe:00:00:00:01"},
+			},
+		},
+		Bridges: []api.Bridge{
+			{LogicalLabel: "bridge0", Ports: []string{"eth0"}},
+		},
+		Networks: []api.Network{
+			{
+				LogicalLabel: "network0",
+				Bridge:       "bridge0",
+				VlanID:       10,
+				Subnet:       "172.22.1.0/24",
+				GwIP:         "172.22.1.1",
+				DHCP: api.DHCP{
+					Enable: true,
+					IPRange: api.IPRange{
+						FromIP: "172.22.1.10",
+						ToIP:   "172.22.1.20",
+					},
+				},
+				Router: &api.Router{OutsideReachability: true},
+			},
+			{
+				LogicalLabel: "network1",
+				Bridge:       "bridge0",
+				VlanID:       20,
+				Subnet:       "172.22.2.0/24",
+				GwIP:         "172.22.2.1",
+				DHCP:         api.DHCP{Enable: true},
+				Router:       &api.Router{OutsideReachability: true},
+			},
+		},
+		Host: &api.HostConfig{
+			HostIPs:        []string{"192.168.0.10"},
+			ControllerPort: 3333,
+		},
+	}
+}
+
+func withNAT(outsideSubnet string) *api.NAT {
+	return &api.NAT{
+		Type:          api.NATPortRestrictedCone,
+		OutsideSubnet: outsideSubnet,
+	}
+}
+
+// wgKey returns valid WireGuard key with all bytes set to b.
+func wgKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+// withWireGuard adds WireGuard server without issues into the model.
+func withWireGuard(m *api.NetworkModel) *api.WireGuardServer {
+	m.Endpoints.WireGuardServers = []api.WireGuardServer{
+		{
+			Endpoint: api.Endpoint{
+				LogicalLabel: "vpn0",
+				Subnet:       "10.10.10.0/24",
+				IP:           "10.10.10.10",
+			},
+			PrivateKey: wgKey(1),
+			TunnelIP:   "10.200.0.1/24",
+			Peers: []api.WireGuardPeer{
+				{PublicKey: wgKey(2), AllowedIPs: []string{"10.200.0.2/32"}},
+			},
+			PrivateSubnets: []string{"10.201.0.0/24"},
+		},
+	}
+	m.Networks[0].Router.ReachableEndpoints = []string{"vpn0"}
+	return &m.Endpoints.WireGuardServers[0]
+}
+
+func issuePaths(issues []netmodel.Issue) []string {
+	var paths []string
+	for _, issue := range issues {
+		paths = append(paths, issue.Path)
+	}
+	return paths
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		modify       func(m *api.NetworkModel)
+		wantErrors   []string // expected JSON paths of errors, in order
+		wantWarnings []string // expected JSON paths of warnings, in order
+		wantMessage  string   // substring of the first error/warning message
+	}{
+		{
+			name:   "valid",
+			modify: func(m *api.NetworkModel) {},
+		},
+		{
+			name: "duplicate port MAC",
+			modify: func(m *api.NetworkModel) {
+				m.Ports = append(m.Ports, api.Port{
+					LogicalLabel: "eth1",
+					MAC:          m.Ports[0].MAC,
+					EVEConnect:   api.EVEConnect{MAC: "02:fe:00:00:00:02"},
+				})
+				m.Bridges[0].Ports = append(m.Bridges[0].Ports, "eth1")
+			},
+			wantErrors:  []string{"ports[1].mac"},
+			wantMessage: "duplicate MAC address",
+		},
+		{
+			name: "port MAC with host prefix",
+			modify: func(m *api.NetworkModel) {
+				m.Ports[0].MAC = "08:33:33:00:00:01"
+			},
+			wantErrors:  []string{"ports[0].mac"},
+			wantMessage: "reserved for the host port",
+		},
+		{
+			name: "duplicate logical label",
+			modify: func(m *api.NetworkModel) {
+				m.Bridges = append(m.Bridges, api.Bridge{LogicalLabel: "bridge0"})
+			},
+			wantErrors:  []string{"bridges[1]"},
+			wantMessage: "duplicate logical label: bridge/bridge0",
+		},
+		{
+			name: "dangling reference",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[1].Bridge = "bridge1"
+			},
+			wantErrors:  []string{"networks[1]"},
+			wantMessage: "referenced item bridge/bridge1 does not exist",
+		},
+		{
+			name: "missing host config",
+			modify: func(m *api.NetworkModel) {
+				m.Host = nil
+			},
+			wantErrors:  []string{"host"},
+			wantMessage: "missing host configuration",
+		},
+		{
+			name: "host without routable IP",
+			modify: func(m *api.NetworkModel) {
+				m.Host.HostIPs = []string{"127.0.0.1", "not-an-ip"}
+			},
+			wantErrors:  []string{"host.hostIPs[1]", "host.hostIPs"},
+			wantMessage: "failed to parse host IP address",
+		},
+		{
+			name: "invalid subnet and gateway",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Subnet = "172.22.1.0/33"
+				m.Networks[0].GwIP = "172.22.1"
+			},
+			wantErrors:  []string{"networks[0].subnet", "networks[0].gwIP"},
+			wantMessage: "network network0 has invalid subnet",
+		},
+		{
+			name: "DHCP range outside of subnet",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].DHCP.IPRange.ToIP = "172.22.2.20"
+			},
+			wantErrors:  []string{"networks[0].dhcp.ipRange"},
+			wantMessage: "DHCP IP range outside of the subnet",
+		},
+		{
+			name: "gateway inside of DHCP range",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].GwIP = "172.22.1.15"
+			},
+			wantWarnings: []string{"networks[0].gwIP"},
+			wantMessage:  "gateway IP (172.22.1.15) of network network0 is inside of the DHCP IP range",
+		},
+		{
+			name: "DHCP static entry inside of DHCP range",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].DHCP.StaticEntries = []api.MACToIP{
+					{MAC: "02:fe:00:00:00:01", IP: "172.22.1.10"},
+				}
+			},
+			wantWarnings: []string{"networks[0].dhcp.staticEntries[0].ip"},
+			wantMessage:  "static DHCP entry IP (172.22.1.10) of network network0 is inside of the DHCP IP range",
+		},
+		{
+			name: "DHCP static entry outside of subnet",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].DHCP.StaticEntries = []api.MACToIP{
+					{MAC: "02:fe:00:00:00:01", IP: "10.0.0.1"},
+				}
+			},
+			wantWarnings: []string{"networks[0].dhcp.staticEntries[0].ip"},
+			wantMessage:  "outside of the subnet",
+		},
+		{
+			name: "MTU too small",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[1].MTU = netmodel.MinMTU - 1
+			},
+			wantErrors:  []string{"networks[1].mtu"},
+			wantMessage: "is too small",
+		},
+		{
+			name: "VLAN and non-VLAN networks on the same bridge",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[1].VlanID = 0
+			},
+			wantErrors:  []string{"bridges[0]"},
+			wantMessage: "both VLAN and non-VLAN",
+		},
+		{
+			name: "unused port and bridge",
+			modify: func(m *api.NetworkModel) {
+				m.Ports = append(m.Ports, api.Port{
+					LogicalLabel: "eth1",
+					MAC:          "02:fd:00:00:00:02",
+					EVEConnect:   api.EVEConnect{MAC: "02:fe:00:00:00:02"},
+				})
+				m.Bridges = append(m.Bridges, api.Bridge{LogicalLabel: "bridge1"})
+			},
+			wantWarnings: []string{"ports[1]", "bridges[1]"},
+			wantMessage:  "port eth1 is not used by any bridge or bond",
+		},
+		{
+			name: "overlapping network subnets",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[1].Subnet = "172.22.0.0/16"
+				m.Networks[1].GwIP = "172.22.0.1"
+			},
+			wantWarnings: []string{"networks[1].subnet"},
+			wantMessage: "subnet of network network1 (172.22.0.0/16) overlaps " +
+				"with subnet of network network0 (172.22.1.0/24)",
+		},
+		{
+			name: "endpoint subnet overlapping with network",
+			modify: func(m *api.NetworkModel) {
+				m.Endpoints.Clients = []api.Client{
+					{Endpoint: api.Endpoint{
+						LogicalLabel: "client0",
+						Subnet:       "172.22.1.128/25",
+						IP:           "172.22.1.130",
+					}},
+				}
+			},
+			wantWarnings: []string{"endpoints.clients[0].subnet"},
+			wantMessage:  "subnet of endpoint client0",
+		},
+		{
+			name: "unreachable endpoint",
+			modify: func(m *api.NetworkModel) {
+				m.Endpoints.NTPServers = []api.NTPServer{
+					{Endpoint: api.Endpoint{
+						LogicalLabel: "ntp0",
+						Subnet:       "10.10.10.0/24",
+						IP:           "10.10.10.10",
+					}},
+				}
+			},
+			wantWarnings: []string{"endpoints.ntpServers[0]"},
+			wantMessage:  "endpoint ntp0 is not reachable from any network",
+		},
+		{
+			name: "endpoint IP outside of its subnet",
+			modify: func(m *api.NetworkModel) {
+				m.Endpoints.Clients = []api.Client{
+					{Endpoint: api.Endpoint{
+						LogicalLabel: "client0",
+						Subnet:       "10.10.10.0/24",
+						IP:           "10.10.11.10",
+					}},
+				}
+			},
+			wantErrors:  []string{"endpoints.clients[0].ip"},
+			wantMessage: "outside of the configured subnet",
+		},
+		{
+			name: "valid NAT",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("100.64.1.0/24")
+				m.Networks[1].Router.NAT = withNAT("100.64.2.0/24")
+				m.Networks[1].Router.NAT.Via = "network0"
+			},
+		},
+		{
+			name: "invalid NAT type",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("100.64.1.0/24")
+				m.Networks[0].Router.NAT.Type = "cone"
+			},
+			wantErrors:  []string{"networks[0].router.nat.type"},
+			wantMessage: "invalid NAT type (cone)",
+		},
+		{
+			name: "full-cone NAT with port randomization and different subnet size",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("100.64.1.0/28")
+				m.Networks[0].Router.NAT.Type = api.NATFullCone
+				m.Networks[0].Router.NAT.RandomizePorts = true
+			},
+			wantErrors: []string{"networks[0].router.nat.randomizePorts",
+				"networks[0].router.nat.outsideSubnet"},
+			wantMessage: "cannot randomize ports",
+		},
+		{
+			name: "NAT outside subnet overlapping with own network",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("172.22.1.0/28")
+			},
+			wantErrors: []string{"networks[0].router.nat.outsideSubnet"},
+			wantMessage: "network network0 has NAT outside subnet (172.22.1.0/28) " +
+				"overlapping with the subnet of network network0",
+		},
+		{
+			name: "NAT outside subnet overlapping with another network",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("172.22.0.0/16")
+			},
+			wantErrors: []string{"networks[0].router.nat.outsideSubnet",
+				"networks[0].router.nat.outsideSubnet"},
+			wantMessage: "overlapping with the subnet of network network0",
+		},
+		{
+			name: "NAT outside subnet overlapping with endpoint",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("10.10.10.0/24")
+				m.Networks[0].Router.ReachableEndpoints = []string{"client0"}
+				m.Endpoints.Clients = []api.Client{
+					{Endpoint: api.Endpoint{
+						LogicalLabel: "client0",
+						Subnet:       "10.10.10.0/24",
+						IP:           "10.10.10.10",
+					}},
+				}
+			},
+			wantErrors:  []string{"networks[0].router.nat.outsideSubnet"},
+			wantMessage: "overlapping with the subnet of endpoint client0",
+		},
+		{
+			name: "NAT outside subnets overlapping with each other",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("100.64.0.0/16")
+				m.Networks[1].Router.NAT = withNAT("100.64.2.0/24")
+			},
+			wantErrors: []string{"networks[1].router.nat.outsideSubnet"},
+			wantMessage: "network network1 has NAT outside subnet (100.64.2.0/24) " +
+				"overlapping with the NAT outside subnet of network network0",
+		},
+		{
+			name: "NAT port forwarding to IP outside of subnet",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("100.64.1.0/24")
+				m.Networks[0].Router.NAT.PortForwarding = []api.NATPortForward{
+					{Protocol: "sctp", OutsidePort: 80, InsideIP: "172.22.2.10"},
+				}
+			},
+			wantErrors: []string{"networks[0].router.nat.portForwarding[0].protocol",
+				"networks[0].router.nat.portForwarding[0].insideIP"},
+			wantMessage: "invalid protocol (sctp)",
+		},
+		{
+			name: "NAT for IPv6 network",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[1].Subnet = "2001:db8::/64"
+				m.Networks[1].GwIP = "2001:db8::1"
+				m.Networks[1].Router.NAT = withNAT("100.64.1.0/24")
+			},
+			wantErrors:  []string{"networks[1].router.nat"},
+			wantMessage: "NAT is not supported for IPv6 network network1",
+		},
+		{
+			name: "NAT chained through itself",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("100.64.1.0/24")
+				m.Networks[0].Router.NAT.Via = "network0"
+			},
+			wantErrors:  []string{"networks[0].router.nat.via"},
+			wantMessage: "network network0 cannot chain NAT through itself",
+		},
+		{
+			name: "NAT chained through network without NAT",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[1].Router.NAT = withNAT("100.64.2.0/24")
+				m.Networks[1].Router.NAT.Via = "network0"
+			},
+			wantErrors:  []string{"networks[1].router.nat.via"},
+			wantMessage: "chains NAT through network network0 without NAT",
+		},
+		{
+			name: "NAT chained through full-cone NAT",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("100.64.1.0/24")
+				m.Networks[0].Router.NAT.Type = api.NATFullCone
+				m.Networks[1].Router.NAT = withNAT("100.64.2.0/24")
+				m.Networks[1].Router.NAT.Via = "network0"
+			},
+			wantErrors:  []string{"networks[1].router.nat.via"},
+			wantMessage: "with full-cone NAT, which is not supported",
+		},
+		{
+			name: "NAT chained through unknown network",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[1].Router.NAT = withNAT("100.64.2.0/24")
+				m.Networks[1].Router.NAT.Via = "network2"
+			},
+			wantErrors:  []string{"networks[1]"},
+			wantMessage: "referenced item network/network2 does not exist",
+		},
+		{
+			name: "NAT chained in a loop",
+			modify: func(m *api.NetworkModel) {
+				m.Networks[0].Router.NAT = withNAT("100.64.1.0/24")
+				m.Networks[0].Router.NAT.Via = "network1"
+				m.Networks[1].Router.NAT = withNAT("100.64.2.0/24")
+				m.Networks[1].Router.NAT.Via = "network0"
+			},
+			wantErrors:  []string{"networks[0].router.nat.via", "networks[1].router.nat.via"},
+			wantMessage: "network network0 has NAT chained in a loop",
+		},
+		{
+			name: "NAT chained in a longer loop",
+			modify: func(m *api.NetworkModel) {
+				m.Networks = append(m.Networks, api.Network{
+					LogicalLabel: "network2",
+					Bridge:       "bridge0",
+					VlanID:       30,
+					Subnet:       "172.22.3.0/24",
+					GwIP:         "172.22.3.1",
+					Router: &api.Router{
+						OutsideReachability: true,
+						NAT:                 withNAT("100.64.3.0/24"),
+					},
+				})
+				m.Networks[0].Router.NAT = withNAT("100.64.1.0/24")
+				m.Networks[0].Router.NAT.Via = "network1"
+				m.Networks[1].Router.NAT = withNAT("100.64.2.0/24")
+				m.Networks[1].Router.NAT.Via = "network2"
+				m.Networks[2].Router.NAT.Via = "network0"
+			},
+			wantErrors: []string{"networks[0].router.nat.via",
+				"networks[1].router.nat.via", "networks[2].router.nat.via"},
+			wantMessage: "has NAT chained in a loop",
+		},
+		{
+			name: "invalid firewall rule",
+			modify: func(m *api.NetworkModel) {
+				m.Firewall.Rules = []api.FwRule{
+					{
+						SrcSubnet: "10.0.0.0/8",
+						Protocol:  api.ICMP,
+						Ports:     []uint16{80},
+						ConnState: []api.FwConnState{api.FwConnEstablished, "closed"},
+						Action:    api.FwAllow,
+					},
+				}
+			},
+			wantErrors: []string{"firewall.rules[0].ports",
+				"firewall.rules[0].connState[1]"},
+			wantMessage: "protocol is neither TCP nor UDP",
+		},
+		{
+			name: "stateful firewall rule after stateless rule",
+			modify: func(m *api.NetworkModel) {
+				m.Firewall.Rules = []api.FwRule{
+					{
+						DstSubnet: "10.0.0.0/8",
+						ConnState: []api.FwConnState{api.FwConnInvalid},
+						Action:    api.FwDrop,
+					},
+					{
+						DstSubnet: "10.1.0.0/16",
+						Action:    api.FwReject,
+					},
+					{
+						DstSubnet: "10.2.0.0/16",
+						ConnState: []api.FwConnState{api.FwConnEstablished},
+						Action:    api.FwDrop,
+					},
+				}
+			},
+			wantWarnings: []string{"firewall.rules[2].connState"},
+			wantMessage:  "will not match established or related traffic",
+		},
+		{
+			name: "valid WireGuard server",
+			modify: func(m *api.NetworkModel) {
+				withWireGuard(m)
+			},
+		},
+		{
+			name: "WireGuard private key not base64",
+			modify: func(m *api.NetworkModel) {
+				withWireGuard(m).PrivateKey = "not a key!"
+			},
+			wantErrors:  []string{"endpoints.wireguardServers[0].privateKey"},
+			wantMessage: "key is not base64-encoded",
+		},
+		{
+			name: "WireGuard key with invalid length",
+			modify: func(m *api.NetworkModel) {
+				wg := withWireGuard(m)
+				wg.Peers[0].PublicKey = base64.StdEncoding.EncodeToString([]byte("short"))
+				wg.Peers[0].PresharedKey = wgKey(3)
+			},
+			wantErrors:  []string{"endpoints.wireguardServers[0].peers[0].publicKey"},
+			wantMessage: "key has invalid length 5",
+		},
+		{
+			name: "WireGuard peers with duplicate key and invalid PSK",
+			modify: func(m *api.NetworkModel) {
+				wg := withWireGuard(m)
+				wg.Peers = append(wg.Peers, api.WireGuardPeer{
+					PublicKey:    wg.Peers[0].PublicKey,
+					PresharedKey: "AAAA",
+					AllowedIPs:   []string{"10.200.0.3/32"},
+				})
+			},
+			wantErrors: []string{"endpoints.wireguardServers[0].peers[1].publicKey",
+				"endpoints.wireguardServers[0].peers[1].presharedKey"},
+			wantMessage: "has duplicate peer",
+		},
+		{
+			name: "WireGuard peer with default route",
+			modify: func(m *api.NetworkModel) {
+				withWireGuard(m).Peers[0].AllowedIPs = []string{"10.200.0.2/32", "0.0.0.0/0"}
+			},
+			wantErrors:  []string{"endpoints.wireguardServers[0].peers[0].allowedIPs[1]"},
+			wantMessage: "default route (0.0.0.0/0)",
+		},
+		{
+			name: "WireGuard private subnet with single address",
+			modify: func(m *api.NetworkModel) {
+				withWireGuard(m).PrivateSubnets = []string{"10.201.0.1/32"}
+			},
+			wantErrors:  []string{"endpoints.wireguardServers[0].privateSubnets[0]"},
+			wantMessage: "without room for a host IP",
+		},
+		{
+			name: "WireGuard private subnet overlapping with tunnel",
+			modify: func(m *api.NetworkModel) {
+				withWireGuard(m).PrivateSubnets = []string{"10.200.0.0/16"}
+			},
+			wantErrors:  []string{"endpoints.wireguardServers[0].privateSubnets[0]"},
+			wantMessage: "overlapping with the tunnel subnet",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			model := validModel()
+			test.modify(&model)
+			_, report := netmodel.Validate(model)
+			if paths := issuePaths(report.Errors); !reflect.DeepEqual(paths, test.wantErrors) {
+				t.Errorf("error paths = %v, want %v (errors: %v)",
+					paths, test.wantErrors, report.Errors)
+			}
+			if paths := issuePaths(report.Warnings); !reflect.DeepEqual(paths, test.wantWarnings) {
+				t.Errorf("warning paths = %v, want %v (warnings: %v)",
+					paths, test.wantWarnings, report.Warnings)
+			}
+			if test.wantMessage == "" {
+				return
+			}
+			issues := append(report.Errors, report.Warnings...)
+			if len(issues) == 0 {
+				return // already reported above
+			}
+			if !strings.Contains(issues[0].Message, test.wantMessage) {
+				t.Errorf("message %q does not contain %q", issues[0].Message, test.wantMessage)
+			}
+		})
+	}
+}
+
+func TestParseCombinesErrors(t *testing.T) {
+	t.Parallel()
+
+	model := validModel()
+	model.Host = nil
+	model.Networks[0].GwIP = "172.22.1"
+	parsed, err := netmodel.Parse(model)
+	if err == nil {
+		t.Fatal("expected error for invalid model")
+	}
+	const want = "host: missing host configuration; " +
+		"networks[0].gwIP: network network0 has invalid gateway IP (172.22.1)"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+	if item := parsed.Items.GetItem(api.Network{}.ItemType(), "network1"); item == nil ||
+		item.Path != "networks[1]" {
+		t.Errorf("network1 not parsed as networks[1]: %+v", item)
+	}
+
+	if _, err = netmodel.Parse(validModel()); err != nil {
+		t.Errorf("unexpected error for valid model: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Package servercert provides TLS certificates for servers running inside
+// Eden-SDN endpoints (captiveportal, syslogsrv). Servers use the certificate
+// configured in the network model or, if there is none, a generated self-signed one.
+package servercert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math"
+	"math/big"
+	"net"
+	"time"
+)
+
+// GetCertificate returns certificate parsed from certPEM and keyPEM or, if certPEM
+// is empty, generates a self-signed certificate for the given host name and IP.
+// Host name is used as DNS name only if it differs from the IP address.
+func GetCertificate(certPEM, keyPEM, host string, ip net.IP) (*tls.Certificate, error) {
+	if certPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, err
+		}
+		return &cert, nil
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"LF Edge"},
+			CommonName:   host,
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IPAddresses:           []net.IP{ip},
+	}
+	if host != "" && host != ip.String() {
+		template.DNSNames = []string{host}
+	}
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template,
+		privKey.Public(), privKey)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{
+		Certificate: [][]byte{derBytes},
+		PrivateKey:  privKey,
+	}, nil
+}
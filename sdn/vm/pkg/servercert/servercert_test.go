@@ -0,0 +1,66 @@
+package servercert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestGetCertificateSelfSigned(t *testing.T) {
+	t.Parallel()
+
+	ip := net.ParseIP("10.20.30.1")
+	tests := []struct {
+		host     string
+		dnsNames []string
+	}{
+		{host: "portal.sdn", dnsNames: []string{"portal.sdn"}},
+		{host: "10.20.30.1"},
+		{host: ""},
+	}
+	for _, test := range tests {
+		cert, err := GetCertificate("", "", test.host, ip)
+		if err != nil {
+			t.Fatalf("host %q: %v", test.host, err)
+		}
+		x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("host %q: %v", test.host, err)
+		}
+		if !reflect.DeepEqual(x509Cert.DNSNames, test.dnsNames) {
+			t.Errorf("host %q: DNS names = %v, expected %v",
+				test.host, x509Cert.DNSNames, test.dnsNames)
+		}
+		if len(x509Cert.IPAddresses) != 1 || !x509Cert.IPAddresses[0].Equal(ip) {
+			t.Errorf("host %q: IP addresses = %v", test.host, x509Cert.IPAddresses)
+		}
+	}
+}
+
+func TestGetCertificateConfigured(t *testing.T) {
+	t.Parallel()
+
+	ip := net.ParseIP("10.20.30.1")
+	generated, err := GetCertificate("", "", "syslog.sdn", ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(generated.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: generated.Certificate[0]})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	cert, err := GetCertificate(string(certPEM), string(keyPEM), "other.sdn", ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cert.Certificate, generated.Certificate) {
+		t.Error("configured certificate was not used")
+	}
+	if _, err = GetCertificate(string(certPEM), "invalid", "other.sdn", ip); err == nil {
+		t.Error("expected error for invalid private key")
+	}
+}
@@ -6,7 +6,8 @@
 
   echo "Starting Eden SDN mgmt agent..."
   while true; do
-    sdnagent -debug
+    # SDN_AGENT_ARGS is set when SDN runs as a container instead of VM.
+    sdnagent -debug ${SDN_AGENT_ARGS}
     echo "Restarting Eden SDN mgmt Agent!!!"
   done
 
@@ -16,6 +16,7 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/einfo"
 	"github.com/lf-edge/eden/pkg/controller/elog"
 	"github.com/lf-edge/eden/pkg/controller/emetric"
+	equery "github.com/lf-edge/eden/pkg/controller/query"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/projects"
@@ -51,6 +52,9 @@ var (
 	query = map[string]string{}
 	found bool
 	items int
+
+	// expressions parsed once from query, metricExpr points dm fields into metric content
+	queryExpr, metricExpr equery.Expr
 )
 
 func mkquery() error {
@@ -69,6 +73,13 @@ func mkquery() error {
 		}
 	}
 
+	var err error
+	if queryExpr, err = equery.FromMap(query); err != nil {
+		return fmt.Errorf("incorrect query: %w", err)
+	}
+	if metricExpr, err = emetric.MetricQuery(query); err != nil {
+		return fmt.Errorf("incorrect query: %w", err)
+	}
 	return nil
 }
 
@@ -167,7 +178,7 @@ func TestLog(t *testing.T) {
 			log *elog.FullLogEntry) error {
 			name := edgeNode.GetID()
 			if query != nil {
-				if elog.LogItemFind(log, query) {
+				if queryExpr.Match(log) {
 					found = true
 				} else {
 					return nil
@@ -237,7 +248,7 @@ func TestAppLog(t *testing.T) {
 			log *logs.LogEntry) error {
 			name := edgeNode.GetID()
 			if query != nil {
-				if eapps.LogItemFind(log, query) {
+				if queryExpr.Match(log) {
 					found = true
 				} else {
 					return nil
@@ -278,7 +289,7 @@ func TestInfo(t *testing.T) {
 			ei *info.ZInfoMsg) error {
 			name := edgeNode.GetID()
 			if query != nil {
-				if einfo.ZInfoFind(ei, query) {
+				if queryExpr.Match(ei) {
 					found = true
 				} else {
 					return nil
@@ -319,7 +330,7 @@ func TestMetrics(t *testing.T) {
 			mtr *metrics.ZMetricMsg) error {
 			name := edgeNode.GetID()
 			if query != nil {
-				if emetric.MetricItemFind(mtr, query) {
+				if metricExpr.Match(mtr) {
 					found = true
 				} else {
 					return nil
@@ -362,7 +373,7 @@ func TestFlowLog(t *testing.T) {
 			flowLog *flowlog.FlowMessage) error {
 			name := edgeNode.GetID()
 			if query != nil {
-				if eflowlog.FlowLogItemFind(flowLog, query) {
+				if queryExpr.Match(flowLog) {
 					found = true
 				} else {
 					return nil